@@ -0,0 +1,283 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/backend/cloud"
+	"github.com/pulumi/pulumi/pkg/backend/state"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+	"github.com/pulumi/pulumi/pkg/version"
+	"github.com/pulumi/pulumi/pkg/workspace"
+)
+
+// aboutRuntimeTimeout is how long to wait for a language runtime to report its version.
+const aboutRuntimeTimeout = 10 * time.Second
+
+// aboutInfo is everything that `pulumi about` reports.  Problems encountered while gathering it are collected in
+// Errors rather than stopping the report, since a report is most useful when something is wrong.
+type aboutInfo struct {
+	CLI      aboutCLI       `json:"cli"`
+	Backend  *aboutBackend  `json:"backend,omitempty"`
+	Project  *aboutProject  `json:"project,omitempty"`
+	Runtimes []aboutRuntime `json:"runtimes"`
+	Plugins  []aboutPlugin  `json:"plugins"`
+	Errors   []string       `json:"errors,omitempty"`
+}
+
+type aboutCLI struct {
+	Version   string `json:"version"`
+	GoVersion string `json:"goVersion"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+}
+
+type aboutBackend struct {
+	Name         string `json:"name"`
+	URL          string `json:"url"`
+	User         string `json:"user,omitempty"`
+	CurrentStack string `json:"currentStack,omitempty"`
+	Reachable    bool   `json:"reachable"`
+	Latency      string `json:"latency,omitempty"`
+}
+
+type aboutProject struct {
+	Name    string `json:"name"`
+	Runtime string `json:"runtime"`
+}
+
+type aboutRuntime struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type aboutPlugin struct {
+	Name    string `json:"name"`
+	Kind    string `json:"kind"`
+	Version string `json:"version,omitempty"`
+}
+
+func newAboutCmd() *cobra.Command {
+	var jsonOut bool
+
+	cmd := &cobra.Command{
+		Use:   "about",
+		Args:  cmdutil.NoArgs,
+		Short: "Print information about the Pulumi environment",
+		Long: "Print information about the Pulumi environment.\n" +
+			"\n" +
+			"This reports the version of the CLI, the backend in use and whether it can be reached, the\n" +
+			"current project and stack, the versions of the language runtimes on the PATH, and the\n" +
+			"installed plugins.  Include its output, which doesn't contain any secrets, in bug reports\n" +
+			"and support requests.",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			info := gatherAboutInfo(commandContext())
+			if jsonOut {
+				b, err := json.MarshalIndent(info, "", "    ")
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(b))
+				return nil
+			}
+			printAboutInfo(info)
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().BoolVarP(
+		&jsonOut, "json", "j", false, "Emit the information as JSON")
+
+	return cmd
+}
+
+// gatherAboutInfo collects the information reported by `pulumi about`.
+func gatherAboutInfo(ctx context.Context) *aboutInfo {
+	info := &aboutInfo{
+		CLI: aboutCLI{
+			Version:   version.Version,
+			GoVersion: runtime.Version(),
+			OS:        runtime.GOOS,
+			Arch:      runtime.GOARCH,
+		},
+	}
+	addError := func(what string, err error) {
+		info.Errors = append(info.Errors, fmt.Sprintf("%s: %v", what, err))
+	}
+
+	if proj, err := workspace.DetectProject(); err == nil {
+		info.Project = &aboutProject{Name: string(proj.Name), Runtime: proj.Runtime}
+	}
+
+	if creds, err := workspace.GetStoredCredentials(); err != nil {
+		addError("reading credentials", err)
+	} else if creds.Current == "" {
+		addError("finding the backend", errors.New("not logged in"))
+	} else if b, err := currentBackend(); err != nil {
+		addError("connecting to the backend", err)
+	} else {
+		info.Backend = &aboutBackend{Name: b.Name(), URL: creds.Current}
+
+		// Listing the stacks exercises the backend's storage, whether that's the local filesystem or the service.
+		start := time.Now()
+		if _, err = b.ListStacks(ctx, nil); err != nil {
+			addError("listing stacks", err)
+		} else {
+			info.Backend.Reachable = true
+			info.Backend.Latency = time.Since(start).Round(time.Millisecond).String()
+		}
+
+		if cb, ok := b.(cloud.Backend); ok {
+			if info.Backend.User, err = cb.CurrentUser(ctx); err != nil {
+				addError("finding the current user", err)
+			}
+		}
+		if info.Project != nil {
+			if s, err := state.CurrentStack(ctx, b); err != nil {
+				addError("finding the current stack", err)
+			} else if s != nil {
+				info.Backend.CurrentStack = s.Name().String()
+			}
+		}
+	}
+
+	info.Runtimes = aboutRuntimes(ctx)
+
+	plugins, err := workspace.GetPlugins()
+	if err != nil {
+		addError("listing plugins", err)
+	}
+	for _, plugin := range plugins {
+		p := aboutPlugin{Name: plugin.Name, Kind: string(plugin.Kind)}
+		if plugin.Version != nil {
+			p.Version = plugin.Version.String()
+		}
+		info.Plugins = append(info.Plugins, p)
+	}
+	sort.Slice(info.Plugins, func(i, j int) bool {
+		pi, pj := info.Plugins[i], info.Plugins[j]
+		if pi.Name != pj.Name {
+			return pi.Name < pj.Name
+		}
+		return pi.Kind < pj.Kind
+	})
+
+	return info
+}
+
+// aboutRuntimes returns the version of each language runtime that Pulumi supports, as reported by the first of its
+// commands found on the PATH.  A runtime that isn't found is reported without a version.
+func aboutRuntimes(ctx context.Context) []aboutRuntime {
+	runtimes := []struct {
+		name     string
+		commands [][]string
+	}{
+		{"nodejs", [][]string{{"node", "--version"}}},
+		{"python", [][]string{{"python3", "--version"}, {"python", "--version"}}},
+		{"go", [][]string{{"go", "version"}}},
+	}
+
+	var result []aboutRuntime
+	for _, rt := range runtimes {
+		r := aboutRuntime{Name: rt.name}
+		for _, command := range rt.commands {
+			path, err := exec.LookPath(command[0])
+			if err != nil {
+				continue
+			}
+
+			cmdCtx, cancel := context.WithTimeout(ctx, aboutRuntimeTimeout)
+			// nolint: gas
+			out, err := exec.CommandContext(cmdCtx, path, command[1:]...).CombinedOutput()
+			cancel()
+			if err == nil {
+				r.Version = strings.TrimSpace(string(out))
+				break
+			}
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
+// printAboutInfo prints the information gathered by `pulumi about` for humans to read.
+func printAboutInfo(info *aboutInfo) {
+	fmt.Printf("CLI\n")
+	fmt.Printf("    Version:      %s\n", info.CLI.Version)
+	fmt.Printf("    Go Version:   %s\n", info.CLI.GoVersion)
+	fmt.Printf("    Platform:     %s/%s\n", info.CLI.OS, info.CLI.Arch)
+
+	fmt.Printf("\nBackend\n")
+	if info.Backend == nil {
+		fmt.Printf("    (none)\n")
+	} else {
+		fmt.Printf("    Name:         %s\n", info.Backend.Name)
+		fmt.Printf("    URL:          %s\n", info.Backend.URL)
+		if info.Backend.User != "" {
+			fmt.Printf("    User:         %s\n", info.Backend.User)
+		}
+		if info.Backend.Reachable {
+			fmt.Printf("    Reachable:    yes (%s)\n", info.Backend.Latency)
+		} else {
+			fmt.Printf("    Reachable:    no\n")
+		}
+	}
+
+	fmt.Printf("\nProject\n")
+	if info.Project == nil {
+		fmt.Printf("    (none)\n")
+	} else {
+		fmt.Printf("    Name:         %s\n", info.Project.Name)
+		fmt.Printf("    Runtime:      %s\n", info.Project.Runtime)
+		if info.Backend != nil && info.Backend.CurrentStack != "" {
+			fmt.Printf("    Stack:        %s\n", info.Backend.CurrentStack)
+		}
+	}
+
+	fmt.Printf("\nLanguage Runtimes\n")
+	for _, rt := range info.Runtimes {
+		v := rt.Version
+		if v == "" {
+			v = "not found"
+		}
+		fmt.Printf("    %-13s %s\n", rt.Name+":", v)
+	}
+
+	fmt.Printf("\nPlugins\n")
+	if len(info.Plugins) == 0 {
+		fmt.Printf("    (none)\n")
+	}
+	for _, plugin := range info.Plugins {
+		fmt.Printf("    %-26s %-12s %s\n", plugin.Name, plugin.Kind, plugin.Version)
+	}
+
+	if len(info.Errors) > 0 {
+		fmt.Printf("\nErrors\n")
+		for _, err := range info.Errors {
+			fmt.Printf("    %s\n", err)
+		}
+	}
+}
@@ -20,7 +20,6 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
-	"github.com/pulumi/pulumi/pkg/backend/cloud"
 	"github.com/pulumi/pulumi/pkg/diag/colors"
 	"github.com/pulumi/pulumi/pkg/util/cmdutil"
 )
@@ -36,6 +35,12 @@ func newCancelCmd() *cobra.Command {
 			"This command cancels the update currently being applied to a stack if any exists.\n" +
 			"Note that this operation is _very dangerous_, and may leave the stack in an\n" +
 			"inconsistent state if a resource operation was pending when the update was canceled.\n" +
+			"Any such operations are recorded in the stack's checkpoint, and are reconciled by\n" +
+			"the next update or by `pulumi stack repair`.\n" +
+			"\n" +
+			"For stacks managed by the local backend, this releases the lock left behind by an\n" +
+			"update whose process has died or hung, and records the update as cancelled in the\n" +
+			"stack's history.  It doesn't stop the update's process if it is still running.\n" +
 			"\n" +
 			"After this command completes successfully, the stack will be ready for further\n" +
 			"updates.",
@@ -50,12 +55,6 @@ func newCancelCmd() *cobra.Command {
 				return err
 			}
 
-			// Ensure that we are targeting the Pulumi cloud.
-			backend, ok := s.Backend().(cloud.Backend)
-			if !ok {
-				return errors.New("the `cancel` command is not supported for local stacks")
-			}
-
 			// Ensure the user really wants to do this.
 			prompt := fmt.Sprintf("This will irreversably cancel the currently running update for '%s'!", s.Name())
 			if !yes && !confirmPrompt(prompt, s.Name().String()) {
@@ -63,7 +62,7 @@ func newCancelCmd() *cobra.Command {
 			}
 
 			// Cancel the update.
-			if err := backend.CancelCurrentUpdate(commandContext(), s.Name()); err != nil {
+			if err := s.Backend().CancelCurrentUpdate(commandContext(), s.Name()); err != nil {
 				return err
 			}
 
@@ -71,6 +70,12 @@ func newCancelCmd() *cobra.Command {
 				colors.Reset)
 			fmt.Println(colors.ColorizeText(msg))
 
+			// Let the user know about any operations that the update left pending.
+			if snap, err := s.Snapshot(commandContext()); err == nil && snap != nil && len(snap.PendingOperations) > 0 {
+				fmt.Printf("The update left %d operation(s) pending; they will be reconciled by the next update, "+
+					"or can be resolved now with `pulumi stack repair`.\n", len(snap.PendingOperations))
+			}
+
 			return nil
 		}),
 	}
@@ -0,0 +1,358 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/pulumi/pulumi/pkg/backend"
+	"github.com/pulumi/pulumi/pkg/backend/state"
+	"github.com/pulumi/pulumi/pkg/tokens"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+	"github.com/pulumi/pulumi/pkg/util/logging"
+	"github.com/pulumi/pulumi/pkg/workspace"
+)
+
+// completeCommand is the name of the hidden command that the completion scripts run to find completions.
+const completeCommand = "__complete"
+
+// completionScripts holds the completion script for each supported shell.  Each defers to `pulumi __complete`, which
+// is passed the words of the command line (after `pulumi`) up to and including the word being completed, and prints
+// the candidates for that word one per line.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for pulumi
+_pulumi() {
+    local cur words cword
+    if declare -F _get_comp_words_by_ref >/dev/null; then
+        _get_comp_words_by_ref -n =: cur words cword
+    else
+        cur="${COMP_WORDS[COMP_CWORD]}"
+        words=("${COMP_WORDS[@]}")
+        cword=$COMP_CWORD
+    fi
+
+    local IFS=$'\n'
+    COMPREPLY=( $(pulumi __complete "${words[@]:1:$cword}" 2>/dev/null) )
+    if declare -F __ltrim_colon_completions >/dev/null; then
+        __ltrim_colon_completions "$cur"
+    fi
+}
+complete -o default -F _pulumi pulumi
+`,
+	"zsh": `#compdef pulumi
+# zsh completion for pulumi
+_pulumi() {
+    local -a completions
+    completions=(${(f)"$(pulumi __complete "${(@)words[2,$CURRENT]}" 2>/dev/null)"})
+    compadd -- "${completions[@]}"
+}
+compdef _pulumi pulumi
+`,
+	"fish": `# fish completion for pulumi
+function __pulumi_complete
+    set -l args (commandline -opc)
+    set -e args[1]
+    pulumi __complete $args (commandline -ct) 2>/dev/null
+end
+complete -c pulumi -f -a '(__pulumi_complete)'
+`,
+}
+
+func newCompletionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "completion <bash|zsh|fish>",
+		Args:  cmdutil.ExactArgs(1),
+		Short: "Generate a shell completion script",
+		Long: "Generate a shell completion script.\n" +
+			"\n" +
+			"Besides commands and flags, the script completes the names of stacks, the keys of the\n" +
+			"current stack's configuration, and the URNs of its resources, by asking the current backend.\n" +
+			"To enable completion, load the script in your shell's startup file; for example:\n" +
+			"\n" +
+			"    bash: source <(pulumi completion bash)\n" +
+			"    zsh:  source <(pulumi completion zsh)\n" +
+			"    fish: pulumi completion fish | source",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			script, has := completionScripts[args[0]]
+			if !has {
+				return errors.Errorf("unsupported shell '%s'; choices are: bash, zsh, fish", args[0])
+			}
+			fmt.Print(script)
+			return nil
+		}),
+	}
+}
+
+// newCompleteCmd returns the hidden command run by the completion scripts.
+func newCompleteCmd(root *cobra.Command) *cobra.Command {
+	return &cobra.Command{
+		Use:                completeCommand + " [words...]",
+		Short:              "Print the completions for a command line",
+		Hidden:             true,
+		DisableFlagParsing: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			for _, c := range completeWords(root, args) {
+				fmt.Println(c)
+			}
+		},
+	}
+}
+
+// completeWords returns the candidates for the last of the given words, which are those on the command line after
+// `pulumi`.  Errors are logged rather than reported, since anything printed would be taken as a candidate.
+func completeWords(root *cobra.Command, words []string) []string {
+	toComplete := ""
+	if len(words) > 0 {
+		toComplete, words = words[len(words)-1], words[:len(words)-1]
+	}
+
+	// Respect `--cwd`, since it changes the project, and so the stack, that the command line refers to.
+	if cwd := completionFlagValue(words, "cwd", "C"); cwd != "" {
+		if err := os.Chdir(cwd); err != nil {
+			logging.V(7).Infof("completion could not change to %s: %v", cwd, err)
+			return nil
+		}
+	}
+
+	cmd, rest, err := root.Find(words)
+	if err != nil || cmd == nil {
+		cmd, rest = root, words
+	}
+
+	var candidates []string
+	if prev := lastWord(words); strings.HasPrefix(prev, "-") && !strings.Contains(prev, "=") {
+		// If the previous word is a flag that takes a value, complete its value.
+		if flag := lookupCompletionFlag(cmd, prev); flag != nil && flag.Value.Type() != "bool" {
+			return filterCompletions(completeFlagValue(flag.Name, rest), toComplete)
+		}
+	}
+
+	if strings.HasPrefix(toComplete, "-") {
+		addFlag := func(flag *pflag.Flag) {
+			if !flag.Hidden {
+				candidates = append(candidates, "--"+flag.Name)
+			}
+		}
+		cmd.NonInheritedFlags().VisitAll(addFlag)
+		cmd.InheritedFlags().VisitAll(addFlag)
+		return filterCompletions(candidates, toComplete)
+	}
+
+	for _, sub := range cmd.Commands() {
+		if sub.IsAvailableCommand() {
+			candidates = append(candidates, sub.Name())
+		}
+	}
+	candidates = append(candidates, completeArgument(cmd, rest)...)
+	return filterCompletions(candidates, toComplete)
+}
+
+// completeArgument returns the candidates for a positional argument of the given command.
+func completeArgument(cmd *cobra.Command, rest []string) []string {
+	var args []string
+	for i := 0; i < len(rest); i++ {
+		if strings.HasPrefix(rest[i], "-") {
+			if flag := lookupCompletionFlag(cmd, rest[i]); flag != nil && flag.Value.Type() != "bool" &&
+				!strings.Contains(rest[i], "=") {
+				i++
+			}
+			continue
+		}
+		args = append(args, rest[i])
+	}
+	stackName := completionFlagValue(rest, "stack", "s")
+
+	switch cmd.CommandPath() {
+	case "pulumi cancel", "pulumi stack rm", "pulumi stack select":
+		if len(args) == 0 {
+			return completeStackNames()
+		}
+	case "pulumi config get", "pulumi config rm", "pulumi config set":
+		if len(args) == 0 {
+			return completeConfigKeys(stackName)
+		}
+	case "pulumi state delete", "pulumi state move", "pulumi state protect", "pulumi state unprotect",
+		"pulumi state taint", "pulumi state untaint":
+		return completeURNs(stackName)
+	case "pulumi state rename":
+		if len(args) == 0 {
+			return completeURNs(stackName)
+		}
+	}
+	return nil
+}
+
+// completeFlagValue returns the candidates for the value of the flag with the given name.
+func completeFlagValue(name string, rest []string) []string {
+	switch name {
+	case "stack":
+		return completeStackNames()
+	case "target", "explain":
+		return completeURNs(completionFlagValue(rest, "stack", "s"))
+	default:
+		return nil
+	}
+}
+
+// completeStackNames returns the names of the stacks in the current backend, limited to those of the current project,
+// if there is one.
+func completeStackNames() []string {
+	b, err := currentBackend()
+	if err != nil {
+		logging.V(7).Infof("completion could not find the current backend: %v", err)
+		return nil
+	}
+
+	var projectFilter *tokens.PackageName
+	if proj, err := workspace.DetectProject(); err == nil {
+		projectFilter = &proj.Name
+	}
+	stacks, err := b.ListStacks(commandContext(), projectFilter)
+	if err != nil {
+		logging.V(7).Infof("completion could not list stacks: %v", err)
+		return nil
+	}
+
+	var names []string
+	for _, s := range stacks {
+		names = append(names, s.Name().String())
+	}
+	return names
+}
+
+// completeConfigKeys returns the keys of the configuration of the given stack, or the current one.
+func completeConfigKeys(stackName string) []string {
+	s := completionStack(stackName)
+	if s == nil {
+		return nil
+	}
+	cfg, _, err := workspace.DetectStackConfig(s.Name().StackName())
+	if err != nil {
+		logging.V(7).Infof("completion could not read the stack's configuration: %v", err)
+		return nil
+	}
+
+	var keys []string
+	for k := range cfg {
+		keys = append(keys, k.String())
+	}
+	return keys
+}
+
+// completeURNs returns the URNs of the resources in the given stack, or the current one.
+func completeURNs(stackName string) []string {
+	s := completionStack(stackName)
+	if s == nil {
+		return nil
+	}
+	snap, err := s.Snapshot(commandContext())
+	if err != nil || snap == nil {
+		logging.V(7).Infof("completion could not read the stack's resources: %v", err)
+		return nil
+	}
+
+	var urns []string
+	for _, res := range snap.Resources {
+		urns = append(urns, string(res.URN))
+	}
+	return urns
+}
+
+// completionStack returns the stack with the given name or, if the name is empty, the current stack.  Unlike
+// requireStack, it never prompts, and returns nil if there is no such stack.
+func completionStack(stackName string) backend.Stack {
+	b, err := currentBackend()
+	if err != nil {
+		logging.V(7).Infof("completion could not find the current backend: %v", err)
+		return nil
+	}
+
+	var s backend.Stack
+	if stackName == "" {
+		s, err = state.CurrentStack(commandContext(), b)
+	} else {
+		var stackRef backend.StackReference
+		if stackRef, err = b.ParseStackReference(stackName); err == nil {
+			s, err = b.GetStack(commandContext(), stackRef)
+		}
+	}
+	if err != nil {
+		logging.V(7).Infof("completion could not find the stack: %v", err)
+		return nil
+	}
+	return s
+}
+
+// lookupCompletionFlag returns the flag, local or inherited, of the given command that the given word names, either
+// in its long form (`--stack`, `--stack=dev`) or by its shorthand (`-s`).
+func lookupCompletionFlag(cmd *cobra.Command, word string) *pflag.Flag {
+	for _, flags := range []*pflag.FlagSet{cmd.Flags(), cmd.InheritedFlags()} {
+		var flag *pflag.Flag
+		if strings.HasPrefix(word, "--") {
+			flag = flags.Lookup(strings.SplitN(strings.TrimPrefix(word, "--"), "=", 2)[0])
+		} else if len(word) == 2 {
+			flag = flags.ShorthandLookup(word[1:])
+		}
+		if flag != nil {
+			return flag
+		}
+	}
+	return nil
+}
+
+// completionFlagValue returns the value given to the flag with the given long and short names among some words, or ""
+// if the flag wasn't given.
+func completionFlagValue(words []string, name, shorthand string) string {
+	for i, word := range words {
+		switch {
+		case word == "--"+name || word == "-"+shorthand:
+			if i+1 < len(words) {
+				return words[i+1]
+			}
+		case strings.HasPrefix(word, "--"+name+"="):
+			return strings.TrimPrefix(word, "--"+name+"=")
+		}
+	}
+	return ""
+}
+
+// filterCompletions returns the sorted, unique candidates that begin with the given prefix.
+func filterCompletions(candidates []string, prefix string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) && !seen[c] {
+			seen[c] = true
+			result = append(result, c)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// lastWord returns the last of the given words, or "" if there are none.
+func lastWord(words []string) string {
+	if len(words) == 0 {
+		return ""
+	}
+	return words[len(words)-1]
+}
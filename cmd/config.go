@@ -15,9 +15,12 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -28,7 +31,9 @@ import (
 
 	"github.com/pulumi/pulumi/pkg/backend"
 	"github.com/pulumi/pulumi/pkg/diag"
+	"github.com/pulumi/pulumi/pkg/encoding"
 	"github.com/pulumi/pulumi/pkg/resource/config"
+	"github.com/pulumi/pulumi/pkg/secrets"
 	"github.com/pulumi/pulumi/pkg/tokens"
 	"github.com/pulumi/pulumi/pkg/util/cmdutil"
 	"github.com/pulumi/pulumi/pkg/workspace"
@@ -37,13 +42,17 @@ import (
 func newConfigCmd() *cobra.Command {
 	var stack string
 	var showSecrets bool
+	var showSource bool
 
 	cmd := &cobra.Command{
 		Use:   "config",
 		Short: "Manage configuration",
 		Long: "Lists all configuration values for a specific stack. To add a new configuration value, run\n" +
 			"'pulumi config set', to remove and existing value run 'pulumi config rm'. To get the value of\n" +
-			"for a specific configuration key, use 'pulumi config get <key-name>'.",
+			"for a specific configuration key, use 'pulumi config get <key-name>'.\n" +
+			"\n" +
+			"Every stack inherits the values in the `stackConfig` section of the project's Pulumi.yaml, unless\n" +
+			"it sets the same keys itself.  Use `--show-source` to see where each value is set.",
 		Args: cmdutil.NoArgs,
 		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
 			stack, err := requireStack(stack, true)
@@ -51,49 +60,75 @@ func newConfigCmd() *cobra.Command {
 				return err
 			}
 
-			return listConfig(stack, showSecrets)
+			return listConfig(stack, showSecrets, showSource)
 		}),
 	}
 
 	cmd.Flags().BoolVar(
 		&showSecrets, "show-secrets", false,
 		"Show secret values when listing config instead of displaying blinded values")
+	cmd.Flags().BoolVar(
+		&showSource, "show-source", false,
+		"Show whether each value is set by the stack or inherited from the project")
 	cmd.PersistentFlags().StringVarP(
 		&stack, "stack", "s", "",
 		"Operate on a different stack than the currently selected stack")
 
 	cmd.AddCommand(newConfigGetCmd(&stack))
 	cmd.AddCommand(newConfigRmCmd(&stack))
+	cmd.AddCommand(newConfigRmAllCmd(&stack))
 	cmd.AddCommand(newConfigSetCmd(&stack))
+	cmd.AddCommand(newConfigSetAllCmd(&stack))
+	cmd.AddCommand(newConfigCpCmd(&stack))
 	cmd.AddCommand(newConfigRefreshCmd(&stack))
 
 	return cmd
 }
 
 func newConfigGetCmd(stack *string) *cobra.Command {
+	var path bool
+	var showSource bool
+
 	getCmd := &cobra.Command{
 		Use:   "get <key>",
 		Short: "Get a single configuration value",
-		Args:  cmdutil.SpecificArgs([]string{"key"}),
+		Long: "Get a single configuration value.\n" +
+			"\n" +
+			"The value is the stack's own, if it sets one, or else the one that it inherits from the\n" +
+			"`stackConfig` section of the project's Pulumi.yaml.  Use `--show-source` to print the file\n" +
+			"that sets it, too.\n" +
+			"\n" +
+			"With `--path`, the key may give the path to a value within an object or array, such as\n" +
+			"`data.endpoints[0].host`.",
+		Args: cmdutil.SpecificArgs([]string{"key"}),
 		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
 			s, err := requireStack(*stack, true)
 			if err != nil {
 				return err
 			}
 
-			key, err := parseConfigKey(args[0])
+			key, keyPath, err := parseConfigKeyArg(args[0], path)
 			if err != nil {
 				return errors.Wrap(err, "invalid configuration key")
 			}
 
-			return getConfig(s, key)
+			return getConfig(s, key, keyPath, showSource)
 		}),
 	}
 
+	getCmd.PersistentFlags().BoolVar(
+		&path, "path", false,
+		"The key gives the path to a value within an object or array")
+	getCmd.PersistentFlags().BoolVar(
+		&showSource, "show-source", false,
+		"Also print the file that sets the value")
+
 	return getCmd
 }
 
 func newConfigRmCmd(stack *string) *cobra.Command {
+	var path bool
+
 	rmCmd := &cobra.Command{
 		Use:   "rm <key>",
 		Short: "Remove configuration value",
@@ -104,7 +139,7 @@ func newConfigRmCmd(stack *string) *cobra.Command {
 				return err
 			}
 
-			key, err := parseConfigKey(args[0])
+			key, keyPath, err := parseConfigKeyArg(args[0], path)
 			if err != nil {
 				return errors.Wrap(err, "invalid configuration key")
 			}
@@ -114,14 +149,18 @@ func newConfigRmCmd(stack *string) *cobra.Command {
 				return err
 			}
 
-			if ps.Config != nil {
-				delete(ps.Config, key)
+			if err = ps.Config.RemovePath(key, keyPath); err != nil {
+				return err
 			}
 
 			return workspace.SaveProjectStack(s.Name().StackName(), ps)
 		}),
 	}
 
+	rmCmd.PersistentFlags().BoolVar(
+		&path, "path", false,
+		"The key gives the path to a value within an object or array, which is removed from it")
+
 	return rmCmd
 }
 
@@ -193,7 +232,9 @@ func newConfigRefreshCmd(stack *string) *cobra.Command {
 }
 
 func newConfigSetCmd(stack *string) *cobra.Command {
+	var path bool
 	var plaintext bool
+	var ref bool
 	var secret bool
 
 	setCmd := &cobra.Command{
@@ -201,7 +242,25 @@ func newConfigSetCmd(stack *string) *cobra.Command {
 		Short: "Set configuration value",
 		Long: "Configuration values can be accessed when a stack is being deployed and used to configure behavior. \n" +
 			"If a value is not present on the command line, pulumi will prompt for the value. Multi-line values\n" +
-			"may be set by piping a file to standard in.",
+			"may be set by piping a file to standard in.\n" +
+			"\n" +
+			"With `--path`, the key may give the path to a value within an object or array, such as\n" +
+			"`data.endpoints[0].host`, which is set within it; any objects and arrays along the path that\n" +
+			"don't exist are created.  Values that are numbers or booleans are stored as such.\n" +
+			"\n" +
+			"With `--ref`, the value is a reference to a secret held in an external store, which is fetched\n" +
+			"each time the stack is deployed, and treated as a secret, but never saved:\n" +
+			"\n" +
+			"    ssm://<name>               a parameter in AWS Systems Manager Parameter Store, such as\n" +
+			"                               ssm:///prod/db/password.\n" +
+			"    secretsmanager://<secret>  a secret in AWS Secrets Manager, given by its name or ARN.  A\n" +
+			"                               property of a secret that holds JSON may be given as a fragment,\n" +
+			"                               as in secretsmanager://prod/db#password.\n" +
+			"\n" +
+			"The query of a reference may give the `region` and `profile` with which to fetch it.\n" +
+			"\n" +
+			"If the project's `configSchema` declares the key, the value must have the type it gives, be one\n" +
+			"of the values it allows, and be a secret if it says so.",
 		Args: cmdutil.RangeArgs(1, 2),
 		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
 
@@ -211,10 +270,13 @@ func newConfigSetCmd(stack *string) *cobra.Command {
 				return err
 			}
 
-			key, err := parseConfigKey(args[0])
+			key, keyPath, err := parseConfigKeyArg(args[0], path)
 			if err != nil {
 				return errors.Wrap(err, "invalid configuration key")
 			}
+			if ref && (secret || path) {
+				return errors.New("--ref may not be combined with --secret or --path")
+			}
 
 			var value string
 			switch {
@@ -240,12 +302,19 @@ func newConfigSetCmd(stack *string) *cobra.Command {
 
 			// Encrypt the config value if needed.
 			var v config.Value
-			if secret {
+			var crypter config.Crypter
+			if ref {
+				if err = secrets.ValidateReference(value); err != nil {
+					return err
+				}
+				v = config.NewReferenceValue(value)
+			} else if secret {
 				c, cerr := backend.GetStackCrypter(s)
 				if cerr != nil {
 					return cerr
 				}
-				enc, eerr := c.EncryptValue(value)
+				crypter = c
+				enc, eerr := config.EncrypterForKey(c, key).EncryptValue(value)
 				if eerr != nil {
 					return eerr
 				}
@@ -259,7 +328,18 @@ func newConfigSetCmd(stack *string) *cobra.Command {
 				return err
 			}
 
-			ps.Config[key] = v
+			if err = ps.Config.SetPath(key, keyPath, v); err != nil {
+				return err
+			}
+
+			// Check the key's new value against the project's config schema before saving it.
+			proj, err := workspace.DetectProject()
+			if err != nil {
+				return err
+			}
+			if err = proj.ValidateConfigValue(key, ps.Config[key], crypter); err != nil {
+				return err
+			}
 
 			err = workspace.SaveProjectStack(s.Name().StackName(), ps)
 			if err != nil {
@@ -267,7 +347,7 @@ func newConfigSetCmd(stack *string) *cobra.Command {
 			}
 
 			// If we saved a plaintext configuration value, and --plaintext was not passed, warn the user.
-			if !secret && !plaintext {
+			if !secret && !ref && !plaintext {
 				cmdutil.Diag().Warningf(
 					diag.Message("", /*urn*/
 						"saved config key '%s' value '%s' as plaintext; "+
@@ -280,9 +360,15 @@ func newConfigSetCmd(stack *string) *cobra.Command {
 		}),
 	}
 
+	setCmd.PersistentFlags().BoolVar(
+		&path, "path", false,
+		"The key gives the path to a value within an object or array")
 	setCmd.PersistentFlags().BoolVar(
 		&plaintext, "plaintext", false,
 		"Save the value as plaintext (unencrypted)")
+	setCmd.PersistentFlags().BoolVar(
+		&ref, "ref", false,
+		"The value is a reference to a secret in an external store, such as ssm:///prod/db/password")
 	setCmd.PersistentFlags().BoolVar(
 		&secret, "secret", false,
 		"Encrypt the value instead of storing it in plaintext")
@@ -290,6 +376,342 @@ func newConfigSetCmd(stack *string) *cobra.Command {
 	return setCmd
 }
 
+func newConfigSetAllCmd(stack *string) *cobra.Command {
+	var file string
+
+	setAllCmd := &cobra.Command{
+		Use:   "set-all",
+		Short: "Set many configuration values at once",
+		Long: "Set many configuration values at once, from a file or from standard in.\n" +
+			"\n" +
+			"The values are given as a YAML or JSON object that maps each key to its value.  A value to be\n" +
+			"encrypted is given as an object with a single `secret` property.  For example:\n" +
+			"\n" +
+			"    aws:region: us-west-2\n" +
+			"    instanceCount: 3\n" +
+			"    dbPassword:\n" +
+			"        secret: hunter2\n" +
+			"\n" +
+			"Either all of the values are set, or, if any of them is invalid, none are.",
+		Args: cmdutil.NoArgs,
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			s, err := requireStack(*stack, true)
+			if err != nil {
+				return err
+			}
+
+			var b []byte
+			m := encoding.YAML
+			if file == "" || file == "-" {
+				b, err = ioutil.ReadAll(os.Stdin)
+			} else {
+				b, err = ioutil.ReadFile(file)
+				if fm, has := encoding.Marshalers[filepath.Ext(file)]; has {
+					m = fm
+				}
+			}
+			if err != nil {
+				return err
+			}
+			values, err := parseBulkConfigValues(b, m)
+			if err != nil {
+				return errors.Wrap(err, "could not read configuration values")
+			}
+
+			ps, err := workspace.DetectProjectStack(s.Name().StackName())
+			if err != nil {
+				return err
+			}
+
+			proj, err := workspace.DetectProject()
+			if err != nil {
+				return err
+			}
+
+			// Parse, encrypt, and check every value before saving any, so that either all of them are set or none are.
+			var encrypter config.Crypter
+			for k, value := range values {
+				key, err := parseConfigKey(k)
+				if err != nil {
+					return errors.Wrapf(err, "invalid configuration key '%s'", k)
+				}
+				v := config.NewValue(value.value)
+				if value.secret {
+					if encrypter == nil {
+						if encrypter, err = backend.GetStackCrypter(s); err != nil {
+							return err
+						}
+					}
+					enc, err := config.EncrypterForKey(encrypter, key).EncryptValue(value.value)
+					if err != nil {
+						return err
+					}
+					v = config.NewSecureValue(enc)
+				}
+				if err = proj.ValidateConfigValue(key, v, encrypter); err != nil {
+					return err
+				}
+				ps.Config[key] = v
+			}
+
+			if err = workspace.SaveProjectStack(s.Name().StackName(), ps); err != nil {
+				return err
+			}
+			fmt.Printf("Set %d configuration value(s).\n", len(values))
+			return nil
+		}),
+	}
+
+	setAllCmd.PersistentFlags().StringVarP(
+		&file, "file", "f", "",
+		"The file to read the values from; by default, or if '-', they are read from standard in")
+
+	return setAllCmd
+}
+
+func newConfigRmAllCmd(stack *string) *cobra.Command {
+	var prefixes []string
+
+	rmAllCmd := &cobra.Command{
+		Use:   "rm-all [<key>...]",
+		Short: "Remove many configuration values at once",
+		Long: "Remove many configuration values at once.\n" +
+			"\n" +
+			"The values to remove are given by key, or by `--prefix`, which removes every value whose key\n" +
+			"starts with the given prefix (e.g. `aws:` to remove all of the AWS provider's configuration).",
+		Args: cmdutil.ArgsFunc(cobra.ArbitraryArgs),
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && len(prefixes) == 0 {
+				return errors.New("either configuration keys or `--prefix` must be given")
+			}
+
+			s, err := requireStack(*stack, true)
+			if err != nil {
+				return err
+			}
+
+			remove := make(map[config.Key]bool)
+			for _, arg := range args {
+				key, err := parseConfigKey(arg)
+				if err != nil {
+					return errors.Wrapf(err, "invalid configuration key '%s'", arg)
+				}
+				remove[key] = true
+			}
+
+			ps, err := workspace.DetectProjectStack(s.Name().StackName())
+			if err != nil {
+				return err
+			}
+
+			var removed config.KeyArray
+			for key := range ps.Config {
+				match := remove[key]
+				for _, prefix := range prefixes {
+					if strings.HasPrefix(key.String(), prefix) || strings.HasPrefix(prettyKey(key), prefix) {
+						match = true
+					}
+				}
+				if match {
+					removed = append(removed, key)
+				}
+			}
+			sort.Sort(removed)
+			for _, key := range removed {
+				delete(ps.Config, key)
+				fmt.Printf("Removed '%s'.\n", prettyKey(key))
+			}
+
+			return workspace.SaveProjectStack(s.Name().StackName(), ps)
+		}),
+	}
+
+	rmAllCmd.PersistentFlags().StringSliceVar(
+		&prefixes, "prefix", []string{},
+		"Remove every value whose key starts with the given prefix; may be repeated")
+
+	return rmAllCmd
+}
+
+func newConfigCpCmd(stack *string) *cobra.Command {
+	var dest string
+
+	cpCmd := &cobra.Command{
+		Use:   "cp [<key>...]",
+		Short: "Copy configuration values to another stack",
+		Long: "Copy configuration values to another stack.\n" +
+			"\n" +
+			"Copies the given values, or all of them if no keys are given, from the current stack (or the\n" +
+			"one given by `--stack`) to the stack given by `--dest`, overwriting any values that it already\n" +
+			"has for the same keys.  Secrets are decrypted, and then encrypted again for the destination.",
+		Args: cmdutil.ArgsFunc(cobra.ArbitraryArgs),
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			if dest == "" {
+				return errors.New("the stack to copy to must be given with `--dest`")
+			}
+
+			src, err := requireStack(*stack, true)
+			if err != nil {
+				return err
+			}
+			dst, err := requireStack(dest, false)
+			if err != nil {
+				return err
+			}
+			if src.Name().String() == dst.Name().String() {
+				return errors.New("the stacks to copy from and to must be different")
+			}
+
+			srcConfig, err := workspace.DetectProjectStack(src.Name().StackName())
+			if err != nil {
+				return err
+			}
+			dstConfig, err := workspace.DetectProjectStack(dst.Name().StackName())
+			if err != nil {
+				return err
+			}
+
+			keys := make(config.KeyArray, 0, len(srcConfig.Config))
+			if len(args) == 0 {
+				for key := range srcConfig.Config {
+					keys = append(keys, key)
+				}
+			}
+			for _, arg := range args {
+				key, err := parseConfigKey(arg)
+				if err != nil {
+					return errors.Wrapf(err, "invalid configuration key '%s'", arg)
+				}
+				if _, has := srcConfig.Config[key]; !has {
+					return errors.Errorf("configuration key '%s' not found for stack '%s'", prettyKey(key), src.Name())
+				}
+				keys = append(keys, key)
+			}
+			sort.Sort(keys)
+
+			if err = copyStackConfig(src, dst, srcConfig, dstConfig, keys); err != nil {
+				return err
+			}
+			if err = workspace.SaveProjectStack(dst.Name().StackName(), dstConfig); err != nil {
+				return err
+			}
+			fmt.Printf("Copied %d configuration value(s) from '%s' to '%s'.\n", len(keys), src.Name(), dst.Name())
+			return nil
+		}),
+	}
+
+	cpCmd.PersistentFlags().StringVarP(
+		&dest, "dest", "d", "",
+		"The stack to copy the configuration values to")
+
+	return cpCmd
+}
+
+// copyStackConfig copies the values with the given keys from one stack's configuration to another's.  Secrets are
+// decrypted with the source stack's crypter, and then encrypted again with the destination stack's.
+func copyStackConfig(src, dst backend.Stack, srcConfig, dstConfig *workspace.ProjectStack, keys config.KeyArray) error {
+	var decrypter config.Decrypter
+	var encrypter config.Encrypter
+	for _, key := range keys {
+		v := srcConfig.Config[key]
+		if !v.Secure() {
+			dstConfig.Config[key] = v
+			continue
+		}
+
+		if decrypter == nil {
+			var err error
+			if decrypter, err = backend.GetStackCrypter(src); err != nil {
+				return err
+			}
+			if encrypter, err = backend.GetStackCrypter(dst); err != nil {
+				return err
+			}
+		}
+		copied, err := v.Copy(decrypter, config.EncrypterForKey(encrypter, key))
+		if err != nil {
+			return errors.Wrapf(err, "could not re-encrypt '%s'", prettyKey(key))
+		}
+		dstConfig.Config[key] = copied
+	}
+	return nil
+}
+
+// bulkConfigValue is a value read by `pulumi config set-all`, along with whether it is to be encrypted.
+type bulkConfigValue struct {
+	value  string
+	secret bool
+}
+
+// parseBulkConfigValues parses the object read by `pulumi config set-all`, which maps each configuration key to its
+// value.  Values that aren't strings are stored as their JSON text; a value of the form `{secret: <value>}` is to be
+// encrypted.
+func parseBulkConfigValues(b []byte, m encoding.Marshaler) (map[string]bulkConfigValue, error) {
+	var raw map[string]interface{}
+	if err := m.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]bulkConfigValue)
+	for k, v := range raw {
+		secret := false
+		if obj, ok := v.(map[interface{}]interface{}); ok {
+			converted := make(map[string]interface{})
+			for mk, mv := range obj {
+				converted[fmt.Sprintf("%v", mk)] = mv
+			}
+			v = converted
+		}
+		if obj, ok := v.(map[string]interface{}); ok {
+			sv, has := obj["secret"]
+			if !has || len(obj) != 1 {
+				return nil, errors.Errorf(
+					"the value of '%s' is an object; only objects with a single `secret` property are supported", k)
+			}
+			v, secret = sv, true
+		}
+		if v == nil {
+			return nil, errors.Errorf("'%s' has no value", k)
+		}
+
+		var value string
+		switch v := v.(type) {
+		case string:
+			value = v
+		case bool, int, int64, float64:
+			value = fmt.Sprintf("%v", v)
+		default:
+			jv, err := json.Marshal(v)
+			if err != nil {
+				return nil, errors.Wrapf(err, "the value of '%s' can't be stored", k)
+			}
+			value = string(jv)
+		}
+		values[k] = bulkConfigValue{value: value, secret: secret}
+	}
+	return values, nil
+}
+
+// parseConfigKeyArg parses a configuration key given on the command line.  If path is true, the key may be followed by
+// the path to a value within an object or array, such as `data.endpoints[0].host`, which is returned too.
+func parseConfigKeyArg(arg string, path bool) (config.Key, config.Path, error) {
+	if !path {
+		key, err := parseConfigKey(arg)
+		return key, nil, err
+	}
+
+	keyPath, err := config.ParsePath(arg)
+	if err != nil {
+		return config.Key{}, nil, err
+	}
+	name, ok := keyPath[0].(string)
+	if !ok {
+		return config.Key{}, nil, errors.Errorf("the path '%s' must start with a key", arg)
+	}
+	key, err := parseConfigKey(name)
+	return key, keyPath[1:], err
+}
+
 func parseConfigKey(key string) (config.Key, error) {
 	// As a convience, we'll treat any key with no delimiter as if:
 	// <program-name>:config:<key> had been written instead
@@ -322,14 +744,12 @@ func prettyKeyForProject(k config.Key, proj *workspace.Project) string {
 	return fmt.Sprintf("%s:%s", k.Namespace(), k.Name())
 }
 
-func listConfig(stack backend.Stack, showSecrets bool) error {
-	ps, err := workspace.DetectProjectStack(stack.Name().StackName())
+func listConfig(stack backend.Stack, showSecrets bool, showSource bool) error {
+	cfg, sources, err := workspace.DetectStackConfig(stack.Name().StackName())
 	if err != nil {
 		return err
 	}
 
-	cfg := ps.Config
-
 	// By default, we will use a blinding decrypter to show '******'.  If requested, display secrets in plaintext.
 	var decrypter config.Decrypter
 	if cfg.HasSecureValue() && showSecrets {
@@ -353,7 +773,11 @@ func listConfig(stack backend.Stack, showSecrets bool) error {
 		}
 	}
 
-	fmt.Printf("%-"+strconv.Itoa(maxkey)+"s %-48s\n", "KEY", "VALUE")
+	if showSource {
+		fmt.Printf("%-"+strconv.Itoa(maxkey)+"s %-48s %s\n", "KEY", "VALUE", "SOURCE")
+	} else {
+		fmt.Printf("%-"+strconv.Itoa(maxkey)+"s %-48s\n", "KEY", "VALUE")
+	}
 	var keys config.KeyArray
 	for key := range cfg {
 		// Note that we use the fully qualified module member here instead of a `prettyKey`, this lets us ensure
@@ -367,21 +791,27 @@ func listConfig(stack backend.Stack, showSecrets bool) error {
 			return errors.Wrap(err, "could not decrypt configuration value")
 		}
 
-		fmt.Printf("%-"+strconv.Itoa(maxkey)+"s %-48s\n", prettyKey(key), decrypted)
+		if showSource {
+			fmt.Printf("%-"+strconv.Itoa(maxkey)+"s %-48s %s\n", prettyKey(key), decrypted, sources[key])
+		} else {
+			fmt.Printf("%-"+strconv.Itoa(maxkey)+"s %-48s\n", prettyKey(key), decrypted)
+		}
 	}
 
 	return nil
 }
 
-func getConfig(stack backend.Stack, key config.Key) error {
-	ps, err := workspace.DetectProjectStack(stack.Name().StackName())
+func getConfig(stack backend.Stack, key config.Key, keyPath config.Path, showSource bool) error {
+	cfg, sources, err := workspace.DetectStackConfig(stack.Name().StackName())
 	if err != nil {
 		return err
 	}
 
-	cfg := ps.Config
-
-	if v, ok := cfg[key]; ok {
+	v, ok, err := cfg.GetPath(key, keyPath)
+	if err != nil {
+		return err
+	}
+	if ok {
 		var d config.Decrypter
 		if v.Secure() {
 			var err error
@@ -395,10 +825,43 @@ func getConfig(stack backend.Stack, key config.Key) error {
 		if err != nil {
 			return errors.Wrap(err, "could not decrypt configuration value")
 		}
+		if v.Object() {
+			var indented bytes.Buffer
+			if err = json.Indent(&indented, []byte(raw), "", "  "); err == nil {
+				raw = indented.String()
+			}
+		}
 		fmt.Printf("%v\n", raw)
+		if showSource {
+			path, err := configSourcePath(stack, sources[key])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("(set by the %s, in %s)\n", sources[key], path)
+		}
 		return nil
 	}
 
+	if len(keyPath) > 0 {
+		return errors.Errorf(
+			"configuration value '%s%s' not found for stack '%s'", prettyKey(key), keyPathSuffix(keyPath), stack.Name())
+	}
 	return errors.Errorf(
 		"configuration key '%s' not found for stack '%s'", prettyKey(key), stack.Name())
 }
+
+// keyPathSuffix returns the path within a configuration value as it follows the key, as in `data.endpoints[0]`.
+func keyPathSuffix(keyPath config.Path) string {
+	if s := keyPath.String(); !strings.HasPrefix(s, "[") {
+		return "." + s
+	}
+	return keyPath.String()
+}
+
+// configSourcePath returns the path of the file in which a value of the given stack's configuration is set.
+func configSourcePath(stack backend.Stack, source workspace.ConfigSource) (string, error) {
+	if source == workspace.ConfigSourceProject {
+		return workspace.DetectProjectPath()
+	}
+	return workspace.DetectProjectStackPath(stack.Name().StackName())
+}
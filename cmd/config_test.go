@@ -19,6 +19,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/pulumi/pulumi/pkg/encoding"
 	"github.com/pulumi/pulumi/pkg/resource/config"
 	"github.com/pulumi/pulumi/pkg/tokens"
 	"github.com/pulumi/pulumi/pkg/workspace"
@@ -30,3 +31,33 @@ func TestPrettyKeyForProject(t *testing.T) {
 	assert.Equal(t, "foo", prettyKeyForProject(config.MustMakeKey("test-package", "foo"), proj))
 	assert.Equal(t, "other-package:bar", prettyKeyForProject(config.MustMakeKey("other-package", "bar"), proj))
 }
+
+func TestParseBulkConfigValues(t *testing.T) {
+	values, err := parseBulkConfigValues([]byte(
+		"aws:region: us-west-2\n"+
+			"instanceCount: 3\n"+
+			"enabled: true\n"+
+			"zones: [a, b]\n"+
+			"dbPassword:\n"+
+			"    secret: hunter2\n"), encoding.YAML)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bulkConfigValue{
+		"aws:region":    {value: "us-west-2"},
+		"instanceCount": {value: "3"},
+		"enabled":       {value: "true"},
+		"zones":         {value: `["a","b"]`},
+		"dbPassword":    {value: "hunter2", secret: true},
+	}, values)
+
+	values, err = parseBulkConfigValues([]byte(`{"count": 2, "token": {"secret": "abc"}}`), encoding.JSON)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bulkConfigValue{
+		"count": {value: "2"},
+		"token": {value: "abc", secret: true},
+	}, values)
+
+	_, err = parseBulkConfigValues([]byte("nested:\n    a: b\n"), encoding.YAML)
+	assert.Error(t, err)
+	_, err = parseBulkConfigValues([]byte("empty:\n"), encoding.YAML)
+	assert.Error(t, err)
+}
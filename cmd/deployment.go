@@ -0,0 +1,361 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/backend"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/config"
+	"github.com/pulumi/pulumi/pkg/resource/stack"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+	"github.com/pulumi/pulumi/pkg/util/contract"
+	"github.com/pulumi/pulumi/pkg/workspace"
+)
+
+func newDeploymentCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "deployment",
+		Short: "Manage deployments that span multiple stacks",
+		Long: "Manage deployments that span multiple stacks.\n" +
+			"\n" +
+			"A deployment file, " + workspace.DeploymentFile + " by default, lists stacks that are deployed\n" +
+			"together, which may belong to different projects.  For example:\n" +
+			"\n" +
+			"    stacks:\n" +
+			"      network:\n" +
+			"        project: ./network\n" +
+			"        stack: prod\n" +
+			"      app:\n" +
+			"        project: ./app\n" +
+			"        stack: prod\n" +
+			"        dependsOn: [ network ]\n" +
+			"        config:\n" +
+			"          app:vpcId: network.vpcId\n" +
+			"\n" +
+			"Each entry names the directory holding its project and, optionally, the stack, which is named\n" +
+			"after the entry by default.  A stack is updated after the stacks it depends on, and destroyed\n" +
+			"before them.  Its `config` sets configuration keys from the outputs of other stacks, written\n" +
+			"as `<entry>.<output>`; a stack depends on each stack whose outputs it uses.  These values are\n" +
+			"set as secrets, encrypted with the stack's own key, since outputs may hold secrets.",
+		Args: cmdutil.NoArgs,
+	}
+
+	cmd.AddCommand(newDeploymentRunCmd())
+
+	return cmd
+}
+
+func newDeploymentRunCmd() *cobra.Command {
+	var file string
+	var concurrency int
+	var color colorFlag
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "run <update|refresh|destroy>",
+		Args:  cmdutil.ExactArgs(1),
+		Short: "Update, refresh, or destroy each of a deployment's stacks",
+		Long: "Update, refresh, or destroy each of a deployment's stacks.\n" +
+			"\n" +
+			"The stacks are updated or refreshed in dependency order, and destroyed in the reverse order.\n" +
+			"Up to `--concurrency` stacks that don't depend on one another are operated on at once.  Before\n" +
+			"a stack is updated or refreshed, the configuration that it takes from other stacks' outputs is\n" +
+			"written to its Pulumi.<stack>.yaml file.  If a stack's operation fails, the stacks that depend on\n" +
+			"it are skipped.\n" +
+			"\n" +
+			"Since the stacks' operations can't be approved one at a time as they run, `--yes` must be\n" +
+			"passed to proceed.",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			op := args[0]
+			switch op {
+			case "up":
+				op = "update"
+			case "update", "refresh", "destroy":
+			default:
+				return errors.Errorf("unknown operation '%s'; choices are: update, refresh, destroy", op)
+			}
+			if !yes {
+				return errors.New("--yes must be passed in to proceed when running a deployment")
+			}
+			if concurrency < 1 {
+				concurrency = 1
+			}
+
+			d, err := workspace.LoadDeployment(file)
+			if err != nil {
+				return errors.Wrapf(err, "could not load deployment file '%s'", file)
+			}
+			b, err := currentBackend()
+			if err != nil {
+				return err
+			}
+
+			run := &deploymentRun{
+				b:           b,
+				d:           d,
+				path:        file,
+				op:          op,
+				color:       color.String(),
+				concurrency: concurrency,
+			}
+			return run.Run()
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&file, "file", "f", workspace.DeploymentFile,
+		"The deployment file that lists the stacks to operate on")
+	cmd.PersistentFlags().IntVar(
+		&concurrency, "concurrency", 1,
+		"The most stacks to operate on at once")
+	cmd.PersistentFlags().VarP(
+		&color, "color", "c", "Colorize output. Choices are: always, never, raw, auto")
+	cmd.PersistentFlags().BoolVarP(
+		&yes, "yes", "y", false,
+		"Automatically approve the operation on each stack")
+
+	return cmd
+}
+
+// deploymentRun performs an operation across the stacks of a deployment, each in a child pulumi process run in the
+// directory of the stack's project.
+type deploymentRun struct {
+	b           backend.Backend
+	d           *workspace.Deployment
+	path        string // the path of the deployment file.
+	op          string // the operation to perform: update, refresh, or destroy.
+	color       string // the colorization to pass along to each child process.
+	concurrency int    // the most stacks to operate on at once.
+
+	outputMutex sync.Mutex // serializes the output of the child processes.
+	outputs     sync.Map   // the outputs of each entry's stack, once its operation has finished.
+	results     sync.Map   // the error, if any, that each entry's operation returned.
+}
+
+// errDeploymentSkipped is the result of a stack that was skipped because a stack that it waited for failed.
+var errDeploymentSkipped = errors.New("skipped")
+
+// Run performs the operation on each stack, waiting for the stacks that each depends on (or, when destroying, that
+// depend on it) to finish first, and prints a summary of the results.
+func (r *deploymentRun) Run() error {
+	order, err := r.d.TopologicalOrder()
+	if err != nil {
+		return err
+	}
+
+	waitsFor := make(map[string][]string)
+	for _, entry := range order {
+		for _, dep := range r.d.Dependencies(entry) {
+			if r.op == "destroy" {
+				waitsFor[dep] = append(waitsFor[dep], entry)
+			} else {
+				waitsFor[entry] = append(waitsFor[entry], dep)
+			}
+		}
+	}
+
+	done := make(map[string]chan struct{})
+	for _, entry := range order {
+		done[entry] = make(chan struct{})
+	}
+	sem := make(chan struct{}, r.concurrency)
+	for _, entry := range order {
+		go func(entry string) {
+			defer close(done[entry])
+
+			for _, dep := range waitsFor[entry] {
+				<-done[dep]
+				if result, _ := r.results.Load(dep); result != nil {
+					r.results.Store(entry, errDeploymentSkipped)
+					return
+				}
+			}
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if err := r.runStack(entry); err != nil {
+				r.results.Store(entry, err)
+			}
+		}(entry)
+	}
+	for _, entry := range order {
+		<-done[entry]
+	}
+
+	failed := 0
+	fmt.Printf("\n%-24s %-24s %s\n", "NAME", "STACK", "RESULT")
+	for _, entry := range order {
+		result := "succeeded"
+		if err, _ := r.results.Load(entry); err != nil {
+			if err != errDeploymentSkipped {
+				failed++
+			}
+			result = err.(error).Error()
+		}
+		fmt.Printf("%-24s %-24s %s\n", entry, r.d.Stacks[entry].StackName(entry), result)
+	}
+	if failed > 0 {
+		return errors.Errorf("%d of %d stacks failed", failed, len(order))
+	}
+	return nil
+}
+
+// runStack performs the operation on a single entry's stack.
+func (r *deploymentRun) runStack(entry string) error {
+	ds := r.d.Stacks[entry]
+	stackName := ds.StackName(entry)
+	dir := r.d.ProjectPath(r.path, entry)
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	if r.op != "destroy" {
+		if err = r.writeConfig(exe, entry, dir); err != nil {
+			return err
+		}
+	}
+	out := &prefixWriter{prefix: fmt.Sprintf("[%s] ", entry), mutex: &r.outputMutex, w: os.Stdout}
+	defer out.Flush()
+
+	// nolint: gas
+	child := exec.Command(exe, r.op, "--stack", stackName, "--yes", "--non-interactive", "--color", r.color)
+	child.Dir = dir
+	child.Stdout = out
+	child.Stderr = out
+	if err = child.Run(); err != nil {
+		return errors.Wrapf(err, "%s failed", r.op)
+	}
+
+	if r.op != "destroy" {
+		outputs, err := r.stackOutputs(stackName)
+		if err != nil {
+			return errors.Wrap(err, "reading outputs")
+		}
+		r.outputs.Store(entry, outputs)
+	}
+	return nil
+}
+
+// writeConfig sets the configuration that an entry's stack takes from the outputs of other stacks in the stack's
+// Pulumi.<stack>.yaml file.  Outputs may hold secrets, so each value is set as a secret, with `pulumi config set
+// --secret` run in the stack's project, so that it is encrypted with the stack's own key.  The value is passed on
+// stdin, so that it never appears on a command line.
+func (r *deploymentRun) writeConfig(exe, entry, dir string) error {
+	ds := r.d.Stacks[entry]
+	stackName := ds.StackName(entry)
+	for k, ref := range ds.Config {
+		if _, err := config.ParseKey(k); err != nil {
+			return err
+		}
+		dep, name, err := workspace.OutputReference(ref)
+		if err != nil {
+			return err
+		}
+		outputs, has := r.outputs.Load(dep)
+		if !has {
+			// The stack wasn't operated on in this run, so read its outputs from its current state.
+			if outputs, err = r.stackOutputs(r.d.Stacks[dep].StackName(dep)); err != nil {
+				return errors.Wrapf(err, "reading the outputs of '%s'", dep)
+			}
+		}
+		v, has := outputs.(resource.PropertyMap)[resource.PropertyKey(name)]
+		if !has || v.IsNull() {
+			return errors.Errorf("stack '%s' has no output named '%s'", dep, name)
+		}
+
+		var out bytes.Buffer
+		// nolint: gas
+		child := exec.Command(exe, "config", "set", "--secret", "--stack", stackName, k)
+		child.Dir = dir
+		child.Stdin = strings.NewReader(stringifyOutput(v.Mappable()))
+		child.Stdout = &out
+		child.Stderr = &out
+		if err = child.Run(); err != nil {
+			return errors.Wrapf(err, "setting '%s': %s", k, strings.TrimSpace(out.String()))
+		}
+	}
+	return nil
+}
+
+// stackOutputs returns the outputs of the stack with the given name.
+func (r *deploymentRun) stackOutputs(stackName string) (resource.PropertyMap, error) {
+	stackRef, err := r.b.ParseStackReference(stackName)
+	if err != nil {
+		return nil, err
+	}
+	s, err := r.b.GetStack(commandContext(), stackRef)
+	if err != nil {
+		return nil, err
+	} else if s == nil {
+		return nil, errors.Errorf("no stack named '%s' found", stackName)
+	}
+	snap, err := s.Snapshot(commandContext())
+	if err != nil {
+		return nil, err
+	}
+	if res, _ := stack.GetRootStackResource(snap); res != nil && res.Outputs != nil {
+		return res.Outputs, nil
+	}
+	return resource.PropertyMap{}, nil
+}
+
+// prefixWriter writes each complete line written to it to an underlying writer, preceded by a prefix, so that the
+// output of several processes can be interleaved and still be told apart.
+type prefixWriter struct {
+	prefix string
+	mutex  *sync.Mutex
+	w      io.Writer
+	buf    []byte
+}
+
+func (pw *prefixWriter) Write(p []byte) (int, error) {
+	pw.buf = append(pw.buf, p...)
+	for {
+		nl := bytes.IndexByte(pw.buf, '\n')
+		if nl < 0 {
+			return len(p), nil
+		}
+		pw.writeLine(pw.buf[:nl+1])
+		pw.buf = pw.buf[nl+1:]
+	}
+}
+
+// Flush writes any incomplete line that remains.
+func (pw *prefixWriter) Flush() {
+	if len(pw.buf) > 0 {
+		pw.writeLine(append(pw.buf, '\n'))
+		pw.buf = nil
+	}
+}
+
+func (pw *prefixWriter) writeLine(line []byte) {
+	pw.mutex.Lock()
+	defer pw.mutex.Unlock()
+	_, err := fmt.Fprintf(pw.w, "%s%s", pw.prefix, line)
+	contract.IgnoreError(err)
+}
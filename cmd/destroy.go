@@ -16,12 +16,18 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/pulumi/pulumi/pkg/backend"
 	"github.com/pulumi/pulumi/pkg/engine"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/resource/graph"
+	"github.com/pulumi/pulumi/pkg/tokens"
 	"github.com/pulumi/pulumi/pkg/util/cmdutil"
 )
 
@@ -33,14 +39,29 @@ func newDestroyCmd() *cobra.Command {
 
 	// Flags for engine.UpdateOptions.
 	var analyzers []string
+	var secretPatterns []string
+	var targets []string
+	var configEnv []string
+	var configEnvSecret []string
+	var targetTypes []string
+	var targetDependents bool
 	var color colorFlag
 	var diffDisplay bool
+	var jsonDisplay bool
+	var quiet bool
+	var summaryOnly bool
+	var forceUnprotect bool
 	var parallel int
+	var retries int
+	var retryBackoff time.Duration
 	var showConfig bool
 	var showReplacementSteps bool
 	var showSames bool
 	var nonInteractive bool
+	var previewOrder bool
 	var skipPreview bool
+	var queue bool
+	var queueWait time.Duration
 	var yes bool
 
 	var cmd = &cobra.Command{
@@ -54,10 +75,38 @@ func newDestroyCmd() *cobra.Command {
 			"all of this stack's resources and associated state will be gone.\n" +
 			"\n" +
 			"Warning: although old snapshots can be used to recreate a stack, this command\n" +
-			"is generally irreversible and should be used with great care.",
+			"is generally irreversible and should be used with great care.\n" +
+			"\n" +
+			"To check a teardown before running it, pass `--preview`: the order in which resources\n" +
+			"would be deleted is shown, grouped into waves of resources that may be deleted in parallel,\n" +
+			"along with any protected resources that would block the destroy.  Nothing is deleted.\n" +
+			"\n" +
+			"To destroy only some resources, pass each one's URN with `--target`, or each of their types\n" +
+			"with `--target-type`.  Every resource that depends on a targeted resource, or is one of its\n" +
+			"children, must be destroyed too; these dependents are listed before the destroy begins, and\n" +
+			"it doesn't proceed unless they are confirmed, or `--target-dependents` is passed.",
 		Args: cmdutil.NoArgs,
 		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
-			interactive := isInteractive(nonInteractive)
+			if previewOrder {
+				s, err := requireStack(stack, false)
+				if err != nil {
+					return err
+				}
+				snap, err := s.Snapshot(commandContext())
+				if err != nil {
+					return err
+				}
+				printDeletionOrder(snap, destroyTargets(snap, targets, targetTypes))
+				return nil
+			}
+
+			if err := checkDisplayModeFlags(quiet, summaryOnly, jsonDisplay); err != nil {
+				return err
+			}
+			if jsonDisplay && !yes {
+				return errors.New("--yes must be passed in to proceed when using --json")
+			}
+			interactive := isInteractive(nonInteractive) && !jsonDisplay
 			if !interactive {
 				yes = true // auto-approve changes, since we cannot prompt.
 			}
@@ -71,6 +120,22 @@ func newDestroyCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+
+			// If only some resources are to be destroyed, make sure that their dependents are meant to be, too.
+			var urns []resource.URN
+			if len(targets) > 0 || len(targetTypes) > 0 {
+				snap, err := s.Snapshot(commandContext())
+				if err != nil {
+					return err
+				}
+				if urns = destroyTargets(snap, targets, targetTypes); len(urns) == 0 {
+					return errors.New("no resources match the given targets")
+				}
+				if err = confirmDestroyDependents(snap, urns, targetDependents, yes); err != nil {
+					return err
+				}
+			}
+
 			proj, root, err := readProject()
 			if err != nil {
 				return err
@@ -81,10 +146,18 @@ func newDestroyCmd() *cobra.Command {
 				return errors.Wrap(err, "gathering environment metadata")
 			}
 
+			if opts.ConfigEnv, err = configEnvVars(configEnv, configEnvSecret); err != nil {
+				return err
+			}
+			opts.QueueTimeout = queueTimeout(queue, queueWait)
 			opts.Engine = engine.UpdateOptions{
-				Analyzers: analyzers,
-				Parallel:  parallel,
-				Debug:     debug,
+				Analyzers:      analyzers,
+				Parallel:       parallel,
+				Retry:          retryPolicy(retries, retryBackoff),
+				Debug:          debug,
+				SecretPatterns: secretPatterns,
+				Targets:        urns,
+				ForceUnprotect: forceUnprotect,
 			}
 			opts.Display = backend.DisplayOptions{
 				Color:                color.Colorization(),
@@ -93,6 +166,9 @@ func newDestroyCmd() *cobra.Command {
 				ShowSameResources:    showSames,
 				IsInteractive:        interactive,
 				DiffDisplay:          diffDisplay,
+				JSONDisplay:          jsonDisplay,
+				Quiet:                quiet,
+				SummaryOnly:          summaryOnly,
 				Debug:                debug,
 			}
 
@@ -118,16 +194,63 @@ func newDestroyCmd() *cobra.Command {
 	cmd.PersistentFlags().StringSliceVar(
 		&analyzers, "analyzer", []string{},
 		"Run one or more analyzers as part of this update")
+	cmd.PersistentFlags().StringSliceVar(
+		&secretPatterns, "secret-pattern", []string{},
+		"Treat properties matching the given path pattern (e.g. 'password' or '**.secretKey') as secrets, "+
+			"masking their values in all output")
+	cmd.PersistentFlags().StringSliceVar(
+		&configEnv, "config-env", []string{},
+		"Use the value of an environment variable for a configuration key, given as <key>=<variable>, "+
+			"instead of the stack's; may be repeated")
+	cmd.PersistentFlags().StringSliceVar(
+		&configEnvSecret, "config-env-secret", []string{},
+		"Use the value of an environment variable as a secret for a configuration key, given as "+
+			"<key>=<variable>; may be repeated")
+	cmd.PersistentFlags().StringSliceVar(
+		&targets, "target", []string{},
+		"Restrict the destroy to the resource with the given URN; other resources are left as they are, "+
+			"except for those that depend on a deleted target.  May be repeated")
+	cmd.PersistentFlags().StringSliceVar(
+		&targetTypes, "target-type", []string{},
+		"Restrict the destroy to the resources of the given type (e.g. 'aws:s3/bucket:Bucket'), as --target does.  "+
+			"May be repeated")
+	cmd.PersistentFlags().BoolVar(
+		&targetDependents, "target-dependents", false,
+		"Destroy the resources that depend on the targeted ones without asking for confirmation")
 	cmd.PersistentFlags().VarP(
 		&color, "color", "c", "Colorize output. Choices are: always, never, raw, auto")
 	cmd.PersistentFlags().BoolVar(
 		&diffDisplay, "diff", false,
 		"Display operation as a rich diff showing the overall change")
+	cmd.PersistentFlags().BoolVar(
+		&jsonDisplay, "json", false,
+		"Write the operation's events to stdout as newline-delimited JSON, as described in pkg/apitype/events.go")
+	cmd.PersistentFlags().BoolVarP(
+		&quiet, "quiet", "q", false,
+		"Display only errors and the final summary of changes")
+	cmd.PersistentFlags().BoolVar(
+		&summaryOnly, "summary-only", false,
+		"Display one line per changed resource, with its operation and number of changed properties, "+
+			"instead of the full diff")
+	cmd.PersistentFlags().BoolVar(
+		&forceUnprotect, "force-unprotect", false,
+		"Remove the protection from any protected resources, so that they are destroyed too; without this, "+
+			"a destroy that would delete protected resources is refused")
 	cmd.PersistentFlags().BoolVar(
 		&nonInteractive, "non-interactive", false, "Disable interactive mode")
 	cmd.PersistentFlags().IntVarP(
 		&parallel, "parallel", "p", 0,
 		"Allow P resource operations to run in parallel at once (<=1 for no parallelism)")
+	cmd.PersistentFlags().IntVar(
+		&retries, "retries", 0,
+		"Retry each resource operation that fails with a transient provider error up to this many times")
+	cmd.PersistentFlags().DurationVar(
+		&retryBackoff, "retry-backoff", deploy.DefaultRetryInitialBackoff,
+		"The time to wait before the first retry of a failed resource operation; the wait doubles after each retry")
+	cmd.PersistentFlags().BoolVar(
+		&previewOrder, "preview", false,
+		"Only show the order in which resources would be deleted, and any protected resources that would block "+
+			"the destroy, without deleting anything")
 	cmd.PersistentFlags().BoolVar(
 		&showConfig, "show-config", false,
 		"Show configuration keys and variables")
@@ -140,9 +263,154 @@ func newDestroyCmd() *cobra.Command {
 	cmd.PersistentFlags().BoolVar(
 		&skipPreview, "skip-preview", false,
 		"Do not perform a preview before performing the destroy")
+	cmd.PersistentFlags().BoolVar(
+		&queue, "queue", false,
+		"If another update of the stack is in progress, wait for it to finish instead of failing")
+	cmd.PersistentFlags().DurationVar(
+		&queueWait, "queue-timeout", defaultQueueTimeout,
+		"How long to wait for another update of the stack to finish when --queue is passed")
 	cmd.PersistentFlags().BoolVarP(
 		&yes, "yes", "y", false,
 		"Automatically approve and perform the destroy after previewing it")
 
 	return cmd
 }
+
+// printDeletionOrder prints the order in which a destroy would delete the resources in the given snapshot, grouped
+// into waves of resources that don't depend on one another, along with the protected resources that would block it.
+func printDeletionOrder(snap *deploy.Snapshot, targets []resource.URN) {
+	if snap == nil {
+		fmt.Printf("The stack has no resources to delete.\n")
+		return
+	}
+
+	resources := destroyClosure(snap, targets)
+	if len(resources) == 0 {
+		fmt.Printf("The stack has no resources to delete.\n")
+		return
+	}
+
+	waves := graph.DeletionWaves(resources)
+	blocked := graph.BlockedByProtection(resources)
+	fmt.Printf("%d resource(s) would be deleted in %d wave(s).  The resources in each wave may be deleted in "+
+		"parallel, once the previous wave has finished:\n", len(resources), len(waves))
+	for i, wave := range waves {
+		fmt.Printf("\nWave %d:\n", i+1)
+		for _, res := range wave {
+			var note string
+			switch {
+			case res.Protect:
+				note = " [protected]"
+			case blocked[res.URN]:
+				note = " [blocked]"
+			}
+			fmt.Printf("    - %s%s\n", res.URN, note)
+		}
+	}
+
+	var protected []resource.URN
+	for _, res := range resources {
+		if res.Protect {
+			protected = append(protected, res.URN)
+		}
+	}
+	if len(protected) > 0 {
+		fmt.Printf("\nThe destroy would fail: %d protected resource(s) cannot be deleted, which in turn blocks "+
+			"%d other resource(s) that they depend on or are parented to:\n", len(protected), len(blocked)-len(protected))
+		for _, urn := range protected {
+			fmt.Printf("    - %s\n", urn)
+		}
+		fmt.Printf("Unprotect these resources first, with `pulumi state unprotect`, or pass `--force-unprotect` to " +
+			"destroy them anyway.\n")
+	}
+}
+
+// destroyTargets returns the URNs of the resources in the given snapshot that a destroy targets, either by URN or by
+// type.  URNs are returned as given, whether or not they exist, so that the engine can warn about those that don't.
+func destroyTargets(snap *deploy.Snapshot, targets []string, targetTypes []string) []resource.URN {
+	urns := targetURNs(targets)
+	if len(targetTypes) == 0 || snap == nil {
+		return urns
+	}
+
+	isType := make(map[tokens.Type]bool)
+	for _, t := range targetTypes {
+		isType[tokens.Type(t)] = true
+	}
+	for _, res := range snap.Resources {
+		if isType[res.Type] && !res.Delete {
+			urns = append(urns, res.URN)
+		}
+	}
+	return urns
+}
+
+// destroyClosure returns the resources in the given snapshot that a destroy would delete, in snapshot order.  Just as
+// the engine does, each targeted resource is deleted along with anything that depends on it or is one of its children;
+// without targets, everything is deleted.
+func destroyClosure(snap *deploy.Snapshot, targets []resource.URN) []*resource.State {
+	if snap == nil {
+		return nil
+	}
+
+	isTargeted := make(map[resource.URN]bool)
+	for _, urn := range targets {
+		isTargeted[urn] = true
+	}
+	condemned := make(map[resource.URN]bool)
+	var resources []*resource.State
+	for _, res := range snap.Resources {
+		required := condemned[res.Parent]
+		for _, dep := range res.Dependencies {
+			required = required || condemned[dep]
+		}
+		if len(targets) == 0 || isTargeted[res.URN] || (required && !res.Delete) {
+			condemned[res.URN] = true
+			resources = append(resources, res)
+		}
+	}
+	return resources
+}
+
+// confirmDestroyDependents lists the resources that a targeted destroy deletes and, if any of them weren't targeted
+// themselves, requires confirmation that these dependents are to be destroyed too, unless targetDependents is true.
+func confirmDestroyDependents(snap *deploy.Snapshot, targets []resource.URN, targetDependents bool, yes bool) error {
+	isTargeted := make(map[resource.URN]bool)
+	for _, urn := range targets {
+		isTargeted[urn] = true
+	}
+	var targeted, dependents []resource.URN
+	for _, res := range destroyClosure(snap, targets) {
+		if isTargeted[res.URN] {
+			targeted = append(targeted, res.URN)
+		} else {
+			dependents = append(dependents, res.URN)
+		}
+	}
+
+	fmt.Printf("The destroy targets %d resource(s):\n", len(targeted))
+	for _, urn := range targeted {
+		fmt.Printf("    - %s\n", urn)
+	}
+	if len(dependents) == 0 {
+		fmt.Println()
+		return nil
+	}
+	fmt.Printf("These %d resource(s), which depend on the targets or are their children, will also be destroyed:\n",
+		len(dependents))
+	for _, urn := range dependents {
+		fmt.Printf("    - %s\n", urn)
+	}
+	fmt.Println()
+
+	if targetDependents {
+		return nil
+	}
+	if yes || !cmdutil.Interactive() {
+		return errors.New("the targeted resources have dependents; pass --target-dependents to destroy them too")
+	}
+	if !confirmPrompt("", "yes") {
+		return errors.New("confirmation declined")
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/backend"
+	"github.com/pulumi/pulumi/pkg/engine"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+)
+
+func newHistoryCmd() *cobra.Command {
+	var stack string
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Args:  cmdutil.NoArgs,
+		Short: "Show the history of a stack's updates",
+		Long: "Show the history of a stack's updates.\n" +
+			"\n" +
+			"Each update is listed, most recent first, along with its version number, when it ran,\n" +
+			"how it ended, and how many resources it changed.  Pass two version numbers to\n" +
+			"`pulumi history diff` to see what changed in the stack between them, or one to\n" +
+			"`pulumi history events` to replay what the engine did during that update.",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			s, err := requireStack(stack, false)
+			if err != nil {
+				return err
+			}
+
+			updates, err := s.Backend().GetHistory(commandContext(), s.Name())
+			if err != nil {
+				return errors.Wrap(err, "getting history")
+			}
+			if len(updates) == 0 {
+				fmt.Printf("Stack '%s' has no updates.\n", s.Name())
+				return nil
+			}
+
+			formatDirective := "%-8s %-8s %-12s %-16s %-10s %-24s %s\n"
+			fmt.Printf(formatDirective, "VERSION", "KIND", "RESULT", "STARTED", "DURATION", "CHANGES", "MESSAGE")
+			for i, update := range updates {
+				if limit > 0 && i == limit {
+					break
+				}
+
+				// Updates are numbered from the oldest, which comes last.
+				version := strconv.Itoa(len(updates) - i)
+				started := humanize.Time(time.Unix(update.StartTime, 0))
+				duration := "n/a"
+				if update.EndTime >= update.StartTime && update.Result != backend.InProgressResult {
+					duration = (time.Duration(update.EndTime-update.StartTime) * time.Second).String()
+				}
+				message := strings.SplitN(update.Message, "\n", 2)[0]
+
+				fmt.Printf(formatDirective, version, update.Kind, update.Result, started, duration,
+					formatResourceChanges(update.ResourceChanges), message)
+			}
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stack, "stack", "s", "",
+		"Choose a stack other than the currently selected one")
+	cmd.PersistentFlags().IntVarP(
+		&limit, "limit", "n", 0,
+		"Show only the given number of most recent updates")
+
+	cmd.AddCommand(newHistoryDiffCmd())
+	cmd.AddCommand(newHistoryEventsCmd())
+
+	return cmd
+}
+
+func newHistoryDiffCmd() *cobra.Command {
+	var stack string
+	var debug bool
+	var secretPatterns []string
+	var color colorFlag
+	var diffFormat diffFormatFlag
+	var showSames bool
+
+	cmd := &cobra.Command{
+		Use:   "diff <version1> <version2>",
+		Args:  cmdutil.ExactArgs(2),
+		Short: "Show the changes to a stack's resources between two updates",
+		Long: "Show the changes to a stack's resources between two updates.\n" +
+			"\n" +
+			"The resources and properties that differ between the states saved by the two updates,\n" +
+			"given by their version numbers as listed by `pulumi history`, are displayed just as\n" +
+			"they would be during a preview.  For example, to see what update 12 changed:\n" +
+			"\n" +
+			"    pulumi history diff 11 12",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			var versions []int
+			for _, arg := range args {
+				version, err := strconv.Atoi(arg)
+				if err != nil || version < 1 {
+					return errors.Errorf("'%s' is not a valid version number", arg)
+				}
+				versions = append(versions, version)
+			}
+
+			s, err := requireStack(stack, false)
+			if err != nil {
+				return err
+			}
+			old, err := loadStackVersion(s, versions[0])
+			if err != nil {
+				return err
+			}
+			new, err := loadStackVersion(s, versions[1])
+			if err != nil {
+				return err
+			}
+
+			displaySnapshotDiff(old, new, backend.DisplayOptions{
+				Color:             color.Colorization(),
+				ShowSameResources: showSames,
+				DiffDisplay:       true,
+				DiffFormat:        diffFormat.DiffFormat(),
+				Debug:             debug,
+			}, secretPatterns)
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stack, "stack", "s", "",
+		"Choose a stack other than the currently selected one")
+	cmd.PersistentFlags().BoolVarP(
+		&debug, "debug", "d", false,
+		"Print detailed debugging output")
+	cmd.PersistentFlags().StringSliceVar(
+		&secretPatterns, "secret-pattern", []string{},
+		"Treat properties matching the given path pattern (e.g. 'password' or '**.secretKey') as secrets, "+
+			"masking their values in all output")
+	cmd.PersistentFlags().VarP(
+		&color, "color", "c", "Colorize output. Choices are: always, never, raw, auto")
+	cmd.PersistentFlags().Var(
+		&diffFormat, "diff-format",
+		"The format in which to display the diff. Choices are: default, patch")
+	cmd.PersistentFlags().BoolVar(
+		&showSames, "show-sames", false,
+		"Show resources that are the same in both updates, in addition to those that changed")
+
+	return cmd
+}
+
+// formatResourceChanges summarizes the number of resources affected by each kind of operation, e.g. "+2 ~1 -1".
+func formatResourceChanges(changes engine.ResourceChanges) string {
+	var parts []string
+	for _, op := range deploy.StepOps {
+		if op == deploy.OpSame {
+			continue
+		}
+		if count := changes[op]; count > 0 {
+			parts = append(parts, fmt.Sprintf("%s%d", strings.TrimSpace(op.RawPrefix()), count))
+		}
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,158 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/backend/local"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+	"github.com/pulumi/pulumi/pkg/util/contract"
+)
+
+func newHistoryEventsCmd() *cobra.Command {
+	var stack string
+	var jsonOut bool
+
+	cmd := &cobra.Command{
+		Use:   "events <version>",
+		Args:  cmdutil.ExactArgs(1),
+		Short: "Replay the events that the engine emitted during an update",
+		Long: "Replay the events that the engine emitted during an update.\n" +
+			"\n" +
+			"Backends that keep their state themselves, such as a local directory or an object store,\n" +
+			"record every event of each update alongside its entry in the stack's history: the steps it\n" +
+			"took, the changes it made to each resource's properties, and its diagnostics.  Given the\n" +
+			"version number of an update, as listed by `pulumi history`, this command shows what that\n" +
+			"update did.  Pass `--json` to write the events just as `pulumi update --json` did.",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			version, err := strconv.Atoi(args[0])
+			if err != nil || version < 1 {
+				return errors.Errorf("'%s' is not a valid version number", args[0])
+			}
+
+			s, err := requireStack(stack, false)
+			if err != nil {
+				return err
+			}
+			b, ok := s.Backend().(local.Backend)
+			if !ok {
+				return errors.Errorf("%s doesn't record the events of updates; view them in its console instead",
+					s.Backend().Name())
+			}
+
+			events, err := b.GetUpdateEvents(commandContext(), s.Name(), version)
+			if err != nil {
+				return err
+			}
+
+			if jsonOut {
+				enc := json.NewEncoder(os.Stdout)
+				for _, event := range events {
+					contract.IgnoreError(enc.Encode(event))
+				}
+				return nil
+			}
+			for _, event := range events {
+				printEngineEvent(event)
+			}
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stack, "stack", "s", "",
+		"Choose a stack other than the currently selected one")
+	cmd.PersistentFlags().BoolVarP(
+		&jsonOut, "json", "j", false,
+		"Write each event as a line of JSON")
+
+	return cmd
+}
+
+// printEngineEvent prints a recorded engine event as a line of text, prefixed by the time at which it was emitted.
+func printEngineEvent(event apitype.EngineEvent) {
+	stamp := time.Unix(event.Timestamp, 0).Format("15:04:05")
+	switch {
+	case event.StdoutEvent != nil:
+		for _, line := range strings.Split(strings.TrimRight(event.StdoutEvent.Message, "\n"), "\n") {
+			fmt.Printf("%s  %s\n", stamp, line)
+		}
+	case event.DiagnosticEvent != nil:
+		d := event.DiagnosticEvent
+		message := strings.TrimRight(d.Message, "\n")
+		if d.URN != "" {
+			fmt.Printf("%s  %s: %s: %s\n", stamp, d.Severity, d.URN, message)
+		} else {
+			fmt.Printf("%s  %s: %s\n", stamp, d.Severity, message)
+		}
+	case event.PreludeEvent != nil:
+		var keys []string
+		for k := range event.PreludeEvent.Config {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fmt.Printf("%s  started with %d configuration value(s)\n", stamp, len(keys))
+		for _, k := range keys {
+			fmt.Printf("%s      %s: %s\n", stamp, k, event.PreludeEvent.Config[k])
+		}
+	case event.ResourcePreEvent != nil:
+		md := event.ResourcePreEvent.Metadata
+		fmt.Printf("%s  %s %s\n", stamp, md.Op, md.URN)
+		for _, d := range md.DetailedDiff {
+			fmt.Printf("%s      %s %s: %s => %s\n", stamp, d.Kind, d.Path, formatEventValue(d.Old),
+				formatEventValue(d.New))
+		}
+	case event.ResOutputsEvent != nil:
+		md := event.ResOutputsEvent.Metadata
+		fmt.Printf("%s  %s %s done\n", stamp, md.Op, md.URN)
+	case event.ResOpFailedEvent != nil:
+		md := event.ResOpFailedEvent.Metadata
+		fmt.Printf("%s  %s %s failed\n", stamp, md.Op, md.URN)
+	case event.SummaryEvent != nil:
+		var ops []string
+		for op, count := range event.SummaryEvent.ResourceChanges {
+			ops = append(ops, fmt.Sprintf("%s=%d", op, count))
+		}
+		sort.Strings(ops)
+		fmt.Printf("%s  finished in %s: %s\n", stamp,
+			time.Duration(event.SummaryEvent.DurationSeconds)*time.Second, strings.Join(ops, " "))
+		if event.SummaryEvent.MaybeCorrupt {
+			fmt.Printf("%s  some resources may have been left in an unknown state\n", stamp)
+		}
+	}
+}
+
+// formatEventValue formats a property value recorded in an event compactly, as JSON.
+func formatEventValue(v interface{}) string {
+	if v == nil {
+		return "<none>"
+	}
+	byts, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(byts)
+}
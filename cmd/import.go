@@ -0,0 +1,194 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/backend"
+	"github.com/pulumi/pulumi/pkg/engine"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/tokens"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+)
+
+// importManifest is the format of a file listing the resources to import in bulk.
+type importManifest struct {
+	Resources []engine.ImportSpec `json:"resources"`
+}
+
+func newImportCmd() *cobra.Command {
+	var debug bool
+	var message string
+	var stack string
+	var file string
+	var color colorFlag
+	var nonInteractive bool
+	var skipPreview bool
+	var yes bool
+
+	var cmd = &cobra.Command{
+		Use:   "import [type name id]",
+		Args:  cmdutil.MaximumNArgs(3),
+		Short: "Import existing resources into a stack",
+		Long: "Import existing resources into a stack.\n" +
+			"\n" +
+			"This command brings resources that were created outside of Pulumi under the management of a\n" +
+			"stack.  Each resource's current state is read from its provider and written into the stack's\n" +
+			"checkpoint, without running the stack's program.  Afterwards, sample code declaring the imported\n" +
+			"resources is printed; add it to the program so that subsequent updates manage the resources,\n" +
+			"rather than deleting them.\n" +
+			"\n" +
+			"A single resource is imported by giving its type, the name to give it, and its provider ID:\n" +
+			"\n" +
+			"    pulumi import aws:s3/bucket:Bucket my-bucket my-bucket-1234\n" +
+			"\n" +
+			"Many resources may be imported at once with `--file`, which names a JSON file of the form:\n" +
+			"\n" +
+			"    {\"resources\": [{\"type\": \"aws:s3/bucket:Bucket\", \"name\": \"my-bucket\", \"id\": \"my-bucket-1234\"}]}",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			var imports []engine.ImportSpec
+			switch {
+			case file != "" && len(args) > 0:
+				return errors.New("a resource may not be given alongside --file")
+			case file != "":
+				manifest, err := readImportManifest(file)
+				if err != nil {
+					return err
+				}
+				imports = manifest.Resources
+			case len(args) == 3:
+				imports = []engine.ImportSpec{{
+					Type: tokens.Type(args[0]),
+					Name: tokens.QName(args[1]),
+					ID:   resource.ID(args[2]),
+				}}
+			default:
+				return errors.New("either a resource's type, name, and ID, or --file, must be given")
+			}
+			if len(imports) == 0 {
+				return errors.New("there are no resources to import")
+			}
+
+			interactive := isInteractive(nonInteractive)
+			if !interactive {
+				yes = true // auto-approve changes, since we cannot prompt.
+			}
+
+			opts, err := updateFlagsToOptions(interactive, skipPreview, yes)
+			if err != nil {
+				return err
+			}
+
+			s, err := requireStack(stack, true)
+			if err != nil {
+				return err
+			}
+
+			proj, root, err := readProject()
+			if err != nil {
+				return err
+			}
+
+			m, err := getUpdateMetadata(message, root)
+			if err != nil {
+				return errors.Wrap(err, "gathering environment metadata")
+			}
+
+			opts.Engine = engine.UpdateOptions{
+				Debug:   debug,
+				Imports: imports,
+			}
+			opts.Display = backend.DisplayOptions{
+				Color:         color.Colorization(),
+				IsInteractive: interactive,
+				Debug:         debug,
+			}
+
+			_, err = s.Update(commandContext(), proj, root, m, opts, cancellationScopes)
+			switch {
+			case err == context.Canceled:
+				return errors.New("import cancelled")
+			case err != nil:
+				return err
+			}
+
+			// Now print code that declares the imported resources, using the states just written to the checkpoint.
+			snap, err := s.Snapshot(commandContext())
+			if err != nil {
+				return err
+			}
+			var imported []*resource.State
+			for _, res := range snap.Resources {
+				for _, imp := range imports {
+					if res.ID == imp.ID && res.Type == imp.Type && res.URN.Name() == imp.Name && !res.Delete {
+						imported = append(imported, res)
+					}
+				}
+			}
+			code, err := engine.GenerateImportCode(proj.Runtime, imported)
+			if err != nil {
+				return errors.Wrap(err, "the resources were imported, but no code could be generated for them")
+			}
+			fmt.Printf("\nPlease add the following code to your program to manage the imported resources:\n\n%s", code)
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().BoolVarP(
+		&debug, "debug", "d", false,
+		"Print detailed debugging output during resource operations")
+	cmd.PersistentFlags().StringVarP(
+		&stack, "stack", "s", "",
+		"Choose a stack other than the currently selected one")
+	cmd.PersistentFlags().StringVarP(
+		&message, "message", "m", "",
+		"Optional message to associate with the import operation")
+	cmd.PersistentFlags().StringVarP(
+		&file, "file", "f", "",
+		"Import the resources listed in the given JSON file, rather than a single resource")
+	cmd.PersistentFlags().VarP(
+		&color, "color", "c", "Colorize output. Choices are: always, never, raw, auto")
+	cmd.PersistentFlags().BoolVar(
+		&nonInteractive, "non-interactive", false, "Disable interactive mode")
+	cmd.PersistentFlags().BoolVar(
+		&skipPreview, "skip-preview", false,
+		"Do not perform a preview before performing the import")
+	cmd.PersistentFlags().BoolVarP(
+		&yes, "yes", "y", false,
+		"Automatically approve and perform the import after previewing it")
+
+	return cmd
+}
+
+// readImportManifest reads a list of resources to import from the given JSON file.
+func readImportManifest(file string) (*importManifest, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read import file")
+	}
+
+	var manifest importManifest
+	if err = json.Unmarshal(b, &manifest); err != nil {
+		return nil, errors.Wrapf(err, "could not read resources to import from '%s'", file)
+	}
+	return &manifest, nil
+}
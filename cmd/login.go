@@ -17,6 +17,7 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/pulumi/pulumi/pkg/backend"
@@ -27,17 +28,69 @@ import (
 
 func newLoginCmd() *cobra.Command {
 	var cloudURL string
+	var readOnly bool
+	var mirrors []string
+	var mirrorFailure string
 	cmd := &cobra.Command{
-		Use:   "login",
+		Use:   "login [url]",
 		Short: "Log into the Pulumi Cloud",
-		Long:  "Log into the Pulumi Cloud.  You can script by using PULUMI_ACCESS_TOKEN environment variable.",
-		Args:  cmdutil.NoArgs,
+		Long: "Log into the Pulumi Cloud.  You can script by using PULUMI_ACCESS_TOKEN environment variable.\n" +
+			"\n" +
+			"To keep stacks' state in an S3 bucket, or another S3-compatible object store, instead of the\n" +
+			"Pulumi Service, log into a URL of the form `s3://bucket/prefix`.  Its query may give the\n" +
+			"bucket's `region`; an `endpoint`, such as a MinIO server's, with `forcePathStyle=true` and\n" +
+			"`disableSSL=true` if the store needs them; and the server-side encryption of the state,\n" +
+			"`sse=AES256` or `sse=aws:kms` with an optional `kmsKeyId`.  AWS credentials are found as they\n" +
+			"are by the AWS CLI.  For example:\n" +
+			"\n" +
+			"    pulumi login 's3://my-bucket/pulumi?region=us-west-2&sse=aws:kms'\n" +
+			"\n" +
+			"To keep it in an Azure Blob Storage container, log into `azblob://container/prefix`.  The\n" +
+			"storage account is given by the URL's `account` parameter or by AZURE_STORAGE_ACCOUNT, or an\n" +
+			"`endpoint` may be given instead.  The container is accessed with the shared access signature\n" +
+			"in AZURE_STORAGE_SAS_TOKEN if it's set, with the account key in AZURE_STORAGE_KEY if that's\n" +
+			"set, and otherwise with the machine's managed identity, or the user-assigned one whose\n" +
+			"`clientId` is given.  Stacks are locked with blob leases, so the lock of an update whose\n" +
+			"process dies expires on its own.\n" +
+			"\n" +
+			"To keep it in a PostgreSQL database, log into `postgres://user@host:port/database`, with any\n" +
+			"of the lib/pq driver's parameters, such as `sslmode`, and optionally the `table` to keep it in\n" +
+			"(by default, pulumi_state).  Give the password with PGPASSWORD rather than in the URL.  Stacks\n" +
+			"are locked with advisory locks, which are released if the update's connection is lost.\n" +
+			"\n" +
+			"Any of these may be logged into with `--read-only`, in which case the CLI refuses to change the\n" +
+			"state of its stacks until it's logged into again without it: stacks can be previewed, exported,\n" +
+			"queried, and graphed, but not updated, refreshed, destroyed, or edited.  Setting PULUMI_READ_ONLY\n" +
+			"to true has the same effect on whatever backend is logged into.\n" +
+			"\n" +
+			"To keep a copy of every stack's state elsewhere, such as in an off-site archive, give the URL of\n" +
+			"another backend with `--mirror`, as many times as there are copies to keep.  Each checkpoint\n" +
+			"written to this backend is then written to the stack of the same name in each mirror, which\n" +
+			"must have been logged into before.  If a mirror can't be written, a warning is shown, unless\n" +
+			"`--mirror-failure=fail` is given, in which case the operation fails.  For example:\n" +
+			"\n" +
+			"    pulumi login --mirror 's3://dr-bucket/pulumi?region=us-east-2'\n" +
+			"\n" +
+			"Logging in again without `--mirror` stops mirroring.",
+		Args: cmdutil.MaximumNArgs(1),
 		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				if cloudURL != "" {
+					return errors.New("only one of --cloud-url or a URL argument may be given")
+				}
+				cloudURL = args[0]
+			}
+
 			var b backend.Backend
 			var err error
 
-			if hasDebugCommands() && local.IsLocalBackendURL(cloudURL) {
-				b, err = local.Login(cmdutil.Diag(), cloudURL)
+			// Backends that keep their state in remote storage are supported; those that keep it on the local disk
+			// are still only available for debugging.
+			if local.IsRemoteStorageBackendURL(cloudURL) || (hasDebugCommands() && local.IsLocalBackendURL(cloudURL)) {
+				b, err = local.Login(cmdutil.Diag(), cloudURL, readOnly)
+			} else if readOnly {
+				return errors.New("--read-only is only supported by backends that keep their state themselves; " +
+					"grant read-only access to stacks in the Pulumi Service instead")
 			} else {
 				b, err = cloud.Login(commandContext(), cmdutil.Diag(), cloudURL)
 			}
@@ -45,11 +98,27 @@ func newLoginCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if err = storeBackendMirrors(mirrors, mirrorFailure); err != nil {
+				return err
+			}
+			for _, m := range mirrors {
+				fmt.Printf("Mirroring the state of stacks to %s\n", m)
+			}
 
+			if lb, ok := b.(local.Backend); ok && lb.ReadOnly() {
+				fmt.Printf("Logged into %s (read-only)\n", b.Name())
+				return nil
+			}
 			fmt.Printf("Logged into %s\n", b.Name())
 			return nil
 		}),
 	}
 	cmd.PersistentFlags().StringVarP(&cloudURL, "cloud-url", "c", "", "A cloud URL to log into")
+	cmd.PersistentFlags().BoolVar(&readOnly, "read-only", false,
+		"Refuse to change the state of stacks in this backend until logged into again without this flag")
+	cmd.PersistentFlags().StringSliceVar(&mirrors, "mirror", nil,
+		"The URL of another backend to which to copy every checkpoint of this backend's stacks; may be repeated")
+	cmd.PersistentFlags().StringVar(&mirrorFailure, "mirror-failure", string(backend.WarnOnMirrorFailure),
+		"What to do when a mirror can't be written: `warn` and carry on, or `fail` the operation")
 	return cmd
 }
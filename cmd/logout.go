@@ -26,12 +26,24 @@ import (
 
 func newLogoutCmd() *cobra.Command {
 	var cloudURL string
+	var forgetPassphrase bool
 	cmd := &cobra.Command{
 		Use:   "logout",
 		Short: "Log out of the Pulumi Cloud",
-		Long:  "Log out of the Pulumi Cloud.  Deletes stored credentials on the local machine.",
-		Args:  cmdutil.NoArgs,
+		Long: "Log out of the Pulumi Cloud.  Deletes stored credentials on the local machine.\n" +
+			"\n" +
+			"If PULUMI_PASSPHRASE_CACHE_TIMEOUT is set to a duration, such as `15m`, the keys unlocked by\n" +
+			"stacks' passphrases are held in memory by a background agent for that long, so that each\n" +
+			"passphrase need not be entered for every command.  With --forget-passphrase, the agent\n" +
+			"forgets them and exits.",
+		Args: cmdutil.NoArgs,
 		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			if forgetPassphrase {
+				if err := workspace.ForgetCachedPassphraseKeys(); err != nil {
+					return errors.Wrap(err, "could not forget cached passphrases")
+				}
+			}
+
 			if cloudURL == "" {
 				creds, err := workspace.GetStoredCredentials()
 				if err != nil {
@@ -42,7 +54,11 @@ func newLogoutCmd() *cobra.Command {
 			}
 
 			if local.IsLocalBackendURL(cloudURL) {
-				return local.New(cmdutil.Diag(), cloudURL).Logout()
+				b, err := local.New(cmdutil.Diag(), cloudURL)
+				if err != nil {
+					return err
+				}
+				return b.Logout()
 			}
 
 			b, err := cloud.New(cmdutil.Diag(), cloudURL)
@@ -54,5 +70,7 @@ func newLogoutCmd() *cobra.Command {
 	}
 	cmd.PersistentFlags().StringVarP(&cloudURL, "cloud-url", "c", "",
 		"A cloud URL to log out of (defaults to current cloud)")
+	cmd.PersistentFlags().BoolVar(&forgetPassphrase, "forget-passphrase", false,
+		"Forget the cached keys unlocked by passphrases, so that they must be entered again")
 	return cmd
 }
@@ -37,12 +37,20 @@ func newLogsCmd() *cobra.Command {
 	var stack string
 	var follow bool
 	var since string
+	var until string
 	var resource string
 
 	logsCmd := &cobra.Command{
 		Use:   "logs",
 		Short: "Show aggregated logs for a stack",
-		Args:  cmdutil.NoArgs,
+		Long: "Show aggregated logs for a stack.\n" +
+			"\n" +
+			"This command collects the runtime logs written by the stack's deployed resources, such as\n" +
+			"the logs of its serverless functions and containers, and shows them in time order.  Each\n" +
+			"line is prefixed with the URN of the resource that wrote it.  The logs can be restricted to\n" +
+			"a time range with `--since` and `--until`, and to one resource (and its children) with\n" +
+			"`--resource`.",
+		Args: cmdutil.NoArgs,
 		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
 			s, err := requireStack(stack, false)
 			if err != nil {
@@ -53,6 +61,12 @@ func newLogsCmd() *cobra.Command {
 			if err != nil {
 				return errors.Wrapf(err, "failed to parse argument to '--since' as duration or timestamp")
 			}
+			var endTime *time.Time
+			if until != "" {
+				if endTime, err = parseSince(until, time.Now()); err != nil {
+					return errors.Wrapf(err, "failed to parse argument to '--until' as duration or timestamp")
+				}
+			}
 			var resourceFilter *operations.ResourceFilter
 			if resource != "" {
 				var rf = operations.ResourceFilter(resource)
@@ -75,6 +89,7 @@ func newLogsCmd() *cobra.Command {
 			for {
 				logs, err := s.GetLogs(commandContext(), operations.LogQuery{
 					StartTime:      startTime,
+					EndTime:        endTime,
 					ResourceFilter: resourceFilter,
 				})
 				if err != nil {
@@ -83,13 +98,13 @@ func newLogsCmd() *cobra.Command {
 
 				for _, logEntry := range logs {
 					if _, shownAlready := shown[logEntry]; !shownAlready {
-						eventTime := time.Unix(0, logEntry.Timestamp*1000000)
-						fmt.Printf("%30.30s[%30.30s] %v\n", eventTime.Format(timeFormat), logEntry.ID, logEntry.Message)
+						fmt.Println(formatLogEntry(logEntry))
 						shown[logEntry] = true
 					}
 				}
 
-				if !follow {
+				// Once the end of the requested range has passed, there are no more logs to follow.
+				if !follow || (endTime != nil && time.Now().After(*endTime)) {
 					return nil
 				}
 
@@ -108,6 +123,10 @@ func newLogsCmd() *cobra.Command {
 		&since, "since", "1h",
 		"Only return logs newer than a relative duration ('5s', '2m', '3h') or absolute timestamp.  "+
 			"Defaults to returning the last 1 hour of logs.")
+	logsCmd.PersistentFlags().StringVar(
+		&until, "until", "",
+		"Only return logs older than a relative duration ('5s', '2m', '3h') or absolute timestamp.  "+
+			"Defaults to returning logs up to the present.")
 	logsCmd.PersistentFlags().StringVarP(
 		&resource, "resource", "r", "",
 		"Only return logs for the requested resource ('name', 'type::name' or full URN).  Defaults to returning all logs.")
@@ -115,6 +134,16 @@ func newLogsCmd() *cobra.Command {
 	return logsCmd
 }
 
+// formatLogEntry renders a log entry as a line of output, prefixed with the URN of the resource that wrote it.
+func formatLogEntry(logEntry operations.LogEntry) string {
+	eventTime := time.Unix(0, logEntry.Timestamp*1000000)
+	line := fmt.Sprintf("%30.30s[%30.30s] %v", eventTime.Format(timeFormat), logEntry.ID, logEntry.Message)
+	if logEntry.URN != "" {
+		line = colors.ColorizeText(colors.SpecUnimportant+string(logEntry.URN)+colors.Reset) + " " + line
+	}
+	return line
+}
+
 func parseSince(since string, reference time.Time) (*time.Time, error) {
 	startTimestamp, err := mobytime.GetTimestamp(since, reference)
 	if err != nil {
@@ -21,6 +21,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
 
@@ -34,6 +35,7 @@ import (
 	"github.com/pulumi/pulumi/pkg/diag/colors"
 
 	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+	"github.com/pulumi/pulumi/pkg/util/contract"
 	"github.com/spf13/cobra"
 
 	survey "gopkg.in/AlecAivazis/survey.v1"
@@ -50,12 +52,29 @@ func newNewCmd() *cobra.Command {
 	var yes bool
 	var offline bool
 	var generateOnly bool
+	var runHooks bool
 	var dir string
 
 	cmd := &cobra.Command{
 		Use:   "new [template]",
 		Short: "Create a new Pulumi project",
-		Args:  cmdutil.MaximumNArgs(1),
+		Long: "Create a new Pulumi project.\n" +
+			"\n" +
+			"The template may be the name of one of Pulumi's templates or, to use a template published\n" +
+			"elsewhere, the URL of a git repository, written `<repository>[//<subdirectory>][#<ref>]`,\n" +
+			"to use the template in the given subdirectory of the repository, at the given branch, tag,\n" +
+			"or commit.  For example:\n" +
+			"\n" +
+			"    pulumi new https://github.com/acme/templates.git//aws-typescript#v1.0.0\n" +
+			"\n" +
+			"A template's .pulumi.template.yaml manifest may declare the config values to prompt for,\n" +
+			"with a description, a default, whether the value is secret or required, and a pattern\n" +
+			"that it must match; and, as `postScaffold`, commands to run once the project has been created.\n" +
+			"\n" +
+			"A template's commands are shown before they are run, and are only run once you confirm them,\n" +
+			"or if you pass --run-hooks.  They are never run with --generate-only, and --yes alone does not\n" +
+			"run them.",
+		Args: cmdutil.MaximumNArgs(1),
 		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
 			var err error
 
@@ -102,40 +121,28 @@ func newNewCmd() *cobra.Command {
 			// Get the selected template.
 			var templateName string
 			if len(args) > 0 {
-				templateName = strings.ToLower(args[0])
+				templateName = args[0]
+				if !workspace.IsTemplateURL(templateName) {
+					templateName = strings.ToLower(templateName)
+				}
 			} else {
 				if templateName, err = chooseTemplate(releases, offline); err != nil {
 					return err
 				}
 			}
 
-			// Download and install the template to the local template cache.
-			if !offline {
-				var tarball io.ReadCloser
-				source := releases.CloudURL()
-				if tarball, err = releases.DownloadTemplate(commandContext(), templateName, false); err != nil {
-					message := ""
-					// If the local template is available locally, provide a nicer error message.
-					if localTemplates, localErr := workspace.ListLocalTemplates(); localErr == nil && len(localTemplates) > 0 {
-						_, m := templateArrayToStringArrayAndMap(localTemplates)
-						if _, ok := m[templateName]; ok {
-							message = fmt.Sprintf(
-								"; rerun the command and pass --offline to use locally cached template '%s'",
-								templateName)
-						}
-					}
-
-					return errors.Wrapf(err, "downloading template '%s' from %s%s", templateName, source, message)
-				}
-				if err = workspace.InstallTemplate(templateName, tarball); err != nil {
-					return errors.Wrapf(err, "installing template '%s' from %s", templateName, source)
-				}
-			}
-
-			// Load the local template.
 			var template workspace.Template
-			if template, err = workspace.LoadLocalTemplate(templateName); err != nil {
-				return errors.Wrapf(err, "template '%s' not found", templateName)
+			if workspace.IsTemplateURL(templateName) {
+				// Templates from git repositories are retrieved afresh each time, rather than cached.
+				var tempDir string
+				if template, tempDir, err = workspace.RetrieveGitTemplate(templateName); err != nil {
+					return err
+				}
+				defer func() {
+					contract.IgnoreError(os.RemoveAll(tempDir))
+				}()
+			} else if template, err = retrieveTemplate(releases, templateName, offline); err != nil {
+				return err
 			}
 
 			// Do a dry run, if we're not forcing files to be overwritten.
@@ -211,9 +218,27 @@ func newNewCmd() *cobra.Command {
 					sort.Sort(keys)
 
 					c := make(config.Map)
+					var encrypter config.Encrypter
 					for _, k := range keys {
-						value := promptForValue(yes, k.String(), template.Config[k], nil)
-						c[k] = config.NewValue(value)
+						value, err := promptForConfigValue(yes, k, template.Config[k])
+						if err != nil {
+							return err
+						}
+						if !template.Config[k].Secret {
+							c[k] = config.NewValue(value)
+							continue
+						}
+
+						if encrypter == nil {
+							if encrypter, err = backend.GetStackCrypter(stack); err != nil {
+								return err
+							}
+						}
+						enc, err := config.EncrypterForKey(encrypter, k).EncryptValue(value)
+						if err != nil {
+							return err
+						}
+						c[k] = config.NewSecureValue(enc)
 					}
 
 					if err = saveConfig(stack.Name().StackName(), c); err != nil {
@@ -237,6 +262,13 @@ func newNewCmd() *cobra.Command {
 				fmt.Println("New project is configured and ready to deploy with 'pulumi update'.")
 			}
 
+			// Run the template's own setup commands, but only if they have been vetted.
+			if len(template.PostScaffold) > 0 && !generateOnly {
+				if err = runPostScaffoldHooks(template.PostScaffold, runHooks, yes); err != nil {
+					return err
+				}
+			}
+
 			return nil
 		}),
 	}
@@ -261,12 +293,126 @@ func newNewCmd() *cobra.Command {
 	cmd.PersistentFlags().BoolVar(
 		&generateOnly, "generate-only", false,
 		"Generate the project only; do not create a stack, save config, or install dependencies")
+	cmd.PersistentFlags().BoolVar(
+		&runHooks, "run-hooks", false,
+		"Run the commands that the template asks to run once the project has been created, without asking")
 	cmd.PersistentFlags().StringVar(&dir, "dir", "",
 		"The location to place the generated project; if not specified, the current directory is used")
 
 	return cmd
 }
 
+// retrieveTemplate downloads the template with the given name and installs it in the local template cache, unless
+// offline is true, and then loads it from the cache.
+func retrieveTemplate(releases cloud.Backend, templateName string, offline bool) (workspace.Template, error) {
+	if !offline {
+		var tarball io.ReadCloser
+		var err error
+		source := releases.CloudURL()
+		if tarball, err = releases.DownloadTemplate(commandContext(), templateName, false); err != nil {
+			message := ""
+			// If the local template is available locally, provide a nicer error message.
+			if localTemplates, localErr := workspace.ListLocalTemplates(); localErr == nil && len(localTemplates) > 0 {
+				_, m := templateArrayToStringArrayAndMap(localTemplates)
+				if _, ok := m[templateName]; ok {
+					message = fmt.Sprintf(
+						"; rerun the command and pass --offline to use locally cached template '%s'",
+						templateName)
+				}
+			}
+
+			return workspace.Template{}, errors.Wrapf(err, "downloading template '%s' from %s%s",
+				templateName, source, message)
+		}
+		if err = workspace.InstallTemplate(templateName, tarball); err != nil {
+			return workspace.Template{}, errors.Wrapf(err, "installing template '%s' from %s", templateName, source)
+		}
+	}
+
+	// Load the local template.
+	template, err := workspace.LoadLocalTemplate(templateName)
+	if err != nil {
+		return workspace.Template{}, errors.Wrapf(err, "template '%s' not found", templateName)
+	}
+	return template, nil
+}
+
+// promptForConfigValue prompts for a config value declared by a template, until the value given is valid.  If yes is
+// true, the default value is returned without prompting, or an error if the default isn't valid.
+func promptForConfigValue(yes bool, key config.Key, v workspace.TemplateConfigValue) (string, error) {
+	if yes {
+		if err := v.Validate(v.Default); err != nil {
+			return "", errors.Wrapf(err, "invalid value for config key '%s'", key)
+		}
+		return v.Default, nil
+	}
+
+	prompt := key.String()
+	if v.Description != "" {
+		prompt = fmt.Sprintf("%s (%s)", prompt, v.Description)
+	}
+	for {
+		var value string
+		if v.Secret {
+			var err error
+			if value, err = cmdutil.ReadConsoleNoEcho(prompt); err != nil {
+				return "", err
+			}
+			if value == "" {
+				value = v.Default
+			}
+		} else {
+			value = promptForValue(false, prompt, v.Default, nil)
+		}
+
+		if err := v.Validate(value); err != nil {
+			// The value is invalid, let the user know and try again
+			fmt.Printf("Sorry, that is not a valid value for %s: %v.\n", key, err)
+			continue
+		}
+		return value, nil
+	}
+}
+
+// runPostScaffoldHooks runs a template's post-scaffold commands, in order, in the new project's directory.  The
+// commands come from the template, which may have been written by anyone, so they are shown first and, unless run is
+// true, only run once the user has confirmed them.  If they can't be confirmed, because there is no terminal to confirm
+// them at or yes has been passed to skip prompts, they are skipped.
+func runPostScaffoldHooks(hooks []string, run bool, yes bool) error {
+	fmt.Println("The template asks to run these commands in the new project's directory:")
+	for _, hook := range hooks {
+		fmt.Printf("    %s\n", hook)
+	}
+	fmt.Println()
+
+	if !run {
+		if yes || !cmdutil.Interactive() {
+			fmt.Println("Skipping the template's commands; pass --run-hooks to run them.")
+			return nil
+		}
+		if !confirmPrompt("", "yes") {
+			fmt.Println("Skipping the template's commands.")
+			return nil
+		}
+	}
+
+	for _, hook := range hooks {
+		fmt.Printf("Running '%s'...\n", hook)
+
+		// nolint: gas, the template's commands are run by a shell on purpose
+		c := exec.Command("sh", "-c", hook)
+		if runtime.GOOS == "windows" {
+			c = exec.Command("cmd", "/C", hook) // nolint: gas
+		}
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+		if err := c.Run(); err != nil {
+			return errors.Wrapf(err, "running the template's command '%s'", hook)
+		}
+	}
+	return nil
+}
+
 // getDevStackName returns the stack name suffixed with -dev.
 func getDevStackName(name string) string {
 	const suffix = "-dev"
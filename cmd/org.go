@@ -0,0 +1,251 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/backend/cloud"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+)
+
+func newOrgCmd() *cobra.Command {
+	var org string
+
+	cmd := &cobra.Command{
+		Use:   "org",
+		Short: "Manage an organization's members, teams, and their access to stacks",
+		Long: "Manage an organization's members, teams, and their access to stacks.\n" +
+			"\n" +
+			"These commands require the Pulumi Service backend.  They act on the organization given by\n" +
+			"`--org`, or, by default, on the logged-in user's own organization.",
+		Args: cmdutil.NoArgs,
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&org, "org", "o", "",
+		"The organization to manage, instead of the logged-in user's own organization")
+
+	cmd.AddCommand(newOrgMembersCmd(&org))
+	cmd.AddCommand(newOrgTeamCmd(&org))
+
+	return cmd
+}
+
+func newOrgMembersCmd(org *string) *cobra.Command {
+	var jsonOut bool
+
+	cmd := &cobra.Command{
+		Use:   "members",
+		Short: "List the members of an organization",
+		Args:  cmdutil.NoArgs,
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			b, orgName, err := orgBackend(*org)
+			if err != nil {
+				return err
+			}
+			members, err := b.ListOrganizationMembers(commandContext(), orgName)
+			if err != nil {
+				return errors.Wrapf(err, "listing the members of organization '%s'", orgName)
+			}
+			sort.Slice(members, func(i, j int) bool { return members[i].GitHubLogin < members[j].GitHubLogin })
+
+			if jsonOut {
+				return printJSON(members)
+			}
+			if len(members) == 0 {
+				fmt.Printf("Organization '%s' has no members.\n", orgName)
+				return nil
+			}
+			fmt.Printf("%-24s %-32s %s\n", "LOGIN", "NAME", "ROLE")
+			for _, m := range members {
+				fmt.Printf("%-24s %-32s %s\n", m.GitHubLogin, m.Name, m.Role)
+			}
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().BoolVarP(
+		&jsonOut, "json", "j", false, "Emit the members as JSON")
+
+	return cmd
+}
+
+func newOrgTeamCmd(org *string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "team",
+		Short: "Manage an organization's teams",
+		Args:  cmdutil.NoArgs,
+	}
+
+	cmd.AddCommand(newOrgTeamCreateCmd(org))
+	cmd.AddCommand(newOrgTeamGrantCmd(org))
+	cmd.AddCommand(newOrgTeamLsCmd(org))
+
+	return cmd
+}
+
+func newOrgTeamLsCmd(org *string) *cobra.Command {
+	var jsonOut bool
+
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List the teams of an organization",
+		Args:  cmdutil.NoArgs,
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			b, orgName, err := orgBackend(*org)
+			if err != nil {
+				return err
+			}
+			teams, err := b.ListTeams(commandContext(), orgName)
+			if err != nil {
+				return errors.Wrapf(err, "listing the teams of organization '%s'", orgName)
+			}
+			sort.Slice(teams, func(i, j int) bool { return teams[i].Name < teams[j].Name })
+
+			if jsonOut {
+				return printJSON(teams)
+			}
+			if len(teams) == 0 {
+				fmt.Printf("Organization '%s' has no teams.\n", orgName)
+				return nil
+			}
+			fmt.Printf("%-24s %-8s %s\n", "NAME", "MEMBERS", "DESCRIPTION")
+			for _, t := range teams {
+				fmt.Printf("%-24s %-8d %s\n", t.Name, len(t.Members), t.Description)
+			}
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().BoolVarP(
+		&jsonOut, "json", "j", false, "Emit the teams as JSON")
+
+	return cmd
+}
+
+func newOrgTeamCreateCmd(org *string) *cobra.Command {
+	var displayName string
+	var description string
+
+	cmd := &cobra.Command{
+		Use:   "create <team-name>",
+		Short: "Create a new team in an organization",
+		Args:  cmdutil.ExactArgs(1),
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			b, orgName, err := orgBackend(*org)
+			if err != nil {
+				return err
+			}
+			team, err := b.CreateTeam(commandContext(), orgName, apitype.CreateTeamRequest{
+				Name:        args[0],
+				DisplayName: displayName,
+				Description: description,
+			})
+			if err != nil {
+				return errors.Wrapf(err, "creating team '%s'", args[0])
+			}
+			fmt.Printf("Created team '%s' in organization '%s'.\n", team.Name, orgName)
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVar(
+		&displayName, "display-name", "", "The name of the team as shown in the console")
+	cmd.PersistentFlags().StringVarP(
+		&description, "description", "d", "", "A description of the team")
+
+	return cmd
+}
+
+func newOrgTeamGrantCmd(org *string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "grant <team-name> <stack-name> <none|read|write|admin>",
+		Short: "Set a team's permission on a stack",
+		Long: "Set a team's permission on a stack.\n" +
+			"\n" +
+			"`read` allows the team's members to view the stack; `write` also allows them to update it;\n" +
+			"and `admin` also allows them to delete it and change its settings.  `none` revokes the\n" +
+			"team's access.  The stack must belong to the team's organization.",
+		Args: cmdutil.ExactArgs(3),
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			team, stackName, permission := args[0], args[1], args[2]
+			if !apitype.IsStackPermission(permission) {
+				return errors.Errorf("unknown permission '%s'; choices are: none, read, write, admin", permission)
+			}
+
+			b, orgName, err := orgBackend(*org)
+			if err != nil {
+				return err
+			}
+			// Stacks given without an owner belong to the organization being managed.
+			if !strings.Contains(stackName, "/") {
+				stackName = orgName + "/" + stackName
+			}
+			stackRef, err := b.ParseStackReference(stackName)
+			if err != nil {
+				return err
+			}
+			if owner := strings.SplitN(stackRef.String(), "/", 2)[0]; owner != orgName {
+				return errors.Errorf("stack '%s' doesn't belong to organization '%s'", stackRef, orgName)
+			}
+
+			err = b.SetTeamStackPermission(commandContext(), team, stackRef, apitype.StackPermission(permission))
+			if err != nil {
+				return errors.Wrapf(err, "setting team '%s''s permission on stack '%s'", team, stackRef)
+			}
+			fmt.Printf("Set team '%s''s permission on stack '%s' to %s.\n", team, stackRef, permission)
+			return nil
+		}),
+	}
+
+	return cmd
+}
+
+// orgBackend returns the current backend, which must be the Pulumi Service, along with the name of the organization
+// to manage: the given one or, if it is empty, the logged-in user's own organization.
+func orgBackend(org string) (cloud.Backend, string, error) {
+	b, err := currentBackend()
+	if err != nil {
+		return nil, "", err
+	}
+	cb, ok := b.(cloud.Backend)
+	if !ok {
+		return nil, "", errors.New("organizations are only supported by the Pulumi Service backend")
+	}
+	if org == "" {
+		if org, err = cb.CurrentUser(commandContext()); err != nil {
+			return nil, "", errors.Wrap(err, "finding the current user")
+		}
+	}
+	return cb, org, nil
+}
+
+// printJSON prints the given value as indented JSON.
+func printJSON(v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
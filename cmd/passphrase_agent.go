@@ -0,0 +1,36 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+	"github.com/pulumi/pulumi/pkg/workspace"
+)
+
+// newPassphraseAgentCmd returns a new command that, when run, holds the keys unlocked by passphrases in memory.  It is
+// hidden since it's started in the background when PULUMI_PASSPHRASE_CACHE_TIMEOUT is set, rather than by users.
+func newPassphraseAgentCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:    "passphrase-agent",
+		Args:   cmdutil.NoArgs,
+		Short:  "Hold the keys unlocked by passphrases in memory, until they expire",
+		Hidden: true,
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			return workspace.ServePassphraseAgent()
+		}),
+	}
+}
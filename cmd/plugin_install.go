@@ -17,23 +17,29 @@ package cmd
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 
 	"github.com/blang/semver"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ed25519"
 
 	"github.com/pulumi/pulumi/pkg/backend/cloud"
 	"github.com/pulumi/pulumi/pkg/diag"
 	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+	"github.com/pulumi/pulumi/pkg/util/contract"
 	"github.com/pulumi/pulumi/pkg/workspace"
 )
 
 func newPluginInstallCmd() *cobra.Command {
+	var checksum string
 	var cloudURL string
 	var exact bool
 	var file string
+	var publicKey string
 	var reinstall bool
+	var skipVerify bool
 	var verbose bool
 	var cmd = &cobra.Command{
 		Use:   "install [KIND NAME VERSION]",
@@ -47,7 +53,13 @@ func newPluginInstallCmd() *cobra.Command {
 			"project.  VERSION cannot be a range: it must be a specific number.\n" +
 			"\n" +
 			"If you let Pulumi compute the set to download, it is conservative and may end up\n" +
-			"downloading more plugins than is strictly necessary.",
+			"downloading more plugins than is strictly necessary.\n" +
+			"\n" +
+			"Before a plugin is extracted, its tarball is checked against the SHA256 checksum published\n" +
+			"alongside it, or, for a tarball installed with --file, the one in a .sha256 file next to it.\n" +
+			"A plugin without a checksum isn't installed unless --skip-verify is passed.  If --public-key\n" +
+			"is passed, the checksum must also carry a valid signature (published in a .sig file) made\n" +
+			"with the corresponding private key.  How each plugin was verified is recorded in its directory.",
 		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
 			// Parse the kind, name, and version, if specified.
 			var installs []workspace.PluginInfo
@@ -72,6 +84,9 @@ func newPluginInstallCmd() *cobra.Command {
 				if file != "" {
 					return errors.New("--file (-f) is only valid if a specific package is being installed")
 				}
+				if checksum != "" {
+					return errors.New("--checksum is only valid if a specific package is being installed")
+				}
 
 				// If a specific plugin wasn't given, compute the set of plugins the current project needs.
 				plugins, err := getProjectPlugins()
@@ -87,6 +102,15 @@ func newPluginInstallCmd() *cobra.Command {
 				}
 			}
 
+			var key ed25519.PublicKey
+			if publicKey != "" {
+				k, err := workspace.ParsePluginPublicKey(publicKey)
+				if err != nil {
+					return errors.Wrap(err, "invalid --public-key")
+				}
+				key = k
+			}
+
 			// Target the cloud URL for downloads.
 			var releases cloud.Backend
 			if len(installs) > 0 && file == "" {
@@ -128,6 +152,8 @@ func newPluginInstallCmd() *cobra.Command {
 				// If we got here, actually try to do the download.
 				var source string
 				var tarball io.ReadCloser
+				var published string
+				var signature []byte
 				var err error
 				if file == "" {
 					source = releases.CloudURL()
@@ -135,6 +161,9 @@ func newPluginInstallCmd() *cobra.Command {
 						cmdutil.Diag().Infoerrf(
 							diag.Message("", "%s downloading from %s"), label, source)
 					}
+					if published, signature, err = releases.GetPluginChecksum(commandContext(), install); err != nil {
+						return errors.Wrapf(err, "%s downloading checksum from %s", label, source)
+					}
 					if tarball, err = releases.DownloadPlugin(commandContext(), install, true); err != nil {
 						return errors.Wrapf(err, "%s downloading from %s", label, source)
 					}
@@ -144,33 +173,84 @@ func newPluginInstallCmd() *cobra.Command {
 						cmdutil.Diag().Infoerrf(
 							diag.Message("", "%s opening tarball from %s"), label, file)
 					}
+					if published, signature, err = readPluginChecksumFiles(file); err != nil {
+						return errors.Wrapf(err, "reading checksum for %s", source)
+					}
 					if tarball, err = os.Open(file); err != nil {
 						return errors.Wrapf(err, "opening file %s", source)
 					}
 				}
+
+				// Verify the tarball before extracting any of it.  A checksum given on the command line takes
+				// precedence over the published one.
+				expected := checksum
+				if expected == "" && published != "" {
+					if expected, err = workspace.ParsePluginChecksum(published); err != nil {
+						contract.IgnoreClose(tarball)
+						return errors.Wrapf(err, "%s invalid published checksum", label)
+					}
+				}
+				if expected == "" && !skipVerify {
+					contract.IgnoreClose(tarball)
+					return errors.Errorf("%s no checksum was published for the plugin; "+
+						"pass --checksum, or --skip-verify to install it without verifying it", label)
+				}
+				if verbose {
+					cmdutil.Diag().Infoerrf(
+						diag.Message("", "%s verifying tarball ..."), label)
+				}
+				verified, verification, err := workspace.VerifyPluginTarball(tarball, expected, signature, key)
+				if err != nil {
+					return errors.Wrapf(err, "%s verifying tarball from %s", label, source)
+				}
+				verification.Source = source
+
 				if verbose {
 					cmdutil.Diag().Infoerrf(
 						diag.Message("", "%s installing tarball ..."), label)
 				}
-				if err = install.Install(tarball); err != nil {
+				if err = install.Install(verified); err != nil {
 					return errors.Wrapf(err, "installing %s from %s", label, source)
 				}
+				if err = install.SaveVerification(verification); err != nil {
+					return errors.Wrapf(err, "recording verification of %s", label)
+				}
 			}
 
 			return nil
 		}),
 	}
 
+	cmd.PersistentFlags().StringVar(&checksum,
+		"checksum", "", "The SHA256 checksum that the plugin's tarball must match, instead of the published one")
 	cmd.PersistentFlags().StringVarP(&cloudURL,
 		"cloud-url", "c", "", "A cloud URL to download releases from")
 	cmd.PersistentFlags().BoolVar(&exact,
 		"exact", false, "Force installation of an exact version match (usually >= is accepted)")
 	cmd.PersistentFlags().StringVarP(&file,
 		"file", "f", "", "Install a plugin from a tarball file, instead of downloading it")
+	cmd.PersistentFlags().StringVar(&publicKey,
+		"public-key", "", "A base64-encoded ed25519 public key with which plugins' checksums must be signed")
 	cmd.PersistentFlags().BoolVar(&reinstall,
 		"reinstall", false, "Reinstall a plugin even if it already exists")
+	cmd.PersistentFlags().BoolVar(&skipVerify,
+		"skip-verify", false, "Install plugins for which no checksum was published, without verifying them")
 	cmd.PersistentFlags().BoolVar(&verbose,
 		"verbose", false, "Print detailed information about the installation steps")
 
 	return cmd
 }
+
+// readPluginChecksumFiles reads the checksum and signature of a local plugin tarball from the .sha256 and .sig files
+// next to it.  Either is empty if its file doesn't exist.
+func readPluginChecksumFiles(file string) (string, []byte, error) {
+	checksum, err := ioutil.ReadFile(file + ".sha256")
+	if err != nil && !os.IsNotExist(err) {
+		return "", nil, err
+	}
+	signature, err := ioutil.ReadFile(file + ".sig")
+	if err != nil && !os.IsNotExist(err) {
+		return "", nil, err
+	}
+	return string(checksum), signature, nil
+}
@@ -15,24 +15,56 @@
 package cmd
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/pulumi/pulumi/pkg/backend"
 	"github.com/pulumi/pulumi/pkg/engine"
+	"github.com/pulumi/pulumi/pkg/resource"
 	"github.com/pulumi/pulumi/pkg/util/cmdutil"
 )
 
 func newPreviewCmd() *cobra.Command {
 	var debug bool
 	var expectNop bool
+	var detailedExitCode bool
+	var expectNoDrift bool
+	var driftReport string
+	var explain string
 	var message string
 	var stack string
 
 	// Flags for engine.UpdateOptions.
 	var analyzers []string
+	var fullDiff bool
+	var diffContextLines int
+	var diffIncludePaths []string
+	var diffExcludePaths []string
+	var diffArchiveContents bool
+	var diffMaxStringLength int
+	var diffMaxArrayElements int
+	var diffMaxObjectKeys int
+	var diffNoTruncate bool
+	var diffCollapseUnchanged bool
+	var diffExpandPaths []string
+	var diffNoLineNumbers bool
+	var diffWidth int
+	var diffArrayKeys []string
+	var diffMatchArrays bool
+	var secretPatterns []string
+	var targets []string
+	var configEnv []string
+	var configEnvSecret []string
+	var savePlan string
 	var color colorFlag
 	var diffDisplay bool
+	var jsonDisplay bool
+	var quiet bool
+	var summaryOnly bool
+	var diffFormat diffFormatFlag
 	var nonInteractive bool
 	var parallel int
 	var showConfig bool
@@ -54,9 +86,29 @@ func newPreviewCmd() *cobra.Command {
 			"actually take place.\n" +
 			"\n" +
 			"The program to run is loaded from the project in the current directory. Use the `-C` or\n" +
-			"`--cwd` flag to use a different directory.",
+			"`--cwd` flag to use a different directory.\n" +
+			"\n" +
+			"With `--detailed-exit-code`, the exit code tells whether the preview proposed any changes:\n" +
+			"\n" +
+			"    0 - the preview succeeded, and proposed no changes\n" +
+			"    1 - the preview failed\n" +
+			"    2 - the preview succeeded, and proposed changes",
 		Args: cmdutil.NoArgs,
-		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) (err error) {
+			if err = checkDisplayModeFlags(quiet, summaryOnly, jsonDisplay); err != nil {
+				return err
+			}
+			if diffContextLines < 0 {
+				return errors.New("--diff-context-lines must not be negative")
+			}
+			if detailedExitCode {
+				defer func() {
+					if _, ok := err.(*cmdutil.ExitCodeError); err != nil && !ok {
+						err = &cmdutil.ExitCodeError{Code: cmdutil.ExitFailure, Err: err}
+					}
+				}()
+			}
+
 			s, err := requireStack(stack, true)
 			if err != nil {
 				return err
@@ -72,31 +124,110 @@ func newPreviewCmd() *cobra.Command {
 				return errors.Wrap(err, "gathering environment metadata")
 			}
 
+			var plan *engine.UpdatePlan
+			if savePlan != "" {
+				plan = &engine.UpdatePlan{}
+			}
+
+			var explanation *engine.Explanation
+			if explain != "" {
+				explanation = engine.NewExplanation(resource.URN(explain))
+			}
+
+			mappings, err := configEnvVars(configEnv, configEnvSecret)
+			if err != nil {
+				return err
+			}
+
 			opts := backend.UpdateOptions{
+				ConfigEnv: mappings,
 				Engine: engine.UpdateOptions{
-					Analyzers: analyzers,
-					Parallel:  parallel,
-					Debug:     debug,
+					Analyzers:        analyzers,
+					Parallel:         parallel,
+					Debug:            debug,
+					DiffContextLines: &diffContextLines,
+					SecretPatterns:   secretPatterns,
+					Targets:          targetURNs(targets),
+					RecordPlan:       plan,
+					Explain:          explanation,
+					StackOutputs:     newStackOutputsReader(s.Backend()),
 				},
 				Display: backend.DisplayOptions{
 					Color:                color.Colorization(),
 					ShowConfig:           showConfig,
 					ShowReplacementSteps: showReplacementSteps,
 					ShowSameResources:    showSames,
-					IsInteractive:        isInteractive(nonInteractive),
+					IsInteractive:        isInteractive(nonInteractive) && !jsonDisplay,
 					DiffDisplay:          diffDisplay,
+					JSONDisplay:          jsonDisplay,
+					Quiet:                quiet,
+					SummaryOnly:          summaryOnly,
+					DiffFormat:           diffFormat.DiffFormat(),
 					Debug:                debug,
+					Diff: engine.DiffOptions{
+						FullDiff:           fullDiff,
+						IncludePaths:       diffIncludePaths,
+						ExcludePaths:       diffExcludePaths,
+						ArchiveContents:    diffArchiveContents,
+						MaxStringLength:    diffMaxStringLength,
+						MaxArrayElements:   diffMaxArrayElements,
+						MaxObjectKeys:      diffMaxObjectKeys,
+						NoTruncate:         diffNoTruncate,
+						CollapseUnchanged:  diffCollapseUnchanged,
+						ExpandPaths:        diffExpandPaths,
+						NoLineNumbers:      diffNoLineNumbers,
+						Width:              diffWidth,
+						ArrayKeys:          diffArrayKeys,
+						MatchArrayElements: diffMatchArrays,
+					},
 				},
 			}
+
+			// If asked to, first check whether any resources have drifted from the state recorded in the checkpoint.
+			if expectNoDrift || driftReport != "" {
+				report := &engine.DriftReport{}
+				driftOpts := opts
+				driftOpts.Engine.DriftReport = report
+				driftOpts.Engine.RecordPlan = nil
+				driftOpts.Engine.Explain = nil
+				if _, err = s.Preview(commandContext(), proj, root, m, driftOpts, cancellationScopes); err != nil {
+					return err
+				}
+				if driftReport != "" {
+					if err = writeJSONFile(driftReport, report); err != nil {
+						return err
+					}
+				}
+				if expectNoDrift && report.HasDrift() {
+					return errors.Errorf("error: %d resource(s) have drifted from the state recorded for this stack",
+						len(report.Resources))
+				}
+			}
+
 			changes, err := s.Preview(commandContext(), proj, root, m, opts, cancellationScopes)
-			switch {
-			case err != nil:
+			if err != nil {
 				return err
-			case expectNop && changes != nil && changes.HasChanges():
-				return errors.New("error: no changes were expected but changes were proposed")
-			default:
-				return nil
 			}
+			if explanation != nil {
+				printExplanation(explanation)
+			}
+			hasChanges := changes != nil && changes.HasChanges()
+			if expectNop && hasChanges {
+				err = errors.New("error: no changes were expected but changes were proposed")
+				if detailedExitCode {
+					return &cmdutil.ExitCodeError{Code: cmdutil.ExitChangesPresent, Err: err}
+				}
+				return err
+			}
+			if plan != nil {
+				if err = writeJSONFile(savePlan, plan); err != nil {
+					return err
+				}
+			}
+			if detailedExitCode && hasChanges {
+				return &cmdutil.ExitCodeError{Code: cmdutil.ExitChangesPresent}
+			}
+			return nil
 		}),
 	}
 
@@ -106,6 +237,21 @@ func newPreviewCmd() *cobra.Command {
 	cmd.PersistentFlags().BoolVar(
 		&expectNop, "expect-no-changes", false,
 		"Return an error if any changes are proposed by this preview")
+	cmd.PersistentFlags().BoolVar(
+		&detailedExitCode, "detailed-exit-code", false,
+		"Exit with code 0 if no changes are proposed, 2 if changes are proposed, and 1 if the preview fails")
+	cmd.PersistentFlags().BoolVar(
+		&expectNoDrift, "expect-no-drift", false,
+		"Refresh the stack's resources first, and return an error if the live state of any of them differs "+
+			"from the state recorded for the stack")
+	cmd.PersistentFlags().StringVar(
+		&driftReport, "drift-report", "",
+		"Refresh the stack's resources first, and save a report of any that have drifted from the state "+
+			"recorded for the stack to the given file, as JSON")
+	cmd.PersistentFlags().StringVar(
+		&explain, "explain", "",
+		"Explain why the preview plans the operation that it does for the resource with the given URN: which of "+
+			"its properties changed, which of its dependencies are changing too, and why the operation was chosen")
 	cmd.PersistentFlags().StringVarP(
 		&stack, "stack", "s", "",
 		"Choose a stack other than the currently selected one")
@@ -118,11 +264,93 @@ func newPreviewCmd() *cobra.Command {
 	cmd.PersistentFlags().StringSliceVar(
 		&analyzers, "analyzer", []string{},
 		"Run one or more analyzers as part of this update")
+	cmd.PersistentFlags().IntVar(
+		&diffContextLines, "diff-context-lines", engine.DefaultDiffContextLines,
+		"The number of unchanged lines to show around each change when diffing text assets")
+	cmd.PersistentFlags().BoolVar(
+		&fullDiff, "full-diff", false,
+		"Show the full contents of changed text assets rather than just the lines around each change")
+	cmd.PersistentFlags().StringSliceVar(
+		&diffIncludePaths, "diff-path", []string{},
+		"Only show properties whose paths match the given pattern (e.g. 'tags.*'); may be repeated")
+	cmd.PersistentFlags().StringSliceVar(
+		&diffExcludePaths, "diff-ignore", []string{},
+		"Hide properties whose paths match the given pattern (e.g. 'metadata.annotations.*'); may be repeated")
+	cmd.PersistentFlags().BoolVar(
+		&diffArchiveContents, "diff-archive-contents", false,
+		"Open changed file and URI archives and show which files within them were added, changed, or deleted")
+	cmd.PersistentFlags().IntVar(
+		&diffMaxStringLength, "max-string-length", engine.DefaultMaxStringLength,
+		"The maximum number of characters of a string property value to show before truncating it")
+	cmd.PersistentFlags().IntVar(
+		&diffMaxArrayElements, "max-array-elements", engine.DefaultMaxArrayElements,
+		"The maximum number of elements of an array property value to show before truncating it")
+	cmd.PersistentFlags().IntVar(
+		&diffMaxObjectKeys, "max-object-keys", engine.DefaultMaxObjectKeys,
+		"The maximum number of properties of an object value to show before truncating it")
+	cmd.PersistentFlags().BoolVar(
+		&diffNoTruncate, "no-truncate", false,
+		"Show property values in their entirety, no matter how large they are")
+	cmd.PersistentFlags().BoolVar(
+		&diffCollapseUnchanged, "collapse-unchanged", false,
+		"Collapse unchanged objects and arrays within a diff into a single line counting their contents")
+	cmd.PersistentFlags().StringSliceVar(
+		&diffExpandPaths, "expand", []string{},
+		"Show unchanged properties whose paths match the given pattern in full, even with --collapse-unchanged; "+
+			"may be repeated")
+	cmd.PersistentFlags().BoolVar(
+		&diffNoLineNumbers, "no-line-numbers", false,
+		"Omit the line numbers shown beside each line of a text diff")
+	cmd.PersistentFlags().IntVar(
+		&diffWidth, "width", 0,
+		"The width to wrap diff output to; by default, the width of the terminal is used, and a negative value "+
+			"disables wrapping")
+	cmd.PersistentFlags().StringSliceVar(
+		&diffArrayKeys, "diff-array-key", []string{},
+		"Match up the elements of changed arrays of objects by the given property (e.g. 'name') rather than by "+
+			"index; may be repeated")
+	cmd.PersistentFlags().BoolVar(
+		&diffMatchArrays, "diff-match-arrays", false,
+		"Match up the elements of changed arrays by value rather than by index, showing only those actually "+
+			"added, removed, or changed")
+	cmd.PersistentFlags().StringSliceVar(
+		&secretPatterns, "secret-pattern", []string{},
+		"Treat properties matching the given path pattern (e.g. 'password' or '**.secretKey') as secrets, "+
+			"masking their values in all output")
+	cmd.PersistentFlags().StringSliceVar(
+		&configEnv, "config-env", []string{},
+		"Use the value of an environment variable for a configuration key, given as <key>=<variable>, "+
+			"instead of the stack's; may be repeated")
+	cmd.PersistentFlags().StringSliceVar(
+		&configEnvSecret, "config-env-secret", []string{},
+		"Use the value of an environment variable as a secret for a configuration key, given as "+
+			"<key>=<variable>; may be repeated")
+	cmd.PersistentFlags().StringSliceVar(
+		&targets, "target", []string{},
+		"Restrict the preview to the resource with the given URN; other resources are left as they are, "+
+			"except for those that depend on a deleted target.  May be repeated")
+	cmd.PersistentFlags().StringVar(
+		&savePlan, "save-plan", "",
+		"Save the operations proposed by this preview to the given file, so that they may later be applied "+
+			"exactly with 'pulumi update --plan'")
 	cmd.PersistentFlags().VarP(
 		&color, "color", "c", "Colorize output. Choices are: always, never, raw, auto")
 	cmd.PersistentFlags().BoolVar(
 		&diffDisplay, "diff", false,
 		"Display operation as a rich diff showing the overall change")
+	cmd.PersistentFlags().BoolVar(
+		&jsonDisplay, "json", false,
+		"Write the operation's events to stdout as newline-delimited JSON, as described in pkg/apitype/events.go")
+	cmd.PersistentFlags().BoolVarP(
+		&quiet, "quiet", "q", false,
+		"Display only errors and the final summary of changes")
+	cmd.PersistentFlags().BoolVar(
+		&summaryOnly, "summary-only", false,
+		"Display one line per changed resource, with its operation and number of changed properties, "+
+			"instead of the full diff")
+	cmd.PersistentFlags().Var(
+		&diffFormat, "diff-format",
+		"The format in which to display changes. Choices are: default, patch (a stable, uncolored textual patch)")
 	cmd.PersistentFlags().BoolVar(
 		&nonInteractive, "non-interactive", false, "Disable interactive mode")
 	cmd.PersistentFlags().IntVarP(
@@ -140,3 +368,55 @@ func newPreviewCmd() *cobra.Command {
 
 	return cmd
 }
+
+// printExplanation prints the chain of steps that explains the operation planned for a resource: first the resource's
+// own step, and then the steps of each of the changing dependencies that may have caused it.
+func printExplanation(explanation *engine.Explanation) {
+	chain := explanation.Chain()
+	if len(chain) == 0 {
+		fmt.Printf("\nThe preview planned no operation for '%s'; is the URN correct?\n", explanation.URN)
+		return
+	}
+
+	fmt.Printf("\nExplanation for '%s':\n", explanation.URN)
+	for i, step := range chain {
+		if i == 1 {
+			fmt.Printf("\nIt depends on resources that are changing too:\n")
+		}
+		fmt.Printf("\n%s: %s\n", step.Op, step.URN)
+		if step.Reason != "" {
+			fmt.Printf("    why: %s\n", step.Reason)
+		}
+		if len(step.Properties) > 0 {
+			source := "the program's inputs"
+			if step.ProviderDiff {
+				source = "the provider"
+			}
+			fmt.Printf("    changed properties (as reported by %s):\n", source)
+			for _, prop := range step.Properties {
+				var notes []string
+				if prop.Replace {
+					notes = append(notes, "requires replacement")
+				}
+				if prop.Computed {
+					notes = append(notes, "unknown until its dependencies are updated")
+				}
+				var note string
+				if len(notes) > 0 {
+					note = " (" + strings.Join(notes, "; ") + ")"
+				}
+				fmt.Printf("        %s %s%s\n", prop.Kind, prop.Path, note)
+				for _, src := range prop.Sources {
+					var outputs string
+					if len(src.Outputs) > 0 {
+						outputs = " (outputs " + strings.Join(src.Outputs, ", ") + ")"
+					}
+					fmt.Printf("            from %s%s\n", src.URN, outputs)
+				}
+			}
+		}
+		for _, dep := range step.ChangedDependencies {
+			fmt.Printf("    depends on changing resource: %s\n", dep)
+		}
+	}
+}
@@ -39,6 +39,7 @@ func NewPulumiCmd() *cobra.Command {
 	var tracingHeaderFlag string
 	var profiling string
 	var verbose int
+	var colorTheme string
 
 	cmd := &cobra.Command{
 		Use: "pulumi",
@@ -49,6 +50,17 @@ func NewPulumiCmd() *cobra.Command {
 				}
 			}
 
+			if colorTheme == "" {
+				colorTheme = os.Getenv("PULUMI_COLOR_THEME")
+			}
+			if colorTheme != "" {
+				theme, err := colors.ParseTheme(colorTheme)
+				if err != nil {
+					return err
+				}
+				colors.SetTheme(theme)
+			}
+
 			logging.InitLogging(logToStderr, verbose, logFlow)
 			cmdutil.InitTracing("pulumi-cli", "pulumi", tracing)
 			if tracingHeaderFlag != "" {
@@ -99,25 +111,39 @@ func NewPulumiCmd() *cobra.Command {
 		"Emit CPU and memory profiles and an execution trace to '[filename].[pid].{cpu,mem,trace}', respectively")
 	cmd.PersistentFlags().IntVarP(&verbose, "verbose", "v", 0,
 		"Enable verbose logging (e.g., v=3); anything >3 is very verbose")
+	cmd.PersistentFlags().StringVar(&colorTheme, "color-theme", "",
+		"The color theme to use for output (dark, light, monochrome, or high-contrast); "+
+			"defaults to $PULUMI_COLOR_THEME, or dark")
 
 	// Common commands:
+	cmd.AddCommand(newAboutCmd())
 	cmd.AddCommand(newCancelCmd())
+	cmd.AddCommand(newCompletionCmd())
 	cmd.AddCommand(newConfigCmd())
+	cmd.AddCommand(newDeploymentCmd())
 	cmd.AddCommand(newDestroyCmd())
+	cmd.AddCommand(newHistoryCmd())
+	cmd.AddCommand(newImportCmd())
 	cmd.AddCommand(newLoginCmd())
 	cmd.AddCommand(newLogoutCmd())
 	cmd.AddCommand(newLogsCmd())
 	cmd.AddCommand(newNewCmd())
+	cmd.AddCommand(newOrgCmd())
 	cmd.AddCommand(newPluginCmd())
 	cmd.AddCommand(newPreviewCmd())
 	cmd.AddCommand(newRefreshCmd())
 	cmd.AddCommand(newStackCmd())
+	cmd.AddCommand(newStateCmd())
 	cmd.AddCommand(newUpdateCmd())
 	cmd.AddCommand(newVersionCmd())
+	cmd.AddCommand(newWatchCmd())
+	cmd.AddCommand(newWhoAmICmd())
 
 	// Less common, and thus hidden, commands:
+	cmd.AddCommand(newCompleteCmd(cmd))
 	cmd.AddCommand(newGenBashCompletionCmd(cmd))
 	cmd.AddCommand(newGenMarkdownCmd(cmd))
+	cmd.AddCommand(newPassphraseAgentCmd())
 
 	// We have a set of commands that are useful for developers of pulumi that we add when PULUMI_DEBUG_COMMANDS is
 	// set to true.
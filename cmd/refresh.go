@@ -16,12 +16,14 @@ package cmd
 
 import (
 	"context"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/pulumi/pulumi/pkg/backend"
 	"github.com/pulumi/pulumi/pkg/engine"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
 	"github.com/pulumi/pulumi/pkg/util/cmdutil"
 )
 
@@ -32,14 +34,26 @@ func newRefreshCmd() *cobra.Command {
 
 	// Flags for engine.UpdateOptions.
 	var analyzers []string
+	var secretPatterns []string
 	var color colorFlag
 	var diffDisplay bool
+	var jsonDisplay bool
+	var quiet bool
+	var summaryOnly bool
 	var parallel int
+	var retries int
+	var retryBackoff time.Duration
 	var showConfig bool
 	var showReplacementSteps bool
 	var showSames bool
 	var nonInteractive bool
 	var skipPreview bool
+	var targets []string
+	var configEnv []string
+	var configEnvSecret []string
+	var targetProperties []string
+	var queue bool
+	var queueWait time.Duration
 	var yes bool
 
 	var cmd = &cobra.Command{
@@ -52,11 +66,21 @@ func newRefreshCmd() *cobra.Command {
 			"the program text isn't updated accordingly, subsequent updates may still appear to be out of\n" +
 			"synch with respect to the cloud provider's source of truth.\n" +
 			"\n" +
+			"To reconcile only some resources, pass each one's URN with `--target`; other resources are\n" +
+			"left as they are, without consulting their providers.  To refresh only some of a resource's\n" +
+			"properties, pass each property's path (e.g. `tags.env`) with `--target-property`.\n" +
+			"\n" +
 			"The program to run is loaded from the project in the current directory. Use the `-C` or\n" +
 			"`--cwd` flag to use a different directory.",
 		Args: cmdutil.NoArgs,
 		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
-			interactive := isInteractive(nonInteractive)
+			if err := checkDisplayModeFlags(quiet, summaryOnly, jsonDisplay); err != nil {
+				return err
+			}
+			if jsonDisplay && !yes {
+				return errors.New("--yes must be passed in to proceed when using --json")
+			}
+			interactive := isInteractive(nonInteractive) && !jsonDisplay
 			if !interactive {
 				yes = true // auto-approve changes, since we cannot prompt.
 			}
@@ -81,10 +105,19 @@ func newRefreshCmd() *cobra.Command {
 				return errors.Wrap(err, "gathering environment metadata")
 			}
 
+			if opts.ConfigEnv, err = configEnvVars(configEnv, configEnvSecret); err != nil {
+				return err
+			}
+			opts.QueueTimeout = queueTimeout(queue, queueWait)
 			opts.Engine = engine.UpdateOptions{
-				Analyzers: analyzers,
-				Parallel:  parallel,
-				Debug:     debug,
+				Analyzers:      analyzers,
+				Parallel:       parallel,
+				Retry:          retryPolicy(retries, retryBackoff),
+				Debug:          debug,
+				SecretPatterns: secretPatterns,
+
+				Targets:           targetURNs(targets),
+				RefreshProperties: targetProperties,
 			}
 			opts.Display = backend.DisplayOptions{
 				Color:                color.Colorization(),
@@ -93,6 +126,9 @@ func newRefreshCmd() *cobra.Command {
 				ShowSameResources:    showSames,
 				IsInteractive:        interactive,
 				DiffDisplay:          diffDisplay,
+				JSONDisplay:          jsonDisplay,
+				Quiet:                quiet,
+				SummaryOnly:          summaryOnly,
 				Debug:                debug,
 			}
 
@@ -119,16 +155,44 @@ func newRefreshCmd() *cobra.Command {
 	cmd.PersistentFlags().StringSliceVar(
 		&analyzers, "analyzer", nil,
 		"Run one or more analyzers as part of this update")
+	cmd.PersistentFlags().StringSliceVar(
+		&secretPatterns, "secret-pattern", []string{},
+		"Treat properties matching the given path pattern (e.g. 'password' or '**.secretKey') as secrets, "+
+			"masking their values in all output")
+	cmd.PersistentFlags().StringSliceVar(
+		&configEnv, "config-env", []string{},
+		"Use the value of an environment variable for a configuration key, given as <key>=<variable>, "+
+			"instead of the stack's; may be repeated")
+	cmd.PersistentFlags().StringSliceVar(
+		&configEnvSecret, "config-env-secret", []string{},
+		"Use the value of an environment variable as a secret for a configuration key, given as "+
+			"<key>=<variable>; may be repeated")
 	cmd.PersistentFlags().VarP(
 		&color, "color", "c", "Colorize output. Choices are: always, never, raw, auto")
 	cmd.PersistentFlags().BoolVar(
 		&diffDisplay, "diff", false,
 		"Display operation as a rich diff showing the overall change")
+	cmd.PersistentFlags().BoolVar(
+		&jsonDisplay, "json", false,
+		"Write the operation's events to stdout as newline-delimited JSON, as described in pkg/apitype/events.go")
+	cmd.PersistentFlags().BoolVarP(
+		&quiet, "quiet", "q", false,
+		"Display only errors and the final summary of changes")
+	cmd.PersistentFlags().BoolVar(
+		&summaryOnly, "summary-only", false,
+		"Display one line per changed resource, with its operation and number of changed properties, "+
+			"instead of the full diff")
 	cmd.PersistentFlags().BoolVar(
 		&nonInteractive, "non-interactive", false, "Disable interactive mode")
 	cmd.PersistentFlags().IntVarP(
 		&parallel, "parallel", "p", 0,
 		"Allow P resource operations to run in parallel at once (<=1 for no parallelism)")
+	cmd.PersistentFlags().IntVar(
+		&retries, "retries", 0,
+		"Retry each resource operation that fails with a transient provider error up to this many times")
+	cmd.PersistentFlags().DurationVar(
+		&retryBackoff, "retry-backoff", deploy.DefaultRetryInitialBackoff,
+		"The time to wait before the first retry of a failed resource operation; the wait doubles after each retry")
 	cmd.PersistentFlags().BoolVar(
 		&showReplacementSteps, "show-replacement-steps", false,
 		"Show detailed resource replacement creates and deletes instead of a single step")
@@ -138,6 +202,20 @@ func newRefreshCmd() *cobra.Command {
 	cmd.PersistentFlags().BoolVar(
 		&skipPreview, "skip-preview", false,
 		"Do not perform a preview before performing the refresh")
+	cmd.PersistentFlags().StringSliceVar(
+		&targets, "target", []string{},
+		"Restrict the refresh to the resource with the given URN; other resources are left as they are.  "+
+			"May be repeated")
+	cmd.PersistentFlags().StringSliceVar(
+		&targetProperties, "target-property", []string{},
+		"Refresh only the property with the given path (e.g. 'tags.env'), leaving the others as they are.  "+
+			"May be repeated")
+	cmd.PersistentFlags().BoolVar(
+		&queue, "queue", false,
+		"If another update of the stack is in progress, wait for it to finish instead of failing")
+	cmd.PersistentFlags().DurationVar(
+		&queueWait, "queue-timeout", defaultQueueTimeout,
+		"How long to wait for another update of the stack to finish when --queue is passed")
 	cmd.PersistentFlags().BoolVarP(
 		&yes, "yes", "y", false,
 		"Automatically approve and perform the refresh after previewing it")
@@ -150,14 +150,24 @@ func newStackCmd() *cobra.Command {
 	cmd.PersistentFlags().BoolVarP(
 		&showURNs, "show-urns", "u", false, "Display each resource's Pulumi-assigned globally unique URN")
 
+	cmd.AddCommand(newStackAddSecretsKeyCmd())
+	cmd.AddCommand(newStackChangeSecretsProviderCmd())
+	cmd.AddCommand(newStackCloneCmd())
+	cmd.AddCommand(newStackDiffCmd())
 	cmd.AddCommand(newStackExportCmd())
 	cmd.AddCommand(newStackGraphCmd())
 	cmd.AddCommand(newStackImportCmd())
 	cmd.AddCommand(newStackInitCmd())
 	cmd.AddCommand(newStackLsCmd())
+	cmd.AddCommand(newStackMigrateCmd())
 	cmd.AddCommand(newStackOutputCmd())
+	cmd.AddCommand(newStackQueryCmd())
+	cmd.AddCommand(newStackRenameCmd())
+	cmd.AddCommand(newStackRepairCmd())
 	cmd.AddCommand(newStackRmCmd())
 	cmd.AddCommand(newStackSelectCmd())
+	cmd.AddCommand(newStackTagCmd())
+	cmd.AddCommand(newStackVerifyCmd())
 
 	return cmd
 }
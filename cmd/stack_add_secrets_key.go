@@ -0,0 +1,127 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/backend/local"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+	"github.com/pulumi/pulumi/pkg/workspace"
+)
+
+func newStackAddSecretsKeyCmd() *cobra.Command {
+	var stack string
+	var configKeys []string
+	var state bool
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "add-secrets-key <name> <provider>",
+		Args:  cmdutil.SpecificArgs([]string{"name", "provider"}),
+		Short: "Encrypt some of a stack's secrets with a further key",
+		Long: "Encrypt some of a stack's secrets with a further key.\n" +
+			"\n" +
+			"A stack's secrets are normally all encrypted with the stack's own key.  This command gives\n" +
+			"the stack a further key, from the key management service whose URL is given as the provider,\n" +
+			"such as awskms://alias/prod-db, which encrypts the values of the configuration keys given by\n" +
+			"--config and, with --state, the stack's state, so that only those who can use both keys can\n" +
+			"read all of its secrets.  The values already set for those keys are re-encrypted now; the\n" +
+			"state is encrypted with the new key from the next time it is written.\n" +
+			"\n" +
+			"Each --config flag gives a configuration key, or, ending with `*`, every key that starts\n" +
+			"with what precedes it, such as `aws:*` or `db*`.  Each secret is encrypted with the first\n" +
+			"key, by name, that is for it.\n" +
+			"\n" +
+			"This command is only supported by backends that keep their state themselves.",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			name, provider := args[0], args[1]
+
+			patterns := make([]string, 0, len(configKeys))
+			for _, k := range configKeys {
+				pattern, err := parseConfigKeyPattern(k)
+				if err != nil {
+					return errors.Wrapf(err, "invalid configuration key '%s'", k)
+				}
+				patterns = append(patterns, pattern)
+			}
+
+			s, err := requireStack(stack, false)
+			if err != nil {
+				return err
+			}
+			b, ok := s.Backend().(local.Backend)
+			if !ok {
+				return errors.Errorf("%s manages the encryption of secrets itself", s.Backend().Name())
+			}
+
+			var changes []string
+			if len(patterns) > 0 {
+				changes = append(changes, fmt.Sprintf("encrypt the values of %s with the '%s' secrets key",
+					strings.Join(patterns, ", "), name))
+			}
+			if state {
+				changes = append(changes, fmt.Sprintf("encrypt the stack's state with the '%s' secrets key", name))
+			}
+			if err = confirmStateEdit(s, changes, yes); err != nil {
+				return err
+			}
+
+			reencrypted, err := b.AddSecretsKey(commandContext(), s.Name(), name, workspace.SecretsKey{
+				Provider: provider,
+				Config:   patterns,
+				State:    state,
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Added the secrets key '%s' to stack '%s', and re-encrypted %d configuration value(s)\n",
+				name, s.Name(), reencrypted)
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stack, "stack", "s", "", "The name of the stack to operate on. Defaults to the current stack")
+	cmd.PersistentFlags().StringSliceVar(
+		&configKeys, "config", nil, "A configuration key whose value the key encrypts; a trailing `*` matches any suffix")
+	cmd.PersistentFlags().BoolVar(
+		&state, "state", false, "Encrypt the stack's state, and so the outputs of its resources, with the key")
+	cmd.PersistentFlags().BoolVarP(
+		&yes, "yes", "y", false, "Skip confirmation prompts, and proceed with the change anyway")
+
+	return cmd
+}
+
+// parseConfigKeyPattern parses a configuration key that may end with `*`, returning it in the form that secrets keys
+// record, `<namespace>:<name>`, with the `*` kept.  A lone `*` matches every key.
+func parseConfigKeyPattern(pattern string) (string, error) {
+	if pattern == "*" {
+		return pattern, nil
+	}
+	wildcard := strings.HasSuffix(pattern, "*")
+	key, err := parseConfigKey(strings.TrimSuffix(pattern, "*"))
+	if err != nil {
+		return "", err
+	}
+	if wildcard {
+		return key.String() + "*", nil
+	}
+	return key.String(), nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/backend/local"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+)
+
+func newStackChangeSecretsProviderCmd() *cobra.Command {
+	var stack string
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "change-secrets-provider <provider>",
+		Args:  cmdutil.SpecificArgs([]string{"provider"}),
+		Short: "Re-encrypt a stack's secrets with a new key",
+		Long: "Re-encrypt a stack's secrets with a new key.\n" +
+			"\n" +
+			"A stack's secrets are kept, encrypted, in its configuration and in the state and history\n" +
+			"of its updates, which may be encrypted in whole too.  This command decrypts all of them\n" +
+			"with the stack's current key and encrypts them again with a new key from the given secrets\n" +
+			"provider, so that the old key no longer reads any of them.  Nothing is changed unless\n" +
+			"every secret can be re-encrypted, and no update can run while it is.\n" +
+			"\n" +
+			"The provider is either `passphrase`, which rotates the stack's passphrase, reading the new\n" +
+			"one from PULUMI_NEW_CONFIG_PASSPHRASE or else asking for it; or the URL of a key in a key\n" +
+			"management service, such as awskms://alias/pulumi, which encrypts a new key of the stack's\n" +
+			"own; `pulumi stack init --help` lists the kinds of keys.  Running this command with the\n" +
+			"stack's current key URL rotates the stack's key, and encrypts it with the current version\n" +
+			"of the key management service's key.\n" +
+			"\n" +
+			"This command is only supported by backends that keep their state themselves.",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			s, err := requireStack(stack, false)
+			if err != nil {
+				return err
+			}
+			b, ok := s.Backend().(local.Backend)
+			if !ok {
+				return errors.Errorf("%s manages the encryption of secrets itself", s.Backend().Name())
+			}
+
+			if err = confirmStateEdit(s, []string{
+				fmt.Sprintf("re-encrypt all secrets with a new key from the '%s' secrets provider", args[0]),
+			}, yes); err != nil {
+				return err
+			}
+
+			rewritten, err := b.ChangeSecretsProvider(commandContext(), s.Name(), args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Re-encrypted the secrets of stack '%s' and %d state file(s)\n", s.Name(), rewritten)
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stack, "stack", "s", "", "The name of the stack to operate on. Defaults to the current stack")
+	cmd.PersistentFlags().BoolVarP(
+		&yes, "yes", "y", false, "Skip confirmation prompts, and proceed with the change anyway")
+
+	return cmd
+}
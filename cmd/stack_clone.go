@@ -0,0 +1,265 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/backend"
+	"github.com/pulumi/pulumi/pkg/backend/cloud"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/config"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/resource/stack"
+	"github.com/pulumi/pulumi/pkg/tokens"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+	"github.com/pulumi/pulumi/pkg/workspace"
+)
+
+func newStackCloneCmd() *cobra.Command {
+	var stackName string
+	var ppc string
+	var copyState bool
+	var stateFilters []string
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "clone <new-stack-name>",
+		Args:  cmdutil.ExactArgs(1),
+		Short: "Create a new stack from an existing one",
+		Long: "Create a new stack from an existing one.\n" +
+			"\n" +
+			"This command creates a new stack, in the same backend as the current stack (or the one\n" +
+			"given by `--stack`), with a copy of its configuration, and selects it.  Secrets are\n" +
+			"decrypted, and then encrypted again for the new stack.  The new stack is tagged from the\n" +
+			"project, just as `pulumi stack init` tags it.\n" +
+			"\n" +
+			"With `--copy-state`, the new stack also starts with a copy of the existing stack's\n" +
+			"resources, limited, if `--state-filter` is given, to the resources with the given URNs or\n" +
+			"types, along with their parents and the resources they depend on.  The copied resources\n" +
+			"are the same cloud resources as the existing stack's, so destroying either stack deletes\n" +
+			"them; this is meant for bootstrapping a stack that will then be updated to manage resources\n" +
+			"of its own.",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			if len(stateFilters) > 0 && !copyState {
+				return errors.New("--state-filter is only valid with --copy-state")
+			}
+
+			src, err := requireStack(stackName, false)
+			if err != nil {
+				return err
+			}
+			b := src.Backend()
+			if err = backend.ValidateStackProperties(args[0], nil); err != nil {
+				return err
+			}
+			dstRef, err := b.ParseStackReference(args[0])
+			if err != nil {
+				return err
+			}
+
+			// Read the state to copy before creating the new stack, so that nothing is created if it can't be read.
+			var snap *deploy.Snapshot
+			if copyState {
+				if snap, err = cloneStackState(src, dstRef.StackName(), stateFilters); err != nil {
+					return err
+				}
+				changes := []string{fmt.Sprintf("copy %d resource(s) to the new stack '%s'; these are the same "+
+					"cloud resources, so destroying either stack deletes them", len(snap.Resources), args[0])}
+				if err = confirmStateEdit(src, changes, yes); err != nil {
+					return err
+				}
+			}
+
+			var createOpts interface{}
+			if _, ok := b.(cloud.Backend); ok {
+				createOpts = cloud.CreateStackOptions{
+					CloudName: ppc,
+				}
+			}
+			dst, err := createStack(b, dstRef, createOpts)
+			if err != nil {
+				return err
+			}
+
+			// Copy the configuration.
+			srcConfig, err := workspace.DetectProjectStack(src.Name().StackName())
+			if err != nil {
+				return err
+			}
+			dstConfig, err := workspace.DetectProjectStack(dst.Name().StackName())
+			if err != nil {
+				return err
+			}
+			keys := make(config.KeyArray, 0, len(srcConfig.Config))
+			for key := range srcConfig.Config {
+				keys = append(keys, key)
+			}
+			sort.Sort(keys)
+			if err = copyStackConfig(src, dst, srcConfig, dstConfig, keys); err != nil {
+				return err
+			}
+			if err = workspace.SaveProjectStack(dst.Name().StackName(), dstConfig); err != nil {
+				return err
+			}
+			fmt.Printf("Copied %d configuration value(s) from '%s'.\n", len(keys), src.Name())
+
+			// Copy the state.
+			if snap != nil {
+				bytes, err := json.Marshal(stack.SerializeDeployment(snap))
+				if err != nil {
+					return err
+				}
+				if err = dst.ImportDeployment(commandContext(), &apitype.UntypedDeployment{
+					Version:    apitype.DeploymentSchemaVersionCurrent,
+					Deployment: bytes,
+				}); err != nil {
+					return errors.Wrap(err, "could not import the copied resources")
+				}
+				fmt.Printf("Copied %d resource(s) from '%s'.\n", len(snap.Resources), src.Name())
+			}
+
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stackName, "stack", "s", "",
+		"Choose a stack to clone other than the currently selected one")
+	cmd.PersistentFlags().StringVarP(
+		&ppc, "ppc", "p", "", "An optional Pulumi Private Cloud (PPC) name to initialize the new stack in")
+	cmd.PersistentFlags().BoolVar(
+		&copyState, "copy-state", false,
+		"Copy the stack's resources to the new stack, as well as its configuration")
+	cmd.PersistentFlags().StringSliceVar(
+		&stateFilters, "state-filter", []string{},
+		"Copy only the resource with the given URN, or the resources of the given type, along with the resources "+
+			"that it needs.  May be repeated")
+	cmd.PersistentFlags().BoolVarP(
+		&yes, "yes", "y", false,
+		"Copy the stack's resources without asking for confirmation")
+
+	return cmd
+}
+
+// cloneStackState returns the part of a stack's state to copy to a new stack with the given name, with the URNs of
+// its resources rewritten to name the new stack.
+func cloneStackState(s backend.Stack, newName tokens.QName, filters []string) (*deploy.Snapshot, error) {
+	deployment, err := s.ExportDeployment(commandContext())
+	if err != nil {
+		return nil, err
+	}
+	snap, err := stack.DeserializeDeployment(deployment)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read the stack's deployment")
+	}
+	if snap == nil {
+		snap = deploy.NewSnapshot(deploy.Manifest{}, nil)
+	}
+
+	snap = filterCloneState(snap, filters)
+	if len(filters) > 0 {
+		var matched bool
+		for _, res := range snap.Resources {
+			matched = matched || res.Type != resource.RootStackType
+		}
+		if !matched {
+			return nil, errors.New("no resources match the given state filters")
+		}
+	}
+
+	if err = moveStackState(snap, newName); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// moveStackState rewrites the URNs of a snapshot's resources to name the given stack, for moving them to it.  The
+// resources of that stack are named after it, but they were never known by the old names, so unlike a rename, the old
+// URNs aren't recorded as aliases.
+func moveStackState(snap *deploy.Snapshot, newName tokens.QName) error {
+	aliases := make(map[*resource.State][]resource.URN)
+	for _, res := range snap.Resources {
+		aliases[res] = res.Aliases
+	}
+	for _, op := range snap.PendingOperations {
+		aliases[op.Resource] = op.Resource.Aliases
+	}
+	if err := deploy.RenameStack(snap, newName, ""); err != nil {
+		return err
+	}
+	for res, resAliases := range aliases {
+		res.Aliases = resAliases
+	}
+	return nil
+}
+
+// filterCloneState returns a snapshot holding the resources of the given one that match any of the filters, each of
+// which is a URN or a type, along with the stack's root resource and, transitively, the parents and dependencies of
+// the resources that match, without which they couldn't be managed.  Without filters, every resource is kept.  Either
+// way, resources pending deletion and operations left pending by an interrupted update are dropped.
+func filterCloneState(snap *deploy.Snapshot, filters []string) *deploy.Snapshot {
+	matches := func(res *resource.State) bool {
+		if len(filters) == 0 || res.Type == resource.RootStackType {
+			return true
+		}
+		for _, f := range filters {
+			if string(res.URN) == f || string(res.Type) == f {
+				return true
+			}
+		}
+		return false
+	}
+
+	byURN := make(map[resource.URN]*resource.State)
+	for _, res := range snap.Resources {
+		if !res.Delete {
+			byURN[res.URN] = res
+		}
+	}
+
+	keep := make(map[resource.URN]bool)
+	var visit func(urn resource.URN)
+	visit = func(urn resource.URN) {
+		res, has := byURN[urn]
+		if !has || keep[urn] {
+			return
+		}
+		keep[urn] = true
+		visit(res.Parent)
+		for _, dep := range res.Dependencies {
+			visit(dep)
+		}
+	}
+	for _, res := range snap.Resources {
+		if !res.Delete && matches(res) {
+			visit(res.URN)
+		}
+	}
+
+	var resources []*resource.State
+	for _, res := range snap.Resources {
+		if !res.Delete && keep[res.URN] {
+			resources = append(resources, res)
+		}
+	}
+	return deploy.NewSnapshot(snap.Manifest, resources)
+}
@@ -0,0 +1,162 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/backend"
+	"github.com/pulumi/pulumi/pkg/backend/local"
+	"github.com/pulumi/pulumi/pkg/engine"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/resource/stack"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+	"github.com/pulumi/pulumi/pkg/util/contract"
+)
+
+func newStackDiffCmd() *cobra.Command {
+	var stackName string
+	var debug bool
+	var secretPatterns []string
+	var color colorFlag
+	var diffFormat diffFormatFlag
+	var showSames bool
+
+	cmd := &cobra.Command{
+		Use:   "diff <old> <new>",
+		Args:  cmdutil.ExactArgs(2),
+		Short: "Show the changes between two deployments of a stack",
+		Long: "Show the changes between two deployments of a stack.\n" +
+			"\n" +
+			"Each deployment may be given either as the version number of one of the stack's\n" +
+			"updates, as listed in its history, or as the name of a file written by\n" +
+			"`pulumi stack export`.  The resources and properties that changed between the\n" +
+			"two are displayed just as they would be during a preview, without running the\n" +
+			"program or contacting any cloud providers.  For example, to see everything that\n" +
+			"changed between updates 41 and 44:\n" +
+			"\n" +
+			"    pulumi stack diff 41 44",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			var s backend.Stack
+			loadSnapshot := func(arg string) (*deploy.Snapshot, error) {
+				if version, err := strconv.Atoi(arg); err == nil {
+					// Versions are looked up in the stack's history, so we'll need the stack.
+					if s == nil {
+						if s, err = requireStack(stackName, false); err != nil {
+							return nil, err
+						}
+					}
+					return loadStackVersion(s, version)
+				}
+
+				deployment, err := readDeploymentFile(arg)
+				if err != nil {
+					return nil, err
+				}
+				snap, err := stack.DeserializeDeployment(deployment)
+				if err != nil {
+					return nil, errors.Wrapf(err, "could not deserialize deployment '%s'", arg)
+				}
+				return snap, nil
+			}
+
+			old, err := loadSnapshot(args[0])
+			if err != nil {
+				return err
+			}
+			new, err := loadSnapshot(args[1])
+			if err != nil {
+				return err
+			}
+
+			displaySnapshotDiff(old, new, backend.DisplayOptions{
+				Color:             color.Colorization(),
+				ShowSameResources: showSames,
+				DiffDisplay:       true,
+				DiffFormat:        diffFormat.DiffFormat(),
+				Debug:             debug,
+			}, secretPatterns)
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stackName, "stack", "s", "",
+		"Choose a stack other than the currently selected one")
+	cmd.PersistentFlags().BoolVarP(
+		&debug, "debug", "d", false,
+		"Print detailed debugging output")
+	cmd.PersistentFlags().StringSliceVar(
+		&secretPatterns, "secret-pattern", []string{},
+		"Treat properties matching the given path pattern (e.g. 'password' or '**.secretKey') as secrets, "+
+			"masking their values in all output")
+	cmd.PersistentFlags().VarP(
+		&color, "color", "c", "Colorize output. Choices are: always, never, raw, auto")
+	cmd.PersistentFlags().Var(
+		&diffFormat, "diff-format",
+		"The format in which to display the diff. Choices are: default, patch")
+	cmd.PersistentFlags().BoolVar(
+		&showSames, "show-sames", false,
+		"Show resources that are the same in both deployments, in addition to those that changed")
+
+	return cmd
+}
+
+// readDeploymentFile reads a deployment written by `pulumi stack export` from the given file.
+func readDeploymentFile(file string) (*apitype.UntypedDeployment, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open file")
+	}
+	defer contract.IgnoreClose(f)
+
+	var deployment apitype.UntypedDeployment
+	if err = json.NewDecoder(f).Decode(&deployment); err != nil {
+		return nil, errors.Wrapf(err, "could not read deployment from '%s'", file)
+	}
+	return &deployment, nil
+}
+
+// loadStackVersion loads the snapshot saved by the given update of a stack, numbered as in the stack's history.
+func loadStackVersion(s backend.Stack, version int) (*deploy.Snapshot, error) {
+	deployment, err := s.Backend().ExportDeploymentVersion(commandContext(), s.Name(), version)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not export version %d", version)
+	}
+	snap, err := stack.DeserializeDeployment(deployment)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not deserialize version %d", version)
+	}
+	return snap, nil
+}
+
+// displaySnapshotDiff displays the changes between two snapshots as a diff, just as a preview would.
+func displaySnapshotDiff(old, new *deploy.Snapshot, opts backend.DisplayOptions, secretPatterns []string) {
+	events := make(chan engine.Event)
+	done := make(chan bool)
+	go local.DisplayEvents("comparing", events, done, opts)
+
+	engine.CompareSnapshots(old, new, events, engine.UpdateOptions{
+		Debug:          opts.Debug,
+		SecretPatterns: secretPatterns,
+	})
+	<-done
+}
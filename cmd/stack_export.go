@@ -21,11 +21,19 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/backend"
+	"github.com/pulumi/pulumi/pkg/resource/stack"
+	"github.com/pulumi/pulumi/pkg/tokens"
 	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+	"github.com/pulumi/pulumi/pkg/workspace"
 )
 
 func newStackExportCmd() *cobra.Command {
 	var file string
+	var types []string
+	var urns []string
+	var redactSecrets bool
 	cmd := &cobra.Command{
 		Use:   "export",
 		Args:  cmdutil.MaximumNArgs(0),
@@ -35,7 +43,14 @@ func newStackExportCmd() *cobra.Command {
 			"The deployment can then be hand-edited and used to update the stack via\n" +
 			"`pulumi stack import`. This process may be used to correct inconsistencies\n" +
 			"in a stack's state due to failed deployments, manual changes to cloud\n" +
-			"resources, etc.",
+			"resources, etc.\n" +
+			"\n" +
+			"The export can be limited to resources of particular types with `--type`, or to\n" +
+			"resources whose URNs match a pattern with `--urn`, in which `*` matches any run of\n" +
+			"characters and `?` any one character.  References to resources that are left out are\n" +
+			"removed when the export is imported.  With `--redact-secrets`, the values of the stack's\n" +
+			"secret configuration are replaced with `[secret]` wherever they appear in the\n" +
+			"resources' properties.",
 		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
 			// Fetch the current stack and export its deployment
 			s, err := requireCurrentStack(false)
@@ -48,6 +63,13 @@ func newStackExportCmd() *cobra.Command {
 				return err
 			}
 
+			filter := deploymentFilter(types, urns)
+			if !filter.IsEmpty() || redactSecrets {
+				if deployment, err = filterExportedDeployment(s, deployment, filter, redactSecrets); err != nil {
+					return err
+				}
+			}
+
 			// Read from stdin or a specified file.
 			writer := os.Stdout
 			if file != "" {
@@ -68,5 +90,75 @@ func newStackExportCmd() *cobra.Command {
 	}
 	cmd.PersistentFlags().StringVarP(
 		&file, "file", "", "", "A filename to write stack output to")
+	cmd.PersistentFlags().StringSliceVar(
+		&types, "type", nil,
+		"Only export resources of the given type (may be repeated)")
+	cmd.PersistentFlags().StringSliceVar(
+		&urns, "urn", nil,
+		"Only export resources whose URNs match the given pattern (may be repeated)")
+	cmd.PersistentFlags().BoolVar(
+		&redactSecrets, "redact-secrets", false,
+		"Replace the values of the stack's secret configuration in resource properties with `[secret]`")
 	return cmd
 }
+
+// deploymentFilter returns the filter that selects the resources with the given types and URN patterns.
+func deploymentFilter(types, urns []string) stack.DeploymentFilter {
+	filter := stack.DeploymentFilter{URNs: urns}
+	for _, t := range types {
+		filter.Types = append(filter.Types, tokens.Type(t))
+	}
+	return filter
+}
+
+// filterExportedDeployment returns the part of a stack's exported deployment that the filter selects, with the values
+// of the stack's secret configuration redacted if redactSecrets is set.
+func filterExportedDeployment(s backend.Stack, deployment *apitype.UntypedDeployment, filter stack.DeploymentFilter,
+	redactSecrets bool) (*apitype.UntypedDeployment, error) {
+
+	typed, err := stack.UnmarshalUntypedDeployment(deployment)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read the stack's deployment")
+	}
+	if !filter.IsEmpty() {
+		if typed = stack.FilterDeployment(typed, filter); len(typed.Resources) == 0 {
+			return nil, errors.New("no resources match the given filters")
+		}
+	}
+	if redactSecrets {
+		secrets, secretsErr := stackSecrets(s)
+		if secretsErr != nil {
+			return nil, secretsErr
+		}
+		stack.RedactSecrets(typed, secrets)
+	}
+	return stack.MarshalUntypedDeployment(typed)
+}
+
+// stackSecrets returns the plaintext values of a stack's secret configuration.
+func stackSecrets(s backend.Stack) ([]string, error) {
+	ps, err := workspace.DetectProjectStack(s.Name().StackName())
+	if err != nil {
+		return nil, err
+	}
+	if !ps.Config.HasSecureValue() {
+		return nil, nil
+	}
+
+	decrypter, err := backend.GetStackCrypter(s)
+	if err != nil {
+		return nil, err
+	}
+	var secrets []string
+	for key, value := range ps.Config {
+		if !value.Secure() {
+			continue
+		}
+		plaintexts, valueErr := value.SecureValues(decrypter)
+		if valueErr != nil {
+			return nil, errors.Wrapf(valueErr, "could not decrypt configuration value '%s'", prettyKey(key))
+		}
+		secrets = append(secrets, plaintexts...)
+	}
+	return secrets, nil
+}
@@ -15,14 +15,22 @@
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
 
 	"github.com/pulumi/pulumi/pkg/graph"
 	"github.com/pulumi/pulumi/pkg/graph/dotconv"
+	"github.com/pulumi/pulumi/pkg/graph/mermaidconv"
 	"github.com/pulumi/pulumi/pkg/resource"
 	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/tokens"
 	"github.com/pulumi/pulumi/pkg/util/cmdutil"
-	"github.com/spf13/cobra"
 )
 
 // Whether or not we should ignore parent edges when building up our graph.
@@ -37,17 +45,43 @@ var dependencyEdgeColor string
 // The color of parent edges in the graph. Defaults to #AA6639, an orange.
 var parentEdgeColor string
 
+// The color of property edges in the graph. Defaults to #4B56A3, a blue.
+var propertyEdgeColor string
+
 func newStackGraphCmd() *cobra.Command {
+	var format string
+	var types []string
+	var urns []string
+	var propertyEdges bool
+
 	cmd := &cobra.Command{
-		Use:   "graph",
+		Use:   "graph <file>",
 		Args:  cmdutil.ExactArgs(1),
 		Short: "Export a stack's dependency graph to a file",
 		Long: "Export a stack's dependency graph to a file.\n" +
 			"\n" +
 			"This command can be used to view the dependency graph that a Pulumi program\n" +
-			"admitted when it was ran. This graph is output in the DOT format. This command operates\n" +
-			"on your stack's most recent deployment.",
+			"admitted when it was ran. This command operates on your stack's most recent deployment.\n" +
+			"The graph is written in the format given by `--format`: DOT (the default), JSON, or a\n" +
+			"Mermaid flowchart.  A file name of `-` writes the graph to standard output.\n" +
+			"\n" +
+			"The graph can be restricted to resources of particular types with `--type`, or to\n" +
+			"particular resources with `--urn`.  With `--property-edges`, edges are also drawn from\n" +
+			"each output property of a resource to the input properties of its dependents that it\n" +
+			"feeds; these are inferred by matching the properties' values, so they may be incomplete.",
 		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			var write func(dg *dependencyGraph, w io.Writer) error
+			switch format {
+			case "dot":
+				write = func(dg *dependencyGraph, w io.Writer) error { return dotconv.Print(dg, w) }
+			case "json":
+				write = printJSONGraph
+			case "mermaid":
+				write = func(dg *dependencyGraph, w io.Writer) error { return mermaidconv.Print(dg, w) }
+			default:
+				return errors.Errorf("unrecognized graph format '%s'; choices are: dot, json, mermaid", format)
+			}
+
 			s, err := requireCurrentStack(false)
 			if err != nil {
 				return err
@@ -57,13 +91,17 @@ func newStackGraphCmd() *cobra.Command {
 				return err
 			}
 
-			dg := makeDependencyGraph(snap)
+			dg := makeDependencyGraph(snap, graphFilter(types, urns), propertyEdges)
+			if args[0] == "-" {
+				return write(dg, os.Stdout)
+			}
+
 			file, err := os.Create(args[0])
 			if err != nil {
 				return err
 			}
 
-			if err := dotconv.Print(dg, file); err != nil {
+			if err := write(dg, file); err != nil {
 				_ = file.Close()
 				return err
 			}
@@ -74,6 +112,14 @@ func newStackGraphCmd() *cobra.Command {
 		}),
 	}
 
+	cmd.Flags().StringVar(&format, "format", "dot",
+		"The format in which to write the graph. Choices are: dot, json, mermaid")
+	cmd.Flags().StringSliceVar(&types, "type", nil,
+		"Only include resources of the given type (may be repeated)")
+	cmd.Flags().StringSliceVar(&urns, "urn", nil,
+		"Only include the resource with the given URN (may be repeated)")
+	cmd.Flags().BoolVar(&propertyEdges, "property-edges", false,
+		"Include edges from the output properties of resources to the input properties that they feed")
 	cmd.Flags().BoolVar(&ignoreParentEdges, "ignore-parent-edges", false,
 		"Ignores edges introduced by parent/child resource relationships")
 	cmd.Flags().BoolVar(&ignoreDependencyEdges, "ignore-dependency-edges", false,
@@ -82,9 +128,30 @@ func newStackGraphCmd() *cobra.Command {
 		"Sets the color of dependency edges in the graph")
 	cmd.Flags().StringVar(&parentEdgeColor, "parent-edge-color", "#AA6639",
 		"Sets the color of parent edges in the graph")
+	cmd.Flags().StringVar(&propertyEdgeColor, "property-edge-color", "#4B56A3",
+		"Sets the color of property edges in the graph")
 	return cmd
 }
 
+// graphFilter returns a function that reports whether a resource belongs in the graph, given the types and URNs that
+// the graph is restricted to.  If neither is given, every resource belongs.
+func graphFilter(types, urns []string) func(res *resource.State) bool {
+	if len(types) == 0 && len(urns) == 0 {
+		return func(*resource.State) bool { return true }
+	}
+
+	include := make(map[string]bool)
+	for _, t := range types {
+		include[t] = true
+	}
+	for _, urn := range urns {
+		include[urn] = true
+	}
+	return func(res *resource.State) bool {
+		return include[string(res.Type)] || include[string(res.URN)]
+	}
+}
+
 // All of the types and code within this file are to provide implementations of the interfaces
 // in the `graph` package, so that we can use the `dotconv` package to output our graph in the
 // DOT format.
@@ -147,6 +214,37 @@ func (edge *parentEdge) Color() string {
 	return parentEdgeColor
 }
 
+// propertyEdges represent the flow of values from the output properties of a
+// resource to the input properties of a resource that depends on it. An edge
+// exists from node A to node B if one of A's outputs was passed to B as an input.
+type propertyEdge struct {
+	to     *dependencyVertex
+	from   *dependencyVertex
+	output resource.PropertyKey
+	input  resource.PropertyKey
+}
+
+func (edge *propertyEdge) Data() interface{} {
+	return nil
+}
+
+// Property edges are labeled with the properties that they connect.
+func (edge *propertyEdge) Label() string {
+	return fmt.Sprintf("%s -> %s", edge.output, edge.input)
+}
+
+func (edge *propertyEdge) To() graph.Vertex {
+	return edge.to
+}
+
+func (edge *propertyEdge) From() graph.Vertex {
+	return edge.from
+}
+
+func (edge *propertyEdge) Color() string {
+	return propertyEdgeColor
+}
+
 // A dependencyVertex contains a reference to the graph to which it belongs
 // and to the resource state that it represents. Incoming and outgoing edges
 // are calculated on-demand using the combination of the graph and the state.
@@ -199,13 +297,19 @@ func (dg *dependencyGraph) Roots() []graph.Edge {
 }
 
 // Makes a dependency graph from a deployment snapshot, allocating a vertex
-// for every resource in the graph.
-func makeDependencyGraph(snapshot *deploy.Snapshot) *dependencyGraph {
+// for every resource in the graph that the filter includes.
+func makeDependencyGraph(snapshot *deploy.Snapshot, include func(res *resource.State) bool,
+	propertyEdges bool) *dependencyGraph {
+
 	dg := &dependencyGraph{
 		vertices: make(map[resource.URN]*dependencyVertex),
 	}
 
 	for _, resource := range snapshot.Resources {
+		if !include(resource) {
+			continue
+		}
+
 		vertex := &dependencyVertex{
 			graph:    dg,
 			resource: resource,
@@ -215,23 +319,34 @@ func makeDependencyGraph(snapshot *deploy.Snapshot) *dependencyGraph {
 	}
 
 	for _, vertex := range dg.vertices {
-		if !ignoreDependencyEdges {
-			// Incoming edges are directly stored within the checkpoint file; they represent
-			// resources on which this vertex immediately depends upon.
-			for _, dep := range vertex.resource.Dependencies {
-				vertexWeDependOn := vertex.graph.vertices[dep]
+		// Edges are only drawn between the resources that made it into the graph.
+		for _, dep := range vertex.resource.Dependencies {
+			vertexWeDependOn, has := vertex.graph.vertices[dep]
+			if !has {
+				continue
+			}
+
+			if !ignoreDependencyEdges {
+				// Incoming edges are directly stored within the checkpoint file; they represent
+				// resources on which this vertex immediately depends upon.
 				edge := &dependencyEdge{to: vertex, from: vertexWeDependOn}
 				vertex.incomingEdges = append(vertex.incomingEdges, edge)
 				vertexWeDependOn.outgoingEdges = append(vertexWeDependOn.outgoingEdges, edge)
 			}
+
+			if propertyEdges {
+				for _, edge := range makePropertyEdges(vertexWeDependOn, vertex) {
+					vertex.incomingEdges = append(vertex.incomingEdges, edge)
+					vertexWeDependOn.outgoingEdges = append(vertexWeDependOn.outgoingEdges, edge)
+				}
+			}
 		}
 
 		// alongside the dependency graph sits the resource parentage graph, which
 		// is also displayed as part of this graph, although with different colored
 		// edges.
 		if !ignoreParentEdges {
-			if parent := vertex.resource.Parent; parent != resource.URN("") {
-				parentVertex := dg.vertices[parent]
+			if parentVertex, has := dg.vertices[vertex.resource.Parent]; has {
 				vertex.outgoingEdges = append(vertex.outgoingEdges, &parentEdge{
 					to:   parentVertex,
 					from: vertex,
@@ -242,3 +357,84 @@ func makeDependencyGraph(snapshot *deploy.Snapshot) *dependencyGraph {
 
 	return dg
 }
+
+// makePropertyEdges infers which of a resource's outputs were passed as inputs to a resource that depends on it. The
+// checkpoint doesn't record this, so an output is taken to feed each input with the same value. Values that are
+// likely to match by coincidence, like booleans and empty strings, are ignored.
+func makePropertyEdges(from, to *dependencyVertex) []graph.Edge {
+	outputs := resource.PropertyMap{}
+	for k, v := range from.resource.Outputs {
+		outputs[k] = v
+	}
+	if from.resource.ID != "" {
+		outputs["id"] = resource.NewStringProperty(string(from.resource.ID))
+	}
+
+	var edges []graph.Edge
+	for _, input := range to.resource.Inputs.StableKeys() {
+		in := to.resource.Inputs[input]
+		if in.IsNull() || in.IsBool() || in.IsComputed() || in.IsOutput() || (in.IsString() && in.StringValue() == "") {
+			continue
+		}
+		for _, output := range outputs.StableKeys() {
+			if outputs[output].DeepEquals(in) {
+				edges = append(edges, &propertyEdge{to: to, from: from, output: output, input: input})
+			}
+		}
+	}
+	return edges
+}
+
+// printJSONGraph writes a dependency graph as a JSON object holding a list of the
+// graph's resources and a list of the edges between them.
+func printJSONGraph(dg *dependencyGraph, w io.Writer) error {
+	type jsonVertex struct {
+		URN    resource.URN `json:"urn"`
+		Type   tokens.Type  `json:"type"`
+		Custom bool         `json:"custom"`
+	}
+	type jsonEdge struct {
+		Kind   string               `json:"kind"`
+		From   resource.URN         `json:"from"`
+		To     resource.URN         `json:"to"`
+		Output resource.PropertyKey `json:"output,omitempty"`
+		Input  resource.PropertyKey `json:"input,omitempty"`
+	}
+
+	urns := make([]string, 0, len(dg.vertices))
+	for urn := range dg.vertices {
+		urns = append(urns, string(urn))
+	}
+	sort.Strings(urns)
+
+	vertices, edges := []jsonVertex{}, []jsonEdge{}
+	for _, urn := range urns {
+		vertex := dg.vertices[resource.URN(urn)]
+		vertices = append(vertices, jsonVertex{
+			URN:    vertex.resource.URN,
+			Type:   vertex.resource.Type,
+			Custom: vertex.resource.Custom,
+		})
+
+		for _, out := range vertex.outgoingEdges {
+			from, to := out.From().(*dependencyVertex), out.To().(*dependencyVertex)
+			edge := jsonEdge{From: from.resource.URN, To: to.resource.URN}
+			switch out := out.(type) {
+			case *dependencyEdge:
+				edge.Kind = "dependency"
+			case *parentEdge:
+				edge.Kind = "parent"
+			case *propertyEdge:
+				edge.Kind, edge.Output, edge.Input = "property", out.output, out.input
+			}
+			edges = append(edges, edge)
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(struct {
+		Resources []jsonVertex `json:"resources"`
+		Edges     []jsonEdge   `json:"edges"`
+	}{Resources: vertices, Edges: edges})
+}
@@ -24,14 +24,19 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/backend"
 	"github.com/pulumi/pulumi/pkg/diag"
 	"github.com/pulumi/pulumi/pkg/resource/stack"
+	"github.com/pulumi/pulumi/pkg/tokens"
 	"github.com/pulumi/pulumi/pkg/util/cmdutil"
 )
 
 func newStackImportCmd() *cobra.Command {
 	var force bool
 	var file string
+	var types []string
+	var urns []string
+	var retarget bool
 	cmd := &cobra.Command{
 		Use:   "import",
 		Args:  cmdutil.MaximumNArgs(0),
@@ -41,7 +46,14 @@ func newStackImportCmd() *cobra.Command {
 			"A deployment that was exported from a stack using `pulumi stack export` and\n" +
 			"hand-edited to correct inconsistencies due to failed updates, manual changes\n" +
 			"to cloud resources, etc. can be reimported to the stack using this command.\n" +
-			"The updated deployment will be read from standard in.",
+			"The updated deployment will be read from standard in.\n" +
+			"\n" +
+			"Only some of the deployment's resources can be imported, by selecting them with `--type`\n" +
+			"and `--urn`, just as for `pulumi stack export`.  References to resources that aren't in\n" +
+			"what is imported, such as those left out of a filtered export, are removed.  To move\n" +
+			"resources exported from another stack to this one, pass `--retarget`, which rewrites\n" +
+			"their URNs to belong to this stack.  The deployment is checked for problems that would\n" +
+			"keep the stack from being used before it is imported.",
 		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
 			// Fetch the current stack and import a deployment.
 			s, err := requireCurrentStack(false)
@@ -65,24 +77,40 @@ func newStackImportCmd() *cobra.Command {
 				return err
 			}
 
+			// Select the resources to import, and fix up their references to any that weren't selected.
+			imported, err := prepareImportedDeployment(&deployment, s.Name().StackName(),
+				deploymentFilter(types, urns), retarget)
+			if err != nil {
+				return importedDeploymentError(s, err)
+			}
+
+			// Check the deployment for problems that would keep the stack from being used, and refuse to import it
+			// if there are any, unless --force was passed.
+			_, problems, err := stack.VerifyUntypedDeployment(imported)
+			if err != nil {
+				return importedDeploymentError(s, err)
+			}
+			var result error
+			for _, problem := range problems {
+				if force {
+					cmdutil.Diag().Warningf(diag.Message(problem.URN, problem.Message))
+				} else {
+					result = multierror.Append(result, errors.New(problem.String()))
+				}
+			}
+			if result != nil {
+				return multierror.Append(result,
+					errors.New("importing this file could be dangerous; rerun with --force to proceed anyway"))
+			}
+
 			// We do, however, now want to unmarshal the json.RawMessage into a real, typed deployment.  We do this so
 			// we can check that the deployment doesn't contain resources from a stack other than the selected one. This
 			// catches errors wherein someone imports the wrong stack's deployment (which can seriously hork things).
-			snapshot, err := stack.DeserializeDeployment(&deployment)
+			snapshot, err := stack.DeserializeDeployment(imported)
 			if err != nil {
-				switch err {
-				case stack.ErrDeploymentSchemaVersionTooOld:
-					return fmt.Errorf("the stack '%s' is too old to be used by this version of the Pulumi CLI",
-						s.Name().StackName())
-				case stack.ErrDeploymentSchemaVersionTooNew:
-					return fmt.Errorf("the stack '%s' is newer than what this version of the Pulumi CLI understands. "+
-						"Please update your version of the Pulumi CLI", s.Name().StackName())
-				}
-
-				return errors.Wrap(err, "could not deserialize deployment")
+				return importedDeploymentError(s, err)
 			}
 
-			var result error
 			for _, res := range snapshot.Resources {
 				if res.URN.Stack() != s.Name().StackName() {
 					msg := fmt.Sprintf("resource '%s' is from a different stack (%s != %s)",
@@ -105,7 +133,7 @@ func newStackImportCmd() *cobra.Command {
 			}
 
 			// Now perform the deployment.
-			if err = s.ImportDeployment(commandContext(), &deployment); err != nil {
+			if err = importStackDeployment(s, imported); err != nil {
 				return errors.Wrap(err, "could not import deployment")
 			}
 			fmt.Printf("Import successful.\n")
@@ -118,6 +146,70 @@ func newStackImportCmd() *cobra.Command {
 		"Force the import to occur, even if apparent errors are discovered beforehand (not recommended)")
 	cmd.PersistentFlags().StringVarP(
 		&file, "file", "", "", "A filename to read stack input from")
+	cmd.PersistentFlags().StringSliceVar(
+		&types, "type", nil,
+		"Only import resources of the given type (may be repeated)")
+	cmd.PersistentFlags().StringSliceVar(
+		&urns, "urn", nil,
+		"Only import resources whose URNs match the given pattern (may be repeated)")
+	cmd.PersistentFlags().BoolVar(
+		&retarget, "retarget", false,
+		"Rewrite the URNs of resources exported from another stack to belong to this one")
 
 	return cmd
 }
+
+// prepareImportedDeployment returns the part of a deployment to import into the given stack: the resources that the
+// filter selects, rewritten to belong to the stack if retarget is set, with their references to any resources that
+// aren't imported removed.  Each removed reference is reported as a warning.
+func prepareImportedDeployment(deployment *apitype.UntypedDeployment, stackName tokens.QName,
+	filter stack.DeploymentFilter, retarget bool) (*apitype.UntypedDeployment, error) {
+
+	typed, err := stack.UnmarshalUntypedDeployment(deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := false
+	if !filter.IsEmpty() {
+		if typed = stack.FilterDeployment(typed, filter); len(typed.Resources) == 0 {
+			return nil, errors.New("no resources match the given filters")
+		}
+		changed = true
+	}
+	for _, fix := range stack.FixupDeployment(typed) {
+		cmdutil.Diag().Warningf(diag.Message(fix.URN, fix.Message))
+		changed = true
+	}
+	if retarget {
+		snap, deserializeErr := stack.DeserializeCheckpoint(&apitype.CheckpointV1{Latest: typed})
+		if deserializeErr != nil {
+			return nil, deserializeErr
+		}
+		if err = moveStackState(snap, stackName); err != nil {
+			return nil, err
+		}
+		typed, changed = stack.SerializeDeployment(snap), true
+	}
+
+	// Leave a deployment that needs no changes as it is, so that any fields that this version of the CLI doesn't
+	// know about are kept.
+	if !changed {
+		return deployment, nil
+	}
+	return stack.MarshalUntypedDeployment(typed)
+}
+
+// importedDeploymentError returns the error to report when a deployment to import into a stack can't be read.
+func importedDeploymentError(s backend.Stack, err error) error {
+	switch err {
+	case stack.ErrDeploymentSchemaVersionTooOld:
+		return fmt.Errorf("the stack '%s' is too old to be used by this version of the Pulumi CLI",
+			s.Name().StackName())
+	case stack.ErrDeploymentSchemaVersionTooNew:
+		return fmt.Errorf("the stack '%s' is newer than what this version of the Pulumi CLI understands. "+
+			"Please update your version of the Pulumi CLI", s.Name().StackName())
+	}
+
+	return errors.Wrap(err, "could not deserialize deployment")
+}
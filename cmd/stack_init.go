@@ -19,11 +19,13 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/pulumi/pulumi/pkg/backend/cloud"
+	"github.com/pulumi/pulumi/pkg/backend/local"
 	"github.com/pulumi/pulumi/pkg/util/cmdutil"
 )
 
 func newStackInitCmd() *cobra.Command {
 	var ppc string
+	var secretsProvider string
 	cmd := &cobra.Command{
 		Use:   "init <stack-name>",
 		Args:  cmdutil.MaximumNArgs(1),
@@ -31,7 +33,22 @@ func newStackInitCmd() *cobra.Command {
 		Long: "Create an empty stack with the given name, ready for updates\n" +
 			"\n" +
 			"This command creates an empty stack with the given name.  It has no resources,\n" +
-			"but afterwards it can become the target of a deployment using the `update` command.",
+			"but afterwards it can become the target of a deployment using the `update` command.\n" +
+			"\n" +
+			"In a backend that keeps its state itself, the stack's secrets are encrypted with a key\n" +
+			"derived from a passphrase, unless `--secrets-provider` gives the URL of a key in a key\n" +
+			"management service, which then encrypts a key of the stack's own:\n" +
+			"\n" +
+			"    awskms://<key>    a key in AWS KMS: a key ID or ARN, or an alias such as alias/pulumi.\n" +
+			"                      The URL's query may give its region, profile and endpoint, as in\n" +
+			"                      awskms://alias/pulumi?region=us-west-2&profile=prod.\n" +
+			"    azurekeyvault://<vault>.vault.azure.net/keys/<key>\n" +
+			"                      a key in Azure Key Vault, used as the service principal given by\n" +
+			"                      AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET, or else\n" +
+			"                      with the machine's managed identity.\n" +
+			"    gcpkms://projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key>\n" +
+			"                      a key in Google Cloud KMS, used with the application default\n" +
+			"                      credentials, or the machine's or GKE workload's service account.",
 		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
 			b, err := currentBackend()
 			if err != nil {
@@ -40,9 +57,16 @@ func newStackInitCmd() *cobra.Command {
 
 			var createOpts interface{}
 			if _, ok := b.(cloud.Backend); ok {
+				if secretsProvider != "" {
+					return errors.Errorf("%s manages the encryption of secrets itself", b.Name())
+				}
 				createOpts = cloud.CreateStackOptions{
 					CloudName: ppc,
 				}
+			} else if secretsProvider != "" {
+				createOpts = local.CreateStackOptions{
+					SecretsProvider: secretsProvider,
+				}
 			}
 
 			var stackName string
@@ -71,5 +95,8 @@ func newStackInitCmd() *cobra.Command {
 	}
 	cmd.PersistentFlags().StringVarP(
 		&ppc, "ppc", "p", "", "An optional Pulumi Private Cloud (PPC) name to initialize this stack in")
+	cmd.PersistentFlags().StringVar(
+		&secretsProvider, "secrets-provider", "", "The secrets provider that encrypts the stack's secrets: "+
+			"`passphrase`, the default, or the URL of a key such as awskms://alias/pulumi")
 	return cmd
 }
@@ -18,11 +18,13 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/dustin/go-humanize"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
+	"github.com/pulumi/pulumi/pkg/apitype"
 	"github.com/pulumi/pulumi/pkg/backend"
 	"github.com/pulumi/pulumi/pkg/backend/cloud"
 	"github.com/pulumi/pulumi/pkg/backend/state"
@@ -34,11 +36,24 @@ import (
 
 func newStackLsCmd() *cobra.Command {
 	var allStacks bool
+	var tagFilters []string
+	var showTags bool
 	cmd := &cobra.Command{
 		Use:   "ls",
 		Short: "List all known stacks",
-		Args:  cmdutil.NoArgs,
+		Long: "List all known stacks.\n" +
+			"\n" +
+			"By default, the stacks of the current project are listed.  Pass `--tag name=value` to list only\n" +
+			"the stacks that have a tag with that value, or `--tag name` to list those that have the tag at\n" +
+			"all; if more than one is given, stacks must match them all.  Pass `--show-tags` to list the tags\n" +
+			"of each stack, too.",
+		Args: cmdutil.NoArgs,
 		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			filters, err := parseStackTagFilters(tagFilters)
+			if err != nil {
+				return err
+			}
+
 			// Ensure we are in a project; if not, we will fail.
 			projPath, err := workspace.DetectProjectPath()
 			if err != nil {
@@ -82,6 +97,9 @@ func newStackLsCmd() *cobra.Command {
 			_, showURLColumn := b.(cloud.Backend)
 
 			for _, stack := range bs {
+				if !matchesStackTagFilters(stack.Tags(), filters) {
+					continue
+				}
 				name := stack.Name().String()
 				stacks[name] = stack
 				stackNames = append(stackNames, name)
@@ -119,6 +137,10 @@ func newStackLsCmd() *cobra.Command {
 				formatDirective += " %s"
 				headers = append(headers, "URL")
 			}
+			if showTags {
+				formatDirective += " %s"
+				headers = append(headers, "TAGS")
+			}
 
 			formatDirective = formatDirective + "\n"
 
@@ -167,6 +189,9 @@ func newStackLsCmd() *cobra.Command {
 					}
 					values = append(values, url)
 				}
+				if showTags {
+					values = append(values, formatStackTags(stack.Tags()))
+				}
 
 				fmt.Printf(formatDirective, values...)
 			}
@@ -176,10 +201,29 @@ func newStackLsCmd() *cobra.Command {
 	}
 	cmd.PersistentFlags().BoolVarP(
 		&allStacks, "all", "a", false, "List all stacks instead of just stacks for the current project")
+	cmd.PersistentFlags().StringSliceVarP(
+		&tagFilters, "tag", "t", []string{},
+		"List only stacks with the given tag, as `name` or `name=value`; may be repeated")
+	cmd.PersistentFlags().BoolVar(
+		&showTags, "show-tags", false, "Show the tags of each stack")
 
 	return cmd
 }
 
+// formatStackTags formats a stack's tags as a single, sorted list of `name=value` pairs.
+func formatStackTags(tags map[apitype.StackTagName]string) string {
+	if len(tags) == 0 {
+		return "n/a"
+	}
+
+	var pairs []string
+	for name, value := range tags {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
 func hasAnyPPCStacks(stacks []backend.Stack) (bool, int) {
 	res, maxLen := false, 0
 	for _, s := range stacks {
@@ -0,0 +1,265 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/backend"
+	"github.com/pulumi/pulumi/pkg/backend/local"
+	"github.com/pulumi/pulumi/pkg/diag"
+	"github.com/pulumi/pulumi/pkg/resource/config"
+	"github.com/pulumi/pulumi/pkg/resource/stack"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+	"github.com/pulumi/pulumi/pkg/workspace"
+)
+
+func newStackMigrateCmd() *cobra.Command {
+	var stackName string
+	var to string
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Args:  cmdutil.NoArgs,
+		Short: "Copy a stack to another backend",
+		Long: "Copy a stack to another backend.\n" +
+			"\n" +
+			"This command creates a stack with the same name in the backend at the URL given by `--to`,\n" +
+			"such as an object store, a local directory, or the Pulumi Service, and copies the current\n" +
+			"stack (or the one given by `--stack`) to it: its resources, its configuration, its tags, and,\n" +
+			"if the new backend keeps its state itself, the history of its updates.  The Pulumi Service\n" +
+			"only records the updates that it runs, so history isn't copied to it.  The new stack is also\n" +
+			"tagged from the project, just as `pulumi stack init` tags it.\n" +
+			"\n" +
+			"The stack's secrets are decrypted and encrypted again for the new backend.  Both stacks\n" +
+			"share the stack's settings file, so afterwards its secrets can only be read through the\n" +
+			"new backend.  The stack is left in the current backend, which stays the one logged into;\n" +
+			"once the migration is verified, remove the old stack with `pulumi stack rm` and log into\n" +
+			"the new backend with `pulumi login`.  The Pulumi Service must have been logged into before,\n" +
+			"so that its credentials are stored.",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			if to == "" {
+				return errors.New("missing required flag --to")
+			}
+			creds, err := workspace.GetStoredCredentials()
+			if err != nil {
+				return err
+			}
+			if strings.TrimSuffix(to, "/") == strings.TrimSuffix(creds.Current, "/") {
+				return errors.Errorf("stacks are already kept in %s", to)
+			}
+
+			src, err := requireStack(stackName, false)
+			if err != nil {
+				return err
+			}
+			dstBackend, err := backendForURL(to)
+			if err != nil {
+				return err
+			}
+			dstRef, err := dstBackend.ParseStackReference(string(src.Name().StackName()))
+			if err != nil {
+				return err
+			}
+			ps, err := workspace.DetectProjectStack(src.Name().StackName())
+			if err != nil {
+				return err
+			}
+
+			// Read everything to copy before creating the new stack, so that nothing is created if it can't be read.
+			migration, err := readStackMigration(src)
+			if err != nil {
+				return err
+			}
+			_, copyHistory := dstBackend.(local.Backend)
+
+			changes := []string{
+				fmt.Sprintf("create stack '%s' in %s", dstRef, dstBackend.Name()),
+				fmt.Sprintf("copy its %d resource(s) and %d configuration value(s)",
+					migration.resources, len(ps.Config)),
+			}
+			switch {
+			case len(migration.history) == 0:
+			case copyHistory:
+				changes = append(changes, fmt.Sprintf("copy the history of its %d update(s)", len(migration.history)))
+			default:
+				changes = append(changes, fmt.Sprintf("leave behind the history of its %d update(s), which %s "+
+					"can't record", len(migration.history), dstBackend.Name()))
+			}
+			if err = confirmStateEdit(src, changes, yes); err != nil {
+				return err
+			}
+
+			dst, err := dstBackend.CreateStack(commandContext(), dstRef, nil)
+			if err != nil {
+				return err
+			}
+			if len(src.Tags()) > 0 {
+				tags := copyStackTags(dst.Tags())
+				for name, value := range src.Tags() {
+					tags[name] = value
+				}
+				if err = backend.UpdateStackTags(commandContext(), dst, tags); err != nil {
+					return errors.Wrap(err, "could not copy the stack's tags")
+				}
+			}
+			reencrypter, err := newConfigReencrypter(src, dst, ps.Config, migration.history)
+			if err != nil {
+				return err
+			}
+
+			// Getting the new stack's crypter may have saved a new passphrase's salt to the stack's settings, so read
+			// them again before re-encrypting the secrets in them.
+			if ps, err = workspace.DetectProjectStack(src.Name().StackName()); err != nil {
+				return err
+			}
+			if ps.Config, err = reencrypter.reencrypt(ps.Config); err != nil {
+				return err
+			}
+			if err = workspace.SaveProjectStack(src.Name().StackName(), ps); err != nil {
+				return err
+			}
+
+			if err = dst.ImportDeployment(commandContext(), migration.deployment); err != nil {
+				return errors.Wrap(err, "could not import the stack's resources")
+			}
+
+			if copyHistory && len(migration.history) > 0 {
+				history := make([]backend.UpdateInfo, len(migration.history))
+				for i, update := range migration.history {
+					if update.Config, err = reencrypter.reencrypt(update.Config); err != nil {
+						return err
+					}
+					history[i] = update
+				}
+				if err = dstBackend.(local.Backend).ImportHistory(commandContext(), dst.Name(), history,
+					migration.deployments); err != nil {
+					return errors.Wrap(err, "could not copy the stack's history")
+				}
+			}
+
+			fmt.Printf("Migrated stack '%s' to %s.\n", src.Name(), dstBackend.Name())
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stackName, "stack", "s", "",
+		"Choose a stack to migrate other than the currently selected one")
+	cmd.PersistentFlags().StringVar(
+		&to, "to", "",
+		"The URL of the backend to migrate the stack to, as given to `pulumi login`")
+	cmd.PersistentFlags().BoolVarP(
+		&yes, "yes", "y", false,
+		"Migrate the stack without asking for confirmation")
+
+	return cmd
+}
+
+// stackMigration is what is copied when a stack is migrated to another backend.
+type stackMigration struct {
+	deployment  *apitype.UntypedDeployment   // the stack's current deployment.
+	resources   int                          // the number of resources in the deployment.
+	history     []backend.UpdateInfo         // the stack's updates, the newest first.
+	deployments []*apitype.UntypedDeployment // the deployment left by each of the updates.
+}
+
+// readStackMigration reads what is to be copied from a stack.  If the deployment left by any of the stack's updates
+// can't be read, its history is left out, with a warning.
+func readStackMigration(s backend.Stack) (*stackMigration, error) {
+	deployment, err := s.ExportDeployment(commandContext())
+	if err != nil {
+		return nil, err
+	}
+	snap, err := stack.DeserializeDeployment(deployment)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read the stack's deployment")
+	}
+	migration := &stackMigration{deployment: deployment}
+	if snap != nil {
+		migration.resources = len(snap.Resources)
+	}
+
+	history, err := s.Backend().GetHistory(commandContext(), s.Name())
+	if err != nil {
+		return nil, err
+	}
+	deployments := make([]*apitype.UntypedDeployment, len(history))
+	for i := range history {
+		version := len(history) - i
+		deployments[i], err = s.Backend().ExportDeploymentVersion(commandContext(), s.Name(), version)
+		if err != nil {
+			cmdutil.Diag().Warningf(diag.Message("", fmt.Sprintf(
+				"the stack's history won't be copied, since the deployment left by update %d can't be read: %v",
+				version, err)))
+			return migration, nil
+		}
+	}
+	migration.history, migration.deployments = history, deployments
+	return migration, nil
+}
+
+// configReencrypter decrypts the secret configuration values of one stack and encrypts them for another.
+type configReencrypter struct {
+	decrypter config.Decrypter
+	encrypter config.Encrypter
+}
+
+// newConfigReencrypter returns a configReencrypter for moving the given configurations from one stack to another.
+// The stacks' crypters, which may prompt for passphrases, are only gotten if any of the configurations have secrets.
+func newConfigReencrypter(src, dst backend.Stack, cfg config.Map,
+	history []backend.UpdateInfo) (*configReencrypter, error) {
+
+	hasSecrets := cfg.HasSecureValue()
+	for _, update := range history {
+		hasSecrets = hasSecrets || update.Config.HasSecureValue()
+	}
+	if !hasSecrets {
+		return &configReencrypter{}, nil
+	}
+
+	decrypter, err := backend.GetStackCrypter(src)
+	if err != nil {
+		return nil, err
+	}
+	encrypter, err := backend.GetStackCrypter(dst)
+	if err != nil {
+		return nil, err
+	}
+	return &configReencrypter{decrypter: decrypter, encrypter: encrypter}, nil
+}
+
+// reencrypt returns a copy of a configuration with its secret values encrypted for the new stack.
+func (r *configReencrypter) reencrypt(cfg config.Map) (config.Map, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	result := make(config.Map)
+	for key, value := range cfg {
+		copied, err := value.Copy(r.decrypter, config.EncrypterForKey(r.encrypter, key))
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not re-encrypt '%s'", prettyKey(key))
+		}
+		result[key] = copied
+	}
+	return result, nil
+}
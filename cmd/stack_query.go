@@ -0,0 +1,196 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/query"
+	"github.com/pulumi/pulumi/pkg/resource/stack"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+)
+
+func newStackQueryCmd() *cobra.Command {
+	var stackName string
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "query <expression>",
+		Args:  cmdutil.ExactArgs(1),
+		Short: "Query a stack's resources and outputs",
+		Long: "Query a stack's resources and outputs.\n" +
+			"\n" +
+			"The expression, written in the JMESPath query language (http://jmespath.org), is\n" +
+			"evaluated against an object with two properties: `resources`, an array holding each of\n" +
+			"the stack's resources, and `outputs`, an object holding the stack's outputs.  Each resource\n" +
+			"has the properties `urn`, `type`, `name`, `id`, `parent`, `custom`, `protect`, `delete`,\n" +
+			"`dependencies`, `inputs`, and `outputs`.  For example, to find the resources tagged with\n" +
+			"`env: prod`, or to list the public IPs of all instances:\n" +
+			"\n" +
+			"    pulumi stack query \"resources[?outputs.tags.env == 'prod'].urn\"\n" +
+			"    pulumi stack query \"resources[?outputs.publicIp].{name: name, ip: outputs.publicIp}\"\n" +
+			"\n" +
+			"By default, the result is printed as a table; pass `--output json` to print it as JSON.",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				return errors.Errorf("unknown output format '%s'; choices are: table, json", output)
+			}
+
+			q, err := query.Parse(args[0])
+			if err != nil {
+				return errors.Wrap(err, "could not parse query")
+			}
+
+			s, err := requireStack(stackName, false)
+			if err != nil {
+				return err
+			}
+			snap, err := s.Snapshot(commandContext())
+			if err != nil {
+				return err
+			}
+
+			var resources []*resource.State
+			if snap != nil {
+				resources = snap.Resources
+			}
+			outputs := resource.PropertyMap{}
+			if res, _ := stack.GetRootStackResource(snap); res != nil && res.Outputs != nil {
+				outputs = res.Outputs
+			}
+
+			result, err := q.Eval(resource.NewObjectProperty(resource.PropertyMap{
+				"resources": query.ResourcesValue(resources),
+				"outputs":   resource.NewObjectProperty(outputs),
+			}))
+			if err != nil {
+				return errors.Wrap(err, "could not evaluate query")
+			}
+
+			if output == "json" {
+				b, err := json.MarshalIndent(queryResultMappable(result), "", "    ")
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(b))
+				return nil
+			}
+			printQueryResult(result)
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stackName, "stack", "s", "",
+		"Choose a stack other than the currently selected one")
+	cmd.PersistentFlags().StringVarP(
+		&output, "output", "o", "table",
+		"The format in which to print the result. Choices are: table, json")
+
+	return cmd
+}
+
+// queryResultMappable converts a query's result to a plain value, which is nil if the result is null.
+func queryResultMappable(v resource.PropertyValue) interface{} {
+	if v.IsNull() {
+		return nil
+	}
+	return v.Mappable()
+}
+
+// printQueryResult prints a query's result as a table.  An array of objects is printed with a column for each of
+// the objects' properties, and an object with a row for each of its properties; anything else is printed one value per
+// line.
+func printQueryResult(result resource.PropertyValue) {
+	switch {
+	case result.IsNull():
+		return
+	case result.IsObject():
+		obj := result.ObjectValue()
+		keys := obj.StableKeys()
+		width := 24
+		for _, k := range keys {
+			if len(k) > width {
+				width = len(k)
+			}
+		}
+		format := "%-" + strconv.Itoa(width) + "s %s\n"
+		fmt.Printf(format, "KEY", "VALUE")
+		for _, k := range keys {
+			fmt.Printf(format, k, stringifyOutput(queryResultMappable(obj[k])))
+		}
+	case result.IsArray():
+		rows := result.ArrayValue()
+
+		// If every row is an object, print a column for each of their properties.
+		var columns []resource.PropertyKey
+		seen := make(map[resource.PropertyKey]bool)
+		for _, row := range rows {
+			if !row.IsObject() {
+				columns = nil
+				break
+			}
+			for _, k := range row.ObjectValue().StableKeys() {
+				if !seen[k] {
+					seen[k] = true
+					columns = append(columns, k)
+				}
+			}
+		}
+		if len(columns) == 0 {
+			for _, row := range rows {
+				fmt.Println(stringifyOutput(queryResultMappable(row)))
+			}
+			return
+		}
+
+		cells := make([][]string, len(rows)+1)
+		widths := make([]int, len(columns))
+		for i, k := range columns {
+			cells[0] = append(cells[0], strings.ToUpper(string(k)))
+			widths[i] = len(k)
+		}
+		for r, row := range rows {
+			for i, k := range columns {
+				cell := ""
+				if v, has := row.ObjectValue()[k]; has && !v.IsNull() {
+					cell = stringifyOutput(v.Mappable())
+				}
+				cells[r+1] = append(cells[r+1], cell)
+				if len(cell) > widths[i] {
+					widths[i] = len(cell)
+				}
+			}
+		}
+		for _, line := range cells {
+			for i, cell := range line {
+				if i == len(line)-1 {
+					fmt.Println(cell)
+				} else {
+					fmt.Printf("%-"+strconv.Itoa(widths[i])+"s  ", cell)
+				}
+			}
+		}
+	default:
+		fmt.Println(stringifyOutput(result.Mappable()))
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/backend"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/stack"
+)
+
+// stackOutputsReader reads the outputs of the stacks that a program references.  A stack may be kept in another
+// backend than the stack being updated, such as a stack in the Pulumi Service referenced by one kept in an object
+// store.  Each backend is opened the first time that one of its stacks is referenced, with the credentials that
+// `pulumi login` stored for its URL, so the current backend stays as it is.
+type stackOutputsReader struct {
+	current backend.Backend // the backend of the stack being updated.
+
+	lock     sync.Mutex
+	backends map[string]backend.Backend // the backends that have been opened, by URL.
+}
+
+func newStackOutputsReader(current backend.Backend) *stackOutputsReader {
+	return &stackOutputsReader{current: current, backends: make(map[string]backend.Backend)}
+}
+
+// ReadStackOutputs returns the outputs of the named stack in the backend at the given URL, or in the current backend
+// if the URL is empty.
+func (r *stackOutputsReader) ReadStackOutputs(backendURL string, name string) (resource.PropertyMap, error) {
+	b, err := r.backend(backendURL)
+	if err != nil {
+		return nil, err
+	}
+
+	ref, err := b.ParseStackReference(name)
+	if err != nil {
+		return nil, err
+	}
+	s, err := b.GetStack(commandContext(), ref)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, errors.Errorf("stack '%s' does not exist in %s", name, b.Name())
+	}
+
+	snap, err := s.Snapshot(commandContext())
+	if err != nil {
+		return nil, err
+	}
+	_, outputs := stack.GetRootStackResource(snap)
+	return resource.NewPropertyMapFromMap(outputs), nil
+}
+
+// backend returns the backend at the given URL, opening it if it hasn't been yet.
+func (r *stackOutputsReader) backend(url string) (backend.Backend, error) {
+	if url == "" {
+		return r.current, nil
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	key := strings.TrimSuffix(url, "/")
+	if b, has := r.backends[key]; has {
+		return b, nil
+	}
+	b, err := backendForURL(url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not open backend %s", url)
+	}
+	r.backends[key] = b
+	return b, nil
+}
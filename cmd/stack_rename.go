@@ -0,0 +1,128 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/backend"
+	"github.com/pulumi/pulumi/pkg/backend/state"
+	"github.com/pulumi/pulumi/pkg/tokens"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+	"github.com/pulumi/pulumi/pkg/workspace"
+)
+
+func newStackRenameCmd() *cobra.Command {
+	var stack string
+	var project string
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "rename <new-stack-name>",
+		Args:  cmdutil.ExactArgs(1),
+		Short: "Rename a stack",
+		Long: "Rename a stack.\n" +
+			"\n" +
+			"Since the URN of each of a stack's resources includes the names of the stack and its\n" +
+			"project, renaming a stack rewrites the URNs in its state, along with the references\n" +
+			"between resources, so that the next update doesn't replace every resource.  The URNs\n" +
+			"that resources had before the rename are recorded in the state as their aliases.\n" +
+			"The stack's configuration file and, for local stacks, its history move with it.\n" +
+			"\n" +
+			"To rename a project, rename each of its stacks with `--project`, passing the project's\n" +
+			"new name, and change the name in Pulumi.yaml to match.",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			s, err := requireStack(stack, false)
+			if err != nil {
+				return err
+			}
+
+			newName := tokens.QName(args[0])
+			if err = backend.ValidateStackProperties(string(newName), nil); err != nil {
+				return err
+			}
+			if project != "" && !tokens.IsPackageName(project) {
+				return errors.Errorf("'%s' is not a valid project name", project)
+			}
+			oldName := s.Name().StackName()
+			if newName == oldName && project == "" {
+				return errors.Errorf("stack '%s' already has that name", oldName)
+			}
+
+			changes := []string{fmt.Sprintf("rename stack '%s' to '%s'", oldName, newName)}
+			if project != "" {
+				changes = append(changes, fmt.Sprintf("move its resources to project '%s'", project))
+			}
+			changes = append(changes, "rewrite the URNs of its resources to match")
+			if err = confirmStateEdit(s, changes, yes); err != nil {
+				return err
+			}
+
+			// Find out whether the stack is the current one, and where its configuration is, before renaming it.
+			current, err := state.CurrentStack(commandContext(), s.Backend())
+			if err != nil {
+				return err
+			}
+			isCurrent := current != nil && current.Name().String() == s.Name().String()
+			oldConfigPath, err := workspace.DetectProjectStackPath(oldName)
+			if err != nil {
+				return err
+			}
+			newConfigPath, err := workspace.DetectProjectStackPath(newName)
+			if err != nil {
+				return err
+			}
+
+			err = s.Backend().RenameStack(commandContext(), s.Name(), newName, tokens.PackageName(project))
+			if err != nil {
+				return err
+			}
+
+			if oldConfigPath != newConfigPath {
+				if err = os.Rename(oldConfigPath, newConfigPath); err != nil && !os.IsNotExist(err) {
+					return errors.Wrap(err, "renaming the stack's configuration file")
+				}
+			}
+			if isCurrent {
+				newRef, err := s.Backend().ParseStackReference(string(newName))
+				if err != nil {
+					return err
+				}
+				if err = state.SetCurrentStack(newRef.String()); err != nil {
+					return err
+				}
+			}
+
+			fmt.Printf("Renamed stack '%s' to '%s'.\n", oldName, newName)
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stack, "stack", "s", "",
+		"Choose a stack other than the currently selected one")
+	cmd.PersistentFlags().StringVar(
+		&project, "project", "",
+		"Also rewrite the stack's resources to belong to the given project")
+	cmd.PersistentFlags().BoolVarP(
+		&yes, "yes", "y", false,
+		"Rename the stack without asking for confirmation")
+
+	return cmd
+}
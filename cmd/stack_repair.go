@@ -0,0 +1,204 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	survey "gopkg.in/AlecAivazis/survey.v1"
+	surveycore "gopkg.in/AlecAivazis/survey.v1/core"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/backend"
+	"github.com/pulumi/pulumi/pkg/diag/colors"
+	"github.com/pulumi/pulumi/pkg/engine"
+	"github.com/pulumi/pulumi/pkg/resource/config"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/resource/stack"
+	"github.com/pulumi/pulumi/pkg/secrets"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+	"github.com/pulumi/pulumi/pkg/util/contract"
+	"github.com/pulumi/pulumi/pkg/workspace"
+)
+
+func newStackRepairCmd() *cobra.Command {
+	var stackName string
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "repair",
+		Args:  cmdutil.NoArgs,
+		Short: "Reconcile the operations that an interrupted update left pending",
+		Long: "Reconcile the operations that an interrupted update left pending.\n" +
+			"\n" +
+			"When an update is interrupted, the operations it was performing are recorded in the\n" +
+			"stack's checkpoint as pending, since whether they took effect is unknown.  This command\n" +
+			"asks each affected resource's provider whether the resource exists, and then resolves\n" +
+			"each pending operation by doing one of the following:\n" +
+			"\n" +
+			"    adopt   record the resource's live state in the checkpoint\n" +
+			"    delete  remove the resource, which no longer exists, from the checkpoint\n" +
+			"    clear   forget the operation, leaving the checkpoint's resources as they are\n" +
+			"\n" +
+			"Each operation is resolved interactively, unless `--yes` is passed, in which case the\n" +
+			"action that best matches the resource's actual state is taken.",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			if !yes && !cmdutil.Interactive() {
+				return errors.New("--yes must be passed in non-interactive mode")
+			}
+
+			s, err := requireStack(stackName, false)
+			if err != nil {
+				return err
+			}
+			snap, err := s.Snapshot(commandContext())
+			if err != nil {
+				return err
+			}
+			if snap == nil || len(snap.PendingOperations) == 0 {
+				fmt.Printf("Stack '%s' has no pending operations.\n", s.Name())
+				return nil
+			}
+
+			statuses, err := inspectPendingOperations(s, snap)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Stack '%s' has %d pending operation(s):\n", s.Name(), len(statuses))
+			repaired := 0
+			for _, status := range statuses {
+				fmt.Printf("\n%s\n", describePendingOperation(status))
+
+				action := status.Recommended
+				if !yes {
+					if action, err = choosePendingOperationRepair(status); err != nil {
+						return err
+					}
+				}
+				if action == "" {
+					continue
+				}
+
+				engine.RepairPendingOperation(snap, status, action)
+				fmt.Printf("    %s\n", action)
+				repaired++
+			}
+
+			if repaired == 0 {
+				fmt.Printf("\nNo pending operations were repaired.\n")
+				return nil
+			}
+			bytes, err := json.Marshal(stack.SerializeDeployment(snap))
+			if err != nil {
+				return err
+			}
+			if err = importStackDeployment(s, &apitype.UntypedDeployment{
+				Version:    apitype.DeploymentSchemaVersionCurrent,
+				Deployment: bytes,
+			}); err != nil {
+				return errors.Wrap(err, "could not save the repaired checkpoint")
+			}
+			fmt.Printf("\nRepaired %d of %d pending operation(s).\n", repaired, len(statuses))
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stackName, "stack", "s", "",
+		"Choose a stack other than the currently selected one")
+	cmd.PersistentFlags().BoolVarP(
+		&yes, "yes", "y", false,
+		"Resolve each pending operation with the recommended action, without prompting")
+
+	return cmd
+}
+
+// inspectPendingOperations loads the providers of the resources whose operations are pending in a stack's snapshot,
+// configured using the stack's configuration, and asks them about the actual state of each resource.
+func inspectPendingOperations(s backend.Stack, snap *deploy.Snapshot) ([]engine.PendingOperationStatus, error) {
+	proj, root, err := readProject()
+	if err != nil {
+		return nil, err
+	}
+	cfg, _, err := workspace.DetectStackConfig(s.Name().StackName())
+	if err != nil {
+		return nil, err
+	}
+
+	var decrypter config.Decrypter = config.NewPanicCrypter()
+	if cfg.HasSecureValue() {
+		if decrypter, err = backend.GetStackCrypter(s); err != nil {
+			return nil, err
+		}
+	}
+	target := &deploy.Target{Name: s.Name().StackName(), Config: cfg,
+		Decrypter: secrets.NewReferenceResolver(decrypter)}
+
+	_, _, plugctx, err := engine.ProjectInfoContext(&engine.Projinfo{Proj: proj, Root: root}, target, nil,
+		cmdutil.Diag(), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer contract.IgnoreClose(plugctx)
+
+	return engine.InspectPendingOperations(plugctx, snap)
+}
+
+// describePendingOperation renders a pending operation, along with what is known about its resource's actual state.
+func describePendingOperation(status engine.PendingOperationStatus) string {
+	var state string
+	switch {
+	case !status.Known:
+		state = "whether it exists is unknown"
+	case status.Live == nil:
+		state = "it does not exist"
+	default:
+		state = "it exists"
+	}
+	return colors.ColorizeText(fmt.Sprintf("%s%s%s %s (%s)",
+		colors.SpecAttention, status.Operation.Type, colors.Reset, status.Operation.Resource.URN, state))
+}
+
+// choosePendingOperationRepair prompts for the action with which to resolve a pending operation.  An empty action
+// means that the operation should be left pending.
+func choosePendingOperationRepair(status engine.PendingOperationStatus) (engine.RepairAction, error) {
+	surveycore.DisableColor = true
+	surveycore.QuestionIcon = ""
+	surveycore.SelectFocusIcon = colors.ColorizeText(colors.BrightGreen + ">" + colors.Reset)
+
+	const skip = "skip"
+	var options []string
+	for _, action := range status.Actions {
+		options = append(options, string(action))
+	}
+	options = append(options, skip)
+
+	var option string
+	if err := survey.AskOne(&survey.Select{
+		Message: "\rHow should this operation be resolved?",
+		Options: options,
+		Default: string(status.Recommended),
+	}, &option, nil); err != nil {
+		return "", errors.New("no action was chosen")
+	}
+	if option == skip {
+		return "", nil
+	}
+	return engine.RepairAction(option), nil
+}
@@ -0,0 +1,39 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStackTagFilters(t *testing.T) {
+	filters, err := parseStackTagFilters([]string{"env=prod", "team"})
+	assert.NoError(t, err)
+
+	assert.True(t, matchesStackTagFilters(map[string]string{"env": "prod", "team": "web"}, filters))
+	assert.True(t, matchesStackTagFilters(map[string]string{"env": "prod", "team": ""}, filters))
+	assert.False(t, matchesStackTagFilters(map[string]string{"env": "dev", "team": "web"}, filters))
+	assert.False(t, matchesStackTagFilters(map[string]string{"env": "prod"}, filters))
+	assert.False(t, matchesStackTagFilters(nil, filters))
+
+	filters, err = parseStackTagFilters([]string{"owner=a=b"})
+	assert.NoError(t, err)
+	assert.True(t, matchesStackTagFilters(map[string]string{"owner": "a=b"}, filters))
+
+	_, err = parseStackTagFilters([]string{"=prod"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,95 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/backend/local"
+	"github.com/pulumi/pulumi/pkg/resource/stack"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+)
+
+func newStackVerifyCmd() *cobra.Command {
+	var stackName string
+	var jsonOut bool
+
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Args:  cmdutil.NoArgs,
+		Short: "Check a stack's checkpoint for corruption and broken resources",
+		Long: "Check a stack's checkpoint for corruption and broken resources.\n" +
+			"\n" +
+			"This loads the stack's checkpoint, which fails if it is truncated or, for stacks whose\n" +
+			"checkpoints are kept by the local backend, if it doesn't match the checksum it was written\n" +
+			"with.  It then checks every resource in it: that its URN is well-formed and matches its type,\n" +
+			"that its parent and dependencies come before it, that it isn't a duplicate, and that its\n" +
+			"state can be read.  Every problem found is reported along with the resource that it's with,\n" +
+			"rather than just the first one, as an update does.\n" +
+			"\n" +
+			"The command fails if any problem is found.  Broken resources can be fixed by hand using\n" +
+			"`pulumi stack export` and `pulumi stack import`.",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			// The checks are all made here, so that every problem is reported rather than just the first.
+			local.DisableIntegrityChecking = true
+
+			s, err := requireStack(stackName, false)
+			if err != nil {
+				return err
+			}
+			untyped, err := s.ExportDeployment(commandContext())
+			if err != nil {
+				return errors.Wrapf(err, "loading the checkpoint of stack '%s'", s.Name())
+			}
+			deployment, problems, err := stack.VerifyUntypedDeployment(untyped)
+			if err != nil {
+				return errors.Wrapf(err, "reading the checkpoint of stack '%s'", s.Name())
+			}
+
+			if jsonOut {
+				if err = printJSON(struct {
+					Stack     string                   `json:"stack"`
+					Resources int                      `json:"resources"`
+					Problems  []stack.IntegrityProblem `json:"problems"`
+				}{s.Name().String(), len(deployment.Resources), problems}); err != nil {
+					return err
+				}
+			} else if len(problems) == 0 {
+				fmt.Printf("Stack '%s' is intact (%d resources).\n", s.Name(), len(deployment.Resources))
+			} else {
+				fmt.Printf("Stack '%s' has %d problem(s):\n", s.Name(), len(problems))
+				for _, problem := range problems {
+					fmt.Printf("    %s\n", problem)
+				}
+			}
+
+			if len(problems) > 0 {
+				return errors.Errorf("stack '%s' failed verification", s.Name())
+			}
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stackName, "stack", "s", "",
+		"Choose a stack other than the currently selected one")
+	cmd.PersistentFlags().BoolVarP(
+		&jsonOut, "json", "j", false, "Emit the problems found as JSON")
+
+	return cmd
+}
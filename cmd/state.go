@@ -0,0 +1,219 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/backend"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/stack"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+	"github.com/pulumi/pulumi/pkg/util/fsutil"
+	"github.com/pulumi/pulumi/pkg/workspace"
+)
+
+func newStateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "state",
+		Short: "Edit the current state of a stack's resources",
+		Long: "Edit the current state of a stack's resources.\n" +
+			"\n" +
+			"Subcommands of this command make targeted changes to the state recorded in a stack's\n" +
+			"checkpoint, without running an update.  Before each change is saved, the stack's previous\n" +
+			"state is backed up to ~/.pulumi/backups/state, from where it can be restored with\n" +
+			"`pulumi stack import`.",
+		Args: cmdutil.NoArgs,
+	}
+
+	cmd.AddCommand(newStateCompactCmd())
+	cmd.AddCommand(newStateDeleteCmd())
+	cmd.AddCommand(newStateGCCmd())
+	cmd.AddCommand(newStateMoveCmd())
+	cmd.AddCommand(newStateProtectCmd())
+	cmd.AddCommand(newStateRenameCmd())
+	cmd.AddCommand(newStateTaintCmd())
+	cmd.AddCommand(newStateUnprotectCmd())
+	cmd.AddCommand(newStateUntaintCmd())
+
+	return cmd
+}
+
+// editStackResources applies the given edit to each of the resources with the given URNs in a stack's checkpoint, and
+// then saves the checkpoint.  Resources pending deletion are never edited.  It is an error for any URN not to refer
+// to a resource in the stack.
+func editStackResources(s backend.Stack, urns []resource.URN, edit func(res *apitype.Resource) error) error {
+	return editStackDeployment(s, func(d *apitype.Deployment) error {
+		resources, err := selectStackResources(s, d, urns)
+		if err != nil {
+			return err
+		}
+		for _, res := range resources {
+			if err = edit(res); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// selectStackResources returns the resources with the given URNs in a stack's deployment, excluding those pending
+// deletion.  It is an error for any URN not to refer to a resource in the stack.
+func selectStackResources(s backend.Stack, d *apitype.Deployment, urns []resource.URN) ([]*apitype.Resource, error) {
+	var resources []*apitype.Resource
+	for _, urn := range urns {
+		var found bool
+		for i := range d.Resources {
+			if res := &d.Resources[i]; res.URN == urn && !res.Delete {
+				resources = append(resources, res)
+				found = true
+			}
+		}
+		if !found {
+			return nil, errors.Errorf("resource '%s' does not exist in stack '%s'", urn, s.Name())
+		}
+	}
+	return resources, nil
+}
+
+// editStackDeployment applies the given edit to a stack's deployment, and then saves it.  The deployment as it was
+// before the edit is first saved to a backup file, so that the edit can be undone with `pulumi stack import`.
+func editStackDeployment(s backend.Stack, edit func(d *apitype.Deployment) error) error {
+	deployment, err := s.ExportDeployment(commandContext())
+	if err != nil {
+		return err
+	}
+
+	// Deserialize the deployment just to check that this version of the CLI understands it.
+	if _, err = stack.DeserializeDeployment(deployment); err != nil {
+		return errors.Wrap(err, "could not read the stack's deployment")
+	}
+	var d apitype.Deployment
+	if err = json.Unmarshal(deployment.Deployment, &d); err != nil {
+		return errors.Wrap(err, "could not read the stack's deployment")
+	}
+
+	if err = edit(&d); err != nil {
+		return err
+	}
+
+	bytes, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	backup, err := backupStackDeployment(s, deployment)
+	if err != nil {
+		return errors.Wrap(err, "could not back up the stack's deployment")
+	}
+	if err = importStackDeployment(s, &apitype.UntypedDeployment{
+		Version:    deployment.Version,
+		Deployment: bytes,
+	}); err != nil {
+		return err
+	}
+	fmt.Printf("The previous state of stack '%s' was backed up to %s.\n", s.Name(), backup)
+	return nil
+}
+
+// backupStackDeployment saves a stack's deployment to a new file in ~/.pulumi/backups/state, and returns the file's
+// path.
+func backupStackDeployment(s backend.Stack, deployment *apitype.UntypedDeployment) (string, error) {
+	u, err := user.Current()
+	if u == nil || err != nil {
+		return "", errors.Wrap(err, "getting user home directory")
+	}
+	dir := filepath.Join(u.HomeDir, workspace.BookkeepingDir, workspace.BackupDir, "state")
+
+	bytes, err := json.MarshalIndent(deployment, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	file := filepath.Join(dir,
+		fmt.Sprintf("%s.%v.json", fsutil.QnamePath(s.Name().StackName()), time.Now().UnixNano()))
+	if err = os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return "", err
+	}
+	if err = ioutil.WriteFile(file, bytes, 0600); err != nil {
+		return "", err
+	}
+	return file, nil
+}
+
+// confirmStateEdit shows the changes that an edit would make to a stack's checkpoint, and unless yes is set, asks for
+// confirmation before the edit goes ahead.
+func confirmStateEdit(s backend.Stack, changes []string, yes bool) error {
+	fmt.Printf("This will make the following changes to the state of stack '%s':\n", s.Name())
+	for _, change := range changes {
+		fmt.Printf("    %s\n", change)
+	}
+	fmt.Println()
+
+	if yes {
+		return nil
+	}
+	if !cmdutil.Interactive() {
+		return errors.New("--yes must be passed in non-interactive mode")
+	}
+	if !confirmPrompt("", "yes") {
+		return errors.New("confirmation declined")
+	}
+	return nil
+}
+
+// dependentStackResources returns the resources in a stack's deployment, other than those in the given set, that
+// are children of, or depend on, any of the resources in the set.
+func dependentStackResources(d *apitype.Deployment, set map[resource.URN]bool) []resource.URN {
+	var dependents []resource.URN
+	for _, res := range d.Resources {
+		if set[res.URN] {
+			continue
+		}
+		depends := set[res.Parent]
+		for _, dep := range res.Dependencies {
+			depends = depends || set[dep]
+		}
+		if depends {
+			dependents = append(dependents, res.URN)
+		}
+	}
+	return dependents
+}
+
+// renameResourceReferences replaces each reference to one of the URNs in the given map, in a resource's URN, parent,
+// or dependencies, with the URN that it maps to.
+func renameResourceReferences(res *apitype.Resource, renames map[resource.URN]resource.URN) {
+	if urn, has := renames[res.URN]; has {
+		res.URN = urn
+	}
+	if urn, has := renames[res.Parent]; has {
+		res.Parent = urn
+	}
+	for i, dep := range res.Dependencies {
+		if urn, has := renames[dep]; has {
+			res.Dependencies[i] = urn
+		}
+	}
+}
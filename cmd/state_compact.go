@@ -0,0 +1,134 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/resource/stack"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+)
+
+func newStateCompactCmd() *cobra.Command {
+	var stackName string
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "compact",
+		Args:  cmdutil.NoArgs,
+		Short: "Remove the entries of a stack's state that no update will need",
+		Long: "Remove the entries of a stack's state that no update will need.\n" +
+			"\n" +
+			"Over the life of a stack, its checkpoint accumulates entries that are never used again:\n" +
+			"resources pending deletion that were never created, that are external, or that duplicate\n" +
+			"another resource's entry; aliases that can't match any resource; and dependencies on\n" +
+			"resources that are gone.  This command removes them, leaving the rest of the checkpoint as\n" +
+			"it is.  The same is done automatically after every successful update.",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			s, err := requireStack(stackName, false)
+			if err != nil {
+				return err
+			}
+
+			// Compact a copy of the stack's state first, so that a stack with nothing to compact is left alone.
+			deployment, err := s.ExportDeployment(commandContext())
+			if err != nil {
+				return err
+			}
+			snap, err := stack.DeserializeDeployment(deployment)
+			if err != nil {
+				return errors.Wrap(err, "could not read the stack's deployment")
+			}
+			if deploy.CompactSnapshot(snap).IsEmpty() {
+				fmt.Printf("The state of stack '%s' has nothing to compact.\n", s.Name())
+				return nil
+			}
+
+			var compaction deploy.Compaction
+			if err = editStackDeployment(s, func(d *apitype.Deployment) error {
+				latest, err := stack.DeserializeCheckpoint(&apitype.CheckpointV1{Latest: d})
+				if err != nil {
+					return err
+				}
+				compaction = deploy.CompactSnapshot(latest)
+				if err = confirmStateEdit(s, compactionChanges(compaction), yes); err != nil {
+					return err
+				}
+				*d = *stack.SerializeDeployment(latest)
+				return nil
+			}); err != nil {
+				return err
+			}
+			fmt.Printf("Removed %d resource(s) pending deletion, %d alias(es), and %d dependency(ies) from "+
+				"the stack's state.\n", len(compaction.Pruned), countURNs(compaction.Aliases),
+				countURNs(compaction.Dependencies))
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stackName, "stack", "s", "",
+		"Choose a stack other than the currently selected one")
+	cmd.PersistentFlags().BoolVarP(
+		&yes, "yes", "y", false,
+		"Compact the state without asking for confirmation")
+
+	return cmd
+}
+
+// compactionChanges describes each of the entries that a compaction removed, in order.
+func compactionChanges(compaction deploy.Compaction) []string {
+	var changes []string
+	for _, res := range compaction.Pruned {
+		changes = append(changes, fmt.Sprintf("remove %s pending deletion (ID '%s')", res.URN, res.ID))
+	}
+	for _, urn := range sortedURNKeys(compaction.Aliases) {
+		for _, alias := range compaction.Aliases[urn] {
+			changes = append(changes, fmt.Sprintf("remove alias %s of %s", alias, urn))
+		}
+	}
+	for _, urn := range sortedURNKeys(compaction.Dependencies) {
+		for _, dep := range compaction.Dependencies[urn] {
+			changes = append(changes, fmt.Sprintf("remove dependency of %s on %s", urn, dep))
+		}
+	}
+	return changes
+}
+
+// sortedURNKeys returns the keys of a map from URNs to lists of URNs, in order.
+func sortedURNKeys(m map[resource.URN][]resource.URN) []resource.URN {
+	var keys []resource.URN
+	for urn := range m {
+		keys = append(keys, urn)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// countURNs returns the number of URNs in all of the lists in a map.
+func countURNs(m map[resource.URN][]resource.URN) int {
+	var n int
+	for _, urns := range m {
+		n += len(urns)
+	}
+	return n
+}
@@ -0,0 +1,139 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+)
+
+func newStateDeleteCmd() *cobra.Command {
+	var stack string
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "delete <urn>...",
+		Args:  cmdutil.MinimumNArgs(1),
+		Short: "Remove resources from a stack's state, without deleting them",
+		Long: "Remove resources from a stack's state, without deleting them.\n" +
+			"\n" +
+			"The resources are forgotten by the stack, but not deleted by their providers: they go on\n" +
+			"existing, and are no longer managed by Pulumi.  This is useful for a resource that was\n" +
+			"deleted by hand, or that should be handed over to something else.\n" +
+			"\n" +
+			"A resource can't be removed while other resources that remain in the stack are its\n" +
+			"children or depend on it, nor while it is protected.",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			s, err := requireStack(stack, false)
+			if err != nil {
+				return err
+			}
+
+			urns := targetURNs(args)
+			if err = editStackDeployment(s, func(d *apitype.Deployment) error {
+				resources, err := selectStackResources(s, d, urns)
+				if err != nil {
+					return err
+				}
+
+				deleted, err := checkStackResourcesDeletable(d, resources)
+				if err != nil {
+					return err
+				}
+
+				var changes []string
+				for _, urn := range urns {
+					changes = append(changes, fmt.Sprintf("delete %s", urn))
+				}
+				if err = confirmStateEdit(s, changes, yes); err != nil {
+					return err
+				}
+
+				deleteStackResources(d, deleted)
+				return nil
+			}); err != nil {
+				return err
+			}
+			fmt.Printf("Removed %d resource(s) from the stack's state.\n", len(urns))
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stack, "stack", "s", "",
+		"Choose a stack other than the currently selected one")
+	cmd.PersistentFlags().BoolVarP(
+		&yes, "yes", "y", false,
+		"Remove the resources without asking for confirmation")
+
+	return cmd
+}
+
+// checkStackResourcesDeletable checks that the given resources can be removed from a stack's deployment: that none
+// of them is protected, and that no other resources are their children or depend on them.  It returns the set of
+// their URNs.
+func checkStackResourcesDeletable(d *apitype.Deployment,
+	resources []*apitype.Resource) (map[resource.URN]bool, error) {
+	deleted := make(map[resource.URN]bool)
+	for _, res := range resources {
+		if res.Protect {
+			return nil, errors.Errorf("resource '%s' is protected; "+
+				"run `pulumi state unprotect` to remove its protection first", res.URN)
+		}
+		deleted[res.URN] = true
+	}
+	if dependents := dependentStackResources(d, deleted); len(dependents) > 0 {
+		return nil, errors.Errorf("the following resources are children of, or depend on, "+
+			"the resources to be removed, and must be removed along with them:\n    %s",
+			strings.Join(urnStrings(dependents), "\n    "))
+	}
+	return deleted, nil
+}
+
+// deleteStackResources removes the resources with the given URNs from a stack's deployment, along with any operations
+// pending on them.
+func deleteStackResources(d *apitype.Deployment, deleted map[resource.URN]bool) {
+	var remaining []apitype.Resource
+	for _, res := range d.Resources {
+		if !deleted[res.URN] {
+			remaining = append(remaining, res)
+		}
+	}
+	d.Resources = remaining
+
+	var operations []apitype.Operation
+	for _, op := range d.PendingOperations {
+		if !deleted[op.Resource.URN] {
+			operations = append(operations, op)
+		}
+	}
+	d.PendingOperations = operations
+}
+
+// urnStrings converts a list of URNs to a list of strings.
+func urnStrings(urns []resource.URN) []string {
+	strs := make([]string, len(urns))
+	for i, urn := range urns {
+		strs[i] = string(urn)
+	}
+	return strs
+}
@@ -0,0 +1,121 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/backend/local"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+)
+
+func newStateGCCmd() *cobra.Command {
+	var keepLast int
+	var keepDays int
+	var dryRun bool
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Args:  cmdutil.NoArgs,
+		Short: "Remove old backups of stacks' checkpoints from the local backend",
+		Long: "Remove old backups of stacks' checkpoints from the local backend.\n" +
+			"\n" +
+			"The local backend backs up a stack's checkpoint after each update, and keeps the last\n" +
+			"checkpoint of each stack that is removed.  This command removes those backups that a\n" +
+			"retention policy doesn't keep: a backup is kept if it is one of its stack's `--keep-last`\n" +
+			"newest backups, or if it is less than `--keep-days` days old.\n" +
+			"\n" +
+			"The policy defaults to the one given by the " + local.BackupKeepLastEnvVar + " and\n" +
+			local.BackupKeepDaysEnvVar + " environment variables, which, when set, are also applied\n" +
+			"after every update.  Setting " + local.CompressBackupsEnvVar + " causes backups to be\n" +
+			"written compressed.",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			b, err := currentBackend()
+			if err != nil {
+				return err
+			}
+			lb, ok := b.(local.Backend)
+			if !ok {
+				return errors.New("only the local backend keeps backups that can be collected")
+			}
+
+			policy, err := local.BackupRetentionFromEnv()
+			if err != nil {
+				return err
+			}
+			if cmd.Flags().Changed("keep-last") {
+				policy.KeepLast = keepLast
+			}
+			if cmd.Flags().Changed("keep-days") {
+				policy.KeepDays = keepDays
+			}
+			if policy.KeepLast < 0 || policy.KeepDays < 0 {
+				return errors.New("--keep-last and --keep-days must not be negative")
+			}
+			if policy.IsZero() {
+				return errors.Errorf("no retention policy was given; pass --keep-last or --keep-days, or set %s "+
+					"or %s", local.BackupKeepLastEnvVar, local.BackupKeepDaysEnvVar)
+			}
+
+			expired, err := lb.CollectBackups(commandContext(), policy, true)
+			if err != nil {
+				return err
+			}
+			if len(expired) == 0 {
+				fmt.Println("There are no backups to remove.")
+				return nil
+			}
+			fmt.Printf("The following %d backup(s) will be removed:\n", len(expired))
+			for _, backup := range expired {
+				fmt.Printf("    %s\n", backup)
+			}
+			fmt.Println()
+			if dryRun {
+				return nil
+			}
+
+			if !yes {
+				if !cmdutil.Interactive() {
+					return errors.New("--yes must be passed in non-interactive mode")
+				}
+				if !confirmPrompt("", "yes") {
+					return errors.New("confirmation declined")
+				}
+			}
+
+			removed, err := lb.CollectBackups(commandContext(), policy, false)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Removed %d backup(s).\n", len(removed))
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().IntVar(
+		&keepLast, "keep-last", 0, "Keep each stack's N newest backups")
+	cmd.PersistentFlags().IntVar(
+		&keepDays, "keep-days", 0, "Keep backups made within the last N days")
+	cmd.PersistentFlags().BoolVar(
+		&dryRun, "dry-run", false, "Only list the backups that would be removed")
+	cmd.PersistentFlags().BoolVarP(
+		&yes, "yes", "y", false, "Remove the backups without prompting for confirmation")
+
+	return cmd
+}
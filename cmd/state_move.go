@@ -0,0 +1,194 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/backend"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+)
+
+func newStateMoveCmd() *cobra.Command {
+	var stack string
+	var dest string
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "move <urn>...",
+		Args:  cmdutil.MinimumNArgs(1),
+		Short: "Move resources from one stack's state to another's",
+		Long: "Move resources from one stack's state to another's.\n" +
+			"\n" +
+			"The resources, along with their children, are removed from the source stack and added\n" +
+			"to the destination stack given by `--dest`, under URNs that name the destination stack.\n" +
+			"The resources themselves are not changed.  This is useful when splitting a stack in two:\n" +
+			"once the resources' definitions have been moved to the destination stack's program too,\n" +
+			"its next update manages them without having to create them afresh.\n" +
+			"\n" +
+			"A resource can't be moved while resources that remain in the source stack depend on it,\n" +
+			"nor while it depends on resources that remain.  Resources whose parent is the source\n" +
+			"stack become children of the destination stack.",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			if dest == "" {
+				return errors.New("a destination stack must be given with `--dest`")
+			}
+
+			s, err := requireStack(stack, false)
+			if err != nil {
+				return err
+			}
+			d, err := requireStack(dest, false)
+			if err != nil {
+				return err
+			}
+			if s.Name().String() == d.Name().String() {
+				return errors.New("the source and destination stacks must differ")
+			}
+
+			urns := targetURNs(args)
+			var moved []apitype.Resource
+			if err = editStackDeployment(s, func(source *apitype.Deployment) error {
+				resources, err := selectStackResources(s, source, urns)
+				if err != nil {
+					return err
+				}
+
+				// Gather up the resources to move, along with all of their descendants.
+				set := make(map[resource.URN]bool)
+				for _, res := range resources {
+					if res.Type == resource.RootStackType {
+						return errors.New("a stack's root resource can't be moved")
+					}
+					set[res.URN] = true
+				}
+				for grown := true; grown; {
+					grown = false
+					for _, res := range source.Resources {
+						if !set[res.URN] && set[res.Parent] {
+							set[res.URN], grown = true, true
+						}
+					}
+				}
+
+				if dependents := dependentStackResources(source, set); len(dependents) > 0 {
+					return errors.Errorf("the following resources depend on the resources to be moved, "+
+						"and must be moved along with them:\n    %s", strings.Join(urnStrings(dependents), "\n    "))
+				}
+				var remaining []apitype.Resource
+				for _, res := range source.Resources {
+					if !set[res.URN] {
+						remaining = append(remaining, res)
+						continue
+					}
+					if res.Parent != "" && !set[res.Parent] && res.Parent.Type() != resource.RootStackType {
+						return errors.Errorf("resource '%s' is a child of '%s', which must be moved along with it",
+							res.URN, res.Parent)
+					}
+					for _, dep := range res.Dependencies {
+						if !set[dep] {
+							return errors.Errorf("resource '%s' depends on '%s', which must be moved along with it",
+								res.URN, dep)
+						}
+					}
+					moved = append(moved, res)
+				}
+				for _, op := range source.PendingOperations {
+					if set[op.Resource.URN] {
+						return errors.Errorf("resource '%s' has a pending operation; "+
+							"run `pulumi stack repair` to resolve it first", op.Resource.URN)
+					}
+				}
+
+				var changes []string
+				for _, res := range moved {
+					changes = append(changes, fmt.Sprintf("move %s", res.URN))
+				}
+				changes = append(changes, fmt.Sprintf("to stack '%s'", d.Name()))
+				if err = confirmStateEdit(s, changes, yes); err != nil {
+					return err
+				}
+
+				// Add the resources to the destination stack before removing them from the source stack, so that if
+				// anything goes wrong, they are in both stacks rather than in neither.
+				if err = moveStackResources(d, moved); err != nil {
+					return err
+				}
+				source.Resources = remaining
+				return nil
+			}); err != nil {
+				return err
+			}
+			fmt.Printf("Moved %d resource(s) from stack '%s' to stack '%s'.\n", len(moved), s.Name(), d.Name())
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stack, "stack", "s", "",
+		"Choose a stack other than the currently selected one to move the resources from")
+	cmd.PersistentFlags().StringVar(
+		&dest, "dest", "",
+		"The stack to move the resources to")
+	cmd.PersistentFlags().BoolVarP(
+		&yes, "yes", "y", false,
+		"Move the resources without asking for confirmation")
+
+	return cmd
+}
+
+// moveStackResources adds resources taken from another stack to a stack's checkpoint, renaming them and the
+// references between them to use the stack's name.  Resources whose parent was the other stack's root resource become
+// children of this stack's root resource.
+func moveStackResources(s backend.Stack, resources []apitype.Resource) error {
+	return editStackDeployment(s, func(d *apitype.Deployment) error {
+		var root resource.URN
+		existing := make(map[resource.URN]bool)
+		for _, res := range d.Resources {
+			existing[res.URN] = true
+			if res.Type == resource.RootStackType && !res.Delete {
+				root = res.URN
+			}
+		}
+
+		renames := make(map[resource.URN]resource.URN)
+		for _, res := range resources {
+			urn := res.URN
+			renames[urn] = resource.NewURN(s.Name().StackName(), urn.Project(), "", urn.QualifiedType(), urn.Name())
+			if existing[renames[urn]] {
+				return errors.Errorf("resource '%s' already exists in stack '%s'", renames[urn], s.Name())
+			}
+		}
+
+		for _, res := range resources {
+			if res.Parent != "" && renames[res.Parent] == "" {
+				if root == "" {
+					return errors.Errorf("stack '%s' has no root resource to parent '%s' to", s.Name(), res.URN)
+				}
+				res.Parent = root
+			}
+			res.Dependencies = append([]resource.URN(nil), res.Dependencies...)
+			renameResourceReferences(&res, renames)
+			d.Resources = append(d.Resources, res)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,141 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/tokens"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+)
+
+func newStateProtectCmd() *cobra.Command {
+	return newStateSetProtectCmd(true)
+}
+
+func newStateUnprotectCmd() *cobra.Command {
+	return newStateSetProtectCmd(false)
+}
+
+// newStateSetProtectCmd creates the command that protects resources, or the one that unprotects them.
+func newStateSetProtectCmd(protect bool) *cobra.Command {
+	var stack string
+	var all bool
+	var types []string
+	var yes bool
+
+	name, verb, short, long := "protect", "Protected", "Protect resources from being deleted",
+		"A protected resource can't be deleted: an update that would delete or replace it fails, and\n"+
+			"`pulumi destroy` refuses to run while the stack contains it, unless `--force-unprotect` is passed.\n"+
+			"Use `pulumi state unprotect` to remove the protection."
+	if !protect {
+		name, verb, short, long = "unprotect", "Unprotected", "Remove the protection from resources",
+			"This undoes `pulumi state protect`, and the `protect` option of the resources' programs.\n"+
+				"Note that the next update of a resource whose program still protects it protects it again."
+	}
+
+	cmd := &cobra.Command{
+		Use:   name + " [<urn>...]",
+		Args:  cmdutil.ArgsFunc(cobra.ArbitraryArgs),
+		Short: short,
+		Long: short + ".\n" +
+			"\n" +
+			long + "\n" +
+			"\n" +
+			"Resources may be named by URN, or selected in bulk: `--all` selects every resource in the\n" +
+			"stack, and `--type` selects every resource of the given type (e.g. `aws:rds/instance:Instance`).",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			bulk := all || len(types) > 0
+			if len(args) == 0 && !bulk {
+				return errors.New("either resource URNs or `--all` or `--type` must be given")
+			} else if len(args) > 0 && bulk {
+				return errors.New("resource URNs can't be given along with `--all` or `--type`")
+			}
+
+			s, err := requireStack(stack, false)
+			if err != nil {
+				return err
+			}
+
+			count := 0
+			if err = editStackDeployment(s, func(d *apitype.Deployment) error {
+				var resources []*apitype.Resource
+				if !bulk {
+					if resources, err = selectStackResources(s, d, targetURNs(args)); err != nil {
+						return err
+					}
+				} else {
+					matchTypes := make(map[tokens.Type]bool)
+					for _, t := range types {
+						matchTypes[tokens.Type(t)] = true
+					}
+					for i := range d.Resources {
+						res := &d.Resources[i]
+						if !res.Delete && res.Type != resource.RootStackType && (all || matchTypes[res.Type]) {
+							resources = append(resources, res)
+						}
+					}
+				}
+
+				// Removing a resource's protection exposes it to deletion, so make sure that's what is wanted.
+				if !protect {
+					var changes []string
+					for _, res := range resources {
+						if res.Protect {
+							changes = append(changes, fmt.Sprintf("unprotect %s", res.URN))
+						}
+					}
+					if len(changes) > 0 {
+						if err := confirmStateEdit(s, changes, yes); err != nil {
+							return err
+						}
+					}
+				}
+
+				for _, res := range resources {
+					res.Protect = protect
+				}
+				count = len(resources)
+				return nil
+			}); err != nil {
+				return err
+			}
+			fmt.Printf("%s %d resource(s).\n", verb, count)
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stack, "stack", "s", "",
+		"Choose a stack other than the currently selected one")
+	cmd.PersistentFlags().BoolVar(
+		&all, "all", false,
+		"Select every resource in the stack")
+	cmd.PersistentFlags().StringSliceVar(
+		&types, "type", nil,
+		"Select every resource of the given type (may be repeated)")
+	if !protect {
+		cmd.PersistentFlags().BoolVarP(
+			&yes, "yes", "y", false,
+			"Unprotect the resources without asking for confirmation")
+	}
+
+	return cmd
+}
@@ -0,0 +1,105 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/tokens"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+)
+
+func newStateRenameCmd() *cobra.Command {
+	var stack string
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "rename <urn> <new-name>",
+		Args:  cmdutil.ExactArgs(2),
+		Short: "Rename a resource in a stack's state",
+		Long: "Rename a resource in a stack's state.\n" +
+			"\n" +
+			"The resource's URN is changed to use the new name, and every reference to it by other\n" +
+			"resources in the stack, as a parent or a dependency, is changed to match.  This is useful\n" +
+			"when a resource has been renamed in the program: without it, the next update would\n" +
+			"delete the resource and create a new one under the new name.",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			s, err := requireStack(stack, false)
+			if err != nil {
+				return err
+			}
+
+			urn, name := resource.URN(args[0]), tokens.QName(args[1])
+			if name == "" || strings.Contains(string(name), resource.URNNameDelimiter) {
+				return errors.Errorf("'%s' is not a valid resource name", name)
+			}
+
+			var newURN resource.URN
+			if err = editStackDeployment(s, func(d *apitype.Deployment) error {
+				// Only once the URN is known to belong to the stack is it safe to pick it apart.
+				if _, err := selectStackResources(s, d, []resource.URN{urn}); err != nil {
+					return err
+				}
+				newURN = resource.NewURN(urn.Stack(), urn.Project(), "", urn.QualifiedType(), name)
+				for _, res := range d.Resources {
+					if res.URN == newURN {
+						return errors.Errorf("resource '%s' already exists in stack '%s'", newURN, s.Name())
+					}
+				}
+
+				changes := []string{fmt.Sprintf("rename %s\n        => %s", urn, newURN)}
+				if dependents := dependentStackResources(d, map[resource.URN]bool{urn: true}); len(dependents) > 0 {
+					changes = append(changes, fmt.Sprintf("update the references of %d resource(s)", len(dependents)))
+				}
+				if err := confirmStateEdit(s, changes, yes); err != nil {
+					return err
+				}
+
+				renameStackResources(d, map[resource.URN]resource.URN{urn: newURN})
+				return nil
+			}); err != nil {
+				return err
+			}
+			fmt.Printf("Renamed %s to %s.\n", urn, newURN)
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stack, "stack", "s", "",
+		"Choose a stack other than the currently selected one")
+	cmd.PersistentFlags().BoolVarP(
+		&yes, "yes", "y", false,
+		"Rename the resource without asking for confirmation")
+
+	return cmd
+}
+
+// renameStackResources changes the URNs of resources in a stack's deployment, as given by a map from their old URNs to
+// their new ones, along with every reference to them by other resources and by the operations pending on them.
+func renameStackResources(d *apitype.Deployment, renames map[resource.URN]resource.URN) {
+	for i := range d.Resources {
+		renameResourceReferences(&d.Resources[i], renames)
+	}
+	for i := range d.PendingOperations {
+		renameResourceReferences(&d.PendingOperations[i].Resource, renames)
+	}
+}
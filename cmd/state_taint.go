@@ -0,0 +1,99 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+)
+
+func newStateTaintCmd() *cobra.Command {
+	var stack string
+	var reason string
+
+	cmd := &cobra.Command{
+		Use:   "taint <urn>...",
+		Args:  cmdutil.MinimumNArgs(1),
+		Short: "Mark resources for replacement by the next update",
+		Long: "Mark resources for replacement by the next update.\n" +
+			"\n" +
+			"A tainted resource is replaced by the next update, even if none of its properties have\n" +
+			"changed; this is useful for a resource that is known to be broken, or that was changed by hand.\n" +
+			"The reason given with `--reason` is shown alongside the replacement in the update's preview.\n" +
+			"Use `pulumi state untaint` to clear a resource's taint.",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			s, err := requireStack(stack, false)
+			if err != nil {
+				return err
+			}
+
+			if err = editStackResources(s, targetURNs(args), func(res *apitype.Resource) error {
+				res.Taint = reason
+				return nil
+			}); err != nil {
+				return err
+			}
+			fmt.Printf("Marked %d resource(s) for replacement by the next update.\n", len(args))
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stack, "stack", "s", "",
+		"Choose a stack other than the currently selected one")
+	cmd.PersistentFlags().StringVarP(
+		&reason, "reason", "r", "tainted by user",
+		"The reason the resources must be replaced, shown in the update's preview")
+
+	return cmd
+}
+
+func newStateUntaintCmd() *cobra.Command {
+	var stack string
+
+	cmd := &cobra.Command{
+		Use:   "untaint <urn>...",
+		Args:  cmdutil.MinimumNArgs(1),
+		Short: "Clear the taint from resources, so that the next update no longer replaces them",
+		Long: "Clear the taint from resources, so that the next update no longer replaces them.\n" +
+			"\n" +
+			"This undoes `pulumi state taint`.",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			s, err := requireStack(stack, false)
+			if err != nil {
+				return err
+			}
+
+			if err = editStackResources(s, targetURNs(args), func(res *apitype.Resource) error {
+				res.Taint = ""
+				return nil
+			}); err != nil {
+				return err
+			}
+			fmt.Printf("Cleared the taint from %d resource(s).\n", len(args))
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stack, "stack", "s", "",
+		"Choose a stack other than the currently selected one")
+
+	return cmd
+}
@@ -0,0 +1,121 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/resource/stack"
+	"github.com/pulumi/pulumi/pkg/tokens"
+)
+
+func newStateTestResource(name string, parent resource.URN, deps ...resource.URN) apitype.Resource {
+	return apitype.Resource{
+		URN:          resource.NewURN("dev", "proj", "", "test:index:Res", tokens.QName(name)),
+		Type:         "test:index:Res",
+		Parent:       parent,
+		Dependencies: deps,
+	}
+}
+
+// newStateTestDeployment returns a deployment in which b is a child of a, c depends on b, and d stands alone.
+func newStateTestDeployment() *apitype.Deployment {
+	a := newStateTestResource("a", "")
+	b := newStateTestResource("b", a.URN)
+	c := newStateTestResource("c", "", b.URN)
+	d := newStateTestResource("d", "")
+	manifest := deploy.Manifest{Version: "1.0.0"}
+	return &apitype.Deployment{
+		Manifest:          apitype.Manifest{Version: manifest.Version, Magic: manifest.NewMagic()},
+		Resources:         []apitype.Resource{a, b, c, d},
+		PendingOperations: []apitype.Operation{{Resource: c, Type: apitype.OperationTypeUpdating}},
+	}
+}
+
+// assertDeploymentIntegrity checks that a deployment that has been edited still makes a valid snapshot.
+func assertDeploymentIntegrity(t *testing.T, d *apitype.Deployment) {
+	untyped, err := stack.MarshalUntypedDeployment(d)
+	if !assert.NoError(t, err) {
+		return
+	}
+	snap, err := stack.DeserializeDeployment(untyped)
+	if assert.NoError(t, err) {
+		assert.NoError(t, snap.VerifyIntegrity())
+	}
+}
+
+func TestStateDelete(t *testing.T) {
+	d := newStateTestDeployment()
+	a, b, c, dd := &d.Resources[0], &d.Resources[1], &d.Resources[2], &d.Resources[3]
+
+	// A resource with children or dependents can't be removed without them.
+	_, err := checkStackResourcesDeletable(d, []*apitype.Resource{a})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), string(b.URN))
+	_, err = checkStackResourcesDeletable(d, []*apitype.Resource{b})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), string(c.URN))
+
+	// Nor can a protected resource.
+	dd.Protect = true
+	_, err = checkStackResourcesDeletable(d, []*apitype.Resource{dd})
+	assert.Error(t, err)
+	dd.Protect = false
+
+	// Removing a resource along with everything that depends on it leaves a valid deployment.
+	deleted, err := checkStackResourcesDeletable(d, []*apitype.Resource{b, c})
+	assert.NoError(t, err)
+	deleteStackResources(d, deleted)
+	if assert.Len(t, d.Resources, 2) {
+		assert.Equal(t, "a", string(d.Resources[0].URN.Name()))
+		assert.Equal(t, "d", string(d.Resources[1].URN.Name()))
+	}
+	assert.Len(t, d.PendingOperations, 0)
+	assertDeploymentIntegrity(t, d)
+}
+
+func TestStateRename(t *testing.T) {
+	d := newStateTestDeployment()
+	oldURN := d.Resources[1].URN
+	newURN := resource.NewURN("dev", "proj", "", "test:index:Res", "renamed")
+
+	renameStackResources(d, map[resource.URN]resource.URN{oldURN: newURN})
+
+	// The resource itself, its child's parent, and its dependent's dependencies, including those of the pending
+	// operation on the dependent, all use the new URN.
+	b, c := d.Resources[1], d.Resources[2]
+	assert.Equal(t, newURN, b.URN)
+	assert.Equal(t, d.Resources[0].URN, b.Parent)
+	assert.Equal(t, []resource.URN{newURN}, c.Dependencies)
+	assert.Equal(t, []resource.URN{newURN}, d.PendingOperations[0].Resource.Dependencies)
+	for _, res := range d.Resources {
+		assert.NotEqual(t, oldURN, res.URN)
+		assert.NotEqual(t, oldURN, res.Parent)
+		assert.NotContains(t, res.Dependencies, oldURN)
+	}
+	assertDeploymentIntegrity(t, d)
+
+	// Renaming a parent renames it in its children.
+	parentURN := resource.NewURN("dev", "proj", "", "test:index:Res", "parent")
+	renameStackResources(d, map[resource.URN]resource.URN{d.Resources[0].URN: parentURN})
+	assert.Equal(t, parentURN, d.Resources[0].URN)
+	assert.Equal(t, parentURN, d.Resources[1].Parent)
+	assertDeploymentIntegrity(t, d)
+}
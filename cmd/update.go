@@ -16,12 +16,14 @@ package cmd
 
 import (
 	"context"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/pulumi/pulumi/pkg/backend"
 	"github.com/pulumi/pulumi/pkg/engine"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
 	"github.com/pulumi/pulumi/pkg/util/cmdutil"
 )
 
@@ -33,14 +35,44 @@ func newUpdateCmd() *cobra.Command {
 
 	// Flags for engine.UpdateOptions.
 	var analyzers []string
+	var fullDiff bool
+	var diffContextLines int
+	var diffIncludePaths []string
+	var diffExcludePaths []string
+	var diffArchiveContents bool
+	var diffMaxStringLength int
+	var diffMaxArrayElements int
+	var diffMaxObjectKeys int
+	var diffNoTruncate bool
+	var diffCollapseUnchanged bool
+	var diffExpandPaths []string
+	var diffNoLineNumbers bool
+	var diffWidth int
+	var diffArrayKeys []string
+	var diffMatchArrays bool
+	var secretPatterns []string
+	var targets []string
+	var configEnv []string
+	var configEnvSecret []string
+	var planFile string
 	var color colorFlag
 	var diffDisplay bool
+	var jsonDisplay bool
+	var quiet bool
+	var summaryOnly bool
+	var diffFormat diffFormatFlag
 	var nonInteractive bool
 	var parallel int
+	var retries int
+	var retryBackoff time.Duration
 	var showConfig bool
 	var showReplacementSteps bool
 	var showSames bool
 	var skipPreview bool
+	var resume bool
+	var rollback bool
+	var queue bool
+	var queueWait time.Duration
 	var yes bool
 
 	var cmd = &cobra.Command{
@@ -58,10 +90,24 @@ func newUpdateCmd() *cobra.Command {
 			"that it may be updated incrementally again later.\n" +
 			"\n" +
 			"The program to run is loaded from the project in the current directory. Use the `-C` or\n" +
-			"`--cwd` flag to use a different directory.",
+			"`--cwd` flag to use a different directory.\n" +
+			"\n" +
+			"Configuration values may be read from environment variables rather than the stack's settings,\n" +
+			"so that values such as the short-lived credentials of CI jobs needn't be saved, by mapping keys\n" +
+			"to variables with `--config-env <key>=<variable>`, or `--config-env-secret` for secrets, or in\n" +
+			"the `configEnv` section of Pulumi.yaml.",
 		Args: cmdutil.NoArgs,
 		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
-			interactive := isInteractive(nonInteractive)
+			if err := checkDisplayModeFlags(quiet, summaryOnly, jsonDisplay); err != nil {
+				return err
+			}
+			if diffContextLines < 0 {
+				return errors.New("--diff-context-lines must not be negative")
+			}
+			if jsonDisplay && !yes {
+				return errors.New("--yes must be passed in to proceed when using --json")
+			}
+			interactive := isInteractive(nonInteractive) && !jsonDisplay
 			if !interactive {
 				yes = true // auto-approve changes, since we cannot prompt.
 			}
@@ -86,10 +132,29 @@ func newUpdateCmd() *cobra.Command {
 				return errors.Wrap(err, "gathering environment metadata")
 			}
 
+			var plan *engine.UpdatePlan
+			if planFile != "" {
+				if plan, err = readUpdatePlan(planFile); err != nil {
+					return err
+				}
+			}
+
+			if opts.ConfigEnv, err = configEnvVars(configEnv, configEnvSecret); err != nil {
+				return err
+			}
+			opts.QueueTimeout = queueTimeout(queue, queueWait)
 			opts.Engine = engine.UpdateOptions{
-				Analyzers: analyzers,
-				Parallel:  parallel,
-				Debug:     debug,
+				Analyzers:        analyzers,
+				Parallel:         parallel,
+				Retry:            retryPolicy(retries, retryBackoff),
+				Debug:            debug,
+				DiffContextLines: &diffContextLines,
+				SecretPatterns:   secretPatterns,
+				Targets:          targetURNs(targets),
+				Plan:             plan,
+				Resume:           resume,
+				Rollback:         rollback,
+				StackOutputs:     newStackOutputsReader(s.Backend()),
 			}
 			opts.Display = backend.DisplayOptions{
 				Color:                color.Colorization(),
@@ -98,7 +163,27 @@ func newUpdateCmd() *cobra.Command {
 				ShowSameResources:    showSames,
 				IsInteractive:        interactive,
 				DiffDisplay:          diffDisplay,
+				JSONDisplay:          jsonDisplay,
+				Quiet:                quiet,
+				SummaryOnly:          summaryOnly,
+				DiffFormat:           diffFormat.DiffFormat(),
 				Debug:                debug,
+				Diff: engine.DiffOptions{
+					FullDiff:           fullDiff,
+					IncludePaths:       diffIncludePaths,
+					ExcludePaths:       diffExcludePaths,
+					ArchiveContents:    diffArchiveContents,
+					MaxStringLength:    diffMaxStringLength,
+					MaxArrayElements:   diffMaxArrayElements,
+					MaxObjectKeys:      diffMaxObjectKeys,
+					NoTruncate:         diffNoTruncate,
+					CollapseUnchanged:  diffCollapseUnchanged,
+					ExpandPaths:        diffExpandPaths,
+					NoLineNumbers:      diffNoLineNumbers,
+					Width:              diffWidth,
+					ArrayKeys:          diffArrayKeys,
+					MatchArrayElements: diffMatchArrays,
+				},
 			}
 
 			changes, err := s.Update(commandContext(), proj, root, m, opts, cancellationScopes)
@@ -133,16 +218,112 @@ func newUpdateCmd() *cobra.Command {
 	cmd.PersistentFlags().StringSliceVar(
 		&analyzers, "analyzer", []string{},
 		"Run one or more analyzers as part of this update")
+	cmd.PersistentFlags().IntVar(
+		&diffContextLines, "diff-context-lines", engine.DefaultDiffContextLines,
+		"The number of unchanged lines to show around each change when diffing text assets")
+	cmd.PersistentFlags().BoolVar(
+		&fullDiff, "full-diff", false,
+		"Show the full contents of changed text assets rather than just the lines around each change")
+	cmd.PersistentFlags().StringSliceVar(
+		&diffIncludePaths, "diff-path", []string{},
+		"Only show properties whose paths match the given pattern (e.g. 'tags.*'); may be repeated")
+	cmd.PersistentFlags().StringSliceVar(
+		&diffExcludePaths, "diff-ignore", []string{},
+		"Hide properties whose paths match the given pattern (e.g. 'metadata.annotations.*'); may be repeated")
+	cmd.PersistentFlags().BoolVar(
+		&diffArchiveContents, "diff-archive-contents", false,
+		"Open changed file and URI archives and show which files within them were added, changed, or deleted")
+	cmd.PersistentFlags().IntVar(
+		&diffMaxStringLength, "max-string-length", engine.DefaultMaxStringLength,
+		"The maximum number of characters of a string property value to show before truncating it")
+	cmd.PersistentFlags().IntVar(
+		&diffMaxArrayElements, "max-array-elements", engine.DefaultMaxArrayElements,
+		"The maximum number of elements of an array property value to show before truncating it")
+	cmd.PersistentFlags().IntVar(
+		&diffMaxObjectKeys, "max-object-keys", engine.DefaultMaxObjectKeys,
+		"The maximum number of properties of an object value to show before truncating it")
+	cmd.PersistentFlags().BoolVar(
+		&diffNoTruncate, "no-truncate", false,
+		"Show property values in their entirety, no matter how large they are")
+	cmd.PersistentFlags().BoolVar(
+		&diffCollapseUnchanged, "collapse-unchanged", false,
+		"Collapse unchanged objects and arrays within a diff into a single line counting their contents")
+	cmd.PersistentFlags().StringSliceVar(
+		&diffExpandPaths, "expand", []string{},
+		"Show unchanged properties whose paths match the given pattern in full, even with --collapse-unchanged; "+
+			"may be repeated")
+	cmd.PersistentFlags().BoolVar(
+		&diffNoLineNumbers, "no-line-numbers", false,
+		"Omit the line numbers shown beside each line of a text diff")
+	cmd.PersistentFlags().IntVar(
+		&diffWidth, "width", 0,
+		"The width to wrap diff output to; by default, the width of the terminal is used, and a negative value "+
+			"disables wrapping")
+	cmd.PersistentFlags().StringSliceVar(
+		&diffArrayKeys, "diff-array-key", []string{},
+		"Match up the elements of changed arrays of objects by the given property (e.g. 'name') rather than by "+
+			"index; may be repeated")
+	cmd.PersistentFlags().BoolVar(
+		&diffMatchArrays, "diff-match-arrays", false,
+		"Match up the elements of changed arrays by value rather than by index, showing only those actually "+
+			"added, removed, or changed")
+	cmd.PersistentFlags().StringSliceVar(
+		&secretPatterns, "secret-pattern", []string{},
+		"Treat properties matching the given path pattern (e.g. 'password' or '**.secretKey') as secrets, "+
+			"masking their values in all output")
+	cmd.PersistentFlags().StringSliceVar(
+		&configEnv, "config-env", []string{},
+		"Use the value of an environment variable for a configuration key, given as <key>=<variable>, "+
+			"instead of the stack's; may be repeated")
+	cmd.PersistentFlags().StringSliceVar(
+		&configEnvSecret, "config-env-secret", []string{},
+		"Use the value of an environment variable as a secret for a configuration key, given as "+
+			"<key>=<variable>; may be repeated")
+	cmd.PersistentFlags().StringSliceVar(
+		&targets, "target", []string{},
+		"Restrict the update to the resource with the given URN; other resources are left as they are, "+
+			"except for those that depend on a deleted target.  May be repeated")
+	cmd.PersistentFlags().BoolVar(
+		&resume, "resume", false,
+		"Resume an interrupted update, first reconciling the operations it left in progress with the actual "+
+			"state of their resources")
+	cmd.PersistentFlags().BoolVar(
+		&rollback, "rollback-on-failure", false,
+		"If any resource operation fails or times out, make a best effort to roll back the changes already made by "+
+			"the update, deleting resources that it created and restoring the previous inputs of resources that it "+
+			"updated")
+	cmd.PersistentFlags().StringVar(
+		&planFile, "plan", "",
+		"Apply a plan saved by 'pulumi preview --save-plan', refusing to perform any operation it doesn't contain")
 	cmd.PersistentFlags().VarP(
 		&color, "color", "c", "Colorize output. Choices are: always, never, raw, auto")
 	cmd.PersistentFlags().BoolVar(
 		&diffDisplay, "diff", false,
 		"Display operation as a rich diff showing the overall change")
+	cmd.PersistentFlags().BoolVar(
+		&jsonDisplay, "json", false,
+		"Write the operation's events to stdout as newline-delimited JSON, as described in pkg/apitype/events.go")
+	cmd.PersistentFlags().BoolVarP(
+		&quiet, "quiet", "q", false,
+		"Display only errors and the final summary of changes")
+	cmd.PersistentFlags().BoolVar(
+		&summaryOnly, "summary-only", false,
+		"Display one line per changed resource, with its operation and number of changed properties, "+
+			"instead of the full diff")
+	cmd.PersistentFlags().Var(
+		&diffFormat, "diff-format",
+		"The format in which to display changes. Choices are: default, patch (a stable, uncolored textual patch)")
 	cmd.PersistentFlags().BoolVar(
 		&nonInteractive, "non-interactive", false, "Disable interactive mode")
 	cmd.PersistentFlags().IntVarP(
 		&parallel, "parallel", "p", 0,
 		"Allow P resource operations to run in parallel at once (<=1 for no parallelism)")
+	cmd.PersistentFlags().IntVar(
+		&retries, "retries", 0,
+		"Retry each resource operation that fails with a transient provider error up to this many times")
+	cmd.PersistentFlags().DurationVar(
+		&retryBackoff, "retry-backoff", deploy.DefaultRetryInitialBackoff,
+		"The time to wait before the first retry of a failed resource operation; the wait doubles after each retry")
 	cmd.PersistentFlags().BoolVar(
 		&showConfig, "show-config", false,
 		"Show configuration keys and variables")
@@ -155,6 +336,12 @@ func newUpdateCmd() *cobra.Command {
 	cmd.PersistentFlags().BoolVar(
 		&skipPreview, "skip-preview", false,
 		"Do not perform a preview before performing the update")
+	cmd.PersistentFlags().BoolVar(
+		&queue, "queue", false,
+		"If another update of the stack is in progress, wait for it to finish instead of failing")
+	cmd.PersistentFlags().DurationVar(
+		&queueWait, "queue-timeout", defaultQueueTimeout,
+		"How long to wait for another update of the stack to finish when --queue is passed")
 	cmd.PersistentFlags().BoolVarP(
 		&yes, "yes", "y", false,
 		"Automatically approve and perform the update after previewing it")
@@ -17,12 +17,15 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
 	"sort"
+	"time"
 
 	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
@@ -30,6 +33,7 @@ import (
 	survey "gopkg.in/AlecAivazis/survey.v1"
 	surveycore "gopkg.in/AlecAivazis/survey.v1/core"
 
+	"github.com/pulumi/pulumi/pkg/apitype"
 	"github.com/pulumi/pulumi/pkg/backend"
 	"github.com/pulumi/pulumi/pkg/backend/cloud"
 	"github.com/pulumi/pulumi/pkg/backend/local"
@@ -37,6 +41,8 @@ import (
 	"github.com/pulumi/pulumi/pkg/diag"
 	"github.com/pulumi/pulumi/pkg/diag/colors"
 	"github.com/pulumi/pulumi/pkg/engine"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
 	"github.com/pulumi/pulumi/pkg/util/cancel"
 	"github.com/pulumi/pulumi/pkg/util/cmdutil"
 	"github.com/pulumi/pulumi/pkg/util/contract"
@@ -56,11 +62,88 @@ func currentBackend() (backend.Backend, error) {
 		return nil, err
 	}
 	if local.IsLocalBackendURL(creds.Current) {
-		return local.New(cmdutil.Diag(), creds.Current), nil
+		return local.New(cmdutil.Diag(), creds.Current)
 	}
 	return cloud.Login(commandContext(), cmdutil.Diag(), creds.Current)
 }
 
+// backendForURL returns the backend at the given URL without logging into it, so the current backend stays as it is.
+// The Pulumi Service must have been logged into before, so that its credentials are stored.
+func backendForURL(url string) (backend.Backend, error) {
+	if local.IsLocalBackendURL(url) {
+		return local.New(cmdutil.Diag(), url)
+	}
+
+	url = cloud.ValueOrDefaultURL(url)
+	token, err := workspace.GetAccessToken(url)
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		return nil, errors.Errorf("there are no stored credentials for %s; log into it once with `pulumi login %s`",
+			url, url)
+	}
+	return cloud.New(cmdutil.Diag(), url)
+}
+
+// storeBackendMirrors records the mirrors of the backend just logged into, validating that each can be reached.
+func storeBackendMirrors(urls []string, failure string) error {
+	if _, err := backend.ParseMirrorFailurePolicy(failure); err != nil {
+		return err
+	}
+	current, err := workspace.GetCurrentCloudURL()
+	if err != nil {
+		return err
+	}
+	for _, url := range urls {
+		if url == current {
+			return errors.Errorf("%s can't be a mirror of itself", url)
+		}
+		if _, err = backendForURL(url); err != nil {
+			return errors.Wrapf(err, "opening mirror %s", url)
+		}
+	}
+	return workspace.StoreBackendMirrors(current, &workspace.BackendMirrors{URLs: urls, Failure: failure})
+}
+
+// currentStateMirrors returns the mirrors of the current backend, or nil if it has none.
+func currentStateMirrors() (*backend.StateMirrors, error) {
+	current, err := workspace.GetCurrentCloudURL()
+	if err != nil {
+		return nil, err
+	}
+	settings, err := workspace.GetBackendMirrors(current)
+	if err != nil || settings == nil {
+		return nil, err
+	}
+
+	policy, err := backend.ParseMirrorFailurePolicy(settings.Failure)
+	if err != nil {
+		return nil, err
+	}
+	mirrors := &backend.StateMirrors{Policy: policy, Sink: cmdutil.Diag()}
+	for _, url := range settings.URLs {
+		b, err := backendForURL(url)
+		if err != nil {
+			return nil, errors.Wrapf(err, "opening mirror %s", url)
+		}
+		mirrors.Backends = append(mirrors.Backends, b)
+	}
+	return mirrors, nil
+}
+
+// importStackDeployment imports a deployment into a stack, and then copies it to the mirrors of the stack's backend.
+func importStackDeployment(s backend.Stack, deployment *apitype.UntypedDeployment) error {
+	if err := s.ImportDeployment(commandContext(), deployment); err != nil {
+		return err
+	}
+	mirrors, err := currentStateMirrors()
+	if err != nil {
+		return err
+	}
+	return mirrors.MirrorDeployment(commandContext(), s.Name(), deployment)
+}
+
 // This is used to control the contents of the tracing header.
 var tracingHeader = os.Getenv("PULUMI_TRACING_HEADER")
 
@@ -304,12 +387,60 @@ func (cf *colorFlag) Type() string {
 
 func (cf *colorFlag) Colorization() colors.Colorization {
 	if cf.value == "" {
+		// Unless a colorization was explicitly requested, respect the NO_COLOR convention.
+		if colors.NoColorRequested() {
+			return colors.Never
+		}
 		return colors.Always
 	}
 
 	return cf.value
 }
 
+// checkDisplayModeFlags returns an error if more than one of the display modes that replace the usual output was
+// requested.
+func checkDisplayModeFlags(quiet, summaryOnly, jsonDisplay bool) error {
+	switch {
+	case quiet && summaryOnly:
+		return errors.New("only one of --quiet and --summary-only may be passed")
+	case jsonDisplay && (quiet || summaryOnly):
+		return errors.New("--quiet and --summary-only cannot be used with --json")
+	}
+	return nil
+}
+
+type diffFormatFlag struct {
+	value backend.DiffFormat
+}
+
+func (df *diffFormatFlag) String() string {
+	if df.value == backend.DiffFormatDefault {
+		return "default"
+	}
+	return string(df.value)
+}
+
+func (df *diffFormatFlag) Set(value string) error {
+	switch value {
+	case "default":
+		df.value = backend.DiffFormatDefault
+	case "patch":
+		df.value = backend.DiffFormatPatch
+	default:
+		return errors.Errorf("unsupported diff format: '%s'.  Supported values are: default, patch", value)
+	}
+
+	return nil
+}
+
+func (df *diffFormatFlag) Type() string {
+	return "backend.DiffFormat"
+}
+
+func (df *diffFormatFlag) DiffFormat() backend.DiffFormat {
+	return df.value
+}
+
 // anyWriter is an io.Writer that will set itself to `true` iff any call to `anyWriter.Write` is made with a
 // non-zero-length slice. This can be used to determine whether or not any data was ever written to the writer.
 type anyWriter bool
@@ -478,8 +609,84 @@ func updateFlagsToOptions(interactive, skipPreview, yes bool) (backend.UpdateOpt
 			errors.New("--yes must be passed in non-interactive mode")
 	}
 
+	mirrors, err := currentStateMirrors()
+	if err != nil {
+		return backend.UpdateOptions{}, err
+	}
+
 	return backend.UpdateOptions{
 		AutoApprove: yes,
 		SkipPreview: skipPreview,
+		Mirrors:     mirrors,
 	}, nil
 }
+
+// defaultQueueTimeout is how long an update waits for another update of its stack to finish when --queue is passed.
+const defaultQueueTimeout = 30 * time.Minute
+
+// queueTimeout returns how long an update waits for another update of its stack to finish, given the --queue and
+// --queue-timeout flags; zero means that it doesn't wait at all.
+func queueTimeout(queue bool, timeout time.Duration) time.Duration {
+	if !queue {
+		return 0
+	}
+	return timeout
+}
+
+// configEnvVars parses the mappings of configuration keys to environment variables given by the --config-env and
+// --config-env-secret flags.
+func configEnvVars(vars, secretVars []string) ([]workspace.ConfigEnvVar, error) {
+	var result []workspace.ConfigEnvVar
+	for _, s := range vars {
+		v, err := workspace.ParseConfigEnvVar(s, false)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid --config-env")
+		}
+		result = append(result, v)
+	}
+	for _, s := range secretVars {
+		v, err := workspace.ParseConfigEnvVar(s, true)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid --config-env-secret")
+		}
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+// retryPolicy returns the policy for retrying resource operations given by the --retries and --retry-backoff flags.
+func retryPolicy(retries int, backoff time.Duration) deploy.RetryPolicy {
+	return deploy.RetryPolicy{MaxAttempts: retries + 1, InitialBackoff: backoff}
+}
+
+// targetURNs converts the URNs given with --target flags into resource URNs.
+func targetURNs(targets []string) []resource.URN {
+	var urns []resource.URN
+	for _, t := range targets {
+		urns = append(urns, resource.URN(t))
+	}
+	return urns
+}
+
+// readUpdatePlan reads a plan saved by `pulumi preview --save-plan` from the given file.
+func readUpdatePlan(file string) (*engine.UpdatePlan, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read plan")
+	}
+
+	var plan engine.UpdatePlan
+	if err = json.Unmarshal(b, &plan); err != nil {
+		return nil, errors.Wrapf(err, "could not read plan from '%s'", file)
+	}
+	return &plan, nil
+}
+
+// writeJSONFile saves a value, such as a plan recorded by a preview, to the given file as indented JSON.
+func writeJSONFile(file string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return errors.Wrap(err, "could not serialize")
+	}
+	return errors.Wrapf(ioutil.WriteFile(file, b, 0600), "could not write '%s'", file)
+}
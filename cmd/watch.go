@@ -0,0 +1,237 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/backend"
+	"github.com/pulumi/pulumi/pkg/diag"
+	"github.com/pulumi/pulumi/pkg/diag/colors"
+	"github.com/pulumi/pulumi/pkg/engine"
+	"github.com/pulumi/pulumi/pkg/operations"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+	"github.com/pulumi/pulumi/pkg/util/logging"
+)
+
+func newWatchCmd() *cobra.Command {
+	var debug bool
+	var message string
+	var stack string
+	var color colorFlag
+	var interval time.Duration
+	var parallel int
+	var showLogs bool
+	var showSames bool
+
+	var cmd = &cobra.Command{
+		Use:   "watch",
+		Short: "Continuously update the resources in a stack as its program changes",
+		Long: "Continuously update the resources in a stack as its program changes.\n" +
+			"\n" +
+			"This command updates the stack, and then watches the program's directory for changes.\n" +
+			"Each time a file changes, the program is run again and the stack is updated to match it,\n" +
+			"without a separate preview or confirmation.  Resources that haven't changed are left as\n" +
+			"they are and aren't shown, so each update only shows (and spends time on) what changed.\n" +
+			"Pass `--logs` to also show the logs written by the stack's resources after each update.\n" +
+			"\n" +
+			"Files and directories whose names begin with '.', and node_modules directories, are not\n" +
+			"watched.  Press ^C to stop watching.\n" +
+			"\n" +
+			"The program to run is loaded from the project in the current directory. Use the `-C` or\n" +
+			"`--cwd` flag to use a different directory.",
+		Args: cmdutil.NoArgs,
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			if interval <= 0 {
+				return errors.New("--interval must be positive")
+			}
+
+			s, err := requireStack(stack, true)
+			if err != nil {
+				return err
+			}
+
+			proj, root, err := readProject()
+			if err != nil {
+				return err
+			}
+
+			mirrors, err := currentStateMirrors()
+			if err != nil {
+				return err
+			}
+
+			opts := backend.UpdateOptions{
+				AutoApprove: true,
+				SkipPreview: true,
+				Mirrors:     mirrors,
+				Engine: engine.UpdateOptions{
+					Parallel:     parallel,
+					Debug:        debug,
+					StackOutputs: newStackOutputsReader(s.Backend()),
+				},
+				Display: backend.DisplayOptions{
+					Color:             color.Colorization(),
+					ShowSameResources: showSames,
+					IsInteractive:     false,
+					Debug:             debug,
+				},
+			}
+
+			started := time.Now()
+			shown := make(map[operations.LogEntry]bool)
+			for {
+				files, err := watchProgramFiles(root)
+				if err != nil {
+					return err
+				}
+
+				m, err := getUpdateMetadata(message, root)
+				if err != nil {
+					return errors.Wrap(err, "gathering environment metadata")
+				}
+
+				// A failed update doesn't end the session: the next change to the program may well fix it.
+				_, err = s.Update(commandContext(), proj, root, m, opts, cancellationScopes)
+				switch {
+				case err == context.Canceled:
+					return errors.New("update cancelled")
+				case err != nil:
+					cmdutil.Diag().Errorf(diag.Message("", "%s"), err)
+				}
+
+				if showLogs {
+					showStackLogs(s, started, shown)
+				}
+
+				fmt.Printf(colors.ColorizeText(
+					colors.BrightMagenta+"\nWatching for changes to the program in %s...\n\n"+colors.Reset), root)
+				for {
+					time.Sleep(interval)
+					changed, err := watchProgramFiles(root)
+					if err != nil {
+						return err
+					}
+					if file, has := changedProgramFile(files, changed); has {
+						fmt.Printf(colors.ColorizeText(
+							colors.BrightMagenta+"%s changed; updating.\n\n"+colors.Reset), file)
+						break
+					}
+				}
+			}
+		}),
+	}
+
+	cmd.PersistentFlags().BoolVarP(
+		&debug, "debug", "d", false,
+		"Print detailed debugging output during resource operations")
+	cmd.PersistentFlags().StringVarP(
+		&stack, "stack", "s", "",
+		"Choose a stack other than the currently selected one")
+	cmd.PersistentFlags().StringVarP(
+		&message, "message", "m", "",
+		"Optional message to associate with each update")
+	cmd.PersistentFlags().VarP(
+		&color, "color", "c", "Colorize output. Choices are: always, never, raw, auto")
+	cmd.PersistentFlags().DurationVar(
+		&interval, "interval", time.Second,
+		"How often to check the program's files for changes")
+	cmd.PersistentFlags().BoolVar(
+		&showLogs, "logs", false,
+		"Show the logs written by the stack's resources after each update")
+	cmd.PersistentFlags().IntVarP(
+		&parallel, "parallel", "p", 0,
+		"Allow P resource operations to run in parallel at once (<=1 for no parallelism)")
+	cmd.PersistentFlags().BoolVar(
+		&showSames, "show-sames", false,
+		"Show resources that don't need be updated because they haven't changed, alongside those that do")
+
+	return cmd
+}
+
+// programFile is what is known about a watched file: enough to tell whether it has changed.
+type programFile struct {
+	size    int64
+	modTime time.Time
+}
+
+// watchProgramFiles returns the files of the program rooted at the given directory that are watched for changes.
+func watchProgramFiles(root string) (map[string]programFile, error) {
+	files := make(map[string]programFile)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// A file may be removed while we look at it; the next walk will notice that it's gone.
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		name := info.Name()
+		if path != root && (strings.HasPrefix(name, ".") || name == "node_modules") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() {
+			files[path] = programFile{size: info.Size(), modTime: info.ModTime()}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "watching %s", root)
+	}
+	return files, nil
+}
+
+// changedProgramFile returns a file that was added, removed, or changed between two walks of a program's files.
+func changedProgramFile(olds, news map[string]programFile) (string, bool) {
+	for path, nf := range news {
+		if of, has := olds[path]; !has || of != nf {
+			return path, true
+		}
+	}
+	for path := range olds {
+		if _, has := news[path]; !has {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// showStackLogs prints the logs written by a stack's resources since the given time that haven't yet been shown.
+// Logs are a nicety here, so failing to fetch them isn't an error.
+func showStackLogs(s backend.Stack, since time.Time, shown map[operations.LogEntry]bool) {
+	logs, err := s.GetLogs(commandContext(), operations.LogQuery{StartTime: &since})
+	if err != nil {
+		logging.V(3).Infof("failed to get logs for stack %s: %v", s.Name(), err)
+		return
+	}
+	for _, logEntry := range logs {
+		if !shown[logEntry] {
+			fmt.Println(formatLogEntry(logEntry))
+			shown[logEntry] = true
+		}
+	}
+}
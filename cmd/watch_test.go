@@ -0,0 +1,97 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeProgramFile writes a file of a program, making the directories it's in.
+func writeProgramFile(t *testing.T, root string, name string, contents string) {
+	path := filepath.Join(root, name)
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	assert.NoError(t, ioutil.WriteFile(path, []byte(contents), 0600))
+}
+
+func TestWatchProgramFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(t *testing.T, root string)
+		changed string // the file that was changed, if a change is noticed.
+	}{
+		{"nothing", func(t *testing.T, root string) {}, ""},
+		{"added", func(t *testing.T, root string) {
+			writeProgramFile(t, root, "lib/new.ts", "export {}")
+		}, "lib/new.ts"},
+		{"removed", func(t *testing.T, root string) {
+			assert.NoError(t, os.Remove(filepath.Join(root, "lib/util.ts")))
+		}, "lib/util.ts"},
+		{"resized", func(t *testing.T, root string) {
+			writeProgramFile(t, root, "index.ts", "import * as util from './lib/util';")
+		}, "index.ts"},
+		{"touched", func(t *testing.T, root string) {
+			later := time.Now().Add(time.Hour)
+			assert.NoError(t, os.Chtimes(filepath.Join(root, "index.ts"), later, later))
+		}, "index.ts"},
+		{"hidden directory", func(t *testing.T, root string) {
+			writeProgramFile(t, root, ".git/refs/heads/master", "abc")
+		}, ""},
+		{"hidden file", func(t *testing.T, root string) {
+			writeProgramFile(t, root, "lib/.util.ts.swp", "swap")
+		}, ""},
+		{"dependency", func(t *testing.T, root string) {
+			writeProgramFile(t, root, "node_modules/pkg/index.js", "module.exports = 2;")
+		}, ""},
+		{"nested dependency", func(t *testing.T, root string) {
+			writeProgramFile(t, root, "lib/node_modules/pkg/index.js", "module.exports = 1;")
+		}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root, err := ioutil.TempDir("", "pulumi-watch-test")
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer func() { assert.NoError(t, os.RemoveAll(root)) }()
+
+			writeProgramFile(t, root, "index.ts", "import './lib/util';")
+			writeProgramFile(t, root, "lib/util.ts", "export {}")
+			writeProgramFile(t, root, ".git/HEAD", "ref: refs/heads/master")
+			writeProgramFile(t, root, "node_modules/pkg/index.js", "module.exports = 1;")
+
+			olds, err := watchProgramFiles(root)
+			assert.NoError(t, err)
+			assert.Len(t, olds, 2)
+
+			test.change(t, root)
+			news, err := watchProgramFiles(root)
+			assert.NoError(t, err)
+			changed, has := changedProgramFile(olds, news)
+			if test.changed == "" {
+				assert.False(t, has, "unexpected change to %s", changed)
+			} else {
+				assert.True(t, has)
+				assert.Equal(t, filepath.Join(root, test.changed), changed)
+			}
+		})
+	}
+}
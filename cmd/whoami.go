@@ -0,0 +1,165 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/backend/cloud"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+	"github.com/pulumi/pulumi/pkg/workspace"
+)
+
+// whoamiInfo is everything that `pulumi whoami` reports.
+type whoamiInfo struct {
+	User          string       `json:"user"`
+	Backend       string       `json:"backend"`
+	URL           string       `json:"url"`
+	Organizations []string     `json:"organizations,omitempty"`
+	Token         *whoamiToken `json:"token,omitempty"`
+}
+
+type whoamiToken struct {
+	Name    string   `json:"name,omitempty"`
+	Scopes  []string `json:"scopes,omitempty"`
+	Expires string   `json:"expires,omitempty"`
+	Expired bool     `json:"expired"`
+}
+
+func newWhoAmICmd() *cobra.Command {
+	var jsonOut bool
+	var verbose bool
+
+	cmd := &cobra.Command{
+		Use:   "whoami",
+		Short: "Display the current logged-in user",
+		Long: "Display the current logged-in user.\n" +
+			"\n" +
+			"This reports the user that the current backend is logged in as.  With `--verbose` or\n" +
+			"`--json`, it also reports the backend, the user's organizations and, for the Pulumi\n" +
+			"Service, the scopes and expiry of the access token in use.  The command fails if the\n" +
+			"credentials can't be used or the token has expired, so CI systems can run it to check\n" +
+			"their credentials before starting a long deployment.",
+		Args: cmdutil.NoArgs,
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			info, err := gatherWhoAmIInfo()
+			if err != nil {
+				return err
+			}
+
+			if jsonOut {
+				if err = printJSON(info); err != nil {
+					return err
+				}
+			} else if verbose {
+				printWhoAmIInfo(info)
+			} else {
+				fmt.Println(info.User)
+			}
+
+			if info.Token != nil && info.Token.Expired {
+				return errors.Errorf("the access token for %s expired at %s; run `pulumi login` to log in again",
+					info.URL, info.Token.Expires)
+			}
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().BoolVarP(
+		&jsonOut, "json", "j", false, "Emit the information as JSON")
+	cmd.PersistentFlags().BoolVarP(
+		&verbose, "verbose", "v", false,
+		"Also display the backend, the user's organizations, and the access token's scopes and expiry")
+
+	return cmd
+}
+
+// gatherWhoAmIInfo collects the information reported by `pulumi whoami`.
+func gatherWhoAmIInfo() (*whoamiInfo, error) {
+	creds, err := workspace.GetStoredCredentials()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read credentials")
+	}
+	if creds.Current == "" {
+		return nil, errors.New("not logged in; run `pulumi login` to log in")
+	}
+
+	b, err := currentBackend()
+	if err != nil {
+		return nil, err
+	}
+	info := &whoamiInfo{Backend: b.Name(), URL: creds.Current}
+
+	cb, ok := b.(cloud.Backend)
+	if !ok {
+		// The local backend has no users of its own; it acts as whoever is running the CLI.
+		u, err := user.Current()
+		if err != nil {
+			return nil, errors.Wrap(err, "finding the current user")
+		}
+		info.User = u.Username
+		return info, nil
+	}
+
+	u, err := cb.CurrentUserInfo(commandContext())
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not authenticate with %s", creds.Current)
+	}
+	info.User = u.GitHubLogin
+	for _, org := range u.Organizations {
+		info.Organizations = append(info.Organizations, org.GitHubLogin)
+	}
+	if u.TokenInfo != nil {
+		info.Token = &whoamiToken{Name: u.TokenInfo.Name, Scopes: u.TokenInfo.Scopes}
+		if u.TokenInfo.Expires != 0 {
+			expires := time.Unix(u.TokenInfo.Expires, 0)
+			info.Token.Expires = expires.Format(time.RFC3339)
+			info.Token.Expired = time.Now().After(expires)
+		}
+	}
+	return info, nil
+}
+
+func printWhoAmIInfo(info *whoamiInfo) {
+	fmt.Printf("User: %s\n", info.User)
+	fmt.Printf("Backend: %s (%s)\n", info.Backend, info.URL)
+	if len(info.Organizations) > 0 {
+		fmt.Printf("Organizations: %s\n", strings.Join(info.Organizations, ", "))
+	}
+	if info.Token != nil {
+		if info.Token.Name != "" {
+			fmt.Printf("Token: %s\n", info.Token.Name)
+		}
+		scopes := "(unrestricted)"
+		if len(info.Token.Scopes) > 0 {
+			scopes = strings.Join(info.Token.Scopes, ", ")
+		}
+		fmt.Printf("Token scopes: %s\n", scopes)
+		switch {
+		case info.Token.Expires == "":
+			fmt.Printf("Token expires: never\n")
+		case info.Token.Expired:
+			fmt.Printf("Token expires: %s (expired)\n", info.Token.Expires)
+		default:
+			fmt.Printf("Token expires: %s\n", info.Token.Expires)
+		}
+	}
+}
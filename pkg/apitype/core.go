@@ -49,12 +49,16 @@ type Deployment = DeploymentV1
 type Manifest = ManifestV1
 type PluginInfo = PluginInfoV1
 type Resource = ResourceV1
+type Operation = OperationV1
 
 // VersionedCheckpoint is a version number plus a json document. The version number describes what
 // version of the Checkpoint structure the Checkpoint member's json document can decode into.
 type VersionedCheckpoint struct {
 	Version    int             `json:"version"`
 	Checkpoint json.RawMessage `json:"checkpoint"`
+	// Checksum, if present, is the hex-encoded SHA-256 hash of the compacted JSON of the checkpoint, so that a
+	// checkpoint that has been corrupted since it was written can be detected.
+	Checksum string `json:"checksum,omitempty"`
 }
 
 // CheckpointV1 is a serialized deployment target plus a record of the latest deployment.
@@ -74,6 +78,29 @@ type DeploymentV1 struct {
 	Manifest ManifestV1 `json:"manifest" yaml:"manifest"`
 	// Resources contains all resources that are currently part of this stack after this deployment has finished.
 	Resources []ResourceV1 `json:"resources,omitempty" yaml:"resources,omitempty"`
+	// PendingOperations are the operations that were still in progress when this deployment was last saved.  These
+	// are only present if an update was interrupted.
+	PendingOperations []OperationV1 `json:"pending_operations,omitempty" yaml:"pending_operations,omitempty"`
+}
+
+// OperationType is the kind of operation being performed on a resource.
+type OperationType string
+
+const (
+	// OperationTypeCreating is the state of resources that are being created.
+	OperationTypeCreating OperationType = "creating"
+	// OperationTypeUpdating is the state of resources that are being updated.
+	OperationTypeUpdating OperationType = "updating"
+	// OperationTypeDeleting is the state of resources that are being deleted.
+	OperationTypeDeleting OperationType = "deleting"
+)
+
+// OperationV1 is an operation that was begun on a resource but had not finished when a deployment was saved.
+type OperationV1 struct {
+	// Resource is the state being operated upon: the new state for creations, and the existing state otherwise.
+	Resource ResourceV1 `json:"resource" yaml:"resource"`
+	// Type is the kind of operation.
+	Type OperationType `json:"type" yaml:"type"`
 }
 
 // UntypedDeployment contains an inner, untyped deployment structure.
@@ -110,6 +137,24 @@ type ResourceV1 struct {
 	Protect bool `json:"protect,omitempty" yaml:"protect,omitempty"`
 	// Dependencies contains the dependency edges to other resources that this depends on.
 	Dependencies []resource.URN `json:"dependencies" yaml:"dependencies,omitempty"`
+	// CustomTimeouts limits how long the resource's operations may take, if any limits were given.
+	CustomTimeouts *CustomTimeoutsV1 `json:"customTimeouts,omitempty" yaml:"customTimeouts,omitempty"`
+	// Taint, if set, is the reason that this resource has been marked for replacement by the next update.
+	Taint string `json:"taint,omitempty" yaml:"taint,omitempty"`
+	// External is set to true when this resource belongs to another, and is only read, never changed, by the engine.
+	External bool `json:"external,omitempty" yaml:"external,omitempty"`
+	// Aliases are the URNs by which this resource was previously known, such as before its stack was renamed.
+	Aliases []resource.URN `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+	// SecretOutputs are the properties whose values hold secrets.  Their inputs and outputs are encrypted.
+	SecretOutputs []resource.PropertyKey `json:"secretOutputs,omitempty" yaml:"secretOutputs,omitempty"`
+}
+
+// CustomTimeoutsV1 records the maximum number of seconds each of a resource's operations may take.  Zero means that
+// the operation may take as long as it needs.
+type CustomTimeoutsV1 struct {
+	Create float64 `json:"create,omitempty" yaml:"create,omitempty"`
+	Update float64 `json:"update,omitempty" yaml:"update,omitempty"`
+	Delete float64 `json:"delete,omitempty" yaml:"delete,omitempty"`
 }
 
 // ManifestV1 captures meta-information about this checkpoint file, such as versions of binaries, etc.
@@ -138,6 +183,13 @@ type ConfigValue struct {
 	String string `json:"string"`
 	// Secret is true if this value is a secret and false otherwise.
 	Secret bool `json:"secret"`
+	// Object is true if this value is an object or array, whose JSON is String, and false otherwise.  The secrets
+	// within an object are held as objects of the form `{"secure": <ciphertext>}`, and the object's own keys named
+	// `secure` or `ref` are escaped with a leading backslash.
+	Object bool `json:"object,omitempty"`
+	// Reference is true if this value is a reference, given by String, to a secret held in an external store, which is
+	// fetched when the value is used, and false otherwise.
+	Reference bool `json:"reference,omitempty"`
 }
 
 // StackTagName is the key for the tags bag in stack. This is just a string, but we use a type alias to provide a richer
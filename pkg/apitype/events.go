@@ -0,0 +1,129 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apitype
+
+// This file describes the events that `pulumi update --json` (and the other update commands) write, one per line, as
+// an update runs.  Each line is an EngineEvent, exactly one of whose event fields is set.  Fields may be added to
+// these types over time, so consumers should ignore fields that they don't recognize.
+
+// EngineEvent is a single event emitted by the engine during an update.
+type EngineEvent struct {
+	// Sequence is the event's position in the update's stream of events, starting at zero.
+	Sequence int `json:"sequence"`
+	// Timestamp is the time at which the event was emitted, in seconds since the Unix epoch.
+	Timestamp int64 `json:"timestamp"`
+
+	StdoutEvent      *StdoutEngineEvent `json:"stdoutEvent,omitempty"`
+	DiagnosticEvent  *DiagnosticEvent   `json:"diagnosticEvent,omitempty"`
+	PreludeEvent     *PreludeEvent      `json:"preludeEvent,omitempty"`
+	SummaryEvent     *SummaryEvent      `json:"summaryEvent,omitempty"`
+	ResourcePreEvent *ResourcePreEvent  `json:"resourcePreEvent,omitempty"`
+	ResOutputsEvent  *ResOutputsEvent   `json:"resOutputsEvent,omitempty"`
+	ResOpFailedEvent *ResOpFailedEvent  `json:"resOpFailedEvent,omitempty"`
+}
+
+// StdoutEngineEvent is a message that the engine writes for display, such as the update's progress.
+type StdoutEngineEvent struct {
+	Message string `json:"message"`
+}
+
+// DiagnosticEvent is a diagnostic message, such as a warning or an error, or output written by the program.
+type DiagnosticEvent struct {
+	// URN is the resource that the message is about, if any.
+	URN      string `json:"urn,omitempty"`
+	Prefix   string `json:"prefix,omitempty"`
+	Message  string `json:"message"`
+	Severity string `json:"severity"` // one of "debug", "info", "info#err", "warning", or "error".
+}
+
+// PreludeEvent is emitted at the start of an update.
+type PreludeEvent struct {
+	// Config is the stack's configuration.  The values of secrets are hidden.
+	Config map[string]string `json:"config"`
+}
+
+// SummaryEvent is emitted at the end of an update.
+type SummaryEvent struct {
+	// MaybeCorrupt is true if one or more resources may have been left in an unknown state.
+	MaybeCorrupt bool `json:"maybeCorrupt"`
+	// DurationSeconds is how long the update took (zero for previews).
+	DurationSeconds int `json:"durationSeconds"`
+	// ResourceChanges counts the resources affected by each kind of operation.
+	ResourceChanges map[OpType]int `json:"resourceChanges"`
+}
+
+// ResourcePreEvent is emitted before a step begins.
+type ResourcePreEvent struct {
+	Metadata StepEventMetadata `json:"metadata"`
+	// Planning is true if the step is only being planned, as part of a preview.
+	Planning bool `json:"planning,omitempty"`
+}
+
+// ResOutputsEvent is emitted when a step completes successfully.
+type ResOutputsEvent struct {
+	Metadata StepEventMetadata `json:"metadata"`
+	Planning bool              `json:"planning,omitempty"`
+}
+
+// ResOpFailedEvent is emitted when a step fails.
+type ResOpFailedEvent struct {
+	Metadata StepEventMetadata `json:"metadata"`
+	// Status is 0 if the failed operation was cleanly undone, and 1 if its resource was left in an unknown state.
+	Status int `json:"status"`
+	// Steps is the number of steps that had completed.
+	Steps int `json:"steps"`
+}
+
+// StepEventMetadata describes a step: an operation on a single resource.
+type StepEventMetadata struct {
+	Op   OpType `json:"op"`
+	URN  string `json:"urn"`
+	Type string `json:"type"`
+
+	// Old is the state of the resource before the step, and New the state after it.  Either may be missing, such as
+	// for creations and deletions respectively.
+	Old *StepEventStateMetadata `json:"old,omitempty"`
+	New *StepEventStateMetadata `json:"new,omitempty"`
+
+	// Keys are the properties whose changes caused the resource to be replaced, if it was.
+	Keys []string `json:"keys,omitempty"`
+	// Logical is true if the step represents an operation in the program, rather than one part of a replacement.
+	Logical bool `json:"logical,omitempty"`
+	// DetailedDiff lists the changes made to the resource's properties, one per changed leaf property.
+	DetailedDiff []PropertyDiff `json:"detailedDiff,omitempty"`
+}
+
+// StepEventStateMetadata is the state of a resource.  The values of secret properties are hidden.
+type StepEventStateMetadata struct {
+	Type     string                 `json:"type"`
+	URN      string                 `json:"urn"`
+	Custom   bool                   `json:"custom,omitempty"`
+	Delete   bool                   `json:"delete,omitempty"`
+	ID       string                 `json:"id,omitempty"`
+	Parent   string                 `json:"parent,omitempty"`
+	Protect  bool                   `json:"protect,omitempty"`
+	Taint    string                 `json:"taint,omitempty"`
+	External bool                   `json:"external,omitempty"`
+	Inputs   map[string]interface{} `json:"inputs,omitempty"`
+	Outputs  map[string]interface{} `json:"outputs,omitempty"`
+}
+
+// PropertyDiff describes a change to a single property.
+type PropertyDiff struct {
+	Path string      `json:"path"` // the path to the property, e.g. "tags.env" or "rules[0].port".
+	Kind string      `json:"kind"` // one of "add", "update", or "delete".
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
@@ -71,6 +71,12 @@ const (
 	OpCreateReplacement OpType = "create-replacement"
 	// OpDeleteReplaced indiciates an existing resource was deleted after replacement.
 	OpDeleteReplaced OpType = "delete-replaced"
+	// OpImport indicates an existing resource was imported into the stack.
+	OpImport OpType = "import"
+	// OpRead indicates an external resource was read.
+	OpRead OpType = "read"
+	// OpReadDiscard indicates an external resource that was no longer read was removed from the stack.
+	OpReadDiscard OpType = "discard"
 )
 
 // UpdateInfo describes a previous update.
@@ -14,6 +14,10 @@
 
 package apitype
 
+import (
+	"github.com/pulumi/pulumi/pkg/resource"
+)
+
 // LogsResult is the JSON shape of responses to a Logs operation.
 type LogsResult struct {
 	Logs []LogEntry `json:"logs"`
@@ -21,9 +25,10 @@ type LogsResult struct {
 
 // LogEntry is the individual entries in a JSON response to a Logs operation.
 type LogEntry struct {
-	ID        string `json:"id"`
-	Timestamp int64  `json:"timestamp"`
-	Message   string `json:"message"`
+	ID        string       `json:"id"`
+	Timestamp int64        `json:"timestamp"`
+	Message   string       `json:"message"`
+	URN       resource.URN `json:"urn,omitempty"` // the resource that wrote the entry, if the service knows it.
 }
 
 // GetStackLogsResponse describes the data returned by the `GET /stack/{stackID}/logs` endpoint of the PPC API.
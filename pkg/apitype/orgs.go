@@ -0,0 +1,84 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apitype
+
+// OrganizationMember describes a member of an organization.
+type OrganizationMember struct {
+	// Name is the member's display name.
+	Name string `json:"name"`
+	// GitHubLogin is the member's GitHub login, by which they are known to the service.
+	GitHubLogin string `json:"githubLogin"`
+	// Role is the member's role in the organization: "admin" or "member".
+	Role string `json:"role"`
+}
+
+// ListOrganizationMembersResponse describes the data returned by the `GET /api/orgs/{orgName}/members` endpoint.
+type ListOrganizationMembersResponse struct {
+	Members []OrganizationMember `json:"members"`
+}
+
+// Team describes a team within an organization.
+type Team struct {
+	// Name is the team's name, which is unique within its organization.
+	Name string `json:"name"`
+	// DisplayName is the name of the team as shown in the console.
+	DisplayName string `json:"displayName,omitempty"`
+	// Description is an optional description of the team.
+	Description string `json:"description,omitempty"`
+	// Members holds the GitHub logins of the team's members.
+	Members []string `json:"members,omitempty"`
+}
+
+// ListTeamsResponse describes the data returned by the `GET /api/orgs/{orgName}/teams` endpoint.
+type ListTeamsResponse struct {
+	Teams []Team `json:"teams"`
+}
+
+// CreateTeamRequest defines the request body for creating a new team.
+type CreateTeamRequest struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// StackPermission is the level of access that a team has to a stack.
+type StackPermission string
+
+const (
+	// StackPermissionNone revokes a team's access to a stack.
+	StackPermissionNone StackPermission = "none"
+	// StackPermissionRead allows a team to view a stack.
+	StackPermissionRead StackPermission = "read"
+	// StackPermissionWrite allows a team to view and update a stack.
+	StackPermissionWrite StackPermission = "write"
+	// StackPermissionAdmin allows a team to view, update, and delete a stack, and to change its settings.
+	StackPermissionAdmin StackPermission = "admin"
+)
+
+// IsStackPermission returns true if the given string names a stack permission.
+func IsStackPermission(s string) bool {
+	switch StackPermission(s) {
+	case StackPermissionNone, StackPermissionRead, StackPermissionWrite, StackPermissionAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
+// SetTeamStackPermissionRequest defines the request body for setting a team's permission on one of its
+// organization's stacks.
+type SetTeamStackPermissionRequest struct {
+	Permission StackPermission `json:"permission"`
+}
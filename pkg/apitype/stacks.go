@@ -51,6 +51,12 @@ type CreateStackRequest struct {
 	Tags map[StackTagName]string `json:"tags,omitEmpty"`
 }
 
+// StackRenameRequest defines the request body for renaming a stack.
+type StackRenameRequest struct {
+	// NewName is the stack's new name.
+	NewName string `json:"newName"`
+}
+
 // CreateStackResponseByName is the response from a create Stack request.
 type CreateStackResponseByName struct {
 	// The name of the cloud used if the default was sent.
@@ -0,0 +1,45 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apitype
+
+// User describes the user that an access token belongs to, as returned by the `GET /api/user` endpoint.
+type User struct {
+	// Name is the user's display name.
+	Name string `json:"name"`
+	// GitHubLogin is the user's GitHub login, by which they are known to the service.
+	GitHubLogin string `json:"githubLogin"`
+	// Organizations holds the organizations that the user is a member of.
+	Organizations []OrganizationSummary `json:"organizations,omitempty"`
+	// TokenInfo describes the access token used to make the request, if the service reported it.
+	TokenInfo *AccessTokenInfo `json:"tokenInfo,omitempty"`
+}
+
+// OrganizationSummary describes an organization that a user is a member of.
+type OrganizationSummary struct {
+	// Name is the organization's display name.
+	Name string `json:"name"`
+	// GitHubLogin is the organization's GitHub login, by which it is known to the service.
+	GitHubLogin string `json:"githubLogin"`
+}
+
+// AccessTokenInfo describes an access token.
+type AccessTokenInfo struct {
+	// Name is the name given to the token when it was created.
+	Name string `json:"name,omitempty"`
+	// Scopes holds the permission scopes granted to the token.
+	Scopes []string `json:"scopes,omitempty"`
+	// Expires is when the token expires, in Unix seconds, or 0 if it doesn't.
+	Expires int64 `json:"expires,omitempty"`
+}
@@ -18,6 +18,7 @@ package backend
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/pulumi/pulumi/pkg/apitype"
 	"github.com/pulumi/pulumi/pkg/engine"
@@ -68,8 +69,13 @@ type Backend interface {
 	// still contains resources.  Otherwise, if the stack contains resources, a non-nil error is returned, and the
 	// first boolean return value will be set to true.
 	RemoveStack(ctx context.Context, stackRef StackReference, force bool) (bool, error)
+	// RenameStack renames a stack, rewriting the URNs of its resources, and the references between them, to use the
+	// new name.  If newProject is non-empty, the URNs are rewritten to name that project, too.
+	RenameStack(ctx context.Context, stackRef StackReference, newName tokens.QName, newProject tokens.PackageName) error
 	// ListStacks returns a list of stack summaries for all known stacks in the target backend.
 	ListStacks(ctx context.Context, projectFilter *tokens.PackageName) ([]Stack, error)
+	// UpdateStackTags replaces the tags of a stack with the given ones.
+	UpdateStackTags(ctx context.Context, stackRef StackReference, tags map[apitype.StackTagName]string) error
 
 	// GetStackCrypter returns an encrypter/decrypter for the given stack's secret config values.
 	GetStackCrypter(stackRef StackReference) (config.Crypter, error)
@@ -87,6 +93,10 @@ type Backend interface {
 	Destroy(ctx context.Context, stackRef StackReference, proj *workspace.Project, root string,
 		m UpdateMetadata, opts UpdateOptions, scopes CancellationScopeSource) (engine.ResourceChanges, error)
 
+	// CancelCurrentUpdate cancels the update currently being applied to the given stack, releasing the stack so that
+	// it can be updated again.
+	CancelCurrentUpdate(ctx context.Context, stackRef StackReference) error
+
 	// GetHistory returns all updates for the stack. The returned UpdateInfo slice will be in
 	// descending order (newest first).
 	GetHistory(ctx context.Context, stackRef StackReference) ([]UpdateInfo, error)
@@ -97,6 +107,10 @@ type Backend interface {
 
 	// ExportDeployment exports the deployment for the given stack as an opaque JSON message.
 	ExportDeployment(ctx context.Context, stackRef StackReference) (*apitype.UntypedDeployment, error)
+	// ExportDeploymentVersion exports the deployment for the given stack as it was after the given update, where
+	// updates are numbered from 1 (the oldest) as in the stack's history.
+	ExportDeploymentVersion(ctx context.Context, stackRef StackReference,
+		version int) (*apitype.UntypedDeployment, error)
 	// ImportDeployment imports the given deployment into the indicated stack.
 	ImportDeployment(ctx context.Context, stackRef StackReference, deployment *apitype.UntypedDeployment) error
 	// Logout logs you out of the backend and removes any stored credentials.
@@ -114,6 +128,24 @@ type UpdateOptions struct {
 	AutoApprove bool
 	// SkipPreview, when true, causes the preview step to be skipped.
 	SkipPreview bool
+	// QueueTimeout, when positive, causes an update of a stack on which another update is in progress to wait up to
+	// this long for that update to finish, rather than failing immediately.
+	QueueTimeout time.Duration
+	// Mirrors, if set, are the backends to which each checkpoint of the stack is copied once it's saved.
+	Mirrors *StateMirrors
+	// ConfigEnv maps configuration keys to the environment variables whose values are used for them, in addition
+	// to the mappings of the project's `configEnv`.
+	ConfigEnv []workspace.ConfigEnvVar
+}
+
+// DisplayOptions returns the options with which to display the update's events, which include those engine options
+// that affect how diffs are rendered.
+func (opts UpdateOptions) DisplayOptions() DisplayOptions {
+	display := opts.Display
+	if opts.Engine.DiffContextLines != nil {
+		display.Diff.ContextLines = opts.Engine.DiffContextLines
+	}
+	return display
 }
 
 // CancellationScope provides a scoped source of cancellation and termination requests.
@@ -52,6 +52,7 @@ import (
 	"github.com/pulumi/pulumi/pkg/resource"
 	"github.com/pulumi/pulumi/pkg/resource/config"
 	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/resource/stack"
 	"github.com/pulumi/pulumi/pkg/tokens"
 	"github.com/pulumi/pulumi/pkg/util/archive"
 	"github.com/pulumi/pulumi/pkg/util/cmdutil"
@@ -134,13 +135,28 @@ type Backend interface {
 	backend.Backend
 
 	CloudURL() string
+	// CurrentUser returns the name of the user that the backend is logged in as.
+	CurrentUser(ctx context.Context) (string, error)
+	// CurrentUserInfo returns the user that the backend is logged in as, along with their organizations and the
+	// scopes and expiry of the access token in use.
+	CurrentUserInfo(ctx context.Context) (apitype.User, error)
 
 	DownloadPlugin(ctx context.Context, info workspace.PluginInfo, progress bool) (io.ReadCloser, error)
+	GetPluginChecksum(ctx context.Context, info workspace.PluginInfo) (string, []byte, error)
 	DownloadTemplate(ctx context.Context, name string, progress bool) (io.ReadCloser, error)
 	ListTemplates(ctx context.Context) ([]workspace.Template, error)
 
-	CancelCurrentUpdate(ctx context.Context, stackRef backend.StackReference) error
 	StackConsoleURL(stackRef backend.StackReference) (string, error)
+
+	// ListOrganizationMembers returns the members of the given organization.
+	ListOrganizationMembers(ctx context.Context, org string) ([]apitype.OrganizationMember, error)
+	// ListTeams returns the teams of the given organization.
+	ListTeams(ctx context.Context, org string) ([]apitype.Team, error)
+	// CreateTeam creates a new team in the given organization.
+	CreateTeam(ctx context.Context, org string, req apitype.CreateTeamRequest) (apitype.Team, error)
+	// SetTeamStackPermission sets the permission that a team of the stack's organization has on the stack.
+	SetTeamStackPermission(ctx context.Context, team string, stackRef backend.StackReference,
+		permission apitype.StackPermission) error
 }
 
 type cloudBackend struct {
@@ -327,6 +343,38 @@ func (b *cloudBackend) Name() string {
 
 func (b *cloudBackend) CloudURL() string { return b.url }
 
+func (b *cloudBackend) CurrentUser(ctx context.Context) (string, error) {
+	return b.client.GetPulumiAccountName(ctx)
+}
+
+func (b *cloudBackend) CurrentUserInfo(ctx context.Context) (apitype.User, error) {
+	return b.client.GetCurrentUser(ctx)
+}
+
+func (b *cloudBackend) ListOrganizationMembers(ctx context.Context, org string) ([]apitype.OrganizationMember,
+	error) {
+	return b.client.ListOrganizationMembers(ctx, org)
+}
+
+func (b *cloudBackend) ListTeams(ctx context.Context, org string) ([]apitype.Team, error) {
+	return b.client.ListTeams(ctx, org)
+}
+
+func (b *cloudBackend) CreateTeam(ctx context.Context, org string,
+	req apitype.CreateTeamRequest) (apitype.Team, error) {
+	return b.client.CreateTeam(ctx, org, req)
+}
+
+func (b *cloudBackend) SetTeamStackPermission(ctx context.Context, team string, stackRef backend.StackReference,
+	permission apitype.StackPermission) error {
+
+	stack, err := b.getCloudStackIdentifier(stackRef)
+	if err != nil {
+		return err
+	}
+	return b.client.SetTeamStackPermission(ctx, team, stack, permission)
+}
+
 func (b *cloudBackend) ParseStackReference(s string) (backend.StackReference, error) {
 	split := strings.Split(s, "/")
 	var owner string
@@ -400,19 +448,9 @@ func (b *cloudBackend) DownloadPlugin(ctx context.Context, info workspace.Plugin
 	progress bool) (io.ReadCloser, error) {
 
 	// Figure out the OS/ARCH pair for the download URL.
-	var os string
-	switch runtime.GOOS {
-	case "darwin", "linux", "windows":
-		os = runtime.GOOS
-	default:
-		return nil, errors.Errorf("unsupported plugin OS: %s", runtime.GOOS)
-	}
-	var arch string
-	switch runtime.GOARCH {
-	case "amd64":
-		arch = runtime.GOARCH
-	default:
-		return nil, errors.Errorf("unsupported plugin architecture: %s", runtime.GOARCH)
+	os, arch, err := pluginPlatform()
+	if err != nil {
+		return nil, err
 	}
 
 	// Now make the client request.
@@ -435,6 +473,39 @@ func (b *cloudBackend) DownloadPlugin(ctx context.Context, info workspace.Plugin
 	return result, nil
 }
 
+// GetPluginChecksum returns the published SHA256 checksum of a plugin's tarball, as written by `sha256sum`, and the
+// base64-encoded signature of the tarball's digest.  Either is empty if it hasn't been published.
+func (b *cloudBackend) GetPluginChecksum(ctx context.Context, info workspace.PluginInfo) (string, []byte, error) {
+	os, arch, err := pluginPlatform()
+	if err != nil {
+		return "", nil, err
+	}
+	checksum, signature, err := b.client.GetPluginChecksum(ctx, info, os, arch)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to download plugin checksum")
+	}
+	return checksum, signature, nil
+}
+
+// pluginPlatform returns the OS/ARCH pair for which to download plugins.
+func pluginPlatform() (string, string, error) {
+	var os string
+	switch runtime.GOOS {
+	case "darwin", "linux", "windows":
+		os = runtime.GOOS
+	default:
+		return "", "", errors.Errorf("unsupported plugin OS: %s", runtime.GOOS)
+	}
+	var arch string
+	switch runtime.GOARCH {
+	case "amd64":
+		arch = runtime.GOARCH
+	default:
+		return "", "", errors.Errorf("unsupported plugin architecture: %s", runtime.GOARCH)
+	}
+	return os, arch, nil
+}
+
 func (b *cloudBackend) ListTemplates(ctx context.Context) ([]workspace.Template, error) {
 	return b.client.ListTemplates(ctx)
 }
@@ -548,6 +619,45 @@ func (b *cloudBackend) RemoveStack(ctx context.Context, stackRef backend.StackRe
 	return b.client.DeleteStack(ctx, stack, force)
 }
 
+func (b *cloudBackend) UpdateStackTags(ctx context.Context, stackRef backend.StackReference,
+	tags map[apitype.StackTagName]string) error {
+
+	stack, err := b.getCloudStackIdentifier(stackRef)
+	if err != nil {
+		return err
+	}
+
+	return b.client.UpdateStackTags(ctx, stack, tags)
+}
+
+func (b *cloudBackend) RenameStack(ctx context.Context, stackRef backend.StackReference, newName tokens.QName,
+	newProject tokens.PackageName) error {
+
+	stackID, err := b.getCloudStackIdentifier(stackRef)
+	if err != nil {
+		return err
+	}
+
+	// Rename the stack first, and then rewrite its checkpoint.  If the rewrite fails, renaming the stack to the name
+	// it already has retries just the rewrite.
+	if newName != stackRef.StackName() {
+		if err = b.client.RenameStack(ctx, stackID, string(newName)); err != nil {
+			return err
+		}
+		stackRef = cloudBackendReference{name: newName, owner: stackID.Owner, b: b}
+	}
+
+	deployment, err := b.ExportDeployment(ctx, stackRef)
+	if err != nil {
+		return err
+	}
+	renamed, err := stack.RenameDeployment(deployment, newName, newProject)
+	if err != nil {
+		return err
+	}
+	return b.ImportDeployment(ctx, stackRef, renamed)
+}
+
 // cloudCrypter is an encrypter/decrypter that uses the Pulumi cloud to encrypt/decrypt a stack's secrets.
 type cloudCrypter struct {
 	backend *cloudBackend
@@ -614,6 +724,14 @@ const (
 	yes     response = "yes"
 	no      response = "no"
 	details response = "details"
+	choose  response = "choose"
+)
+
+// The responses to each change when choosing which of an update's changes to make.
+const (
+	approve response = "approve"
+	skip    response = "skip"
+	abort   response = "abort"
 )
 
 func getStack(ctx context.Context, b *cloudBackend, stackRef backend.StackReference) (backend.Stack, error) {
@@ -651,7 +769,7 @@ func createDiff(events []engine.Event, displayOpts backend.DisplayOptions) strin
 func (b *cloudBackend) PreviewThenPrompt(
 	ctx context.Context, updateKind client.UpdateKind, stack backend.Stack, pkg *workspace.Project, root string,
 	m backend.UpdateMetadata, opts backend.UpdateOptions,
-	scopes backend.CancellationScopeSource) (engine.ResourceChanges, error) {
+	scopes backend.CancellationScopeSource) (engine.ResourceChanges, []resource.URN, error) {
 
 	// create a channel to hear about the update events from the engine. this will be used so that
 	// we can build up the diff display in case the user asks to see the details of the diff
@@ -679,23 +797,26 @@ func (b *cloudBackend) PreviewThenPrompt(
 		c, err := b.updateStack(
 			ctx, updateKind, stack, pkg, root, m, opts, eventsChannel, true /*dryRun*/, scopes)
 		if err != nil {
-			return c, err
+			return c, nil, err
 		}
 		changes = c
 	}
 
 	// If there are no changes, or we're auto-approving or just previewing, we can skip the confirmation prompt.
 	if opts.AutoApprove || updateKind == client.UpdateKindPreview {
-		return changes, nil
+		return changes, nil, nil
 	}
 
 	// Otherwise, ensure the user wants to proceed.
-	return changes, confirmBeforeUpdating(updateKind, stack, events, opts)
+	targets, err := confirmBeforeUpdating(updateKind, stack, events, opts)
+	return changes, targets, err
 }
 
-// confirmBeforeUpdating asks the user whether to proceed.  A nil error means yes.
+// confirmBeforeUpdating asks the user whether to proceed.  A nil error means yes.  If the user chose to approve only
+// some of the changes, the URNs of the resources whose changes were approved are returned; otherwise, the returned
+// URNs are nil, and all of the changes are approved.
 func confirmBeforeUpdating(updateKind client.UpdateKind, stack backend.Stack,
-	events []engine.Event, opts backend.UpdateOptions) error {
+	events []engine.Event, opts backend.UpdateOptions) ([]resource.URN, error) {
 	for {
 		var response string
 
@@ -710,6 +831,9 @@ func confirmBeforeUpdating(updateKind client.UpdateKind, stack backend.Stack,
 		// have that information since all the PPC does is forward stdout events to us.
 		if stack.(Stack).RunLocally() && !opts.SkipPreview {
 			choices = append(choices, string(details))
+			if updateKind == client.UpdateKindUpdate {
+				choices = append(choices, string(choose))
+			}
 		}
 
 		var previewWarning string
@@ -724,24 +848,84 @@ func confirmBeforeUpdating(updateKind client.UpdateKind, stack backend.Stack,
 			Options: choices,
 			Default: string(no),
 		}, &response, nil); err != nil {
-			return errors.Wrapf(err, "confirmation cancelled, not proceeding with the %s", updateKind)
+			return nil, errors.Wrapf(err, "confirmation cancelled, not proceeding with the %s", updateKind)
 		}
 
 		if response == string(no) {
-			return errors.Errorf("confirmation declined, not proceeding with the %s", updateKind)
+			return nil, errors.Errorf("confirmation declined, not proceeding with the %s", updateKind)
 		}
 
 		if response == string(yes) {
-			return nil
+			return nil, nil
 		}
 
 		if response == string(details) {
-			diff := createDiff(events, opts.Display)
+			diff := createDiff(events, opts.DisplayOptions())
 			_, err := os.Stdout.WriteString(diff + "\n\n")
 			contract.IgnoreError(err)
 			continue
 		}
+
+		if response == string(choose) {
+			return chooseChanges(updateKind, events, opts)
+		}
+	}
+}
+
+// chooseChanges steps through each of the changes in a preview, showing its full diff, and asks the user whether to
+// approve or skip it, or to abort the update.  The URNs of the resources whose changes were approved are returned.
+func chooseChanges(updateKind client.UpdateKind, events []engine.Event,
+	opts backend.UpdateOptions) ([]resource.URN, error) {
+
+	// Gather the changes, one for each resource.  A replacement shows up as several steps, of which the logical one
+	// best describes it.
+	var urns []resource.URN
+	steps := make(map[resource.URN]engine.StepEventMetadata)
+	for _, e := range events {
+		if e.Type != engine.ResourcePreEvent {
+			continue
+		}
+		step := e.Payload.(engine.ResourcePreEventPayload).Metadata
+		if step.Op == deploy.OpSame || step.Op == deploy.OpRead {
+			continue
+		}
+		if prior, has := steps[step.URN]; !has {
+			urns = append(urns, step.URN)
+		} else if prior.Logical || !step.Logical {
+			continue
+		}
+		steps[step.URN] = step
 	}
+
+	display := opts.DisplayOptions()
+	var approved []resource.URN
+	for i, urn := range urns {
+		step := steps[urn]
+		summary := engine.GetResourcePropertiesSummary(step, 0, display.Diff)
+		details := engine.GetResourcePropertiesDetails(
+			step, 0, true /*planning*/, false /*summary*/, display.Debug, display.Diff)
+		_, err := os.Stdout.WriteString(display.Color.Colorize(
+			fmt.Sprintf("\nChange %d of %d:\n", i+1, len(urns)) + summary + details + colors.Reset + "\n"))
+		contract.IgnoreError(err)
+
+		var response string
+		if err := survey.AskOne(&survey.Select{
+			Message: "\b" + colors.ColorizeText(
+				colors.BrightWhite+fmt.Sprintf("Do you want to %s %s?", step.Op, urn.Name())+colors.Reset),
+			Options: []string{string(approve), string(skip), string(abort)},
+			Default: string(skip),
+		}, &response, nil); err != nil || response == string(abort) {
+			return nil, errors.Errorf("confirmation declined, not proceeding with the %s", updateKind)
+		}
+		if response == string(approve) {
+			approved = append(approved, urn)
+		}
+	}
+
+	if len(approved) == 0 {
+		return nil, errors.Errorf("no changes were approved, not proceeding with the %s", updateKind)
+	}
+	return approved, nil
 }
 
 func (b *cloudBackend) PreviewThenPromptThenExecute(
@@ -762,11 +946,16 @@ func (b *cloudBackend) PreviewThenPromptThenExecute(
 	}
 
 	// Preview the operation to the user and ask them if they want to proceed.
-	changes, err := b.PreviewThenPrompt(ctx, updateKind, stack, pkg, root, m, opts, scopes)
+	changes, targets, err := b.PreviewThenPrompt(ctx, updateKind, stack, pkg, root, m, opts, scopes)
 	if err != nil || updateKind == client.UpdateKindPreview {
 		return changes, err
 	}
 
+	// If only some of the changes were approved, restrict the update to the resources that they change.
+	if targets != nil {
+		opts.Engine.Targets = targets
+	}
+
 	// Now do the real operation.  We don't care about the events it issues, so just pass a nil channel along.
 	return b.updateStack(ctx, updateKind, stack, pkg, root, m, opts, nil, false /*dryRun*/, scopes)
 }
@@ -808,10 +997,16 @@ func (b *cloudBackend) createAndStartUpdate(
 	if err != nil {
 		return client.UpdateIdentifier{}, 0, "", err
 	}
-	workspaceStack, err := workspace.DetectProjectStack(stackRef.StackName())
+	cfg, _, err := workspace.DetectStackConfig(stackRef.StackName())
 	if err != nil {
 		return client.UpdateIdentifier{}, 0, "", errors.Wrap(err, "getting configuration")
 	}
+	err = backend.ApplyConfigEnv(cfg, opts.ConfigEnv, func() (config.Encrypter, error) {
+		return b.GetStackCrypter(stackRef)
+	})
+	if err != nil {
+		return client.UpdateIdentifier{}, 0, "", err
+	}
 	metadata := apitype.UpdateMetadata{
 		Message:     m.Message,
 		Environment: m.Environment,
@@ -821,7 +1016,7 @@ func (b *cloudBackend) createAndStartUpdate(
 		return getUpdateContents(programContext, pkg.UseDefaultIgnores(), showProgress)
 	}
 	update, err := b.client.CreateUpdate(
-		ctx, action, stack, pkg, workspaceStack.Config, main, metadata, opts.Engine, dryRun, getContents)
+		ctx, action, stack, pkg, cfg, main, metadata, opts.Engine, dryRun, getContents)
 	if err != nil {
 		return client.UpdateIdentifier{}, 0, "", err
 	}
@@ -832,7 +1027,7 @@ func (b *cloudBackend) createAndStartUpdate(
 	if err != nil {
 		return client.UpdateIdentifier{}, 0, "", errors.Wrap(err, "getting stack tags")
 	}
-	version, token, err := b.client.StartUpdate(ctx, update, tags)
+	version, token, err := b.startUpdate(ctx, update, tags, stackRef, opts.QueueTimeout)
 	if err != nil {
 		return client.UpdateIdentifier{}, 0, "", err
 	}
@@ -843,6 +1038,41 @@ func (b *cloudBackend) createAndStartUpdate(
 	return update, version, token, nil
 }
 
+// updateQueuePollInterval is how often an update that is waiting for another update of its stack to finish tries again
+// to start.
+const updateQueuePollInterval = 5 * time.Second
+
+// startUpdate starts an update.  If another update of the stack is in progress, and timeout is positive, it keeps
+// trying to start the update until that update has finished or the timeout has elapsed.  The service doesn't say how
+// many other updates are waiting, so only the time spent waiting is reported.
+func (b *cloudBackend) startUpdate(ctx context.Context, update client.UpdateIdentifier,
+	tags map[apitype.StackTagName]string, stackRef backend.StackReference,
+	timeout time.Duration) (int, string, error) {
+
+	start := time.Now()
+	for {
+		version, token, err := b.client.StartUpdate(ctx, update, tags)
+		errResp, ok := err.(*apitype.ErrorResponse)
+		if timeout <= 0 || !ok || errResp.Code != http.StatusConflict {
+			return version, token, err
+		}
+
+		waited := time.Since(start)
+		if waited >= timeout {
+			return 0, "", errors.Wrapf(err, "timed out after %v waiting for another update of stack '%s' to finish",
+				timeout, stackRef)
+		}
+		fmt.Printf("Waiting for another update of stack '%s' to finish (waited %v of %v)...\n",
+			stackRef, waited.Round(time.Second), timeout)
+
+		select {
+		case <-ctx.Done():
+			return 0, "", ctx.Err()
+		case <-time.After(updateQueuePollInterval):
+		}
+	}
+}
+
 // updateStack performs a the provided type of update on a stack hosted in the Pulumi Cloud.
 func (b *cloudBackend) updateStack(
 	ctx context.Context, action client.UpdateKind, stack backend.Stack, pkg *workspace.Project,
@@ -929,17 +1159,32 @@ func (b *cloudBackend) runEngineAction(
 	scopes backend.CancellationScopeSource) (engine.ResourceChanges, error) {
 	contract.Assertf(dryRun || token != "", "expected a non-empty token when doing a non-dryrun update")
 
-	u, err := b.newUpdate(ctx, stackRef, pkg, root, update, token)
+	u, err := b.newUpdate(ctx, stackRef, pkg, root, update, token, opts.ConfigEnv)
 	if err != nil {
 		return nil, err
 	}
 
+	// Only previews and updates run the program, so only they need its configuration to match the project's schema.
+	if action == client.UpdateKindPreview || action == client.UpdateKindUpdate {
+		if err = pkg.ValidateConfig(u.target.Config, u.target.Decrypter); err != nil {
+			return nil, err
+		}
+	}
+
 	persister := b.newSnapshotPersister(ctx, u.update, u.tokenSource)
-	manager := backend.NewSnapshotManager(persister, u.GetTarget().Snapshot)
+	var manager *backend.SnapshotManager
+	if u.tokenSource != nil {
+		lease := &cloudUpdateLease{stack: stackRef.StackName(), tokenSource: u.tokenSource}
+		mirrored := opts.Mirrors.Persister(ctx, stackRef, persister)
+		manager = backend.NewLeasedSnapshotManager(mirrored, lease, u.GetTarget().Snapshot)
+	} else {
+		manager = backend.NewSnapshotManager(persister, u.GetTarget().Snapshot)
+	}
 	displayEvents := make(chan engine.Event)
 	displayDone := make(chan bool)
 
-	go u.RecordAndDisplayEvents(getActionLabel(string(action), dryRun), displayEvents, displayDone, opts.Display)
+	go u.RecordAndDisplayEvents(
+		getActionLabel(string(action), dryRun), displayEvents, displayDone, opts.DisplayOptions())
 
 	engineEvents := make(chan engine.Event)
 
@@ -968,6 +1213,16 @@ func (b *cloudBackend) runEngineAction(
 		engineCtx.ParentSpan = parentSpan.Context()
 	}
 
+	// Estimate the duration of each step from the durations of past updates' operations.  These are only estimates,
+	// so if they can't be loaded, the update proceeds without them.
+	if opts.Engine.Durations == nil {
+		durations, durationsErr := b.getDurations(update.StackIdentifier)
+		if durationsErr != nil {
+			logging.V(7).Infof("Failed to load operation durations for stack '%s': %v", stackRef, durationsErr)
+		}
+		opts.Engine.Durations = durations
+	}
+
 	switch action {
 	case client.UpdateKindPreview:
 		changes, err = engine.Update(u, engineCtx, opts.Engine, true)
@@ -986,12 +1241,25 @@ func (b *cloudBackend) runEngineAction(
 	close(engineEvents)
 	close(displayEvents)
 	close(displayDone)
+	if !dryRun && err == nil {
+		// Prune the entries of the stack's snapshot that have outlived their use.  The snapshot is fine as it is, so
+		// if this fails, the update hasn't.
+		if _, compactErr := manager.Compact(); compactErr != nil {
+			logging.V(7).Infof("Failed to compact the snapshot of stack '%s': %v", stackRef, compactErr)
+		}
+	}
 	contract.IgnoreClose(manager)
 
 	// Make sure that the goroutine writing to displayEvents and callerEventsOpt
 	// has exited before proceeding
 	<-eventsDone
 	if !dryRun {
+		if durations := opts.Engine.Durations; durations != nil {
+			if durationsErr := b.saveDurations(update.StackIdentifier, durations); durationsErr != nil {
+				logging.V(7).Infof("Failed to save operation durations for stack '%s': %v", stackRef, durationsErr)
+			}
+		}
+
 		status := apitype.UpdateStatusSucceeded
 		if err != nil {
 			status = apitype.UpdateStatusFailed
@@ -1090,9 +1358,16 @@ func convertConfig(apiConfig map[string]apitype.ConfigValue) (config.Map, error)
 		if err != nil {
 			return nil, err
 		}
-		if rawV.Secret {
+		switch {
+		case rawV.Object:
+			if c[k], err = config.NewObjectValue(rawV.String); err != nil {
+				return nil, err
+			}
+		case rawV.Reference:
+			c[k] = config.NewReferenceValue(rawV.String)
+		case rawV.Secret:
 			c[k] = config.NewSecureValue(rawV.String)
-		} else {
+		default:
 			c[k] = config.NewValue(rawV.String)
 		}
 	}
@@ -1113,7 +1388,7 @@ func (b *cloudBackend) GetLogs(ctx context.Context, stackRef backend.StackRefere
 	// If we're dealing with a stack that runs its operations locally, get the stack's target and fetch the logs
 	// directly
 	if stack.(Stack).RunLocally() {
-		target, targetErr := b.getTarget(ctx, stackRef)
+		target, targetErr := b.getTarget(ctx, stackRef, nil)
 		if targetErr != nil {
 			return nil, targetErr
 		}
@@ -1144,6 +1419,22 @@ func (b *cloudBackend) ExportDeployment(ctx context.Context,
 	return &deployment, nil
 }
 
+func (b *cloudBackend) ExportDeploymentVersion(ctx context.Context,
+	stackRef backend.StackReference, version int) (*apitype.UntypedDeployment, error) {
+
+	stack, err := b.getCloudStackIdentifier(stackRef)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment, err := b.client.ExportStackDeploymentVersion(ctx, stack, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return &deployment, nil
+}
+
 func (b *cloudBackend) ImportDeployment(ctx context.Context, stackRef backend.StackReference,
 	deployment *apitype.UntypedDeployment) error {
 
@@ -86,6 +86,10 @@ func init() {
 	addEndpoint("PATCH", "/api/orgs/{orgName}/clouds/{cloudName}", "patchCloud")
 	addEndpoint("POST", "/api/orgs/{orgName}/clouds/{cloudName}/default", "setDefaultCloud")
 	addEndpoint("GET", "/api/orgs/{orgName}/clouds/{cloudName}/status", "getCloudStatus")
+	addEndpoint("GET", "/api/orgs/{orgName}/members", "listOrganizationMembers")
+	addEndpoint("GET", "/api/orgs/{orgName}/teams", "listTeams")
+	addEndpoint("POST", "/api/orgs/{orgName}/teams", "createTeam")
+	addEndpoint("PUT", "/api/orgs/{orgName}/teams/{teamName}/stacks/{stackName}", "setTeamStackPermission")
 	addEndpoint("GET", "/api/orgs/{orgName}/programs", "listRepositories")
 	addEndpoint("GET", "/api/orgs/{orgName}/programs/{repoName}", "getRepository")
 	addEndpoint("GET", "/api/orgs/{orgName}/programs/{repoName}/{projName}", "getProject")
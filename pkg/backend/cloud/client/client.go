@@ -19,9 +19,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 	"time"
 
 	"github.com/pkg/errors"
@@ -85,6 +87,12 @@ func getStackPath(stack StackIdentifier, components ...string) string {
 	return path.Join(append([]string{fmt.Sprintf("/api/stacks/%s/%s", stack.Owner, stack.Stack)}, components...)...)
 }
 
+// getOrgPath returns the API path for the given organization with the given components joined with path separators
+// and appended to the organization root.
+func getOrgPath(org string, components ...string) string {
+	return path.Join(append([]string{fmt.Sprintf("/api/orgs/%s", org)}, components...)...)
+}
+
 // getUpdatePath returns the API path to for the given stack with the given components joined with path separators
 // and appended to the update root.
 func getUpdatePath(update UpdateIdentifier, components ...string) string {
@@ -95,36 +103,77 @@ func getUpdatePath(update UpdateIdentifier, components ...string) string {
 // GetPulumiAccountName returns the user implied by the API token associated with this client.
 func (pc *Client) GetPulumiAccountName(ctx context.Context) (string, error) {
 	if pc.apiUser == "" {
-		resp := struct {
-			GitHubLogin string `json:"githubLogin"`
-		}{}
-		if err := pc.restCall(ctx, "GET", "/api/user", nil, nil, &resp); err != nil {
+		user, err := pc.GetCurrentUser(ctx)
+		if err != nil {
 			return "", err
 		}
+		pc.apiUser = user.GitHubLogin
+	}
 
-		if resp.GitHubLogin == "" {
-			return "", errors.New("unexpected response from server")
-		}
+	return pc.apiUser, nil
+}
 
-		pc.apiUser = resp.GitHubLogin
+// GetCurrentUser returns the user implied by the API token associated with this client, along with their
+// organizations and, if the service reports it, the token's scopes and expiry.
+func (pc *Client) GetCurrentUser(ctx context.Context) (apitype.User, error) {
+	var resp apitype.User
+	if err := pc.restCall(ctx, "GET", "/api/user", nil, nil, &resp); err != nil {
+		return apitype.User{}, err
 	}
 
-	return pc.apiUser, nil
+	if resp.GitHubLogin == "" {
+		return apitype.User{}, errors.New("unexpected response from server")
+	}
+
+	return resp, nil
 }
 
 // DownloadPlugin downloads the indicated plugin from the Pulumi API.
 func (pc *Client) DownloadPlugin(ctx context.Context, info workspace.PluginInfo, os,
 	arch string) (io.ReadCloser, int64, error) {
 
-	endpoint := fmt.Sprintf("/releases/plugins/pulumi-%s-%s-v%s-%s-%s.tar.gz",
-		info.Kind, info.Name, info.Version, os, arch)
-	_, resp, err := pc.apiCall(ctx, "GET", endpoint, nil)
+	_, resp, err := pc.apiCall(ctx, "GET", getPluginPath(info, os, arch), nil)
 	if err != nil {
 		return nil, 0, err
 	}
 	return resp.Body, resp.ContentLength, nil
 }
 
+// GetPluginChecksum returns the published SHA256 checksum of the indicated plugin's tarball and the signature of its
+// digest.  Either is empty if it hasn't been published.
+func (pc *Client) GetPluginChecksum(ctx context.Context, info workspace.PluginInfo, os,
+	arch string) (string, []byte, error) {
+
+	endpoint := getPluginPath(info, os, arch)
+	checksum, err := pc.getReleaseFile(ctx, endpoint+".sha256")
+	if err != nil {
+		return "", nil, err
+	}
+	signature, err := pc.getReleaseFile(ctx, endpoint+".sig")
+	if err != nil {
+		return "", nil, err
+	}
+	return string(checksum), signature, nil
+}
+
+// getReleaseFile downloads a small file from the release endpoint, returning nil if there is no such file.
+func (pc *Client) getReleaseFile(ctx context.Context, endpoint string) ([]byte, error) {
+	_, resp, err := pc.apiCall(ctx, "GET", endpoint, nil)
+	if err != nil {
+		if errResp, ok := err.(*apitype.ErrorResponse); ok && errResp.Code == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer contract.IgnoreClose(resp.Body)
+	return ioutil.ReadAll(resp.Body)
+}
+
+// getPluginPath returns the path of the indicated plugin's tarball on the release endpoint.
+func getPluginPath(info workspace.PluginInfo, os, arch string) string {
+	return fmt.Sprintf("/releases/plugins/pulumi-%s-%s-v%s-%s-%s.tar.gz", info.Kind, info.Name, info.Version, os, arch)
+}
+
 // ListTemplates lists all templates of which the Pulumi API knows.
 func (pc *Client) ListTemplates(ctx context.Context) ([]workspace.Template, error) {
 	// Query all templates.
@@ -187,9 +236,16 @@ func (pc *Client) GetLatestConfiguration(ctx context.Context, stackID StackIdent
 		if err != nil {
 			return nil, err
 		}
-		if v.Secret {
+		switch {
+		case v.Object:
+			if cfg[newKey], err = config.NewObjectValue(v.String); err != nil {
+				return nil, err
+			}
+		case v.Reference:
+			cfg[newKey] = config.NewReferenceValue(v.String)
+		case v.Secret:
 			cfg[newKey] = config.NewSecureValue(v.String)
-		} else {
+		default:
 			cfg[newKey] = config.NewValue(v.String)
 		}
 	}
@@ -249,6 +305,58 @@ func (pc *Client) DeleteStack(ctx context.Context, stack StackIdentifier, force
 	return false, pc.restCall(ctx, "DELETE", path, nil, nil, nil)
 }
 
+// RenameStack renames the indicated stack.
+func (pc *Client) RenameStack(ctx context.Context, stack StackIdentifier, newName string) error {
+	req := apitype.StackRenameRequest{NewName: newName}
+	return pc.restCall(ctx, "POST", getStackPath(stack, "rename"), nil, &req, nil)
+}
+
+// UpdateStackTags updates the stacks's tags, replacing all existing tags.
+func (pc *Client) UpdateStackTags(
+	ctx context.Context, stack StackIdentifier, tags map[apitype.StackTagName]string) error {
+	// Validate stack tags.
+	if err := backend.ValidateStackProperties(stack.Stack, tags); err != nil {
+		return errors.Wrap(err, "validating stack properties")
+	}
+
+	return pc.restCall(ctx, "PATCH", getStackPath(stack, "tags"), nil, tags, nil)
+}
+
+// ListOrganizationMembers lists the members of the indicated organization.
+func (pc *Client) ListOrganizationMembers(ctx context.Context, org string) ([]apitype.OrganizationMember, error) {
+	var resp apitype.ListOrganizationMembersResponse
+	if err := pc.restCall(ctx, "GET", getOrgPath(org, "members"), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Members, nil
+}
+
+// ListTeams lists the teams of the indicated organization.
+func (pc *Client) ListTeams(ctx context.Context, org string) ([]apitype.Team, error) {
+	var resp apitype.ListTeamsResponse
+	if err := pc.restCall(ctx, "GET", getOrgPath(org, "teams"), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Teams, nil
+}
+
+// CreateTeam creates a new team in the indicated organization.
+func (pc *Client) CreateTeam(ctx context.Context, org string, req apitype.CreateTeamRequest) (apitype.Team, error) {
+	var team apitype.Team
+	if err := pc.restCall(ctx, "POST", getOrgPath(org, "teams"), nil, &req, &team); err != nil {
+		return apitype.Team{}, err
+	}
+	return team, nil
+}
+
+// SetTeamStackPermission sets the permission that a team has on one of its organization's stacks.
+func (pc *Client) SetTeamStackPermission(ctx context.Context, team string, stack StackIdentifier,
+	permission apitype.StackPermission) error {
+
+	req := apitype.SetTeamStackPermissionRequest{Permission: permission}
+	return pc.restCall(ctx, "PUT", getOrgPath(stack.Owner, "teams", team, "stacks", stack.Stack), nil, &req, nil)
+}
+
 // EncryptValue encrypts a plaintext value in the context of the indicated stack.
 func (pc *Client) EncryptValue(ctx context.Context, stack StackIdentifier, plaintext []byte) ([]byte, error) {
 	req := apitype.EncryptValueRequest{Plaintext: plaintext}
@@ -280,7 +388,12 @@ func (pc *Client) GetStackLogs(ctx context.Context, stack StackIdentifier,
 
 	logs := make([]operations.LogEntry, 0, len(response.Logs))
 	for _, entry := range response.Logs {
-		logs = append(logs, operations.LogEntry(entry))
+		logs = append(logs, operations.LogEntry{
+			ID:        entry.ID,
+			Timestamp: entry.Timestamp,
+			Message:   entry.Message,
+			URN:       entry.URN,
+		})
 	}
 
 	return logs, nil
@@ -308,6 +421,20 @@ func (pc *Client) ExportStackDeployment(ctx context.Context,
 	return apitype.UntypedDeployment(resp), nil
 }
 
+// ExportStackDeploymentVersion exports the indicated stack's deployment, as it was after the given update version, as a
+// raw JSON message.
+func (pc *Client) ExportStackDeploymentVersion(ctx context.Context,
+	stack StackIdentifier, version int) (apitype.UntypedDeployment, error) {
+
+	var resp apitype.ExportStackResponse
+	exportPath := getStackPath(stack, "export", strconv.Itoa(version))
+	if err := pc.restCall(ctx, "GET", exportPath, nil, nil, &resp); err != nil {
+		return apitype.UntypedDeployment{}, err
+	}
+
+	return apitype.UntypedDeployment(resp), nil
+}
+
 // ImportStackDeployment imports a new deployment into the indicated stack.
 func (pc *Client) ImportStackDeployment(ctx context.Context, stack StackIdentifier,
 	deployment *apitype.UntypedDeployment) (UpdateIdentifier, error) {
@@ -335,12 +462,24 @@ func (pc *Client) CreateUpdate(
 	// First create the update program request.
 	wireConfig := make(map[string]apitype.ConfigValue)
 	for k, cv := range cfg {
-		v, err := cv.Value(config.NopDecrypter)
-		contract.AssertNoError(err)
+		// An object is sent as it is stored, with the ciphertext of its secrets within it.
+		var v string
+		if cv.Object() {
+			b, err := cv.MarshalJSON()
+			contract.AssertNoError(err)
+			v = string(b)
+		} else {
+			var err error
+			v, err = cv.Value(config.NopDecrypter)
+			contract.AssertNoError(err)
+		}
 
+		_, ref := cv.Reference()
 		wireConfig[k.Namespace()+":config:"+k.Name()] = apitype.ConfigValue{
-			String: v,
-			Secret: cv.Secure(),
+			String:    v,
+			Secret:    cv.Secure(),
+			Object:    cv.Object(),
+			Reference: ref,
 		}
 	}
 
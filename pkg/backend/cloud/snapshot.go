@@ -19,6 +19,7 @@ import (
 
 	"github.com/pulumi/pulumi/pkg/backend"
 	"github.com/pulumi/pulumi/pkg/backend/cloud/client"
+	"github.com/pulumi/pulumi/pkg/resource/config"
 	"github.com/pulumi/pulumi/pkg/resource/deploy"
 	"github.com/pulumi/pulumi/pkg/resource/stack"
 )
@@ -46,6 +47,12 @@ func (persister *cloudSnapshotPersister) Save(snapshot *deploy.Snapshot) error {
 		return err
 	}
 	deployment := stack.SerializeDeployment(snapshot)
+	err = stack.EncryptSecretOutputs(stack.DeploymentResources(deployment), func() (config.Encrypter, error) {
+		return &cloudCrypter{backend: persister.backend, stack: persister.update.StackIdentifier}, nil
+	})
+	if err != nil {
+		return err
+	}
 	return persister.backend.client.PatchUpdateCheckpoint(persister.context, persister.update, deployment, token)
 }
 
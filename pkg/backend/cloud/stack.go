@@ -41,13 +41,14 @@ type Stack interface {
 
 // cloudStack is a cloud stack descriptor.
 type cloudStack struct {
-	name      backend.StackReference // the stack's name.
-	cloudURL  string                 // the URL to the cloud containing this stack.
-	orgName   string                 // the organization that owns this stack.
-	cloudName string                 // the PPC in which this stack is running.
-	config    config.Map             // the stack's config bag.
-	snapshot  **deploy.Snapshot      // a snapshot representing the latest deployment state (allocated on first use)
-	b         *cloudBackend          // a pointer to the backend this stack belongs to.
+	name      backend.StackReference          // the stack's name.
+	cloudURL  string                          // the URL to the cloud containing this stack.
+	orgName   string                          // the organization that owns this stack.
+	cloudName string                          // the PPC in which this stack is running.
+	config    config.Map                      // the stack's config bag.
+	snapshot  **deploy.Snapshot               // a snapshot representing the latest deployment state (allocated on first use)
+	tags      map[apitype.StackTagName]string // the stack's tags.
+	b         *cloudBackend                   // a pointer to the backend this stack belongs to.
 }
 
 type cloudBackendReference struct {
@@ -86,6 +87,7 @@ func newStack(apistack apitype.Stack, b *cloudBackend) Stack {
 		cloudName: apistack.CloudName,
 		config:    nil, // TODO[pulumi/pulumi-service#249]: add the config variables.
 		snapshot:  nil, // We explicitly allocate the snapshot on first use, since it is expensive to compute.
+		tags:      apistack.Tags,
 		b:         b,
 	}
 }
@@ -94,13 +96,14 @@ func newStack(apistack apitype.Stack, b *cloudBackend) Stack {
 // managed stacks. All engine operations for a managed stack--previews, updates, destroys, etc.--run locally.
 const managedCloudName = "pulumi"
 
-func (s *cloudStack) Name() backend.StackReference { return s.name }
-func (s *cloudStack) Config() config.Map           { return s.config }
-func (s *cloudStack) Backend() backend.Backend     { return s.b }
-func (s *cloudStack) CloudURL() string             { return s.cloudURL }
-func (s *cloudStack) OrgName() string              { return s.orgName }
-func (s *cloudStack) CloudName() string            { return s.cloudName }
-func (s *cloudStack) RunLocally() bool             { return s.cloudName == managedCloudName }
+func (s *cloudStack) Name() backend.StackReference          { return s.name }
+func (s *cloudStack) Config() config.Map                    { return s.config }
+func (s *cloudStack) Backend() backend.Backend              { return s.b }
+func (s *cloudStack) CloudURL() string                      { return s.cloudURL }
+func (s *cloudStack) OrgName() string                       { return s.orgName }
+func (s *cloudStack) CloudName() string                     { return s.cloudName }
+func (s *cloudStack) RunLocally() bool                      { return s.cloudName == managedCloudName }
+func (s *cloudStack) Tags() map[apitype.StackTagName]string { return s.tags }
 
 func (s *cloudStack) Snapshot(ctx context.Context) (*deploy.Snapshot, error) {
 	if s.snapshot != nil {
@@ -16,7 +16,15 @@ package cloud
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
 	"time"
 
 	"github.com/pkg/errors"
@@ -28,8 +36,11 @@ import (
 	"github.com/pulumi/pulumi/pkg/diag/colors"
 	"github.com/pulumi/pulumi/pkg/engine"
 	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/config"
 	"github.com/pulumi/pulumi/pkg/resource/deploy"
 	"github.com/pulumi/pulumi/pkg/resource/stack"
+	"github.com/pulumi/pulumi/pkg/secrets"
+	"github.com/pulumi/pulumi/pkg/tokens"
 	"github.com/pulumi/pulumi/pkg/workspace"
 )
 
@@ -101,6 +112,24 @@ func (ts *tokenSource) GetToken() (string, error) {
 	return resp.token, resp.err
 }
 
+// cloudUpdateLease is an update's lease on a stack, as granted by the service and renewed by a tokenSource.
+type cloudUpdateLease struct {
+	stack       tokens.QName
+	tokenSource *tokenSource
+}
+
+// Check returns an error if the lease could not be renewed. The service refuses renewal with a 409 once another
+// operation has taken over the stack, which is reported as a backend.ConcurrentUpdateError.
+func (l *cloudUpdateLease) Check() error {
+	if _, err := l.tokenSource.GetToken(); err != nil {
+		if errResp, ok := err.(*apitype.ErrorResponse); ok && errResp.Code == http.StatusConflict {
+			return &backend.ConcurrentUpdateError{Stack: l.stack, Conflict: errResp.Message}
+		}
+		return errors.Wrap(err, "renewing the update's lease")
+	}
+	return nil
+}
+
 // cloudUpdate is an implementation of engine.Update backed by remote state and a local program.
 type cloudUpdate struct {
 	context context.Context
@@ -205,7 +234,8 @@ func (u *cloudUpdate) RecordAndDisplayEvents(action string,
 }
 
 func (b *cloudBackend) newUpdate(ctx context.Context, stackRef backend.StackReference, proj *workspace.Project,
-	root string, update client.UpdateIdentifier, token string) (*cloudUpdate, error) {
+	root string, update client.UpdateIdentifier, token string,
+	configEnv []workspace.ConfigEnvVar) (*cloudUpdate, error) {
 
 	// Create a token source for this update if necessary.
 	var tokenSource *tokenSource
@@ -218,7 +248,7 @@ func (b *cloudBackend) newUpdate(ctx context.Context, stackRef backend.StackRefe
 	}
 
 	// Construct the deployment target.
-	target, err := b.getTarget(ctx, stackRef)
+	target, err := b.getTarget(ctx, stackRef, configEnv)
 	if err != nil {
 		return nil, err
 	}
@@ -249,9 +279,16 @@ func (b *cloudBackend) getSnapshot(ctx context.Context, stackRef backend.StackRe
 	return snapshot, nil
 }
 
-func (b *cloudBackend) getTarget(ctx context.Context, stackRef backend.StackReference) (*deploy.Target, error) {
+func (b *cloudBackend) getTarget(ctx context.Context, stackRef backend.StackReference,
+	configEnv []workspace.ConfigEnvVar) (*deploy.Target, error) {
 	// Pull the local stack info so we can get at its configuration bag.
-	stk, err := workspace.DetectProjectStack(stackRef.StackName())
+	cfg, _, err := workspace.DetectStackConfig(stackRef.StackName())
+	if err != nil {
+		return nil, err
+	}
+	err = backend.ApplyConfigEnv(cfg, configEnv, func() (config.Encrypter, error) {
+		return b.GetStackCrypter(stackRef)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -274,10 +311,66 @@ func (b *cloudBackend) getTarget(ctx context.Context, stackRef backend.StackRefe
 		}
 	}
 
+	getDecrypter := func() (config.Decrypter, error) { return decrypter, nil }
+	if err = stack.DecryptSecretOutputs(snapshot, getDecrypter); err != nil {
+		return nil, err
+	}
+
 	return &deploy.Target{
 		Name:      stackRef.StackName(),
-		Config:    stk.Config,
-		Decrypter: decrypter,
+		Config:    cfg,
+		Decrypter: secrets.NewReferenceResolver(decrypter),
 		Snapshot:  snapshot,
 	}, nil
 }
+
+// durationsPath returns the path of the file recording how long the operations of a stack's past updates took.  The
+// service has no place to keep these, so they are kept on this machine, under a directory for each service URL.
+func (b *cloudBackend) durationsPath(stack client.StackIdentifier) (string, error) {
+	u, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+	h := sha1.Sum([]byte(b.url))
+	return filepath.Join(u.HomeDir, workspace.BookkeepingDir, workspace.DurationsDir,
+		hex.EncodeToString(h[:]), stack.Owner, stack.Stack+".json"), nil
+}
+
+// getDurations loads the durations of the operations of a stack's past updates run from this machine.  A stack that
+// has never been updated from this machine has no recorded durations.
+func (b *cloudBackend) getDurations(stack client.StackIdentifier) (*engine.OperationDurations, error) {
+	path, err := b.durationsPath(stack)
+	if err != nil {
+		return nil, err
+	}
+
+	durations := engine.NewOperationDurations()
+	byts, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return durations, nil
+		}
+		return nil, err
+	}
+	if err = json.Unmarshal(byts, durations); err != nil {
+		return nil, errors.Wrapf(err, "reading durations file %s", path)
+	}
+	return durations, nil
+}
+
+// saveDurations saves the durations of the operations of a stack's updates.
+func (b *cloudBackend) saveDurations(stack client.StackIdentifier, durations *engine.OperationDurations) error {
+	path, err := b.durationsPath(stack)
+	if err != nil {
+		return err
+	}
+
+	byts, err := json.MarshalIndent(durations, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, byts, 0600)
+}
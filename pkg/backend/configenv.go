@@ -0,0 +1,80 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/resource/config"
+	"github.com/pulumi/pulumi/pkg/workspace"
+)
+
+// ApplyConfigEnv overrides the values of a stack's configuration with those of the environment variables that are
+// mapped to its keys, by the project's `configEnv` or by the given mappings, which take precedence.  A variable of the
+// project's that isn't set is skipped, so that the stack's own value is used, but each of the given mappings' must be
+// set.  Secrets are encrypted with the encrypter returned by getEncrypter, which is only called if there are any.
+func ApplyConfigEnv(cfg config.Map, mappings []workspace.ConfigEnvVar,
+	getEncrypter func() (config.Encrypter, error)) error {
+
+	proj, err := workspace.DetectProject()
+	if err != nil {
+		return err
+	}
+
+	var encrypter config.Encrypter
+	apply := func(v workspace.ConfigEnvVar, required bool) error {
+		value, ok := os.LookupEnv(v.Env)
+		if !ok {
+			if required {
+				return errors.Errorf("the environment variable %s, which gives the value of '%s', is not set", v.Env, v.Key)
+			}
+			return nil
+		}
+		key, err := v.ConfigKey(proj)
+		if err != nil {
+			return errors.Wrapf(err, "invalid configuration key '%s'", v.Key)
+		}
+		if !v.Secret {
+			cfg[key] = config.NewValue(value)
+			return nil
+		}
+
+		if encrypter == nil {
+			if encrypter, err = getEncrypter(); err != nil {
+				return err
+			}
+		}
+		ciphertext, err := config.EncrypterForKey(encrypter, key).EncryptValue(value)
+		if err != nil {
+			return errors.Wrapf(err, "encrypting the value of %s", v.Env)
+		}
+		cfg[key] = config.NewSecureValue(ciphertext)
+		return nil
+	}
+
+	for _, v := range proj.ConfigEnv {
+		if err = apply(v, false); err != nil {
+			return err
+		}
+	}
+	for _, v := range mappings {
+		if err = apply(v, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -14,7 +14,10 @@
 
 package backend
 
-import "github.com/pulumi/pulumi/pkg/diag/colors"
+import (
+	"github.com/pulumi/pulumi/pkg/diag/colors"
+	"github.com/pulumi/pulumi/pkg/engine"
+)
 
 // DisplayOptions controls how the output of events are rendered
 type DisplayOptions struct {
@@ -25,5 +28,21 @@ type DisplayOptions struct {
 	SummaryDiff          bool                // If the diff display should be summarized
 	IsInteractive        bool                // If we should display things interactively
 	DiffDisplay          bool                // true if we should display things as a rich diff
+	JSONDisplay          bool                // true if we should write events as newline-delimited JSON
+	Quiet                bool                // true to display only errors and the final summary
+	SummaryOnly          bool                // true to display one line per changed resource and the final summary
 	Debug                bool
+	Diff                 engine.DiffOptions // options that control how property diffs are rendered.
+	DiffFormat           DiffFormat         // the format in which to display the diff.
 }
+
+// DiffFormat selects the format in which a diff is displayed.
+type DiffFormat string
+
+const (
+	// DiffFormatDefault displays events as they occur, using either the progress or rich diff display.
+	DiffFormatDefault DiffFormat = ""
+	// DiffFormatPatch displays each resource's property changes as a unified-diff-like textual patch, without any
+	// colorization, suitable for storing and comparing between runs.
+	DiffFormatPatch DiffFormat = "patch"
+)
@@ -0,0 +1,197 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package inspect reads the state of stacks, so that programs such as dashboards and audit tools can examine it
+// without running the CLI.  It opens backends just as the CLI does, using the credentials stored by `pulumi login`,
+// loads snapshots of stacks' deployments, and compares them.
+//
+// Nothing in this package changes the state that it reads.  Stacks whose state is encrypted with a passphrase can
+// only be read if PULUMI_CONFIG_PASSPHRASE is set, since there is no terminal from which to read it.
+package inspect
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/backend"
+	"github.com/pulumi/pulumi/pkg/backend/cloud"
+	"github.com/pulumi/pulumi/pkg/backend/local"
+	"github.com/pulumi/pulumi/pkg/diag"
+	"github.com/pulumi/pulumi/pkg/diag/colors"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/resource/stack"
+	"github.com/pulumi/pulumi/pkg/workspace"
+)
+
+// Open opens the backend at the given URL, which may be that of the Pulumi Service or of a backend that keeps its
+// state itself, such as `s3://bucket/prefix`.  If the URL is empty, it opens the backend that the CLI is logged into.
+// The Pulumi Service must have been logged into with `pulumi login`, so that its credentials are stored.
+func Open(url string) (backend.Backend, error) {
+	if url == "" {
+		current, err := workspace.GetCurrentCloudURL()
+		if err != nil {
+			return nil, err
+		}
+		url = current
+	}
+
+	d := diag.DefaultSink(ioutil.Discard, os.Stderr, diag.FormatOptions{Color: colors.Never})
+	if local.IsLocalBackendURL(url) {
+		return local.New(d, url)
+	}
+
+	url = cloud.ValueOrDefaultURL(url)
+	token, err := workspace.GetAccessToken(url)
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		return nil, errors.Errorf("there are no stored credentials for %s; log into it once with `pulumi login %s`",
+			url, url)
+	}
+	return cloud.New(d, url)
+}
+
+// Snapshot loads the latest snapshot of the named stack in a backend.  A stack that has never been deployed has an
+// empty snapshot.
+func Snapshot(ctx context.Context, b backend.Backend, stackName string) (*deploy.Snapshot, error) {
+	s, err := getStack(ctx, b, stackName)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := s.Snapshot(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading the snapshot of stack '%s'", stackName)
+	}
+	if snap == nil {
+		snap = deploy.NewSnapshot(deploy.Manifest{}, nil)
+	}
+	return snap, nil
+}
+
+// SnapshotVersion loads the snapshot of the named stack in a backend as it was after the given update, where updates
+// are numbered from 1 (the oldest) as in the stack's history.
+func SnapshotVersion(ctx context.Context, b backend.Backend, stackName string, version int) (*deploy.Snapshot, error) {
+	s, err := getStack(ctx, b, stackName)
+	if err != nil {
+		return nil, err
+	}
+	deployment, err := b.ExportDeploymentVersion(ctx, s.Name(), version)
+	if err != nil {
+		return nil, errors.Wrapf(err, "exporting version %d of stack '%s'", version, stackName)
+	}
+	return stack.DeserializeDeployment(deployment)
+}
+
+// getStack returns the named stack in a backend, failing if it doesn't exist.
+func getStack(ctx context.Context, b backend.Backend, stackName string) (backend.Stack, error) {
+	ref, err := b.ParseStackReference(stackName)
+	if err != nil {
+		return nil, err
+	}
+	s, err := b.GetStack(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, errors.Errorf("stack '%s' does not exist in %s", stackName, b.Name())
+	}
+	return s, nil
+}
+
+// Resources returns the resources in a snapshot that match the given filter, or all of them if it is nil, in the
+// snapshot's order.  Resources pending deletion, left by replacements that haven't finished, are skipped.
+func Resources(snap *deploy.Snapshot, filter func(res *resource.State) bool) []*resource.State {
+	if snap == nil {
+		return nil
+	}
+
+	var result []*resource.State
+	for _, res := range snap.Resources {
+		if !res.Delete && (filter == nil || filter(res)) {
+			result = append(result, res)
+		}
+	}
+	return result
+}
+
+// OfType returns a filter for Resources that matches resources of the given type, such as `aws:s3/bucket:Bucket`.
+func OfType(t string) func(res *resource.State) bool {
+	return func(res *resource.State) bool {
+		return string(res.Type) == t
+	}
+}
+
+// Outputs returns the outputs of a snapshot's stack: those of its root stack resource.
+func Outputs(snap *deploy.Snapshot) resource.PropertyMap {
+	res, _ := stack.GetRootStackResource(snap)
+	if res == nil || res.Outputs == nil {
+		return resource.PropertyMap{}
+	}
+	return res.Outputs
+}
+
+// Change describes how a resource changed from one snapshot of a stack to another.
+type Change struct {
+	deploy.ResourceChange
+
+	// ChangedInputs are the keys of the inputs that were added, removed, or changed, if the resource was updated or
+	// replaced.
+	ChangedInputs []resource.PropertyKey
+	// ChangedOutputs are the keys of the outputs that were added, removed, or changed, if the resource was updated or
+	// replaced.
+	ChangedOutputs []resource.PropertyKey
+}
+
+// Diff returns the resources that changed from one snapshot of a stack to another, either of which may be nil: those
+// that were created, deleted, replaced, or updated.  Those of the second snapshot come first, in its order, followed
+// by those that were deleted.
+func Diff(old, new *deploy.Snapshot) []Change {
+	var changes []Change
+	for _, change := range deploy.CompareSnapshots(old, new) {
+		if change.Op == deploy.OpSame {
+			continue
+		}
+		c := Change{ResourceChange: change}
+		if change.Old != nil && change.New != nil {
+			c.ChangedInputs = changedKeys(change.Old.Inputs, change.New.Inputs)
+			c.ChangedOutputs = changedKeys(change.Old.Outputs, change.New.Outputs)
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// changedKeys returns the keys, in order, whose values differ between two property maps, including those in only
+// one of them.
+func changedKeys(old, new resource.PropertyMap) []resource.PropertyKey {
+	var keys []resource.PropertyKey
+	for k, o := range old {
+		if n, has := new[k]; !has || !o.DeepEquals(n) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range new {
+		if _, has := old[k]; !has {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -0,0 +1,82 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inspect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/tokens"
+)
+
+func newResource(t tokens.Type, name string, id resource.ID, inputs resource.PropertyMap) *resource.State {
+	return resource.NewState(t, resource.URN(name), true, false, id, inputs, inputs, "", false, nil)
+}
+
+func TestResourcesAndOutputs(t *testing.T) {
+	stackRes := resource.NewState(resource.RootStackType, resource.URN("stack"), false, false, "",
+		resource.PropertyMap{}, resource.NewPropertyMapFromMap(map[string]interface{}{"url": "http://example.com"}),
+		"", false, nil)
+	bucket := newResource("aws:s3/bucket:Bucket", "bucket", "b-1", resource.PropertyMap{})
+	pending := newResource("aws:s3/bucket:Bucket", "bucket", "b-0", resource.PropertyMap{})
+	pending.Delete = true
+	snap := deploy.NewSnapshot(deploy.Manifest{}, []*resource.State{stackRes, pending, bucket})
+
+	assert.Equal(t, []*resource.State{stackRes, bucket}, Resources(snap, nil))
+	assert.Equal(t, []*resource.State{bucket}, Resources(snap, OfType("aws:s3/bucket:Bucket")))
+	assert.Nil(t, Resources(nil, nil))
+
+	assert.Equal(t, resource.NewStringProperty("http://example.com"), Outputs(snap)["url"])
+	assert.Len(t, Outputs(deploy.NewSnapshot(deploy.Manifest{}, nil)), 0)
+}
+
+func TestDiff(t *testing.T) {
+	inputs := func(m map[string]interface{}) resource.PropertyMap { return resource.NewPropertyMapFromMap(m) }
+
+	same := newResource("test", "same", "s", inputs(map[string]interface{}{"a": "x"}))
+	oldUpdated := newResource("test", "updated", "u", inputs(map[string]interface{}{"a": "x", "b": "y"}))
+	newUpdated := newResource("test", "updated", "u", inputs(map[string]interface{}{"a": "z", "c": "w"}))
+	oldReplaced := newResource("test", "replaced", "r-1", inputs(map[string]interface{}{"a": "x"}))
+	newReplaced := newResource("test", "replaced", "r-2", inputs(map[string]interface{}{"a": "x"}))
+	created := newResource("test", "created", "c", inputs(nil))
+	deleted := newResource("test", "deleted", "d", inputs(nil))
+
+	old := deploy.NewSnapshot(deploy.Manifest{}, []*resource.State{same, oldUpdated, oldReplaced, deleted})
+	new := deploy.NewSnapshot(deploy.Manifest{}, []*resource.State{same, newUpdated, newReplaced, created})
+
+	changes := Diff(old, new)
+	assert.Len(t, changes, 4)
+
+	assert.Equal(t, deploy.OpUpdate, changes[0].Op)
+	assert.Equal(t, resource.URN("updated"), changes[0].URN)
+	assert.Equal(t, []resource.PropertyKey{"a", "b", "c"}, changes[0].ChangedInputs)
+	assert.Equal(t, []resource.PropertyKey{"a", "b", "c"}, changes[0].ChangedOutputs)
+
+	assert.Equal(t, deploy.OpReplace, changes[1].Op)
+	assert.Empty(t, changes[1].ChangedInputs)
+
+	assert.Equal(t, deploy.OpCreate, changes[2].Op)
+	assert.Nil(t, changes[2].Old)
+
+	assert.Equal(t, deploy.OpDelete, changes[3].Op)
+	assert.Nil(t, changes[3].New)
+
+	// Comparing with a missing snapshot creates or deletes everything.
+	assert.Len(t, Diff(nil, new), 4)
+	assert.Len(t, Diff(old, nil), 4)
+}
@@ -0,0 +1,47 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"fmt"
+
+	"github.com/pulumi/pulumi/pkg/tokens"
+)
+
+// UpdateLease is an update's claim on a stack: the right, held by one update at a time, to change the stack's
+// checkpoint.  Each backend hands one out when an update begins, however it keeps stacks from being updated
+// concurrently, and the update checks it before saving each change, so that an update that has lost its claim, as when
+// `pulumi cancel` has released it, or another update has taken it over, stops rather than overwriting the changes made
+// by whatever holds the stack now.
+type UpdateLease interface {
+	// Check returns a *ConcurrentUpdateError if the lease is no longer held.
+	Check() error
+}
+
+// ConcurrentUpdateError is returned when an update can't save its changes to a stack because the stack has been
+// claimed by something else since the update began.
+type ConcurrentUpdateError struct {
+	Stack tokens.QName // the stack being updated.
+	// Conflict describes what has claimed the stack, such as another update, if it is known.
+	Conflict string
+}
+
+func (e *ConcurrentUpdateError) Error() string {
+	msg := fmt.Sprintf("stack '%s' was claimed by another operation while this update was running", e.Stack)
+	if e.Conflict != "" {
+		msg += fmt.Sprintf(" (%s)", e.Conflict)
+	}
+	return msg + "; this update has stopped rather than overwrite its changes"
+}
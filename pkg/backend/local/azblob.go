@@ -0,0 +1,641 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/util/contract"
+)
+
+// azblobBackendURLPrefix is the URL scheme that selects a backend that keeps its state in an Azure Blob Storage
+// container.
+const azblobBackendURLPrefix = "azblob://"
+
+const (
+	// azblobSASTokenEnvVar is the environment variable holding the shared access signature with which to access a
+	// container.  If it's unset, the container is accessed with the storage account's key, if there is one, and with
+	// the identity managed by Azure for the machine otherwise.
+	azblobSASTokenEnvVar = "AZURE_STORAGE_SAS_TOKEN"
+	// azblobAccountKeyEnvVar is the environment variable holding the base64-encoded key of the storage account, with
+	// which requests are signed if there's no shared access signature.
+	azblobAccountKeyEnvVar = "AZURE_STORAGE_KEY"
+	// azblobAccountEnvVar is the environment variable holding the name of the storage account, if it isn't given in
+	// the backend's URL.
+	azblobAccountEnvVar = "AZURE_STORAGE_ACCOUNT"
+	// azblobAPIVersion is the version of the Blob Storage REST API that is used; it's the first to accept OAuth tokens.
+	azblobAPIVersion = "2017-11-09"
+	// azblobIdentityEndpoint is where the Azure Instance Metadata Service issues tokens for managed identities.
+	azblobIdentityEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+	// azblobResource is the resource for which managed identity tokens are requested.
+	azblobResource = "https://storage.azure.com/"
+)
+
+// azblobStorage is a storage in an Azure Blob Storage container.  Its files are block blobs whose names are their
+// paths, below an optional prefix, so that it has the same layout as a local backend's directory.  Stacks are locked
+// with leases on their lock blobs, which are renewed for as long as their updates run, so that the lock of an update
+// whose process dies expires on its own, and checkpoints are written only if their blobs' ETags are still the ones that
+// were read, so that two updates can't silently overwrite each other's checkpoints.
+type azblobStorage struct {
+	endpoint         string // the URL of the storage account's blob service.
+	account          string // the name of the storage account, if it's known.
+	container        string
+	prefix           string
+	sasToken         string // the shared access signature with which to access the container, if any.
+	accountKey       []byte // the key with which to sign requests, if any.
+	clientID         string // the client ID of the user-assigned managed identity to use, if any.
+	identityEndpoint string // where to request managed identity tokens.
+
+	tokenLock sync.Mutex
+	token     string    // the current managed identity token, if one has been issued.
+	expires   time.Time // when the current managed identity token expires.
+}
+
+// newAzblobStorage returns the storage selected by a URL of the form `azblob://container/prefix`.  The URL's query
+// may give the storage `account`, if the AZURE_STORAGE_ACCOUNT environment variable doesn't; an `endpoint` to use
+// instead of the account's public one; and the `clientId` of a user-assigned managed identity to use instead of the
+// machine's system-assigned one.  The container is accessed with the shared access signature in the
+// AZURE_STORAGE_SAS_TOKEN environment variable if it's set, with the account key in AZURE_STORAGE_KEY if that's set,
+// and with the managed identity otherwise.
+func newAzblobStorage(storageURL string) (*azblobStorage, error) {
+	u, err := url.Parse(storageURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", storageURL)
+	}
+	if u.Host == "" {
+		return nil, errors.Errorf("%s does not name a container; expected azblob://container/prefix", storageURL)
+	}
+
+	query := u.Query()
+	account := query.Get("account")
+	if account == "" {
+		account = os.Getenv(azblobAccountEnvVar)
+	}
+	endpoint := query.Get("endpoint")
+	if endpoint == "" {
+		if account == "" {
+			return nil, errors.Errorf("%s: the storage account must be given by its account parameter or by %s",
+				storageURL, azblobAccountEnvVar)
+		}
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", account)
+	}
+
+	var accountKey []byte
+	if key := os.Getenv(azblobAccountKeyEnvVar); key != "" {
+		if account == "" {
+			return nil, errors.Errorf("%s: the storage account must be given by its account parameter or by %s "+
+				"to use %s", storageURL, azblobAccountEnvVar, azblobAccountKeyEnvVar)
+		}
+		if accountKey, err = base64.StdEncoding.DecodeString(key); err != nil {
+			return nil, errors.Wrapf(err, "decoding %s", azblobAccountKeyEnvVar)
+		}
+	}
+
+	return &azblobStorage{
+		endpoint:         strings.TrimSuffix(endpoint, "/"),
+		account:          account,
+		container:        u.Host,
+		prefix:           strings.Trim(u.Path, "/"),
+		sasToken:         strings.TrimPrefix(os.Getenv(azblobSASTokenEnvVar), "?"),
+		accountKey:       accountKey,
+		clientID:         query.Get("clientId"),
+		identityEndpoint: azblobIdentityEndpoint,
+	}, nil
+}
+
+// azblobError is an error returned by the Blob Storage API.
+type azblobError struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *azblobError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("Azure Blob Storage returned %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("Azure Blob Storage returned %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// isAzblobStatus returns true if an error is an azblobError with the given status code.
+func isAzblobStatus(err error, statusCode int) bool {
+	azErr, ok := err.(*azblobError)
+	return ok && azErr.StatusCode == statusCode
+}
+
+// name returns the name of the blob at the given path.
+func (s *azblobStorage) name(p string) string {
+	return path.Join(s.prefix, filepath.ToSlash(p))
+}
+
+// authorize adds the container's credentials to a request.
+func (s *azblobStorage) authorize(req *http.Request) error {
+	if s.sasToken != "" {
+		if req.URL.RawQuery != "" {
+			req.URL.RawQuery += "&"
+		}
+		req.URL.RawQuery += s.sasToken
+		return nil
+	}
+	if s.accountKey != nil {
+		s.signSharedKey(req)
+		return nil
+	}
+
+	s.tokenLock.Lock()
+	defer s.tokenLock.Unlock()
+
+	// Use the current token until shortly before it expires.
+	if s.token == "" || time.Now().Add(5*time.Minute).After(s.expires) {
+		query := url.Values{"api-version": {"2018-02-01"}, "resource": {azblobResource}}
+		if s.clientID != "" {
+			query.Set("client_id", s.clientID)
+		}
+		tokenReq, err := http.NewRequest("GET", s.identityEndpoint+"?"+query.Encode(), nil)
+		if err != nil {
+			return err
+		}
+		tokenReq.Header.Set("Metadata", "true")
+		// The metadata service is only reachable on Azure; elsewhere, give up on it quickly.
+		client := &http.Client{Timeout: 10 * time.Second}
+		resp, err := client.Do(tokenReq)
+		if err != nil {
+			return errors.Wrapf(err, "requesting a managed identity token (set %s to use a shared access signature "+
+				"instead)", azblobSASTokenEnvVar)
+		}
+		defer contract.IgnoreClose(resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			return errors.Errorf("requesting a managed identity token: %s", resp.Status)
+		}
+
+		var token struct {
+			AccessToken string `json:"access_token"`
+			ExpiresOn   string `json:"expires_on"`
+		}
+		if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+			return errors.Wrap(err, "reading managed identity token")
+		}
+		expiresOn, err := strconv.ParseInt(token.ExpiresOn, 10, 64)
+		if err != nil {
+			return errors.Wrap(err, "reading managed identity token expiry")
+		}
+		s.token, s.expires = token.AccessToken, time.Unix(expiresOn, 0)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	return nil
+}
+
+// signSharedKey signs a request with the storage account's key, as described at
+// https://docs.microsoft.com/en-us/rest/api/storageservices/authorize-with-shared-key.  The request must already have
+// all of its headers, including its x-ms-date, since they're part of what's signed.
+func (s *azblobStorage) signSharedKey(req *http.Request) {
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+	var stringToSign bytes.Buffer
+	for _, field := range []string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // the Date header, which x-ms-date supersedes.
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+	} {
+		stringToSign.WriteString(field + "\n")
+	}
+
+	// The canonicalized headers are the x-ms- ones, by name, in lower case.
+	var msHeaders []string
+	for name := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-ms-") {
+			msHeaders = append(msHeaders, lower)
+		}
+	}
+	sort.Strings(msHeaders)
+	for _, name := range msHeaders {
+		stringToSign.WriteString(name + ":" + strings.TrimSpace(req.Header.Get(name)) + "\n")
+	}
+
+	// The canonicalized resource is the account and path, followed by the query's parameters, by name, in lower case.
+	stringToSign.WriteString("/" + s.account + req.URL.EscapedPath())
+	query := req.URL.Query()
+	params := make(map[string][]string)
+	var names []string
+	for name, values := range query {
+		lower := strings.ToLower(name)
+		if _, has := params[lower]; !has {
+			names = append(names, lower)
+		}
+		params[lower] = append(params[lower], values...)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		values := params[name]
+		sort.Strings(values)
+		stringToSign.WriteString("\n" + name + ":" + strings.Join(values, ","))
+	}
+
+	mac := hmac.New(sha256.New, s.accountKey)
+	_, err := mac.Write(stringToSign.Bytes())
+	contract.IgnoreError(err)
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	req.Header.Set("Authorization", "SharedKey "+s.account+":"+signature)
+}
+
+// do performs a request of the Blob Storage API on the container, or on the blob with the given name if name isn't
+// empty, and returns the response if its status is one of the expected ones, or an azblobError otherwise.  The
+// caller must close the response's body.
+func (s *azblobStorage) do(method string, name string, query url.Values, headers map[string]string,
+	body []byte, expected ...int) (*http.Response, error) {
+
+	u := s.endpoint + "/" + s.container
+	if name != "" {
+		u += "/" + (&url.URL{Path: name}).EscapedPath()
+	}
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, u, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-ms-version", azblobAPIVersion)
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if err = s.authorize(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, code := range expected {
+		if resp.StatusCode == code {
+			return resp, nil
+		}
+	}
+
+	defer contract.IgnoreClose(resp.Body)
+	azErr := &azblobError{StatusCode: resp.StatusCode}
+	if method != "HEAD" {
+		// The body describes the error, if it can be read.
+		if byts, readErr := ioutil.ReadAll(resp.Body); readErr == nil {
+			contract.IgnoreError(xml.Unmarshal(byts, azErr))
+		}
+	}
+	return nil, azErr
+}
+
+// notExist returns the error reported by the os package for a missing file, if err reports a missing blob, and err
+// otherwise.
+func (s *azblobStorage) notExist(op string, p string, err error) error {
+	if isAzblobStatus(err, http.StatusNotFound) {
+		return &os.PathError{Op: op, Path: s.Describe(p), Err: os.ErrNotExist}
+	}
+	return errors.Wrapf(err, "%s %s", op, s.Describe(p))
+}
+
+func (s *azblobStorage) ReadFile(p string) ([]byte, error) {
+	resp, err := s.do("GET", s.name(p), nil, nil, nil, http.StatusOK)
+	if err != nil {
+		return nil, s.notExist("read", p, err)
+	}
+	defer contract.IgnoreClose(resp.Body)
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (s *azblobStorage) ReadFileVersion(p string) ([]byte, string, error) {
+	resp, err := s.do("GET", s.name(p), nil, nil, nil, http.StatusOK)
+	if err != nil {
+		return nil, "", s.notExist("read", p, err)
+	}
+	defer contract.IgnoreClose(resp.Body)
+	data, err := ioutil.ReadAll(resp.Body)
+	return data, resp.Header.Get("ETag"), err
+}
+
+// putBlob writes the block blob with the given name, with the given extra headers, and returns its ETag.
+func (s *azblobStorage) putBlob(name string, data []byte, headers map[string]string) (string, error) {
+	all := map[string]string{"x-ms-blob-type": "BlockBlob"}
+	for k, v := range headers {
+		all[k] = v
+	}
+	resp, err := s.do("PUT", name, nil, all, data, http.StatusCreated)
+	if err != nil {
+		return "", err
+	}
+	return resp.Header.Get("ETag"), resp.Body.Close()
+}
+
+func (s *azblobStorage) WriteFile(p string, data []byte) error {
+	if _, err := s.putBlob(s.name(p), data, nil); err != nil {
+		return errors.Wrapf(err, "writing %s", s.Describe(p))
+	}
+	return nil
+}
+
+func (s *azblobStorage) WriteFileIfVersion(p string, data []byte, version string) (string, error) {
+	precondition := map[string]string{"If-None-Match": "*"}
+	if version != "" {
+		precondition = map[string]string{"If-Match": version}
+	}
+	etag, err := s.putBlob(s.name(p), data, precondition)
+	switch {
+	case isAzblobStatus(err, http.StatusConflict) || isAzblobStatus(err, http.StatusPreconditionFailed):
+		return "", &os.PathError{Op: "write", Path: s.Describe(p), Err: errFileChanged}
+	case err != nil:
+		return "", errors.Wrapf(err, "writing %s", s.Describe(p))
+	default:
+		return etag, nil
+	}
+}
+
+func (s *azblobStorage) CreateFile(p string, data []byte) error {
+	_, err := s.putBlob(s.name(p), data, map[string]string{"If-None-Match": "*"})
+	switch {
+	case isAzblobStatus(err, http.StatusConflict) || isAzblobStatus(err, http.StatusPreconditionFailed):
+		return &os.PathError{Op: "create", Path: s.Describe(p), Err: os.ErrExist}
+	case err != nil:
+		return errors.Wrapf(err, "creating %s", s.Describe(p))
+	default:
+		return nil
+	}
+}
+
+// properties returns the headers describing a blob, or nil if it doesn't exist.
+func (s *azblobStorage) properties(p string) (http.Header, error) {
+	resp, err := s.do("HEAD", s.name(p), nil, nil, nil, http.StatusOK)
+	switch {
+	case isAzblobStatus(err, http.StatusNotFound):
+		return nil, nil
+	case err != nil:
+		return nil, errors.Wrapf(err, "reading %s", s.Describe(p))
+	default:
+		return resp.Header, resp.Body.Close()
+	}
+}
+
+func (s *azblobStorage) Exists(p string) (bool, error) {
+	props, err := s.properties(p)
+	return props != nil, err
+}
+
+// deleteBlob deletes a blob, which may hold the given lease, if it exists.
+func (s *azblobStorage) deleteBlob(name string, leaseID string) error {
+	var headers map[string]string
+	if leaseID != "" {
+		headers = map[string]string{"x-ms-lease-id": leaseID}
+	}
+	resp, err := s.do("DELETE", name, nil, headers, nil, http.StatusAccepted)
+	switch {
+	case isAzblobStatus(err, http.StatusNotFound):
+		return nil
+	case err != nil:
+		return errors.Wrapf(err, "removing %s/%s/%s", s.endpoint, s.container, name)
+	default:
+		return resp.Body.Close()
+	}
+}
+
+func (s *azblobStorage) Remove(p string) error {
+	return s.deleteBlob(s.name(p), "")
+}
+
+func (s *azblobStorage) RemoveAll(p string) error {
+	blobs, err := s.list(s.name(p)+"/", false)
+	if err != nil {
+		return err
+	}
+	for _, blob := range blobs {
+		if err = s.deleteBlob(blob.Name, ""); err != nil {
+			return err
+		}
+	}
+	return s.Remove(p)
+}
+
+func (s *azblobStorage) Rename(from, to string) error {
+	fromName, toName := s.name(from), s.name(to)
+
+	// Blob Storage has no directories, so moving one moves each of the blobs below its prefix.  The blobs are small, so
+	// they're simply read and written again, which needs no more permissions than the rest of the storage's use.
+	blobs, err := s.list(fromName+"/", false)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, blob := range blobs {
+		names = append(names, blob.Name)
+	}
+	if exists, err := s.Exists(from); err != nil {
+		return err
+	} else if exists {
+		names = append(names, fromName)
+	}
+	if len(names) == 0 {
+		return &os.PathError{Op: "rename", Path: s.Describe(from), Err: os.ErrNotExist}
+	}
+
+	for _, name := range names {
+		resp, err := s.do("GET", name, nil, nil, nil, http.StatusOK)
+		if err != nil {
+			return errors.Wrapf(err, "reading %s/%s/%s", s.endpoint, s.container, name)
+		}
+		data, err := ioutil.ReadAll(resp.Body)
+		contract.IgnoreClose(resp.Body)
+		if err != nil {
+			return err
+		}
+		if _, err = s.putBlob(toName+strings.TrimPrefix(name, fromName), data, nil); err != nil {
+			return errors.Wrapf(err, "writing %s", s.Describe(to))
+		}
+		if err = s.deleteBlob(name, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// azblobListing is the result of listing the blobs in a container.
+type azblobListing struct {
+	Blobs      []azblobListedBlob `xml:"Blobs>Blob"`
+	NextMarker string             `xml:"NextMarker"`
+}
+
+// azblobListedBlob describes a blob in a listing.
+type azblobListedBlob struct {
+	Name         string `xml:"Name"`
+	LastModified string `xml:"Properties>Last-Modified"`
+}
+
+// list returns the blobs whose names start with the given prefix, either all of them or, if shallow is true, only
+// those not within a further directory.
+func (s *azblobStorage) list(prefix string, shallow bool) ([]storageFile, error) {
+	var blobs []storageFile
+	marker := ""
+	for {
+		query := url.Values{"restype": {"container"}, "comp": {"list"}, "prefix": {prefix}}
+		if shallow {
+			query.Set("delimiter", "/")
+		}
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		resp, err := s.do("GET", "", query, nil, nil, http.StatusOK)
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing %s/%s/%s", s.endpoint, s.container, prefix)
+		}
+		var listing azblobListing
+		err = xml.NewDecoder(resp.Body).Decode(&listing)
+		contract.IgnoreClose(resp.Body)
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing %s/%s/%s", s.endpoint, s.container, prefix)
+		}
+
+		for _, blob := range listing.Blobs {
+			modTime, err := time.Parse(http.TimeFormat, blob.LastModified)
+			if err != nil {
+				return nil, errors.Wrapf(err, "reading the modification time of %s", blob.Name)
+			}
+			blobs = append(blobs, storageFile{Name: blob.Name, ModTime: modTime})
+		}
+
+		if marker = listing.NextMarker; marker == "" {
+			return blobs, nil
+		}
+	}
+}
+
+func (s *azblobStorage) List(dir string) ([]storageFile, error) {
+	prefix := s.name(dir) + "/"
+	files, err := s.list(prefix, true)
+	if err != nil {
+		return nil, err
+	}
+	for i := range files {
+		files[i].Name = strings.TrimPrefix(files[i].Name, prefix)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+func (s *azblobStorage) Describe(p string) string {
+	return s.endpoint + "/" + s.container + "/" + s.name(p)
+}
+
+// lease performs an action on the lease of a blob.
+func (s *azblobStorage) lease(p string, headers map[string]string, expected int) (*http.Response, error) {
+	return s.do("PUT", s.name(p), url.Values{"comp": {"lease"}}, headers, nil, expected)
+}
+
+func (s *azblobStorage) AcquireLease(p string, data []byte, duration time.Duration) (string, error) {
+	acquire := map[string]string{
+		"x-ms-lease-action":   "acquire",
+		"x-ms-lease-duration": strconv.Itoa(int(duration / time.Second)),
+	}
+	resp, err := s.lease(p, acquire, http.StatusCreated)
+	if isAzblobStatus(err, http.StatusNotFound) {
+		// A lease can only be taken on a blob that exists.  If another writer creates it first, that's fine; whoever
+		// then takes the lease holds the lock.
+		if err = s.CreateFile(p, nil); err != nil && !os.IsExist(err) {
+			return "", err
+		}
+		resp, err = s.lease(p, acquire, http.StatusCreated)
+	}
+	if err != nil {
+		if isAzblobStatus(err, http.StatusConflict) {
+			return "", &os.PathError{Op: "lease", Path: s.Describe(p), Err: os.ErrExist}
+		}
+		return "", errors.Wrapf(err, "leasing %s", s.Describe(p))
+	}
+	leaseID := resp.Header.Get("x-ms-lease-id")
+	contract.IgnoreClose(resp.Body)
+
+	// Only the holder of the lease may write the blob.
+	if _, err = s.putBlob(s.name(p), data, map[string]string{"x-ms-lease-id": leaseID}); err != nil {
+		contract.IgnoreError(s.ReleaseLease(p, leaseID))
+		return "", errors.Wrapf(err, "writing %s", s.Describe(p))
+	}
+	return leaseID, nil
+}
+
+func (s *azblobStorage) RenewLease(p string, leaseID string) error {
+	resp, err := s.lease(p, map[string]string{"x-ms-lease-action": "renew", "x-ms-lease-id": leaseID}, http.StatusOK)
+	if err != nil {
+		return errors.Wrapf(err, "renewing the lease on %s", s.Describe(p))
+	}
+	return resp.Body.Close()
+}
+
+func (s *azblobStorage) ReleaseLease(p string, leaseID string) error {
+	if leaseID == "" {
+		resp, err := s.lease(p, map[string]string{"x-ms-lease-action": "break", "x-ms-lease-break-period": "0"},
+			http.StatusAccepted)
+		switch {
+		case isAzblobStatus(err, http.StatusNotFound):
+			return nil
+		case err != nil && !isAzblobStatus(err, http.StatusConflict): // a conflict means there's no lease to break.
+			return errors.Wrapf(err, "breaking the lease on %s", s.Describe(p))
+		case err == nil:
+			contract.IgnoreClose(resp.Body)
+		}
+	}
+	// Deleting the blob ends the lease on it.
+	return s.deleteBlob(s.name(p), leaseID)
+}
+
+func (s *azblobStorage) IsLeased(p string) (bool, error) {
+	props, err := s.properties(p)
+	if err != nil || props == nil {
+		return false, err
+	}
+	return props.Get("x-ms-lease-state") == "leased", nil
+}
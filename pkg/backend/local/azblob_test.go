@@ -0,0 +1,329 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// azblobListPageSize is the number of blobs that the fake Blob Storage service lists at a time.
+const azblobListPageSize = 2
+
+// fakeAzblob is a Blob Storage service that holds the blobs of a single container in memory.
+type fakeAzblob struct {
+	t         *testing.T
+	lock      sync.Mutex
+	container string
+	blobs     map[string][]byte
+	etags     map[string]string
+	version   int
+	lists     int                        // the number of listings requested.
+	authorize func(r *http.Request) bool // checks each request's credentials.
+}
+
+func newFakeAzblob(t *testing.T, container string) *fakeAzblob {
+	return &fakeAzblob{
+		t:         t,
+		container: container,
+		blobs:     make(map[string][]byte),
+		etags:     make(map[string]string),
+		authorize: func(r *http.Request) bool { return r.URL.Query().Get("sig") != "" },
+	}
+}
+
+func (f *fakeAzblob) fail(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	_, err := fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+	assert.NoError(f.t, err)
+}
+
+func (f *fakeAzblob) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if !f.authorize(r) {
+		f.fail(w, http.StatusForbidden, "AuthenticationFailed")
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/"+f.container)
+	if name == "" {
+		f.list(w, r)
+		return
+	}
+	name = strings.TrimPrefix(name, "/")
+
+	data, has := f.blobs[name]
+	switch r.Method {
+	case "GET", "HEAD":
+		if !has {
+			f.fail(w, http.StatusNotFound, "BlobNotFound")
+			return
+		}
+		w.Header().Set("ETag", f.etags[name])
+		_, err := w.Write(data)
+		assert.NoError(f.t, err)
+	case "PUT":
+		if match := r.Header.Get("If-Match"); match != "" && match != f.etags[name] {
+			f.fail(w, http.StatusPreconditionFailed, "ConditionNotMet")
+			return
+		}
+		if r.Header.Get("If-None-Match") == "*" && has {
+			f.fail(w, http.StatusConflict, "BlobAlreadyExists")
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(f.t, err)
+		f.version++
+		f.blobs[name], f.etags[name] = body, fmt.Sprintf(`"0x%d"`, f.version)
+		w.Header().Set("ETag", f.etags[name])
+		w.WriteHeader(http.StatusCreated)
+	case "DELETE":
+		if !has {
+			f.fail(w, http.StatusNotFound, "BlobNotFound")
+			return
+		}
+		delete(f.blobs, name)
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		f.fail(w, http.StatusMethodNotAllowed, "UnsupportedHttpVerb")
+	}
+}
+
+// list lists the blobs whose names start with a prefix, a page at a time, with a marker for the next page.
+func (f *fakeAzblob) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	f.lists++
+	var names []string
+	for name := range f.blobs {
+		if strings.HasPrefix(name, query.Get("prefix")) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	start := 0
+	if marker := query.Get("marker"); marker != "" {
+		var err error
+		start, err = strconv.Atoi(marker)
+		assert.NoError(f.t, err)
+	}
+	var listing azblobListing
+	for i := start; i < len(names) && i < start+azblobListPageSize; i++ {
+		listing.Blobs = append(listing.Blobs, azblobListedBlob{
+			Name:         names[i],
+			LastModified: time.Now().UTC().Format(http.TimeFormat),
+		})
+	}
+	if start+azblobListPageSize < len(names) {
+		listing.NextMarker = strconv.Itoa(start + azblobListPageSize)
+	}
+	byts, err := xml.Marshal(struct {
+		XMLName xml.Name `xml:"EnumerationResults"`
+		azblobListing
+	}{azblobListing: listing})
+	assert.NoError(f.t, err)
+	_, err = w.Write(byts)
+	assert.NoError(f.t, err)
+}
+
+// newAzblobTestStorage returns a storage whose container, below the prefix "state", is held by the given service,
+// and which is accessed with a shared access signature.
+func newAzblobTestStorage(f *fakeAzblob) (*azblobStorage, func()) {
+	server := httptest.NewServer(f)
+	return &azblobStorage{
+		endpoint:  server.URL,
+		container: f.container,
+		prefix:    "state",
+		sasToken:  "sv=2017-11-09&sig=test",
+	}, server.Close
+}
+
+// TestAzblobSharedKeySignature signs a request with an account key, and checks the signature against one made by hand
+// from the canonical form of the request.
+func TestAzblobSharedKeySignature(t *testing.T) {
+	key := []byte("not a real key")
+	s := &azblobStorage{account: "myaccount", accountKey: key}
+
+	req, err := http.NewRequest("PUT",
+		"https://myaccount.blob.core.windows.net/state/.pulumi/stacks/my%20stack.json?comp=lease&Timeout=30",
+		bytes.NewReader([]byte("hello")))
+	assert.NoError(t, err)
+	req.Header.Set("x-ms-version", azblobAPIVersion)
+	req.Header.Set("x-ms-date", "Mon, 13 Aug 2018 17:00:00 GMT")
+	req.Header.Set("X-Ms-Lease-Action", "acquire")
+	req.Header.Set("If-Match", `"0x1"`)
+	assert.NoError(t, s.authorize(req))
+
+	stringToSign := "PUT\n\n\n5\n\n\n\n\n\"0x1\"\n\n\n\n" +
+		"x-ms-date:Mon, 13 Aug 2018 17:00:00 GMT\n" +
+		"x-ms-lease-action:acquire\n" +
+		"x-ms-version:2017-11-09\n" +
+		"/myaccount/state/.pulumi/stacks/my%20stack.json\n" +
+		"comp:lease\n" +
+		"timeout:30"
+	mac := hmac.New(sha256.New, key)
+	_, err = mac.Write([]byte(stringToSign))
+	assert.NoError(t, err)
+	expected := "SharedKey myaccount:" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	assert.Equal(t, expected, req.Header.Get("Authorization"))
+}
+
+// TestAzblobSharedKeyURL reads the account key from the environment, which requires the account to be known.
+func TestAzblobSharedKeyURL(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte("not a real key"))
+	assert.NoError(t, os.Setenv(azblobAccountKeyEnvVar, key))
+	defer func() { assert.NoError(t, os.Unsetenv(azblobAccountKeyEnvVar)) }()
+
+	s, err := newAzblobStorage("azblob://container/prefix?account=myaccount")
+	assert.NoError(t, err)
+	assert.Equal(t, "myaccount", s.account)
+	assert.Equal(t, []byte("not a real key"), s.accountKey)
+
+	_, err = newAzblobStorage("azblob://container/prefix?endpoint=http://127.0.0.1:10000")
+	assert.Error(t, err)
+}
+
+func TestAzblobReadWrite(t *testing.T) {
+	f := newFakeAzblob(t, "container")
+	s, cleanup := newAzblobTestStorage(f)
+	defer cleanup()
+
+	assert.NoError(t, s.WriteFile(".pulumi/stacks/dev.json", []byte("v1")))
+	assert.Equal(t, []byte("v1"), f.blobs["state/.pulumi/stacks/dev.json"])
+	data, err := s.ReadFile(".pulumi/stacks/dev.json")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", string(data))
+
+	// A missing blob is a missing file.
+	_, err = s.ReadFile(".pulumi/stacks/missing.json")
+	assert.True(t, os.IsNotExist(err), "expected a missing file, got %v", err)
+	exists, err := s.Exists(".pulumi/stacks/missing.json")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.NoError(t, s.Remove(".pulumi/stacks/missing.json"))
+
+	// Other errors are reported as they are.
+	f.authorize = func(*http.Request) bool { return false }
+	_, err = s.ReadFile(".pulumi/stacks/dev.json")
+	assert.False(t, os.IsNotExist(err))
+	assert.Contains(t, err.Error(), "AuthenticationFailed")
+}
+
+// TestAzblobWriteIfVersion writes a blob only if its ETag is the one that was read, or only if it doesn't exist.
+func TestAzblobWriteIfVersion(t *testing.T) {
+	f := newFakeAzblob(t, "container")
+	s, cleanup := newAzblobTestStorage(f)
+	defer cleanup()
+
+	etag, err := s.WriteFileIfVersion("dev.json", []byte("v1"), "")
+	assert.NoError(t, err)
+	_, err = s.WriteFileIfVersion("dev.json", []byte("theirs"), "")
+	assert.True(t, isFileChanged(err), "expected a conflict, got %v", err)
+
+	_, version, err := s.ReadFileVersion("dev.json")
+	assert.NoError(t, err)
+	assert.Equal(t, etag, version)
+	assert.NoError(t, s.WriteFile("dev.json", []byte("theirs")))
+	_, err = s.WriteFileIfVersion("dev.json", []byte("ours"), version)
+	assert.True(t, isFileChanged(err), "expected a conflict, got %v", err)
+	assert.Equal(t, "theirs", string(f.blobs["state/dev.json"]))
+
+	_, version, err = s.ReadFileVersion("dev.json")
+	assert.NoError(t, err)
+	_, err = s.WriteFileIfVersion("dev.json", []byte("ours"), version)
+	assert.NoError(t, err)
+	assert.Equal(t, "ours", string(f.blobs["state/dev.json"]))
+}
+
+// TestAzblobListPages lists more blobs than fit in one page of a listing.
+func TestAzblobListPages(t *testing.T) {
+	f := newFakeAzblob(t, "container")
+	s, cleanup := newAzblobTestStorage(f)
+	defer cleanup()
+
+	var expected []string
+	for i := 0; i < 2*azblobListPageSize+1; i++ {
+		name := fmt.Sprintf("dev-%d.json", i)
+		assert.NoError(t, s.WriteFile(".pulumi/history/dev/"+name, nil))
+		expected = append(expected, name)
+	}
+	assert.NoError(t, s.WriteFile(".pulumi/stacks/dev.json", nil))
+
+	files, err := s.List(".pulumi/history/dev")
+	assert.NoError(t, err)
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name)
+	}
+	assert.Equal(t, expected, names)
+	assert.Equal(t, 3, f.lists)
+}
+
+// TestAzblobIdentityToken accesses a container with a managed identity's token, which is requested from the metadata
+// service once and used until shortly before it expires.
+func TestAzblobIdentityToken(t *testing.T) {
+	var tokens int
+	var expires time.Time
+	identity := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "true", r.Header.Get("Metadata"))
+		assert.Equal(t, azblobResource, r.URL.Query().Get("resource"))
+		assert.Equal(t, "my-identity", r.URL.Query().Get("client_id"))
+		tokens++
+		_, err := fmt.Fprintf(w, `{"access_token": "token-%d", "expires_on": "%d"}`, tokens, expires.Unix())
+		assert.NoError(t, err)
+	}))
+	defer identity.Close()
+
+	f := newFakeAzblob(t, "container")
+	var bearer string
+	f.authorize = func(r *http.Request) bool {
+		bearer = r.Header.Get("Authorization")
+		return strings.HasPrefix(bearer, "Bearer token-")
+	}
+	s, cleanup := newAzblobTestStorage(f)
+	defer cleanup()
+	s.sasToken, s.clientID, s.identityEndpoint = "", "my-identity", identity.URL
+
+	expires = time.Now().Add(time.Hour)
+	assert.NoError(t, s.WriteFile("dev.json", nil))
+	_, err := s.ReadFile("dev.json")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, tokens)
+	assert.Equal(t, "Bearer token-1", bearer)
+
+	// A token that's about to expire is replaced.
+	s.expires = time.Now().Add(time.Minute)
+	_, err = s.ReadFile("dev.json")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, tokens)
+	assert.Equal(t, "Bearer token-2", bearer)
+}
@@ -18,11 +18,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"net/url"
 	"os"
 	"os/user"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -49,12 +50,50 @@ const localBackendURLPrefix = "local://"
 type Backend interface {
 	backend.Backend
 	local() // at the moment, no local specific info, so just use a marker function.
+
+	// ReadOnly returns true if the backend is in read-only mode, refusing to change the state of its stacks.
+	ReadOnly() bool
+
+	// ChangeSecretsProvider re-encrypts the secrets of a stack, in its configuration and throughout its state and
+	// history, with a new key from the given secrets provider, `passphrase` or the URL of a key in a key management
+	// service, and returns the number of state files rewritten.
+	ChangeSecretsProvider(ctx context.Context, stackRef backend.StackReference, provider string) (int, error)
+
+	// AddSecretsKey gives a stack a further key, kept by a key management service, that encrypts the values of the
+	// configuration keys it's for and, if it's asked to, the stack's state, instead of the stack's own key.  The values
+	// already set for those keys are re-encrypted with it, and their number is returned.
+	AddSecretsKey(ctx context.Context, stackRef backend.StackReference, name string,
+		key workspace.SecretsKey) (int, error)
+
+	// CollectBackups removes the backups that the given policy doesn't keep, and returns their locations.  If dryRun
+	// is set, it only returns the locations of the backups that it would remove.
+	CollectBackups(ctx context.Context, policy BackupRetention, dryRun bool) ([]string, error)
+
+	// GetUpdateEvents returns the events that the engine emitted during the given update to a stack, where updates are
+	// numbered from 1 (the oldest) as in the stack's history.
+	GetUpdateEvents(ctx context.Context, stackRef backend.StackReference, version int) ([]apitype.EngineEvent, error)
+
+	// ImportHistory records updates made to a stack elsewhere, such as in another backend, as the history of a stack
+	// that has none, along with the deployment that each update left.  As from GetHistory, the newest comes first.
+	ImportHistory(ctx context.Context, stackRef backend.StackReference, updates []backend.UpdateInfo,
+		deployments []*apitype.UntypedDeployment) error
 }
 
 type localBackend struct {
-	d         diag.Sink
-	url       string
-	stateRoot string
+	d       diag.Sink
+	url     string
+	storage storage
+
+	leasesLock sync.Mutex
+	leases     map[tokens.QName]*stackLease // the leases held on the locks of stacks, if the storage supports them.
+
+	cryptersLock sync.Mutex
+	crypters     map[tokens.QName]config.Crypter // the crypters of stacks, once their passphrases have been read.
+
+	versionsLock sync.Mutex
+	versions     map[tokens.QName]string // the versions of stacks' checkpoints, if the storage supports them.
+
+	readOnly bool // true if the backend refuses to change the state of its stacks.
 }
 
 type localBackendReference struct {
@@ -79,19 +118,88 @@ func stateRootFromLocalURL(localURL string) string {
 	return localURL[len(localBackendURLPrefix):]
 }
 
+// IsLocalBackendURL returns true if the URL selects a backend that keeps its state itself, either in a directory on
+// the local disk or in remote storage, rather than in the Pulumi Service.
 func IsLocalBackendURL(url string) bool {
-	return strings.HasPrefix(url, localBackendURLPrefix)
+	return strings.HasPrefix(url, localBackendURLPrefix) || IsRemoteStorageBackendURL(url)
 }
 
-func New(d diag.Sink, localURL string) Backend {
-	return &localBackend{d: d, url: localURL, stateRoot: stateRootFromLocalURL(localURL)}
+// IsRemoteStorageBackendURL returns true if the URL selects a backend that keeps its state in remote storage: an
+// object store, such as `s3://bucket/prefix` or `azblob://container/prefix`, or a database, such as
+// `postgres://host/database`.
+func IsRemoteStorageBackendURL(url string) bool {
+	for _, prefix := range []string{
+		s3BackendURLPrefix, azblobBackendURLPrefix, postgresBackendURLPrefix, postgresqlBackendURLPrefix,
+	} {
+		if strings.HasPrefix(url, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func New(d diag.Sink, localURL string) (Backend, error) {
+	var s storage
+	switch {
+	case strings.HasPrefix(localURL, s3BackendURLPrefix):
+		store, err := newS3Storage(localURL)
+		if err != nil {
+			return nil, err
+		}
+		s = store
+	case strings.HasPrefix(localURL, azblobBackendURLPrefix):
+		store, err := newAzblobStorage(localURL)
+		if err != nil {
+			return nil, err
+		}
+		s = store
+	case strings.HasPrefix(localURL, postgresBackendURLPrefix) || strings.HasPrefix(localURL, postgresqlBackendURLPrefix):
+		store, err := newPostgresStorage(localURL)
+		if err != nil {
+			return nil, err
+		}
+		s = store
+	default:
+		s = &fsStorage{root: stateRootFromLocalURL(localURL)}
+	}
+	readOnly, err := isReadOnly(localURL)
+	if err != nil {
+		return nil, err
+	}
+	return &localBackend{d: d, url: localURL, storage: s, readOnly: readOnly}, nil
 }
 
-func Login(d diag.Sink, localURL string) (Backend, error) {
-	return New(d, localURL), workspace.StoreAccessToken(localURL, "", true)
+// Login logs into the backend at the given URL, making it the current backend.  If readOnly is set, the backend is in
+// read-only mode whenever it's used until it's logged into again without it.
+func Login(d diag.Sink, localURL string, readOnly bool) (Backend, error) {
+	b, err := New(d, localURL)
+	if err != nil {
+		return nil, err
+	}
+	if IsRemoteStorageBackendURL(localURL) {
+		// Check that the storage can be reached with the credentials at hand before remembering it.
+		if _, err = b.ListStacks(context.Background(), nil); err != nil {
+			return nil, errors.Wrapf(err, "could not reach %s", localURL)
+		}
+	}
+	if err = workspace.StoreAccessToken(localURL, "", true); err != nil {
+		return nil, err
+	}
+	if err = workspace.StoreReadOnlyAccess(localURL, readOnly); err != nil {
+		return nil, err
+	}
+	return New(d, localURL)
 }
 
 func (b *localBackend) Name() string {
+	if IsRemoteStorageBackendURL(b.url) {
+		// Don't show any password given in the URL.
+		if u, err := url.Parse(b.url); err == nil && u.User != nil {
+			u.User = url.User(u.User.Username())
+			return u.String()
+		}
+		return b.url
+	}
 	name, err := os.Hostname()
 	contract.IgnoreError(err)
 	if name == "" {
@@ -106,12 +214,28 @@ func (b *localBackend) ParseStackReference(stackRefName string) (backend.StackRe
 
 func (b *localBackend) local() {}
 
+// CreateStackOptions is an optional bag of options specific to creating local stacks.
+type CreateStackOptions struct {
+	// SecretsProvider is the secrets provider that encrypts the stack's secrets: `passphrase`, the default, or the URL
+	// of a key in a key management service, such as `awskms://alias/pulumi`.
+	SecretsProvider string
+}
+
 func (b *localBackend) CreateStack(ctx context.Context, stackRef backend.StackReference,
 	opts interface{}) (backend.Stack, error) {
 
-	contract.Requiref(opts == nil, "opts", "local stacks do not support any options")
+	if opts == nil {
+		opts = CreateStackOptions{}
+	}
+	localOpts, ok := opts.(CreateStackOptions)
+	if !ok {
+		return nil, errors.New("expected a local.CreateStackOptions value for opts parameter")
+	}
 
 	stackName := stackRef.StackName()
+	if err := b.checkWritable("create stack '" + string(stackName) + "'"); err != nil {
+		return nil, err
+	}
 	if stackName == "" {
 		return nil, errors.New("invalid empty stack name")
 	}
@@ -128,12 +252,19 @@ func (b *localBackend) CreateStack(ctx context.Context, stackRef backend.StackRe
 		return nil, errors.Wrap(err, "validating stack properties")
 	}
 
+	if err = b.initSecretsProvider(stackName, localOpts.SecretsProvider); err != nil {
+		return nil, err
+	}
+
 	file, err := b.saveStack(stackName, nil, nil)
 	if err != nil {
 		return nil, err
 	}
+	if err = b.saveStackTags(stackName, tags); err != nil {
+		return nil, errors.Wrap(err, "saving stack tags")
+	}
 
-	stack := newStack(stackRef, file, nil, nil, b)
+	stack := newStack(stackRef, file, nil, nil, tags, b)
 	fmt.Printf("Created stack '%s'.\n", stack.Name())
 
 	return stack, nil
@@ -147,9 +278,13 @@ func (b *localBackend) GetStack(ctx context.Context, stackRef backend.StackRefer
 		return nil, nil
 	case err != nil:
 		return nil, err
-	default:
-		return newStack(stackRef, path, config, snapshot, b), nil
 	}
+
+	tags, err := b.getStackTags(stackName)
+	if err != nil {
+		return nil, err
+	}
+	return newStack(stackRef, path, config, snapshot, tags, b), nil
 }
 
 func (b *localBackend) ListStacks(ctx context.Context, projectFilter *tokens.PackageName) ([]backend.Stack, error) {
@@ -160,9 +295,20 @@ func (b *localBackend) ListStacks(ctx context.Context, projectFilter *tokens.Pac
 
 	var results []backend.Stack
 	for _, stackName := range stacks {
-		stack, err := b.GetStack(ctx, localBackendReference{name: stackName})
+		ref := localBackendReference{name: stackName}
+		stack, err := b.GetStack(ctx, ref)
 		if err != nil {
-			return nil, err
+			// A stack whose state is encrypted with a passphrase that we don't have is still listed, just without its
+			// configuration or resources.
+			if _, ok := errors.Cause(err).(*encryptedStateError); !ok {
+				return nil, err
+			}
+			logging.V(7).Infof("Listing stack %s without its state: %v", stackName, err)
+			tags, tagsErr := b.getStackTags(stackName)
+			if tagsErr != nil {
+				return nil, tagsErr
+			}
+			stack = newStack(ref, b.storage.Describe(b.stackPath(stackName)), nil, nil, tags, b)
 		}
 		results = append(results, stack)
 	}
@@ -172,6 +318,9 @@ func (b *localBackend) ListStacks(ctx context.Context, projectFilter *tokens.Pac
 
 func (b *localBackend) RemoveStack(ctx context.Context, stackRef backend.StackReference, force bool) (bool, error) {
 	stackName := stackRef.StackName()
+	if err := b.checkWritable("remove stack '" + string(stackName) + "'"); err != nil {
+		return false, err
+	}
 	_, snapshot, _, err := b.getStack(stackName)
 	if err != nil {
 		return false, err
@@ -185,8 +334,75 @@ func (b *localBackend) RemoveStack(ctx context.Context, stackRef backend.StackRe
 	return false, b.removeStack(stackName)
 }
 
+func (b *localBackend) UpdateStackTags(ctx context.Context, stackRef backend.StackReference,
+	tags map[apitype.StackTagName]string) error {
+
+	stackName := stackRef.StackName()
+	if err := b.checkWritable("change the tags of stack '" + string(stackName) + "'"); err != nil {
+		return err
+	}
+	if err := backend.ValidateStackProperties(string(stackName), tags); err != nil {
+		return errors.Wrap(err, "validating stack properties")
+	}
+	if exists, err := b.storage.Exists(b.stackPath(stackName)); err != nil {
+		return err
+	} else if !exists {
+		return errors.Errorf("stack '%s' does not exist", stackName)
+	}
+	return b.saveStackTags(stackName, tags)
+}
+
+func (b *localBackend) RenameStack(ctx context.Context, stackRef backend.StackReference, newName tokens.QName,
+	newProject tokens.PackageName) error {
+
+	stackName := stackRef.StackName()
+	if err := b.checkWritable("rename stack '" + string(stackName) + "'"); err != nil {
+		return err
+	}
+	if lock, err := b.getStackLock(stackName); err != nil {
+		return err
+	} else if lock != nil {
+		return errors.Errorf("stack '%s' is being updated; wait for the update to finish, or run `pulumi cancel`",
+			stackName)
+	}
+	if newName != stackName {
+		if exists, err := b.storage.Exists(b.stackPath(newName)); err != nil {
+			return err
+		} else if exists {
+			return errors.Errorf("stack '%s' already exists", newName)
+		}
+	}
+
+	config, snap, _, err := b.getStack(stackName)
+	if err != nil {
+		return err
+	}
+	if err = deploy.RenameStack(snap, newName, newProject); err != nil {
+		return err
+	}
+	if _, err = b.saveStack(newName, config, snap); err != nil {
+		return err
+	}
+	if newName == stackName {
+		return nil
+	}
+
+	// Move the stack's history, backups, and tags along with it, and then remove what remains of the old stack.
+	moves := map[string]string{
+		b.historyDirectory(stackName): b.historyDirectory(newName),
+		b.backupDirectory(stackName):  b.backupDirectory(newName),
+		b.tagsPath(stackName):         b.tagsPath(newName),
+	}
+	for from, to := range moves {
+		if err = b.storage.Rename(from, to); err != nil && !os.IsNotExist(errors.Cause(err)) {
+			return errors.Wrapf(err, "moving %s", from)
+		}
+	}
+	return b.removeStack(stackName)
+}
+
 func (b *localBackend) GetStackCrypter(stackRef backend.StackReference) (config.Crypter, error) {
-	return symmetricCrypter(stackRef.StackName())
+	return b.stackCrypter(stackRef.StackName())
 }
 
 func (b *localBackend) GetLatestConfiguration(ctx context.Context,
@@ -215,8 +431,7 @@ func (b *localBackend) Update(
 	opts backend.UpdateOptions, scopes backend.CancellationScopeSource) (engine.ResourceChanges, error) {
 
 	// The Pulumi Service will pick up changes to a stack's tags on each update. (e.g. changing the description
-	// in Pulumi.yaml.) While this isn't necessary for local updates, we do the validation here to keep
-	// parity with stacks managed by the Pulumi Service.
+	// in Pulumi.yaml.)  We do the same, leaving alone any other tags that the stack has.
 	tags, err := backend.GetStackTags()
 	if err != nil {
 		return nil, errors.Wrap(err, "getting stack tags")
@@ -225,6 +440,9 @@ func (b *localBackend) Update(
 	if err = backend.ValidateStackProperties(string(stackName), tags); err != nil {
 		return nil, errors.Wrap(err, "validating stack properties")
 	}
+	if _, err = b.mergeStackTags(stackName, tags); err != nil {
+		return nil, errors.Wrap(err, "saving stack tags")
+	}
 	return b.performEngineOp("updating", backend.DeployUpdate,
 		stackName, proj, root, m, opts, scopes, engine.Update)
 }
@@ -249,25 +467,97 @@ func (b *localBackend) performEngineOp(op string, kind backend.UpdateKind,
 	stackName tokens.QName, proj *workspace.Project, root string, m backend.UpdateMetadata, opts backend.UpdateOptions,
 	scopes backend.CancellationScopeSource, performEngineOp engineOpFunc) (engine.ResourceChanges, error) {
 
-	update, err := b.newUpdate(stackName, proj, root)
+	dryRun := (kind == backend.PreviewUpdate)
+	if !dryRun {
+		if err := b.checkWritable(string(kind) + " stack '" + string(stackName) + "'"); err != nil {
+			return nil, err
+		}
+	}
+
+	update, err := b.newUpdate(stackName, proj, root, opts.ConfigEnv)
 	if err != nil {
 		return nil, err
 	}
 
+	// Only previews and updates run the program, so only they need its configuration to match the project's schema.
+	if kind == backend.PreviewUpdate || kind == backend.DeployUpdate {
+		if err = proj.ValidateConfig(update.target.Config, update.target.Decrypter); err != nil {
+			return nil, err
+		}
+	}
+
 	events := make(chan engine.Event)
-	dryRun := (kind == backend.PreviewUpdate)
+
+	// Lock the stack for the duration of the update, so that concurrent updates don't clobber each other's
+	// checkpoints.  Previews don't change the stack, so they needn't lock it.
+	var lease *localUpdateLease
+	if !dryRun {
+		info := backend.UpdateInfo{
+			Kind:        kind,
+			StartTime:   time.Now().Unix(),
+			Message:     m.Message,
+			Environment: m.Environment,
+			Config:      update.GetTarget().Config,
+			Result:      backend.InProgressResult,
+		}
+		var token string
+		if opts.QueueTimeout > 0 {
+			token, err = b.lockStackQueued(stackName, info, opts.QueueTimeout)
+		} else {
+			token, err = b.lockStack(stackName, info)
+		}
+		if err != nil {
+			return nil, err
+		}
+		lease = &localUpdateLease{backend: b, name: stackName, token: token}
+		defer func() {
+			// Leave the lock alone if it's no longer this update's, so as not to release another's.
+			if leaseErr := lease.Check(); leaseErr != nil {
+				logging.V(7).Infof("Not unlocking stack '%s': %v", stackName, leaseErr)
+				return
+			}
+			if unlockErr := b.unlockStack(stackName); unlockErr != nil {
+				logging.V(7).Infof("Failed to unlock stack '%s': %v", stackName, unlockErr)
+			}
+		}()
+	}
 
 	cancelScope := scopes.NewScope(events, dryRun)
 	defer cancelScope.Close()
 
+	// Record the events of updates that change the stack in their event logs, as they're displayed.
+	displayEvents := events
+	var eventLog *updateEventLog
+	if !dryRun {
+		displayEvents = make(chan engine.Event)
+		eventLog = &updateEventLog{}
+		go recordEvents(events, displayEvents, eventLog, opts.DisplayOptions())
+	}
+
 	done := make(chan bool)
-	go DisplayEvents(op, events, done, opts.Display)
+	go DisplayEvents(op, displayEvents, done, opts.DisplayOptions())
 
 	// Create the management machinery.
 	persister := b.newSnapshotPersister(stackName)
-	manager := backend.NewSnapshotManager(persister, update.GetTarget().Snapshot)
+	var manager *backend.SnapshotManager
+	if lease != nil {
+		mirrored := opts.Mirrors.Persister(context.Background(), localBackendReference{name: stackName}, persister)
+		manager = backend.NewLeasedSnapshotManager(mirrored, lease, update.GetTarget().Snapshot)
+	} else {
+		manager = backend.NewSnapshotManager(persister, update.GetTarget().Snapshot)
+	}
 	engineCtx := &engine.Context{Cancel: cancelScope.Context(), Events: events, SnapshotManager: manager}
 
+	// Estimate the duration of each step from the durations of past updates' operations.  These are only estimates,
+	// so if they can't be loaded, the update proceeds without them.
+	if opts.Engine.Durations == nil {
+		durations, err := b.getDurations(stackName)
+		if err != nil {
+			logging.V(7).Infof("Failed to load operation durations for stack '%s': %v", stackName, err)
+		}
+		opts.Engine.Durations = durations
+	}
+
 	// Perform the update
 	start := time.Now().Unix()
 	changes, updateErr := performEngineOp(update, engineCtx, opts.Engine, dryRun)
@@ -276,6 +566,13 @@ func (b *localBackend) performEngineOp(op string, kind backend.UpdateKind,
 	<-done
 	close(events)
 	close(done)
+	if !dryRun && updateErr == nil {
+		// Prune the entries of the stack's snapshot that have outlived their use.  The snapshot is fine as it is, so
+		// if this fails, the update hasn't.
+		if _, err := manager.Compact(); err != nil {
+			logging.V(7).Infof("Failed to compact the snapshot of stack '%s': %v", stackName, err)
+		}
+	}
 	contract.IgnoreClose(manager)
 
 	// Save update results.
@@ -299,8 +596,19 @@ func (b *localBackend) performEngineOp(op string, kind backend.UpdateKind,
 	var saveErr error
 	var backupErr error
 	if !dryRun {
-		saveErr = b.addToHistory(stackName, info)
+		// Write the update's last snapshot as the stack's checkpoint, so that the copy kept in its history is whole,
+		// unless the update has lost its claim on the stack.
+		if saveErr = lease.Check(); saveErr == nil {
+			if saveErr = persister.compactJournal(); saveErr == nil {
+				saveErr = b.addToHistory(stackName, info, eventLog.Events())
+			}
+		}
 		backupErr = b.backupStack(stackName)
+		if durations := opts.Engine.Durations; durations != nil {
+			if err := b.saveDurations(stackName, durations); err != nil {
+				logging.V(7).Infof("Failed to save operation durations for stack '%s': %v", stackName, err)
+			}
+		}
 	}
 
 	if updateErr != nil {
@@ -323,11 +631,42 @@ func (b *localBackend) GetHistory(ctx context.Context, stackRef backend.StackRef
 	return updates, nil
 }
 
+func (b *localBackend) GetUpdateEvents(ctx context.Context, stackRef backend.StackReference,
+	version int) ([]apitype.EngineEvent, error) {
+	return b.getUpdateEvents(stackRef.StackName(), version)
+}
+
+func (b *localBackend) ImportHistory(ctx context.Context, stackRef backend.StackReference,
+	updates []backend.UpdateInfo, deployments []*apitype.UntypedDeployment) error {
+	if err := b.checkWritable("import the history of stack '" + string(stackRef.StackName()) + "'"); err != nil {
+		return err
+	}
+	return b.importHistory(stackRef.StackName(), updates, deployments)
+}
+
+// CancelCurrentUpdate releases the lock held by a stack's running update, and records the update as cancelled in
+// the stack's history.  It is meant for updates whose process has died or hung, leaving the stack locked: since the
+// operations that such an update had begun are recorded in the stack's checkpoint, the next update reconciles them.
+func (b *localBackend) CancelCurrentUpdate(ctx context.Context, stackRef backend.StackReference) error {
+	stackName := stackRef.StackName()
+	if err := b.checkWritable("cancel the update of stack '" + string(stackName) + "'"); err != nil {
+		return err
+	}
+	lock, err := b.getStackLock(stackName)
+	if err != nil {
+		return err
+	}
+	if lock == nil {
+		return errors.Errorf("stack '%s' has no update in progress", stackName)
+	}
+	return b.cancelLockedUpdate(stackName, lock)
+}
+
 func (b *localBackend) GetLogs(ctx context.Context, stackRef backend.StackReference,
 	query operations.LogQuery) ([]operations.LogEntry, error) {
 
 	stackName := stackRef.StackName()
-	target, err := b.getTarget(stackName)
+	target, err := b.getTarget(stackName, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -363,6 +702,27 @@ func (b *localBackend) ExportDeployment(ctx context.Context,
 		return nil, err
 	}
 
+	return makeUntypedDeployment(snap)
+}
+
+func (b *localBackend) ExportDeploymentVersion(ctx context.Context,
+	stackRef backend.StackReference, version int) (*apitype.UntypedDeployment, error) {
+
+	chk, err := b.getHistoryCheckpoint(stackRef.StackName(), version)
+	if err != nil {
+		return nil, err
+	}
+
+	snap, err := stack.DeserializeCheckpoint(chk)
+	if err != nil {
+		return nil, err
+	}
+
+	return makeUntypedDeployment(snap)
+}
+
+// makeUntypedDeployment serializes a snapshot, which may be nil, as an opaque JSON deployment.
+func makeUntypedDeployment(snap *deploy.Snapshot) (*apitype.UntypedDeployment, error) {
 	if snap == nil {
 		snap = deploy.NewSnapshot(deploy.Manifest{}, nil)
 	}
@@ -382,6 +742,16 @@ func (b *localBackend) ImportDeployment(ctx context.Context, stackRef backend.St
 	deployment *apitype.UntypedDeployment) error {
 
 	stackName := stackRef.StackName()
+	if err := b.checkWritable("import a deployment into stack '" + string(stackName) + "'"); err != nil {
+		return err
+	}
+	if lock, err := b.getStackLock(stackName); err != nil {
+		return err
+	} else if lock != nil {
+		return errors.Errorf("stack '%s' is being updated by %s; wait for the update to finish, or run "+
+			"`pulumi cancel`", stackName, lock)
+	}
+
 	config, _, _, err := b.getStack(stackName)
 	if err != nil {
 		return err
@@ -416,19 +786,14 @@ func (b *localBackend) getLocalStacks() ([]tokens.QName, error) {
 	// Read the stack directory.
 	path := b.stackPath("")
 
-	files, err := ioutil.ReadDir(path)
-	if err != nil && !os.IsNotExist(err) {
+	files, err := b.storage.List(path)
+	if err != nil {
 		return nil, errors.Errorf("could not read stacks: %v", err)
 	}
 
 	for _, file := range files {
-		// Ignore directories.
-		if file.IsDir() {
-			continue
-		}
-
 		// Skip files without valid extensions (e.g., *.bak files).
-		stackfn := file.Name()
+		stackfn := file.Name
 		ext := filepath.Ext(stackfn)
 		if _, has := encoding.Marshalers[ext]; !has {
 			continue
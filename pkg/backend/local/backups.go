@@ -0,0 +1,179 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/tokens"
+	"github.com/pulumi/pulumi/pkg/util/logging"
+)
+
+const (
+	// BackupKeepLastEnvVar is the environment variable holding the number of each stack's newest backups to keep.
+	BackupKeepLastEnvVar = "PULUMI_BACKUP_KEEP_LAST"
+	// BackupKeepDaysEnvVar is the environment variable holding the number of days for which to keep backups.
+	BackupKeepDaysEnvVar = "PULUMI_BACKUP_KEEP_DAYS"
+	// CompressBackupsEnvVar is the environment variable that, if truthy, causes backups to be written compressed.
+	CompressBackupsEnvVar = "PULUMI_COMPRESS_BACKUPS"
+)
+
+// BackupRetention is a policy for which backups of a stack's checkpoint to keep.  A backup is kept if either part of
+// the policy keeps it, and a part that is zero keeps nothing; a policy that is all zero, however, keeps everything.
+type BackupRetention struct {
+	KeepLast int // the number of the newest backups to keep.
+	KeepDays int // the number of days for which to keep backups.
+}
+
+// IsZero returns true if the policy keeps every backup.
+func (r BackupRetention) IsZero() bool {
+	return r.KeepLast == 0 && r.KeepDays == 0
+}
+
+// BackupRetentionFromEnv returns the retention policy given by the PULUMI_BACKUP_KEEP_LAST and PULUMI_BACKUP_KEEP_DAYS
+// environment variables.  If neither is set, every backup is kept.
+func BackupRetentionFromEnv() (BackupRetention, error) {
+	var policy BackupRetention
+	for envVar, value := range map[string]*int{
+		BackupKeepLastEnvVar: &policy.KeepLast,
+		BackupKeepDaysEnvVar: &policy.KeepDays,
+	} {
+		s := os.Getenv(envVar)
+		if s == "" {
+			continue
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return BackupRetention{}, errors.Errorf("%s must be a non-negative number, not '%s'", envVar, s)
+		}
+		*value = n
+	}
+	return policy, nil
+}
+
+// expired returns the backups that the policy doesn't keep, from the oldest to the newest.
+func (r BackupRetention) expired(backups []storageFile, now time.Time) []storageFile {
+	if r.IsZero() {
+		return nil
+	}
+
+	sorted := make([]storageFile, len(backups))
+	copy(sorted, backups)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ModTime.Before(sorted[j].ModTime) })
+
+	cutoff := now.AddDate(0, 0, -r.KeepDays)
+	var expired []storageFile
+	for i, backup := range sorted {
+		newest := len(sorted)-i <= r.KeepLast
+		recent := r.KeepDays > 0 && backup.ModTime.After(cutoff)
+		if !newest && !recent {
+			expired = append(expired, backup)
+		}
+	}
+	return expired
+}
+
+// CollectBackups removes the backups that the given policy doesn't keep: those of each stack's checkpoint, and the
+// last checkpoints of stacks that have been removed.  It returns the locations of the backups removed, or, if dryRun
+// is set, of those that would be removed.
+func (b *localBackend) CollectBackups(ctx context.Context, policy BackupRetention, dryRun bool) ([]string, error) {
+	if !dryRun {
+		if err := b.checkWritable("remove backups"); err != nil {
+			return nil, err
+		}
+	}
+
+	stacks, err := b.getLocalStacks()
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for _, name := range stacks {
+		stackRemoved, err := b.pruneBackups(name, policy, dryRun)
+		removed = append(removed, stackRemoved...)
+		if err != nil {
+			return removed, err
+		}
+	}
+
+	// A removed stack leaves its last checkpoint behind, renamed with a .bak extension, as its only backup.
+	stackDir := b.stackPath("")
+	files, err := b.storage.List(stackDir)
+	if err != nil {
+		return removed, err
+	}
+	exists := make(map[string]bool)
+	for _, file := range files {
+		exists[file.Name] = true
+	}
+	var orphans []storageFile
+	for _, file := range files {
+		if strings.HasSuffix(file.Name, ".bak") && !exists[strings.TrimSuffix(file.Name, ".bak")] {
+			orphans = append(orphans, file)
+		}
+	}
+	for _, orphan := range orphans {
+		if len(policy.expired([]storageFile{orphan}, time.Now())) == 0 {
+			continue
+		}
+		orphanPath := filepath.Join(stackDir, orphan.Name)
+		if !dryRun {
+			if err = b.storage.Remove(orphanPath); err != nil {
+				return removed, err
+			}
+		}
+		removed = append(removed, b.storage.Describe(orphanPath))
+	}
+
+	return removed, nil
+}
+
+// pruneBackups removes the backups of a stack's checkpoint that the given policy doesn't keep, and returns their
+// locations.  If dryRun is set, it only returns the locations of the backups that it would remove.
+func (b *localBackend) pruneBackups(name tokens.QName, policy BackupRetention, dryRun bool) ([]string, error) {
+	if policy.IsZero() {
+		return nil, nil
+	}
+
+	dir := b.backupDirectory(name)
+	backups, err := b.storage.List(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for _, backup := range policy.expired(backups, time.Now()) {
+		backupPath := filepath.Join(dir, backup.Name)
+		if !dryRun {
+			if err = b.storage.Remove(backupPath); err != nil {
+				return removed, err
+			}
+		}
+		removed = append(removed, b.storage.Describe(backupPath))
+	}
+	if len(removed) > 0 && !dryRun {
+		logging.V(7).Infof("Removed %d expired backups of stack %s", len(removed), name)
+	}
+	return removed, nil
+}
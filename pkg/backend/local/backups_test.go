@@ -0,0 +1,155 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/tokens"
+)
+
+func TestBackupRetentionExpired(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+
+	// Five backups, half a day apart from whole days old, listed out of order.
+	var backups []storageFile
+	for _, age := range []int{2, 0, 4, 1, 3} {
+		backups = append(backups, storageFile{
+			Name:    "dev." + strconv.Itoa(age) + ".json",
+			ModTime: now.Add(-time.Duration(age)*day - day/2),
+		})
+	}
+
+	tests := []struct {
+		policy  BackupRetention
+		expired []string // from the oldest to the newest.
+	}{
+		{BackupRetention{}, nil},
+		{BackupRetention{KeepLast: 2}, []string{"dev.4.json", "dev.3.json", "dev.2.json"}},
+		{BackupRetention{KeepLast: 5}, nil},
+		{BackupRetention{KeepDays: 2}, []string{"dev.4.json", "dev.3.json", "dev.2.json"}},
+		{BackupRetention{KeepDays: 10}, nil},
+		{BackupRetention{KeepLast: 1, KeepDays: 2}, []string{"dev.4.json", "dev.3.json", "dev.2.json"}},
+		{BackupRetention{KeepLast: 4, KeepDays: 1}, []string{"dev.4.json"}},
+		{BackupRetention{KeepLast: 1, KeepDays: 0}, []string{"dev.4.json", "dev.3.json", "dev.2.json", "dev.1.json"}},
+	}
+	for _, test := range tests {
+		var expired []string
+		for _, backup := range test.policy.expired(backups, now) {
+			expired = append(expired, backup.Name)
+		}
+		assert.Equal(t, test.expired, expired, "%+v", test.policy)
+	}
+}
+
+func TestBackupRetentionFromEnv(t *testing.T) {
+	defer func() {
+		assert.NoError(t, os.Unsetenv(BackupKeepLastEnvVar))
+		assert.NoError(t, os.Unsetenv(BackupKeepDaysEnvVar))
+	}()
+
+	policy, err := BackupRetentionFromEnv()
+	assert.NoError(t, err)
+	assert.True(t, policy.IsZero())
+
+	assert.NoError(t, os.Setenv(BackupKeepLastEnvVar, "10"))
+	assert.NoError(t, os.Setenv(BackupKeepDaysEnvVar, "30"))
+	policy, err = BackupRetentionFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, BackupRetention{KeepLast: 10, KeepDays: 30}, policy)
+
+	for _, bad := range []string{"-1", "ten"} {
+		assert.NoError(t, os.Setenv(BackupKeepDaysEnvVar, bad))
+		_, err = BackupRetentionFromEnv()
+		assert.Error(t, err, bad)
+	}
+}
+
+// putAged writes a file that was last modified the given time ago.
+func putAged(s *memStorage, p string, age time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.putLocked(p, []byte("{}"))
+	f := s.files[s.key(p)]
+	f.modTime = time.Now().Add(-age)
+	s.files[s.key(p)] = f
+}
+
+// TestCollectBackups removes the expired backups of each stack's checkpoint, and the expired last checkpoints of
+// stacks that have been removed, but nothing else.
+func TestCollectBackups(t *testing.T) {
+	b, s := newConflictTestBackend()
+	name := tokens.QName("dev")
+	_, _, err := b.writeStack(name, nil, deploy.NewSnapshot(deploy.Manifest{}, nil))
+	assert.NoError(t, err)
+	assert.NoError(t, s.RemoveAll(b.backupDirectory(name)))
+
+	day := 24 * time.Hour
+	backupDir := b.backupDirectory(name)
+	putAged(s, filepath.Join(backupDir, "dev.1.json"), 3*day)
+	putAged(s, filepath.Join(backupDir, "dev.2.json"), 2*day)
+	putAged(s, filepath.Join(backupDir, "dev.3.json"), time.Hour)
+	putAged(s, b.stackPath("gone")+".bak", 3*day)
+	putAged(s, b.stackPath("recent")+".bak", time.Hour)
+	putAged(s, b.stackPath(name)+".bak", 3*day)
+
+	policy := BackupRetention{KeepDays: 1}
+	expected := []string{
+		s.Describe(filepath.Join(backupDir, "dev.1.json")),
+		s.Describe(filepath.Join(backupDir, "dev.2.json")),
+		s.Describe(b.stackPath("gone") + ".bak"),
+	}
+
+	// A dry run only reports what would be removed.
+	removed, err := b.CollectBackups(context.Background(), policy, true)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, removed)
+	files, err := s.List(backupDir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 3)
+
+	removed, err = b.CollectBackups(context.Background(), policy, false)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, removed)
+	for p, exists := range map[string]bool{
+		filepath.Join(backupDir, "dev.1.json"): false,
+		filepath.Join(backupDir, "dev.2.json"): false,
+		filepath.Join(backupDir, "dev.3.json"): true,
+		b.stackPath("gone") + ".bak":           false,
+		b.stackPath("recent") + ".bak":         true,
+		b.stackPath(name) + ".bak":             true,
+		b.stackPath(name):                      true,
+	} {
+		has, err := s.Exists(p)
+		assert.NoError(t, err)
+		assert.Equal(t, exists, has, p)
+	}
+
+	// A read-only backend removes nothing, unless it's only asked what it would remove.
+	b.readOnly = true
+	_, err = b.CollectBackups(context.Background(), policy, false)
+	assert.Error(t, err)
+	_, err = b.CollectBackups(context.Background(), policy, true)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,157 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/backend"
+	"github.com/pulumi/pulumi/pkg/tokens"
+)
+
+// In a storage that supports conditional writes, the backend remembers the version of each stack's checkpoint that it
+// first read, and writes the checkpoint only if it is still that version, or the one that the backend last wrote.  So
+// if another writer, such as an update that raced this one for the stack's lock, changes the checkpoint in between,
+// the write fails with a checkpointConflictError rather than silently discarding the other writer's changes.  Reading
+// the checkpoint for a plan's target forgets the version first, so that it's the version that the plan was made
+// against that the checkpoint is expected to have.
+
+// checkpointConflictError is returned when a stack's checkpoint can't be written because another writer has changed
+// it since it was read.
+type checkpointConflictError struct {
+	name   tokens.QName
+	lock   *updateLock         // the lock of the competing update, if another holds it.
+	latest *backend.UpdateInfo // the latest update in the stack's history, if the lock isn't another's.
+}
+
+func (e *checkpointConflictError) Error() string {
+	msg := fmt.Sprintf("the checkpoint of stack '%s' was changed by another writer since it was read", e.name)
+	switch {
+	case e.lock != nil:
+		msg += fmt.Sprintf(", by %s", e.lock)
+	case e.latest != nil:
+		msg += fmt.Sprintf("; the latest update recorded is a %s started %s", e.latest.Kind,
+			time.Unix(e.latest.StartTime, 0).Format(time.RFC1123))
+	}
+	return msg + "; its changes were kept, and this operation's were not saved.  Run `pulumi refresh` to reconcile " +
+		"the stack with its resources before trying again"
+}
+
+// IsCheckpointConflictError returns true if an error was returned because a stack's checkpoint was changed by another
+// writer since it was read.
+func IsCheckpointConflictError(err error) bool {
+	_, ok := errors.Cause(err).(*checkpointConflictError)
+	return ok
+}
+
+// readCheckpointFile reads the checkpoint file of a stack, remembering its version if this is the first time that it
+// has been read since the version was last forgotten.
+func (b *localBackend) readCheckpointFile(name tokens.QName) ([]byte, error) {
+	cs, ok := b.storage.(conditionalStorage)
+	if !ok {
+		return b.storage.ReadFile(b.stackPath(name))
+	}
+
+	data, version, err := cs.ReadFileVersion(b.stackPath(name))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	b.versionsLock.Lock()
+	defer b.versionsLock.Unlock()
+	if _, has := b.versions[name]; !has {
+		if b.versions == nil {
+			b.versions = make(map[tokens.QName]string)
+		}
+		b.versions[name] = version
+	}
+	return data, err
+}
+
+// writeCheckpointFile writes the checkpoint file of a stack, keeping the file it replaces as a backup, and returns
+// the location of the backup.  In a storage that supports conditional writes, it fails with a
+// checkpointConflictError if the checkpoint has changed since it was read.
+func (b *localBackend) writeCheckpointFile(name tokens.QName, file string, data []byte) (string, error) {
+	cs, ok := b.storage.(conditionalStorage)
+	if !ok {
+		// Back up the existing file if it already exists.
+		bck := b.backupTarget(file)
+
+		// And now write out the new snapshot file, overwriting that location.
+		return bck, b.storage.WriteFile(file, data)
+	}
+
+	// Check the checkpoint's version before backing it up, so that a conflicting write leaves the backup alone.  If
+	// the checkpoint was never read, it's written only if it hasn't changed since now.
+	_, version, err := cs.ReadFileVersion(file)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	b.versionsLock.Lock()
+	expected, has := b.versions[name]
+	b.versionsLock.Unlock()
+	if has && expected != version {
+		return "", b.checkpointConflict(name)
+	}
+
+	bck := b.backupTarget(file)
+
+	written, err := cs.WriteFileIfVersion(file, data, version)
+	if err != nil {
+		if isFileChanged(err) {
+			return "", b.checkpointConflict(name)
+		}
+		return "", err
+	}
+
+	b.versionsLock.Lock()
+	defer b.versionsLock.Unlock()
+	if b.versions == nil {
+		b.versions = make(map[tokens.QName]string)
+	}
+	b.versions[name] = written
+	return bck, nil
+}
+
+// forgetCheckpointVersion forgets the version of a stack's checkpoint, as when the stack is removed, or before it is
+// read for a plan.
+func (b *localBackend) forgetCheckpointVersion(name tokens.QName) {
+	b.versionsLock.Lock()
+	defer b.versionsLock.Unlock()
+	delete(b.versions, name)
+}
+
+// checkpointConflict returns the error reporting that a stack's checkpoint was changed by another writer, naming the
+// update that is the likeliest to have changed it: the one holding the stack's lock, if it isn't this process, or
+// else the latest in the stack's history.
+func (b *localBackend) checkpointConflict(name tokens.QName) error {
+	result := &checkpointConflictError{name: name}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	if lock, err := b.getStackLock(name); err == nil && lock != nil && (lock.PID != os.Getpid() || lock.Host != host) {
+		result.lock = lock
+		return result
+	}
+	if history, err := b.getHistory(name); err == nil && len(history) > 0 {
+		result.latest = &history[0]
+	}
+	return result
+}
@@ -0,0 +1,254 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/tokens"
+)
+
+// memStorage is an in-memory storage that supports conditional writes, as an object store does.
+type memStorage struct {
+	lock    sync.Mutex
+	files   map[string]memFile
+	version int // the version last given to a file.
+}
+
+type memFile struct {
+	data    []byte
+	version string
+	modTime time.Time
+}
+
+func newMemStorage() *memStorage {
+	return &memStorage{files: make(map[string]memFile)}
+}
+
+func (s *memStorage) key(p string) string {
+	return path.Clean(filepath.ToSlash(p))
+}
+
+// putLocked writes a file, giving it a new version, which it returns.
+func (s *memStorage) putLocked(p string, data []byte) string {
+	s.version++
+	version := strconv.Itoa(s.version)
+	s.files[s.key(p)] = memFile{data: append([]byte(nil), data...), version: version, modTime: time.Now()}
+	return version
+}
+
+func (s *memStorage) ReadFile(p string) ([]byte, error) {
+	data, _, err := s.ReadFileVersion(p)
+	return data, err
+}
+
+func (s *memStorage) ReadFileVersion(p string) ([]byte, string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	f, has := s.files[s.key(p)]
+	if !has {
+		return nil, "", &os.PathError{Op: "read", Path: p, Err: os.ErrNotExist}
+	}
+	return append([]byte(nil), f.data...), f.version, nil
+}
+
+func (s *memStorage) WriteFile(p string, data []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.putLocked(p, data)
+	return nil
+}
+
+func (s *memStorage) WriteFileIfVersion(p string, data []byte, version string) (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.files[s.key(p)].version != version {
+		return "", &os.PathError{Op: "write", Path: p, Err: errFileChanged}
+	}
+	return s.putLocked(p, data), nil
+}
+
+func (s *memStorage) CreateFile(p string, data []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, has := s.files[s.key(p)]; has {
+		return &os.PathError{Op: "create", Path: p, Err: os.ErrExist}
+	}
+	s.putLocked(p, data)
+	return nil
+}
+
+func (s *memStorage) Exists(p string) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	_, has := s.files[s.key(p)]
+	return has, nil
+}
+
+func (s *memStorage) Remove(p string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.files, s.key(p))
+	return nil
+}
+
+func (s *memStorage) RemoveAll(p string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	dir := s.key(p)
+	for k := range s.files {
+		if k == dir || strings.HasPrefix(k, dir+"/") {
+			delete(s.files, k)
+		}
+	}
+	return nil
+}
+
+func (s *memStorage) Rename(from, to string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	src, dst := s.key(from), s.key(to)
+	moved := false
+	for k, f := range s.files {
+		if k == src || strings.HasPrefix(k, src+"/") {
+			delete(s.files, k)
+			s.files[dst+strings.TrimPrefix(k, src)] = f
+			moved = true
+		}
+	}
+	if !moved {
+		return &os.PathError{Op: "rename", Path: from, Err: os.ErrNotExist}
+	}
+	return nil
+}
+
+func (s *memStorage) List(dir string) ([]storageFile, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	prefix := s.key(dir) + "/"
+	var files []storageFile
+	for k, f := range s.files {
+		if strings.HasPrefix(k, prefix) && !strings.Contains(k[len(prefix):], "/") {
+			files = append(files, storageFile{Name: k[len(prefix):], ModTime: f.modTime})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+func (s *memStorage) Describe(p string) string {
+	return "mem://" + s.key(p)
+}
+
+// newConflictTestBackend returns a backend over an in-memory storage that supports conditional writes.
+func newConflictTestBackend() (*localBackend, *memStorage) {
+	s := newMemStorage()
+	return &localBackend{url: "mem://", storage: s}, s
+}
+
+// TestCheckpointWriteAfterRead writes a checkpoint that hasn't changed since it was read, which records the version
+// written so that the backend's own writes never conflict with one another.
+func TestCheckpointWriteAfterRead(t *testing.T) {
+	b, s := newConflictTestBackend()
+	name := tokens.QName("dev")
+	file := b.stackPath(name)
+	assert.NoError(t, s.WriteFile(file, []byte("v1")))
+
+	data, err := b.readCheckpointFile(name)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", string(data))
+
+	bck, err := b.writeCheckpointFile(name, file, []byte("v2"))
+	assert.NoError(t, err)
+	assert.Equal(t, s.Describe(file+".bak"), bck)
+	backup, err := s.ReadFile(file + ".bak")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", string(backup))
+
+	// The version written is the one expected next time.
+	_, version, err := s.ReadFileVersion(file)
+	assert.NoError(t, err)
+	assert.Equal(t, version, b.versions[name])
+	_, err = b.writeCheckpointFile(name, file, []byte("v3"))
+	assert.NoError(t, err)
+	data, err = s.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "v3", string(data))
+}
+
+// TestCheckpointWriteConflict writes a checkpoint that another writer changed since it was read, which must fail
+// without touching either the checkpoint or its backup.
+func TestCheckpointWriteConflict(t *testing.T) {
+	b, s := newConflictTestBackend()
+	name := tokens.QName("dev")
+	file := b.stackPath(name)
+	assert.NoError(t, s.WriteFile(file, []byte("v1")))
+	assert.NoError(t, s.WriteFile(file+".bak", []byte("v0")))
+
+	_, err := b.readCheckpointFile(name)
+	assert.NoError(t, err)
+	assert.NoError(t, s.WriteFile(file, []byte("theirs")))
+
+	_, err = b.writeCheckpointFile(name, file, []byte("ours"))
+	assert.True(t, IsCheckpointConflictError(err), "expected a conflict, got %v", err)
+
+	data, err := s.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "theirs", string(data))
+	backup, err := s.ReadFile(file + ".bak")
+	assert.NoError(t, err)
+	assert.Equal(t, "v0", string(backup))
+
+	// Once the version is forgotten, as it is before a plan reads the checkpoint again, the write goes through.
+	b.forgetCheckpointVersion(name)
+	_, err = b.writeCheckpointFile(name, file, []byte("ours"))
+	assert.NoError(t, err)
+}
+
+// TestCheckpointWriteNeverRead writes checkpoints that were never read: a new stack's, which is written only if no
+// other writer created it first, and an existing one's, which is written against its version at the time.
+func TestCheckpointWriteNeverRead(t *testing.T) {
+	b, s := newConflictTestBackend()
+	name := tokens.QName("dev")
+	file := b.stackPath(name)
+
+	// The stack doesn't exist yet, so reading it records that, and a checkpoint created meanwhile is a conflict.
+	_, err := b.readCheckpointFile(name)
+	assert.True(t, os.IsNotExist(err))
+	assert.NoError(t, s.WriteFile(file, []byte("theirs")))
+	_, err = b.writeCheckpointFile(name, file, []byte("ours"))
+	assert.True(t, IsCheckpointConflictError(err), "expected a conflict, got %v", err)
+
+	// A checkpoint that was never read at all is written against whatever version it has now.
+	b.forgetCheckpointVersion(name)
+	_, err = b.writeCheckpointFile(name, file, []byte("ours"))
+	assert.NoError(t, err)
+	data, err := s.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "ours", string(data))
+	backup, err := s.ReadFile(file + ".bak")
+	assert.NoError(t, err)
+	assert.Equal(t, "theirs", string(backup))
+}
@@ -16,17 +16,23 @@ package local
 
 import (
 	cryptorand "crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
 	"os"
+	"os/exec"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/pulumi/pulumi/pkg/resource/config"
+	"github.com/pulumi/pulumi/pkg/secrets"
 	"github.com/pulumi/pulumi/pkg/tokens"
 	"github.com/pulumi/pulumi/pkg/util/cmdutil"
 	"github.com/pulumi/pulumi/pkg/util/contract"
+	"github.com/pulumi/pulumi/pkg/util/logging"
 	"github.com/pulumi/pulumi/pkg/workspace"
 )
 
@@ -38,78 +44,300 @@ func readPassphrase(prompt string) (string, error) {
 }
 
 // defaultCrypter gets the right value encrypter/decrypter given the project configuration.
-func defaultCrypter(stackName tokens.QName, cfg config.Map) (config.Crypter, error) {
+func (b *localBackend) defaultCrypter(stackName tokens.QName, cfg config.Map) (config.Crypter, error) {
 	// If there is no config, we can use a standard panic crypter.
 	if !cfg.HasSecureValue() {
 		return config.NewPanicCrypter(), nil
 	}
 
 	// Otherwise, we will use an encrypted one.
-	return symmetricCrypter(stackName)
+	return b.stackCrypter(stackName)
 }
 
-// symmetricCrypter gets the right value encrypter/decrypter for this project.
-func symmetricCrypter(stackName tokens.QName) (config.Crypter, error) {
-	contract.Assertf(stackName != "", "stackName", "!= \"\"")
+// stackCrypter gets the value encrypter/decrypter for a stack, which encrypts its secret configuration values and, if
+// asked to, its state.  Crypters are cached, since reading the passphrase may prompt for it and deriving a key from it
+// is deliberately slow.
+func (b *localBackend) stackCrypter(stackName tokens.QName) (config.Crypter, error) {
+	b.cryptersLock.Lock()
+	defer b.cryptersLock.Unlock()
 
+	if crypter, ok := b.crypters[stackName]; ok {
+		return crypter, nil
+	}
+	crypter, err := newStackCrypter(stackName)
+	if err != nil {
+		return nil, err
+	}
+	if b.crypters == nil {
+		b.crypters = make(map[tokens.QName]config.Crypter)
+	}
+	b.crypters[stackName] = crypter
+	return crypter, nil
+}
+
+// encryptedStateError is returned when the state of a stack is encrypted and can't be decrypted.
+type encryptedStateError struct {
+	stackName tokens.QName
+	err       error
+}
+
+func (e *encryptedStateError) Error() string {
+	return fmt.Sprintf("the state of stack '%s' is encrypted, and can't be decrypted: %v", e.stackName, e.err)
+}
+
+// stateDecrypter gets the decrypter for the encrypted state of a stack.  Unlike stackCrypter, it never sets up a new
+// passphrase for a stack that doesn't have one, since the state couldn't have been encrypted with it.
+func (b *localBackend) stateDecrypter(stackName tokens.QName) (config.Decrypter, error) {
 	info, err := workspace.DetectProjectStack(stackName)
+	if err != nil {
+		return nil, &encryptedStateError{stackName: stackName, err: err}
+	}
+	if info.EncryptionSalt == "" && info.EncryptedKey == "" {
+		return nil, &encryptedStateError{stackName: stackName,
+			err: errors.New("the stack has no key; was its encryptionsalt or encryptedkey removed from its settings?")}
+	}
+
+	crypter, err := b.stackCrypter(stackName)
+	if err != nil {
+		return nil, &encryptedStateError{stackName: stackName, err: err}
+	}
+	return crypter, nil
+}
+
+// newStackCrypter gets the crypter of a stack from the secrets provider named in its settings: a key in a key
+// management service, if there is one, or else a passphrase.
+func newStackCrypter(stackName tokens.QName) (config.Crypter, error) {
+	contract.Assertf(stackName != "", "stackName %s", "!= \"\"")
+
+	info, err := workspace.DetectProjectStack(stackName)
+	if err != nil {
+		return nil, err
+	}
+	var crypter config.Crypter
+	if secrets.IsKeyManagerURL(info.SecretsProvider) {
+		crypter, err = keyManagerCrypter(stackName, info)
+	} else {
+		crypter, err = symmetricCrypter(stackName, info)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return withSecretsKeys(crypter, info)
+}
+
+// withSecretsKeys returns a crypter that encrypts the secrets that a stack's further secrets keys are for with those
+// keys, and the rest with the given crypter.  If the stack has no further keys, the crypter is returned as it is.
+func withSecretsKeys(crypter config.Crypter, info *workspace.ProjectStack) (config.Crypter, error) {
+	if len(info.SecretsKeys) == 0 {
+		return crypter, nil
+	}
+
+	// The keys are tried in order of their names, so that which is used for a secret doesn't depend on map order.
+	names := make([]string, 0, len(info.SecretsKeys))
+	for name := range info.SecretsKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	routes := make([]secrets.Route, 0, len(names))
+	for _, name := range names {
+		key := info.SecretsKeys[name]
+		km, err := secrets.OpenKeyManager(key.Provider)
+		if err != nil {
+			return nil, errors.Wrapf(err, "secrets key '%s'", name)
+		}
+		keyCrypter, err := secrets.CrypterFromKey(km, key.EncryptedKey)
+		if err != nil {
+			return nil, errors.Wrapf(err, "secrets key '%s'", name)
+		}
+		routes = append(routes, secrets.Route{Name: name, Crypter: keyCrypter, Config: key.Config, State: key.State})
+	}
+	return secrets.NewRoutingCrypter(crypter, routes), nil
+}
+
+// keyManagerCrypter gets the crypter of a stack whose data key is wrapped by a key in a key management service,
+// creating the data key if the stack doesn't have one yet.
+func keyManagerCrypter(stackName tokens.QName, info *workspace.ProjectStack) (config.Crypter, error) {
+	km, err := secrets.OpenKeyManager(info.SecretsProvider)
+	if err != nil {
+		return nil, err
+	}
+	if info.EncryptedKey != "" {
+		return secrets.CrypterFromKey(km, info.EncryptedKey)
+	}
+
+	crypter, key, err := secrets.NewCrypter(km)
+	if err != nil {
+		return nil, err
+	}
+	info.EncryptedKey = key
+	if err = workspace.SaveProjectStack(stackName, info); err != nil {
+		return nil, err
+	}
+	return crypter, nil
+}
+
+// symmetricCrypter gets the crypter of a stack whose secrets are encrypted with a key derived from a passphrase.
+func symmetricCrypter(stackName tokens.QName, info *workspace.ProjectStack) (config.Crypter, error) {
+	timeout, err := passphraseCacheTimeout()
 	if err != nil {
 		return nil, err
 	}
 
 	// If we have a salt, we can just use it.
 	if info.EncryptionSalt != "" {
+		// A key that the passphrase unlocked recently is used without asking for the passphrase again.
+		if timeout > 0 {
+			if key := cachedSymmetricKey(info.EncryptionSalt); key != nil {
+				return config.NewSymmetricCrypter(key), nil
+			}
+		}
+
 		phrase, phraseErr := readPassphrase("Enter your passphrase to unlock config/secrets\n" +
 			"    (set PULUMI_CONFIG_PASSPHRASE to remember)")
 		if phraseErr != nil {
 			return nil, phraseErr
 		}
 
-		crypter, crypterErr := symmetricCrypterFromPhraseAndState(phrase, info.EncryptionSalt)
-		if crypterErr != nil {
-			return nil, crypterErr
+		key, keyErr := symmetricKeyFromPhraseAndState(phrase, info.EncryptionSalt)
+		if keyErr != nil {
+			return nil, keyErr
 		}
 
-		return crypter, nil
+		cacheSymmetricKey(info.EncryptionSalt, key, timeout)
+		return config.NewSymmetricCrypter(key), nil
 	}
 
 	// Here, the stack does not have an EncryptionSalt, so we will get a passphrase and create one
-	phrase, err := readPassphrase("Enter your passphrase to protect config/secrets")
+	phrase, err := readNewPassphrase(readPassphrase, "Enter your passphrase to protect config/secrets")
 	if err != nil {
 		return nil, err
 	}
-	confirm, err := readPassphrase("Re-enter your passphrase to confirm")
-	if err != nil {
+
+	// Now store the result and save it.
+	key, state := newSymmetricKey(phrase)
+	info.EncryptionSalt = state
+	if err = workspace.SaveProjectStack(stackName, info); err != nil {
 		return nil, err
 	}
+
+	cacheSymmetricKey(state, key, timeout)
+	return config.NewSymmetricCrypter(key), nil
+}
+
+// passphraseCacheTimeout returns how long the keys unlocked by passphrases are cached.  They aren't cached at all if
+// the passphrase is set by PULUMI_CONFIG_PASSPHRASE, since it never needs to be entered.
+func passphraseCacheTimeout() (time.Duration, error) {
+	if os.Getenv("PULUMI_CONFIG_PASSPHRASE") != "" {
+		return 0, nil
+	}
+	return workspace.GetPassphraseCacheTimeout()
+}
+
+// passphraseCacheID returns the ID under which the key of a stack with the given encryption state is cached.  Each
+// stack's state has its own salt, so it identifies the stack's key without naming the stack.
+func passphraseCacheID(state string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(state)))
+}
+
+// cachedSymmetricKey returns the key for the given encryption state that the passphrase agent holds, or nil if it holds
+// none.  The cache only saves typing the passphrase, so a key that can't be read from it, or that doesn't match the
+// state, is ignored.
+func cachedSymmetricKey(state string) []byte {
+	key, err := workspace.GetCachedPassphraseKey(passphraseCacheID(state))
+	if err != nil {
+		logging.V(7).Infof("could not read the passphrase cache: %v", err)
+		return nil
+	}
+	if len(key) != config.SymmetricCrypterKeyBytes || checkSymmetricKey(key, state) != nil {
+		return nil
+	}
+	return key
+}
+
+// cacheSymmetricKey has the passphrase agent hold the key for the given encryption state until the timeout passes,
+// starting the agent if it isn't running.  Failing to cache the key only means that the passphrase must be entered
+// again next time, so it isn't an error.
+func cacheSymmetricKey(state string, key []byte, timeout time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+	id := passphraseCacheID(state)
+	err := workspace.StoreCachedPassphraseKey(id, key, timeout)
+	if err == workspace.ErrNoPassphraseAgent {
+		// Start an agent to hold the key, and give it a moment to begin listening.
+		if err = startPassphraseAgent(); err == nil {
+			for i := 0; i < 20; i++ {
+				time.Sleep(50 * time.Millisecond)
+				if err = workspace.StoreCachedPassphraseKey(id, key, timeout); err != workspace.ErrNoPassphraseAgent {
+					break
+				}
+			}
+		}
+	}
+	if err != nil {
+		logging.V(7).Infof("could not cache the passphrase's key: %v", err)
+	}
+}
+
+// startPassphraseAgent starts a `pulumi passphrase-agent` in the background, in its own process group so that it
+// outlives this command and doesn't receive the terminal's interrupts.
+func startPassphraseAgent() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	agent := exec.Command(exe, "passphrase-agent") // nolint: gas
+	cmdutil.RegisterProcessGroup(agent)
+	if err = agent.Start(); err != nil {
+		return errors.Wrap(err, "starting a passphrase agent")
+	}
+	return agent.Process.Release()
+}
+
+// readNewPassphrase reads a new passphrase with the given function, asking for it twice to confirm it.
+func readNewPassphrase(read func(prompt string) (string, error), prompt string) (string, error) {
+	phrase, err := read(prompt)
+	if err != nil {
+		return "", err
+	}
+	confirm, err := read("Re-enter your passphrase to confirm")
+	if err != nil {
+		return "", err
+	}
 	if phrase != confirm {
-		return nil, errors.New("passphrases do not match")
+		return "", errors.New("passphrases do not match")
 	}
+	return phrase, nil
+}
 
+// newSymmetricCrypter constructs a Crypter from a passphrase with a new salt, and returns it along with the encryption
+// state to save in the stack's settings, from which symmetricKeyFromPhraseAndState derives its key again.
+func newSymmetricCrypter(phrase string) (config.Crypter, string) {
+	key, state := newSymmetricKey(phrase)
+	return config.NewSymmetricCrypter(key), state
+}
+
+// newSymmetricKey derives a key from a passphrase with a new salt, and returns it along with its encryption state.
+func newSymmetricKey(phrase string) ([]byte, string) {
 	// Produce a new salt.
 	salt := make([]byte, 8)
-	_, err = cryptorand.Read(salt)
+	_, err := cryptorand.Read(salt)
 	contract.Assertf(err == nil, "could not read from system random")
 
 	// Encrypt a message and store it with the salt so we can test if the password is correct later.
-	crypter := config.NewSymmetricCrypterFromPassphrase(phrase, salt)
-	msg, err := crypter.EncryptValue("pulumi")
+	key := config.DeriveSymmetricKey(phrase, salt)
+	msg, err := config.NewSymmetricCrypter(key).EncryptValue("pulumi")
 	contract.AssertNoError(err)
 
-	// Now store the result and save it.
-	info.EncryptionSalt = fmt.Sprintf("v1:%s:%s", base64.StdEncoding.EncodeToString(salt), msg)
-	if err = workspace.SaveProjectStack(stackName, info); err != nil {
-		return nil, err
-	}
-
-	return crypter, nil
+	return key, fmt.Sprintf("v1:%s:%s", base64.StdEncoding.EncodeToString(salt), msg)
 }
 
-// given a passphrase and an encryption state, construct a Crypter from it. Our encryption
+// given a passphrase and an encryption state, derive the key of a Crypter from it. Our encryption
 // state value is a version tag followed by version specific state information. Presently, we only have one version
 // we support (`v1`) which is AES-256-GCM using a key derived from a passphrase using 1,000,000 iterations of PDKDF2
 // using SHA256.
-func symmetricCrypterFromPhraseAndState(phrase string, state string) (config.Crypter, error) {
+func symmetricKeyFromPhraseAndState(phrase string, state string) ([]byte, error) {
 	splits := strings.SplitN(state, ":", 3)
 	if len(splits) != 3 {
 		return nil, errors.New("malformed state value")
@@ -124,13 +352,21 @@ func symmetricCrypterFromPhraseAndState(phrase string, state string) (config.Cry
 		return nil, err
 	}
 
-	decrypter := config.NewSymmetricCrypterFromPassphrase(phrase, salt)
-	decrypted, err := decrypter.DecryptValue(state[indexN(state, ":", 2)+1:])
-	if err != nil || decrypted != "pulumi" {
-		return nil, errors.New("incorrect passphrase")
+	key := config.DeriveSymmetricKey(phrase, salt)
+	if err = checkSymmetricKey(key, state); err != nil {
+		return nil, err
 	}
 
-	return decrypter, nil
+	return key, nil
+}
+
+// checkSymmetricKey returns an error unless a key decrypts the message that an encryption state holds to test it.
+func checkSymmetricKey(key []byte, state string) error {
+	decrypted, err := config.NewSymmetricCrypter(key).DecryptValue(state[indexN(state, ":", 2)+1:])
+	if err != nil || decrypted != "pulumi" {
+		return errors.New("incorrect passphrase")
+	}
+	return nil
 }
 
 func indexN(s string, substr string, n int) int {
@@ -22,6 +22,7 @@ import (
 	"os"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pulumi/pulumi/pkg/backend"
@@ -30,8 +31,11 @@ import (
 	"github.com/pulumi/pulumi/pkg/engine"
 	"github.com/pulumi/pulumi/pkg/resource"
 	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/tokens"
 	"github.com/pulumi/pulumi/pkg/util/cmdutil"
 	"github.com/pulumi/pulumi/pkg/util/contract"
+
+	"golang.org/x/crypto/ssh/terminal"
 )
 
 // DisplayEvents reads events from the `events` channel until it is closed, displaying each event as
@@ -41,7 +45,13 @@ func DisplayEvents(
 	action string, events <-chan engine.Event,
 	done chan<- bool, opts backend.DisplayOptions) {
 
-	if opts.DiffDisplay {
+	if opts.JSONDisplay {
+		DisplayJSONEvents(action, events, done, opts)
+	} else if opts.DiffFormat == backend.DiffFormatPatch {
+		DisplayPatchEvents(action, events, done, opts)
+	} else if opts.Quiet || opts.SummaryOnly {
+		DisplaySummaryEvents(action, events, done, opts)
+	} else if opts.DiffDisplay {
 		DisplayDiffEvents(action, events, done, opts)
 	} else {
 		// in progress display, we can't show separate create/delete for a single resource.
@@ -82,6 +92,13 @@ func DisplayDiffEvents(action string,
 		done <- true
 	}()
 
+	// Unless a width was given explicitly, wrap output to fit the terminal (if we're writing to one).
+	if opts.Diff.Width == 0 {
+		if width, _, err := terminal.GetSize(int(os.Stdout.Fd())); err == nil {
+			opts.Diff.Width = width
+		}
+	}
+
 	seen := make(map[resource.URN]engine.StepEventMetadata)
 
 	for {
@@ -111,6 +128,77 @@ func DisplayDiffEvents(action string,
 	}
 }
 
+// DisplayPatchEvents displays the engine events as a series of textual patches, one per changed resource.  Only the
+// patches are written to stdout, so that the output is stable and can be saved and compared between runs; errors and
+// warnings are written to stderr, and all other events are dropped.
+func DisplayPatchEvents(action string,
+	events <-chan engine.Event, done chan<- bool, opts backend.DisplayOptions) {
+
+	defer func() {
+		done <- true
+	}()
+
+	opts.Color = colors.Never
+	for event := range events {
+		switch event.Type {
+		case engine.CancelEvent:
+			return
+		case engine.ResourcePreEvent:
+			payload := event.Payload.(engine.ResourcePreEventPayload)
+			if shouldShow(payload.Metadata, opts) && payload.Metadata.Op != deploy.OpSame {
+				fprintIgnoreError(os.Stdout, engine.GetResourcePropertiesPatch(
+					payload.Metadata, payload.Planning, opts.Diff))
+			}
+		case engine.DiagEvent:
+			payload := event.Payload.(engine.DiagEventPayload)
+			if payload.Severity == diag.Error || payload.Severity == diag.Warning {
+				fprintIgnoreError(os.Stderr, opts.Color.Colorize(payload.Message))
+			}
+		}
+	}
+}
+
+// DisplaySummaryEvents displays only the errors and the final summary of an operation, for stacks whose full output
+// would overwhelm a log.  Unless opts.Quiet is set, it also displays one line per changed resource, giving the
+// operation and the number of properties that change.
+func DisplaySummaryEvents(action string,
+	events <-chan engine.Event, done chan<- bool, opts backend.DisplayOptions) {
+
+	defer func() {
+		done <- true
+	}()
+
+	for event := range events {
+		switch event.Type {
+		case engine.CancelEvent:
+			return
+		case engine.ResourcePreEvent:
+			payload := event.Payload.(engine.ResourcePreEventPayload)
+			if !opts.Quiet && shouldShow(payload.Metadata, opts) && payload.Metadata.Op != deploy.OpSame {
+				fprintIgnoreError(os.Stdout, opts.Color.Colorize(
+					renderResourceSummaryLine(payload.Metadata, payload.Planning, opts)))
+			}
+		case engine.SummaryEvent:
+			fprintIgnoreError(os.Stdout, renderSummaryEvent(event.Payload.(engine.SummaryEventPayload), opts))
+		case engine.DiagEvent:
+			payload := event.Payload.(engine.DiagEventPayload)
+			if payload.Severity == diag.Error {
+				fprintIgnoreError(os.Stderr, opts.Color.Colorize(payload.Message))
+			}
+		}
+	}
+}
+
+// renderResourceSummaryLine renders a single line describing the operation on a resource and, if its properties
+// change, how many of them do.
+func renderResourceSummaryLine(step engine.StepEventMetadata, planning bool, opts backend.DisplayOptions) string {
+	line := fmt.Sprintf("%v%v %v %v", step.Op.Prefix(), step.Op, step.Type, step.URN.Name())
+	if stats := engine.GetResourcePropertiesDiffStats(step, planning, opts.Debug, opts.Diff); stats.Any() {
+		line += fmt.Sprintf(" (%v)", stats)
+	}
+	return line + colors.Reset + "\n"
+}
+
 func RenderDiffEvent(
 	event engine.Event, seen map[resource.URN]engine.StepEventMetadata, opts backend.DisplayOptions) string {
 
@@ -120,7 +208,8 @@ func RenderDiffEvent(
 	case engine.PreludeEvent:
 		return renderPreludeEvent(event.Payload.(engine.PreludeEventPayload), opts)
 	case engine.SummaryEvent:
-		return renderSummaryEvent(event.Payload.(engine.SummaryEventPayload), opts)
+		payload := event.Payload.(engine.SummaryEventPayload)
+		return renderDiffStatsRollup(payload, seen, opts) + renderSummaryEvent(payload, opts)
 	case engine.ResourceOperationFailed:
 		return renderResourceOperationFailedEvent(event.Payload.(engine.ResourceOperationFailedPayload), opts)
 	case engine.ResourceOutputsEvent:
@@ -155,7 +244,7 @@ func renderSummaryEvent(event engine.SummaryEventPayload, opts backend.DisplayOp
 
 	changeCount := 0
 	for op, c := range changes {
-		if op != deploy.OpSame {
+		if op != deploy.OpSame && op != deploy.OpRead {
 			changeCount += c
 		}
 	}
@@ -180,7 +269,7 @@ func renderSummaryEvent(event engine.SummaryEventPayload, opts backend.DisplayOp
 
 	out := &bytes.Buffer{}
 	fprintIgnoreError(out, opts.Color.Colorize(fmt.Sprintf("%vinfo%v: %v %v %v\n",
-		colors.SpecInfo, colors.Reset, changesLabel, plural("change", changeCount), kind)))
+		colors.SpecInfo, colors.Reset, changesLabel, cmdutil.Plural("change", changeCount), kind)))
 
 	var planTo string
 	if event.IsPreview {
@@ -196,26 +285,72 @@ func renderSummaryEvent(event engine.SummaryEventPayload, opts backend.DisplayOp
 					opDescription = op.PastTense()
 				}
 				fprintIgnoreError(out, opts.Color.Colorize(fmt.Sprintf("    %v%v %v %v%v%v\n",
-					op.Prefix(), c, plural("resource", c), planTo, opDescription, colors.Reset)))
+					op.Prefix(), c, cmdutil.Plural("resource", c), planTo, opDescription, colors.Reset)))
 			}
 		}
 	}
 
 	if c := changes[deploy.OpSame]; c > 0 {
-		fprintfIgnoreError(out, "      %v %v unchanged\n", c, plural("resource", c))
+		fprintfIgnoreError(out, "      %v %v unchanged\n", c, cmdutil.Plural("resource", c))
 	}
 
-	// For actual deploys, we print some additional summary information
+	// For actual deploys, we print some additional summary information; for previews, we estimate it.
 	if !event.IsPreview {
 		if changeCount > 0 {
 			fprintIgnoreError(out, opts.Color.Colorize(fmt.Sprintf("%vUpdate duration: %v%v\n",
 				colors.SpecUnimportant, event.Duration, colors.Reset)))
 		}
+	} else if changeCount > 0 && event.EstimatedDuration > 0 {
+		fprintIgnoreError(out, opts.Color.Colorize(fmt.Sprintf("%vEstimated update duration: about %v%v\n",
+			colors.SpecUnimportant, roundDuration(event.EstimatedDuration), colors.Reset)))
 	}
 
 	return out.String()
 }
 
+// renderDiffStatsRollup renders the number of properties added, changed, and deleted across all of the resources
+// that were updated or replaced, grouped by resource type.
+func renderDiffStatsRollup(
+	event engine.SummaryEventPayload,
+	seen map[resource.URN]engine.StepEventMetadata,
+	opts backend.DisplayOptions) string {
+
+	counts := make(map[tokens.Type]int)
+	stats := make(map[tokens.Type]*engine.DiffStats)
+	for _, step := range seen {
+		if !shouldShow(step, opts) {
+			continue
+		}
+		s := engine.GetResourcePropertiesDiffStats(step, event.IsPreview, opts.Debug, opts.Diff)
+		if !s.Any() {
+			continue
+		}
+		if stats[step.Type] == nil {
+			stats[step.Type] = &engine.DiffStats{}
+		}
+		stats[step.Type].Merge(s)
+		counts[step.Type]++
+	}
+	if len(stats) == 0 {
+		return ""
+	}
+
+	var types []string
+	for t := range stats {
+		types = append(types, string(t))
+	}
+	sort.Strings(types)
+
+	out := &bytes.Buffer{}
+	fprintIgnoreError(out, opts.Color.Colorize(
+		fmt.Sprintf("%vProperty changes by resource type:%v\n", colors.SpecUnimportant, colors.Reset)))
+	for _, t := range types {
+		c := counts[tokens.Type(t)]
+		fprintfIgnoreError(out, "    %v (%v %v): %v\n", t, c, cmdutil.Plural("resource", c), stats[tokens.Type(t)])
+	}
+	return out.String()
+}
+
 func renderPreludeEvent(event engine.PreludeEventPayload, opts backend.DisplayOptions) string {
 	out := &bytes.Buffer{}
 
@@ -267,12 +402,21 @@ func renderResourcePreEvent(
 
 	if shouldShow(payload.Metadata, opts) || isRootStack(payload.Metadata) {
 		indent := engine.GetIndent(payload.Metadata, seen)
-		summary := engine.GetResourcePropertiesSummary(payload.Metadata, indent)
+		summary := engine.GetResourcePropertiesSummary(payload.Metadata, indent, opts.Diff)
+
+		// Tally up the property changes as the details are printed, so we can follow them with a compact summary.
+		var stats engine.DiffStats
+		diffOpts := opts.Diff
+		diffOpts.Stats = &stats
 		details := engine.GetResourcePropertiesDetails(
-			payload.Metadata, indent, payload.Planning, opts.SummaryDiff, payload.Debug)
+			payload.Metadata, indent, payload.Planning, opts.SummaryDiff, payload.Debug, diffOpts)
 
 		fprintIgnoreError(out, opts.Color.Colorize(summary))
 		fprintIgnoreError(out, opts.Color.Colorize(details))
+		if stats.Any() {
+			fprintIgnoreError(out, opts.Color.Colorize(fmt.Sprintf("%v%v    (%v)%v\n",
+				colors.SpecUnimportant, strings.Repeat("    ", indent), stats, colors.Reset)))
+		}
 		fprintIgnoreError(out, opts.Color.Colorize(colors.Reset))
 	}
 
@@ -288,7 +432,8 @@ func renderResourceOutputsEvent(
 
 	if shouldShow(payload.Metadata, opts) || isRootStack(payload.Metadata) {
 		indent := engine.GetIndent(payload.Metadata, seen)
-		text := engine.GetResourceOutputsPropertiesString(payload.Metadata, indent+1, payload.Planning, payload.Debug)
+		text := engine.GetResourceOutputsPropertiesString(
+			payload.Metadata, indent+1, payload.Planning, payload.Debug, opts.Diff)
 
 		fprintIgnoreError(out, opts.Color.Colorize(text))
 	}
@@ -322,13 +467,6 @@ func shouldShow(step engine.StepEventMetadata, opts backend.DisplayOptions) bool
 	return true
 }
 
-func plural(s string, c int) string {
-	if c != 1 {
-		s += "s"
-	}
-	return s
-}
-
 func fprintfIgnoreError(w io.Writer, format string, a ...interface{}) {
 	_, err := fmt.Fprintf(w, format, a...)
 	contract.IgnoreError(err)
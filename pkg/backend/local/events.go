@@ -0,0 +1,125 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/backend"
+	"github.com/pulumi/pulumi/pkg/engine"
+	"github.com/pulumi/pulumi/pkg/tokens"
+	"github.com/pulumi/pulumi/pkg/util/contract"
+)
+
+// The events that the engine emits during an update, such as the steps it takes, the diffs of the resources that it
+// changes, and its diagnostics, are recorded in the update's event log, which is saved in the stack's history next to
+// the update's record and the checkpoint it left.  They're kept in the same form as `--json` displays them, so that
+// what an update did can be audited long after it ran.
+
+// updateEventLog collects the events of an update.
+type updateEventLog struct {
+	lock   sync.Mutex
+	events []apitype.EngineEvent
+}
+
+// record adds an event to the log.
+func (l *updateEventLog) record(event engine.Event, opts backend.DisplayOptions) {
+	apiEvent, ok := convertEngineEvent(event, opts)
+	if !ok {
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	apiEvent.Sequence, apiEvent.Timestamp = len(l.events), time.Now().Unix()
+	l.events = append(l.events, apiEvent)
+}
+
+// Events returns the events recorded so far.
+func (l *updateEventLog) Events() []apitype.EngineEvent {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.events
+}
+
+// recordEvents forwards the engine's events to the display, recording each in the log before it is displayed.  Like
+// the display, it stops at the cancellation event that marks the end of the events.
+func recordEvents(events <-chan engine.Event, display chan<- engine.Event, log *updateEventLog,
+	opts backend.DisplayOptions) {
+
+	for event := range events {
+		if event.Type != engine.CancelEvent {
+			log.record(event, opts)
+		}
+		display <- event
+		if event.Type == engine.CancelEvent {
+			return
+		}
+	}
+}
+
+// eventsFile returns the path of the file that holds the events of the update whose record is in the given file.
+func eventsFile(historyFile string) string {
+	return strings.TrimSuffix(historyFile, ".history.json") + ".events.json"
+}
+
+// getUpdateEvents loads the events recorded during the given update to a stack, where updates are numbered from 1
+// (the oldest) as in the stack's history.
+func (b *localBackend) getUpdateEvents(name tokens.QName, version int) ([]apitype.EngineEvent, error) {
+	contract.Require(name != "", "name")
+
+	dir := b.historyDirectory(name)
+	allFiles, err := b.storage.List(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// As in getHistory, files are named such that older updates come first.
+	var updates []string
+	for _, file := range allFiles {
+		if strings.HasSuffix(file.Name, ".history.json") {
+			updates = append(updates, path.Join(dir, file.Name))
+		}
+	}
+	if version < 1 || version > len(updates) {
+		return nil, errors.Errorf("stack '%s' has no update with version %d", name, version)
+	}
+
+	file := eventsFile(updates[version-1])
+	byts, err := b.storage.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Errorf("no events were recorded for update %d of stack '%s'", version, name)
+		}
+		return nil, errors.Wrapf(err, "reading events file %s", b.storage.Describe(file))
+	}
+	if byts, err = b.decodeState(name, byts); err != nil {
+		return nil, err
+	}
+
+	var events []apitype.EngineEvent
+	if err = json.Unmarshal(byts, &events); err != nil {
+		return nil, errors.Wrapf(err, "reading events file %s", b.storage.Describe(file))
+	}
+	return events, nil
+}
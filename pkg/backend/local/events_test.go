@@ -0,0 +1,106 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/backend"
+	"github.com/pulumi/pulumi/pkg/diag"
+	"github.com/pulumi/pulumi/pkg/engine"
+	"github.com/pulumi/pulumi/pkg/tokens"
+)
+
+func stdoutEvent(message string) engine.Event {
+	return engine.Event{Type: engine.StdoutColorEvent, Payload: engine.StdoutEventPayload{Message: message}}
+}
+
+// TestRecordEvents records the events of an update as they're passed on to the display, and checks that they're
+// recorded in the order that they were emitted.
+func TestRecordEvents(t *testing.T) {
+	events, display := make(chan engine.Event), make(chan engine.Event)
+	log := &updateEventLog{}
+	go recordEvents(events, display, log, backend.DisplayOptions{})
+
+	emitted := []engine.Event{
+		stdoutEvent("one"),
+		{Type: engine.DiagEvent, Payload: engine.DiagEventPayload{Message: "debugging", Severity: diag.Debug}},
+		stdoutEvent("two"),
+		{Type: engine.DiagEvent, Payload: engine.DiagEventPayload{Message: "warning", Severity: diag.Warning}},
+		stdoutEvent("three"),
+		{Type: engine.CancelEvent},
+	}
+	go func() {
+		for _, event := range emitted {
+			events <- event
+		}
+	}()
+
+	// Every event is displayed, up to the cancellation that ends them.
+	for _, event := range emitted {
+		assert.Equal(t, event.Type, (<-display).Type)
+	}
+
+	// Debug diagnostics aren't recorded unless they're displayed.
+	recorded := log.Events()
+	if assert.Len(t, recorded, 4) {
+		for i, event := range recorded {
+			assert.Equal(t, i, event.Sequence)
+		}
+		assert.Equal(t, "one", recorded[0].StdoutEvent.Message)
+		assert.Equal(t, "two", recorded[1].StdoutEvent.Message)
+		assert.Equal(t, "warning", recorded[2].DiagnosticEvent.Message)
+		assert.Equal(t, "three", recorded[3].StdoutEvent.Message)
+	}
+}
+
+// TestUpdateEvents saves the events of a stack's updates in its history, and reads those of each update back.
+func TestUpdateEvents(t *testing.T) {
+	b, _ := newConflictTestBackend()
+	name := tokens.QName("dev")
+	_, _, err := b.writeStack(name, nil, nil)
+	assert.NoError(t, err)
+
+	// The second update recorded no events, as updates made before events were recorded didn't.
+	var logs [][]apitype.EngineEvent
+	for i := 0; i < 3; i++ {
+		var events []apitype.EngineEvent
+		if i != 1 {
+			log := &updateEventLog{}
+			for j := 0; j < 3; j++ {
+				log.record(stdoutEvent(fmt.Sprintf("update %d event %d", i+1, j)), backend.DisplayOptions{})
+			}
+			events = log.Events()
+		}
+		logs = append(logs, events)
+		assert.NoError(t, b.addToHistory(name, backend.UpdateInfo{Kind: backend.DeployUpdate}, events))
+	}
+
+	for _, version := range []int{1, 3} {
+		events, err := b.getUpdateEvents(name, version)
+		assert.NoError(t, err)
+		assert.Equal(t, logs[version-1], events, "version %d", version)
+	}
+	_, err = b.getUpdateEvents(name, 2)
+	assert.Error(t, err)
+	_, err = b.getUpdateEvents(name, 4)
+	assert.Error(t, err)
+	_, err = b.getUpdateEvents(name, 0)
+	assert.Error(t, err)
+}
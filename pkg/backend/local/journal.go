@@ -0,0 +1,163 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/tokens"
+	"github.com/pulumi/pulumi/pkg/util/contract"
+	"github.com/pulumi/pulumi/pkg/util/fsutil"
+	"github.com/pulumi/pulumi/pkg/util/logging"
+	"github.com/pulumi/pulumi/pkg/workspace"
+)
+
+// Rather than rewriting a stack's whole checkpoint each time an update changes a resource, the local backend records
+// each change in the stack's journal: a directory of entries, each of which says how a snapshot differs from the one
+// before it.  The state of a stack is its checkpoint with the entries of its journal applied in order.  When the
+// journal grows large, and when an update finishes, it is compacted: the latest snapshot is written as the checkpoint
+// and the journal is removed.
+//
+// A journal is kept in a directory named for the time in the manifest of the checkpoint that it follows, so that the
+// entries kept for an older checkpoint, as when compaction is interrupted, are never applied to a newer one.
+
+// maxJournalEntries is the number of entries after which a journal is compacted, however small they are.
+const maxJournalEntries = 1000
+
+// journalEntry records how a snapshot differs from the snapshot before it.
+type journalEntry struct {
+	// Manifest is the manifest of the snapshot.
+	Manifest apitype.ManifestV1 `json:"manifest"`
+	// Resources are the resources of the snapshot, in order.
+	Resources []journalResources `json:"resources,omitempty"`
+	// PendingOperations are the operations pending in the snapshot.
+	PendingOperations []apitype.OperationV1 `json:"pending_operations,omitempty"`
+}
+
+// journalResources is either a run of resources carried over, unchanged, from the snapshot before, or a resource that
+// is new or has changed.
+type journalResources struct {
+	// From and To are the bounds, [From, To), of a run of resources in the snapshot before.
+	From int `json:"from,omitempty"`
+	To   int `json:"to,omitempty"`
+	// Resource, if it is set, is a resource that is new or has changed, rather than a run.
+	Resource *apitype.ResourceV1 `json:"resource,omitempty"`
+}
+
+// apply returns the resources of the snapshot recorded by the entry, given the resources of the snapshot before it.
+func (e *journalEntry) apply(prev []apitype.ResourceV1) ([]apitype.ResourceV1, error) {
+	var resources []apitype.ResourceV1
+	for _, r := range e.Resources {
+		if r.Resource != nil {
+			resources = append(resources, *r.Resource)
+			continue
+		}
+		if r.From < 0 || r.To <= r.From || r.To > len(prev) {
+			return nil, errors.Errorf("entry refers to resources [%d, %d) of a snapshot with %d", r.From, r.To, len(prev))
+		}
+		resources = append(resources, prev[r.From:r.To]...)
+	}
+	return resources, nil
+}
+
+// resources returns the resources that the entry records in whole, including those with pending operations, so that
+// they can be changed in place.
+func (e *journalEntry) resources() []*apitype.ResourceV1 {
+	var resources []*apitype.ResourceV1
+	for _, r := range e.Resources {
+		if r.Resource != nil {
+			resources = append(resources, r.Resource)
+		}
+	}
+	for i := range e.PendingOperations {
+		resources = append(resources, &e.PendingOperations[i].Resource)
+	}
+	return resources
+}
+
+// journalDirectory returns the directory that holds the journals of a stack.
+func (b *localBackend) journalDirectory(stack tokens.QName) string {
+	contract.Require(stack != "", "stack")
+
+	return filepath.Join(workspace.JournalDir, fsutil.QnamePath(stack))
+}
+
+// journalPath returns the path of an entry of the journal that follows the checkpoint with the given manifest time.
+// Entries are numbered from 1, and their paths sort in the order that they must be applied.
+func (b *localBackend) journalPath(stack tokens.QName, checkpointTime time.Time, entry int) string {
+	return filepath.Join(b.journalDirectory(stack), journalKey(checkpointTime), fmt.Sprintf("%08d.json", entry))
+}
+
+// journalKey returns the name of the directory that holds the journal of the checkpoint with the given manifest time.
+func journalKey(checkpointTime time.Time) string {
+	return strconv.FormatInt(checkpointTime.UnixNano(), 10)
+}
+
+// applyJournal applies the entries of the journal of a stack's checkpoint, if it has any, to the checkpoint.
+func (b *localBackend) applyJournal(name tokens.QName, chk *apitype.CheckpointV1) error {
+	if chk.Latest == nil {
+		return nil
+	}
+
+	dir := filepath.Join(b.journalDirectory(name), journalKey(chk.Latest.Manifest.Time))
+	files, err := b.storage.List(dir)
+	if err != nil {
+		return err
+	}
+
+	for i, file := range files {
+		entryPath := filepath.Join(dir, file.Name)
+		data, err := b.storage.ReadFile(entryPath)
+		if err != nil {
+			return err
+		}
+
+		var entry journalEntry
+		if data, err = b.decodeState(name, data); err == nil {
+			err = json.Unmarshal(data, &entry)
+		} else if _, ok := err.(*encryptedStateError); ok {
+			return err
+		}
+		if err != nil {
+			// The last entry may have been cut short by the update that was writing it dying.  The snapshot that it
+			// recorded was never saved, just as if the update had died before writing it.
+			if i == len(files)-1 {
+				logging.V(7).Infof("Ignoring unreadable last journal entry %s: %v", b.storage.Describe(entryPath), err)
+				break
+			}
+			return errors.Wrapf(err, "reading %s", b.storage.Describe(entryPath))
+		}
+
+		resources, err := entry.apply(chk.Latest.Resources)
+		if err != nil {
+			return errors.Wrapf(err, "applying %s", b.storage.Describe(entryPath))
+		}
+		chk.Latest.Manifest = entry.Manifest
+		chk.Latest.Resources = resources
+		chk.Latest.PendingOperations = entry.PendingOperations
+	}
+
+	if len(files) > 0 {
+		logging.V(7).Infof("Applied %d journal entries to the checkpoint of stack %s", len(files), name)
+	}
+	return nil
+}
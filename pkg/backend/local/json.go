@@ -0,0 +1,165 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/backend"
+	"github.com/pulumi/pulumi/pkg/diag"
+	"github.com/pulumi/pulumi/pkg/diag/colors"
+	"github.com/pulumi/pulumi/pkg/engine"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/util/contract"
+)
+
+// DisplayJSONEvents writes the engine events to stdout as they come in, each as a line of JSON holding an
+// apitype.EngineEvent, so that other programs can follow the progress of an update.
+func DisplayJSONEvents(action string, events <-chan engine.Event, done chan<- bool, opts backend.DisplayOptions) {
+	defer func() {
+		done <- true
+	}()
+
+	enc := json.NewEncoder(os.Stdout)
+	sequence := 0
+	for event := range events {
+		// The engine marks the end of its events with a cancellation event.
+		if event.Type == engine.CancelEvent {
+			return
+		}
+
+		if apiEvent, ok := convertEngineEvent(event, opts); ok {
+			apiEvent.Sequence, apiEvent.Timestamp = sequence, time.Now().Unix()
+			sequence++
+			contract.IgnoreError(enc.Encode(apiEvent))
+		}
+	}
+}
+
+// convertEngineEvent converts an engine event to its JSON form.  It returns false if the event shouldn't be written.
+func convertEngineEvent(event engine.Event, opts backend.DisplayOptions) (apitype.EngineEvent, bool) {
+	var apiEvent apitype.EngineEvent
+	switch event.Type {
+	case engine.StdoutColorEvent:
+		p := event.Payload.(engine.StdoutEventPayload)
+		apiEvent.StdoutEvent = &apitype.StdoutEngineEvent{Message: colors.Never.Colorize(p.Message)}
+	case engine.DiagEvent:
+		p := event.Payload.(engine.DiagEventPayload)
+		if p.Severity == diag.Debug && !opts.Debug {
+			return apiEvent, false
+		}
+		apiEvent.DiagnosticEvent = &apitype.DiagnosticEvent{
+			URN:      string(p.URN),
+			Prefix:   colors.Never.Colorize(p.Prefix),
+			Message:  colors.Never.Colorize(p.Message),
+			Severity: string(p.Severity),
+		}
+	case engine.PreludeEvent:
+		p := event.Payload.(engine.PreludeEventPayload)
+		apiEvent.PreludeEvent = &apitype.PreludeEvent{Config: p.Config}
+	case engine.SummaryEvent:
+		p := event.Payload.(engine.SummaryEventPayload)
+		changes := make(map[apitype.OpType]int)
+		for op, count := range p.ResourceChanges {
+			changes[apitype.OpType(op)] = count
+		}
+		apiEvent.SummaryEvent = &apitype.SummaryEvent{
+			MaybeCorrupt:    p.MaybeCorrupt,
+			DurationSeconds: int(p.Duration.Seconds()),
+			ResourceChanges: changes,
+		}
+	case engine.ResourcePreEvent:
+		p := event.Payload.(engine.ResourcePreEventPayload)
+		apiEvent.ResourcePreEvent = &apitype.ResourcePreEvent{
+			Metadata: convertStepEventMetadata(p.Metadata),
+			Planning: p.Planning,
+		}
+	case engine.ResourceOutputsEvent:
+		p := event.Payload.(engine.ResourceOutputsEventPayload)
+		apiEvent.ResOutputsEvent = &apitype.ResOutputsEvent{
+			Metadata: convertStepEventMetadata(p.Metadata),
+			Planning: p.Planning,
+		}
+	case engine.ResourceOperationFailed:
+		p := event.Payload.(engine.ResourceOperationFailedPayload)
+		apiEvent.ResOpFailedEvent = &apitype.ResOpFailedEvent{
+			Metadata: convertStepEventMetadata(p.Metadata),
+			Status:   int(p.Status),
+			Steps:    p.Steps,
+		}
+	default:
+		contract.Failf("unknown event type '%s'", event.Type)
+	}
+	return apiEvent, true
+}
+
+func convertStepEventMetadata(md engine.StepEventMetadata) apitype.StepEventMetadata {
+	var keys []string
+	for _, k := range md.Keys {
+		keys = append(keys, string(k))
+	}
+
+	var diffs []apitype.PropertyDiff
+	for _, d := range md.DetailedDiff {
+		diffs = append(diffs, apitype.PropertyDiff{
+			Path: d.Path.String(),
+			Kind: string(d.Kind),
+			Old:  mappableValue(d.Old),
+			New:  mappableValue(d.New),
+		})
+	}
+
+	return apitype.StepEventMetadata{
+		Op:           apitype.OpType(md.Op),
+		URN:          string(md.URN),
+		Type:         string(md.Type),
+		Old:          convertStepEventStateMetadata(md.Old),
+		New:          convertStepEventStateMetadata(md.New),
+		Keys:         keys,
+		Logical:      md.Logical,
+		DetailedDiff: diffs,
+	}
+}
+
+func convertStepEventStateMetadata(md *engine.StepEventStateMetadata) *apitype.StepEventStateMetadata {
+	if md == nil {
+		return nil
+	}
+
+	return &apitype.StepEventStateMetadata{
+		Type:     string(md.Type),
+		URN:      string(md.URN),
+		Custom:   md.Custom,
+		Delete:   md.Delete,
+		ID:       string(md.ID),
+		Parent:   string(md.Parent),
+		Protect:  md.Protect,
+		Taint:    md.Taint,
+		External: md.External,
+		Inputs:   md.Inputs.Mappable(),
+		Outputs:  md.Outputs.Mappable(),
+	}
+}
+
+// mappableValue converts a property value to a plain value that can be marshaled, or nil if the value is null.
+func mappableValue(v resource.PropertyValue) interface{} {
+	if v.V == nil {
+		return nil
+	}
+	return v.Mappable()
+}
@@ -0,0 +1,424 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	ps "github.com/mitchellh/go-ps"
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/backend"
+	"github.com/pulumi/pulumi/pkg/diag"
+	"github.com/pulumi/pulumi/pkg/tokens"
+	"github.com/pulumi/pulumi/pkg/util/contract"
+	"github.com/pulumi/pulumi/pkg/util/fsutil"
+	"github.com/pulumi/pulumi/pkg/util/logging"
+	"github.com/pulumi/pulumi/pkg/workspace"
+)
+
+// updateLock is the content of a stack's lock file, which exists for as long as an update of the stack is running.
+type updateLock struct {
+	// Update describes the update holding the lock.
+	Update backend.UpdateInfo `json:"update"`
+	// PID is the ID of the process performing the update.
+	PID int `json:"pid"`
+	// Host is the name of the machine on which the update is running.
+	Host string `json:"host"`
+	// Token is unique to the update holding the lock, so that it can tell whether it still does.
+	Token string `json:"token,omitempty"`
+}
+
+// String describes the update holding the lock.
+func (l *updateLock) String() string {
+	return fmt.Sprintf("a %s started %s by process %d on %s",
+		l.Update.Kind, time.Unix(l.Update.StartTime, 0).Format(time.RFC1123), l.PID, l.Host)
+}
+
+// stackLockedError is returned by lockStack when another update holds the stack's lock.
+type stackLockedError struct {
+	name tokens.QName
+	lock *updateLock // the lock held by the other update, if it could be read.
+}
+
+func (e *stackLockedError) Error() string {
+	if e.lock == nil {
+		return fmt.Sprintf("stack '%s' is locked by another update", e.name)
+	}
+	return fmt.Sprintf("stack '%s' is locked by %s; "+
+		"if that update is no longer running, run `pulumi cancel` to release the lock", e.name, e.lock)
+}
+
+// lockPath returns the path of the file that locks a stack while it is being updated.
+func (b *localBackend) lockPath(stack tokens.QName) string {
+	contract.Require(stack != "", "stack")
+
+	return filepath.Join(workspace.LockDir, fsutil.QnamePath(stack)+".json")
+}
+
+// lockStack takes the lock of a stack for the given update, failing if another update already holds it, unless that
+// update's process is known to have died.  It returns the lock's token.
+func (b *localBackend) lockStack(name tokens.QName, update backend.UpdateInfo) (string, error) {
+	contract.Require(name != "", "name")
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	var token [16]byte
+	if _, err = rand.Read(token[:]); err != nil {
+		return "", err
+	}
+	lock := &updateLock{Update: update, PID: os.Getpid(), Host: host, Token: hex.EncodeToString(token[:])}
+	byts, err := json.MarshalIndent(lock, "", "    ")
+	if err != nil {
+		return "", err
+	}
+
+	// If the lock is held by an update whose process has died, take it over, but only once, so that racing updates
+	// can't keep taking it from each other.
+	for takenOver := false; ; takenOver = true {
+		if ls, ok := b.storage.(leaseStorage); ok {
+			err = b.leaseStackLock(ls, name, byts)
+		} else {
+			// Creating the file exclusively ensures that only one of any racing updates takes the lock.
+			err = b.storage.CreateFile(b.lockPath(name), byts)
+		}
+		if err == nil {
+			return lock.Token, nil
+		}
+		if !os.IsExist(errors.Cause(err)) {
+			return "", errors.Wrap(err, "locking stack")
+		}
+
+		held, lockErr := b.getStackLock(name)
+		if lockErr != nil {
+			held = nil
+		}
+		if takenOver || held == nil || !held.isStale(host) {
+			return "", &stackLockedError{name: name, lock: held}
+		}
+		if err = b.releaseStaleLock(name, held); err != nil {
+			return "", errors.Wrap(err, "releasing stale lock")
+		}
+	}
+}
+
+// lockClaimPath returns the path of the file that claims the stale lock with the given token, for the one update that
+// may release it.
+func (b *localBackend) lockClaimPath(stack tokens.QName, token string) string {
+	contract.Require(stack != "", "stack")
+
+	return filepath.Join(workspace.LockDir, fsutil.QnamePath(stack)+"."+token+".claim")
+}
+
+// lockClaimStaleness is how long a claim on a stale lock may exist before other updates assume that the update that
+// made it died before it could release the lock, and claim the lock themselves.
+const lockClaimStaleness = time.Minute
+
+// releaseStaleLock releases a stale lock, so that it can be taken over.  Racing updates may all find the same stale
+// lock, so only the one that first claims it, by creating a claim file named for the lock's token exclusively, may
+// release it; and it only does so if the lock still has that token, so that it never releases a lock that another
+// update has taken in the meantime.  An update that can't release the lock simply tries to take it again.
+func (b *localBackend) releaseStaleLock(name tokens.QName, held *updateLock) error {
+	claim := b.lockClaimPath(name, held.Token)
+	if claimed, err := b.claimStaleLock(claim); err != nil || !claimed {
+		return err
+	}
+	defer func() {
+		if err := b.storage.Remove(claim); err != nil {
+			logging.V(7).Infof("Failed to remove the claim on the lock of stack '%s': %v", name, err)
+		}
+	}()
+
+	current, err := b.getStackLock(name)
+	if err != nil {
+		return err
+	}
+	if current == nil || current.Token != held.Token {
+		return nil
+	}
+
+	b.d.Warningf(diag.Message("" /*urn*/, "stack '%s' was locked by a %s by process %d, which is no longer "+
+		"running; recording it as cancelled and taking over the lock"), name, held.Update.Kind, held.PID)
+	return b.cancelLockedUpdate(name, held)
+}
+
+// claimStaleLock creates the file claiming a stale lock, returning false if another update has already claimed it.  A
+// claim that has expired, since the update that made it died, is replaced.
+func (b *localBackend) claimStaleLock(claim string) (bool, error) {
+	err := b.storage.CreateFile(claim, nil)
+	if err == nil {
+		return true, nil
+	} else if !os.IsExist(errors.Cause(err)) {
+		return false, err
+	}
+
+	files, err := b.storage.List(filepath.Dir(claim))
+	if err != nil {
+		return false, err
+	}
+	for _, f := range files {
+		if f.Name != filepath.Base(claim) || time.Since(f.ModTime) < lockClaimStaleness {
+			continue
+		}
+		logging.V(7).Infof("Replacing the expired claim %s", b.storage.Describe(claim))
+		if err = b.storage.Remove(claim); err != nil {
+			return false, err
+		}
+		if err = b.storage.CreateFile(claim, nil); err != nil {
+			if os.IsExist(errors.Cause(err)) {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// isStale returns true if the lock was left by an update whose process is known to have died: one that ran on the
+// given host, whose process no longer exists.  The processes of other hosts can't be checked, so their locks are never
+// stale; they must be released with `pulumi cancel`.
+func (l *updateLock) isStale(host string) bool {
+	if l.Host != host || host == "unknown" || l.PID == 0 {
+		return false
+	}
+	proc, err := ps.FindProcess(l.PID)
+	return err == nil && proc == nil
+}
+
+// cancelLockedUpdate records the update holding a stack's lock as cancelled in the stack's history, and releases the
+// lock.  Since the operations that the update had begun are recorded in the stack's checkpoint, the next update
+// reconciles them.
+func (b *localBackend) cancelLockedUpdate(name tokens.QName, lock *updateLock) error {
+	info := lock.Update
+	info.Result = backend.CancelledResult
+	info.EndTime = time.Now().Unix()
+	if err := b.addToHistory(name, info, nil); err != nil {
+		return errors.Wrap(err, "saving update info")
+	}
+	return b.unlockStack(name)
+}
+
+// lockLeaseDuration is how long the lease on a stack's lock lasts, in storages that support leases, unless it is
+// renewed.  The update holding the lock renews it three times as often, so that it isn't lost to a slow request.
+const lockLeaseDuration = time.Minute
+
+// stackLease is a lease held on a stack's lock.
+type stackLease struct {
+	id   string
+	stop chan bool // closed to stop renewing the lease.
+}
+
+// leaseStackLock writes a stack's lock file, and takes a lease on it that is renewed until the stack is unlocked.
+func (b *localBackend) leaseStackLock(ls leaseStorage, name tokens.QName, data []byte) error {
+	file := b.lockPath(name)
+	id, err := ls.AcquireLease(file, data, lockLeaseDuration)
+	if err != nil {
+		return err
+	}
+
+	lease := &stackLease{id: id, stop: make(chan bool)}
+	go func() {
+		ticker := time.NewTicker(lockLeaseDuration / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-lease.stop:
+				return
+			case <-ticker.C:
+				if err := ls.RenewLease(file, id); err != nil {
+					logging.V(7).Infof("Failed to renew the lease on the lock of stack '%s': %v", name, err)
+				}
+			}
+		}
+	}()
+
+	b.leasesLock.Lock()
+	defer b.leasesLock.Unlock()
+	if b.leases == nil {
+		b.leases = make(map[tokens.QName]*stackLease)
+	}
+	b.leases[name] = lease
+	return nil
+}
+
+// queuePollInterval is how often an update waiting in a stack's queue checks whether it may take the stack's lock.
+const queuePollInterval = 2 * time.Second
+
+// queueEntryStaleness is how long an update's entry in a stack's queue may go without being refreshed before other
+// updates assume that its process has died, and stop waiting behind it.
+const queueEntryStaleness = time.Minute
+
+// queuePath returns the path of the directory that holds an entry for each update waiting for a stack's lock.
+func (b *localBackend) queuePath(stack tokens.QName) string {
+	contract.Require(stack != "", "stack")
+
+	return filepath.Join(workspace.LockDir, fsutil.QnamePath(stack)+".queue")
+}
+
+// lockStackQueued takes the lock of a stack for the given update like lockStack, but if another update holds the lock,
+// waits up to the given timeout for it, in line behind any other updates already waiting.  The update's position in
+// the queue is reported whenever it changes.  It returns the lock's token.
+func (b *localBackend) lockStackQueued(name tokens.QName, update backend.UpdateInfo,
+	timeout time.Duration) (string, error) {
+	dir := b.queuePath(name)
+	// Entries are named so that they sort in the order in which their updates joined the queue.
+	entry := fmt.Sprintf("%020d-%d.json", time.Now().UnixNano(), os.Getpid())
+	file := filepath.Join(dir, entry)
+	if err := b.storage.WriteFile(file, []byte("{}")); err != nil {
+		return "", errors.Wrap(err, "joining stack queue")
+	}
+	defer func() {
+		contract.IgnoreError(b.storage.Remove(file))
+	}()
+
+	deadline := time.Now().Add(timeout)
+	lastPosition := -1
+	for {
+		position, err := b.queuePosition(dir, entry)
+		if err != nil {
+			return "", errors.Wrap(err, "reading stack queue")
+		}
+
+		var lockErr error
+		if position == 0 {
+			var token string
+			if token, lockErr = b.lockStack(name, update); lockErr == nil {
+				return token, nil
+			} else if _, locked := lockErr.(*stackLockedError); !locked {
+				return "", lockErr
+			}
+		}
+
+		if time.Now().After(deadline) {
+			if lockErr == nil {
+				return "", errors.Errorf("timed out after %v waiting for stack '%s' to be unlocked, with %d other "+
+					"update(s) still ahead in the queue", timeout, name, position)
+			}
+			return "", errors.Wrapf(lockErr, "timed out after %v waiting in the queue", timeout)
+		}
+		if position != lastPosition {
+			fmt.Printf("Waiting for another update of stack '%s' to finish (position %d in the queue)...\n",
+				name, position+1)
+			lastPosition = position
+		}
+
+		time.Sleep(queuePollInterval)
+
+		// Refresh the entry, so that other updates know that this one is still waiting.
+		if err = b.storage.WriteFile(file, []byte("{}")); err != nil {
+			return "", errors.Wrap(err, "refreshing stack queue entry")
+		}
+	}
+}
+
+// queuePosition returns the number of updates ahead of the one with the given entry in a stack's queue, ignoring any
+// entries that have gone stale.
+func (b *localBackend) queuePosition(dir string, entry string) (int, error) {
+	files, err := b.storage.List(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	// List sorts the entries by name, and so in the order in which they joined the queue.
+	position := 0
+	for _, f := range files {
+		if f.Name == entry {
+			return position, nil
+		}
+		if time.Since(f.ModTime) < queueEntryStaleness {
+			position++
+		}
+	}
+	return 0, errors.Errorf("entry %s is missing from the stack queue", entry)
+}
+
+// unlockStack releases the lock of a stack.
+func (b *localBackend) unlockStack(name tokens.QName) error {
+	ls, ok := b.storage.(leaseStorage)
+	if !ok {
+		return b.storage.Remove(b.lockPath(name))
+	}
+
+	// Release the lease if this process holds it, and otherwise break the lease held by another.
+	b.leasesLock.Lock()
+	lease := b.leases[name]
+	delete(b.leases, name)
+	b.leasesLock.Unlock()
+
+	var id string
+	if lease != nil {
+		close(lease.stop)
+		id = lease.id
+	}
+	return ls.ReleaseLease(b.lockPath(name), id)
+}
+
+// getStackLock returns the lock held on a stack, or nil if the stack isn't locked.
+func (b *localBackend) getStackLock(name tokens.QName) (*updateLock, error) {
+	byts, err := b.storage.ReadFile(b.lockPath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// A lock whose lease has expired was left by an update whose process died, and no longer holds the stack.
+	if ls, ok := b.storage.(leaseStorage); ok {
+		if leased, err := ls.IsLeased(b.lockPath(name)); err != nil {
+			return nil, err
+		} else if !leased {
+			return nil, nil
+		}
+	}
+
+	var lock updateLock
+	if err = json.Unmarshal(byts, &lock); err != nil {
+		return nil, errors.Wrapf(err, "reading lock file %s", b.storage.Describe(b.lockPath(name)))
+	}
+	return &lock, nil
+}
+
+// localUpdateLease is an update's lease on a stack, which it holds for as long as the stack's lock has its token.
+type localUpdateLease struct {
+	backend *localBackend
+	name    tokens.QName
+	token   string
+}
+
+var _ backend.UpdateLease = (*localUpdateLease)(nil)
+
+func (l *localUpdateLease) Check() error {
+	lock, err := l.backend.getStackLock(l.name)
+	switch {
+	case err != nil:
+		return errors.Wrap(err, "checking the stack's lock")
+	case lock == nil:
+		return &backend.ConcurrentUpdateError{Stack: l.name, Conflict: "its lock was released, as by `pulumi cancel`"}
+	case lock.Token != l.token:
+		return &backend.ConcurrentUpdateError{Stack: l.name, Conflict: fmt.Sprintf("it is now locked by %s", lock)}
+	default:
+		return nil
+	}
+}
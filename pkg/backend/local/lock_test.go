@@ -0,0 +1,201 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/backend"
+	"github.com/pulumi/pulumi/pkg/diag"
+	"github.com/pulumi/pulumi/pkg/diag/colors"
+	"github.com/pulumi/pulumi/pkg/tokens"
+	"github.com/pulumi/pulumi/pkg/workspace"
+)
+
+// deadPID is the ID of a process that can't exist, since it's beyond the largest that any platform hands out.
+const deadPID = 1 << 30
+
+// newLockTestBackend returns a backend over an in-memory storage, holding the checkpoint of a stack, whose update
+// history records any lock that's taken over.
+func newLockTestBackend(t *testing.T, name tokens.QName) (*localBackend, *memStorage) {
+	s := newMemStorage()
+	b := &localBackend{
+		d:       diag.DefaultSink(ioutil.Discard, ioutil.Discard, diag.FormatOptions{Color: colors.Never}),
+		url:     "mem://",
+		storage: s,
+	}
+	assert.NoError(t, s.WriteFile(b.stackPath(name), []byte("{}")))
+	return b, s
+}
+
+// holdLock writes a stack's lock as though it were held by the given process.
+func holdLock(t *testing.T, b *localBackend, name tokens.QName, pid int, host string, token string) {
+	byts, err := json.Marshal(&updateLock{
+		Update: backend.UpdateInfo{Kind: backend.DeployUpdate, StartTime: time.Now().Unix()},
+		PID:    pid,
+		Host:   host,
+		Token:  token,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, b.storage.WriteFile(b.lockPath(name), byts))
+}
+
+func TestLockIsStale(t *testing.T) {
+	host, err := os.Hostname()
+	assert.NoError(t, err)
+
+	assert.True(t, (&updateLock{PID: deadPID, Host: host}).isStale(host))
+	assert.False(t, (&updateLock{PID: os.Getpid(), Host: host}).isStale(host))
+
+	// The processes of other hosts, or of unknown ones, can't be checked.
+	assert.False(t, (&updateLock{PID: deadPID, Host: "elsewhere"}).isStale(host))
+	assert.False(t, (&updateLock{PID: deadPID, Host: "unknown"}).isStale("unknown"))
+	assert.False(t, (&updateLock{Host: host}).isStale(host))
+}
+
+// TestLockTakeover takes over the lock left by an update whose process has died, recording that update as cancelled.
+func TestLockTakeover(t *testing.T) {
+	name := tokens.QName("dev")
+	b, _ := newLockTestBackend(t, name)
+	host, err := os.Hostname()
+	assert.NoError(t, err)
+	holdLock(t, b, name, deadPID, host, "dead")
+
+	token, err := b.lockStack(name, backend.UpdateInfo{Kind: backend.DeployUpdate})
+	assert.NoError(t, err)
+	lock, err := b.getStackLock(name)
+	assert.NoError(t, err)
+	assert.Equal(t, token, lock.Token)
+	assert.Equal(t, os.Getpid(), lock.PID)
+
+	history, err := b.getHistory(name)
+	assert.NoError(t, err)
+	if assert.Len(t, history, 1) {
+		assert.Equal(t, backend.CancelledResult, history[0].Result)
+	}
+
+	// The claim on the dead update's lock is gone.
+	files, err := b.storage.List(workspace.LockDir)
+	assert.NoError(t, err)
+	for _, f := range files {
+		assert.NotContains(t, f.Name, ".claim")
+	}
+}
+
+// TestLockLiveRefused refuses to take the lock of a stack from an update that may still be running: one in this
+// process, or on another host.  Only releasing the lock, as `pulumi cancel` does, lets another update take it.
+func TestLockLiveRefused(t *testing.T) {
+	name := tokens.QName("dev")
+	b, _ := newLockTestBackend(t, name)
+	host, err := os.Hostname()
+	assert.NoError(t, err)
+
+	for _, holder := range []struct {
+		pid  int
+		host string
+	}{{os.Getpid(), host}, {deadPID, "elsewhere"}} {
+		holdLock(t, b, name, holder.pid, holder.host, "live")
+		_, err = b.lockStack(name, backend.UpdateInfo{Kind: backend.DeployUpdate})
+		if assert.IsType(t, &stackLockedError{}, err) {
+			assert.Equal(t, "live", err.(*stackLockedError).lock.Token)
+		}
+		lock, lockErr := b.getStackLock(name)
+		assert.NoError(t, lockErr)
+		assert.Equal(t, "live", lock.Token)
+	}
+
+	history, err := b.getHistory(name)
+	assert.NoError(t, err)
+	assert.Len(t, history, 0)
+
+	assert.NoError(t, b.unlockStack(name))
+	_, err = b.lockStack(name, backend.UpdateInfo{Kind: backend.DeployUpdate})
+	assert.NoError(t, err)
+}
+
+// TestLockClaimed leaves a stale lock alone while another update's claim on it is fresh, and takes it over once the
+// claim has expired.
+func TestLockClaimed(t *testing.T) {
+	name := tokens.QName("dev")
+	b, s := newLockTestBackend(t, name)
+	host, err := os.Hostname()
+	assert.NoError(t, err)
+	holdLock(t, b, name, deadPID, host, "dead")
+	claim := b.lockClaimPath(name, "dead")
+	assert.NoError(t, s.CreateFile(claim, nil))
+
+	_, err = b.lockStack(name, backend.UpdateInfo{Kind: backend.DeployUpdate})
+	assert.IsType(t, &stackLockedError{}, err)
+	lock, err := b.getStackLock(name)
+	assert.NoError(t, err)
+	assert.Equal(t, "dead", lock.Token)
+
+	s.lock.Lock()
+	f := s.files[s.key(claim)]
+	f.modTime = time.Now().Add(-2 * lockClaimStaleness)
+	s.files[s.key(claim)] = f
+	s.lock.Unlock()
+
+	_, err = b.lockStack(name, backend.UpdateInfo{Kind: backend.DeployUpdate})
+	assert.NoError(t, err)
+	exists, err := s.Exists(claim)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+// TestLockTakeoverRace races several updates to take over the same stale lock: exactly one of them may take it, and
+// the dead update is recorded as cancelled only once.
+func TestLockTakeoverRace(t *testing.T) {
+	name := tokens.QName("dev")
+	b, _ := newLockTestBackend(t, name)
+	host, err := os.Hostname()
+	assert.NoError(t, err)
+	holdLock(t, b, name, deadPID, host, "dead")
+
+	const racers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, racers)
+	for i := 0; i < racers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := b.lockStack(name, backend.UpdateInfo{Kind: backend.DeployUpdate})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	taken := 0
+	for err := range errs {
+		if err == nil {
+			taken++
+		} else {
+			assert.IsType(t, &stackLockedError{}, err)
+		}
+	}
+	assert.Equal(t, 1, taken)
+
+	history, err := b.getHistory(name)
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+}
@@ -0,0 +1,117 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/backend"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/config"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/tokens"
+)
+
+// newMigrationTestBackend returns a backend holding a stack that has been updated the given number of times, each
+// update adding a resource that depends on the one before it.
+func newMigrationTestBackend(t *testing.T, name tokens.QName, updates int) *localBackend {
+	b, _ := newConflictTestBackend()
+	start := time.Date(2018, 8, 1, 12, 0, 0, 0, time.UTC)
+
+	var resources []*resource.State
+	for i := 0; i < updates; i++ {
+		urn := resource.NewURN(name, "proj", "", "test:index:Res", tokens.QName(string(rune('a'+i))))
+		var deps []resource.URN
+		if i > 0 {
+			deps = []resource.URN{resources[i-1].URN}
+		}
+		resources = append(resources, resource.NewState("test:index:Res", urn, true, false, resource.ID(urn.Name()),
+			resource.PropertyMap{"index": resource.NewNumberProperty(float64(i))},
+			resource.PropertyMap{
+				"index": resource.NewNumberProperty(float64(i)),
+				"id":    resource.NewStringProperty("x"),
+			},
+			"", false, deps))
+
+		cfg := config.Map{config.MustMakeKey("proj", "update"): config.NewValue(string(rune('a' + i)))}
+		_, _, err := b.writeStack(name, cfg, deploy.NewSnapshot(deploy.Manifest{}, resources))
+		assert.NoError(t, err)
+		assert.NoError(t, b.addToHistory(name, backend.UpdateInfo{
+			Kind:        backend.DeployUpdate,
+			StartTime:   start.Add(time.Duration(i) * time.Hour).Unix(),
+			EndTime:     start.Add(time.Duration(i)*time.Hour + time.Minute).Unix(),
+			Result:      backend.SucceededResult,
+			Config:      cfg,
+			Environment: map[string]string{"update": string(rune('a' + i))},
+		}, nil))
+	}
+	return b
+}
+
+// TestMigrateRoundTrip copies a stack's deployment and history to another backend, as `pulumi stack migrate` does,
+// and checks that what the other backend exports is exactly what was copied.
+func TestMigrateRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	name := tokens.QName("dev")
+	ref := localBackendReference{name: name}
+	src := newMigrationTestBackend(t, name, 3)
+	dst, _ := newConflictTestBackend()
+	_, _, err := dst.writeStack(name, nil, nil)
+	assert.NoError(t, err)
+
+	deployment, err := src.ExportDeployment(ctx, ref)
+	assert.NoError(t, err)
+	assert.NoError(t, dst.ImportDeployment(ctx, ref, deployment))
+	copied, err := dst.ExportDeployment(ctx, ref)
+	assert.NoError(t, err)
+	assert.Equal(t, deployment.Version, copied.Version)
+	assert.Equal(t, string(deployment.Deployment), string(copied.Deployment))
+
+	history, err := src.GetHistory(ctx, ref)
+	assert.NoError(t, err)
+	assert.Len(t, history, 3)
+	deployments := make([]*apitype.UntypedDeployment, len(history))
+	for i := range history {
+		deployments[i], err = src.ExportDeploymentVersion(ctx, ref, len(history)-i)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, dst.ImportHistory(ctx, ref, history, deployments))
+
+	copiedHistory, err := dst.GetHistory(ctx, ref)
+	assert.NoError(t, err)
+	assert.Equal(t, history, copiedHistory)
+	for i := range history {
+		version := len(history) - i
+		copied, err := dst.ExportDeploymentVersion(ctx, ref, version)
+		assert.NoError(t, err)
+		assert.Equal(t, string(deployments[i].Deployment), string(copied.Deployment), "version %d", version)
+	}
+
+	// The checkpoint saved with each update has that update's configuration.
+	for i := range history {
+		version := len(history) - i
+		chk, err := dst.getHistoryCheckpoint(name, version)
+		assert.NoError(t, err)
+		assert.Equal(t, history[i].Config, chk.Config, "version %d", version)
+	}
+
+	// History can only be copied to a stack that has none.
+	assert.Error(t, dst.ImportHistory(ctx, ref, history, deployments))
+}
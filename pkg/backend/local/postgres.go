@@ -0,0 +1,332 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/lib/pq" // register the PostgreSQL driver.
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/util/contract"
+)
+
+const (
+	// postgresBackendURLPrefix and postgresqlBackendURLPrefix are the URL schemes that select a backend that keeps
+	// its state in a PostgreSQL database.
+	postgresBackendURLPrefix   = "postgres://"
+	postgresqlBackendURLPrefix = "postgresql://"
+
+	// postgresDefaultTable is the name of the table in which state is kept, unless another is given.
+	postgresDefaultTable = "pulumi_state"
+
+	// postgresLockClass is the first key of the advisory locks that lock stacks, which distinguishes them from any
+	// other advisory locks taken on the database; the second is a hash of the path of the stack's lock file.
+	postgresLockClass = 0x50554c55
+)
+
+// postgresTableName matches the table names that may be given in a backend's URL.
+var postgresTableName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)?$`)
+
+// postgresStorage is a storage in a PostgreSQL table, which holds a row for each of the storage's files, keyed by
+// its path, with its JSON contents as a jsonb column, so that the state of stacks can be queried.  For example:
+//
+//	SELECT r->>'urn' FROM pulumi_state, jsonb_array_elements(data->'checkpoint'->'latest'->'resources') r
+//	    WHERE path = 'stacks/dev.json';
+//
+// Moves and removals of directories are transactional.  Stacks are locked with session-level advisory locks, which
+// are held by a connection for as long as an update runs, so that the lock of an update whose process dies is
+// released along with its connection.
+type postgresStorage struct {
+	db    *sql.DB
+	table string
+	desc  string // the URL of the database without its credentials, for messages.
+
+	leasesLock sync.Mutex
+	leases     map[string]*sql.Conn // the connections holding the advisory locks taken by this process.
+}
+
+// newPostgresStorage returns the storage selected by a URL of the form `postgres://user@host:port/database`.  The URL
+// may have any of the parameters accepted by the lib/pq driver, such as `sslmode`, along with the `table` in which to
+// keep state, which is created if it doesn't exist.  The password is best given by the PGPASSWORD environment
+// variable rather than in the URL, which is stored in the credentials file.
+func newPostgresStorage(storageURL string) (*postgresStorage, error) {
+	u, err := url.Parse(storageURL)
+	if err != nil {
+		return nil, errors.Errorf("could not parse the database URL")
+	}
+
+	query := u.Query()
+	table := query.Get("table")
+	if table == "" {
+		table = postgresDefaultTable
+	} else if !postgresTableName.MatchString(table) {
+		return nil, errors.Errorf("'%s' is not a valid table name", table)
+	}
+	query.Del("table")
+	u.RawQuery = query.Encode()
+
+	db, err := sql.Open("postgres", u.String())
+	if err != nil {
+		return nil, errors.Wrap(err, "opening database")
+	}
+
+	u.User, u.RawQuery = nil, ""
+	s := &postgresStorage{db: db, table: table, desc: u.String()}
+	if _, err = db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		path text PRIMARY KEY,
+		data jsonb NOT NULL,
+		modified timestamptz NOT NULL DEFAULT now()
+	)`, table)); err != nil {
+		contract.IgnoreClose(db)
+		return nil, errors.Wrapf(err, "creating table %s in %s", table, s.desc)
+	}
+	return s, nil
+}
+
+// key returns the key of the row for the file at the given path.
+func (s *postgresStorage) key(p string) string {
+	return filepath.ToSlash(p)
+}
+
+func (s *postgresStorage) ReadFile(p string) ([]byte, error) {
+	var data string
+	err := s.db.QueryRow(fmt.Sprintf(`SELECT data::text FROM %s WHERE path = $1`, s.table), s.key(p)).Scan(&data)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, &os.PathError{Op: "read", Path: s.Describe(p), Err: os.ErrNotExist}
+	case err != nil:
+		return nil, errors.Wrapf(err, "reading %s", s.Describe(p))
+	default:
+		return []byte(data), nil
+	}
+}
+
+func (s *postgresStorage) WriteFile(p string, data []byte) error {
+	if _, err := s.db.Exec(fmt.Sprintf(`INSERT INTO %s (path, data) VALUES ($1, $2)
+		ON CONFLICT (path) DO UPDATE SET data = excluded.data, modified = now()`, s.table),
+		s.key(p), string(data)); err != nil {
+		return errors.Wrapf(err, "writing %s", s.Describe(p))
+	}
+	return nil
+}
+
+func (s *postgresStorage) CreateFile(p string, data []byte) error {
+	res, err := s.db.Exec(fmt.Sprintf(`INSERT INTO %s (path, data) VALUES ($1, $2)
+		ON CONFLICT (path) DO NOTHING`, s.table), s.key(p), string(data))
+	if err != nil {
+		return errors.Wrapf(err, "creating %s", s.Describe(p))
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return &os.PathError{Op: "create", Path: s.Describe(p), Err: os.ErrExist}
+	}
+	return nil
+}
+
+func (s *postgresStorage) Exists(p string) (bool, error) {
+	var exists bool
+	if err := s.db.QueryRow(fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE path = $1)`, s.table),
+		s.key(p)).Scan(&exists); err != nil {
+		return false, errors.Wrapf(err, "reading %s", s.Describe(p))
+	}
+	return exists, nil
+}
+
+func (s *postgresStorage) Remove(p string) error {
+	if _, err := s.db.Exec(fmt.Sprintf(`DELETE FROM %s WHERE path = $1`, s.table), s.key(p)); err != nil {
+		return errors.Wrapf(err, "removing %s", s.Describe(p))
+	}
+	return nil
+}
+
+func (s *postgresStorage) RemoveAll(p string) error {
+	// Paths are compared with left() rather than LIKE, since they may hold LIKE's wildcards.
+	if _, err := s.db.Exec(fmt.Sprintf(`DELETE FROM %s WHERE path = $1 OR left(path, length($2)) = $2`, s.table),
+		s.key(p), s.key(p)+"/"); err != nil {
+		return errors.Wrapf(err, "removing %s", s.Describe(p))
+	}
+	return nil
+}
+
+func (s *postgresStorage) Rename(from, to string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	err = func() error {
+		// Rows already at the new paths are replaced, as files are by a rename on disk.
+		selected := `path = $1 OR left(path, length($1) + 1) = $1 || '/'`
+		if _, err := tx.Exec(fmt.Sprintf(`DELETE FROM %[1]s WHERE path IN
+			(SELECT $2 || substr(path, length($1) + 1) FROM %[1]s WHERE %[2]s)`, s.table, selected),
+			s.key(from), s.key(to)); err != nil {
+			return err
+		}
+		res, err := tx.Exec(fmt.Sprintf(`UPDATE %s SET path = $2 || substr(path, length($1) + 1), modified = now()
+			WHERE %s`, s.table, selected), s.key(from), s.key(to))
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return &os.PathError{Op: "rename", Path: s.Describe(from), Err: os.ErrNotExist}
+		}
+		return nil
+	}()
+	if err != nil {
+		contract.IgnoreError(tx.Rollback())
+		if os.IsNotExist(err) {
+			return err
+		}
+		return errors.Wrapf(err, "moving %s", s.Describe(from))
+	}
+	return tx.Commit()
+}
+
+func (s *postgresStorage) List(dir string) ([]storageFile, error) {
+	prefix := s.key(dir) + "/"
+	rows, err := s.db.Query(fmt.Sprintf(`SELECT path, modified FROM %s
+		WHERE left(path, length($1)) = $1 AND strpos(substr(path, length($1) + 1), '/') = 0
+		ORDER BY path`, s.table), prefix)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing %s", s.Describe(dir))
+	}
+	defer contract.IgnoreClose(rows)
+
+	var files []storageFile
+	for rows.Next() {
+		var file storageFile
+		if err = rows.Scan(&file.Name, &file.ModTime); err != nil {
+			return nil, err
+		}
+		file.Name = strings.TrimPrefix(file.Name, prefix)
+		files = append(files, file)
+	}
+	return files, rows.Err()
+}
+
+func (s *postgresStorage) Describe(p string) string {
+	return fmt.Sprintf("%s (%s: %s)", s.desc, s.table, s.key(p))
+}
+
+// jsonOnly marks postgresStorage as a jsonStorage, since files are kept in a jsonb column.
+func (s *postgresStorage) jsonOnly() {}
+
+// lockKey returns the second key of the advisory lock on the file at the given path.
+func (s *postgresStorage) lockKey(p string) int32 {
+	h := fnv.New32a()
+	_, err := h.Write([]byte(s.table + ":" + s.key(p)))
+	contract.IgnoreError(err)
+	return int32(h.Sum32())
+}
+
+// AcquireLease takes an advisory lock for the file on a connection of its own, which holds it until the lease is
+// released or the process exits.  Advisory locks don't expire, so the duration is ignored.
+func (s *postgresStorage) AcquireLease(p string, data []byte, duration time.Duration) (string, error) {
+	ctx := context.Background()
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return "", errors.Wrapf(err, "connecting to %s", s.desc)
+	}
+
+	var locked bool
+	if err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1, $2)`,
+		postgresLockClass, s.lockKey(p)).Scan(&locked); err != nil {
+		contract.IgnoreClose(conn)
+		return "", errors.Wrapf(err, "locking %s", s.Describe(p))
+	}
+	if !locked {
+		contract.IgnoreClose(conn)
+		return "", &os.PathError{Op: "lock", Path: s.Describe(p), Err: os.ErrExist}
+	}
+	if err = s.WriteFile(p, data); err != nil {
+		contract.IgnoreClose(conn)
+		return "", err
+	}
+
+	leaseID := fmt.Sprintf("%d-%d", os.Getpid(), time.Now().UnixNano())
+	s.leasesLock.Lock()
+	defer s.leasesLock.Unlock()
+	if s.leases == nil {
+		s.leases = make(map[string]*sql.Conn)
+	}
+	s.leases[leaseID] = conn
+	return leaseID, nil
+}
+
+// RenewLease checks that the connection holding the advisory lock is still open; the lock itself needs no renewal.
+func (s *postgresStorage) RenewLease(p string, leaseID string) error {
+	s.leasesLock.Lock()
+	conn := s.leases[leaseID]
+	s.leasesLock.Unlock()
+	if conn == nil {
+		return errors.Errorf("no lease %s is held on %s", leaseID, s.Describe(p))
+	}
+	return conn.PingContext(context.Background())
+}
+
+// ReleaseLease removes the file and releases the advisory lock on it.  An advisory lock can only be released by the
+// session that holds it, so to break the lock of another process, that process's session is terminated, which
+// requires the privileges to do so.
+func (s *postgresStorage) ReleaseLease(p string, leaseID string) error {
+	if leaseID == "" {
+		if _, err := s.db.Exec(`SELECT pg_terminate_backend(pid) FROM pg_locks
+			WHERE locktype = 'advisory' AND granted AND classid::bigint = $1 AND objid::bigint = $2 AND objsubid = 2`,
+			postgresLockClass, int64(uint32(s.lockKey(p)))); err != nil {
+			return errors.Wrapf(err, "breaking the lock on %s", s.Describe(p))
+		}
+		return s.Remove(p)
+	}
+
+	s.leasesLock.Lock()
+	conn := s.leases[leaseID]
+	delete(s.leases, leaseID)
+	s.leasesLock.Unlock()
+	if conn == nil {
+		return errors.Errorf("no lease %s is held on %s", leaseID, s.Describe(p))
+	}
+	defer contract.IgnoreClose(conn)
+
+	if err := s.Remove(p); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1, $2)`,
+		postgresLockClass, s.lockKey(p)); err != nil {
+		return errors.Wrapf(err, "unlocking %s", s.Describe(p))
+	}
+	return nil
+}
+
+func (s *postgresStorage) IsLeased(p string) (bool, error) {
+	var leased bool
+	if err := s.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM pg_locks
+		WHERE locktype = 'advisory' AND granted AND classid::bigint = $1 AND objid::bigint = $2 AND objsubid = 2)`,
+		postgresLockClass, int64(uint32(s.lockKey(p)))).Scan(&leased); err != nil {
+		return false, errors.Wrapf(err, "reading the lock on %s", s.Describe(p))
+	}
+	return leased, nil
+}
@@ -0,0 +1,160 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/util/contract"
+)
+
+// postgresTestURLEnvVar is the environment variable holding the URL of a database in which the PostgreSQL storage is
+// tested, such as `postgres://postgres@localhost/postgres?sslmode=disable`.  The tests that need one are skipped if
+// it's unset.
+const postgresTestURLEnvVar = "PULUMI_TEST_POSTGRES_URL"
+
+// newPostgresTestStorages returns storages over a new table in the test database, each with a pool of connections of
+// its own, as if they were used by different processes.  The table is dropped by the returned function.
+func newPostgresTestStorages(t *testing.T, count int) ([]*postgresStorage, func()) {
+	dbURL := os.Getenv(postgresTestURLEnvVar)
+	if dbURL == "" {
+		t.Skipf("Skipping; %s is not set", postgresTestURLEnvVar)
+	}
+	u, err := url.Parse(dbURL)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	query := u.Query()
+	query.Set("table", fmt.Sprintf("pulumi_test_%d", time.Now().UnixNano()))
+	u.RawQuery = query.Encode()
+
+	var storages []*postgresStorage
+	for i := 0; i < count; i++ {
+		s, err := newPostgresStorage(u.String())
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		storages = append(storages, s)
+	}
+	return storages, func() {
+		_, err := storages[0].db.Exec(fmt.Sprintf(`DROP TABLE %s`, storages[0].table))
+		assert.NoError(t, err)
+		for _, s := range storages {
+			contract.IgnoreClose(s.db)
+		}
+	}
+}
+
+func TestPostgresTableName(t *testing.T) {
+	// The table's name is checked before the database is opened.
+	_, err := newPostgresStorage("postgres://localhost/pulumi?table=state;drop")
+	assert.Error(t, err)
+	_, err = newPostgresStorage("postgres://localhost/pulumi?table=1state")
+	assert.Error(t, err)
+}
+
+func TestPostgresReadWrite(t *testing.T) {
+	storages, cleanup := newPostgresTestStorages(t, 1)
+	defer cleanup()
+	s := storages[0]
+
+	// A missing row is a missing file.
+	_, err := s.ReadFile("stacks/dev.json")
+	assert.True(t, os.IsNotExist(err), "expected a missing file, got %v", err)
+	exists, err := s.Exists("stacks/dev.json")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	err = s.Rename("stacks/dev.json", "stacks/prod.json")
+	assert.True(t, os.IsNotExist(err), "expected a missing file, got %v", err)
+
+	assert.NoError(t, s.CreateFile("stacks/dev.json", []byte(`{"version":1}`)))
+	assert.True(t, os.IsExist(s.CreateFile("stacks/dev.json", []byte(`{"version":2}`))))
+	assert.NoError(t, s.WriteFile("stacks/dev.json", []byte(`{"version":3}`)))
+	data, err := s.ReadFile("stacks/dev.json")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"version":3}`, string(data))
+
+	// Only the directory's own files are listed, and a directory is moved with everything below it.
+	assert.NoError(t, s.WriteFile("history/dev/dev-1.json", []byte(`{}`)))
+	assert.NoError(t, s.WriteFile("history/dev/nested/dev-1.json", []byte(`{}`)))
+	assert.NoError(t, s.WriteFile("history/dev2/dev2-1.json", []byte(`{}`)))
+	files, err := s.List("history/dev")
+	assert.NoError(t, err)
+	if assert.Len(t, files, 1) {
+		assert.Equal(t, "dev-1.json", files[0].Name)
+	}
+	assert.NoError(t, s.Rename("history/dev", "history/prod"))
+	for p, expected := range map[string]bool{
+		"history/dev/dev-1.json":         false,
+		"history/prod/dev-1.json":        true,
+		"history/prod/nested/dev-1.json": true,
+		"history/dev2/dev2-1.json":       true,
+	} {
+		exists, err := s.Exists(p)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, exists, p)
+	}
+}
+
+// TestPostgresLease locks a file with an advisory lock, which another process can't take until it's released, or
+// until it's broken by terminating the session that holds it.
+func TestPostgresLease(t *testing.T) {
+	storages, cleanup := newPostgresTestStorages(t, 2)
+	defer cleanup()
+	ours, theirs := storages[0], storages[1]
+	const lock = "locks/dev.json"
+
+	leaseID, err := ours.AcquireLease(lock, []byte(`{"pid":1}`), time.Minute)
+	assert.NoError(t, err)
+	_, err = theirs.AcquireLease(lock, []byte(`{"pid":2}`), time.Minute)
+	assert.True(t, os.IsExist(err), "expected a held lock, got %v", err)
+	leased, err := theirs.IsLeased(lock)
+	assert.NoError(t, err)
+	assert.True(t, leased)
+	assert.NoError(t, ours.RenewLease(lock, leaseID))
+	data, err := theirs.ReadFile(lock)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"pid":1}`, string(data))
+
+	assert.NoError(t, ours.ReleaseLease(lock, leaseID))
+	leased, err = theirs.IsLeased(lock)
+	assert.NoError(t, err)
+	assert.False(t, leased)
+	exists, err := theirs.Exists(lock)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// Once released, the lock can be taken by another process, and broken by this one.
+	_, err = theirs.AcquireLease(lock, []byte(`{"pid":2}`), time.Minute)
+	assert.NoError(t, err)
+	assert.NoError(t, ours.ReleaseLease(lock, ""))
+	for i := 0; i < 50; i++ {
+		// The terminated session's locks are released once its backend has exited.
+		if leased, err = ours.IsLeased(lock); err != nil || !leased {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.NoError(t, err)
+	assert.False(t, leased)
+	_, err = ours.AcquireLease(lock, []byte(`{"pid":1}`), time.Minute)
+	assert.NoError(t, err)
+}
@@ -686,7 +686,8 @@ func (display *ProgressDisplay) processEndSteps() {
 	if !display.isPreview {
 		if display.stackUrn != "" {
 			stackStep := display.eventUrnToResourceRow[display.stackUrn].Step()
-			props := engine.GetResourceOutputsPropertiesString(stackStep, 0, false, display.opts.Debug)
+			props := engine.GetResourceOutputsPropertiesString(
+				stackStep, 0, false, display.opts.Debug, display.opts.Diff)
 			if props != "" {
 				if !wroteDiagnosticHeader {
 					display.writeBlankLine()
@@ -750,6 +751,32 @@ func splitIntoDisplayableLines(msg string) []string {
 	return lines
 }
 
+// estimateRemaining returns how much longer the steps in progress will probably take, based on their estimated
+// durations.  Steps are assumed to be applied one at a time, so this overestimates when they run in parallel.
+func (display *ProgressDisplay) estimateRemaining() time.Duration {
+	if display.isPreview {
+		return 0
+	}
+
+	var remaining time.Duration
+	for _, row := range display.resourceRows {
+		if row.Done() {
+			continue
+		}
+		if estimate, started := row.Estimate(); estimate > 0 {
+			if left := estimate - time.Since(started); left > 0 {
+				remaining += left
+			}
+		}
+	}
+	return remaining
+}
+
+// roundDuration rounds a duration for display, to the nearest second.
+func roundDuration(d time.Duration) time.Duration {
+	return (d + time.Second/2) / time.Second * time.Second
+}
+
 func (display *ProgressDisplay) processTick() {
 	// Got a tick.  Update all  resources if we're in a terminal.  If we're not, then this won't do
 	// anything.
@@ -844,12 +871,16 @@ func (display *ProgressDisplay) processNormalEvent(event engine.Event) {
 	}
 
 	if event.Type == engine.ResourcePreEvent {
-		step := event.Payload.(engine.ResourcePreEventPayload).Metadata
+		payload := event.Payload.(engine.ResourcePreEventPayload)
+		step := payload.Metadata
 		if step.Op == "" {
 			contract.Failf("Got empty op for %s", event.Type)
 		}
 
 		row.SetStep(step)
+		if payload.EstimatedDuration > 0 {
+			row.SetEstimate(payload.EstimatedDuration, time.Now())
+		}
 	} else if event.Type == engine.ResourceOutputsEvent {
 		// transition the status to done.
 		if !isRootEvent {
@@ -975,6 +1006,12 @@ func (display *ProgressDisplay) getStepDoneDescription(step engine.StepEventMeta
 				return "deleting failed"
 			case deploy.OpReplace:
 				return "replacing failed"
+			case deploy.OpImport:
+				return "importing failed"
+			case deploy.OpRead:
+				return "reading failed"
+			case deploy.OpReadDiscard:
+				return "discarding failed"
 			}
 		} else {
 			switch op {
@@ -992,6 +1029,12 @@ func (display *ProgressDisplay) getStepDoneDescription(step engine.StepEventMeta
 				return "created for replacement"
 			case deploy.OpDeleteReplaced:
 				return "deleted for replacement"
+			case deploy.OpImport:
+				return "imported"
+			case deploy.OpRead:
+				return "read"
+			case deploy.OpReadDiscard:
+				return "discarded"
 			}
 		}
 
@@ -1022,6 +1065,12 @@ func getPreviewText(op deploy.StepOp) string {
 		return "create for replacement"
 	case deploy.OpDeleteReplaced:
 		return "delete for replacement"
+	case deploy.OpImport:
+		return "import"
+	case deploy.OpRead:
+		return "read"
+	case deploy.OpReadDiscard:
+		return "discard"
 	}
 
 	contract.Failf("Unrecognized resource step op: %v", op)
@@ -1061,6 +1110,12 @@ func (display *ProgressDisplay) getStepInProgressDescription(step engine.StepEve
 			return "creating for replacement"
 		case deploy.OpDeleteReplaced:
 			return "deleting for replacement"
+		case deploy.OpImport:
+			return "importing"
+		case deploy.OpRead:
+			return "reading"
+		case deploy.OpReadDiscard:
+			return "discarding"
 		}
 
 		contract.Failf("Unrecognized resource step op: %v", op)
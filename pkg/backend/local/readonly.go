@@ -0,0 +1,72 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+	"github.com/pulumi/pulumi/pkg/workspace"
+)
+
+// A backend in read-only mode refuses to change the state of its stacks: stacks can be listed, previewed, exported,
+// queried, and so on, but not updated, refreshed, destroyed, created, removed, renamed, or edited.  It is meant for
+// those, such as analysts and dashboards, who must be able to read production stacks but must never change them.
+// Since the backend enforces it, rather than the storage's permissions, it guards against mistakes, not malice.
+
+// ReadOnlyEnvVar is the environment variable that, if true, puts every backend that keeps its state itself in
+// read-only mode, whether or not it was logged into in that mode.
+const ReadOnlyEnvVar = "PULUMI_READ_ONLY"
+
+// readOnlyError is returned when a backend in read-only mode is asked to change the state of a stack.
+type readOnlyError struct {
+	backend string // the name of the backend.
+	op      string // what the backend was asked to do.
+}
+
+func (e *readOnlyError) Error() string {
+	return fmt.Sprintf("cannot %s: %s is in read-only mode; log into it without --read-only, and with %s unset, "+
+		"to make changes", e.op, e.backend, ReadOnlyEnvVar)
+}
+
+// IsReadOnlyError returns true if an error was returned because a backend in read-only mode was asked to change the
+// state of a stack.
+func IsReadOnlyError(err error) bool {
+	_, ok := errors.Cause(err).(*readOnlyError)
+	return ok
+}
+
+// isReadOnly returns true if the backend at the given URL is in read-only mode.
+func isReadOnly(url string) (bool, error) {
+	if cmdutil.IsTruthy(os.Getenv(ReadOnlyEnvVar)) {
+		return true, nil
+	}
+	return workspace.IsReadOnlyAccess(url)
+}
+
+func (b *localBackend) ReadOnly() bool {
+	return b.readOnly
+}
+
+// checkWritable returns an error if the backend is in read-only mode, naming the change that it was asked to make.
+func (b *localBackend) checkWritable(op string) error {
+	if b.readOnly {
+		return &readOnlyError{backend: b.Name(), op: op}
+	}
+	return nil
+}
@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/pulumi/pulumi/pkg/diag"
 	"github.com/pulumi/pulumi/pkg/diag/colors"
@@ -47,6 +48,11 @@ type ResourceRow interface {
 	// ellipses to show progress for in-flight resources.
 	Tick() int
 
+	// The estimated duration of the row's step, and the time at which the step started.  Used to show the step's
+	// progress.
+	Estimate() (time.Duration, time.Time)
+	SetEstimate(estimate time.Duration, started time.Time)
+
 	Done() bool
 	SetDone()
 
@@ -97,6 +103,13 @@ func (data *headerRowData) ColorizedColumns() []string {
 		data.columns = []string{"", header("Type"), header("Name"), statusColumn, header("Info")}
 	}
 
+	// While an update is in progress, show how much longer the steps in progress will probably take.
+	if remaining := data.display.estimateRemaining(); remaining > 0 && !data.display.Done {
+		columns := append([]string(nil), data.columns...)
+		columns[infoColumn] = colors.BrightBlue + fmt.Sprintf("Info (about %v remaining)", roundDuration(remaining)) +
+			colors.Reset
+		return columns
+	}
 	return data.columns
 }
 
@@ -117,6 +130,10 @@ type resourceRowData struct {
 	// ellipses to show progress for in-flight resources.
 	tick int
 
+	// The estimated duration of the step (zero if unknown), and the time at which it started.
+	estimate time.Duration
+	started  time.Time
+
 	// If the engine finished processing this resources.
 	done bool
 
@@ -170,6 +187,15 @@ func (data *resourceRowData) Tick() int {
 	return data.tick
 }
 
+func (data *resourceRowData) Estimate() (time.Duration, time.Time) {
+	return data.estimate, data.started
+}
+
+func (data *resourceRowData) SetEstimate(estimate time.Duration, started time.Time) {
+	data.estimate = estimate
+	data.started = started
+}
+
 func (data *resourceRowData) Done() bool {
 	return data.done
 }
@@ -328,6 +354,19 @@ func (data *resourceRowData) getInfo() string {
 		diagMsg += msg
 	}
 
+	if step.Op == deploy.OpReplace && step.DeleteBeforeReplace {
+		appendDiagMessage("delete before replace")
+	}
+
+	// While the step is in progress, show how far along it probably is, based on how long it usually takes.
+	if !data.done && !data.display.isPreview && data.estimate > 0 {
+		percent := int(100 * time.Since(data.started) / data.estimate)
+		if percent > 99 {
+			percent = 99
+		}
+		appendDiagMessage(fmt.Sprintf("%d%% of about %v", percent, roundDuration(data.estimate)))
+	}
+
 	diagInfo := data.diagInfo
 
 	if diagInfo.ErrorCount == 1 {
@@ -0,0 +1,372 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/util/contract"
+)
+
+// s3BackendURLPrefix is the URL scheme that selects a backend that keeps its state in an S3 bucket.
+const s3BackendURLPrefix = "s3://"
+
+// s3Storage is a storage in an S3 bucket, or in any object store with an S3-compatible API, such as MinIO.  Its files
+// are objects whose keys are their paths, below an optional prefix, so that it has the same layout as a local
+// backend's directory: each stack's checkpoint is at `<prefix>/stacks/<stack>.json`, and its history, backups and
+// lock are kept under prefixes of its own.
+//
+// Checkpoints are written with conditional PUTs, which succeed only if the object's ETag is still the one that was
+// read, so that two updates can't silently overwrite each other's checkpoints.  Stores that ignore the preconditions
+// write the object anyway, and CreateFile, and thus the locking of stacks, checks what it wrote by reading it back.
+type s3Storage struct {
+	svc      s3iface.S3API
+	bucket   string
+	prefix   string
+	sse      string // the server-side encryption to request for new objects, if any: AES256 or aws:kms.
+	kmsKeyID string // the KMS key with which to encrypt new objects, if sse is aws:kms.
+}
+
+// newS3Storage returns the storage selected by a URL of the form `s3://bucket/prefix`.  The URL's query may give the
+// bucket's `region`; an `endpoint` to use instead of AWS's, along with `forcePathStyle=true` and `disableSSL=true` as
+// S3-compatible stores often require; and the server-side encryption of the objects written, `sse=AES256` or
+// `sse=aws:kms`, with an optional `kmsKeyId`.  Credentials are found as they are by the AWS CLI.
+func newS3Storage(storageURL string) (*s3Storage, error) {
+	u, err := url.Parse(storageURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", storageURL)
+	}
+	if u.Host == "" {
+		return nil, errors.Errorf("%s does not name a bucket; expected s3://bucket/prefix", storageURL)
+	}
+
+	query := u.Query()
+	config := aws.NewConfig()
+	if region := query.Get("region"); region != "" {
+		config = config.WithRegion(region)
+	}
+	if endpoint := query.Get("endpoint"); endpoint != "" {
+		config = config.WithEndpoint(endpoint)
+	}
+	for param, set := range map[string]func(bool) *aws.Config{
+		"forcePathStyle": config.WithS3ForcePathStyle,
+		"disableSSL":     config.WithDisableSSL,
+	} {
+		if value := query.Get(param); value != "" {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, errors.Errorf("%s: %s must be true or false", storageURL, param)
+			}
+			set(b)
+		}
+	}
+
+	sse, kmsKeyID := query.Get("sse"), query.Get("kmsKeyId")
+	switch {
+	case sse != "" && sse != s3.ServerSideEncryptionAes256 && sse != s3.ServerSideEncryptionAwsKms:
+		return nil, errors.Errorf("%s: sse must be %s or %s", storageURL,
+			s3.ServerSideEncryptionAes256, s3.ServerSideEncryptionAwsKms)
+	case kmsKeyID != "" && sse != s3.ServerSideEncryptionAwsKms:
+		return nil, errors.Errorf("%s: kmsKeyId requires sse=%s", storageURL, s3.ServerSideEncryptionAwsKms)
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *config,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "creating AWS session")
+	}
+
+	return &s3Storage{
+		svc:      s3.New(sess),
+		bucket:   u.Host,
+		prefix:   strings.Trim(u.Path, "/"),
+		sse:      sse,
+		kmsKeyID: kmsKeyID,
+	}, nil
+}
+
+// key returns the key of the object at the given path.
+func (s *s3Storage) key(p string) string {
+	return path.Join(s.prefix, filepath.ToSlash(p))
+}
+
+// isS3NotFound returns true if an error reports that an object doesn't exist.
+func isS3NotFound(err error) bool {
+	if reqErr, ok := err.(awserr.RequestFailure); ok && reqErr.StatusCode() == http.StatusNotFound {
+		return true
+	}
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == s3.ErrCodeNoSuchKey
+}
+
+func (s *s3Storage) ReadFile(p string) ([]byte, error) {
+	out, err := s.svc.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(p)),
+	})
+	if err != nil {
+		if isS3NotFound(err) {
+			return nil, &os.PathError{Op: "read", Path: s.Describe(p), Err: os.ErrNotExist}
+		}
+		return nil, errors.Wrapf(err, "reading %s", s.Describe(p))
+	}
+	defer contract.IgnoreClose(out.Body)
+	return ioutil.ReadAll(out.Body)
+}
+
+func (s *s3Storage) ReadFileVersion(p string) ([]byte, string, error) {
+	out, err := s.svc.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(p)),
+	})
+	if err != nil {
+		if isS3NotFound(err) {
+			return nil, "", &os.PathError{Op: "read", Path: s.Describe(p), Err: os.ErrNotExist}
+		}
+		return nil, "", errors.Wrapf(err, "reading %s", s.Describe(p))
+	}
+	defer contract.IgnoreClose(out.Body)
+	data, err := ioutil.ReadAll(out.Body)
+	return data, aws.StringValue(out.ETag), err
+}
+
+// putObject writes the object at the given path with the given extra headers, such as preconditions, and returns its
+// ETag.
+func (s *s3Storage) putObject(p string, data []byte, headers map[string]string) (string, error) {
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(p)),
+		Body:   bytes.NewReader(data),
+	}
+	if s.sse != "" {
+		input.ServerSideEncryption = aws.String(s.sse)
+	}
+	if s.kmsKeyID != "" {
+		input.SSEKMSKeyId = aws.String(s.kmsKeyID)
+	}
+	out, err := s.svc.PutObjectWithContext(aws.BackgroundContext(), input, func(req *request.Request) {
+		for k, v := range headers {
+			req.HTTPRequest.Header.Set(k, v)
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(out.ETag), nil
+}
+
+// isS3PreconditionFailed returns true if an error reports that a conditional write failed because the object had
+// changed, or because another conditional write of it was in progress.
+func isS3PreconditionFailed(err error) bool {
+	reqErr, ok := err.(awserr.RequestFailure)
+	return ok && (reqErr.StatusCode() == http.StatusPreconditionFailed || reqErr.StatusCode() == http.StatusConflict)
+}
+
+func (s *s3Storage) WriteFile(p string, data []byte) error {
+	if _, err := s.putObject(p, data, nil); err != nil {
+		return errors.Wrapf(err, "writing %s", s.Describe(p))
+	}
+	return nil
+}
+
+func (s *s3Storage) WriteFileIfVersion(p string, data []byte, version string) (string, error) {
+	precondition := map[string]string{"If-None-Match": "*"}
+	if version != "" {
+		precondition = map[string]string{"If-Match": version}
+	}
+	etag, err := s.putObject(p, data, precondition)
+	switch {
+	case isS3PreconditionFailed(err):
+		return "", &os.PathError{Op: "write", Path: s.Describe(p), Err: errFileChanged}
+	case err != nil:
+		return "", errors.Wrapf(err, "writing %s", s.Describe(p))
+	default:
+		return etag, nil
+	}
+}
+
+func (s *s3Storage) CreateFile(p string, data []byte) error {
+	exists, err := s.Exists(p)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if _, err = s.putObject(p, data, map[string]string{"If-None-Match": "*"}); err != nil {
+			if isS3PreconditionFailed(err) {
+				return &os.PathError{Op: "create", Path: s.Describe(p), Err: os.ErrExist}
+			}
+			return errors.Wrapf(err, "writing %s", s.Describe(p))
+		}
+		// If the store ignored the precondition, and another writer created the object at the same time, at most one
+		// of the writes survives; read it back to find out whether it was this one.
+		written, err := s.ReadFile(p)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if bytes.Equal(written, data) {
+			return nil
+		}
+	}
+	return &os.PathError{Op: "create", Path: s.Describe(p), Err: os.ErrExist}
+}
+
+func (s *s3Storage) Exists(p string) (bool, error) {
+	_, err := s.svc.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(p)),
+	})
+	switch {
+	case err == nil:
+		return true, nil
+	case isS3NotFound(err):
+		return false, nil
+	default:
+		return false, errors.Wrapf(err, "reading %s", s.Describe(p))
+	}
+}
+
+func (s *s3Storage) Remove(p string) error {
+	// Deleting an object that doesn't exist succeeds.
+	if _, err := s.svc.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(p)),
+	}); err != nil {
+		return errors.Wrapf(err, "removing %s", s.Describe(p))
+	}
+	return nil
+}
+
+func (s *s3Storage) RemoveAll(p string) error {
+	keys, err := s.listKeys(s.key(p) + "/")
+	if err != nil {
+		return err
+	}
+	for _, key := range append(keys, s.key(p)) {
+		if _, err = s.svc.DeleteObject(&s3.DeleteObjectInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(key),
+		}); err != nil {
+			return errors.Wrapf(err, "removing s3://%s/%s", s.bucket, key)
+		}
+	}
+	return nil
+}
+
+func (s *s3Storage) Rename(from, to string) error {
+	fromKey, toKey := s.key(from), s.key(to)
+
+	// S3 has no directories, so moving one moves each of the objects below its prefix.
+	keys, err := s.listKeys(fromKey + "/")
+	if err != nil {
+		return err
+	}
+	if exists, err := s.Exists(from); err != nil {
+		return err
+	} else if exists {
+		keys = append(keys, fromKey)
+	}
+	if len(keys) == 0 {
+		return &os.PathError{Op: "rename", Path: s.Describe(from), Err: os.ErrNotExist}
+	}
+
+	for _, key := range keys {
+		input := &s3.CopyObjectInput{
+			Bucket:     aws.String(s.bucket),
+			Key:        aws.String(toKey + strings.TrimPrefix(key, fromKey)),
+			CopySource: aws.String((&url.URL{Path: s.bucket + "/" + key}).EscapedPath()),
+		}
+		if s.sse != "" {
+			input.ServerSideEncryption = aws.String(s.sse)
+		}
+		if s.kmsKeyID != "" {
+			input.SSEKMSKeyId = aws.String(s.kmsKeyID)
+		}
+		if _, err = s.svc.CopyObject(input); err != nil {
+			return errors.Wrapf(err, "copying s3://%s/%s", s.bucket, key)
+		}
+		if _, err = s.svc.DeleteObject(&s3.DeleteObjectInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(key),
+		}); err != nil {
+			return errors.Wrapf(err, "removing s3://%s/%s", s.bucket, key)
+		}
+	}
+	return nil
+}
+
+func (s *s3Storage) List(dir string) ([]storageFile, error) {
+	prefix := s.key(dir) + "/"
+
+	var files []storageFile
+	err := s.svc.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket:    aws.String(s.bucket),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String("/"),
+	}, func(page *s3.ListObjectsV2Output, last bool) bool {
+		for _, obj := range page.Contents {
+			files = append(files, storageFile{
+				Name:    strings.TrimPrefix(aws.StringValue(obj.Key), prefix),
+				ModTime: aws.TimeValue(obj.LastModified),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing %s", s.Describe(dir))
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+// listKeys returns the keys of all of the objects whose keys start with the given prefix.
+func (s *s3Storage) listKeys(prefix string) ([]string, error) {
+	var keys []string
+	err := s.svc.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, last bool) bool {
+		for _, obj := range page.Contents {
+			keys = append(keys, aws.StringValue(obj.Key))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing s3://%s/%s", s.bucket, prefix)
+	}
+	return keys, nil
+}
+
+func (s *s3Storage) Describe(p string) string {
+	return "s3://" + s.bucket + "/" + s.key(p)
+}
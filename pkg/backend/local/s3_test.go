@@ -0,0 +1,310 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/stretchr/testify/assert"
+)
+
+// s3ListPageSize is the number of objects that the fake S3 service lists at a time.
+const s3ListPageSize = 2
+
+// fakeS3 is an S3 service that holds the objects of a single bucket in memory.  It implements only the operations
+// that an s3Storage uses.
+type fakeS3 struct {
+	s3iface.S3API
+
+	lock    sync.Mutex
+	bucket  string
+	objects map[string]fakeS3Object
+	version int
+	pages   int    // the number of pages of listings returned.
+	sse     string // the server-side encryption requested by the last write.
+}
+
+type fakeS3Object struct {
+	data    []byte
+	etag    string
+	modTime time.Time
+}
+
+func newFakeS3(bucket string) *fakeS3 {
+	return &fakeS3{bucket: bucket, objects: make(map[string]fakeS3Object)}
+}
+
+func fakeS3Failure(code string, statusCode int) error {
+	return awserr.NewRequestFailure(awserr.New(code, http.StatusText(statusCode), nil), statusCode, "request")
+}
+
+func (f *fakeS3) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	obj, has := f.objects[aws.StringValue(input.Key)]
+	if !has {
+		return nil, fakeS3Failure(s3.ErrCodeNoSuchKey, http.StatusNotFound)
+	}
+	return &s3.GetObjectOutput{
+		Body: ioutil.NopCloser(bytes.NewReader(obj.data)),
+		ETag: aws.String(obj.etag),
+	}, nil
+}
+
+func (f *fakeS3) HeadObject(input *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	obj, has := f.objects[aws.StringValue(input.Key)]
+	if !has {
+		// HEAD responses have no body, so S3 can't say which key is missing.
+		return nil, fakeS3Failure("NotFound", http.StatusNotFound)
+	}
+	return &s3.HeadObjectOutput{ETag: aws.String(obj.etag)}, nil
+}
+
+// put writes an object, giving it a new ETag, which it returns.
+func (f *fakeS3) put(key string, data []byte) string {
+	f.version++
+	etag := fmt.Sprintf(`"%d"`, f.version)
+	f.objects[key] = fakeS3Object{data: data, etag: etag, modTime: time.Now()}
+	return etag
+}
+
+func (f *fakeS3) PutObjectWithContext(ctx aws.Context, input *s3.PutObjectInput,
+	opts ...request.Option) (*s3.PutObjectOutput, error) {
+
+	req := &request.Request{HTTPRequest: &http.Request{Header: http.Header{}}}
+	for _, opt := range opts {
+		opt(req)
+	}
+	data, err := ioutil.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	key := aws.StringValue(input.Key)
+	obj, has := f.objects[key]
+	if match := req.HTTPRequest.Header.Get("If-Match"); match != "" && (!has || match != obj.etag) {
+		return nil, fakeS3Failure("PreconditionFailed", http.StatusPreconditionFailed)
+	}
+	if req.HTTPRequest.Header.Get("If-None-Match") == "*" && has {
+		return nil, fakeS3Failure("PreconditionFailed", http.StatusPreconditionFailed)
+	}
+	f.sse = aws.StringValue(input.ServerSideEncryption)
+	return &s3.PutObjectOutput{ETag: aws.String(f.put(key, data))}, nil
+}
+
+func (f *fakeS3) DeleteObject(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	delete(f.objects, aws.StringValue(input.Key))
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func (f *fakeS3) CopyObject(input *s3.CopyObjectInput) (*s3.CopyObjectOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	source, err := url.PathUnescape(aws.StringValue(input.CopySource))
+	if err != nil {
+		return nil, err
+	}
+	obj, has := f.objects[strings.TrimPrefix(source, f.bucket+"/")]
+	if !has {
+		return nil, fakeS3Failure(s3.ErrCodeNoSuchKey, http.StatusNotFound)
+	}
+	f.put(aws.StringValue(input.Key), obj.data)
+	return &s3.CopyObjectOutput{}, nil
+}
+
+// ListObjectsV2Pages lists the objects whose keys start with a prefix, a page at a time.  If there's a delimiter,
+// the keys that have it after the prefix are rolled up into common prefixes, which this fake doesn't return.
+func (f *fakeS3) ListObjectsV2Pages(input *s3.ListObjectsV2Input,
+	fn func(page *s3.ListObjectsV2Output, last bool) bool) error {
+
+	f.lock.Lock()
+	prefix, delimiter := aws.StringValue(input.Prefix), aws.StringValue(input.Delimiter)
+	var keys []string
+	for key := range f.objects {
+		if strings.HasPrefix(key, prefix) &&
+			(delimiter == "" || !strings.Contains(strings.TrimPrefix(key, prefix), delimiter)) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	var pages []*s3.ListObjectsV2Output
+	for start := 0; start == 0 || start < len(keys); start += s3ListPageSize {
+		page := &s3.ListObjectsV2Output{}
+		for i := start; i < len(keys) && i < start+s3ListPageSize; i++ {
+			page.Contents = append(page.Contents, &s3.Object{
+				Key:          aws.String(keys[i]),
+				LastModified: aws.Time(f.objects[keys[i]].modTime),
+			})
+		}
+		if start+s3ListPageSize < len(keys) {
+			page.IsTruncated = aws.Bool(true)
+			page.NextContinuationToken = aws.String(strconv.Itoa(start + s3ListPageSize))
+		}
+		pages = append(pages, page)
+	}
+	f.lock.Unlock()
+
+	for i, page := range pages {
+		f.pages++
+		if !fn(page, i == len(pages)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+// newS3TestStorage returns a storage whose bucket, below the prefix "state", is held by a fake S3 service.
+func newS3TestStorage() (*s3Storage, *fakeS3) {
+	f := newFakeS3("bucket")
+	return &s3Storage{svc: f, bucket: f.bucket, prefix: "state"}, f
+}
+
+func TestS3ReadWrite(t *testing.T) {
+	s, f := newS3TestStorage()
+
+	assert.NoError(t, s.WriteFile(".pulumi/stacks/dev.json", []byte("v1")))
+	assert.Equal(t, []byte("v1"), f.objects["state/.pulumi/stacks/dev.json"].data)
+	data, err := s.ReadFile(".pulumi/stacks/dev.json")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", string(data))
+	exists, err := s.Exists(".pulumi/stacks/dev.json")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	// A missing object is a missing file.
+	_, err = s.ReadFile(".pulumi/stacks/missing.json")
+	assert.True(t, os.IsNotExist(err), "expected a missing file, got %v", err)
+	_, _, err = s.ReadFileVersion(".pulumi/stacks/missing.json")
+	assert.True(t, os.IsNotExist(err), "expected a missing file, got %v", err)
+	exists, err = s.Exists(".pulumi/stacks/missing.json")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// Objects are written with the server-side encryption asked for.
+	s.sse = s3.ServerSideEncryptionAes256
+	assert.NoError(t, s.WriteFile(".pulumi/stacks/dev.json", []byte("v2")))
+	assert.Equal(t, s3.ServerSideEncryptionAes256, f.sse)
+}
+
+// TestS3WriteIfVersion writes an object only if its ETag is the one that was read, or only if it doesn't exist.
+func TestS3WriteIfVersion(t *testing.T) {
+	s, f := newS3TestStorage()
+
+	etag, err := s.WriteFileIfVersion("dev.json", []byte("v1"), "")
+	assert.NoError(t, err)
+	_, err = s.WriteFileIfVersion("dev.json", []byte("theirs"), "")
+	assert.True(t, isFileChanged(err), "expected a conflict, got %v", err)
+	assert.True(t, os.IsExist(s.CreateFile("dev.json", []byte("theirs"))))
+
+	assert.NoError(t, s.WriteFile("dev.json", []byte("theirs")))
+	_, err = s.WriteFileIfVersion("dev.json", []byte("ours"), etag)
+	assert.True(t, isFileChanged(err), "expected a conflict, got %v", err)
+	assert.Equal(t, "theirs", string(f.objects["state/dev.json"].data))
+
+	_, version, err := s.ReadFileVersion("dev.json")
+	assert.NoError(t, err)
+	_, err = s.WriteFileIfVersion("dev.json", []byte("ours"), version)
+	assert.NoError(t, err)
+	assert.Equal(t, "ours", string(f.objects["state/dev.json"].data))
+}
+
+// TestS3ListPages lists a directory with more files than fit in one page of a listing, alongside the files of other
+// directories, both below it and beside it.
+func TestS3ListPages(t *testing.T) {
+	s, f := newS3TestStorage()
+
+	var expected []string
+	for i := 0; i < 2*s3ListPageSize+1; i++ {
+		name := fmt.Sprintf("dev-%d.json", i)
+		assert.NoError(t, s.WriteFile(".pulumi/history/dev/"+name, nil))
+		expected = append(expected, name)
+	}
+	assert.NoError(t, s.WriteFile(".pulumi/history/dev/nested/dev.json", nil))
+	assert.NoError(t, s.WriteFile(".pulumi/history/dev2/dev2-0.json", nil))
+
+	files, err := s.List(".pulumi/history/dev")
+	assert.NoError(t, err)
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name)
+	}
+	assert.Equal(t, expected, names)
+	assert.Equal(t, 3, f.pages)
+
+	// Removing the directory removes everything below it, and nothing beside it.
+	assert.NoError(t, s.RemoveAll(".pulumi/history/dev"))
+	var keys []string
+	for key := range f.objects {
+		keys = append(keys, key)
+	}
+	assert.Equal(t, []string{"state/.pulumi/history/dev2/dev2-0.json"}, keys)
+}
+
+// TestS3Prefix finds the bucket and the prefix below which files are kept in a backend's URL.
+func TestS3Prefix(t *testing.T) {
+	s, err := newS3Storage("s3://bucket/some/prefix/?region=us-west-2")
+	assert.NoError(t, err)
+	assert.Equal(t, "bucket", s.bucket)
+	assert.Equal(t, "some/prefix/.pulumi/stacks/dev.json", s.key(".pulumi/stacks/dev.json"))
+	assert.Equal(t, "s3://bucket/some/prefix/.pulumi/stacks/dev.json", s.Describe(".pulumi/stacks/dev.json"))
+
+	s, err = newS3Storage("s3://bucket")
+	assert.NoError(t, err)
+	assert.Equal(t, ".pulumi/stacks/dev.json", s.key(".pulumi/stacks/dev.json"))
+
+	_, err = newS3Storage("s3:///prefix")
+	assert.Error(t, err)
+	_, err = newS3Storage("s3://bucket?sse=des")
+	assert.Error(t, err)
+
+	// Renaming a directory moves the objects below the storage's prefix, and only those of the directory.
+	s, f := newS3TestStorage()
+	f.put("elsewhere/.pulumi/stacks/dev.json", nil)
+	assert.NoError(t, s.WriteFile(".pulumi/stacks/dev.json", []byte("checkpoint")))
+	assert.NoError(t, s.WriteFile(".pulumi/history/dev/dev-1.json", []byte("update")))
+	assert.NoError(t, s.Rename(".pulumi/history/dev", ".pulumi/history/prod"))
+	var keys []string
+	for key := range f.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	assert.Equal(t, []string{
+		"elsewhere/.pulumi/stacks/dev.json",
+		"state/.pulumi/history/prod/dev-1.json",
+		"state/.pulumi/stacks/dev.json",
+	}, keys)
+}
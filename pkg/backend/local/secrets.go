@@ -0,0 +1,579 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/backend"
+	"github.com/pulumi/pulumi/pkg/resource/config"
+	"github.com/pulumi/pulumi/pkg/resource/stack"
+	"github.com/pulumi/pulumi/pkg/secrets"
+	"github.com/pulumi/pulumi/pkg/tokens"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+	"github.com/pulumi/pulumi/pkg/util/logging"
+	"github.com/pulumi/pulumi/pkg/workspace"
+)
+
+// PassphraseSecretsProvider is the name of the secrets provider that encrypts a stack's secrets with a key derived
+// from a passphrase.
+const PassphraseSecretsProvider = "passphrase"
+
+// checkSecretsProvider checks that a secrets provider is one that self-managed backends support: a passphrase, or a key
+// in a key management service.
+func checkSecretsProvider(provider string) error {
+	if provider != PassphraseSecretsProvider && !secrets.IsKeyManagerURL(provider) {
+		return errors.Errorf("unknown secrets provider '%s'; expected '%s' or the URL of a key, such as "+
+			"%s://alias/<name>", provider, PassphraseSecretsProvider, secrets.AWSKMSScheme)
+	}
+	return nil
+}
+
+// initSecretsProvider records the secrets provider of a new stack in its settings.  A stack whose provider is a key in
+// a key management service is given a data key now, so that the key is checked before the stack is created; one whose
+// provider is a passphrase is asked for it when its first secret is encrypted.
+func (b *localBackend) initSecretsProvider(stackName tokens.QName, provider string) error {
+	if provider == "" || provider == PassphraseSecretsProvider {
+		return nil
+	}
+	if err := checkSecretsProvider(provider); err != nil {
+		return err
+	}
+
+	info, err := workspace.DetectProjectStack(stackName)
+	if err != nil {
+		return err
+	}
+	if info.Config.HasSecureValue() {
+		return errors.Errorf("the settings of stack '%s' already hold secrets encrypted with another key; remove "+
+			"them, or create the stack without a secrets provider and then run `pulumi stack change-secrets-provider`",
+			stackName)
+	}
+	km, err := secrets.OpenKeyManager(provider)
+	if err != nil {
+		return err
+	}
+	crypter, key, err := secrets.NewCrypter(km)
+	if err != nil {
+		return err
+	}
+	info.SecretsProvider, info.EncryptedKey, info.EncryptionSalt = provider, key, ""
+	if err = workspace.SaveProjectStack(stackName, info); err != nil {
+		return err
+	}
+
+	b.cryptersLock.Lock()
+	defer b.cryptersLock.Unlock()
+	if b.crypters == nil {
+		b.crypters = make(map[tokens.QName]config.Crypter)
+	}
+	b.crypters[stackName] = crypter
+	return nil
+}
+
+// NewConfigPassphraseEnvVar is the environment variable that holds the new passphrase of a stack whose secrets are
+// being re-encrypted with a new passphrase, just as PULUMI_CONFIG_PASSPHRASE holds its current one.
+const NewConfigPassphraseEnvVar = "PULUMI_NEW_CONFIG_PASSPHRASE"
+
+// readNewConfigPassphrase reads the new passphrase of a stack whose secrets are being re-encrypted.
+func readNewConfigPassphrase(prompt string) (string, error) {
+	if phrase := os.Getenv(NewConfigPassphraseEnvVar); phrase != "" {
+		return phrase, nil
+	}
+	return cmdutil.ReadConsoleNoEcho(prompt)
+}
+
+// rotatedFile is a file of a stack's state whose secrets have been re-encrypted, but which hasn't been written yet.
+type rotatedFile struct {
+	path string // the path of the file in the storage.
+	old  []byte // the file's contents, as they were read.
+	new  []byte // the file's contents with its secrets re-encrypted.
+}
+
+// secretsRotation re-encrypts the secrets of a stack, as found in its settings and throughout its state, with a new
+// crypter.  Every file is read and re-encrypted before any is written, so that nothing is changed unless all of it
+// can be.
+type secretsRotation struct {
+	b    *localBackend
+	name tokens.QName
+	dec  config.Decrypter // the stack's current crypter.
+	enc  config.Crypter   // the stack's new crypter.
+
+	files []rotatedFile
+}
+
+func (b *localBackend) ChangeSecretsProvider(ctx context.Context, stackRef backend.StackReference,
+	provider string) (int, error) {
+
+	name := stackRef.StackName()
+	if err := b.checkWritable("re-encrypt the secrets of stack '" + string(name) + "'"); err != nil {
+		return 0, err
+	}
+	if err := checkSecretsProvider(provider); err != nil {
+		return 0, err
+	}
+
+	info, err := workspace.DetectProjectStack(name)
+	if err != nil {
+		return 0, err
+	}
+	if info.EncryptionSalt == "" && info.EncryptedKey == "" {
+		return 0, errors.Errorf("stack '%s' has no key, so it has no secrets to re-encrypt", name)
+	}
+	dec, err := b.stackCrypter(name)
+	if err != nil {
+		return 0, err
+	}
+
+	// A new key is made even if the provider is the same, so that the stack's secrets can no longer be read with its
+	// current key.
+	var enc config.Crypter
+	if provider == PassphraseSecretsProvider {
+		phrase, err := readNewPassphrase(readNewConfigPassphrase, "Enter your new passphrase to protect "+
+			"config/secrets\n    (set "+NewConfigPassphraseEnvVar+" to remember)")
+		if err != nil {
+			return 0, err
+		}
+		enc, info.EncryptionSalt = newSymmetricCrypter(phrase)
+		info.SecretsProvider, info.EncryptedKey = "", ""
+	} else {
+		km, err := secrets.OpenKeyManager(provider)
+		if err != nil {
+			return 0, err
+		}
+		if enc, info.EncryptedKey, err = secrets.NewCrypter(km); err != nil {
+			return 0, err
+		}
+		info.SecretsProvider, info.EncryptionSalt = provider, ""
+	}
+	// The stack's further secrets keys go on encrypting the secrets they're for.
+	if enc, err = withSecretsKeys(enc, info); err != nil {
+		return 0, err
+	}
+
+	// Hold the stack's lock throughout, so that no update writes state encrypted with the old key meanwhile.
+	if _, err = b.lockStack(name, backend.UpdateInfo{
+		Kind:      backend.UpdateKind("change-secrets-provider"),
+		StartTime: time.Now().Unix(),
+		Result:    backend.InProgressResult,
+	}); err != nil {
+		return 0, err
+	}
+	defer func() {
+		if unlockErr := b.unlockStack(name); unlockErr != nil {
+			logging.V(7).Infof("Failed to unlock stack '%s': %v", name, unlockErr)
+		}
+	}()
+
+	r := &secretsRotation{b: b, name: name, dec: dec, enc: enc}
+	if err = r.prepare(); err != nil {
+		return 0, err
+	}
+
+	// Re-encrypt the stack's configuration, and check that all of it, and all of the state, can be read with the new
+	// crypter before writing any of it.
+	if info.Config, err = reencryptConfig(info.Config, dec, enc); err != nil {
+		return 0, err
+	}
+	if err = r.verify(info.Config); err != nil {
+		return 0, errors.Wrap(err, "verifying the re-encrypted secrets")
+	}
+
+	// Write the state and then the settings, from which the new key is recreated.  If any of it can't be written, what
+	// was written is put back as it was.
+	if err = r.commit(func() error { return workspace.SaveProjectStack(name, info) }); err != nil {
+		return 0, err
+	}
+
+	b.cryptersLock.Lock()
+	b.crypters[name] = enc
+	b.cryptersLock.Unlock()
+	b.forgetCheckpointVersion(name)
+
+	return len(r.files), nil
+}
+
+func (b *localBackend) AddSecretsKey(ctx context.Context, stackRef backend.StackReference, name string,
+	key workspace.SecretsKey) (int, error) {
+
+	stackName := stackRef.StackName()
+	if err := b.checkWritable("add a secrets key to stack '" + string(stackName) + "'"); err != nil {
+		return 0, err
+	}
+	if !secrets.IsKeyManagerURL(key.Provider) {
+		return 0, errors.Errorf("'%s' is not the URL of a key; secrets keys must be kept by a key management service, "+
+			"such as %s://alias/<name>", key.Provider, secrets.AWSKMSScheme)
+	}
+	if name == "" || strings.Contains(name, ":") {
+		return 0, errors.Errorf("'%s' is not a valid name for a secrets key; it may not be empty or contain a colon", name)
+	}
+	if len(key.Config) == 0 && !key.State {
+		return 0, errors.New("a secrets key must encrypt some configuration keys or the stack's state")
+	}
+
+	info, err := workspace.DetectProjectStack(stackName)
+	if err != nil {
+		return 0, err
+	}
+	if _, has := info.SecretsKeys[name]; has {
+		return 0, errors.Errorf("stack '%s' already has a secrets key named '%s'", stackName, name)
+	}
+	dec, err := b.stackCrypter(stackName)
+	if err != nil {
+		return 0, err
+	}
+
+	km, err := secrets.OpenKeyManager(key.Provider)
+	if err != nil {
+		return 0, err
+	}
+	if _, key.EncryptedKey, err = secrets.NewCrypter(km); err != nil {
+		return 0, err
+	}
+	if info.SecretsKeys == nil {
+		info.SecretsKeys = make(map[string]*workspace.SecretsKey)
+	}
+	info.SecretsKeys[name] = &key
+
+	// Re-encrypt the values that the new key is for, so that they can no longer be read without it.  The state is
+	// encrypted with it from the next time it's written.
+	enc, err := withSecretsKeys(secrets.PrimaryCrypter(dec), info)
+	if err != nil {
+		return 0, err
+	}
+	route := secrets.Route{Config: key.Config}
+	reencrypted := 0
+	for k, v := range info.Config {
+		if !v.Secure() || !route.MatchesConfigKey(k) {
+			continue
+		}
+		if info.Config[k], err = v.Copy(dec, config.EncrypterForKey(enc, k)); err != nil {
+			return 0, errors.Wrapf(err, "re-encrypting '%s'", k)
+		}
+		reencrypted++
+	}
+
+	if err = workspace.SaveProjectStack(stackName, info); err != nil {
+		return 0, err
+	}
+	b.cryptersLock.Lock()
+	b.crypters[stackName] = enc
+	b.cryptersLock.Unlock()
+	return reencrypted, nil
+}
+
+// prepare reads and re-encrypts the stack's state: its checkpoint and copies of it, its journal, and its history.
+func (r *secretsRotation) prepare() error {
+	// The checkpoint, along with the copies made of it before it is replaced, and its backups.
+	stackPath := r.b.stackPath(r.name)
+	stackDir, stackFile := filepath.Dir(stackPath), filepath.Base(stackPath)
+	if err := r.prepareDir(stackDir, r.checkpoint, func(name string) bool {
+		return name == stackFile || strings.HasPrefix(name, stackFile+".")
+	}); err != nil {
+		return err
+	}
+	if err := r.prepareDir(r.b.backupDirectory(r.name), r.checkpoint, nil); err != nil {
+		return err
+	}
+
+	// The journal of changes made to the checkpoint since it was written.
+	if err := r.prepareJournal(); err != nil {
+		return err
+	}
+
+	// The history: records of updates, the checkpoints that they left, and their events.
+	return r.prepareDir(r.b.historyDirectory(r.name), func(data []byte, file string) ([]byte, error) {
+		switch {
+		case strings.HasSuffix(file, ".history.json"):
+			return r.historyRecord(data)
+		case strings.HasSuffix(file, ".checkpoint.json"):
+			return r.checkpoint(data, file)
+		default:
+			return r.blob(data)
+		}
+	}, nil)
+}
+
+// prepareJournal re-encrypts the entries of the stack's journal, which are kept in a directory for each checkpoint
+// that the journal follows.
+func (r *secretsRotation) prepareJournal() error {
+	data, err := r.b.storage.ReadFile(r.b.stackPath(r.name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if data, err = r.b.decodeState(r.name, data); err != nil {
+		return err
+	}
+	chk, err := stack.UnmarshalVersionedCheckpointToLatestCheckpoint(data)
+	if err != nil {
+		return err
+	}
+	if chk.Latest == nil {
+		return nil
+	}
+
+	dir := filepath.Join(r.b.journalDirectory(r.name), journalKey(chk.Latest.Manifest.Time))
+	return r.prepareDir(dir, func(data []byte, file string) ([]byte, error) {
+		return r.journalEntry(data)
+	}, nil)
+}
+
+// journalEntry re-encrypts an entry of the journal: the secret outputs of the resources that it records and, if it is
+// encrypted, the whole of it.
+func (r *secretsRotation) journalEntry(data []byte) ([]byte, error) {
+	encrypted := stack.IsEncryptedCheckpoint(data)
+	plain, err := r.b.decodeState(r.name, data)
+	if err != nil {
+		return nil, err
+	}
+	var entry journalEntry
+	if err = json.Unmarshal(plain, &entry); err != nil {
+		// An entry cut short by an update that died is never applied, so it is left as it is.
+		return r.blob(data)
+	}
+	reencrypted, err := stack.ReencryptSecretOutputs(entry.resources(), r.dec, r.enc)
+	if err != nil {
+		return nil, err
+	}
+	if reencrypted == 0 {
+		return r.blob(data)
+	}
+	if plain, err = json.Marshal(&entry); err != nil {
+		return nil, err
+	}
+	if !encrypted {
+		return plain, nil
+	}
+	return stack.EncryptCheckpoint(plain, r.enc)
+}
+
+// prepareDir re-encrypts the files in a directory that match the given filter, or all of them if it is nil, with the
+// given function.  The function returns nil for a file that has no secrets.
+func (r *secretsRotation) prepareDir(dir string, reencrypt func(data []byte, file string) ([]byte, error),
+	filter func(name string) bool) error {
+
+	files, err := r.b.storage.List(dir)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if filter != nil && !filter(file.Name) {
+			continue
+		}
+		p := filepath.Join(dir, file.Name)
+		data, err := r.b.storage.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rotated, err := reencrypt(data, p)
+		if err != nil {
+			return errors.Wrapf(err, "re-encrypting %s", r.b.storage.Describe(p))
+		}
+		if rotated != nil {
+			r.files = append(r.files, rotatedFile{path: p, old: data, new: rotated})
+		}
+	}
+	return nil
+}
+
+// checkpoint re-encrypts a checkpoint: the secret values of the configuration that it holds, the secret outputs of its
+// resources and, if it is encrypted, the whole of it.  It is compressed again if it was compressed.
+func (r *secretsRotation) checkpoint(data []byte, file string) ([]byte, error) {
+	encrypted := stack.IsEncryptedCheckpoint(data)
+	decrypted := data
+	if encrypted {
+		var err error
+		if decrypted, err = stack.DecryptCheckpoint(data, r.dec); err != nil {
+			return nil, err
+		}
+	}
+	plain, err := stack.DecompressCheckpoint(decrypted)
+	if err != nil {
+		return nil, err
+	}
+	chk, err := stack.UnmarshalVersionedCheckpointToLatestCheckpoint(plain)
+	if err != nil {
+		return nil, err
+	}
+	var secretOutputs int
+	if chk.Latest != nil {
+		if secretOutputs, err = stack.ReencryptSecretOutputs(stack.DeploymentResources(chk.Latest), r.dec,
+			r.enc); err != nil {
+			return nil, err
+		}
+	}
+	if !chk.Config.HasSecureValue() && secretOutputs == 0 {
+		if !encrypted {
+			return nil, nil
+		}
+		return stack.EncryptCheckpoint(decrypted, r.enc)
+	}
+
+	cfg, err := reencryptConfig(chk.Config, r.dec, r.enc)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := stack.DeserializeCheckpoint(chk)
+	if err != nil {
+		return nil, err
+	}
+	if plain, err = json.MarshalIndent(stack.SerializeCheckpoint(chk.Stack, cfg, snap), "", "    "); err != nil {
+		return nil, err
+	}
+	if stack.IsCompressedCheckpoint(decrypted) {
+		if plain, err = stack.CompressCheckpoint(plain); err != nil {
+			return nil, err
+		}
+	}
+	if !encrypted {
+		return plain, nil
+	}
+	return stack.EncryptCheckpoint(plain, r.enc)
+}
+
+// historyRecord re-encrypts the secret values of the configuration in the record of an update.
+func (r *secretsRotation) historyRecord(data []byte) ([]byte, error) {
+	var update backend.UpdateInfo
+	if err := json.Unmarshal(data, &update); err != nil {
+		return nil, err
+	}
+	if !update.Config.HasSecureValue() {
+		return nil, nil
+	}
+
+	cfg, err := reencryptConfig(update.Config, r.dec, r.enc)
+	if err != nil {
+		return nil, err
+	}
+	update.Config = cfg
+	return json.MarshalIndent(&update, "", "    ")
+}
+
+// blob re-encrypts a file that is wholly encrypted, or not at all, such as an entry of a journal or the events of an
+// update.
+func (r *secretsRotation) blob(data []byte) ([]byte, error) {
+	if !stack.IsEncryptedCheckpoint(data) {
+		return nil, nil
+	}
+	plain, err := stack.DecryptCheckpoint(data, r.dec)
+	if err != nil {
+		return nil, err
+	}
+	return stack.EncryptCheckpoint(plain, r.enc)
+}
+
+// verify checks that the re-encrypted configuration and state can all be read with the new crypter.
+func (r *secretsRotation) verify(cfg config.Map) error {
+	if _, err := cfg.Decrypt(r.enc); err != nil {
+		return errors.Wrap(err, "the stack's configuration")
+	}
+	for _, file := range r.files {
+		if err := r.verifyFile(file); err != nil {
+			return errors.Wrap(err, r.b.storage.Describe(file.path))
+		}
+	}
+	return nil
+}
+
+// verifyFile checks that a re-encrypted file, and the secret values of any configuration that it holds, can be read
+// with the new crypter.
+func (r *secretsRotation) verifyFile(file rotatedFile) error {
+	data := file.new
+	if stack.IsEncryptedCheckpoint(data) {
+		var err error
+		if data, err = stack.DecryptCheckpoint(data, r.enc); err != nil {
+			return err
+		}
+	}
+	data, err := stack.DecompressCheckpoint(data)
+	if err != nil {
+		return err
+	}
+
+	var cfg config.Map
+	switch {
+	case strings.HasSuffix(file.path, ".history.json"):
+		var update backend.UpdateInfo
+		if err = json.Unmarshal(data, &update); err != nil {
+			return err
+		}
+		cfg = update.Config
+	case strings.HasSuffix(file.path, ".events.json") || strings.HasPrefix(file.path, r.b.journalDirectory(r.name)):
+		return nil
+	default:
+		chk, err := stack.UnmarshalVersionedCheckpointToLatestCheckpoint(data)
+		if err != nil {
+			return err
+		}
+		cfg = chk.Config
+	}
+	_, err = cfg.Decrypt(r.enc)
+	return err
+}
+
+// commit writes the re-encrypted files, and then calls the given function.  If a file can't be written, or the
+// function fails, the files already written are put back as they were.
+func (r *secretsRotation) commit(finish func() error) error {
+	var err error
+	written := 0
+	for _, file := range r.files {
+		if err = r.b.storage.WriteFile(file.path, file.new); err != nil {
+			err = errors.Wrapf(err, "writing %s", r.b.storage.Describe(file.path))
+			break
+		}
+		written++
+	}
+	if err == nil {
+		if err = finish(); err == nil {
+			return nil
+		}
+	}
+
+	for _, file := range r.files[:written] {
+		if restoreErr := r.b.storage.WriteFile(file.path, file.old); restoreErr != nil {
+			return errors.Wrapf(err, "%s could not be restored (%v), and its secrets can only be read with the new "+
+				"key", r.b.storage.Describe(file.path), restoreErr)
+		}
+	}
+	return err
+}
+
+// reencryptConfig returns a copy of a configuration with its secret values decrypted with one crypter and encrypted
+// with another.
+func reencryptConfig(cfg config.Map, dec config.Decrypter, enc config.Encrypter) (config.Map, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	result := make(config.Map)
+	for key, value := range cfg {
+		copied, err := value.Copy(dec, config.EncrypterForKey(enc, key))
+		if err != nil {
+			return nil, errors.Wrapf(err, "re-encrypting '%s'", key)
+		}
+		result[key] = copied
+	}
+	return result, nil
+}
@@ -0,0 +1,185 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/backend"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/config"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/resource/stack"
+	"github.com/pulumi/pulumi/pkg/tokens"
+)
+
+// failingStorage is a storage that fails to write the files that match a filter.
+type failingStorage struct {
+	storage
+	fail func(path string) bool
+}
+
+func (s *failingStorage) WriteFile(path string, data []byte) error {
+	if s.fail(path) {
+		return errors.Errorf("injected failure writing %s", path)
+	}
+	return s.storage.WriteFile(path, data)
+}
+
+// newSecretsTestBackend returns a backend holding a stack whose checkpoint has a secret configuration value and a
+// secret output, both encrypted with the returned crypter, along with a record of the update that wrote it.
+func newSecretsTestBackend(t *testing.T, name tokens.QName) (*localBackend, config.Crypter, func()) {
+	b, cleanup := newTestBackend(t)
+	crypter := withTestCrypter(b, name)
+
+	password, err := crypter.EncryptValue("hunter2")
+	assert.NoError(t, err)
+	cfg := config.Map{config.MustMakeKey("proj", "password"): config.NewSecureValue(password)}
+
+	res := resource.NewState("test:index:Database", "urn:pulumi:dev::proj::test:index:Database::db", true, false,
+		"db-1", resource.PropertyMap{},
+		resource.NewPropertyMapFromMap(map[string]interface{}{"password": "hunter2"}), "", false, nil)
+	res.SecretOutputs = []resource.PropertyKey{"password"}
+	_, _, err = b.writeStack(name, cfg, deploy.NewSnapshot(deploy.Manifest{}, []*resource.State{res}))
+	assert.NoError(t, err)
+	assert.NoError(t, b.addToHistory(name, backend.UpdateInfo{Kind: backend.DeployUpdate, Config: cfg}, nil))
+	return b, crypter, cleanup
+}
+
+// storedFiles returns the contents of every file of a stack's checkpoint and history.
+func storedFiles(t *testing.T, b *localBackend, name tokens.QName) map[string]string {
+	contents := make(map[string]string)
+	for _, dir := range []string{filepath.Dir(b.stackPath(name)), b.historyDirectory(name)} {
+		files, err := b.storage.List(dir)
+		assert.NoError(t, err)
+		for _, f := range files {
+			data, err := b.storage.ReadFile(filepath.Join(dir, f.Name))
+			assert.NoError(t, err)
+			contents[filepath.Join(dir, f.Name)] = string(data)
+		}
+	}
+	return contents
+}
+
+// assertReadableWith checks that every secret in a stack's checkpoint and history can be read with the given crypter,
+// or that none can.
+func assertReadableWith(t *testing.T, b *localBackend, name tokens.QName, dec config.Decrypter, readable bool) {
+	for file, data := range storedFiles(t, b, name) {
+		if strings.HasSuffix(file, ".history.json") {
+			continue
+		}
+		chk, err := stack.UnmarshalVersionedCheckpointToLatestCheckpoint([]byte(data))
+		if !assert.NoError(t, err, file) {
+			continue
+		}
+		_, err = chk.Config.Decrypt(dec)
+		assert.Equal(t, readable, err == nil, "%s: %v", file, err)
+
+		snap, err := stack.DeserializeCheckpoint(chk)
+		assert.NoError(t, err, file)
+		err = stack.DecryptSecretOutputs(snap, func() (config.Decrypter, error) { return dec, nil })
+		assert.Equal(t, readable, err == nil, "%s: %v", file, err)
+		if readable {
+			assert.Equal(t, resource.NewStringProperty("hunter2"), snap.Resources[0].Outputs["password"], file)
+		}
+	}
+
+	history, err := b.getHistory(name)
+	assert.NoError(t, err)
+	for _, update := range history {
+		_, err = update.Config.Decrypt(dec)
+		assert.Equal(t, readable, err == nil, "%v", err)
+	}
+}
+
+func TestSecretsRotation(t *testing.T) {
+	name := tokens.QName("dev")
+	b, old, cleanup := newSecretsTestBackend(t, name)
+	defer cleanup()
+	enc := config.NewSymmetricCrypter([]byte(strings.Repeat("n", config.SymmetricCrypterKeyBytes)))
+
+	r := &secretsRotation{b: b, name: name, dec: old, enc: enc}
+	assert.NoError(t, r.prepare())
+	assert.NoError(t, r.verify(nil))
+	assert.NoError(t, r.commit(func() error { return nil }))
+
+	// The checkpoint, its history copy, and the update's record all hold secrets.
+	assert.Len(t, r.files, 3)
+	assertReadableWith(t, b, name, enc, true)
+	assertReadableWith(t, b, name, old, false)
+}
+
+// TestSecretsRotationUndecryptable aborts a rotation in which a secret can't be read with the stack's current key,
+// without writing anything.
+func TestSecretsRotationUndecryptable(t *testing.T) {
+	name := tokens.QName("dev")
+	b, old, cleanup := newSecretsTestBackend(t, name)
+	defer cleanup()
+	other := config.NewSymmetricCrypter([]byte(strings.Repeat("o", config.SymmetricCrypterKeyBytes)))
+	enc := config.NewSymmetricCrypter([]byte(strings.Repeat("n", config.SymmetricCrypterKeyBytes)))
+
+	// Record an update whose configuration was encrypted with some other key.
+	ciphertext, err := other.EncryptValue("elsewhere")
+	assert.NoError(t, err)
+	assert.NoError(t, b.addToHistory(name, backend.UpdateInfo{
+		Kind:   backend.DeployUpdate,
+		Config: config.Map{config.MustMakeKey("proj", "token"): config.NewSecureValue(ciphertext)},
+	}, nil))
+	before := storedFiles(t, b, name)
+
+	r := &secretsRotation{b: b, name: name, dec: old, enc: enc}
+	assert.Error(t, r.prepare())
+	assert.Equal(t, before, storedFiles(t, b, name))
+}
+
+// TestSecretsRotationPartialFailure fails a rotation partway through writing its files, and when its settings can't
+// be saved afterwards, and checks that the stack's state is put back as it was each time.
+func TestSecretsRotationPartialFailure(t *testing.T) {
+	name := tokens.QName("dev")
+	b, old, cleanup := newSecretsTestBackend(t, name)
+	defer cleanup()
+	enc := config.NewSymmetricCrypter([]byte(strings.Repeat("n", config.SymmetricCrypterKeyBytes)))
+	before := storedFiles(t, b, name)
+
+	// The record of the update is written after the checkpoints, which must then be put back.
+	fs := &failingStorage{storage: b.storage, fail: func(path string) bool {
+		return strings.HasSuffix(path, ".history.json")
+	}}
+	b.storage = fs
+	r := &secretsRotation{b: b, name: name, dec: old, enc: enc}
+	assert.NoError(t, r.prepare())
+	finished := false
+	err := r.commit(func() error {
+		finished = true
+		return nil
+	})
+	assert.Error(t, err)
+	assert.False(t, finished)
+	b.storage = fs.storage
+	assert.Equal(t, before, storedFiles(t, b, name))
+	assertReadableWith(t, b, name, old, true)
+
+	r = &secretsRotation{b: b, name: name, dec: old, enc: enc}
+	assert.NoError(t, r.prepare())
+	err = r.commit(func() error { return errors.New("could not save the stack's settings") })
+	assert.Error(t, err)
+	assert.Equal(t, before, storedFiles(t, b, name))
+	assertReadableWith(t, b, name, old, true)
+}
@@ -15,32 +15,149 @@
 package local
 
 import (
+	"encoding/json"
 	"os"
+	"time"
 
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/backend"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/config"
 	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/resource/stack"
 	"github.com/pulumi/pulumi/pkg/tokens"
+	"github.com/pulumi/pulumi/pkg/util/logging"
 )
 
-// localSnapshotManager is a simple SnapshotManager implementation that persists snapshots
-// to disk on the local machine.
+// localSnapshotPersister is a SnapshotPersister that persists snapshots to the storage of a local backend.  The first
+// snapshot that it saves is written as the stack's checkpoint; each one after it is recorded as an entry in the
+// checkpoint's journal, until the journal is compacted.
 type localSnapshotPersister struct {
 	name    tokens.QName
 	backend *localBackend
+
+	config         config.Map              // the stack's configuration, saved along with its snapshots.
+	configLoaded   bool                    // true once the stack's configuration has been loaded.
+	last           *deploy.Snapshot        // the last snapshot saved.
+	indices        map[*resource.State]int // the index of each resource in the last snapshot saved.
+	checkpointTime time.Time               // the manifest time of the checkpoint that the journal follows.
+	checkpointSize int                     // the size of the checkpoint that the journal follows.
+	entries        int                     // the number of entries in the journal.
+	journalSize    int                     // the total size of the entries in the journal.
 }
 
+var _ backend.IncrementalSnapshotPersister = (*localSnapshotPersister)(nil)
+
 func (sm *localSnapshotPersister) Invalidate() error {
 	return nil
 }
 
 func (sm *localSnapshotPersister) Save(snapshot *deploy.Snapshot) error {
-	config, _, _, err := sm.backend.getStack(sm.name)
-	if err != nil && !os.IsNotExist(err) {
+	return sm.compact(snapshot)
+}
+
+func (sm *localSnapshotPersister) SaveIncremental(snapshot *deploy.Snapshot, changed []*resource.State) error {
+	// Compact the journal once its entries add up to more than the checkpoint, so that the time spent rewriting the
+	// checkpoint is proportional to the size of the changes since it was last written.
+	if sm.last == nil || sm.entries >= maxJournalEntries || sm.journalSize > sm.checkpointSize {
+		return sm.compact(snapshot)
+	}
+
+	entry := sm.diff(snapshot, changed)
+	if err := stack.EncryptSecretOutputs(entry.resources(), sm.backend.secretOutputsEncrypter(sm.name)); err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "serializing journal entry")
+	}
+	if data, err = sm.backend.encodeState(sm.name, data); err != nil {
+		return err
+	}
+	entryPath := sm.backend.journalPath(sm.name, sm.checkpointTime, sm.entries+1)
+	if err = sm.backend.storage.WriteFile(entryPath, data); err != nil {
+		return errors.Wrap(err, "An IO error occurred during the current operation")
+	}
+	logging.V(7).Infof("Saved stack %s journal entry to: %s", sm.name, sm.backend.storage.Describe(entryPath))
+
+	sm.entries++
+	sm.journalSize += len(data)
+	sm.remember(snapshot)
+	return nil
+}
+
+// compactJournal writes the last snapshot saved as the stack's checkpoint, if it is only recorded in the journal.
+func (sm *localSnapshotPersister) compactJournal() error {
+	if sm.entries == 0 {
+		return nil
+	}
+	return sm.compact(sm.last)
+}
+
+// compact writes a snapshot as the stack's checkpoint, replacing its journal.
+func (sm *localSnapshotPersister) compact(snapshot *deploy.Snapshot) error {
+	if !sm.configLoaded {
+		config, _, _, err := sm.backend.getStack(sm.name)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		sm.config, sm.configLoaded = config, true
+	}
+
+	_, size, err := sm.backend.writeStack(sm.name, sm.config, snapshot)
+	if err != nil {
 		return err
 	}
 
-	_, err = sm.backend.saveStack(sm.name, config, snapshot)
-	return err
+	sm.checkpointTime = snapshot.Manifest.Time
+	sm.checkpointSize = size
+	sm.entries = 0
+	sm.journalSize = 0
+	sm.remember(snapshot)
+	return nil
+}
+
+// diff returns the journal entry that records how a snapshot differs from the last snapshot saved.  Resources that
+// were in the last snapshot, and weren't changed in place, are recorded as runs of its resources; only the rest are
+// serialized.
+func (sm *localSnapshotPersister) diff(snapshot *deploy.Snapshot, changed []*resource.State) *journalEntry {
+	isChanged := make(map[*resource.State]bool)
+	for _, res := range changed {
+		isChanged[res] = true
+	}
+
+	entry := &journalEntry{Manifest: stack.SerializeManifest(snapshot.Manifest)}
+	for _, res := range snapshot.Resources {
+		i, ok := sm.indices[res]
+		if !ok || isChanged[res] {
+			serialized := stack.SerializeResource(res)
+			entry.Resources = append(entry.Resources, journalResources{Resource: &serialized})
+			continue
+		}
+
+		// Extend the last run if this resource follows it in the last snapshot, too.
+		if n := len(entry.Resources); n > 0 && entry.Resources[n-1].Resource == nil && entry.Resources[n-1].To == i {
+			entry.Resources[n-1].To++
+		} else {
+			entry.Resources = append(entry.Resources, journalResources{From: i, To: i + 1})
+		}
+	}
+	for _, op := range snapshot.PendingOperations {
+		entry.PendingOperations = append(entry.PendingOperations, stack.SerializeOperation(op))
+	}
+	return entry
+}
 
+// remember records a snapshot as the last one saved.
+func (sm *localSnapshotPersister) remember(snapshot *deploy.Snapshot) {
+	sm.last = snapshot
+	sm.indices = make(map[*resource.State]int)
+	if snapshot != nil {
+		for i, res := range snapshot.Resources {
+			sm.indices[res] = i
+		}
+	}
 }
 
 func (b *localBackend) newSnapshotPersister(stackName tokens.QName) *localSnapshotPersister {
@@ -29,25 +29,27 @@ import (
 // Stack is a local stack.  This simply adds some local-specific properties atop the standard backend stack interface.
 type Stack interface {
 	backend.Stack
-	Path() string // a path to the stack's checkpoint file on disk.
+	Path() string // the location of the stack's checkpoint file, on disk or in an object store.
 }
 
 // localStack is a local stack descriptor.
 type localStack struct {
-	name     backend.StackReference // the stack's name.
-	path     string                 // a path to the stack's checkpoint file on disk.
-	config   config.Map             // the stack's config bag.
-	snapshot *deploy.Snapshot       // a snapshot representing the latest deployment state.
-	b        *localBackend          // a pointer to the backend this stack belongs to.
+	name     backend.StackReference          // the stack's name.
+	path     string                          // the location of the stack's checkpoint file.
+	config   config.Map                      // the stack's config bag.
+	snapshot *deploy.Snapshot                // a snapshot representing the latest deployment state.
+	tags     map[apitype.StackTagName]string // the stack's tags.
+	b        *localBackend                   // a pointer to the backend this stack belongs to.
 }
 
 func newStack(name backend.StackReference, path string, config config.Map,
-	snapshot *deploy.Snapshot, b *localBackend) Stack {
+	snapshot *deploy.Snapshot, tags map[apitype.StackTagName]string, b *localBackend) Stack {
 	return &localStack{
 		name:     name,
 		path:     path,
 		config:   config,
 		snapshot: snapshot,
+		tags:     tags,
 		b:        b,
 	}
 }
@@ -57,6 +59,7 @@ func (s *localStack) Config() config.Map                                     { r
 func (s *localStack) Snapshot(ctx context.Context) (*deploy.Snapshot, error) { return s.snapshot, nil }
 func (s *localStack) Backend() backend.Backend                               { return s.b }
 func (s *localStack) Path() string                                           { return s.path }
+func (s *localStack) Tags() map[apitype.StackTagName]string                  { return s.tags }
 
 func (s *localStack) Remove(ctx context.Context, force bool) (bool, error) {
 	return backend.RemoveStack(ctx, s, force)
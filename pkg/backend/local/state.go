@@ -17,7 +17,6 @@ package local
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
@@ -29,9 +28,11 @@ import (
 	"github.com/pulumi/pulumi/pkg/apitype"
 	"github.com/pulumi/pulumi/pkg/backend"
 	"github.com/pulumi/pulumi/pkg/encoding"
+	"github.com/pulumi/pulumi/pkg/engine"
 	"github.com/pulumi/pulumi/pkg/resource/config"
 	"github.com/pulumi/pulumi/pkg/resource/deploy"
 	"github.com/pulumi/pulumi/pkg/resource/stack"
+	"github.com/pulumi/pulumi/pkg/secrets"
 	"github.com/pulumi/pulumi/pkg/tokens"
 	"github.com/pulumi/pulumi/pkg/util/cmdutil"
 	"github.com/pulumi/pulumi/pkg/util/contract"
@@ -42,6 +43,17 @@ import (
 
 const DisableCheckpointBackupsEnvVar = "PULUMI_DISABLE_CHECKPOINT_BACKUPS"
 
+// CompressCheckpointsEnvVar is the environment variable that, if truthy, causes checkpoints to be written compressed.
+// Checkpoints are read whether or not they are compressed, so this can be changed at any time; each checkpoint is
+// rewritten in the chosen format the next time its stack is updated.
+const CompressCheckpointsEnvVar = "PULUMI_COMPRESS_CHECKPOINTS"
+
+// EncryptCheckpointsEnvVar is the environment variable that, if truthy, causes the whole state of stacks, not just
+// their secret values, to be written encrypted with the passphrase of each stack.  This covers their checkpoints and
+// the journals, history, and backups of them.  Encrypted state is read whether or not this is set, as long as the
+// passphrase is available.
+const EncryptCheckpointsEnvVar = "PULUMI_ENCRYPT_CHECKPOINTS"
+
 // DisableIntegrityChecking can be set to true to disable checkpoint state integrity verification.  This is not
 // recommended, because it could mean proceeding even in the face of a corrupted checkpoint state file, but can
 // be used as a last resort when a command absolutely must be run.
@@ -67,11 +79,12 @@ func (u *update) GetTarget() *deploy.Target {
 	return u.target
 }
 
-func (b *localBackend) newUpdate(stackName tokens.QName, proj *workspace.Project, root string) (*update, error) {
+func (b *localBackend) newUpdate(stackName tokens.QName, proj *workspace.Project, root string,
+	configEnv []workspace.ConfigEnvVar) (*update, error) {
 	contract.Require(stackName != "", "stackName")
 
 	// Construct the deployment target.
-	target, err := b.getTarget(stackName)
+	target, err := b.getTarget(stackName, configEnv)
 	if err != nil {
 		return nil, err
 	}
@@ -85,23 +98,35 @@ func (b *localBackend) newUpdate(stackName tokens.QName, proj *workspace.Project
 	}, nil
 }
 
-func (b *localBackend) getTarget(stackName tokens.QName) (*deploy.Target, error) {
-	stk, err := workspace.DetectProjectStack(stackName)
+func (b *localBackend) getTarget(stackName tokens.QName, configEnv []workspace.ConfigEnvVar) (*deploy.Target, error) {
+	cfg, _, err := workspace.DetectStackConfig(stackName)
+	if err != nil {
+		return nil, err
+	}
+	err = backend.ApplyConfigEnv(cfg, configEnv, func() (config.Encrypter, error) {
+		return b.stackCrypter(stackName)
+	})
 	if err != nil {
 		return nil, err
 	}
-	decrypter, err := defaultCrypter(stackName, stk.Config)
+	decrypter, err := b.defaultCrypter(stackName, cfg)
 	if err != nil {
 		return nil, err
 	}
+	// The target's snapshot is what a plan's changes are made against, so the checkpoint is expected to still be the
+	// version read here, rather than any read before, when those changes are written.
+	b.forgetCheckpointVersion(stackName)
 	_, snapshot, _, err := b.getStack(stackName)
 	if err != nil {
 		return nil, err
 	}
+	if err = stack.DecryptSecretOutputs(snapshot, b.secretOutputsDecrypter(stackName)); err != nil {
+		return nil, err
+	}
 	return &deploy.Target{
 		Name:      stackName,
-		Config:    stk.Config,
-		Decrypter: decrypter,
+		Config:    cfg,
+		Decrypter: secrets.NewReferenceResolver(decrypter),
 		Snapshot:  snapshot,
 	}, nil
 }
@@ -111,7 +136,7 @@ func (b *localBackend) getStack(name tokens.QName) (config.Map, *deploy.Snapshot
 		return nil, nil, "", errors.New("invalid empty stack name")
 	}
 
-	file := b.stackPath(name)
+	file := b.storage.Describe(b.stackPath(name))
 
 	chk, err := b.getCheckpoint(name)
 	if err != nil {
@@ -127,8 +152,9 @@ func (b *localBackend) getStack(name tokens.QName) (config.Map, *deploy.Snapshot
 	// Ensure the snapshot passes verification before returning it, to catch bugs early.
 	if !DisableIntegrityChecking {
 		if verifyerr := snapshot.VerifyIntegrity(); verifyerr != nil {
-			return nil, nil, file,
-				errors.Wrapf(verifyerr, "%s: snapshot integrity failure; refusing to use it", file)
+			return nil, nil, file, errors.Wrapf(verifyerr,
+				"%s: snapshot integrity failure; refusing to use it (run `pulumi stack verify` to find every problem)",
+				file)
 		}
 	}
 
@@ -138,50 +164,86 @@ func (b *localBackend) getStack(name tokens.QName) (config.Map, *deploy.Snapshot
 // GetCheckpoint loads a checkpoint file for the given stack in this project, from the current project workspace.
 func (b *localBackend) getCheckpoint(stackName tokens.QName) (*apitype.CheckpointV1, error) {
 	chkpath := b.stackPath(stackName)
-	bytes, err := ioutil.ReadFile(chkpath)
+	bytes, err := b.readCheckpointFile(stackName)
 	if err != nil {
 		return nil, err
 	}
+	if bytes, err = b.decodeState(stackName, bytes); err != nil {
+		return nil, err
+	}
 
-	return stack.UnmarshalVersionedCheckpointToLatestCheckpoint(bytes)
+	chk, err := stack.UnmarshalVersionedCheckpointToLatestCheckpoint(bytes)
+	if err != nil {
+		return nil, err
+	}
+	if err = b.applyJournal(stackName, chk); err != nil {
+		return nil, errors.Wrapf(err, "applying the journal of %s", b.storage.Describe(chkpath))
+	}
+	return chk, nil
 }
 
 func (b *localBackend) saveStack(name tokens.QName,
 	config map[config.Key]config.Value, snap *deploy.Snapshot) (string, error) {
+	file, _, err := b.writeStack(name, config, snap)
+	return file, err
+}
+
+// writeStack saves a stack's checkpoint, replacing any journal of changes to the checkpoint that it had, and returns
+// the location and the size of the checkpoint.
+func (b *localBackend) writeStack(name tokens.QName,
+	config map[config.Key]config.Value, snap *deploy.Snapshot) (string, int, error) {
 	// Make a serializable stack and then use the encoder to encode it.
 	file := b.stackPath(name)
 	m, ext := encoding.Detect(file)
 	if m == nil {
-		return "", errors.Errorf("resource serialization failed; illegal markup extension: '%v'", ext)
+		return "", 0, errors.Errorf("resource serialization failed; illegal markup extension: '%v'", ext)
 	}
 	if filepath.Ext(file) == "" {
 		file = file + ext
 	}
-	chk := stack.SerializeCheckpoint(name, config, snap)
+	var latest *apitype.Deployment
+	if snap != nil {
+		latest = stack.SerializeDeployment(snap)
+		resources := stack.DeploymentResources(latest)
+		if err := stack.EncryptSecretOutputs(resources, b.secretOutputsEncrypter(name)); err != nil {
+			return "", 0, err
+		}
+	}
+	chk := stack.MakeCheckpoint(name, config, latest)
 	byts, err := m.Marshal(chk)
 	if err != nil {
-		return "", errors.Wrap(err, "An IO error occurred during the current operation")
+		return "", 0, errors.Wrap(err, "An IO error occurred during the current operation")
 	}
-
-	// Back up the existing file if it already exists.
-	bck := backupTarget(file)
-
-	// Ensure the directory exists.
-	if err = os.MkdirAll(filepath.Dir(file), 0700); err != nil {
-		return "", errors.Wrap(err, "An IO error occurred during the current operation")
+	if b.compressCheckpoints() {
+		if byts, err = stack.CompressCheckpoint(byts); err != nil {
+			return "", 0, errors.Wrap(err, "compressing checkpoint")
+		}
+	}
+	if byts, err = b.encodeState(name, byts); err != nil {
+		return "", 0, err
 	}
 
-	// And now write out the new snapshot file, overwriting that location.
-	if err = ioutil.WriteFile(file, byts, 0600); err != nil {
-		return "", errors.Wrap(err, "An IO error occurred during the current operation")
+	// Write out the new snapshot file, backing up the existing one.
+	bck, err := b.writeCheckpointFile(name, file, byts)
+	if err != nil {
+		if IsCheckpointConflictError(err) {
+			return "", 0, err
+		}
+		return "", 0, errors.Wrap(err, "An IO error occurred during the current operation")
 	}
 
-	logging.V(7).Infof("Saved stack %s checkpoint to: %s (backup=%s)", name, file, bck)
+	logging.V(7).Infof("Saved stack %s checkpoint to: %s (backup=%s)", name, b.storage.Describe(file), bck)
+
+	// The checkpoint now holds any changes that were journaled, and the journal, which was kept for the previous
+	// checkpoint, would never be applied to this one anyway.
+	if err = b.storage.RemoveAll(b.journalDirectory(name)); err != nil {
+		logging.V(7).Infof("Failed to remove the journal of stack %s: %v", name, err)
+	}
 
 	// And if we are retaining historical checkpoint information, write it out again
 	if cmdutil.IsTruthy(os.Getenv("PULUMI_RETAIN_CHECKPOINTS")) {
-		if err = ioutil.WriteFile(fmt.Sprintf("%v.%v", file, time.Now().UnixNano()), byts, 0600); err != nil {
-			return "", errors.Wrap(err, "An IO error occurred during the current operation")
+		if err = b.storage.WriteFile(fmt.Sprintf("%v.%v", file, time.Now().UnixNano()), byts); err != nil {
+			return "", 0, errors.Wrap(err, "An IO error occurred during the current operation")
 		}
 	}
 
@@ -190,13 +252,92 @@ func (b *localBackend) saveStack(name tokens.QName,
 		// out the checkpoint file since it may contain resource state updates.  But we will warn the user that the
 		// file is already written and might be bad.
 		if verifyerr := snap.VerifyIntegrity(); verifyerr != nil {
-			return "", errors.Wrapf(verifyerr,
+			return "", 0, errors.Wrapf(verifyerr,
 				"%s: snapshot integrity failure; it was already written, but is invalid (backup available at %s)",
-				file, bck)
+				b.storage.Describe(file), bck)
+		}
+	}
+
+	return b.storage.Describe(file), len(byts), nil
+}
+
+// compressCheckpoints returns true if checkpoints should be written compressed: if they were asked to be, and the
+// storage can hold files that aren't JSON.
+func (b *localBackend) compressCheckpoints() bool {
+	return b.canWriteBinary(CompressCheckpointsEnvVar)
+}
+
+// compressBackups returns true if backups should be written compressed, just as compressCheckpoints does for
+// checkpoints.
+func (b *localBackend) compressBackups() bool {
+	return b.canWriteBinary(CompressBackupsEnvVar)
+}
+
+// encryptCheckpoints returns true if the state of stacks should be written encrypted, just as compressCheckpoints
+// does for compression.
+func (b *localBackend) encryptCheckpoints() bool {
+	return b.canWriteBinary(EncryptCheckpointsEnvVar)
+}
+
+// canWriteBinary returns true if the given environment variable asks for files to be written in a binary format, as
+// when compressing or encrypting them, and the storage can hold files that aren't JSON.
+func (b *localBackend) canWriteBinary(envVar string) bool {
+	if !cmdutil.IsTruthy(os.Getenv(envVar)) {
+		return false
+	}
+	if _, ok := b.storage.(jsonStorage); ok {
+		logging.V(7).Infof("Ignoring %s, since %s only holds JSON", envVar, b.Name())
+		return false
+	}
+	return true
+}
+
+// encodeState encrypts the contents of a file that holds some of a stack's state, such as its checkpoint or an entry
+// of its journal, if state is to be written encrypted.
+func (b *localBackend) encodeState(name tokens.QName, data []byte) ([]byte, error) {
+	if !b.encryptCheckpoints() {
+		return data, nil
+	}
+	crypter, err := b.stackCrypter(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting the passphrase to encrypt the state of stack '%s'", name)
+	}
+	return stack.EncryptCheckpoint(data, crypter)
+}
+
+// secretOutputsEncrypter returns a function that gets the crypter with which the secret outputs of a stack's resources
+// are encrypted, for stack.EncryptSecretOutputs.
+func (b *localBackend) secretOutputsEncrypter(name tokens.QName) func() (config.Encrypter, error) {
+	return func() (config.Encrypter, error) {
+		crypter, err := b.stackCrypter(name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting the passphrase to encrypt the secret outputs of stack '%s'", name)
 		}
+		return crypter, nil
 	}
+}
 
-	return file, nil
+// secretOutputsDecrypter returns a function that gets the decrypter for the secret outputs of a stack's resources, for
+// stack.DecryptSecretOutputs.
+func (b *localBackend) secretOutputsDecrypter(name tokens.QName) func() (config.Decrypter, error) {
+	return func() (config.Decrypter, error) {
+		return b.stateDecrypter(name)
+	}
+}
+
+// decodeState returns the contents of a file that holds some of a stack's state, decrypted, if it is encrypted, and
+// decompressed.
+func (b *localBackend) decodeState(name tokens.QName, data []byte) ([]byte, error) {
+	if stack.IsEncryptedCheckpoint(data) {
+		decrypter, err := b.stateDecrypter(name)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = stack.DecryptCheckpoint(data, decrypter); err != nil {
+			return nil, err
+		}
+	}
+	return stack.DecompressCheckpoint(data)
 }
 
 // removeStack removes information about a stack from the current workspace.
@@ -205,21 +346,36 @@ func (b *localBackend) removeStack(name tokens.QName) error {
 
 	// Just make a backup of the file and don't write out anything new.
 	file := b.stackPath(name)
-	backupTarget(file)
+	b.backupTarget(file)
+	b.forgetCheckpointVersion(name)
+
+	if err := b.storage.RemoveAll(b.journalDirectory(name)); err != nil {
+		return err
+	}
+	if err := b.storage.Remove(b.tagsPath(name)); err != nil {
+		return err
+	}
 
 	historyDir := b.historyDirectory(name)
-	return os.RemoveAll(historyDir)
+	return b.storage.RemoveAll(historyDir)
 }
 
 // backupTarget makes a backup of an existing file, in preparation for writing a new one.  Instead of a copy, it
-// simply renames the file, which is simpler, more efficient, etc.
-func backupTarget(file string) string {
+// simply renames the file, which is simpler, more efficient, etc., unless the storage writes files conditionally.
+func (b *localBackend) backupTarget(file string) string {
 	contract.Require(file != "", "file")
 	bck := file + ".bak"
-	err := os.Rename(file, bck)
-	contract.IgnoreError(err) // ignore errors.
+	if _, ok := b.storage.(conditionalStorage); ok {
+		// The file is copied, rather than moved, since it must still exist to be written conditionally.
+		if data, err := b.storage.ReadFile(file); err == nil {
+			contract.IgnoreError(b.storage.WriteFile(bck, data))
+		}
+	} else {
+		err := b.storage.Rename(file, bck)
+		contract.IgnoreError(err) // ignore errors.
+	}
 	// IDEA: consider multiple backups (.bak.bak.bak...etc).
-	return bck
+	return b.storage.Describe(bck)
 }
 
 // backupStack copies the current Checkpoint file to ~/.pulumi/backups.
@@ -233,7 +389,7 @@ func (b *localBackend) backupStack(name tokens.QName) error {
 
 	// Read the current checkpoint file. (Assuming it aleady exists.)
 	stackPath := b.stackPath(name)
-	byts, err := ioutil.ReadFile(stackPath)
+	byts, err := b.storage.ReadFile(stackPath)
 	if err != nil {
 		return err
 	}
@@ -241,21 +397,31 @@ func (b *localBackend) backupStack(name tokens.QName) error {
 	// Get the backup directory.
 	backupDir := b.backupDirectory(name)
 
-	// Ensure the backup directory exists.
-	if err = os.MkdirAll(backupDir, 0700); err != nil {
-		return err
-	}
-
 	// Write out the new backup checkpoint file.
 	stackFile := filepath.Base(stackPath)
 	ext := filepath.Ext(stackFile)
 	base := strings.TrimSuffix(stackFile, ext)
 	backupFile := fmt.Sprintf("%s.%v%s", base, time.Now().UnixNano(), ext)
-	return ioutil.WriteFile(filepath.Join(backupDir, backupFile), byts, 0600)
+	if b.compressBackups() && !stack.IsCompressedCheckpoint(byts) && !stack.IsEncryptedCheckpoint(byts) {
+		if byts, err = stack.CompressCheckpoint(byts); err != nil {
+			return errors.Wrap(err, "compressing backup")
+		}
+	}
+	if err = b.storage.WriteFile(filepath.Join(backupDir, backupFile), byts); err != nil {
+		return err
+	}
+
+	// Now that there's a new backup, remove those that are no longer to be kept.
+	policy, err := BackupRetentionFromEnv()
+	if err != nil {
+		return err
+	}
+	_, err = b.pruneBackups(name, policy, false)
+	return err
 }
 
 func (b *localBackend) stackPath(stack tokens.QName) string {
-	path := filepath.Join(b.stateRoot, workspace.StackDir)
+	path := workspace.StackDir
 	if stack != "" {
 		path = filepath.Join(path, fsutil.QnamePath(stack)+".json")
 	}
@@ -266,13 +432,48 @@ func (b *localBackend) stackPath(stack tokens.QName) string {
 func (b *localBackend) historyDirectory(stack tokens.QName) string {
 	contract.Require(stack != "", "stack")
 
-	return filepath.Join(b.stateRoot, workspace.HistoryDir, fsutil.QnamePath(stack))
+	return filepath.Join(workspace.HistoryDir, fsutil.QnamePath(stack))
 }
 
 func (b *localBackend) backupDirectory(stack tokens.QName) string {
 	contract.Require(stack != "", "stack")
 
-	return filepath.Join(b.stateRoot, workspace.BackupDir, fsutil.QnamePath(stack))
+	return filepath.Join(workspace.BackupDir, fsutil.QnamePath(stack))
+}
+
+// durationsPath returns the path of the file recording how long the operations of a stack's past updates took.
+func (b *localBackend) durationsPath(stack tokens.QName) string {
+	return filepath.Join(b.historyDirectory(stack), "durations.json")
+}
+
+// getDurations loads the durations of the operations of a stack's past updates.  A stack that has never been updated
+// has no recorded durations.
+func (b *localBackend) getDurations(name tokens.QName) (*engine.OperationDurations, error) {
+	contract.Require(name != "", "name")
+
+	durations := engine.NewOperationDurations()
+	byts, err := b.storage.ReadFile(b.durationsPath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return durations, nil
+		}
+		return nil, err
+	}
+	if err = json.Unmarshal(byts, durations); err != nil {
+		return nil, errors.Wrapf(err, "reading durations file %s", b.durationsPath(name))
+	}
+	return durations, nil
+}
+
+// saveDurations saves the durations of the operations of a stack's updates.
+func (b *localBackend) saveDurations(name tokens.QName, durations *engine.OperationDurations) error {
+	contract.Require(name != "", "name")
+
+	byts, err := json.MarshalIndent(durations, "", "    ")
+	if err != nil {
+		return err
+	}
+	return b.storage.WriteFile(b.durationsPath(name), byts)
 }
 
 // getHistory returns locally stored update history. The first element of the result will be
@@ -280,23 +481,20 @@ func (b *localBackend) backupDirectory(stack tokens.QName) string {
 func (b *localBackend) getHistory(name tokens.QName) ([]backend.UpdateInfo, error) {
 	contract.Require(name != "", "name")
 
+	// History doesn't exist until a stack has been updated, in which case there are no files.
 	dir := b.historyDirectory(name)
-	allFiles, err := ioutil.ReadDir(dir)
+	allFiles, err := b.storage.List(dir)
 	if err != nil {
-		// History doesn't exist until a stack has been updated.
-		if os.IsNotExist(err) {
-			return nil, nil
-		}
 		return nil, err
 	}
 
 	var updates []backend.UpdateInfo
 
-	// os.ReadDir returns the array sorted by file name, but because of how we name files, older updates come before
+	// List returns the array sorted by file name, but because of how we name files, older updates come before
 	// newer ones. Loop backwards so we added the newest updates to the array we will return first.
 	for i := len(allFiles) - 1; i >= 0; i-- {
 		file := allFiles[i]
-		filepath := path.Join(dir, file.Name())
+		filepath := path.Join(dir, file.Name)
 
 		// Open all of the history files, ignoring the checkpoints.
 		if !strings.HasSuffix(filepath, ".history.json") {
@@ -304,13 +502,13 @@ func (b *localBackend) getHistory(name tokens.QName) ([]backend.UpdateInfo, erro
 		}
 
 		var update backend.UpdateInfo
-		b, err := ioutil.ReadFile(filepath)
+		byts, err := b.storage.ReadFile(filepath)
 		if err != nil {
-			return nil, errors.Wrapf(err, "reading history file %s", filepath)
+			return nil, errors.Wrapf(err, "reading history file %s", b.storage.Describe(filepath))
 		}
-		err = json.Unmarshal(b, &update)
+		err = json.Unmarshal(byts, &update)
 		if err != nil {
-			return nil, errors.Wrapf(err, "reading history file %s", filepath)
+			return nil, errors.Wrapf(err, "reading history file %s", b.storage.Describe(filepath))
 		}
 
 		updates = append(updates, update)
@@ -319,17 +517,63 @@ func (b *localBackend) getHistory(name tokens.QName) ([]backend.UpdateInfo, erro
 	return updates, nil
 }
 
-// addToHistory saves the UpdateInfo and makes a copy of the current Checkpoint file.
-func (b *localBackend) addToHistory(name tokens.QName, update backend.UpdateInfo) error {
+// getHistoryCheckpoint loads the copy of the checkpoint saved after the given update, where updates are numbered from
+// 1 (the oldest) as in the stack's history.
+func (b *localBackend) getHistoryCheckpoint(name tokens.QName, version int) (*apitype.CheckpointV1, error) {
 	contract.Require(name != "", "name")
 
 	dir := b.historyDirectory(name)
-	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+	allFiles, err := b.storage.List(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// As in getHistory, files are named such that older updates come first.
+	var checkpoints []string
+	for _, file := range allFiles {
+		if strings.HasSuffix(file.Name, ".checkpoint.json") {
+			checkpoints = append(checkpoints, path.Join(dir, file.Name))
+		}
+	}
+	if version < 1 || version > len(checkpoints) {
+		return nil, errors.Errorf("stack '%s' has no update with version %d", name, version)
+	}
+
+	bytes, err := b.storage.ReadFile(checkpoints[version-1])
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading checkpoint file %s", b.storage.Describe(checkpoints[version-1]))
+	}
+	if bytes, err = b.decodeState(name, bytes); err != nil {
+		return nil, err
+	}
+	return stack.UnmarshalVersionedCheckpointToLatestCheckpoint(bytes)
+}
+
+// addToHistory saves the UpdateInfo and makes a copy of the current Checkpoint file, along with the events of the
+// update, if any were recorded.
+func (b *localBackend) addToHistory(name tokens.QName, update backend.UpdateInfo,
+	events []apitype.EngineEvent) error {
+	contract.Require(name != "", "name")
+
+	// Make a copy of the checkpoint file. (Assuming it aleady exists.)
+	byts, err := b.storage.ReadFile(b.stackPath(name))
+	if err != nil {
 		return err
 	}
 
+	return b.writeHistoryEntry(name, time.Now(), update, byts, events)
+}
+
+// writeHistoryEntry saves the UpdateInfo of an update to a stack that happened at the given time, along with the
+// contents of the checkpoint file that it left and the events that it emitted, if any were recorded.  Entries are
+// named such that they sort in the order of their times.
+func (b *localBackend) writeHistoryEntry(name tokens.QName, when time.Time, update backend.UpdateInfo,
+	checkpoint []byte, events []apitype.EngineEvent) error {
+
+	dir := b.historyDirectory(name)
+
 	// Prefix for the update and checkpoint files.
-	pathPrefix := path.Join(dir, fmt.Sprintf("%s-%d", name, time.Now().UnixNano()))
+	pathPrefix := path.Join(dir, fmt.Sprintf("%s-%d", name, when.UnixNano()))
 
 	// Save the history file.
 	byts, err := json.MarshalIndent(&update, "", "    ")
@@ -338,16 +582,63 @@ func (b *localBackend) addToHistory(name tokens.QName, update backend.UpdateInfo
 	}
 
 	historyFile := fmt.Sprintf("%s.history.json", pathPrefix)
-	if err = ioutil.WriteFile(historyFile, byts, os.ModePerm); err != nil {
+	if err = b.storage.WriteFile(historyFile, byts); err != nil {
 		return err
 	}
 
-	// Make a copy of the checkpoint file. (Assuming it aleady exists.)
-	byts, err = ioutil.ReadFile(b.stackPath(name))
+	if events != nil {
+		// The events hold the same values as the checkpoint, so they're encrypted if it is.
+		if byts, err = json.Marshal(events); err != nil {
+			return err
+		}
+		if byts, err = b.encodeState(name, byts); err != nil {
+			return err
+		}
+		if err = b.storage.WriteFile(eventsFile(historyFile), byts); err != nil {
+			return err
+		}
+	}
+
+	checkpointFile := fmt.Sprintf("%s.checkpoint.json", pathPrefix)
+	return b.storage.WriteFile(checkpointFile, checkpoint)
+}
+
+// importHistory records updates made to a stack elsewhere as its history, along with the deployment that each left.
+// As from getHistory, the newest update comes first.
+func (b *localBackend) importHistory(name tokens.QName, updates []backend.UpdateInfo,
+	deployments []*apitype.UntypedDeployment) error {
+	contract.Require(name != "", "name")
+	contract.Require(len(deployments) == len(updates), "deployments")
+
+	existing, err := b.getHistory(name)
 	if err != nil {
 		return err
 	}
+	if len(existing) > 0 {
+		return errors.Errorf("stack '%s' already has a history of %d update(s)", name, len(existing))
+	}
 
-	checkpointFile := fmt.Sprintf("%s.checkpoint.json", pathPrefix)
-	return ioutil.WriteFile(checkpointFile, byts, os.ModePerm)
+	// Record the oldest update first.  Each is named for the time it started, offset by its position in the history
+	// so that updates started in the same second keep their order.
+	for i := len(updates) - 1; i >= 0; i-- {
+		update := updates[i]
+
+		var snap *deploy.Snapshot
+		if snap, err = stack.DeserializeDeployment(deployments[i]); err != nil {
+			return errors.Wrapf(err, "reading the deployment left by update %d", len(updates)-i)
+		}
+		var byts []byte
+		if byts, err = json.MarshalIndent(stack.SerializeCheckpoint(name, update.Config, snap), "", "    "); err != nil {
+			return err
+		}
+		if byts, err = b.encodeState(name, byts); err != nil {
+			return err
+		}
+
+		when := time.Unix(update.StartTime, int64(len(updates)-i))
+		if err = b.writeHistoryEntry(name, when, update, byts, nil); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,84 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/config"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/resource/stack"
+	"github.com/pulumi/pulumi/pkg/tokens"
+	"github.com/pulumi/pulumi/pkg/util/contract"
+)
+
+// newTestBackend returns a backend whose state is kept in a new temporary directory, which is removed by the
+// returned function.
+func newTestBackend(t *testing.T) (*localBackend, func()) {
+	dir, err := ioutil.TempDir("", "pulumi-local-backend-test")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	b := &localBackend{url: "file://" + dir, storage: &fsStorage{root: dir}}
+	return b, func() { contract.IgnoreError(os.RemoveAll(dir)) }
+}
+
+// withTestCrypter gives a stack a crypter, as if its passphrase had already been read.
+func withTestCrypter(b *localBackend, name tokens.QName) config.Crypter {
+	crypter := config.NewSymmetricCrypter(make([]byte, config.SymmetricCrypterKeyBytes))
+	b.crypters = map[tokens.QName]config.Crypter{name: crypter}
+	return crypter
+}
+
+// TestWriteStackEncryptsSecretOutputs writes a stack whose resource has a secret output, and checks that the stored
+// checkpoint holds only its ciphertext, that the checkpoint's checksum still matches, and that the secret is read back.
+func TestWriteStackEncryptsSecretOutputs(t *testing.T) {
+	b, cleanup := newTestBackend(t)
+	defer cleanup()
+	name := tokens.QName("dev")
+	crypter := withTestCrypter(b, name)
+
+	res := resource.NewState("test:index:Database", "urn:pulumi:dev::test::test:index:Database::db", true, false,
+		"db-1", resource.NewPropertyMapFromMap(map[string]interface{}{"name": "app"}),
+		resource.NewPropertyMapFromMap(map[string]interface{}{"name": "app", "password": "hunter2"}),
+		"", false, nil)
+	res.SecretOutputs = []resource.PropertyKey{"password"}
+	snap := deploy.NewSnapshot(deploy.Manifest{}, []*resource.State{res})
+
+	_, _, err := b.writeStack(name, nil, snap)
+	assert.NoError(t, err)
+
+	byts, err := b.storage.ReadFile(b.stackPath(name))
+	assert.NoError(t, err)
+	assert.False(t, strings.Contains(string(byts), "hunter2"), "the secret was stored in plaintext")
+
+	// The checksum covers the checkpoint as it was stored, with the secret encrypted.
+	_, err = stack.UnmarshalVersionedCheckpointToLatestCheckpoint(byts)
+	assert.NoError(t, err)
+
+	_, read, _, err := b.getStack(name)
+	assert.NoError(t, err)
+	err = stack.DecryptSecretOutputs(read, func() (config.Decrypter, error) { return crypter, nil })
+	assert.NoError(t, err)
+	if assert.Len(t, read.Resources, 1) {
+		assert.Equal(t, resource.NewStringProperty("hunter2"), read.Resources[0].Outputs["password"])
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// storage is where a local backend keeps its state: the checkpoints, history, backups and locks of its stacks.  Paths
+// are relative to the root of the storage.  Missing files are reported with errors for which os.IsNotExist is true,
+// just as they are by the os package.
+type storage interface {
+	// ReadFile returns the contents of a file.
+	ReadFile(path string) ([]byte, error)
+	// WriteFile writes a file, replacing it if it exists and creating any directories that it needs.
+	WriteFile(path string, data []byte) error
+	// CreateFile writes a file, failing with an error for which os.IsExist is true if the file already exists.
+	CreateFile(path string, data []byte) error
+	// Exists returns true if a file exists.
+	Exists(path string) (bool, error)
+	// Remove removes a file, if it exists.
+	Remove(path string) error
+	// RemoveAll removes a directory and everything in it, if it exists.
+	RemoveAll(path string) error
+	// Rename moves a file or a directory, and everything in it, to a new path.
+	Rename(from, to string) error
+	// List returns the files, but not the directories, directly within a directory, sorted by name.  A directory
+	// that doesn't exist has no files.
+	List(dir string) ([]storageFile, error)
+	// Describe returns the full location of a path, for messages.
+	Describe(path string) string
+}
+
+// jsonStorage is implemented by storages that can only hold files whose contents are JSON.
+type jsonStorage interface {
+	storage
+	// jsonOnly marks the storage as only holding JSON.
+	jsonOnly()
+}
+
+// leaseStorage is implemented by storages that can lock a file with a lease that expires unless it's renewed, so that
+// the lock of an update whose process dies is released on its own.
+type leaseStorage interface {
+	storage
+	// AcquireLease writes a file and takes a lease on it for the given duration, failing with an error for which
+	// os.IsExist is true if another lease on the file is held.  It returns the ID of the lease.
+	AcquireLease(path string, data []byte, duration time.Duration) (string, error)
+	// RenewLease renews a lease for another period of its duration.
+	RenewLease(path string, leaseID string) error
+	// ReleaseLease removes a file along with the lease on it, first breaking the lease if leaseID is empty.
+	ReleaseLease(path string, leaseID string) error
+	// IsLeased returns true if a lease on a file is held.
+	IsLeased(path string) (bool, error)
+}
+
+// conditionalStorage is implemented by storages that can write a file only if it hasn't changed since it was read, as
+// object stores can with preconditions on the ETags of their objects, so that of two writers of a file, one can't
+// silently overwrite what the other wrote.
+type conditionalStorage interface {
+	storage
+	// ReadFileVersion returns the contents of a file along with its version, an opaque tag that changes whenever the
+	// file is written.
+	ReadFileVersion(path string) ([]byte, string, error)
+	// WriteFileIfVersion writes a file only if its version is still the given one or, if version is empty, only if it
+	// doesn't exist, and returns its new version.  If it has changed, it fails with an error for which
+	// isFileChanged is true.
+	WriteFileIfVersion(path string, data []byte, version string) (string, error)
+}
+
+// errFileChanged is the error with which a conditional write fails when the file has changed since it was read.
+var errFileChanged = errors.New("file was changed by another writer")
+
+// isFileChanged returns true if an error reports that a conditional write failed because the file had changed.
+func isFileChanged(err error) bool {
+	if pathErr, ok := errors.Cause(err).(*os.PathError); ok {
+		return pathErr.Err == errFileChanged
+	}
+	return false
+}
+
+// storageFile describes a file in a storage.
+type storageFile struct {
+	Name    string    // the name of the file, without its directory.
+	ModTime time.Time // when the file was last written.
+}
+
+// fsStorage is a storage on the local filesystem, rooted at a directory.
+type fsStorage struct {
+	root string
+}
+
+func (s *fsStorage) path(path string) string {
+	return filepath.Join(s.root, path)
+}
+
+func (s *fsStorage) ReadFile(path string) ([]byte, error) {
+	return ioutil.ReadFile(s.path(path))
+}
+
+func (s *fsStorage) WriteFile(path string, data []byte) error {
+	file := s.path(path)
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, data, 0600)
+}
+
+func (s *fsStorage) CreateFile(path string, data []byte) error {
+	file := s.path(path)
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	// Creating the file exclusively ensures that only one of any racing writers creates it.
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		if removeErr := os.Remove(file); removeErr != nil {
+			return removeErr
+		}
+	}
+	return err
+}
+
+func (s *fsStorage) Exists(path string) (bool, error) {
+	_, err := os.Stat(s.path(path))
+	switch {
+	case os.IsNotExist(err):
+		return false, nil
+	case err != nil:
+		return false, err
+	default:
+		return true, nil
+	}
+}
+
+func (s *fsStorage) Remove(path string) error {
+	if err := os.Remove(s.path(path)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *fsStorage) RemoveAll(path string) error {
+	return os.RemoveAll(s.path(path))
+}
+
+func (s *fsStorage) Rename(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(s.path(to)), 0700); err != nil {
+		return err
+	}
+	return os.Rename(s.path(from), s.path(to))
+}
+
+func (s *fsStorage) List(dir string) ([]storageFile, error) {
+	infos, err := ioutil.ReadDir(s.path(dir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var files []storageFile
+	for _, info := range infos {
+		if !info.IsDir() {
+			files = append(files, storageFile{Name: info.Name(), ModTime: info.ModTime()})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+func (s *fsStorage) Describe(path string) string {
+	return s.path(path)
+}
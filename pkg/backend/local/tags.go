@@ -0,0 +1,88 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/tokens"
+	"github.com/pulumi/pulumi/pkg/util/contract"
+	"github.com/pulumi/pulumi/pkg/util/fsutil"
+	"github.com/pulumi/pulumi/pkg/workspace"
+)
+
+// The tags of a stack are kept apart from its checkpoint, so that they can be read when listing stacks without
+// reading, and perhaps decrypting, the whole state of each, and so that changing them doesn't rewrite the checkpoint.
+// Besides the tags derived from the project, which are refreshed each time the stack is updated, as the Pulumi Service
+// does, a stack may have any others, with which users record whatever metadata they like.
+
+// tagsPath returns the path of the file that holds the tags of a stack.
+func (b *localBackend) tagsPath(stack tokens.QName) string {
+	contract.Require(stack != "", "stack")
+
+	return filepath.Join(workspace.TagDir, fsutil.QnamePath(stack)+".json")
+}
+
+// getStackTags loads the tags of a stack.  A stack whose tags have never been saved has none.
+func (b *localBackend) getStackTags(name tokens.QName) (map[apitype.StackTagName]string, error) {
+	tags := make(map[apitype.StackTagName]string)
+	byts, err := b.storage.ReadFile(b.tagsPath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return tags, nil
+		}
+		return nil, err
+	}
+	if err = json.Unmarshal(byts, &tags); err != nil {
+		return nil, errors.Wrapf(err, "reading tags file %s", b.storage.Describe(b.tagsPath(name)))
+	}
+	return tags, nil
+}
+
+// saveStackTags replaces the tags of a stack.
+func (b *localBackend) saveStackTags(name tokens.QName, tags map[apitype.StackTagName]string) error {
+	if len(tags) == 0 {
+		return b.storage.Remove(b.tagsPath(name))
+	}
+
+	byts, err := json.MarshalIndent(tags, "", "    ")
+	if err != nil {
+		return err
+	}
+	return b.storage.WriteFile(b.tagsPath(name), byts)
+}
+
+// mergeStackTags adds the given tags to those a stack has, replacing the values of any it already has, and returns
+// the stack's tags.
+func (b *localBackend) mergeStackTags(name tokens.QName,
+	tags map[apitype.StackTagName]string) (map[apitype.StackTagName]string, error) {
+
+	existing, err := b.getStackTags(name)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range tags {
+		existing[k] = v
+	}
+	if err = b.saveStackTags(name, existing); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
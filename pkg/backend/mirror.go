@@ -0,0 +1,190 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/diag"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/config"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/resource/stack"
+	"github.com/pulumi/pulumi/pkg/util/logging"
+)
+
+// MirrorFailurePolicy says what happens when the state of a stack can't be written to one of its mirrors.
+type MirrorFailurePolicy string
+
+const (
+	// WarnOnMirrorFailure warns that the mirror couldn't be written, and carries on.  The mirror is written again
+	// with the next checkpoint, so it catches up once it can be reached.
+	WarnOnMirrorFailure MirrorFailurePolicy = "warn"
+	// FailOnMirrorFailure fails the operation that wrote the checkpoint, just as if the primary backend couldn't be
+	// written.
+	FailOnMirrorFailure MirrorFailurePolicy = "fail"
+)
+
+// ParseMirrorFailurePolicy parses the name of a MirrorFailurePolicy, which defaults to WarnOnMirrorFailure.
+func ParseMirrorFailurePolicy(s string) (MirrorFailurePolicy, error) {
+	switch MirrorFailurePolicy(s) {
+	case "", WarnOnMirrorFailure:
+		return WarnOnMirrorFailure, nil
+	case FailOnMirrorFailure:
+		return FailOnMirrorFailure, nil
+	default:
+		return "", errors.Errorf("unknown mirror failure policy '%s'; expected '%s' or '%s'",
+			s, WarnOnMirrorFailure, FailOnMirrorFailure)
+	}
+}
+
+// StateMirrors are the backends to which every checkpoint of a stack in another backend, its primary, is copied, so
+// that there is always a copy of the stack's state elsewhere, such as in an off-site archive.  Each mirror holds a
+// stack of the same name, which is created when it's first written.
+type StateMirrors struct {
+	Backends []Backend           // the backends to which state is mirrored.
+	Policy   MirrorFailurePolicy // what happens when a mirror can't be written.
+	Sink     diag.Sink           // the sink to which failures are reported, when they are only warned of.
+}
+
+// Persister returns a persister that saves each snapshot of the given stack with the given persister, and then
+// copies it to each mirror.  If there are no mirrors, it returns the given persister.
+func (m *StateMirrors) Persister(ctx context.Context, stackRef StackReference,
+	primary SnapshotPersister) SnapshotPersister {
+
+	if m == nil || len(m.Backends) == 0 {
+		return primary
+	}
+	return &mirroredSnapshotPersister{ctx: ctx, stackRef: stackRef, primary: primary, mirrors: m}
+}
+
+// MirrorDeployment copies a deployment of the given stack, which has just been written to its primary backend, to
+// each mirror.
+func (m *StateMirrors) MirrorDeployment(ctx context.Context, stackRef StackReference,
+	deployment *apitype.UntypedDeployment) error {
+
+	if m == nil {
+		return nil
+	}
+	for _, b := range m.Backends {
+		if err := mirrorDeployment(ctx, b, stackRef, deployment); err != nil {
+			err = errors.Wrapf(err, "mirroring the state of stack '%s' to %s", stackRef, b.Name())
+			if m.Policy == FailOnMirrorFailure {
+				return err
+			}
+			if m.Sink != nil {
+				m.Sink.Warningf(diag.Message("" /*urn*/, "%v"), err)
+			} else {
+				logging.V(7).Infof("%v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// mirrorDeployment imports a deployment into the stack of the same name in a mirror, creating it if it doesn't exist.
+func mirrorDeployment(ctx context.Context, b Backend, stackRef StackReference,
+	deployment *apitype.UntypedDeployment) error {
+
+	ref, err := b.ParseStackReference(string(stackRef.StackName()))
+	if err != nil {
+		return err
+	}
+	s, err := b.GetStack(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		if s, err = b.CreateStack(ctx, ref, nil); err != nil {
+			return err
+		}
+	}
+	if deployment, err = encryptMirroredSecrets(b, ref, deployment); err != nil {
+		return err
+	}
+	return s.ImportDeployment(ctx, deployment)
+}
+
+// encryptMirroredSecrets encrypts the secret outputs of a deployment that is to be imported into a mirror with the
+// mirror's own crypter for the stack, so that they're never written in plaintext and the mirror can read them back.
+func encryptMirroredSecrets(b Backend, ref StackReference,
+	deployment *apitype.UntypedDeployment) (*apitype.UntypedDeployment, error) {
+
+	var typed apitype.Deployment
+	if err := json.Unmarshal(deployment.Deployment, &typed); err != nil {
+		return nil, err
+	}
+	encrypted := false
+	err := stack.EncryptSecretOutputs(stack.DeploymentResources(&typed), func() (config.Encrypter, error) {
+		encrypted = true
+		return b.GetStackCrypter(ref)
+	})
+	if err != nil || !encrypted {
+		return deployment, err
+	}
+	data, err := json.Marshal(typed)
+	if err != nil {
+		return nil, err
+	}
+	return &apitype.UntypedDeployment{Version: deployment.Version, Deployment: json.RawMessage(data)}, nil
+}
+
+// mirroredSnapshotPersister is a SnapshotPersister that copies each snapshot that it saves to a stack's mirrors.
+// Snapshots are saved incrementally if the primary persister can; they are always copied to the mirrors whole.
+type mirroredSnapshotPersister struct {
+	ctx      context.Context
+	stackRef StackReference
+	primary  SnapshotPersister
+	mirrors  *StateMirrors
+}
+
+var _ IncrementalSnapshotPersister = (*mirroredSnapshotPersister)(nil)
+
+func (p *mirroredSnapshotPersister) Invalidate() error {
+	return p.primary.Invalidate()
+}
+
+func (p *mirroredSnapshotPersister) Save(snapshot *deploy.Snapshot) error {
+	if err := p.primary.Save(snapshot); err != nil {
+		return err
+	}
+	return p.mirror(snapshot)
+}
+
+func (p *mirroredSnapshotPersister) SaveIncremental(snapshot *deploy.Snapshot, changed []*resource.State) error {
+	if incremental, ok := p.primary.(IncrementalSnapshotPersister); ok {
+		if err := incremental.SaveIncremental(snapshot, changed); err != nil {
+			return err
+		}
+		return p.mirror(snapshot)
+	}
+	return p.Save(snapshot)
+}
+
+// mirror copies a snapshot that has been saved to the mirrors.
+func (p *mirroredSnapshotPersister) mirror(snapshot *deploy.Snapshot) error {
+	data, err := json.Marshal(stack.SerializeDeployment(snapshot))
+	if err != nil {
+		return err
+	}
+	return p.mirrors.MirrorDeployment(p.ctx, p.stackRef, &apitype.UntypedDeployment{
+		Version:    1,
+		Deployment: json.RawMessage(data),
+	})
+}
@@ -0,0 +1,115 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/tokens"
+)
+
+type mockStackReference tokens.QName
+
+func (r mockStackReference) String() string          { return string(r) }
+func (r mockStackReference) StackName() tokens.QName { return tokens.QName(r) }
+
+// mockMirrorBackend is a backend that records the deployments imported into its stacks.  Only the methods used to
+// mirror state are implemented.
+type mockMirrorBackend struct {
+	Backend
+	err      error
+	stacks   map[tokens.QName]*mockMirrorStack
+	imported []*apitype.UntypedDeployment
+}
+
+type mockMirrorStack struct {
+	Stack
+	b *mockMirrorBackend
+}
+
+func (s *mockMirrorStack) ImportDeployment(ctx context.Context, deployment *apitype.UntypedDeployment) error {
+	if s.b.err != nil {
+		return s.b.err
+	}
+	s.b.imported = append(s.b.imported, deployment)
+	return nil
+}
+
+func (b *mockMirrorBackend) Name() string { return "mock" }
+
+func (b *mockMirrorBackend) ParseStackReference(s string) (StackReference, error) {
+	return mockStackReference(s), nil
+}
+
+func (b *mockMirrorBackend) GetStack(ctx context.Context, ref StackReference) (Stack, error) {
+	if s, ok := b.stacks[ref.StackName()]; ok {
+		return s, nil
+	}
+	return nil, nil
+}
+
+func (b *mockMirrorBackend) CreateStack(ctx context.Context, ref StackReference, opts interface{}) (Stack, error) {
+	if b.stacks == nil {
+		b.stacks = make(map[tokens.QName]*mockMirrorStack)
+	}
+	s := &mockMirrorStack{b: b}
+	b.stacks[ref.StackName()] = s
+	return s, nil
+}
+
+func TestMirroredSnapshotPersister(t *testing.T) {
+	primary := &MockIncrementalStackPersister{}
+	up, down := &mockMirrorBackend{}, &mockMirrorBackend{err: errors.New("unreachable")}
+	mirrors := &StateMirrors{Backends: []Backend{up, down}, Policy: WarnOnMirrorFailure}
+
+	persister := mirrors.Persister(context.Background(), mockStackReference("dev"), primary)
+	incremental, ok := persister.(IncrementalSnapshotPersister)
+	assert.True(t, ok)
+
+	// Snapshots are saved incrementally to the primary, and copied whole to each mirror, creating its stack.  A mirror
+	// that can't be written is only warned of.
+	resA := NewResource("a")
+	assert.NoError(t, persister.Save(NewSnapshot([]*resource.State{resA})))
+	assert.NoError(t, incremental.SaveIncremental(NewSnapshot([]*resource.State{resA}), []*resource.State{resA}))
+	assert.Len(t, primary.SavedSnapshots, 2)
+	assert.Len(t, primary.Changed, 1)
+	assert.Len(t, up.imported, 2)
+	assert.Contains(t, up.stacks, tokens.QName("dev"))
+	assert.Len(t, down.imported, 0)
+
+	// Under the fail policy, a mirror that can't be written fails the save.
+	mirrors.Policy = FailOnMirrorFailure
+	assert.Error(t, persister.Save(NewSnapshot(nil)))
+	assert.Len(t, up.imported, 3)
+}
+
+func TestStateMirrorsNone(t *testing.T) {
+	primary := &MockStackPersister{}
+	var mirrors *StateMirrors
+	assert.Equal(t, primary, mirrors.Persister(context.Background(), mockStackReference("dev"), primary))
+	assert.NoError(t, mirrors.MirrorDeployment(context.Background(), mockStackReference("dev"), nil))
+
+	policy, err := ParseMirrorFailurePolicy("")
+	assert.NoError(t, err)
+	assert.Equal(t, WarnOnMirrorFailure, policy)
+	_, err = ParseMirrorFailurePolicy("ignore")
+	assert.Error(t, err)
+}
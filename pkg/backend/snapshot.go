@@ -39,6 +39,21 @@ type SnapshotPersister interface {
 	Save(snapshot *deploy.Snapshot) error
 }
 
+// IncrementalSnapshotPersister is implemented by persisters that save a snapshot by recording how it differs from the
+// last snapshot that they saved, so that the cost of saving is proportional to the size of the change rather than
+// the size of the snapshot.
+//
+// Resources are told apart by identity: a resource that wasn't in the last snapshot is new, and a resource that was
+// is unchanged unless it is among those given as changed.  The engine changes some resources in place, such as when
+// it registers their outputs, and the SnapshotManager reports these.
+type IncrementalSnapshotPersister interface {
+	SnapshotPersister
+
+	// SaveIncremental persists the given snapshot, in which only the given resources have been changed in place since
+	// the last snapshot was persisted.
+	SaveIncremental(snapshot *deploy.Snapshot, changed []*resource.State) error
+}
+
 // SnapshotManager is an implementation of engine.SnapshotManager that inspects steps and performs
 // mutations on the global snapshot object serially. This implementation maintains two bits of state: the "base"
 // snapshot, which is completely immutable and represents the state of the world prior to the application
@@ -56,9 +71,12 @@ type SnapshotPersister interface {
 // that it creates and expects those mutations to be persisted directly to the snapshot.
 type SnapshotManager struct {
 	persister        SnapshotPersister        // The persister responsible for invalidating and persisting the snapshot
+	lease            UpdateLease              // The update's lease on the stack, checked before each save, if any
 	baseSnapshot     *deploy.Snapshot         // The base snapshot for this plan
 	resources        []*resource.State        // The list of resources operated upon by this plan
+	operations       []deploy.Operation       // The list of operations begun, but not yet finished, by this plan
 	dones            map[*resource.State]bool // The set of resources that have been operated upon already by this plan
+	changed          []*resource.State        // The resources changed in place since the snapshot was last persisted
 	doVerify         bool                     // If true, verify the snapshot before persisting it
 	plugins          []workspace.PluginInfo   // The list of plugins loaded by the plan, to be saved in the manifest
 	mutationRequests chan func()              // The queue of mutation requests, to be retired serially by the manager
@@ -71,6 +89,47 @@ func (sm *SnapshotManager) Close() error {
 	return nil
 }
 
+// Compact removes the entries of the snapshot that no update will need, as described by deploy.CompactSnapshot, and
+// saves the snapshot whole if any were removed.  It is meant to be called once a plan has finished successfully, before
+// the manager is closed.
+func (sm *SnapshotManager) Compact() (deploy.Compaction, error) {
+	var compaction deploy.Compaction
+	responseChan := make(chan error)
+	sm.mutationRequests <- func() {
+		snap := sm.snap()
+		if compaction = deploy.CompactSnapshot(snap); compaction.IsEmpty() {
+			responseChan <- nil
+			return
+		}
+
+		var err error
+		if sm.lease != nil {
+			err = sm.lease.Check()
+		}
+		if err == nil {
+			err = sm.persister.Save(snap)
+		}
+		if err == nil {
+			// The compacted snapshot is the base of any mutations that follow.
+			sm.baseSnapshot = snap
+			sm.resources, sm.operations, sm.changed = nil, nil, nil
+			sm.dones = make(map[*resource.State]bool)
+		}
+		if err == nil && sm.doVerify {
+			if err = snap.VerifyIntegrity(); err != nil {
+				err = errors.Wrapf(err, "after compaction of snapshot")
+			}
+		}
+
+		if err != nil {
+			err = errors.Wrap(err, "failed to save compacted snapshot")
+		}
+		responseChan <- err
+	}
+
+	return compaction, <-responseChan
+}
+
 // If you need to understand what's going on in this file, start here!
 //
 // mutate is the serialization point for reads and writes of the global snapshot state.
@@ -91,7 +150,21 @@ func (sm *SnapshotManager) mutate(mutator func()) error {
 		mutator()
 
 		snap := sm.snap()
-		err := sm.persister.Save(snap)
+		var err error
+		if sm.lease != nil {
+			// Save nothing if the update has lost its claim on the stack, lest it overwrite another's changes.
+			err = sm.lease.Check()
+		}
+		if err == nil {
+			if incremental, ok := sm.persister.(IncrementalSnapshotPersister); ok {
+				err = incremental.SaveIncremental(snap, sm.changed)
+			} else {
+				err = sm.persister.Save(snap)
+			}
+		}
+		if err == nil {
+			sm.changed = nil
+		}
 		if err == nil && sm.doVerify {
 			if err = snap.VerifyIntegrity(); err != nil {
 				err = errors.Wrapf(err, "after mutation of snapshot")
@@ -114,13 +187,16 @@ func (sm *SnapshotManager) mutate(mutator func()) error {
 //
 // Due to the way this is currently implemented, the engine directly mutates output properties
 // on the resource State object that it created. Since we are storing pointers to these objects
-// in the `resources` slice, we need only to do a no-op mutation in order to flush these new
-// mutations to disk.
+// in the `resources` slice, we need only to record that the resource changed in order to flush
+// these new mutations to disk.
 //
 // Note that this is completely not thread-safe and defeats the purpose of having a `mutate` callback
 // entirely, but the hope is that this state of things will not be permament.
 func (sm *SnapshotManager) RegisterResourceOutputs(step deploy.Step) error {
-	return sm.refresh()
+	logging.V(9).Infof("SnapshotManager: RegisterResourceOutputs(%v)", step.URN())
+	return sm.mutate(func() {
+		sm.markChanged(step.New())
+	})
 }
 
 // RecordPlugin records that the current plan loaded a plugin and saves it in the snapshot.
@@ -138,6 +214,23 @@ func (sm *SnapshotManager) BeginMutation(step deploy.Step) (engine.SnapshotMutat
 	contract.Require(step != nil, "step != nil")
 	logging.V(9).Infof("SnapshotManager: Beginning mutation for step `%s` on resource `%s`", step.Op(), step.URN())
 
+	// Record any operation that is about to be performed on a resource before it begins, so that should this update
+	// be interrupted, a later update can find out what was in progress and reconcile it.
+	switch step.Op() {
+	case deploy.OpCreate, deploy.OpCreateReplacement, deploy.OpImport:
+		if err := sm.beginOperation(step.New(), deploy.OperationTypeCreating); err != nil {
+			return nil, err
+		}
+	case deploy.OpUpdate:
+		if err := sm.beginOperation(step.Old(), deploy.OperationTypeUpdating); err != nil {
+			return nil, err
+		}
+	case deploy.OpDelete, deploy.OpDeleteReplaced:
+		if err := sm.beginOperation(step.Old(), deploy.OperationTypeDeleting); err != nil {
+			return nil, err
+		}
+	}
+
 	// This is for compat with the existing update model with the service. Invalidating a
 	// stack sets a bit in a database indicating that the stored snapshot is not valid.
 	if err := sm.persister.Invalidate(); err != nil {
@@ -150,10 +243,14 @@ func (sm *SnapshotManager) BeginMutation(step deploy.Step) (engine.SnapshotMutat
 		return &sameSnapshotMutation{sm}, nil
 	case deploy.OpCreate, deploy.OpCreateReplacement:
 		return &createSnapshotMutation{sm}, nil
+	case deploy.OpImport:
+		return &importSnapshotMutation{sm}, nil
 	case deploy.OpUpdate:
 		return &updateSnapshotMutation{sm}, nil
-	case deploy.OpDelete, deploy.OpDeleteReplaced:
+	case deploy.OpDelete, deploy.OpDeleteReplaced, deploy.OpReadDiscard:
 		return &deleteSnapshotMutation{sm}, nil
+	case deploy.OpRead:
+		return &readSnapshotMutation{sm}, nil
 	case deploy.OpReplace:
 		return &replaceSnapshotMutation{}, nil
 	}
@@ -179,6 +276,10 @@ func (ssm *sameSnapshotMutation) End(step deploy.Step, successful bool) error {
 	contract.Require(step != nil, "step != nil")
 	logging.V(9).Infof("SnapshotManager: sameSnapshotMutation.End(..., %v)", successful)
 	return ssm.manager.mutate(func() {
+		// Resources whose creation was skipped because they weren't targeted never make it into the snapshot.
+		if same, ok := step.(*deploy.SameStep); ok && same.IsSkippedCreate() {
+			return
+		}
 		if successful {
 			ssm.manager.markDone(step.Old())
 			ssm.manager.markNew(step.New())
@@ -194,6 +295,7 @@ func (csm *createSnapshotMutation) End(step deploy.Step, successful bool) error
 	contract.Require(step != nil, "step != nil")
 	logging.V(9).Infof("SnapshotManager: createSnapshotMutation.End(..., %v)", successful)
 	return csm.manager.mutate(func() {
+		csm.manager.endOperation(step.New())
 		if successful {
 			// There is some very subtle behind-the-scenes magic here that
 			// comes into play whenever this create is a CreateReplacement.
@@ -203,12 +305,37 @@ func (csm *createSnapshotMutation) End(step deploy.Step, successful bool) error
 			// being replaced as part of a Create-Before-Delete replacement sequence.
 			// Since we are storing the base snapshot and all resources by reference
 			// (we have pointers to engine-allocated objects), this transparently
-			// "just works" for the SnapshotManager.
+			// "just works" for the SnapshotManager, although persisters that only
+			// save what has changed need to be told.
+			if old := step.Old(); old != nil && old.Delete {
+				csm.manager.markChanged(old)
+			}
 			csm.manager.markNew(step.New())
 		}
 	})
 }
 
+type importSnapshotMutation struct {
+	manager *SnapshotManager
+}
+
+func (ism *importSnapshotMutation) End(step deploy.Step, successful bool) error {
+	contract.Require(step != nil, "step != nil")
+	logging.V(9).Infof("SnapshotManager: importSnapshotMutation.End(..., %v)", successful)
+	return ism.manager.mutate(func() {
+		ism.manager.endOperation(step.New())
+		if successful {
+			// An imported resource is parented to a resource, the stack's root, that this plan didn't otherwise
+			// touch.  Carry the parent over from the base snapshot first, so that it still precedes its child.
+			if parent := ism.manager.baseResource(step.New().Parent); parent != nil && !ism.manager.dones[parent] {
+				ism.manager.markDone(parent)
+				ism.manager.markNew(parent)
+			}
+			ism.manager.markNew(step.New())
+		}
+	})
+}
+
 type updateSnapshotMutation struct {
 	manager *SnapshotManager
 }
@@ -217,9 +344,10 @@ func (usm *updateSnapshotMutation) End(step deploy.Step, successful bool) error
 	contract.Require(step != nil, "step != nil")
 	logging.V(9).Infof("SnapshotManager: updateSnapshotMutation.End(..., %v)", successful)
 	return usm.manager.mutate(func() {
+		usm.manager.endOperation(step.Old())
 		if successful {
 			usm.manager.markDone(step.Old())
-			usm.manager.markNew(step.New())
+			usm.manager.markReplaced(step.Old(), step.New())
 		}
 	})
 }
@@ -232,9 +360,29 @@ func (dsm *deleteSnapshotMutation) End(step deploy.Step, successful bool) error
 	contract.Require(step != nil, "step != nil")
 	logging.V(9).Infof("SnapshotManager: deleteSnapshotMutation.End(..., %v)", successful)
 	return dsm.manager.mutate(func() {
+		dsm.manager.endOperation(step.Old())
 		if successful {
 			contract.Assert(!step.Old().Protect)
 			dsm.manager.markDone(step.Old())
+			dsm.manager.unmarkNew(step.Old())
+		}
+	})
+}
+
+type readSnapshotMutation struct {
+	manager *SnapshotManager
+}
+
+func (rsm *readSnapshotMutation) End(step deploy.Step, successful bool) error {
+	contract.Require(step != nil, "step != nil")
+	logging.V(9).Infof("SnapshotManager: readSnapshotMutation.End(..., %v)", successful)
+	return rsm.manager.mutate(func() {
+		if successful {
+			// An external resource that has been read before replaces its old state with what was just read.
+			if old := step.Old(); old != nil {
+				rsm.manager.markDone(old)
+			}
+			rsm.manager.markNew(step.New())
 		}
 	})
 }
@@ -243,12 +391,23 @@ type replaceSnapshotMutation struct{}
 
 func (rsm *replaceSnapshotMutation) End(step deploy.Step, successful bool) error { return nil }
 
-// refresh does a no-op mutation that forces the SnapshotManager to persist the
-// snapshot exactly as it is currently to disk. This is useful when a mutation
-// has failed and we do not intend to persist the failed mutation.
-func (sm *SnapshotManager) refresh() error {
-	logging.V(9).Infof("SnapshotManager: refresh()")
-	return sm.mutate(func() {})
+// beginOperation records, and persists, that an operation of the given type is about to be performed on a resource.
+func (sm *SnapshotManager) beginOperation(state *resource.State, typ deploy.OperationType) error {
+	contract.Assert(state != nil)
+	logging.V(9).Infof("SnapshotManager: beginOperation(%v, %v)", state.URN, typ)
+	return sm.mutate(func() {
+		sm.operations = append(sm.operations, deploy.Operation{Resource: state, Type: typ})
+	})
+}
+
+// endOperation removes the record of the operation being performed on a resource, now that it has finished.
+func (sm *SnapshotManager) endOperation(state *resource.State) {
+	for i, op := range sm.operations {
+		if op.Resource == state {
+			sm.operations = append(sm.operations[:i], sm.operations[i+1:]...)
+			return
+		}
+	}
 }
 
 // markDone marks a resource as having been processed. Resources that have been marked
@@ -268,6 +427,54 @@ func (sm *SnapshotManager) markNew(state *resource.State) {
 	logging.V(9).Infof("Appended new state snapshot to be written: %v", state.URN)
 }
 
+// unmarkNew removes a resource that this plan produced, but has since deleted, from the new snapshot, as when a
+// rollback deletes a resource that was created earlier in the plan.
+func (sm *SnapshotManager) unmarkNew(state *resource.State) {
+	for i, res := range sm.resources {
+		if res == state {
+			sm.resources = append(sm.resources[:i], sm.resources[i+1:]...)
+			logging.V(9).Infof("Removed new state snapshot to be written: %v", state.URN)
+			return
+		}
+	}
+}
+
+// markReplaced marks a resource's new state as existing in the new snapshot, in place of its old state.  Ordinarily,
+// the old state is from the base snapshot, and this is the same as markNew.  If this plan produced the old state,
+// however, as when a rollback reverts an earlier update, the new state takes the old state's place, so that it still
+// precedes any resources that depend on it.
+func (sm *SnapshotManager) markReplaced(old *resource.State, new *resource.State) {
+	contract.Assert(new != nil)
+	for i, res := range sm.resources {
+		if res == old {
+			sm.resources[i] = new
+			logging.V(9).Infof("Replaced new state snapshot to be written: %v", new.URN)
+			return
+		}
+	}
+	sm.markNew(new)
+}
+
+// markChanged marks a resource that the engine has changed in place, rather than by replacing its state, so that
+// incremental persisters save it again.
+func (sm *SnapshotManager) markChanged(state *resource.State) {
+	contract.Assert(state != nil)
+	sm.changed = append(sm.changed, state)
+	logging.V(9).Infof("Marked state snapshot as changed: %v", state.URN)
+}
+
+// baseResource returns the live resource with the given URN in the base snapshot, if any.
+func (sm *SnapshotManager) baseResource(urn resource.URN) *resource.State {
+	if base := sm.baseSnapshot; base != nil && urn != "" {
+		for _, res := range base.Resources {
+			if res.URN == urn && !res.Delete {
+				return res
+			}
+		}
+	}
+	return nil
+}
+
 // snap produces a new Snapshot given the base snapshot and a list of resources that the current
 // plan has created.
 func (sm *SnapshotManager) snap() *deploy.Snapshot {
@@ -320,7 +527,15 @@ func (sm *SnapshotManager) snap() *deploy.Snapshot {
 	}
 
 	manifest.Magic = manifest.NewMagic()
-	return deploy.NewSnapshot(manifest, resources)
+	snap := deploy.NewSnapshot(manifest, resources)
+
+	// Carry forward any operations left pending by an earlier, interrupted update that haven't been reconciled, along
+	// with those that are in progress now.
+	if base := sm.baseSnapshot; base != nil {
+		snap.PendingOperations = append(snap.PendingOperations, base.PendingOperations...)
+	}
+	snap.PendingOperations = append(snap.PendingOperations, sm.operations...)
+	return snap
 }
 
 // NewSnapshotManager creates a new SnapshotManager for the given stack name, using the given persister
@@ -330,8 +545,17 @@ func (sm *SnapshotManager) snap() *deploy.Snapshot {
 // given to the engine! The engine will mutate this object and correctness of the
 // SnapshotManager depends on being able to observe this mutation. (This is not ideal...)
 func NewSnapshotManager(persister SnapshotPersister, baseSnap *deploy.Snapshot) *SnapshotManager {
+	return NewLeasedSnapshotManager(persister, nil, baseSnap)
+}
+
+// NewLeasedSnapshotManager creates a new SnapshotManager like NewSnapshotManager, which checks that the update still
+// holds the given lease on its stack before persisting each snapshot.
+func NewLeasedSnapshotManager(persister SnapshotPersister, lease UpdateLease,
+	baseSnap *deploy.Snapshot) *SnapshotManager {
+
 	manager := &SnapshotManager{
 		persister:        persister,
+		lease:            lease,
 		baseSnapshot:     baseSnap,
 		dones:            make(map[*resource.State]bool),
 		doVerify:         true,
@@ -49,6 +49,16 @@ func (m *MockStackPersister) Invalidate() error {
 	return nil
 }
 
+type MockIncrementalStackPersister struct {
+	MockStackPersister
+	Changed [][]*resource.State
+}
+
+func (m *MockIncrementalStackPersister) SaveIncremental(snap *deploy.Snapshot, changed []*resource.State) error {
+	m.Changed = append(m.Changed, changed)
+	return m.Save(snap)
+}
+
 func MockSetup(t *testing.T, baseSnap *deploy.Snapshot) (*SnapshotManager, *MockStackPersister) {
 	err := baseSnap.VerifyIntegrity()
 	if !assert.NoError(t, err) {
@@ -275,7 +285,7 @@ func TestVexingDeployment(t *testing.T) {
 
 	// mocking out the behavior of a provider indicating that this resource needs to be deleted
 	createReplacement := deploy.NewCreateReplacementStep(nil, MockRegisterResourceEvent{}, c, cPrime, nil, true)
-	replace := deploy.NewReplaceStep(nil, c, cPrime, nil, true)
+	replace := deploy.NewReplaceStep(nil, c, cPrime, nil, nil, true)
 	c.Delete = true
 
 	applyStep(createReplacement)
@@ -284,7 +294,7 @@ func TestVexingDeployment(t *testing.T) {
 	// cPrime now exists, c is now pending deletion
 	// dPrime now depends on cPrime, which got replaced
 	dPrime := NewResource(string(d.URN), cPrime.URN)
-	applyStep(deploy.NewUpdateStep(nil, MockRegisterResourceEvent{}, d, dPrime, nil))
+	applyStep(deploy.NewUpdateStep(nil, MockRegisterResourceEvent{}, d, dPrime, nil, nil))
 
 	lastSnap := sp.SavedSnapshots[len(sp.SavedSnapshots)-1]
 	assert.Len(t, lastSnap.Resources, 6)
@@ -348,6 +358,47 @@ func TestDeletion(t *testing.T) {
 	assert.Len(t, lastSnap.Resources, 0)
 }
 
+func TestExternalResources(t *testing.T) {
+	resourceA := NewResource("a")
+	resourceA.Custom, resourceA.External, resourceA.ID = true, true, "a-id"
+	resourceB := NewResource("b")
+	resourceB.Custom, resourceB.External, resourceB.ID = true, true, "b-id"
+	snap := NewSnapshot([]*resource.State{
+		resourceA,
+		resourceB,
+	})
+
+	manager, sp := MockSetup(t, snap)
+
+	// Reading an external resource replaces its old state with the state just read.
+	resourceAPrime := NewResource("a")
+	resourceAPrime.Custom, resourceAPrime.External, resourceAPrime.ID = true, true, "a-id"
+	resourceAPrime.Outputs["foo"] = resource.NewStringProperty("bar")
+	read := deploy.NewReadStep(nil, nil, resourceA, resourceAPrime)
+	mutation, err := manager.BeginMutation(read)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.NoError(t, mutation.End(read, true)) {
+		t.FailNow()
+	}
+
+	// Discarding an external resource removes it from the snapshot, as a delete would.
+	discard := deploy.NewDeleteStep(nil, resourceB)
+	assert.Equal(t, deploy.OpReadDiscard, discard.Op())
+	mutation, err = manager.BeginMutation(discard)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.NoError(t, mutation.End(discard, true)) {
+		t.FailNow()
+	}
+
+	lastSnap := sp.SavedSnapshots[len(sp.SavedSnapshots)-1]
+	assert.Len(t, lastSnap.Resources, 1)
+	assert.Equal(t, resourceAPrime, lastSnap.Resources[0])
+}
+
 func TestFailedDelete(t *testing.T) {
 	resourceA := NewResource("a")
 	snap := NewSnapshot([]*resource.State{
@@ -372,3 +423,149 @@ func TestFailedDelete(t *testing.T) {
 	assert.Len(t, lastSnap.Resources, 1)
 	assert.Equal(t, resourceA.URN, lastSnap.Resources[0].URN)
 }
+
+func TestPendingOperations(t *testing.T) {
+	resourceA := NewResource("a")
+	snap := NewSnapshot([]*resource.State{
+		resourceA,
+	})
+
+	manager, sp := MockSetup(t, snap)
+	step := deploy.NewDeleteStep(nil, resourceA)
+	mutation, err := manager.BeginMutation(step)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// the delete should have been recorded as pending before it began, so that it can be reconciled
+	// should the update be interrupted.
+	pendingSnap := sp.SavedSnapshots[len(sp.SavedSnapshots)-1]
+	if assert.Len(t, pendingSnap.PendingOperations, 1) {
+		assert.Equal(t, deploy.OperationTypeDeleting, pendingSnap.PendingOperations[0].Type)
+		assert.Equal(t, resourceA, pendingSnap.PendingOperations[0].Resource)
+	}
+
+	err = mutation.End(step, true)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// once the delete has finished, it is no longer pending.
+	lastSnap := sp.SavedSnapshots[len(sp.SavedSnapshots)-1]
+	assert.Len(t, lastSnap.PendingOperations, 0)
+	assert.Len(t, lastSnap.Resources, 0)
+}
+
+func TestRollback(t *testing.T) {
+	resourceA := NewResource("a")
+	snap := NewSnapshot([]*resource.State{
+		resourceA,
+	})
+
+	manager, sp := MockSetup(t, snap)
+	applySteps := func(steps ...deploy.Step) {
+		for _, step := range steps {
+			mutation, err := manager.BeginMutation(step)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			err = mutation.End(step, true)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+		}
+	}
+
+	// update a, and then create b, which depends on it.
+	resourceB := NewResource("b", resourceA.URN)
+	applied := []deploy.Step{
+		deploy.NewUpdateStep(nil, MockRegisterResourceEvent{}, resourceA, NewResource("a"), nil, nil),
+		deploy.NewCreateStep(nil, MockRegisterResourceEvent{}, resourceB),
+	}
+	applySteps(applied...)
+
+	// rolling back deletes b, and then updates a once more.
+	rollback, failed := deploy.NewRollbackSteps(nil, applied)
+	assert.Len(t, failed, 0)
+	if !assert.Len(t, rollback, 2) {
+		t.FailNow()
+	}
+	assert.Equal(t, deploy.OpDelete, rollback[0].Op())
+	assert.Equal(t, resourceB, rollback[0].Old())
+	assert.Equal(t, deploy.OpUpdate, rollback[1].Op())
+	applySteps(rollback...)
+
+	// the snapshot should contain only the rolled-back a.
+	lastSnap := sp.SavedSnapshots[len(sp.SavedSnapshots)-1]
+	if assert.Len(t, lastSnap.Resources, 1) {
+		assert.Equal(t, rollback[1].New(), lastSnap.Resources[0])
+	}
+}
+
+func TestRegisterResourceOutputsIncremental(t *testing.T) {
+	snap := NewSnapshot(nil)
+	sp := &MockIncrementalStackPersister{}
+	manager := NewSnapshotManager(sp, snap)
+
+	resourceA := NewResource("a")
+	step := deploy.NewCreateStep(nil, MockRegisterResourceEvent{}, resourceA)
+	mutation, err := manager.BeginMutation(step)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	err = mutation.End(step, true)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// nothing was changed in place by the create itself.
+	for _, changed := range sp.Changed {
+		assert.Len(t, changed, 0)
+	}
+
+	// registering outputs changes the resource in place, which the persister must be told about.
+	resourceA.Outputs["foo"] = resource.NewStringProperty("bar")
+	err = manager.RegisterResourceOutputs(step)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	lastChanged := sp.Changed[len(sp.Changed)-1]
+	if assert.Len(t, lastChanged, 1) {
+		assert.Equal(t, resourceA, lastChanged[0])
+	}
+}
+
+type MockUpdateLease struct {
+	Lost bool
+}
+
+func (m *MockUpdateLease) Check() error {
+	if m.Lost {
+		return &ConcurrentUpdateError{Stack: "stack"}
+	}
+	return nil
+}
+
+func TestLostLease(t *testing.T) {
+	resourceA := NewResource("a")
+	snap := NewSnapshot([]*resource.State{
+		resourceA,
+	})
+
+	sp := &MockStackPersister{}
+	lease := &MockUpdateLease{}
+	manager := NewLeasedSnapshotManager(sp, lease, snap)
+
+	step := deploy.NewDeleteStep(nil, resourceA)
+	mutation, err := manager.BeginMutation(step)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	saved := len(sp.SavedSnapshots)
+
+	// once the lease is lost, nothing more is saved.
+	lease.Lost = true
+	err = mutation.End(step, true)
+	assert.Error(t, err)
+	assert.Len(t, sp.SavedSnapshots, saved)
+}
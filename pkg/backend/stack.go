@@ -36,6 +36,7 @@ type Stack interface {
 	Config() config.Map                                     // the current config map.
 	Snapshot(ctx context.Context) (*deploy.Snapshot, error) // the latest deployment snapshot.
 	Backend() Backend                                       // the backend this stack belongs to.
+	Tags() map[apitype.StackTagName]string                  // the stack's tags.
 
 	// Preview changes to this stack.
 	Preview(ctx context.Context, proj *workspace.Project, root string, m UpdateMetadata, opts UpdateOptions,
@@ -114,6 +115,11 @@ func ImportStackDeployment(ctx context.Context, s Stack, deployment *apitype.Unt
 	return s.Backend().ImportDeployment(ctx, s.Name(), deployment)
 }
 
+// UpdateStackTags replaces the tags of this stack with the given ones.
+func UpdateStackTags(ctx context.Context, s Stack, tags map[apitype.StackTagName]string) error {
+	return s.Backend().UpdateStackTags(ctx, s.Name(), tags)
+}
+
 // GetStackTags returns the set of tags for the "current" stack, based on the environment
 // and Pulumi.yaml file.
 func GetStackTags() (map[apitype.StackTagName]string, error) {
@@ -52,6 +52,8 @@ const (
 	SucceededResult UpdateResult = "succeeded"
 	// FailedResult is for updates that have failed.
 	FailedResult = "failed"
+	// CancelledResult is for updates that were cancelled before they completed.
+	CancelledResult UpdateResult = "cancelled"
 )
 
 // Keys we use for values put into UpdateInfo.Environment.
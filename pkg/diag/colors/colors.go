@@ -42,7 +42,7 @@ func Colorize(s fmt.Stringer) string {
 }
 
 func ColorizeText(s string) string {
-	c, err := loreley.CompileAndExecuteToString(s, nil, nil)
+	c, err := loreley.CompileAndExecuteToString(applyTheme(s), nil, nil)
 	contract.Assertf(err == nil, "Expected no errors during string colorization; str=%v, err=%v", s, err)
 	return c
 }
@@ -101,4 +101,5 @@ var (
 	SpecDelete            = Red          // for deletes (in the diff sense).
 	SpecCreateReplacement = BrightGreen  // for replacement creates (in the diff sense).
 	SpecDeleteReplaced    = BrightRed    // for replacement deletes (in the diff sense).
+	SpecImport            = BrightBlue   // for imports of existing resources.
 )
@@ -0,0 +1,118 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package colors
+
+import (
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Theme selects the palette used when colorizing text.  Text is always written using the basic colors above (either
+// directly or through the Spec* colors); the active theme then decides how each of those colors is actually displayed.
+type Theme string
+
+const (
+	// ThemeDark is the default theme, intended for terminals with dark backgrounds.
+	ThemeDark Theme = "dark"
+	// ThemeLight swaps pale colors for darker ones that remain legible on terminals with light backgrounds.
+	ThemeLight Theme = "light"
+	// ThemeMonochrome drops all colors, but retains styles such as bold and underline.
+	ThemeMonochrome Theme = "monochrome"
+	// ThemeHighContrast shows all colors in their bold, bright variants.
+	ThemeHighContrast Theme = "high-contrast"
+)
+
+// Themes lists all of the supported themes.
+var Themes = []Theme{ThemeDark, ThemeLight, ThemeMonochrome, ThemeHighContrast}
+
+// themeCommands maps, for each theme, the color commands that the theme displays differently to the directives that
+// replace them.  An empty replacement drops the command entirely.  Commands not in a theme's map are left as-is.
+var themeCommands = map[Theme]map[string]string{
+	ThemeDark: {},
+	ThemeLight: {
+		"fg 3":  Command("fg 130"), // yellow => dark orange.
+		"fg 7":  Command("fg 0"),   // white => black.
+		"fg 8":  Command("fg 242"), // bright black => mid gray.
+		"fg 9":  Command("fg 124"), // bright red => dark red.
+		"fg 10": Command("fg 28"),  // bright green => dark green.
+		"fg 11": Command("fg 94"),  // bright yellow => brown.
+		"fg 14": Command("fg 30"),  // bright cyan => teal.
+		"fg 15": Command("fg 0"),   // bright white => black.
+	},
+	ThemeMonochrome: {
+		"fg 0": "", "fg 1": "", "fg 2": "", "fg 3": "", "fg 4": "", "fg 5": "", "fg 6": "", "fg 7": "",
+		"fg 8": "", "fg 9": "", "fg 10": "", "fg 11": "", "fg 12": "", "fg 13": "", "fg 14": "", "fg 15": "",
+	},
+	ThemeHighContrast: {
+		"fg 1": BrightRed + Bold, "fg 2": BrightGreen + Bold, "fg 3": BrightYellow + Bold,
+		"fg 4": BrightBlue + Bold, "fg 5": BrightMagenta + Bold, "fg 6": BrightCyan + Bold,
+		"fg 7": BrightWhite + Bold, "fg 8": White,
+		"fg 9": BrightRed + Bold, "fg 10": BrightGreen + Bold, "fg 11": BrightYellow + Bold,
+		"fg 12": BrightBlue + Bold, "fg 13": BrightMagenta + Bold, "fg 14": BrightCyan + Bold,
+		"fg 15": BrightWhite + Bold,
+	},
+}
+
+var themeLock sync.RWMutex
+var currentTheme = ThemeDark
+
+// ParseTheme parses the name of a theme, returning an error if it isn't one of the supported themes.
+func ParseTheme(name string) (Theme, error) {
+	for _, t := range Themes {
+		if string(t) == name {
+			return t, nil
+		}
+	}
+	return "", errors.Errorf("unsupported color theme: '%s'.  Supported values are: dark, light, monochrome, "+
+		"high-contrast", name)
+}
+
+// SetTheme selects the theme used for all subsequent colorization.
+func SetTheme(t Theme) {
+	themeLock.Lock()
+	defer themeLock.Unlock()
+	currentTheme = t
+}
+
+// CurrentTheme returns the theme currently used for colorization.
+func CurrentTheme() Theme {
+	themeLock.RLock()
+	defer themeLock.RUnlock()
+	return currentTheme
+}
+
+// applyTheme rewrites the color commands embedded in a string according to the current theme.
+func applyTheme(s string) string {
+	commands := themeCommands[CurrentTheme()]
+	if len(commands) == 0 {
+		return s
+	}
+
+	return tagRegexp.ReplaceAllStringFunc(s, func(tag string) string {
+		command := tagRegexp.FindStringSubmatch(tag)[1]
+		if replacement, has := commands[command]; has {
+			return replacement
+		}
+		return tag
+	})
+}
+
+// NoColorRequested returns true if the user has asked for colorless output by setting the NO_COLOR environment
+// variable (see https://no-color.org).
+func NoColorRequested() bool {
+	return os.Getenv("NO_COLOR") != ""
+}
@@ -0,0 +1,45 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package colors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyTheme(t *testing.T) {
+	defer SetTheme(CurrentTheme())
+
+	s := SpecCreate + "+ added" + Reset + Bold + "!" + Reset
+
+	SetTheme(ThemeDark)
+	assert.Equal(t, s, applyTheme(s))
+
+	SetTheme(ThemeMonochrome)
+	assert.Equal(t, "+ added"+Reset+Bold+"!"+Reset, applyTheme(s))
+
+	SetTheme(ThemeHighContrast)
+	assert.Equal(t, BrightGreen+Bold+"+ added"+Reset+Bold+"!"+Reset, applyTheme(s))
+}
+
+func TestParseTheme(t *testing.T) {
+	theme, err := ParseTheme("light")
+	assert.NoError(t, err)
+	assert.Equal(t, ThemeLight, theme)
+
+	_, err = ParseTheme("sepia")
+	assert.Error(t, err)
+}
@@ -52,3 +52,94 @@ func GetAnalyzeResourceFailureError(urn resource.URN) *Diag {
 func GetPreviewFailedError(urn resource.URN) *Diag {
 	return newError(urn, 2005, "Preview failed: %v")
 }
+
+func GetUntargetedDependencyError(urn resource.URN) *Diag {
+	return newError(urn, 2006,
+		"Resource '%v' depends on '%v', which does not exist yet and will not be created because it is not a "+
+			"target of this update; add it to the targets to create it")
+}
+
+func GetTargetNotFoundWarning(urn resource.URN) *Diag {
+	return newError(urn, 2007, "Target '%v' does not refer to a resource in this stack or program")
+}
+
+func GetPendingOperationsWarning(urn resource.URN) *Diag {
+	return newError(urn, 2008,
+		"The last update of this stack was interrupted with %v operation(s) still in progress; run "+
+			"'pulumi update --resume' to reconcile them")
+}
+
+func GetPendingCreateWarning(urn resource.URN) *Diag {
+	return newError(urn, 2009,
+		"Resource '%v' may have been created by an interrupted update, but its ID was never recorded; if it exists, "+
+			"it must be deleted by hand")
+}
+
+func GetPendingOperationReconciledInfo(urn resource.URN) *Diag {
+	return newError(urn, 2010, "Reconciled interrupted operation on resource '%v': %v")
+}
+
+func GetOperationRetryWarning(urn resource.URN) *Diag {
+	return newError(urn, 2011,
+		"%v of resource '%v' failed with a transient error (attempt %v of %v); retrying in %v: %v")
+}
+
+func GetRollbackFailedWarning(urn resource.URN) *Diag {
+	return newError(urn, 2012, "The %v of resource '%v' could not be rolled back; it must be reverted by hand")
+}
+
+func GetRollbackSummaryInfo(urn resource.URN) *Diag {
+	return newError(urn, 2013, "The update failed, so %v change(s) were rolled back; %v could not be rolled back")
+}
+
+func GetResourceDependencyCycleError(urn resource.URN) *Diag {
+	return newError(urn, 2014,
+		"Resource '%v' is part of a dependency cycle:\n%v\nTo break the cycle, %v.")
+}
+
+func GetDeleteWaveInfo(urn resource.URN) *Diag {
+	return newError(urn, 2015, "Deleting wave %v of %v: %v resource(s) in parallel")
+}
+
+func GetBlueGreenPhasesInfo(urn resource.URN) *Diag {
+	return newError(urn, 2016,
+		"Blue/green group '%v': first, %v replacement resource(s) are created; then, once the readiness check %v "+
+			"passes, the %v old resource(s) are deleted")
+}
+
+func GetBlueGreenNotReadyError(urn resource.URN) *Diag {
+	return newError(urn, 2017,
+		"Blue/green group '%v' did not become ready, so its old resources have not been deleted: %v")
+}
+
+func GetExternalResourceDriftWarning(urn resource.URN) *Diag {
+	return newError(urn, 2018,
+		"External resource '%v' has changed since it was last read: [%v]; it is not managed by this stack, so "+
+			"nothing is done about it")
+}
+
+func GetDestroyProtectedResourcesError(urn resource.URN) *Diag {
+	return newError(urn, 2019,
+		"The destroy would delete %v protected resource(s):\n%v\nUnprotect them first with 'pulumi state unprotect', "+
+			"or rerun the destroy with --force-unprotect")
+}
+
+func GetForceUnprotectInfo(urn resource.URN) *Diag {
+	return newError(urn, 2020, "Removing protection from resource '%v' so that it can be destroyed")
+}
+
+func GetAliasedResourceWarning(urn resource.URN) *Diag {
+	return newError(urn, 2021,
+		"Resource '%v' was previously renamed to '%v'; if the program is still using the old name, the resource "+
+			"will be created anew, and the renamed one deleted")
+}
+
+func GetImpliedTargetInfo(urn resource.URN) *Diag {
+	return newError(urn, 2022, "Also targeting '%v', because the targeted resource '%v' depends on it")
+}
+
+func GetBlueGreenDeleteBeforeReplaceWarning(urn resource.URN) *Diag {
+	return newError(urn, 2023,
+		"Resource '%v' is a member of blue/green group '%v', but its provider requires it to be deleted before it is "+
+			"replaced; it is deleted first, and so is not part of the group's blue/green replacement")
+}
@@ -0,0 +1,263 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+)
+
+// maxArrayMatchCells bounds the work done to match up the elements of two arrays; larger arrays are simply diffed by
+// index.
+const maxArrayMatchCells = 1 << 20
+
+// minElementSimilarity is the fraction of an object's properties that must be unchanged for an old and new element to
+// be considered the same element with some changes, rather than a deletion and an unrelated addition.
+const minElementSimilarity = 0.5
+
+// arrayElementDiff describes the change made to a single element of an array.
+type arrayElementDiff struct {
+	Index int                    // the element's index in the new array, or in the old array if it was deleted.
+	Op    deploy.StepOp          // one of OpCreate, OpDelete, OpUpdate, or OpSame.
+	Value resource.PropertyValue // the element's value, for additions, deletions, and unchanged elements.
+	Diff  resource.ValueDiff     // the element's diff, for updates.
+}
+
+// getArrayElementDiffs returns the changes made to each element of an array, in display order.  By default, elements
+// are compared by index; if requested by the diff options, elements are instead matched up by an identity key or by
+// similarity, so that inserting or removing an element doesn't show every element after it as changed.
+func getArrayElementDiffs(diff resource.ValueDiff, opts DiffOptions) []arrayElementDiff {
+	if diff.Old.IsArray() && diff.New.IsArray() {
+		olds, news := diff.Old.ArrayValue(), diff.New.ArrayValue()
+		if len(olds)*len(news) <= maxArrayMatchCells {
+			if key, ok := findArrayIdentityKey(olds, news, opts.ArrayKeys); ok {
+				return alignArrayElements(olds, news, matchArrayElementsByKey(olds, news, key))
+			}
+			if opts.MatchArrayElements {
+				return alignArrayElements(olds, news, matchArrayElementsByValue(olds, news))
+			}
+		}
+	}
+
+	a := diff.Array
+	var elems []arrayElementDiff
+	for i := 0; i < a.Len(); i++ {
+		if add, isadd := a.Adds[i]; isadd {
+			elems = append(elems, arrayElementDiff{Index: i, Op: deploy.OpCreate, Value: add})
+		} else if delete, isdelete := a.Deletes[i]; isdelete {
+			elems = append(elems, arrayElementDiff{Index: i, Op: deploy.OpDelete, Value: delete})
+		} else if update, isupdate := a.Updates[i]; isupdate {
+			elems = append(elems, arrayElementDiff{Index: i, Op: deploy.OpUpdate, Diff: update})
+		} else {
+			elems = append(elems, arrayElementDiff{Index: i, Op: deploy.OpSame, Value: a.Sames[i]})
+		}
+	}
+	return elems
+}
+
+// findArrayIdentityKey returns the first of the given keys that identifies the elements of both arrays: that is, every
+// element is an object with a unique, known primitive value for the key.
+func findArrayIdentityKey(olds, news []resource.PropertyValue, keys []string) (resource.PropertyKey, bool) {
+	for _, k := range keys {
+		key := resource.PropertyKey(k)
+		if _, ok := arrayIdentities(olds, key); !ok {
+			continue
+		}
+		if _, ok := arrayIdentities(news, key); !ok {
+			continue
+		}
+		return key, true
+	}
+	return "", false
+}
+
+// arrayIdentities returns the identity of each element of an array under the given key, or false if some element
+// doesn't have a unique identity.
+func arrayIdentities(elems []resource.PropertyValue, key resource.PropertyKey) ([]string, bool) {
+	ids := make([]string, len(elems))
+	seen := make(map[string]bool)
+	for i, elem := range elems {
+		if !elem.IsObject() {
+			return nil, false
+		}
+		id, has := elem.ObjectValue()[key]
+		if !has || !(id.IsString() || id.IsNumber() || id.IsBool()) {
+			return nil, false
+		}
+		ids[i] = fmt.Sprintf("%s:%v", id.TypeString(), id.V)
+		if seen[ids[i]] {
+			return nil, false
+		}
+		seen[ids[i]] = true
+	}
+	return ids, true
+}
+
+// matchArrayElementsByKey matches each new element to the old element with the same identity, if any.  The result
+// holds, for each new element, the index of its matching old element, or -1 if it has none.
+func matchArrayElementsByKey(olds, news []resource.PropertyValue, key resource.PropertyKey) []int {
+	oldIDs, _ := arrayIdentities(olds, key)
+	newIDs, _ := arrayIdentities(news, key)
+
+	oldIndices := make(map[string]int)
+	for i, id := range oldIDs {
+		oldIndices[id] = i
+	}
+
+	matches := make([]int, len(news))
+	for j, id := range newIDs {
+		if i, has := oldIndices[id]; has {
+			matches[j] = i
+		} else {
+			matches[j] = -1
+		}
+	}
+	return matches
+}
+
+// matchArrayElementsByValue matches up unchanged elements of the two arrays using their longest common subsequence.
+// Within each run of unmatched elements between them, old and new elements are then paired up if the runs are the same
+// length (a simple in-place edit), or if the elements are sufficiently similar objects.  The result is as for
+// matchArrayElementsByKey.
+func matchArrayElementsByValue(olds, news []resource.PropertyValue) []int {
+	// lcs[i][j] is the length of the longest common subsequence of olds[i:] and news[j:].
+	lcs := make([][]int, len(olds)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(news)+1)
+	}
+	for i := len(olds) - 1; i >= 0; i-- {
+		for j := len(news) - 1; j >= 0; j-- {
+			if olds[i].DeepEquals(news[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	matches := make([]int, len(news))
+	for j := range matches {
+		matches[j] = -1
+	}
+
+	i, j := 0, 0
+	gapOld, gapNew := 0, 0
+	for i < len(olds) && j < len(news) {
+		if olds[i].DeepEquals(news[j]) {
+			matchArrayGap(olds, news, gapOld, i, gapNew, j, matches)
+			matches[j] = i
+			i, j = i+1, j+1
+			gapOld, gapNew = i, j
+		} else if lcs[i+1][j] >= lcs[i][j+1] {
+			i++
+		} else {
+			j++
+		}
+	}
+	matchArrayGap(olds, news, gapOld, len(olds), gapNew, len(news), matches)
+	return matches
+}
+
+// matchArrayGap pairs up the old elements in [oldStart, oldEnd) with the new elements in [newStart, newEnd), none of
+// which are unchanged, recording the pairs in matches.  Pairs never cross, so that the elements stay in order.
+func matchArrayGap(olds, news []resource.PropertyValue, oldStart, oldEnd, newStart, newEnd int, matches []int) {
+	if oldEnd-oldStart == newEnd-newStart {
+		for k := 0; k < oldEnd-oldStart; k++ {
+			matches[newStart+k] = oldStart + k
+		}
+		return
+	}
+
+	next := newStart
+	for i := oldStart; i < oldEnd && next < newEnd; i++ {
+		for j := next; j < newEnd; j++ {
+			if elementSimilarity(olds[i], news[j]) >= minElementSimilarity {
+				matches[j] = i
+				next = j + 1
+				break
+			}
+		}
+	}
+}
+
+// elementSimilarity returns the fraction of properties that two objects have in common, from 0 (nothing in common) to
+// 1 (identical).  Values that aren't both objects are never considered similar.
+func elementSimilarity(old, new resource.PropertyValue) float64 {
+	if !old.IsObject() || !new.IsObject() {
+		return 0
+	}
+
+	oldObj, newObj := old.ObjectValue(), new.ObjectValue()
+	keys := make(map[resource.PropertyKey]bool)
+	same := 0
+	for k, v := range oldObj {
+		keys[k] = true
+		if nv, has := newObj[k]; has && v.DeepEquals(nv) {
+			same++
+		}
+	}
+	for k := range newObj {
+		keys[k] = true
+	}
+	if len(keys) == 0 {
+		return 1
+	}
+	return float64(same) / float64(len(keys))
+}
+
+// alignArrayElements turns the matches between the elements of two arrays into the changes made to each element, in
+// the order of the new array.  Unmatched old elements are shown as deleted, just before the new element that follows
+// them, and unmatched new elements as added.
+func alignArrayElements(olds, news []resource.PropertyValue, matches []int) []arrayElementDiff {
+	matched := make(map[int]bool)
+	for _, i := range matches {
+		if i >= 0 {
+			matched[i] = true
+		}
+	}
+
+	var elems []arrayElementDiff
+	next := 0
+	deleteUpTo := func(end int) {
+		for ; next < end; next++ {
+			if !matched[next] {
+				elems = append(elems, arrayElementDiff{Index: next, Op: deploy.OpDelete, Value: olds[next]})
+			}
+		}
+	}
+
+	for j, i := range matches {
+		if i < 0 {
+			elems = append(elems, arrayElementDiff{Index: j, Op: deploy.OpCreate, Value: news[j]})
+			continue
+		}
+
+		deleteUpTo(i)
+		if next == i {
+			next++
+		}
+		if diff := olds[i].Diff(news[j]); diff != nil {
+			elems = append(elems, arrayElementDiff{Index: j, Op: deploy.OpUpdate, Diff: *diff})
+		} else {
+			elems = append(elems, arrayElementDiff{Index: j, Op: deploy.OpSame, Value: news[j]})
+		}
+	}
+	deleteUpTo(len(olds))
+	return elems
+}
@@ -0,0 +1,112 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+)
+
+// describeArrayDiff diffs two arrays and describes the change made to each element as "<op> <index>".
+func describeArrayDiff(t *testing.T, olds, news []interface{}, opts DiffOptions) []string {
+	diff := resource.NewPropertyValue(olds).Diff(resource.NewPropertyValue(news))
+	if diff == nil {
+		return nil
+	}
+	if !assert.NotNil(t, diff.Array) {
+		return nil
+	}
+
+	var descs []string
+	for _, elem := range getArrayElementDiffs(*diff, opts) {
+		descs = append(descs, fmt.Sprintf("%s %d", elem.Op, elem.Index))
+	}
+	return descs
+}
+
+func named(name string, value int) map[string]interface{} {
+	return map[string]interface{}{"name": name, "value": value}
+}
+
+func TestArrayDiffByIndex(t *testing.T) {
+	opts := DiffOptions{}
+
+	// Without matching, an insertion shows every element after it as changed.
+	assert.Equal(t, []string{"same 0", "update 1", "create 2"},
+		describeArrayDiff(t, []interface{}{1, 2}, []interface{}{1, 9, 2}, opts))
+	assert.Equal(t, []string{"same 0", "update 1", "delete 2"},
+		describeArrayDiff(t, []interface{}{1, 9, 2}, []interface{}{1, 2}, opts))
+	assert.Equal(t, []string{"create 0"}, describeArrayDiff(t, []interface{}{}, []interface{}{1}, opts))
+	assert.Equal(t, []string{"delete 0"}, describeArrayDiff(t, []interface{}{1}, []interface{}{}, opts))
+}
+
+func TestArrayDiffByValue(t *testing.T) {
+	opts := DiffOptions{MatchArrayElements: true}
+
+	tests := []struct {
+		name     string
+		olds     []interface{}
+		news     []interface{}
+		expected []string
+	}{
+		{"insert", []interface{}{1, 2}, []interface{}{1, 9, 2}, []string{"same 0", "create 1", "same 2"}},
+		{"insert first", []interface{}{1, 2}, []interface{}{0, 1, 2}, []string{"create 0", "same 1", "same 2"}},
+		{"append", []interface{}{1, 2}, []interface{}{1, 2, 3}, []string{"same 0", "same 1", "create 2"}},
+		{"delete", []interface{}{1, 9, 2}, []interface{}{1, 2}, []string{"same 0", "delete 1", "same 1"}},
+		{"delete last", []interface{}{1, 2, 3}, []interface{}{1, 2}, []string{"same 0", "same 1", "delete 2"}},
+		{"edit in place", []interface{}{1, 2, 3}, []interface{}{1, 5, 3}, []string{"same 0", "update 1", "same 2"}},
+		// A move is shown as a deletion from its old position and an addition at its new one.
+		{"move", []interface{}{1, 2, 3}, []interface{}{3, 1, 2}, []string{"create 0", "same 1", "same 2", "delete 2"}},
+		{"from empty", []interface{}{}, []interface{}{1, 2}, []string{"create 0", "create 1"}},
+		{"to empty", []interface{}{1, 2}, []interface{}{}, []string{"delete 0", "delete 1"}},
+		{"both empty", []interface{}{}, []interface{}{}, nil},
+		// Objects that are mostly the same are shown as changed, rather than as a deletion and an addition.
+		{
+			"similar objects",
+			[]interface{}{named("a", 1), named("b", 2)},
+			[]interface{}{named("a", 1), named("x", 0), named("b", 3)},
+			[]string{"same 0", "create 1", "update 2"},
+		},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, describeArrayDiff(t, test.olds, test.news, opts), test.name)
+	}
+}
+
+func TestArrayDiffByKey(t *testing.T) {
+	opts := DiffOptions{ArrayKeys: []string{"id", "name"}}
+
+	a, b, c := named("a", 1), named("b", 2), named("c", 3)
+
+	// Elements keep their identities, however they are rearranged.
+	assert.Equal(t, []string{"same 0", "same 1", "same 2"},
+		describeArrayDiff(t, []interface{}{a, b, c}, []interface{}{c, a, b}, opts))
+	assert.Equal(t, []string{"same 0", "create 1", "same 2"},
+		describeArrayDiff(t, []interface{}{a, b}, []interface{}{a, c, b}, opts))
+	assert.Equal(t, []string{"same 0", "delete 1", "same 1"},
+		describeArrayDiff(t, []interface{}{a, b, c}, []interface{}{a, c}, opts))
+	assert.Equal(t, []string{"update 0", "same 1"},
+		describeArrayDiff(t, []interface{}{a, b}, []interface{}{named("a", 10), b}, opts))
+	assert.Equal(t, []string{"create 0", "create 1"},
+		describeArrayDiff(t, []interface{}{}, []interface{}{a, b}, opts))
+
+	// If the key doesn't identify every element, elements are compared by index.
+	assert.Equal(t, []string{"update 0", "update 1", "create 2"},
+		describeArrayDiff(t, []interface{}{a, b}, []interface{}{b, a, named("a", 4)}, opts))
+}
@@ -0,0 +1,55 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/util/contract"
+)
+
+// CompareSnapshots compares two snapshots of a stack, such as those exported before and after a series of updates,
+// without running a program or contacting any providers.  A resource event is emitted for each resource in either
+// snapshot, describing how it changed from the first to the second, followed by a summary of all the changes.  This
+// lets the changes be rendered just as they would be while previewing an update.
+func CompareSnapshots(old *deploy.Snapshot, new *deploy.Snapshot,
+	events chan<- Event, opts UpdateOptions) ResourceChanges {
+
+	contract.Require(events != nil, "events")
+
+	defer func() { events <- cancelEvent() }()
+
+	emitter := &eventEmitter{Chan: events, SecretPatterns: opts.SecretPatterns}
+
+	changes := make(ResourceChanges)
+	for _, change := range deploy.CompareSnapshots(old, new) {
+		res := change.New
+		if res == nil {
+			res = change.Old
+		}
+		changes[change.Op]++
+		events <- Event{
+			Type: ResourcePreEvent,
+			Payload: ResourcePreEventPayload{
+				Metadata: emitter.makeStateEventMetadata(change.Op, change.URN, res.Type, nil,
+					change.Old, change.New, res, true, opts.Debug),
+				Planning: false,
+				Debug:    opts.Debug,
+			},
+		}
+	}
+
+	emitter.updateSummaryEvent(false, 0, changes)
+	return changes
+}
@@ -15,6 +15,13 @@
 package engine
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/diag"
+	"github.com/pulumi/pulumi/pkg/resource"
 	"github.com/pulumi/pulumi/pkg/resource/deploy"
 	"github.com/pulumi/pulumi/pkg/resource/plugin"
 	"github.com/pulumi/pulumi/pkg/util/contract"
@@ -33,7 +40,7 @@ func Destroy(u UpdateInfo, ctx *Context, opts UpdateOptions, dryRun bool) (Resou
 	}
 	defer info.Close()
 
-	emitter := makeEventEmitter(ctx.Events, u)
+	emitter := makeEventEmitter(ctx.Events, u, opts)
 	return update(ctx, info, planOptions{
 		UpdateOptions: opts,
 		SourceFunc:    newDestroySource,
@@ -55,7 +62,64 @@ func newDestroySource(
 		}
 	}
 
+	// Refuse to destroy protected resources, unless their protection is to be removed.
+	if target != nil && target.Snapshot != nil {
+		if err := checkProtectedResources(target.Snapshot, opts); err != nil {
+			return nil, err
+		}
+	}
+
 	// Create a nil source.  This simply returns "nothing" as the new state, which will cause the
 	// engine to destroy the entire existing state.
 	return deploy.NullSource, nil
 }
+
+// checkProtectedResources returns an error if a destroy would delete any protected resources from the given snapshot.
+// If the destroy forcibly unprotects resources, their protection is removed instead, so that they can be deleted.
+func checkProtectedResources(snap *deploy.Snapshot, opts planOptions) error {
+	protected := destroyedProtectedResources(snap, opts.Targets)
+	if len(protected) == 0 {
+		return nil
+	}
+
+	if !opts.ForceUnprotect {
+		var lines []string
+		for _, res := range protected {
+			lines = append(lines, fmt.Sprintf("\t%s", res.URN))
+		}
+		opts.Diag.Errorf(diag.GetDestroyProtectedResourcesError(""), len(protected), strings.Join(lines, "\n"))
+		return errors.New("refusing to destroy protected resources")
+	}
+
+	for _, res := range protected {
+		opts.Diag.Infof(diag.GetForceUnprotectInfo(res.URN), res.URN)
+		res.Protect = false
+	}
+	return nil
+}
+
+// destroyedProtectedResources returns the protected resources in a snapshot that a destroy would delete.  Without
+// targets, every resource is deleted; otherwise, each targeted resource is deleted along with everything that depends
+// on it or is one of its children.
+func destroyedProtectedResources(snap *deploy.Snapshot, targets []resource.URN) []*resource.State {
+	isTargeted := make(map[resource.URN]bool)
+	for _, urn := range targets {
+		isTargeted[urn] = true
+	}
+
+	condemned := make(map[resource.URN]bool)
+	var protected []*resource.State
+	for _, res := range snap.Resources {
+		required := condemned[res.Parent]
+		for _, dep := range res.Dependencies {
+			required = required || condemned[dep]
+		}
+		if len(targets) == 0 || isTargeted[res.URN] || required {
+			condemned[res.URN] = true
+			if res.Protect && !res.Delete {
+				protected = append(protected, res)
+			}
+		}
+	}
+	return protected
+}
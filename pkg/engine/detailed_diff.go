@@ -0,0 +1,199 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"sort"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/plugin"
+)
+
+// detailedDiffStep is implemented by steps that may carry a detailed diff supplied by their resource's provider.
+type detailedDiffStep interface {
+	DetailedDiff() map[string]plugin.PropertyDiff
+}
+
+// applyDetailedDiff reconciles a diff of a step's old and new properties with the provider's detailed diff of the same
+// step, which is authoritative: a provider may normalize its inputs, so that some properties that differ textually
+// don't change at all.  Changes that the provider didn't report are shown as unchanged, and changes that it reported
+// but that the diff missed are added.  The result is never nil, even if nothing changed, so that the display shows the
+// provider's view rather than diffing the properties itself.
+func applyDetailedDiff(diff *resource.ObjectDiff, olds resource.PropertyMap, news resource.PropertyMap,
+	detailed map[string]plugin.PropertyDiff) *resource.ObjectDiff {
+
+	var paths []string
+	for p := range detailed {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	var reported []resource.PropertyPath
+	for _, p := range paths {
+		if path := resource.ParsePropertyPath(p); len(path) > 0 {
+			reported = append(reported, path)
+		}
+	}
+
+	result := &resource.ObjectDiff{
+		Adds:    make(resource.PropertyMap),
+		Deletes: make(resource.PropertyMap),
+		Sames:   make(resource.PropertyMap),
+		Updates: make(map[resource.PropertyKey]resource.ValueDiff),
+	}
+	if diff != nil {
+		result = filterObjectDiff(nil, diff, reported)
+	}
+
+	for i, path := range reported {
+		k := resource.PropertyKey(path[0])
+		if result.Changed(k) {
+			continue
+		}
+		old, hasOld := olds[k]
+		new, hasNew := news[k]
+		delete(result.Sames, k)
+		switch kind := detailed[paths[i]].Kind; {
+		case (kind == plugin.DiffAdd || kind == plugin.DiffAddReplace) && hasNew && !hasOld:
+			result.Adds[k] = new
+		case (kind == plugin.DiffDelete || kind == plugin.DiffDeleteReplace) && hasOld && !hasNew:
+			result.Deletes[k] = old
+		default:
+			result.Updates[k] = resource.ValueDiff{Old: old, New: new}
+		}
+	}
+	return result
+}
+
+// detailedDiffCoverage returns whether a property at the given path was reported as changed in its entirety, and
+// whether any property beneath it was reported as changed.
+func detailedDiffCoverage(path resource.PropertyPath, reported []resource.PropertyPath) (bool, bool) {
+	var whole, beneath bool
+	for _, r := range reported {
+		switch {
+		case hasPathPrefix(path, r):
+			whole = true
+		case hasPathPrefix(r, path):
+			beneath = true
+		}
+	}
+	return whole, beneath
+}
+
+// hasPathPrefix returns true if the given property path begins with the given prefix.
+func hasPathPrefix(path resource.PropertyPath, prefix resource.PropertyPath) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// filterObjectDiff returns a copy of the given object diff, found at the given path, in which each change that wasn't
+// reported by the provider is shown as unchanged instead.
+func filterObjectDiff(path resource.PropertyPath, diff *resource.ObjectDiff,
+	reported []resource.PropertyPath) *resource.ObjectDiff {
+
+	result := &resource.ObjectDiff{
+		Adds:    make(resource.PropertyMap),
+		Deletes: make(resource.PropertyMap),
+		Sames:   make(resource.PropertyMap),
+		Updates: make(map[resource.PropertyKey]resource.ValueDiff),
+	}
+	for k, v := range diff.Sames {
+		result.Sames[k] = v
+	}
+	for k, v := range diff.Adds {
+		if whole, beneath := detailedDiffCoverage(path.Key(k), reported); whole || beneath {
+			result.Adds[k] = v
+		} else {
+			result.Sames[k] = v
+		}
+	}
+	for k, v := range diff.Deletes {
+		if whole, beneath := detailedDiffCoverage(path.Key(k), reported); whole || beneath {
+			result.Deletes[k] = v
+		} else {
+			result.Sames[k] = v
+		}
+	}
+	for k, v := range diff.Updates {
+		if update, changed := filterValueDiff(path.Key(k), v, reported); changed {
+			result.Updates[k] = update
+		} else {
+			result.Sames[k] = v.New
+		}
+	}
+	return result
+}
+
+// filterArrayDiff is the equivalent of filterObjectDiff for array diffs.
+func filterArrayDiff(path resource.PropertyPath, diff *resource.ArrayDiff,
+	reported []resource.PropertyPath) *resource.ArrayDiff {
+
+	result := &resource.ArrayDiff{
+		Adds:    make(map[int]resource.PropertyValue),
+		Deletes: make(map[int]resource.PropertyValue),
+		Sames:   make(map[int]resource.PropertyValue),
+		Updates: make(map[int]resource.ValueDiff),
+	}
+	for i, v := range diff.Sames {
+		result.Sames[i] = v
+	}
+	for i, v := range diff.Adds {
+		if whole, beneath := detailedDiffCoverage(path.Index(i), reported); whole || beneath {
+			result.Adds[i] = v
+		} else {
+			result.Sames[i] = v
+		}
+	}
+	for i, v := range diff.Deletes {
+		if whole, beneath := detailedDiffCoverage(path.Index(i), reported); whole || beneath {
+			result.Deletes[i] = v
+		} else {
+			result.Sames[i] = v
+		}
+	}
+	for i, v := range diff.Updates {
+		if update, changed := filterValueDiff(path.Index(i), v, reported); changed {
+			result.Updates[i] = update
+		} else {
+			result.Sames[i] = v.New
+		}
+	}
+	return result
+}
+
+// filterValueDiff filters the diff of a single value, found at the given path, returning the result and whether the
+// provider reported any change to the value at all.
+func filterValueDiff(path resource.PropertyPath, diff resource.ValueDiff,
+	reported []resource.PropertyPath) (resource.ValueDiff, bool) {
+
+	whole, beneath := detailedDiffCoverage(path, reported)
+	switch {
+	case whole:
+		return diff, true
+	case !beneath:
+		return diff, false
+	case diff.Object != nil:
+		diff.Object = filterObjectDiff(path, diff.Object, reported)
+	case diff.Array != nil:
+		diff.Array = filterArrayDiff(path, diff.Array, reported)
+	}
+	return diff, true
+}
@@ -0,0 +1,216 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/plugin"
+)
+
+// describeObjectDiff returns the changes in an object diff as sorted strings of the form "<op><path>", where op is `+`
+// for adds, `-` for deletes, and `~` for updates.  Updates to objects and arrays are described by their elements.
+func describeObjectDiff(path resource.PropertyPath, diff *resource.ObjectDiff) []string {
+	var changes []string
+	for k := range diff.Adds {
+		changes = append(changes, "+"+path.Key(k).String())
+	}
+	for k := range diff.Deletes {
+		changes = append(changes, "-"+path.Key(k).String())
+	}
+	for k, update := range diff.Updates {
+		changes = append(changes, describeValueDiff(path.Key(k), update)...)
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// describeArrayDiffChanges is the equivalent of describeObjectDiff for array diffs.
+func describeArrayDiffChanges(path resource.PropertyPath, diff *resource.ArrayDiff) []string {
+	var changes []string
+	for i := range diff.Adds {
+		changes = append(changes, "+"+path.Index(i).String())
+	}
+	for i := range diff.Deletes {
+		changes = append(changes, "-"+path.Index(i).String())
+	}
+	for i, update := range diff.Updates {
+		changes = append(changes, describeValueDiff(path.Index(i), update)...)
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+func describeValueDiff(path resource.PropertyPath, diff resource.ValueDiff) []string {
+	switch {
+	case diff.Object != nil:
+		return describeObjectDiff(path, diff.Object)
+	case diff.Array != nil:
+		return describeArrayDiffChanges(path, diff.Array)
+	default:
+		return []string{"~" + path.String()}
+	}
+}
+
+func TestApplyDetailedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		olds     map[string]interface{}
+		news     map[string]interface{}
+		detailed map[string]plugin.PropertyDiff
+		expected []string
+	}{
+		{
+			name:     "unreported change",
+			olds:     map[string]interface{}{"a": "old"},
+			news:     map[string]interface{}{"a": "new"},
+			expected: nil,
+		},
+		{
+			name:     "nested object property",
+			olds:     map[string]interface{}{"a": map[string]interface{}{"b": 1, "c": 2}, "d": "old"},
+			news:     map[string]interface{}{"a": map[string]interface{}{"b": 10, "c": 20}, "d": "new"},
+			detailed: map[string]plugin.PropertyDiff{"a.b": {Kind: plugin.DiffUpdate}},
+			expected: []string{"~a.b"},
+		},
+		{
+			name:     "whole object",
+			olds:     map[string]interface{}{"a": map[string]interface{}{"b": 1, "c": 2}},
+			news:     map[string]interface{}{"a": map[string]interface{}{"b": 10, "c": 20}},
+			detailed: map[string]plugin.PropertyDiff{"a": {Kind: plugin.DiffUpdate}},
+			expected: []string{"~a.b", "~a.c"},
+		},
+		{
+			name:     "nested object add",
+			olds:     map[string]interface{}{"a": map[string]interface{}{"b": 1}},
+			news:     map[string]interface{}{"a": map[string]interface{}{"b": 1, "c": 2, "d": 3}},
+			detailed: map[string]plugin.PropertyDiff{"a.d": {Kind: plugin.DiffAdd}},
+			expected: []string{"+a.d"},
+		},
+		{
+			name: "array element property",
+			olds: map[string]interface{}{
+				"list": []interface{}{map[string]interface{}{"x": 1}, map[string]interface{}{"x": 2, "y": 1}},
+			},
+			news: map[string]interface{}{
+				"list": []interface{}{
+					map[string]interface{}{"x": 1},
+					map[string]interface{}{"x": 3, "y": 2},
+					map[string]interface{}{"x": 4},
+				},
+			},
+			detailed: map[string]plugin.PropertyDiff{"list.1.x": {Kind: plugin.DiffUpdate}},
+			expected: []string{"~list.1.x"},
+		},
+		{
+			name:     "array add",
+			olds:     map[string]interface{}{"list": []interface{}{1, 2}},
+			news:     map[string]interface{}{"list": []interface{}{1, 3, 4}},
+			detailed: map[string]plugin.PropertyDiff{"list.2": {Kind: plugin.DiffAdd}},
+			expected: []string{"+list.2"},
+		},
+		{
+			name:     "array delete",
+			olds:     map[string]interface{}{"list": []interface{}{1, 2, 3}},
+			news:     map[string]interface{}{"list": []interface{}{1}},
+			detailed: map[string]plugin.PropertyDiff{"list.2": {Kind: plugin.DiffDelete}},
+			expected: []string{"-list.2"},
+		},
+		{
+			name:     "reported but not diffed",
+			olds:     map[string]interface{}{"a": "same"},
+			news:     map[string]interface{}{"a": "same"},
+			detailed: map[string]plugin.PropertyDiff{"a": {Kind: plugin.DiffUpdateReplace}},
+			expected: []string{"~a"},
+		},
+		{
+			name:     "reported delete",
+			olds:     map[string]interface{}{"a": "old", "b": "old"},
+			news:     map[string]interface{}{},
+			detailed: map[string]plugin.PropertyDiff{"b": {Kind: plugin.DiffDelete}},
+			expected: []string{"-b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			olds := resource.NewPropertyMapFromMap(tt.olds)
+			news := resource.NewPropertyMapFromMap(tt.news)
+			result := applyDetailedDiff(olds.Diff(news), olds, news, tt.detailed)
+			if assert.NotNil(t, result) {
+				assert.Equal(t, tt.expected, describeObjectDiff(nil, result))
+			}
+		})
+	}
+}
+
+func TestFilterObjectDiff(t *testing.T) {
+	olds := resource.NewPropertyMapFromMap(map[string]interface{}{
+		"a": map[string]interface{}{"b": map[string]interface{}{"c": 1, "d": 1}, "e": 1},
+		"f": 1,
+	})
+	news := resource.NewPropertyMapFromMap(map[string]interface{}{
+		"a": map[string]interface{}{"b": map[string]interface{}{"c": 2, "d": 2}, "e": 2},
+		"g": 1,
+	})
+	diff := olds.Diff(news)
+
+	reported := []resource.PropertyPath{
+		resource.ParsePropertyPath("a.b.c"),
+		resource.ParsePropertyPath("g"),
+	}
+	result := filterObjectDiff(nil, diff, reported)
+	assert.Equal(t, []string{"+g", "~a.b.c"}, describeObjectDiff(nil, result))
+
+	// Unreported changes are kept as sames.
+	assert.True(t, result.Sames["f"].DeepEquals(resource.NewNumberProperty(1)))
+	assert.True(t, result.Updates["a"].Object.Sames["e"].DeepEquals(resource.NewNumberProperty(2)))
+
+	// The original diff is left as it was.
+	assert.Equal(t, []string{"+g", "-f", "~a.b.c", "~a.b.d", "~a.e"}, describeObjectDiff(nil, diff))
+}
+
+func TestFilterArrayDiff(t *testing.T) {
+	old := resource.NewPropertyValue([]interface{}{
+		[]interface{}{1, 2},
+		map[string]interface{}{"x": 1},
+		3,
+	})
+	new := resource.NewPropertyValue([]interface{}{
+		[]interface{}{1, 3},
+		map[string]interface{}{"x": 2},
+	})
+	diff := old.Diff(new)
+	if !assert.NotNil(t, diff) || !assert.NotNil(t, diff.Array) {
+		t.FailNow()
+	}
+
+	path := resource.ParsePropertyPath("list")
+	reported := []resource.PropertyPath{
+		resource.ParsePropertyPath("list.0.1"),
+		resource.ParsePropertyPath("list.2"),
+	}
+	result := filterArrayDiff(path, diff.Array, reported)
+	assert.Equal(t, []string{"-list.2", "~list.0.1"}, describeArrayDiffChanges(path, result))
+	assert.Contains(t, result.Sames, 1)
+
+	// Reporting an element in its entirety keeps all of the changes beneath it.
+	reported = []resource.PropertyPath{resource.ParsePropertyPath("list.1")}
+	result = filterArrayDiff(path, diff.Array, reported)
+	assert.Equal(t, []string{"~list.1.x"}, describeArrayDiffChanges(path, result))
+}
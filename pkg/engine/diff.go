@@ -16,20 +16,225 @@ package engine
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"reflect"
 	"sort"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/pkg/errors"
 	"github.com/sergi/go-diff/diffmatchpatch"
 
 	"github.com/pulumi/pulumi/pkg/diag/colors"
 	"github.com/pulumi/pulumi/pkg/resource"
 	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
 	"github.com/pulumi/pulumi/pkg/util/contract"
+	"github.com/pulumi/pulumi/pkg/util/logging"
 )
 
+// DefaultDiffContextLines is the number of unchanged lines shown around each change in a text diff by default.
+const DefaultDiffContextLines = 2
+
+// Default limits on how much of a large property value is shown.
+const (
+	DefaultMaxStringLength  = 1024 // the default maximum number of characters of a string to show.
+	DefaultMaxArrayElements = 100  // the default maximum number of elements of an array to show.
+	DefaultMaxObjectKeys    = 100  // the default maximum number of properties of an object to show.
+)
+
+// DiffOptions controls how resource property diffs are rendered.
+type DiffOptions struct {
+	// ContextLines is the number of unchanged lines to show around each change in a text diff; if nil, the default
+	// of DefaultDiffContextLines is used.  Backends set this from the update's engine.UpdateOptions.DiffContextLines.
+	ContextLines *int
+	// FullDiff, when true, shows the entirety of a text diff rather than just the context around each change.
+	FullDiff bool
+	// IncludePaths, if non-empty, restricts the properties shown to those whose paths match one of these glob patterns
+	// (along with everything beneath them).
+	IncludePaths []string
+	// ExcludePaths is a list of glob patterns for property paths that should never be shown.
+	ExcludePaths []string
+	// MaxStringLength is the maximum number of characters of a string value to show; if zero, the default of
+	// DefaultMaxStringLength is used, and if negative, strings are never truncated.
+	MaxStringLength int
+	// MaxArrayElements is the maximum number of elements of an array value to show; if zero, the default of
+	// DefaultMaxArrayElements is used, and if negative, arrays are never truncated.
+	MaxArrayElements int
+	// MaxObjectKeys is the maximum number of properties of an object value to show; if zero, the default of
+	// DefaultMaxObjectKeys is used, and if negative, objects are never truncated.
+	MaxObjectKeys int
+	// NoTruncate, when true, shows property values in their entirety regardless of the above limits.
+	NoTruncate bool
+	// CollapseUnchanged, when true, shows unchanged objects and arrays nested within a diff as a single line counting
+	// their elements, rather than printing their entire contents.
+	CollapseUnchanged bool
+	// ExpandPaths is a list of glob patterns for property paths that should be shown in full even when
+	// CollapseUnchanged is set.
+	ExpandPaths []string
+	// Width, if positive, is the width of the terminal; long lines are wrapped to fit within it, and URNs shortened.
+	Width int
+	// NoLineNumbers, when true, omits the old and new line numbers normally shown beside each line of a text diff.
+	NoLineNumbers bool
+	// ArchiveContents, when true, opens changed path- and URI-based archives and diffs their member files, rather than
+	// just showing that the archive's hash changed.
+	ArchiveContents bool
+	// ArrayKeys is a list of property names, such as "name", that identify the elements of arrays of objects.  When
+	// every element of both the old and new arrays has a unique value for one of these keys, elements are matched up
+	// by that value rather than by index, so that inserting or removing an element doesn't shift all those after it.
+	ArrayKeys []string
+	// MatchArrayElements, when true, matches up the elements of changed arrays by value and similarity rather than by
+	// index, so that only the elements actually added, removed, or changed are shown.
+	MatchArrayElements bool
+	// Stats, if non-nil, accumulates counts of the property changes printed while rendering a diff.
+	Stats *DiffStats
+
+	render *renderState // the custom renderer for the step currently being rendered, if any.
+}
+
+// DiffStats counts the properties added, changed, and deleted by a diff.
+type DiffStats struct {
+	Adds    int // the number of properties added.
+	Updates int // the number of properties whose values changed.
+	Deletes int // the number of properties deleted.
+}
+
+// Any returns true if any property changes have been counted.
+func (s DiffStats) Any() bool {
+	return s.Adds > 0 || s.Updates > 0 || s.Deletes > 0
+}
+
+// Merge adds the counts from other into these stats.
+func (s *DiffStats) Merge(other DiffStats) {
+	s.Adds += other.Adds
+	s.Updates += other.Updates
+	s.Deletes += other.Deletes
+}
+
+// String renders the stats as a compact summary, e.g. "1 added, 2 changed, 0 deleted properties".
+func (s DiffStats) String() string {
+	total := s.Adds + s.Updates + s.Deletes
+	noun := "properties"
+	if total == 1 {
+		noun = "property"
+	}
+	return fmt.Sprintf("%d added, %d changed, %d deleted %s", s.Adds, s.Updates, s.Deletes, noun)
+}
+
+func (opts DiffOptions) countAdd() {
+	if opts.Stats != nil {
+		opts.Stats.Adds++
+	}
+}
+
+func (opts DiffOptions) countUpdate() {
+	if opts.Stats != nil {
+		opts.Stats.Updates++
+	}
+}
+
+func (opts DiffOptions) countDelete() {
+	if opts.Stats != nil {
+		opts.Stats.Deletes++
+	}
+}
+
+// filterPath decides whether the property at the given path should be shown, given the include and exclude patterns
+// in these options.  It also returns the options that should be used when printing the property's children.
+func (opts DiffOptions) filterPath(path resource.PropertyPath) (bool, DiffOptions) {
+	if path.MatchesAny(opts.ExcludePaths) {
+		return false, opts
+	}
+	if len(opts.IncludePaths) == 0 {
+		return true, opts
+	}
+	if path.MatchesAny(opts.IncludePaths) {
+		// Everything beneath an included property is included too (save for explicit exclusions).
+		opts.IncludePaths = nil
+		return true, opts
+	}
+
+	// Otherwise, we still need to show this property if something beneath it may be included.
+	for _, pattern := range opts.IncludePaths {
+		if path.MayMatchDescendants(pattern) {
+			return true, opts
+		}
+	}
+	return false, opts
+}
+
+// contextLines returns the number of unchanged context lines that should be shown around changes in a text diff.
+func (opts DiffOptions) contextLines() int {
+	if opts.ContextLines == nil || *opts.ContextLines < 0 {
+		return DefaultDiffContextLines
+	}
+	return *opts.ContextLines
+}
+
+// truncateLimit returns the effective value of a truncation limit, or -1 if there is no limit.
+func (opts DiffOptions) truncateLimit(limit int, def int) int {
+	switch {
+	case opts.NoTruncate || limit < 0:
+		return -1
+	case limit == 0:
+		return def
+	default:
+		return limit
+	}
+}
+
+func (opts DiffOptions) maxStringLength() int {
+	return opts.truncateLimit(opts.MaxStringLength, DefaultMaxStringLength)
+}
+
+func (opts DiffOptions) maxArrayElements() int {
+	return opts.truncateLimit(opts.MaxArrayElements, DefaultMaxArrayElements)
+}
+
+func (opts DiffOptions) maxObjectKeys() int {
+	return opts.truncateLimit(opts.MaxObjectKeys, DefaultMaxObjectKeys)
+}
+
+// elider prints the entries of an object or array, up to a limit (or all of them if the limit is negative), and
+// counts those that are left out.  Only entries that actually print something count towards the limit, so that
+// entries hidden by path filters don't use it up.  Entries beyond the limit are still rendered (into a buffer that
+// is thrown away), so that the diff statistics cover them.
+type elider struct {
+	limit  int // the maximum number of entries to print, or -1 for no limit.
+	shown  int // the number of entries printed so far.
+	elided int // the number of entries left out so far.
+}
+
+// print renders an entry with the given function, and prints it unless the limit has already been reached.
+func (e *elider) print(b *bytes.Buffer, render func(b *bytes.Buffer)) {
+	var eb bytes.Buffer
+	render(&eb)
+	if eb.Len() == 0 {
+		return
+	}
+	if e.limit >= 0 && e.shown >= e.limit {
+		e.elided++
+		return
+	}
+	e.shown++
+	writeString(b, eb.String())
+}
+
+// printElided notes how many entries were left out, if any.
+func (e *elider) printElided(b *bytes.Buffer, indent int, op deploy.StepOp, prefix bool, noun string) {
+	if e.elided > 0 {
+		writeWithIndent(b, indent, op, prefix, "... (%d more %s)\n", e.elided, cmdutil.Plural(noun, e.elided))
+	}
+}
+
 // GetIndent computes a step's parent indentation.
 func GetIndent(step StepEventMetadata, seen map[resource.URN]StepEventMetadata) int {
 	indent := 0
@@ -58,6 +263,10 @@ func printStepHeader(b *bytes.Buffer, step StepEventMetadata) {
 		// show a locked symbol, since we are either newly protecting this resource, or retaining protection.
 		extra = " 🔒"
 	}
+	if old != nil && old.Taint != "" && (step.Op == deploy.OpReplace || step.Op == deploy.OpCreateReplacement) {
+		// show why a tainted resource is being replaced, since its properties may not have changed at all.
+		extra += fmt.Sprintf(" [tainted: %s]", old.Taint)
+	}
 	writeString(b, fmt.Sprintf("%s: (%s)%s\n", string(step.Type), step.Op, extra))
 }
 
@@ -107,7 +316,7 @@ func writeVerbatim(b *bytes.Buffer, op deploy.StepOp, value string) {
 	writeWithIndentNoPrefix(b, 0, op, "%s", value)
 }
 
-func GetResourcePropertiesSummary(step StepEventMetadata, indent int) string {
+func GetResourcePropertiesSummary(step StepEventMetadata, indent int, opts DiffOptions) string {
 	var b bytes.Buffer
 
 	op := step.Op
@@ -137,19 +346,55 @@ func GetResourcePropertiesSummary(step StepEventMetadata, indent int) string {
 		writeWithIndentNoPrefix(&b, indent+1, simplePropOp, "[id=%s]\n", string(id))
 	}
 	if urn != "" {
-		writeWithIndentNoPrefix(&b, indent+1, simplePropOp, "[urn=%s]\n", urn)
+		// In narrow terminals, shorten the URN so that it fits on a single line.
+		urnWidth := opts.Width - 4*(indent+1) - len("[urn=]")
+		writeWithIndentNoPrefix(&b, indent+1, simplePropOp, "[urn=%s]\n", shortenURN(urn, urnWidth))
 	}
 
-	return b.String()
+	// If this step replaces the resource, list the properties whose changes caused the replacement.
+	if (op == deploy.OpReplace || op == deploy.OpCreateReplacement) && len(step.Keys) > 0 {
+		var keys []string
+		for _, k := range step.Keys {
+			keys = append(keys, string(k))
+		}
+		writeWithIndentNoPrefix(&b, indent+1, op, "[replaced due to changes in: %s]\n", strings.Join(keys, ", "))
+	}
+
+	// Make it clear when the old resource will be deleted before its replacement is created, as this differs from
+	// the usual ordering and may cause downtime.
+	if op == deploy.OpReplace && step.DeleteBeforeReplace {
+		writeWithIndentNoPrefix(&b, indent+1, op, "[delete before replace]\n")
+	}
+
+	return wrapText(b.String(), opts.Width)
 }
 
 func GetResourcePropertiesDetails(
-	step StepEventMetadata, indent int, planning bool, summary bool, debug bool) string {
+	step StepEventMetadata, indent int, planning bool, summary bool, debug bool, opts DiffOptions) string {
 	var b bytes.Buffer
 
 	// indent everything an additional level, like other properties.
 	indent++
 
+	// If a custom renderer has been registered for this type of resource, give it the first crack at rendering.
+	if r := getRenderer(step.Type); r != nil {
+		opts.render = &renderState{
+			renderer: r,
+			ctx: RenderContext{
+				Type:     step.Type,
+				Op:       step.Op,
+				Planning: planning,
+				Summary:  summary,
+				Debug:    debug,
+			},
+		}
+		if renderWith(&b, indent, opts, func(r Renderer, ctx RenderContext) (string, bool) {
+			return r.RenderStep(step, ctx)
+		}) {
+			return wrapText(b.String(), opts.Width)
+		}
+	}
+
 	var replaces []resource.PropertyKey
 	if step.Op == deploy.OpCreateReplacement || step.Op == deploy.OpReplace {
 		replaces = step.Keys
@@ -158,24 +403,41 @@ func GetResourcePropertiesDetails(
 	old, new := step.Old, step.New
 	if old == nil && new != nil {
 		if len(new.Outputs) > 0 {
-			printObject(&b, new.Outputs, planning, indent, step.Op, false, debug)
+			printObject(&b, new.Outputs, planning, indent, step.Op, false, debug, nil, opts)
 		} else {
-			printObject(&b, new.Inputs, planning, indent, step.Op, false, debug)
+			printObject(&b, new.Inputs, planning, indent, step.Op, false, debug, nil, opts)
 		}
 	} else if new == nil && old != nil {
 		// in summary view, we don't have to print out the entire object that is getting deleted.
 		// note, the caller will have already printed out the type/name/id/urn of the resource,
 		// and that's sufficient for a summarized deletion view.
 		if !summary {
-			printObject(&b, old.Inputs, planning, indent, step.Op, false, debug)
+			printObject(&b, old.Inputs, planning, indent, step.Op, false, debug, nil, opts)
 		}
+	} else if step.Diff != nil {
+		// The engine has already computed the diff between the old and new states, preferring the provider's detailed
+		// diff if it supplied one, so just print it.
+		printObjectDiff(&b, *step.Diff, replaces, false, planning, indent, summary, debug, nil, opts)
 	} else if len(new.Outputs) > 0 {
-		printOldNewDiffs(&b, old.Outputs, new.Outputs, replaces, planning, indent, step.Op, summary, debug)
+		printOldNewDiffs(&b, old.Outputs, new.Outputs, replaces, planning, indent, step.Op, summary, debug, nil, opts)
 	} else {
-		printOldNewDiffs(&b, old.Inputs, new.Inputs, replaces, planning, indent, step.Op, summary, debug)
+		printOldNewDiffs(&b, old.Inputs, new.Inputs, replaces, planning, indent, step.Op, summary, debug, nil, opts)
 	}
 
-	return b.String()
+	return wrapText(b.String(), opts.Width)
+}
+
+// GetResourcePropertiesDiffStats computes counts of the properties added, changed, and deleted by a step, as they
+// would be shown by GetResourcePropertiesDetails.  Only updates and replacements are counted, since creates and
+// deletes don't render a property-level diff.
+func GetResourcePropertiesDiffStats(step StepEventMetadata, planning bool, debug bool, opts DiffOptions) DiffStats {
+	var stats DiffStats
+	if step.Old == nil || step.New == nil {
+		return stats
+	}
+	opts.Stats = &stats
+	GetResourcePropertiesDetails(step, 0, planning, true /*summary*/, debug, opts)
+	return stats
 }
 
 func maxKey(keys []resource.PropertyKey) int {
@@ -190,25 +452,31 @@ func maxKey(keys []resource.PropertyKey) int {
 
 func printObject(
 	b *bytes.Buffer, props resource.PropertyMap, planning bool,
-	indent int, op deploy.StepOp, prefix bool, debug bool) {
+	indent int, op deploy.StepOp, prefix bool, debug bool, path resource.PropertyPath, opts DiffOptions) {
 
 	// Compute the maximum with of property keys so we can justify everything.
 	keys := props.StableKeys()
 	maxkey := maxKey(keys)
 
 	// Now print out the values intelligently based on the type.
+	e := elider{limit: opts.maxObjectKeys()}
 	for _, k := range keys {
-		if v := props[k]; shouldPrintPropertyValue(v, planning) {
-			printPropertyTitle(b, string(k), maxkey, indent, op, prefix)
-			printPropertyValue(b, v, planning, indent, op, prefix, debug)
+		kpath := path.Key(k)
+		show, kopts := opts.filterPath(kpath)
+		if v := props[k]; show && shouldPrintPropertyValue(v, planning) {
+			e.print(b, func(b *bytes.Buffer) {
+				printPropertyTitle(b, string(k), maxkey, indent, op, prefix)
+				printPropertyValue(b, v, planning, indent, op, prefix, debug, kpath, kopts)
+			})
 		}
 	}
+	e.printElided(b, indent, op, prefix, "property")
 }
 
 // GetResourceOutputsPropertiesString prints only those properties that either differ from the input properties or, if
 // there is an old snapshot of the resource, differ from the prior old snapshot's output properties.
 func GetResourceOutputsPropertiesString(
-	step StepEventMetadata, indent int, planning bool, debug bool) string {
+	step StepEventMetadata, indent int, planning bool, debug bool, opts DiffOptions) string {
 
 	var b bytes.Buffer
 
@@ -229,8 +497,10 @@ func GetResourceOutputsPropertiesString(
 	maxkey := maxKey(keys)
 	for _, k := range keys {
 		out := outs[k]
+		kpath := resource.PropertyPath(nil).Key(k)
+		show, kopts := opts.filterPath(kpath)
 		// Print this property if it is printable and either ins doesn't have it or it's different.
-		if shouldPrintPropertyValue(out, true) {
+		if show && shouldPrintPropertyValue(out, true) {
 			var print bool
 			if in, has := ins[k]; has {
 				print = (out.Diff(in) != nil)
@@ -244,12 +514,12 @@ func GetResourceOutputsPropertiesString(
 					firstout = false
 				}
 				printPropertyTitle(&b, string(k), maxkey, indent, op, false)
-				printPropertyValue(&b, out, planning, indent, op, false, debug)
+				printPropertyValue(&b, out, planning, indent, op, false, debug, kpath, kopts)
 			}
 		}
 	}
 
-	return b.String()
+	return wrapText(b.String(), opts.Width)
 }
 
 func considerSameIfNotCreateOrDelete(op deploy.StepOp) deploy.StepOp {
@@ -289,25 +559,38 @@ func printPropertyTitle(b *bytes.Buffer, name string, align int, indent int, op
 
 func printPropertyValue(
 	b *bytes.Buffer, v resource.PropertyValue, planning bool,
-	indent int, op deploy.StepOp, prefix bool, debug bool) {
+	indent int, op deploy.StepOp, prefix bool, debug bool, path resource.PropertyPath, opts DiffOptions) {
 
 	if isPrimitive(v) {
-		printPrimitivePropertyValue(b, v, planning, op)
+		printPrimitivePropertyValue(b, v, planning, op, opts)
 	} else if v.IsArray() {
 		arr := v.ArrayValue()
 		if len(arr) == 0 {
 			writeVerbatim(b, op, "[]")
 		} else {
 			writeVerbatim(b, op, "[\n")
+			e := elider{limit: opts.maxArrayElements()}
 			for i, elem := range arr {
-				writeWithIndent(b, indent, op, prefix, "    [%d]: ", i)
-				printPropertyValue(b, elem, planning, indent+1, op, prefix, debug)
+				ipath := path.Index(i)
+				show, iopts := opts.filterPath(ipath)
+				if !show {
+					continue
+				}
+				e.print(b, func(b *bytes.Buffer) {
+					writeWithIndent(b, indent, op, prefix, "    [%d]: ", i)
+					printPropertyValue(b, elem, planning, indent+1, op, prefix, debug, ipath, iopts)
+				})
 			}
+			e.printElided(b, indent+1, op, prefix, "element")
 			writeWithIndentNoPrefix(b, indent, op, "]")
 		}
 	} else if v.IsAsset() {
 		a := v.AssetValue()
-		if a.IsText() {
+		if renderWith(b, indent, opts, func(r Renderer, ctx RenderContext) (string, bool) {
+			return r.RenderAsset(path, a, ctx)
+		}) {
+			// The custom renderer has taken care of it.
+		} else if a.IsText() {
 			write(b, op, "asset(text:%s) {\n", shortHash(a.Hash))
 
 			a = resource.MassageIfUserProgramCodeAsset(a, debug)
@@ -336,7 +619,7 @@ func printPropertyValue(
 			}
 			sort.Strings(names)
 			for _, name := range names {
-				printAssetOrArchive(b, assets[name], name, planning, indent, op, prefix, debug)
+				printAssetOrArchive(b, assets[name], name, planning, indent, op, prefix, debug, path, opts)
 			}
 			writeWithIndentNoPrefix(b, indent, op, "}")
 		} else if path, has := a.GetPath(); has {
@@ -352,7 +635,7 @@ func printPropertyValue(
 			writeVerbatim(b, op, "{}")
 		} else {
 			writeVerbatim(b, op, "{\n")
-			printObject(b, obj, planning, indent+1, op, prefix, debug)
+			printObject(b, obj, planning, indent+1, op, prefix, debug, path, opts)
 			writeWithIndentNoPrefix(b, indent, op, "}")
 		}
 	}
@@ -361,9 +644,9 @@ func printPropertyValue(
 
 func printAssetOrArchive(
 	b *bytes.Buffer, v interface{}, name string, planning bool,
-	indent int, op deploy.StepOp, prefix bool, debug bool) {
+	indent int, op deploy.StepOp, prefix bool, debug bool, path resource.PropertyPath, opts DiffOptions) {
 	writeWithIndent(b, indent, op, prefix, "    \"%v\": ", name)
-	printPropertyValue(b, assetOrArchiveToPropertyValue(v), planning, indent+1, op, prefix, debug)
+	printPropertyValue(b, assetOrArchiveToPropertyValue(v), planning, indent+1, op, prefix, debug, path, opts)
 }
 
 func assetOrArchiveToPropertyValue(v interface{}) resource.PropertyValue {
@@ -388,19 +671,19 @@ func shortHash(hash string) string {
 func printOldNewDiffs(
 	b *bytes.Buffer, olds resource.PropertyMap, news resource.PropertyMap,
 	replaces []resource.PropertyKey, planning bool, indent int, op deploy.StepOp,
-	summary bool, debug bool) {
+	summary bool, debug bool, path resource.PropertyPath, opts DiffOptions) {
 
 	// Get the full diff structure between the two, and print it (recursively).
 	if diff := olds.Diff(news); diff != nil {
-		printObjectDiff(b, *diff, replaces, false, planning, indent, summary, debug)
+		printObjectDiff(b, *diff, replaces, false, planning, indent, summary, debug, path, opts)
 	} else {
-		printObject(b, news, planning, indent, op, true, debug)
+		printObject(b, news, planning, indent, op, true, debug, path, opts)
 	}
 }
 
 func printObjectDiff(b *bytes.Buffer, diff resource.ObjectDiff,
 	replaces []resource.PropertyKey, causedReplace bool, planning bool,
-	indent int, summary bool, debug bool) {
+	indent int, summary bool, debug bool, path resource.PropertyPath, opts DiffOptions) {
 
 	contract.Assert(indent > 0)
 
@@ -418,73 +701,154 @@ func printObjectDiff(b *bytes.Buffer, diff resource.ObjectDiff,
 	}
 
 	// To print an object diff, enumerate the keys in stable order, and print each property independently.
+	e := elider{limit: opts.maxObjectKeys()}
 	for _, k := range keys {
-		titleFunc := func(top deploy.StepOp, prefix bool) {
-			printPropertyTitle(b, string(k), maxkey, indent, top, prefix)
+		// Skip any properties that have been filtered out by the include/exclude path patterns.
+		kpath := path.Key(k)
+		show, kopts := opts.filterPath(kpath)
+		if !show {
+			continue
 		}
-		if add, isadd := diff.Adds[k]; isadd {
-			if shouldPrintPropertyValue(add, planning) {
-				printAdd(b, add, titleFunc, planning, indent, debug)
-			}
-		} else if delete, isdelete := diff.Deletes[k]; isdelete {
-			if shouldPrintPropertyValue(delete, planning) {
-				printDelete(b, delete, titleFunc, planning, indent, debug)
+
+		e.print(b, func(b *bytes.Buffer) {
+			titleFunc := func(top deploy.StepOp, prefix bool) {
+				printPropertyTitle(b, string(k), maxkey, indent, top, prefix)
 			}
-		} else if update, isupdate := diff.Updates[k]; isupdate {
-			if !causedReplace && replaceMap != nil {
-				causedReplace = replaceMap[k]
+			if add, isadd := diff.Adds[k]; isadd {
+				if shouldPrintPropertyValue(add, planning) {
+					kopts.countAdd()
+					printAdd(b, add, titleFunc, planning, indent, debug, kpath, kopts)
+				}
+			} else if delete, isdelete := diff.Deletes[k]; isdelete {
+				if shouldPrintPropertyValue(delete, planning) {
+					kopts.countDelete()
+					printDelete(b, delete, titleFunc, planning, indent, debug, kpath, kopts)
+				}
+			} else if update, isupdate := diff.Updates[k]; isupdate {
+				if replaceMap[k] {
+					// Call out explicitly that this property's change is what requires the resource to be replaced.
+					var pb bytes.Buffer
+					printPropertyValueDiff(
+						&pb, func(top deploy.StepOp, prefix bool) {
+							printPropertyTitle(&pb, string(k), maxkey, indent, top, prefix)
+						}, update, true, planning, indent, summary, debug, kpath, kopts)
+					writeString(b,
+						annotateFirstLine(pb.String(), deploy.OpReplace.Color()+" (requires replacement)"+colors.Reset))
+				} else {
+					printPropertyValueDiff(
+						b, titleFunc, update, causedReplace, planning,
+						indent, summary, debug, kpath, kopts)
+				}
+			} else if same := diff.Sames[k]; !summary && shouldPrintPropertyValue(same, planning) {
+				titleFunc(deploy.OpSame, false)
+				printSamePropertyValue(b, same, planning, indent, debug, kpath, kopts)
 			}
+		})
+	}
+	e.printElided(b, indent, deploy.OpSame, false, "property")
+}
 
-			printPropertyValueDiff(
-				b, titleFunc, update, causedReplace, planning,
-				indent, summary, debug)
-		} else if same := diff.Sames[k]; !summary && shouldPrintPropertyValue(same, planning) {
-			titleFunc(deploy.OpSame, false)
-			printPropertyValue(b, diff.Sames[k], planning, indent, deploy.OpSame, false, debug)
+// printSamePropertyValue prints an unchanged property value within a diff.  If requested by the diff options, non-empty
+// objects and arrays are collapsed into a single line that just counts their contents.
+func printSamePropertyValue(
+	b *bytes.Buffer, v resource.PropertyValue, planning bool,
+	indent int, debug bool, path resource.PropertyPath, opts DiffOptions) {
+
+	// Values are never collapsed if they are explicitly expanded, or if a path filter may select something inside them.
+	if opts.CollapseUnchanged && len(opts.IncludePaths) == 0 && !path.MatchesAny(opts.ExpandPaths) {
+		if v.IsObject() && len(v.ObjectValue()) > 0 {
+			n := len(v.ObjectValue())
+			write(b, deploy.OpSame, "{ %d unchanged %s }\n", n, cmdutil.Plural("property", n))
+			return
+		} else if v.IsArray() && len(v.ArrayValue()) > 0 {
+			n := len(v.ArrayValue())
+			write(b, deploy.OpSame, "[ %d unchanged %s ]\n", n, cmdutil.Plural("element", n))
+			return
 		}
 	}
+
+	printPropertyValue(b, v, planning, indent, deploy.OpSame, false, debug, path, opts)
+}
+
+// annotateFirstLine appends an annotation to the end of the first line of some rendered text.
+func annotateFirstLine(text string, annotation string) string {
+	if i := strings.Index(text, "\n"); i >= 0 {
+		return text[:i] + annotation + text[i:]
+	}
+	return text + annotation
 }
 
 func printPropertyValueDiff(
 	b *bytes.Buffer, titleFunc func(deploy.StepOp, bool),
 	diff resource.ValueDiff, causedReplace bool, planning bool,
-	indent int, summary bool, debug bool) {
+	indent int, summary bool, debug bool, path resource.PropertyPath, opts DiffOptions) {
 
 	op := deploy.OpUpdate
 	contract.Assert(indent > 0)
 
+	// Let any custom renderer render the change first.
+	var rb bytes.Buffer
+	if renderWith(&rb, indent, opts, func(r Renderer, ctx RenderContext) (string, bool) {
+		return r.RenderPropertyDiff(path, diff.Old, diff.New, ctx)
+	}) {
+		opts.countUpdate()
+		titleFunc(op, true)
+		writeString(b, rb.String())
+		return
+	}
+
 	if diff.Array != nil {
 		titleFunc(op, true)
 		writeVerbatim(b, op, "[\n")
 
-		a := diff.Array
-		for i := 0; i < a.Len(); i++ {
-			elemTitleFunc := func(eop deploy.StepOp, eprefix bool) {
-				writeWithIndent(b, indent+1, eop, eprefix, "[%d]: ", i)
-			}
-			if add, isadd := a.Adds[i]; isadd {
-				printAdd(b, add, elemTitleFunc, planning, indent+2, debug)
-			} else if delete, isdelete := a.Deletes[i]; isdelete {
-				printDelete(b, delete, elemTitleFunc, planning, indent+2, debug)
-			} else if update, isupdate := a.Updates[i]; isupdate {
-				printPropertyValueDiff(
-					b, elemTitleFunc, update, causedReplace, planning,
-					indent+2, summary, debug)
-			} else if !summary {
-				elemTitleFunc(deploy.OpSame, false)
-				printPropertyValue(b, a.Sames[i], planning, indent+2, deploy.OpSame, false, debug)
+		e := elider{limit: opts.maxArrayElements()}
+		for _, elem := range getArrayElementDiffs(diff, opts) {
+			i := elem.Index
+			ipath := path.Index(i)
+			show, iopts := opts.filterPath(ipath)
+			if !show {
+				continue
 			}
+
+			e.print(b, func(b *bytes.Buffer) {
+				elemTitleFunc := func(eop deploy.StepOp, eprefix bool) {
+					writeWithIndent(b, indent+1, eop, eprefix, "[%d]: ", i)
+				}
+				switch elem.Op {
+				case deploy.OpCreate:
+					iopts.countAdd()
+					printAdd(b, elem.Value, elemTitleFunc, planning, indent+2, debug, ipath, iopts)
+				case deploy.OpDelete:
+					iopts.countDelete()
+					printDelete(b, elem.Value, elemTitleFunc, planning, indent+2, debug, ipath, iopts)
+				case deploy.OpUpdate:
+					printPropertyValueDiff(
+						b, elemTitleFunc, elem.Diff, causedReplace, planning,
+						indent+2, summary, debug, ipath, iopts)
+				default:
+					if !summary {
+						elemTitleFunc(deploy.OpSame, false)
+						printSamePropertyValue(b, elem.Value, planning, indent+2, debug, ipath, iopts)
+					}
+				}
+			})
 		}
+		e.printElided(b, indent+1, deploy.OpSame, false, "element")
 		writeWithIndentNoPrefix(b, indent, op, "]\n")
 	} else if diff.Object != nil {
 		titleFunc(op, true)
 		writeVerbatim(b, op, "{\n")
-		printObjectDiff(b, *diff.Object, nil, causedReplace, planning, indent+1, summary, debug)
+		printObjectDiff(b, *diff.Object, nil, causedReplace, planning, indent+1, summary, debug, path, opts)
 		writeWithIndentNoPrefix(b, indent, op, "}\n")
 	} else {
 		shouldPrintOld := shouldPrintPropertyValue(diff.Old, false)
 		shouldPrintNew := shouldPrintPropertyValue(diff.New, false)
 
+		// Whatever shape it is printed in, a changed leaf value counts as a single updated property.
+		if shouldPrintOld || shouldPrintNew {
+			opts.countUpdate()
+		}
+
 		if shouldPrintOld && shouldPrintNew {
 			if diff.Old.IsArchive() &&
 				diff.New.IsArchive() &&
@@ -492,15 +856,56 @@ func printPropertyValueDiff(
 
 				printArchiveDiff(
 					b, titleFunc, diff.Old.ArchiveValue(), diff.New.ArchiveValue(),
-					planning, indent, summary, debug)
+					planning, indent, summary, debug, path, opts)
 				return
 			}
 
+			// Masked secrets must never be diffed textually, since that would reveal their tags.
+			if diff.Old.IsString() && diff.New.IsString() && !isMaskedSecret(diff.Old) && !isMaskedSecret(diff.New) {
+				// Strings holding JSON documents (e.g. IAM policies) get a structural diff, so that only the keys
+				// that actually changed are highlighted rather than the whole document.
+				if jsonDiff := diffJSONStrings(diff.Old.StringValue(), diff.New.StringValue()); jsonDiff != nil {
+					jsonTitleFunc := func(top deploy.StepOp, prefix bool) {
+						titleFunc(top, prefix)
+						writeVerbatim(b, top, "json ")
+					}
+					// The property as a whole has already been counted as a single update.
+					jsonOpts := opts
+					jsonOpts.Stats = nil
+					printPropertyValueDiff(
+						b, jsonTitleFunc, *jsonDiff, causedReplace, planning, indent, summary, debug, path, jsonOpts)
+					return
+				}
+
+				// Strings holding base64-encoded text (e.g. Kubernetes secrets or user data) are decoded, so that the
+				// change can be shown as a line-based diff of the underlying text.
+				if oldText, newText, ok := decodeBase64TextPair(diff.Old.StringValue(), diff.New.StringValue()); ok {
+					titleFunc(deploy.OpUpdate, true /*indent*/)
+					write(b, op, "base64-decoded {\n")
+					writeString(b, getTextDiffString(oldText, newText, indent+1, opts))
+					writeWithIndentNoPrefix(b, indent, op, "}\n")
+					return
+				}
+
+				var sb bytes.Buffer
+				if printStringDiff(&sb, diff.Old.StringValue(), diff.New.StringValue()) {
+					titleFunc(deploy.OpUpdate, true /*indent*/)
+					writeString(b, sb.String())
+					writeVerbatim(b, deploy.OpUpdate, "\n")
+					return
+				}
+			}
+
 			if isPrimitive(diff.Old) && isPrimitive(diff.New) {
 				titleFunc(deploy.OpUpdate, true /*indent*/)
-				printPrimitivePropertyValue(b, diff.Old, planning, deploy.OpDelete)
+				printPrimitivePropertyValue(b, diff.Old, planning, deploy.OpDelete, opts)
 				writeVerbatim(b, deploy.OpUpdate, " => ")
-				printPrimitivePropertyValue(b, diff.New, planning, deploy.OpCreate)
+				printPrimitivePropertyValue(b, diff.New, planning, deploy.OpCreate, opts)
+				if diff.Old.IsNumber() && diff.New.IsNumber() {
+					// Show how much a number changed by, along with durations and sizes in friendlier units.
+					write(b, deploy.OpUpdate, " %s",
+						formatNumberChange(diff.Old.NumberValue(), diff.New.NumberValue(), path))
+				}
 				writeVerbatim(b, deploy.OpUpdate, "\n")
 				return
 			}
@@ -509,10 +914,10 @@ func printPropertyValueDiff(
 		// If we ended up here, the two values either differ by type, or they have different primitive values.  We will
 		// simply emit a deletion line followed by an addition line.
 		if shouldPrintOld {
-			printDelete(b, diff.Old, titleFunc, planning, indent, debug)
+			printDelete(b, diff.Old, titleFunc, planning, indent, debug, path, opts)
 		}
 		if shouldPrintNew {
-			printAdd(b, diff.New, titleFunc, planning, indent, debug)
+			printAdd(b, diff.New, titleFunc, planning, indent, debug, path, opts)
 		}
 	}
 }
@@ -522,7 +927,8 @@ func isPrimitive(value resource.PropertyValue) bool {
 		value.IsBool() || value.IsComputed() || value.IsOutput()
 }
 
-func printPrimitivePropertyValue(b *bytes.Buffer, v resource.PropertyValue, planning bool, op deploy.StepOp) {
+func printPrimitivePropertyValue(
+	b *bytes.Buffer, v resource.PropertyValue, planning bool, op deploy.StepOp, opts DiffOptions) {
 	contract.Assert(isPrimitive(v))
 
 	if v.IsNull() {
@@ -532,7 +938,17 @@ func printPrimitivePropertyValue(b *bytes.Buffer, v resource.PropertyValue, plan
 	} else if v.IsNumber() {
 		write(b, op, "%v", v.NumberValue())
 	} else if v.IsString() {
-		write(b, op, "%q", v.StringValue())
+		if s := v.StringValue(); isMaskedSecret(v) {
+			// Print masked secrets without quotes or tags, to make it clear this isn't the value itself.
+			writeVerbatim(b, op, SecretSentinel)
+		} else if limit := opts.maxStringLength(); limit >= 0 && utf8.RuneCountInString(s) > limit {
+			// Show only the start of very long strings, noting how much has been left out.
+			runes := []rune(s)
+			write(b, op, "%q", string(runes[:limit]))
+			write(b, op, " ... (%d more %s)", len(runes)-limit, cmdutil.Plural("character", len(runes)-limit))
+		} else {
+			write(b, op, "%q", s)
+		}
 	} else if v.IsComputed() || v.IsOutput() {
 		// We render computed and output values differently depending on whether or not we are
 		// planning or deploying: in the former case, we display `computed<type>` or `output<type>`;
@@ -551,26 +967,214 @@ func printPrimitivePropertyValue(b *bytes.Buffer, v resource.PropertyValue, plan
 	}
 }
 
+// diffJSONStrings returns a structural diff between two strings if both hold JSON-encoded objects or arrays.  If
+// either string isn't such a document, or the two documents are semantically identical, nil is returned.
+func diffJSONStrings(old string, new string) *resource.ValueDiff {
+	oldValue, ok := parseJSONDocument(old)
+	if !ok {
+		return nil
+	}
+	newValue, ok := parseJSONDocument(new)
+	if !ok {
+		return nil
+	}
+	return oldValue.Diff(newValue)
+}
+
+// parseJSONDocument decodes a string holding a JSON object or array into a property value.
+func parseJSONDocument(s string) (resource.PropertyValue, bool) {
+	trimmed := strings.TrimSpace(s)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return resource.PropertyValue{}, false
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(trimmed), &v); err != nil {
+		return resource.PropertyValue{}, false
+	}
+	return resource.NewPropertyValue(v), true
+}
+
+// minBase64Length is the shortest string that will be considered for base64 decoding; shorter strings are too likely to
+// be ordinary words that just happen to be valid base64.
+const minBase64Length = 16
+
+// decodeBase64TextPair decodes two strings if both are base64-encoded, printable text.
+func decodeBase64TextPair(old string, new string) (string, string, bool) {
+	oldText, ok := decodeBase64Text(old)
+	if !ok {
+		return "", "", false
+	}
+	newText, ok := decodeBase64Text(new)
+	if !ok {
+		return "", "", false
+	}
+	return oldText, newText, true
+}
+
+// decodeBase64Text decodes a string if it is base64-encoded, printable text.
+func decodeBase64Text(s string) (string, bool) {
+	if len(s) < minBase64Length {
+		return "", false
+	}
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || !utf8.Valid(data) {
+		return "", false
+	}
+	text := string(data)
+	for _, r := range text {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return "", false
+		}
+	}
+	return text, true
+}
+
+// getTextDiffString renders a line-based diff between two pieces of text.
+func getTextDiffString(old string, new string, indent int, opts DiffOptions) string {
+	differ := diffmatchpatch.New()
+	differ.DiffTimeout = 0
+
+	hashed1, hashed2, lineArray := differ.DiffLinesToChars(old, new)
+	diffs1 := differ.DiffMain(hashed1, hashed2, false)
+	diffs2 := differ.DiffCharsToLines(diffs1, lineArray)
+
+	return diffToPrettyString(diffs2, indent, opts)
+}
+
+// printStringDiff prints an intra-line diff between two string values, so that a small edit inside of a long string
+// highlights just the words that changed rather than printing both values in their entirety.  Deleted text is written
+// as [-text-] and inserted text as {+text+}, so that the diff remains legible even without colorization.  If the
+// strings are too dissimilar for an inline diff to be useful, nothing is written and false is returned.
+func printStringDiff(b *bytes.Buffer, old string, new string) bool {
+	diffs := diffWords(old, new)
+
+	// Only bother with an inline diff if a meaningful portion of the text is shared between the two values;
+	// otherwise, the interleaved insertions and deletions are harder to read than the old and new values.
+	same := 0
+	for _, d := range diffs {
+		if d.Type == diffmatchpatch.DiffEqual {
+			same += len(d.Text)
+		}
+	}
+	longest := len(old)
+	if len(new) > longest {
+		longest = len(new)
+	}
+	if same*2 < longest {
+		return false
+	}
+
+	writeVerbatim(b, deploy.OpUpdate, "\"")
+	for _, d := range diffs {
+		// Quote each fragment the same way printPrimitivePropertyValue quotes whole strings, minus the delimiters.
+		text := strconv.Quote(d.Text)
+		text = text[1 : len(text)-1]
+
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			write(b, deploy.OpDelete, "[-%s-]", text)
+		case diffmatchpatch.DiffInsert:
+			write(b, deploy.OpCreate, "{+%s+}", text)
+		case diffmatchpatch.DiffEqual:
+			writeVerbatim(b, deploy.OpUpdate, text)
+		}
+	}
+	writeVerbatim(b, deploy.OpUpdate, "\"")
+	return true
+}
+
+// diffWords diffs two strings a word at a time, so that an edit never splits a word in two.  This uses the same trick
+// as diffmatchpatch's line mode: each distinct token is replaced by a single rune, the rune strings are diffed, and the
+// resulting diffs are mapped back onto the original tokens.
+func diffWords(old string, new string) []diffmatchpatch.Diff {
+	var tokens []string
+	runes := make(map[string]rune)
+	encode := func(s string) string {
+		var encoded []rune
+		for _, token := range splitWords(s) {
+			r, has := runes[token]
+			if !has {
+				r = rune(len(tokens))
+				if r >= 0xD800 {
+					r += 0x800 // skip the surrogate range, which can't survive a round trip through a string.
+				}
+				runes[token] = r
+				tokens = append(tokens, token)
+			}
+			encoded = append(encoded, r)
+		}
+		return string(encoded)
+	}
+	decode := func(r rune) string {
+		if r >= 0xE000 {
+			r -= 0x800
+		}
+		return tokens[r]
+	}
+
+	differ := diffmatchpatch.New()
+	differ.DiffTimeout = 0
+	diffs := differ.DiffCleanupSemantic(differ.DiffMain(encode(old), encode(new), false))
+	for i := range diffs {
+		var text bytes.Buffer
+		for _, r := range diffs[i].Text {
+			text.WriteString(decode(r))
+		}
+		diffs[i].Text = text.String()
+	}
+	return diffs
+}
+
+// splitWords splits a string into tokens for diffWords: runs of letters and digits, runs of whitespace, and individual
+// punctuation characters.  Concatenating the tokens yields the original string.
+func splitWords(s string) []string {
+	class := func(r rune) int {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			return 1
+		case unicode.IsSpace(r):
+			return 2
+		default:
+			return 0
+		}
+	}
+
+	var tokens []string
+	start, last := 0, -1
+	for i, r := range s {
+		c := class(r)
+		if i > start && (c != last || c == 0) {
+			tokens = append(tokens, s[start:i])
+			start = i
+		}
+		last = c
+	}
+	if start < len(s) {
+		tokens = append(tokens, s[start:])
+	}
+	return tokens
+}
+
 func printDelete(
 	b *bytes.Buffer, v resource.PropertyValue, title func(deploy.StepOp, bool),
-	planning bool, indent int, debug bool) {
+	planning bool, indent int, debug bool, path resource.PropertyPath, opts DiffOptions) {
 	op := deploy.OpDelete
 	title(op, true)
-	printPropertyValue(b, v, planning, indent, op, true, debug)
+	printPropertyValue(b, v, planning, indent, op, true, debug, path, opts)
 }
 
 func printAdd(
 	b *bytes.Buffer, v resource.PropertyValue, title func(deploy.StepOp, bool),
-	planning bool, indent int, debug bool) {
+	planning bool, indent int, debug bool, path resource.PropertyPath, opts DiffOptions) {
 	op := deploy.OpCreate
 	title(op, true)
-	printPropertyValue(b, v, planning, indent, op, true, debug)
+	printPropertyValue(b, v, planning, indent, op, true, debug, path, opts)
 }
 
 func printArchiveDiff(
 	b *bytes.Buffer, titleFunc func(deploy.StepOp, bool),
 	oldArchive *resource.Archive, newArchive *resource.Archive,
-	planning bool, indent int, summary bool, debug bool) {
+	planning bool, indent int, summary bool, debug bool, path resource.PropertyPath, opts DiffOptions) {
 
 	// TODO: this could be called recursively from itself.  In the recursive case, we might have an
 	// archive that actually hasn't changed.  Check for that, and terminate the diff printing.
@@ -582,13 +1186,15 @@ func printArchiveDiff(
 	if oldPath, has := oldArchive.GetPath(); has {
 		if newPath, has := newArchive.GetPath(); has {
 			titleFunc(op, true)
-			write(b, op, "archive(file:%s) { %s }\n", hashChange, getTextChangeString(oldPath, newPath))
+			write(b, op, "archive(file:%s) { %s }", hashChange, getTextChangeString(oldPath, newPath))
+			printArchiveContentsDiff(b, oldArchive, newArchive, planning, indent, summary, debug, path, opts)
 			return
 		}
 	} else if oldURI, has := oldArchive.GetURI(); has {
 		if newURI, has := newArchive.GetURI(); has {
 			titleFunc(op, true)
-			write(b, op, "archive(uri:%s) { %s }\n", hashChange, getTextChangeString(oldURI, newURI))
+			write(b, op, "archive(uri:%s) { %s }", hashChange, getTextChangeString(oldURI, newURI))
+			printArchiveContentsDiff(b, oldArchive, newArchive, planning, indent, summary, debug, path, opts)
 			return
 		}
 	} else {
@@ -598,7 +1204,7 @@ func printArchiveDiff(
 		if newAssets, has := newArchive.GetAssets(); has {
 			titleFunc(op, true)
 			write(b, op, "archive(assets:%s) {\n", hashChange)
-			printAssetsDiff(b, oldAssets, newAssets, planning, indent+1, summary, debug)
+			printAssetsDiff(b, oldAssets, newAssets, planning, indent+1, summary, debug, path, opts)
 			writeWithIndentNoPrefix(b, indent, deploy.OpUpdate, "}\n")
 			return
 		}
@@ -607,16 +1213,85 @@ func printArchiveDiff(
 	// Type of archive changed, print this out as an remove and an add.
 	printDelete(
 		b, assetOrArchiveToPropertyValue(oldArchive),
-		titleFunc, planning, indent, debug)
+		titleFunc, planning, indent, debug, path, opts)
 	printAdd(
 		b, assetOrArchiveToPropertyValue(newArchive),
-		titleFunc, planning, indent, debug)
+		titleFunc, planning, indent, debug, path, opts)
+}
+
+// printArchiveContentsDiff finishes the line describing a changed path- or URI-based archive.  If requested by the
+// diff options, and the contents of both archives are available, it follows that line with a per-file diff of the
+// archives' members.
+func printArchiveContentsDiff(
+	b *bytes.Buffer, oldArchive *resource.Archive, newArchive *resource.Archive,
+	planning bool, indent int, summary bool, debug bool, path resource.PropertyPath, opts DiffOptions) {
+
+	if !opts.ArchiveContents || oldArchive.Hash == newArchive.Hash ||
+		!oldArchive.HasContents() || !newArchive.HasContents() {
+		writeVerbatim(b, deploy.OpUpdate, "\n")
+		return
+	}
+
+	oldAssets, err := readArchiveMembers(oldArchive)
+	if err != nil {
+		logging.V(7).Infof("unable to read old archive contents for diffing: %v", err)
+		writeVerbatim(b, deploy.OpUpdate, "\n")
+		return
+	}
+	newAssets, err := readArchiveMembers(newArchive)
+	if err != nil {
+		logging.V(7).Infof("unable to read new archive contents for diffing: %v", err)
+		writeVerbatim(b, deploy.OpUpdate, "\n")
+		return
+	}
+
+	writeVerbatim(b, deploy.OpUpdate, " {\n")
+	printAssetsDiff(b, oldAssets, newAssets, planning, indent+1, true /*summary*/, debug, path, opts)
+	writeWithIndentNoPrefix(b, indent, deploy.OpUpdate, "}\n")
+}
+
+// readArchiveMembers reads all of the files in an archive into a map of assets keyed by file name, suitable for
+// diffing with printAssetsDiff.  Textual files are read as text assets so that their contents can be diffed; any
+// other files are represented only by their name and hash.
+func readArchiveMembers(archive *resource.Archive) (map[string]interface{}, error) {
+	reader, err := archive.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer contract.IgnoreClose(reader)
+
+	members := make(map[string]interface{})
+	for {
+		name, blob, err := reader.Next()
+		if err == io.EOF {
+			return members, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		data, err := ioutil.ReadAll(blob)
+		contract.IgnoreClose(blob)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading archive member '%s'", name)
+		}
+
+		if len(data) > 0 && utf8.Valid(data) {
+			asset, err := resource.NewTextAsset(string(data))
+			if err != nil {
+				return nil, err
+			}
+			members[name] = asset
+		} else {
+			hash := sha256.Sum256(data)
+			members[name] = &resource.Asset{Sig: resource.AssetSig, Hash: hex.EncodeToString(hash[:]), Path: name}
+		}
+	}
 }
 
 func printAssetsDiff(
 	b *bytes.Buffer,
 	oldAssets map[string]interface{}, newAssets map[string]interface{},
-	planning bool, indent int, summary bool, debug bool) {
+	planning bool, indent int, summary bool, debug bool, path resource.PropertyPath, opts DiffOptions) {
 
 	// Diffing assets proceeds by getting the sorted list of asset names from both the old and
 	// new assets, and then stepwise processing each.  For any asset in old that isn't in new,
@@ -670,11 +1345,11 @@ func printAssetsDiff(
 				case *resource.Archive:
 					printArchiveDiff(
 						b, titleFunc, t, newAsset.(*resource.Archive),
-						planning, indent, summary, debug)
+						planning, indent, summary, debug, path, opts)
 				case *resource.Asset:
 					printAssetDiff(
 						b, titleFunc, t, newAsset.(*resource.Asset),
-						planning, indent, summary, debug)
+						planning, indent, summary, debug, path, opts)
 				}
 
 				i++
@@ -701,7 +1376,7 @@ func printAssetsDiff(
 			}
 			printDelete(
 				b, assetOrArchiveToPropertyValue(oldAssets[oldName]),
-				titleFunc, planning, newIndent, debug)
+				titleFunc, planning, newIndent, debug, path, opts)
 			i++
 			continue
 		} else {
@@ -712,7 +1387,7 @@ func printAssetsDiff(
 			}
 			printAdd(
 				b, assetOrArchiveToPropertyValue(newAssets[newName]),
-				titleFunc, planning, newIndent, debug)
+				titleFunc, planning, newIndent, debug, path, opts)
 			j++
 		}
 	}
@@ -739,7 +1414,7 @@ func makeAssetHeader(asset *resource.Asset) string {
 func printAssetDiff(
 	b *bytes.Buffer, titleFunc func(deploy.StepOp, bool),
 	oldAsset *resource.Asset, newAsset *resource.Asset,
-	planning bool, indent int, summary bool, debug bool) {
+	planning bool, indent int, summary bool, debug bool, path resource.PropertyPath, opts DiffOptions) {
 
 	op := deploy.OpUpdate
 
@@ -765,14 +1440,7 @@ func printAssetDiff(
 			massagedOldText := resource.MassageIfUserProgramCodeAsset(oldAsset, debug).Text
 			massagedNewText := resource.MassageIfUserProgramCodeAsset(newAsset, debug).Text
 
-			differ := diffmatchpatch.New()
-			differ.DiffTimeout = 0
-
-			hashed1, hashed2, lineArray := differ.DiffLinesToChars(massagedOldText, massagedNewText)
-			diffs1 := differ.DiffMain(hashed1, hashed2, false)
-			diffs2 := differ.DiffCharsToLines(diffs1, lineArray)
-
-			writeString(b, diffToPrettyString(diffs2, indent+1))
+			writeString(b, getTextDiffString(massagedOldText, massagedNewText, indent+1, opts))
 
 			writeWithIndentNoPrefix(b, indent, op, "}\n")
 			return
@@ -797,10 +1465,10 @@ func printAssetDiff(
 	// Type of asset changed, print this out as an remove and an add.
 	printDelete(
 		b, assetOrArchiveToPropertyValue(oldAsset),
-		titleFunc, planning, indent, debug)
+		titleFunc, planning, indent, debug, path, opts)
 	printAdd(
 		b, assetOrArchiveToPropertyValue(newAsset),
-		titleFunc, planning, indent, debug)
+		titleFunc, planning, indent, debug, path, opts)
 }
 
 func getTextChangeString(old string, new string) string {
@@ -814,28 +1482,32 @@ func getTextChangeString(old string, new string) string {
 // diffToPrettyString takes the full diff produed by diffmatchpatch and condenses it into something
 // useful we can print to the console.  Specifically, while it includes any adds/removes in
 // green/red, it will also show portions of the unchanged text to help give surrounding context to
-// those add/removes. Because the unchanged portions may be very large, it only included around 3
-// lines before/after the change.
-func diffToPrettyString(diffs []diffmatchpatch.Diff, indent int) string {
+// those add/removes. Because the unchanged portions may be very large, it only includes a few
+// lines before/after the change (as controlled by opts), unless a full diff was requested.
+func diffToPrettyString(diffs []diffmatchpatch.Diff, indent int, opts DiffOptions) string {
 	var buff bytes.Buffer
 
-	writeDiff := func(op deploy.StepOp, text string) {
+	// Split each chunk of the diff into its lines, numbering them by their positions in the old and new text.
+	chunks, width := numberDiffLines(diffs)
+	if opts.NoLineNumbers {
+		width = 0
+	}
+
+	writeDiff := func(op deploy.StepOp, line numberedLine) {
 		var prefix bool
 		if op == deploy.OpCreate || op == deploy.OpDelete {
 			prefix = true
 		}
-		writeWithIndent(&buff, indent, op, prefix, "%s", text)
+		writeWithIndent(&buff, indent, op, prefix, "%s%s", line.gutter(op, width), line.text)
 	}
+	elision := numberedLine{text: "..."}
 
 	for index, diff := range diffs {
-		text := diff.Text
-		lines := strings.Split(text, "\n")
+		lines := chunks[index]
 		printLines := func(op deploy.StepOp, startInclusive int, endExclusive int) {
 			for i := startInclusive; i < endExclusive; i++ {
-				if strings.TrimSpace(lines[i]) != "" {
-					writeDiff(op, lines[i])
-					buff.WriteString("\n")
-				}
+				writeDiff(op, lines[i])
+				buff.WriteString("\n")
 			}
 		}
 
@@ -845,34 +1517,39 @@ func diffToPrettyString(diffs []diffmatchpatch.Diff, indent int) string {
 		case diffmatchpatch.DiffDelete:
 			printLines(deploy.OpDelete, 0, len(lines))
 		case diffmatchpatch.DiffEqual:
-			var trimmedLines []string
-			for _, line := range lines {
-				if strings.TrimSpace(line) != "" {
-					trimmedLines = append(trimmedLines, line)
-				}
+			if opts.FullDiff {
+				printLines(deploy.OpSame, 0, len(lines))
+				continue
 			}
-			lines = trimmedLines
+			contextLines := opts.contextLines()
 
-			const contextLines = 2
+			// Eliding a single line would save nothing, unless no context at all was asked for.
+			slack := 1
+			if contextLines == 0 {
+				slack = 0
+			}
 
 			// Show the unchanged text in white.
 			if index == 0 {
 				// First chunk of the file.
-				if len(lines) > contextLines+1 {
-					writeDiff(deploy.OpSame, "...\n")
+				if len(lines) > contextLines+slack {
+					writeDiff(deploy.OpSame, elision)
+					buff.WriteString("\n")
 					printLines(deploy.OpSame, len(lines)-contextLines, len(lines))
 					continue
 				}
 			} else if index == len(diffs)-1 {
-				if len(lines) > contextLines+1 {
+				if len(lines) > contextLines+slack {
 					printLines(deploy.OpSame, 0, contextLines)
-					writeDiff(deploy.OpSame, "...\n")
+					writeDiff(deploy.OpSame, elision)
+					buff.WriteString("\n")
 					continue
 				}
 			} else {
-				if len(lines) > (2*contextLines + 1) {
+				if len(lines) > (2*contextLines + slack) {
 					printLines(deploy.OpSame, 0, contextLines)
-					writeDiff(deploy.OpSame, "...\n")
+					writeDiff(deploy.OpSame, elision)
+					buff.WriteString("\n")
 					printLines(deploy.OpSame, len(lines)-contextLines, len(lines))
 					continue
 				}
@@ -884,3 +1561,81 @@ func diffToPrettyString(diffs []diffmatchpatch.Diff, indent int) string {
 
 	return buff.String()
 }
+
+// numberedLine is a single non-blank line of a text diff, along with its line numbers in the old and new text (zero
+// if the line doesn't appear in that text).
+type numberedLine struct {
+	text string
+	old  int
+	new  int
+}
+
+// gutter renders the line numbers to show beside a line of a text diff, padded to the given width.  Deleted lines
+// show only their old line number, and added lines only their new one.  A zero width disables line numbers entirely.
+func (l numberedLine) gutter(op deploy.StepOp, width int) string {
+	if width == 0 {
+		return ""
+	}
+
+	num := func(n int) string {
+		if n == 0 {
+			return strings.Repeat(" ", width)
+		}
+		return fmt.Sprintf("%*d", width, n)
+	}
+
+	switch op {
+	case deploy.OpCreate:
+		return num(0) + " " + num(l.new) + " | "
+	case deploy.OpDelete:
+		return num(l.old) + " " + num(0) + " | "
+	default:
+		return num(l.old) + " " + num(l.new) + " | "
+	}
+}
+
+// numberDiffLines splits each chunk of a line-based diff into its non-blank lines, numbering each by its position in
+// the old and new text.  It also returns the width needed to display the largest line number.
+func numberDiffLines(diffs []diffmatchpatch.Diff) ([][]numberedLine, int) {
+	chunks := make([][]numberedLine, len(diffs))
+	oldLine, newLine := 0, 0
+	for i, diff := range diffs {
+		lines := strings.Split(diff.Text, "\n")
+		if len(lines) > 1 && lines[len(lines)-1] == "" {
+			// The chunk ends with a newline, which doesn't begin another line.
+			lines = lines[:len(lines)-1]
+		}
+
+		for _, line := range lines {
+			switch diff.Type {
+			case diffmatchpatch.DiffInsert:
+				newLine++
+			case diffmatchpatch.DiffDelete:
+				oldLine++
+			case diffmatchpatch.DiffEqual:
+				oldLine++
+				newLine++
+			}
+
+			// Blank lines still count towards the line numbers, but aren't displayed.
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+
+			nl := numberedLine{text: line}
+			if diff.Type != diffmatchpatch.DiffInsert {
+				nl.old = oldLine
+			}
+			if diff.Type != diffmatchpatch.DiffDelete {
+				nl.new = newLine
+			}
+			chunks[i] = append(chunks[i], nl)
+		}
+	}
+
+	largest := oldLine
+	if newLine > largest {
+		largest = newLine
+	}
+	return chunks, len(strconv.Itoa(largest))
+}
@@ -0,0 +1,167 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/diag/colors"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+)
+
+func TestSplitWords(t *testing.T) {
+	assert.Nil(t, splitWords(""))
+	assert.Equal(t, []string{"hello"}, splitWords("hello"))
+	assert.Equal(t, []string{"hello", ",", " ", "world", "!"}, splitWords("hello, world!"))
+
+	s := "arn:aws:iam::123456789012:role/my_role  name\tthere"
+	assert.Equal(t,
+		[]string{"arn", ":", "aws", ":", "iam", ":", ":", "123456789012", ":", "role", "/", "my_role",
+			"  ", "name", "\t", "there"},
+		splitWords(s))
+	assert.Equal(t, s, strings.Join(splitWords(s), ""))
+}
+
+func TestPrintStringDiff(t *testing.T) {
+	tests := []struct {
+		old      string
+		new      string
+		expected string
+	}{
+		// A changed word is replaced in its entirety, even though most of its characters are unchanged.
+		{"the quick brown fox", "the quick browner fox", `"the quick [-brown-]{+browner+} fox"`},
+		{"t2.micro instance", "t2.medium instance", `"t2.[-micro-]{+medium+} instance"`},
+		// Pure insertions and deletions.
+		{"hello world", "hello big world", `"hello {+big +}world"`},
+		{"hello big world", "hello world", `"hello [-big -]world"`},
+		// Fragments are quoted like whole strings.
+		{"line one\nline two", "line one\nline 2", `"line one\nline [-two-]{+2+}"`},
+		// Multi-byte characters are never split.
+		{"café au lait", "café au laits", `"café au [-lait-]{+laits+}"`},
+	}
+	for _, test := range tests {
+		var b bytes.Buffer
+		assert.True(t, printStringDiff(&b, test.old, test.new), "%q -> %q", test.old, test.new)
+		assert.Equal(t, test.expected, colors.Never.Colorize(b.String()), "%q -> %q", test.old, test.new)
+	}
+}
+
+func TestPrintStringDiffDissimilar(t *testing.T) {
+	var b bytes.Buffer
+	assert.False(t, printStringDiff(&b, "alpha beta gamma", "delta epsilon zeta"))
+	assert.Equal(t, "", b.String())
+}
+
+func TestTextDiffContextLines(t *testing.T) {
+	old := "alpha\nbravo\ncharlie\ndelta\necho\nfoxtrot\ngolf\n"
+	new := "alpha\nbravo\ncharlie\nDELTA\necho\nfoxtrot\ngolf\n"
+	diff := func(contextLines *int) string {
+		opts := DiffOptions{ContextLines: contextLines, NoLineNumbers: true}
+		return colors.Never.Colorize(getTextDiffString(old, new, 1, opts))
+	}
+	lines := func(n int) *int { return &n }
+
+	// By default, two lines of context are shown on either side of a change.
+	text := diff(nil)
+	for _, word := range []string{"alpha", "bravo", "charlie", "delta", "DELTA", "echo", "foxtrot", "golf"} {
+		assert.Contains(t, text, word)
+	}
+
+	text = diff(lines(1))
+	for _, word := range []string{"charlie", "delta", "DELTA", "echo", "..."} {
+		assert.Contains(t, text, word)
+	}
+	for _, word := range []string{"alpha", "bravo", "foxtrot", "golf"} {
+		assert.NotContains(t, text, word)
+	}
+
+	// No context at all means just the changed lines.
+	text = diff(lines(0))
+	for _, word := range []string{"delta", "DELTA", "..."} {
+		assert.Contains(t, text, word)
+	}
+	for _, word := range []string{"alpha", "bravo", "charlie", "echo", "foxtrot", "golf"} {
+		assert.NotContains(t, text, word)
+	}
+}
+
+func TestTruncation(t *testing.T) {
+	props := resource.NewPropertyMapFromMap(map[string]interface{}{
+		"a": 1, "b": 2, "c": 3, "d": 4, "e": 5,
+	})
+	render := func(opts DiffOptions) string {
+		var b bytes.Buffer
+		printObject(&b, props, false, 1, deploy.OpSame, false, false, nil, opts)
+		return colors.Never.Colorize(b.String())
+	}
+
+	// Values within the default limits aren't truncated.
+	text := render(DiffOptions{})
+	for _, k := range []string{"a", "b", "c", "d", "e"} {
+		assert.Contains(t, text, k+": ")
+	}
+	assert.NotContains(t, text, "more")
+	assert.Equal(t, DefaultMaxObjectKeys, DiffOptions{}.maxObjectKeys())
+	assert.Equal(t, DefaultMaxStringLength, DiffOptions{}.maxStringLength())
+	assert.Equal(t, -1, DiffOptions{MaxStringLength: -1}.maxStringLength())
+	assert.Equal(t, -1, DiffOptions{MaxStringLength: 10, NoTruncate: true}.maxStringLength())
+
+	// Properties hidden by path filters neither use up the limit nor count as more.
+	text = render(DiffOptions{MaxObjectKeys: 2, ExcludePaths: []string{"b"}})
+	assert.Contains(t, text, "a: ")
+	assert.Contains(t, text, "c: ")
+	assert.NotContains(t, text, "b: ")
+	assert.NotContains(t, text, "d: ")
+	assert.Contains(t, text, "... (2 more properties)")
+
+	text = render(DiffOptions{MaxObjectKeys: 2, NoTruncate: true})
+	assert.Contains(t, text, "e: ")
+	assert.NotContains(t, text, "more")
+
+	var b bytes.Buffer
+	arr := resource.NewPropertyValue([]interface{}{1, 2, 3, 4, 5})
+	printPropertyValue(&b, arr, false, 1, deploy.OpSame, false, false, nil, DiffOptions{MaxArrayElements: 4})
+	text = colors.Never.Colorize(b.String())
+	assert.Contains(t, text, "[3]: ")
+	assert.NotContains(t, text, "[4]: ")
+	assert.Contains(t, text, "... (1 more element)")
+}
+
+func TestTruncateObjectDiff(t *testing.T) {
+	olds := resource.NewPropertyMapFromMap(map[string]interface{}{
+		"a": 1, "b": 2, "c": 3, "d": 4, "e": 5,
+	})
+	news := resource.NewPropertyMapFromMap(map[string]interface{}{
+		"a": 10, "b": 20, "c": 30, "d": 40, "e": 50,
+	})
+
+	var stats DiffStats
+	var b bytes.Buffer
+	opts := DiffOptions{MaxObjectKeys: 2, Stats: &stats}
+	printObjectDiff(&b, *olds.Diff(news), nil, false, false, 1, false, false, nil, opts)
+	text := colors.Never.Colorize(b.String())
+	assert.Contains(t, text, "a: ")
+	assert.Contains(t, text, "b: ")
+	assert.NotContains(t, text, "c: ")
+	assert.Contains(t, text, "... (3 more properties)")
+
+	// The properties left out still count towards the statistics.
+	assert.Equal(t, DiffStats{Updates: 5}, stats)
+}
@@ -0,0 +1,91 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"sync"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/resource/stack"
+	"github.com/pulumi/pulumi/pkg/tokens"
+)
+
+// DriftKind is the way in which a resource's live state has drifted from the state recorded in its stack's checkpoint.
+type DriftKind string
+
+const (
+	DriftModified DriftKind = "modified" // the resource's live properties differ from those in the checkpoint.
+	DriftDeleted  DriftKind = "deleted"  // the resource no longer exists.
+)
+
+// PropertyDrift describes a single property whose live value differs from the value in the checkpoint.  Values are
+// serialized just as they are in a checkpoint, with any secrets masked.
+type PropertyDrift struct {
+	Path string           `json:"path"`
+	Kind PropertyDiffKind `json:"kind"`
+	Old  interface{}      `json:"old,omitempty"` // the value recorded in the checkpoint.
+	New  interface{}      `json:"new,omitempty"` // the live value.
+}
+
+// ResourceDrift describes a resource whose live state has drifted from its checkpointed state.
+type ResourceDrift struct {
+	URN        resource.URN    `json:"urn"`
+	Type       tokens.Type     `json:"type"`
+	Kind       DriftKind       `json:"kind"`
+	Properties []PropertyDrift `json:"properties,omitempty"`
+}
+
+// DriftReport is the result of detecting drift: every resource whose live state, as read from its provider, differs
+// from the state recorded in the stack's checkpoint.
+type DriftReport struct {
+	Resources []ResourceDrift `json:"resources"`
+
+	lock sync.Mutex
+}
+
+// HasDrift returns true if any resource has drifted.
+func (r *DriftReport) HasDrift() bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return len(r.Resources) > 0
+}
+
+// recordStep adds any drift found by a step of a refresh preview to the report.  A refresh updates or replaces each
+// resource whose live state has changed, and deletes each resource that no longer exists.
+func (r *DriftReport) recordStep(step deploy.Step, emitter eventEmitter) {
+	var drift ResourceDrift
+	switch step.Op() {
+	case deploy.OpUpdate, deploy.OpReplace:
+		md := emitter.makeStepEventMetadata(step, false)
+		drift = ResourceDrift{URN: step.URN(), Type: step.Type(), Kind: DriftModified}
+		for _, diff := range md.DetailedDiff {
+			drift.Properties = append(drift.Properties, PropertyDrift{
+				Path: diff.Path.String(),
+				Kind: diff.Kind,
+				Old:  stack.SerializePropertyValue(diff.Old),
+				New:  stack.SerializePropertyValue(diff.New),
+			})
+		}
+	case deploy.OpDelete:
+		drift = ResourceDrift{URN: step.URN(), Type: step.Type(), Kind: DriftDeleted}
+	default:
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.Resources = append(r.Resources, drift)
+}
@@ -0,0 +1,96 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/tokens"
+)
+
+// maxDurationSamples bounds the weight given to past samples when averaging an operation's duration, so that the
+// average follows changes in how long operations take rather than being fixed by a long history.
+const maxDurationSamples = 10
+
+// OperationDuration is the average time that one kind of operation has taken for one type of resource.
+type OperationDuration struct {
+	Samples int           `json:"samples"` // the number of operations averaged, up to maxDurationSamples.
+	Average time.Duration `json:"average"` // the average duration of the operations.
+}
+
+// OperationDurations records how long each kind of operation has taken for each type of resource in past updates, so
+// that the durations of the steps of later updates can be estimated.
+type OperationDurations struct {
+	Types map[tokens.Type]map[deploy.StepOp]OperationDuration `json:"types"`
+
+	lock sync.Mutex
+}
+
+// NewOperationDurations creates an empty record of operation durations.
+func NewOperationDurations() *OperationDurations {
+	return &OperationDurations{Types: make(map[tokens.Type]map[deploy.StepOp]OperationDuration)}
+}
+
+// timedOp returns true if the duration of a step with the given operation is worth recording.  Only the operations
+// that call a provider take any appreciable time; a replacement's time is that of the steps that make it up.
+func timedOp(op deploy.StepOp) bool {
+	switch op {
+	case deploy.OpCreate, deploy.OpUpdate, deploy.OpDelete, deploy.OpCreateReplacement, deploy.OpDeleteReplaced,
+		deploy.OpImport, deploy.OpRead:
+		return true
+	default:
+		return false
+	}
+}
+
+// Estimate returns the expected duration of an operation on a resource of the given type, or zero if no such
+// operation has been recorded.
+func (d *OperationDurations) Estimate(t tokens.Type, op deploy.StepOp) time.Duration {
+	if d == nil {
+		return 0
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.Types[t][op].Average
+}
+
+// Record adds the duration of a completed operation to the average for its resource type and operation.
+func (d *OperationDurations) Record(t tokens.Type, op deploy.StepOp, duration time.Duration) {
+	if d == nil || !timedOp(op) {
+		return
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.Types == nil {
+		d.Types = make(map[tokens.Type]map[deploy.StepOp]OperationDuration)
+	}
+	ops, has := d.Types[t]
+	if !has {
+		ops = make(map[deploy.StepOp]OperationDuration)
+		d.Types[t] = ops
+	}
+
+	avg := ops[op]
+	if avg.Samples < maxDurationSamples {
+		avg.Samples++
+	}
+	avg.Average += (duration - avg.Average) / time.Duration(avg.Samples)
+	ops[op] = avg
+}
@@ -15,7 +15,13 @@
 package engine
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/pulumi/pulumi/pkg/diag"
@@ -28,6 +34,41 @@ import (
 	"github.com/pulumi/pulumi/pkg/util/logging"
 )
 
+// SecretSentinel is the text that replaces secret values wherever they would otherwise be displayed.
+const SecretSentinel = "[secret]"
+
+// secretMaskKey keys the tags that tell masked secrets apart.  It is chosen afresh by every process, so that a tag
+// reveals nothing about the value that it stands for.
+var secretMaskKey = func() []byte {
+	key := make([]byte, sha256.Size)
+	_, err := rand.Read(key)
+	contract.AssertNoError(err)
+	return key
+}()
+
+// maskSecret returns the masked form of a secret value: the secret sentinel, followed by a tag derived from the value.
+// Two masked secrets are therefore equal exactly when the values that they stand for are, so that diffs still report
+// a changed secret as changed, even though neither its old nor its new value is ever shown.
+func maskSecret(v resource.PropertyValue) resource.PropertyValue {
+	data, err := json.Marshal(v.Mappable())
+	if err != nil {
+		return resource.NewStringProperty(SecretSentinel)
+	}
+	mac := hmac.New(sha256.New, secretMaskKey)
+	_, err = mac.Write(data)
+	contract.AssertNoError(err)
+	return resource.NewStringProperty(SecretSentinel + "#" + hex.EncodeToString(mac.Sum(nil)[:8]))
+}
+
+// isMaskedSecret returns true if the given value is a secret masked by maskSecret.
+func isMaskedSecret(v resource.PropertyValue) bool {
+	if !v.IsString() {
+		return false
+	}
+	s := v.StringValue()
+	return s == SecretSentinel || strings.HasPrefix(s, SecretSentinel+"#")
+}
+
 // Event represents an event generated by the engine during an operation. The underlying
 // type for the `Payload` field will differ depending on the value of the `Type` field
 type Event struct {
@@ -78,6 +119,9 @@ type SummaryEventPayload struct {
 	MaybeCorrupt    bool            // true if one or more resources may be corrupt
 	Duration        time.Duration   // the duration of the entire update operation (zero values for previews)
 	ResourceChanges ResourceChanges // count of changed resources, useful for reporting
+	// the estimated duration of the previewed update, based on the durations of past operations (zero if unknown,
+	// and for updates).  Steps are assumed to be applied one at a time.
+	EstimatedDuration time.Duration
 }
 
 type ResourceOperationFailedPayload struct {
@@ -96,6 +140,8 @@ type ResourcePreEventPayload struct {
 	Metadata StepEventMetadata
 	Planning bool
 	Debug    bool
+	// the estimated duration of the step, based on the durations of past operations (zero if unknown).
+	EstimatedDuration time.Duration
 }
 
 type StepEventMetadata struct {
@@ -107,6 +153,32 @@ type StepEventMetadata struct {
 	Res     *StepEventStateMetadata // the latest state for the resource that is known (worst case, old).
 	Keys    []resource.PropertyKey  // the keys causing replacement (only for CreateStep and ReplaceStep).
 	Logical bool                    // true if this step represents a logical operation in the program.
+	// the diff between the old and new states' properties (only for steps with both states, and nil if none changed).
+	// The outputs are diffed if the new state has any, and the inputs otherwise, exactly as the display does.  If the
+	// provider supplied a detailed diff, only the changes that it reported are included.
+	Diff *resource.ObjectDiff
+	// the individual property changes within Diff, flattened to one entry per changed leaf property, in path order.
+	DetailedDiff []PropertyDiff
+	// true if this step replaces its resource by deleting the old resource before creating the new one (only for
+	// ReplaceStep).
+	DeleteBeforeReplace bool
+}
+
+// PropertyDiffKind is the kind of change made to a single property.
+type PropertyDiffKind string
+
+const (
+	PropertyDiffAdd    PropertyDiffKind = "add"    // the property was added.
+	PropertyDiffUpdate PropertyDiffKind = "update" // the property's value changed.
+	PropertyDiffDelete PropertyDiffKind = "delete" // the property was deleted.
+)
+
+// PropertyDiff describes a change made to a single property by a step.
+type PropertyDiff struct {
+	Path resource.PropertyPath  // the path to the changed property.
+	Kind PropertyDiffKind       // the kind of change made.
+	Old  resource.PropertyValue // the property's old value (null for additions).
+	New  resource.PropertyValue // the property's new value (null for deletions).
 }
 
 type StepEventStateMetadata struct {
@@ -124,6 +196,10 @@ type StepEventStateMetadata struct {
 	Parent resource.URN
 	// true to "protect" this resource (protected resources cannot be deleted).
 	Protect bool
+	// if non-empty, the reason this resource has been tainted, and so must be replaced.
+	Taint string
+	// true if this resource is external: read by the engine, but never created, updated, or deleted.
+	External bool
 	// the resource's input properties (as specified by the program). Note: because this will cross
 	// over rpc boundaries it will be slightly different than the Inputs found in resource_state.
 	// Specifically, secrets will have been filtered out, and large values (like assets) will be
@@ -136,7 +212,7 @@ type StepEventStateMetadata struct {
 	Outputs resource.PropertyMap
 }
 
-func makeEventEmitter(events chan<- Event, update UpdateInfo) eventEmitter {
+func makeEventEmitter(events chan<- Event, update UpdateInfo, opts UpdateOptions) eventEmitter {
 	target := update.GetTarget()
 	var secrets []string
 	if target.Config.HasSecureValue() {
@@ -144,25 +220,27 @@ func makeEventEmitter(events chan<- Event, update UpdateInfo) eventEmitter {
 			if !v.Secure() {
 				continue
 			}
-			secret, err := v.Value(target.Decrypter)
+			plaintexts, err := v.SecureValues(target.Decrypter)
 			contract.AssertNoError(err)
 
-			secrets = append(secrets, secret)
+			secrets = append(secrets, plaintexts...)
 		}
 	}
 
-	logging.AddGlobalFilter(logging.CreateFilter(secrets, "[secret]"))
+	logging.AddGlobalFilter(logging.CreateFilter(secrets, SecretSentinel))
 
 	return eventEmitter{
-		Chan: events,
+		Chan:           events,
+		SecretPatterns: opts.SecretPatterns,
 	}
 }
 
 type eventEmitter struct {
-	Chan chan<- Event
+	Chan           chan<- Event
+	SecretPatterns []string // property path patterns whose values are masked before being emitted.
 }
 
-func makeStepEventMetadata(step deploy.Step, debug bool) StepEventMetadata {
+func (e *eventEmitter) makeStepEventMetadata(step deploy.Step, debug bool) StepEventMetadata {
 	var keys []resource.PropertyKey
 
 	if step.Op() == deploy.OpCreateReplacement {
@@ -171,33 +249,180 @@ func makeStepEventMetadata(step deploy.Step, debug bool) StepEventMetadata {
 		keys = step.(*deploy.ReplaceStep).Keys()
 	}
 
+	md := e.makeStateEventMetadata(
+		step.Op(), step.URN(), step.Type(), keys, step.Old(), step.New(), step.Res(), step.Logical(), debug)
+	if replace, ok := step.(*deploy.ReplaceStep); ok {
+		md.DeleteBeforeReplace = replace.DeleteBeforeReplace()
+	}
+
+	// If the provider supplied its own, detailed diff of the step's changes, prefer it to the diff computed here.
+	if dd, ok := step.(detailedDiffStep); ok && dd.DetailedDiff() != nil && md.Old != nil && md.New != nil {
+		olds, news := md.Old.Inputs, md.New.Inputs
+		if len(md.New.Outputs) > 0 {
+			olds, news = md.Old.Outputs, md.New.Outputs
+		}
+		md.Diff = applyDetailedDiff(md.Diff, olds, news, dd.DetailedDiff())
+		md.DetailedDiff = flattenObjectDiff(nil, md.Diff, nil)
+	}
+	return md
+}
+
+// makeStateEventMetadata makes the metadata for an event describing an operation that takes a resource from one state
+// to another, masking any secrets in those states.
+func (e *eventEmitter) makeStateEventMetadata(op deploy.StepOp, urn resource.URN, t tokens.Type,
+	keys []resource.PropertyKey, olds *resource.State, news *resource.State, res *resource.State,
+	logical bool, debug bool) StepEventMetadata {
+
+	old := makeStepEventStateMetadata(olds, debug, e.SecretPatterns)
+	new := makeStepEventStateMetadata(news, debug, e.SecretPatterns)
+	diff := makeStepEventDiff(old, new)
+
 	return StepEventMetadata{
-		Op:      step.Op(),
-		URN:     step.URN(),
-		Type:    step.Type(),
-		Keys:    keys,
-		Old:     makeStepEventStateMetadata(step.Old(), debug),
-		New:     makeStepEventStateMetadata(step.New(), debug),
-		Res:     makeStepEventStateMetadata(step.Res(), debug),
-		Logical: step.Logical(),
+		Op:           op,
+		URN:          urn,
+		Type:         t,
+		Keys:         keys,
+		Old:          old,
+		New:          new,
+		Res:          makeStepEventStateMetadata(res, debug, e.SecretPatterns),
+		Logical:      logical,
+		Diff:         diff,
+		DetailedDiff: flattenObjectDiff(nil, diff, nil),
+	}
+}
+
+// makeStepEventDiff diffs the properties of a step's old and new states, after any secrets have been masked, so that
+// the diff never reveals more than the states themselves.
+func makeStepEventDiff(old *StepEventStateMetadata, new *StepEventStateMetadata) *resource.ObjectDiff {
+	if old == nil || new == nil {
+		return nil
+	}
+	if len(new.Outputs) > 0 {
+		return old.Outputs.Diff(new.Outputs)
+	}
+	return old.Inputs.Diff(new.Inputs)
+}
+
+// flattenObjectDiff appends an entry for each leaf property changed by an object diff to the given list.
+func flattenObjectDiff(path resource.PropertyPath, diff *resource.ObjectDiff, diffs []PropertyDiff) []PropertyDiff {
+	if diff == nil {
+		return diffs
+	}
+
+	for _, k := range diff.Keys() {
+		kpath := path.Key(k)
+		if add, isadd := diff.Adds[k]; isadd {
+			diffs = append(diffs, PropertyDiff{Path: kpath, Kind: PropertyDiffAdd, New: add})
+		} else if delete, isdelete := diff.Deletes[k]; isdelete {
+			diffs = append(diffs, PropertyDiff{Path: kpath, Kind: PropertyDiffDelete, Old: delete})
+		} else if update, isupdate := diff.Updates[k]; isupdate {
+			diffs = flattenValueDiff(kpath, update, diffs)
+		}
+	}
+	return diffs
+}
+
+// flattenValueDiff appends an entry for each leaf property changed by a value diff to the given list.
+func flattenValueDiff(path resource.PropertyPath, diff resource.ValueDiff, diffs []PropertyDiff) []PropertyDiff {
+	switch {
+	case diff.Object != nil:
+		return flattenObjectDiff(path, diff.Object, diffs)
+	case diff.Array != nil:
+		a := diff.Array
+		for i := 0; i < a.Len(); i++ {
+			ipath := path.Index(i)
+			if add, isadd := a.Adds[i]; isadd {
+				diffs = append(diffs, PropertyDiff{Path: ipath, Kind: PropertyDiffAdd, New: add})
+			} else if delete, isdelete := a.Deletes[i]; isdelete {
+				diffs = append(diffs, PropertyDiff{Path: ipath, Kind: PropertyDiffDelete, Old: delete})
+			} else if update, isupdate := a.Updates[i]; isupdate {
+				diffs = flattenValueDiff(ipath, update, diffs)
+			}
+		}
+		return diffs
+	default:
+		return append(diffs, PropertyDiff{Path: path, Kind: PropertyDiffUpdate, Old: diff.Old, New: diff.New})
 	}
 }
 
-func makeStepEventStateMetadata(state *resource.State, debug bool) *StepEventStateMetadata {
+func makeStepEventStateMetadata(state *resource.State, debug bool,
+	secretPatterns []string) *StepEventStateMetadata {
 	if state == nil {
 		return nil
 	}
 
 	return &StepEventStateMetadata{
-		Type:    state.Type,
-		URN:     state.URN,
-		Custom:  state.Custom,
-		Delete:  state.Delete,
-		ID:      state.ID,
-		Parent:  state.Parent,
-		Protect: state.Protect,
-		Inputs:  filterPropertyMap(state.Inputs, debug),
-		Outputs: filterPropertyMap(state.Outputs, debug),
+		Type:     state.Type,
+		URN:      state.URN,
+		Custom:   state.Custom,
+		Delete:   state.Delete,
+		ID:       state.ID,
+		Parent:   state.Parent,
+		Protect:  state.Protect,
+		Taint:    state.Taint,
+		External: state.External,
+		Inputs:   maskSecretProperties(maskSecretOutputs(filterPropertyMap(state.Inputs, debug), state), secretPatterns),
+		Outputs:  maskSecretProperties(maskSecretOutputs(filterPropertyMap(state.Outputs, debug), state), secretPatterns),
+	}
+}
+
+// maskSecretOutputs replaces the values of the properties that the engine found to hold secrets, because they were
+// derived from secrets, with their masked forms.  Values that aren't known yet are left as they are.
+func maskSecretOutputs(props resource.PropertyMap, state *resource.State) resource.PropertyMap {
+	if len(state.SecretOutputs) == 0 || props == nil {
+		return props
+	}
+	result := props.Copy()
+	for _, k := range state.SecretOutputs {
+		if v, has := result[k]; has && !v.IsNull() && !v.IsComputed() && !v.IsOutput() {
+			result[k] = maskSecret(v)
+		}
+	}
+	return result
+}
+
+// maskSecretProperties replaces the value of every property whose path matches one of the given patterns with its
+// masked form, so that the value never makes it into the event stream (and, from there, the display or logs).
+func maskSecretProperties(props resource.PropertyMap, patterns []string) resource.PropertyMap {
+	if len(patterns) == 0 || props == nil {
+		return props
+	}
+	return maskSecretObject(nil, props, patterns)
+}
+
+func maskSecretObject(path resource.PropertyPath, props resource.PropertyMap,
+	patterns []string) resource.PropertyMap {
+
+	result := make(resource.PropertyMap, len(props))
+	for k, v := range props {
+		result[k] = maskSecretValue(path.Key(k), v, patterns)
+	}
+	return result
+}
+
+func maskSecretValue(path resource.PropertyPath, v resource.PropertyValue,
+	patterns []string) resource.PropertyValue {
+
+	// Null values carry no information, so there is nothing to hide; leave them be so diffs remain accurate.
+	if v.IsNull() {
+		return v
+	}
+	if path.MatchesAny(patterns) {
+		return maskSecret(v)
+	}
+
+	switch {
+	case v.IsObject():
+		return resource.NewObjectProperty(maskSecretObject(path, v.ObjectValue(), patterns))
+	case v.IsArray():
+		arr := v.ArrayValue()
+		result := make([]resource.PropertyValue, len(arr))
+		for i, elem := range arr {
+			result[i] = maskSecretValue(path.Index(i), elem, patterns)
+		}
+		return resource.NewArrayProperty(result)
+	default:
+		return v
 	}
 }
 
@@ -318,7 +543,7 @@ func (e *eventEmitter) resourceOperationFailedEvent(
 	e.Chan <- Event{
 		Type: ResourceOperationFailed,
 		Payload: ResourceOperationFailedPayload{
-			Metadata: makeStepEventMetadata(step, debug),
+			Metadata: e.makeStepEventMetadata(step, debug),
 			Status:   status,
 			Steps:    steps,
 		},
@@ -333,7 +558,7 @@ func (e *eventEmitter) resourceOutputsEvent(
 	e.Chan <- Event{
 		Type: ResourceOutputsEvent,
 		Payload: ResourceOutputsEventPayload{
-			Metadata: makeStepEventMetadata(step, debug),
+			Metadata: e.makeStepEventMetadata(step, debug),
 			Planning: planning,
 			Debug:    debug,
 		},
@@ -341,16 +566,17 @@ func (e *eventEmitter) resourceOutputsEvent(
 }
 
 func (e *eventEmitter) resourcePreEvent(
-	step deploy.Step, planning bool, debug bool) {
+	step deploy.Step, planning bool, debug bool, estimate time.Duration) {
 
 	contract.Requiref(e != nil, "e", "!= nil")
 
 	e.Chan <- Event{
 		Type: ResourcePreEvent,
 		Payload: ResourcePreEventPayload{
-			Metadata: makeStepEventMetadata(step, debug),
-			Planning: planning,
-			Debug:    debug,
+			Metadata:          e.makeStepEventMetadata(step, debug),
+			Planning:          planning,
+			Debug:             debug,
+			EstimatedDuration: estimate,
 		},
 	}
 }
@@ -375,7 +601,7 @@ func (e *eventEmitter) preludeEvent(isPreview bool, cfg config.Map) {
 	}
 }
 
-func (e *eventEmitter) previewSummaryEvent(resourceChanges ResourceChanges) {
+func (e *eventEmitter) previewSummaryEvent(resourceChanges ResourceChanges, estimate time.Duration) {
 	contract.Requiref(e != nil, "e", "!= nil")
 
 	e.Chan <- Event{
@@ -385,6 +611,8 @@ func (e *eventEmitter) previewSummaryEvent(resourceChanges ResourceChanges) {
 			MaybeCorrupt:    false,
 			Duration:        0,
 			ResourceChanges: resourceChanges,
+
+			EstimatedDuration: estimate,
 		},
 	}
 }
@@ -0,0 +1,73 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+)
+
+func TestMaskSecret(t *testing.T) {
+	hunter2 := maskSecret(resource.NewStringProperty("hunter2"))
+	assert.True(t, isMaskedSecret(hunter2))
+	assert.False(t, strings.Contains(hunter2.StringValue(), "hunter2"))
+
+	// Equal values mask to equal values, and different values to different ones, so diffs stay accurate.
+	assert.Equal(t, hunter2, maskSecret(resource.NewStringProperty("hunter2")))
+	assert.NotEqual(t, hunter2, maskSecret(resource.NewStringProperty("hunter3")))
+	assert.NotEqual(t, hunter2, maskSecret(resource.NewPropertyValue([]interface{}{"hunter2"})))
+
+	assert.True(t, isMaskedSecret(resource.NewStringProperty(SecretSentinel)))
+	assert.False(t, isMaskedSecret(resource.NewStringProperty("[secret] agent")))
+	assert.False(t, isMaskedSecret(resource.NewNumberProperty(42)))
+}
+
+func TestMakeStepEventStateMetadataMasksSecrets(t *testing.T) {
+	state := func(password string) *resource.State {
+		return &resource.State{
+			Inputs: resource.NewPropertyMapFromMap(map[string]interface{}{
+				"user":     "admin",
+				"password": password,
+			}),
+			Outputs: resource.NewPropertyMapFromMap(map[string]interface{}{
+				"user":       "admin",
+				"password":   password,
+				"connection": "admin:" + password + "@db",
+			}),
+			SecretOutputs: []resource.PropertyKey{"connection"},
+		}
+	}
+
+	old := makeStepEventStateMetadata(state("hunter2"), false, []string{"password"})
+	new := makeStepEventStateMetadata(state("hunter3"), false, []string{"password"})
+	for _, meta := range []*StepEventStateMetadata{old, new} {
+		assert.Equal(t, "admin", meta.Inputs["user"].StringValue())
+		assert.True(t, isMaskedSecret(meta.Inputs["password"]))
+		assert.True(t, isMaskedSecret(meta.Outputs["password"]))
+		assert.True(t, isMaskedSecret(meta.Outputs["connection"]))
+	}
+
+	// The secrets changed, so the masked values must differ too.
+	diff := old.Outputs.Diff(new.Outputs)
+	if assert.NotNil(t, diff) {
+		assert.True(t, diff.Updated("password"))
+		assert.True(t, diff.Updated("connection"))
+		assert.False(t, diff.Changed("user"))
+	}
+}
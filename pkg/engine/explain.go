@@ -0,0 +1,265 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/tokens"
+)
+
+// ExplainedProperty is a single property changed by an explained step.
+type ExplainedProperty struct {
+	Path     string           `json:"path"`
+	Kind     PropertyDiffKind `json:"kind"`
+	Replace  bool             `json:"replace,omitempty"`  // true if changing this property requires a replacement.
+	Computed bool             `json:"computed,omitempty"` // true if the new value awaits the output of a dependency.
+	// the upstream resources whose outputs the property's new value was computed from.
+	Sources []ExplainedSource `json:"sources,omitempty"`
+}
+
+// ExplainedSource is an upstream resource that a changed property's value came from.
+type ExplainedSource struct {
+	URN resource.URN `json:"urn"`
+	// the resource's outputs whose values appear in the property's new value, if any could be matched.
+	Outputs []string `json:"outputs,omitempty"`
+}
+
+// ExplainedStep describes the step planned for a single resource, and why that operation was chosen.
+type ExplainedStep struct {
+	URN          resource.URN        `json:"urn"`
+	Type         tokens.Type         `json:"type"`
+	Op           deploy.StepOp       `json:"op"`
+	Reason       string              `json:"reason"`
+	Properties   []ExplainedProperty `json:"properties,omitempty"`
+	ProviderDiff bool                `json:"providerDiff,omitempty"` // true if the provider supplied a detailed diff.
+	// the resource's dependencies that are themselves changing, and so may have caused this step.
+	ChangedDependencies []resource.URN `json:"changedDependencies,omitempty"`
+}
+
+// Explanation is the result of explaining why a preview plans the operation that it does for a resource.  Each step
+// of the preview is recorded, so that the chain of changed dependencies that led to the resource's step can be found.
+type Explanation struct {
+	URN resource.URN `json:"urn"` // the resource to explain.
+
+	steps   map[resource.URN]ExplainedStep
+	outputs map[resource.URN]resource.PropertyMap // the new outputs of each resource, used to trace property values.
+	lock    sync.Mutex
+}
+
+// NewExplanation creates an explanation of the step planned for the resource with the given URN.
+func NewExplanation(urn resource.URN) *Explanation {
+	return &Explanation{
+		URN:     urn,
+		steps:   make(map[resource.URN]ExplainedStep),
+		outputs: make(map[resource.URN]resource.PropertyMap),
+	}
+}
+
+// Chain returns the explained step for the resource, followed by the steps of each of its changed dependencies,
+// transitively, in breadth-first order.  It returns nil if the preview planned no step for the resource.
+func (e *Explanation) Chain() []ExplainedStep {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if _, has := e.steps[e.URN]; !has {
+		return nil
+	}
+
+	var chain []ExplainedStep
+	seen := map[resource.URN]bool{e.URN: true}
+	queue := []resource.URN{e.URN}
+	for len(queue) > 0 {
+		step := e.steps[queue[0]]
+		queue = queue[1:]
+		chain = append(chain, step)
+		for _, dep := range step.ChangedDependencies {
+			if !seen[dep] {
+				seen[dep] = true
+				queue = append(queue, dep)
+			}
+		}
+	}
+	return chain
+}
+
+// recordStep records a step of the preview.  Only the logical step for each resource is recorded; the steps that
+// make up the rest of a replacement add nothing to its explanation.
+func (e *Explanation) recordStep(step deploy.Step, emitter eventEmitter) {
+	if !step.Logical() {
+		return
+	}
+
+	md := emitter.makeStepEventMetadata(step, false)
+	explained := ExplainedStep{URN: step.URN(), Type: step.Type(), Op: step.Op()}
+
+	// Note which of the changed properties require a replacement, preferring the provider's own account of that.
+	replaceKeys := make(map[resource.PropertyKey]bool)
+	for _, k := range md.Keys {
+		replaceKeys[k] = true
+	}
+	dd, hasDetailedDiff := step.(detailedDiffStep)
+	detailed := hasDetailedDiff && dd.DetailedDiff() != nil
+	explained.ProviderDiff = detailed
+	var values []resource.PropertyValue
+	for _, diff := range md.DetailedDiff {
+		values = append(values, diff.New)
+		prop := ExplainedProperty{Path: diff.Path.String(), Kind: diff.Kind, Computed: diff.New.ContainsUnknowns()}
+		if detailed {
+			for p, pd := range dd.DetailedDiff() {
+				if pd.Kind.IsReplace() && hasPathPrefix(diff.Path, resource.ParsePropertyPath(p)) {
+					prop.Replace = true
+				}
+			}
+		}
+		if !prop.Replace && len(diff.Path) > 0 {
+			prop.Replace = replaceKeys[resource.PropertyKey(diff.Path[0])]
+		}
+		explained.Properties = append(explained.Properties, prop)
+	}
+
+	explained.Reason = explainOp(step, md)
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	// Steps are planned in dependency order, so all of this resource's dependencies have been recorded already.
+	if new := step.New(); new != nil {
+		for _, dep := range new.Dependencies {
+			if depStep, has := e.steps[dep]; has && depStep.Op != deploy.OpSame && depStep.Op != deploy.OpRead {
+				explained.ChangedDependencies = append(explained.ChangedDependencies, dep)
+			}
+		}
+		for i := range explained.Properties {
+			path := md.DetailedDiff[i].Path
+			explained.Properties[i].Sources = e.traceSources(new, path, values[i], explained.ChangedDependencies)
+		}
+		e.outputs[step.URN()] = new.Outputs
+	}
+	e.steps[step.URN()] = explained
+}
+
+// traceSources finds the upstream resources that a changed property's new value came from.  If the program reported
+// the dependencies of the property's top-level key, each of those is a source; otherwise, a changed dependency is a
+// source only if one of its outputs can be found in the value.  Either way, the outputs whose values appear in the
+// property's value are listed.  The caller must hold the explanation's lock.
+func (e *Explanation) traceSources(new *resource.State, path resource.PropertyPath, value resource.PropertyValue,
+	changed []resource.URN) []ExplainedSource {
+
+	candidates, known := changed, false
+	if len(path) > 0 && new.PropertyDependencies != nil {
+		candidates, known = new.PropertyDependencies[resource.PropertyKey(path[0])]
+	}
+
+	var sources []ExplainedSource
+	for _, dep := range candidates {
+		var outputs []string
+		for k, out := range e.outputs[dep] {
+			if !isTraceableValue(out) || !containsValue(value, out) {
+				continue
+			}
+			outputs = append(outputs, string(k))
+		}
+		if !known && len(outputs) == 0 {
+			continue
+		}
+		sort.Strings(outputs)
+		sources = append(sources, ExplainedSource{URN: dep, Outputs: outputs})
+	}
+	return sources
+}
+
+// isTraceableValue returns true if a value is specific enough to identify where a property's value came from: nulls,
+// unknowns, and empty values would match far too much.
+func isTraceableValue(v resource.PropertyValue) bool {
+	switch {
+	case v.IsNull() || v.ContainsUnknowns():
+		return false
+	case v.IsString():
+		return v.StringValue() != ""
+	case v.IsArray():
+		return len(v.ArrayValue()) > 0
+	case v.IsObject():
+		return len(v.ObjectValue()) > 0
+	default:
+		return true
+	}
+}
+
+// containsValue returns true if the given value is, or holds somewhere within it, an element equal to the target.
+func containsValue(v, target resource.PropertyValue) bool {
+	if v.DeepEquals(target) {
+		return true
+	}
+	switch {
+	case v.IsArray():
+		for _, elem := range v.ArrayValue() {
+			if containsValue(elem, target) {
+				return true
+			}
+		}
+	case v.IsObject():
+		for _, elem := range v.ObjectValue() {
+			if containsValue(elem, target) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// explainOp returns a human-readable description of why the planner chose a step's operation.
+func explainOp(step deploy.Step, md StepEventMetadata) string {
+	switch step.Op() {
+	case deploy.OpSame:
+		return "none of the resource's properties changed"
+	case deploy.OpCreate:
+		return "the resource does not exist in the stack yet"
+	case deploy.OpUpdate:
+		return "the provider can update the changed properties in place"
+	case deploy.OpDelete:
+		return "the resource is no longer registered by the program"
+	case deploy.OpImport:
+		return "the resource is being imported into the stack"
+	case deploy.OpRead:
+		return "the resource is external; its state is read from its provider, but it is never changed"
+	case deploy.OpReadDiscard:
+		return "the external resource is no longer read by the program; it is left as it is"
+	case deploy.OpReplace:
+		var reason string
+		if old := step.Old(); old != nil && old.Taint != "" {
+			reason = fmt.Sprintf("the resource is tainted (%s)", old.Taint)
+		} else if len(md.Keys) > 0 {
+			keys := make([]string, len(md.Keys))
+			for i, k := range md.Keys {
+				keys[i] = string(k)
+			}
+			reason = fmt.Sprintf("changes to [%s] cannot be made in place", strings.Join(keys, ", "))
+		} else {
+			reason = "the provider requires the resource to be replaced"
+		}
+		if md.DeleteBeforeReplace {
+			reason += "; the old resource is deleted before its replacement is created"
+		}
+		return reason
+	default:
+		return ""
+	}
+}
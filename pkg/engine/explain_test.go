@@ -0,0 +1,55 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+)
+
+func TestTraceSources(t *testing.T) {
+	vpc := resource.URN("urn:pulumi:test::test::aws:ec2/vpc:Vpc::vpc")
+	role := resource.URN("urn:pulumi:test::test::aws:iam/role:Role::role")
+
+	e := NewExplanation("urn:pulumi:test::test::aws:ec2/subnet:Subnet::subnet")
+	e.outputs[vpc] = resource.NewPropertyMapFromMap(map[string]interface{}{
+		"id":        "vpc-1234",
+		"arn":       "arn:vpc-1234",
+		"tags":      map[string]interface{}{},
+		"cidrBlock": "10.0.0.0/16",
+	})
+	e.outputs[role] = resource.NewPropertyMapFromMap(map[string]interface{}{
+		"arn": "arn:role",
+	})
+
+	value := resource.NewPropertyValue(map[string]interface{}{"ids": []interface{}{"vpc-1234"}})
+	path := resource.ParsePropertyPath("vpcConfig")
+	changed := []resource.URN{vpc, role}
+
+	// Without the program's property dependencies, only the changed dependencies whose outputs match are sources.
+	new := &resource.State{}
+	assert.Equal(t, []ExplainedSource{{URN: vpc, Outputs: []string{"id"}}},
+		e.traceSources(new, path, value, changed))
+
+	// With them, every reported dependency is a source, even if none of its outputs can be matched.
+	new.PropertyDependencies = map[resource.PropertyKey][]resource.URN{"vpcConfig": {role}}
+	assert.Equal(t, []ExplainedSource{{URN: role}}, e.traceSources(new, path, value, changed))
+
+	// A property that the program reported no dependencies for has no sources.
+	assert.Nil(t, e.traceSources(new, resource.ParsePropertyPath("name"), value, changed))
+}
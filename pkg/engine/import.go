@@ -0,0 +1,153 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/diag"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/resource/plugin"
+	"github.com/pulumi/pulumi/pkg/tokens"
+	"github.com/pulumi/pulumi/pkg/util/contract"
+	"github.com/pulumi/pulumi/pkg/workspace"
+)
+
+// ImportSpec identifies an existing resource, created outside of Pulumi, to bring under management.
+type ImportSpec struct {
+	Type tokens.Type  `json:"type"` // the resource's type.
+	Name tokens.QName `json:"name"` // the name to give the resource in the stack.
+	ID   resource.ID  `json:"id"`   // the provider's ID for the existing resource.
+}
+
+func newImportSource(
+	opts planOptions, proj *workspace.Project, pwd, main string,
+	target *deploy.Target, plugctx *plugin.Context, dryRun bool) (deploy.Source, error) {
+
+	// Like destroy, import doesn't run the program, and so loads only the providers recorded in the checkpoint.  Any
+	// other provider is loaded on demand when its resources are read.
+	if target != nil && target.Snapshot != nil {
+		kinds := plugin.AllPlugins & ^plugin.LanguagePlugins
+		if err := plugctx.Host.EnsurePlugins(target.Snapshot.Manifest.Plugins, kinds); err != nil {
+			return nil, err
+		}
+	}
+	return deploy.NullSource, nil
+}
+
+// importResources reads each of the existing resources in opts.Imports from its provider and adds it to the stack's
+// checkpoint, without running the stack's program.  Each resource is parented to the stack's root resource, if there
+// is one, so that a program that later declares the resource with the same name takes over its management.
+func importResources(ctx *Context, info *planContext, opts planOptions, dryRun bool) (ResourceChanges, error) {
+
+	result, err := plan(ctx, info, opts, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	defer contract.IgnoreClose(result)
+
+	done, err := result.Chdir()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	opts.Events.preludeEvent(dryRun, result.Ctx.Update.GetTarget().Config)
+
+	var actions interface {
+		deploy.Events
+		ops() ResourceChanges
+	}
+	if dryRun {
+		actions = newPlanActions(opts)
+	} else {
+		actions = newUpdateActions(ctx, info.Update, opts)
+	}
+
+	steps, err := newImportSteps(result.Plan, info.Update.GetProject(), opts.Imports)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	for _, step := range steps {
+		if cancelErr := ctx.Cancel.CancelErr(); cancelErr != nil {
+			return actions.ops(), cancelErr
+		}
+
+		var payload interface{}
+		if payload, err = actions.OnResourceStepPre(step); err == nil {
+			var status resource.Status
+			status, err = step.Apply(dryRun)
+			if postErr := actions.OnResourceStepPost(payload, step, status, err); err == nil {
+				err = postErr
+			}
+		}
+		if err != nil {
+			opts.Diag.Errorf(diag.Message(step.URN(), err.Error()))
+			break
+		}
+	}
+
+	changes := actions.ops()
+	if dryRun {
+		opts.Events.previewSummaryEvent(changes, 0)
+	} else {
+		opts.Events.updateSummaryEvent(false, time.Since(start), changes)
+	}
+	return changes, err
+}
+
+// newImportSteps creates a step to import each of the given resources.  It is an error to import a resource whose
+// URN is already in use by the stack.
+func newImportSteps(plan *deploy.Plan, proj *workspace.Project, imports []ImportSpec) ([]deploy.Step, error) {
+	target := plan.Target()
+
+	// Find the stack's root resource, if any, to serve as the parent of the imported resources.  Just as for the
+	// resources a program registers, the root stack's type doesn't contribute to its children's URNs.
+	var parent resource.URN
+	olds := plan.Olds()
+	for urn, old := range olds {
+		if old.Type == resource.RootStackType && old.Parent == "" {
+			parent = urn
+			break
+		}
+	}
+
+	seen := make(map[resource.URN]bool)
+	var steps []deploy.Step
+	for _, imp := range imports {
+		if imp.Type == "" || imp.Name == "" || imp.ID == "" {
+			return nil, errors.Errorf("resources to import must have a type, name, and ID")
+		}
+
+		urn := resource.NewURN(target.Name, proj.Name, "", imp.Type, imp.Name)
+		if _, has := olds[urn]; has || seen[urn] {
+			return nil, errors.Errorf("resource '%s' already exists", urn)
+		}
+		seen[urn] = true
+
+		new := resource.NewState(imp.Type, urn, true, false, imp.ID,
+			resource.PropertyMap{}, nil, parent, false, nil)
+		steps = append(steps, deploy.NewImportStep(plan, new))
+	}
+	return steps, nil
+}
+
+func (acts *planActions) ops() ResourceChanges   { return ResourceChanges(acts.Ops) }
+func (acts *updateActions) ops() ResourceChanges { return ResourceChanges(acts.Ops) }
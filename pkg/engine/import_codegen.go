@@ -0,0 +1,253 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/tokens"
+)
+
+// GenerateImportCode returns sample source code, in the language of the given runtime, that declares each of the
+// given imported resources with its current properties.  Adding this code to the stack's program lets the program
+// take over the resources' management.  It is an error for a resource to have an input whose value can't be written
+// as source code, such as an asset, as the code would not reproduce the resource.
+func GenerateImportCode(runtime string, states []*resource.State) (string, error) {
+	var gen func(w *bytes.Buffer, state *resource.State) error
+	switch runtime {
+	case "nodejs":
+		gen = genTypeScriptResource
+	case "python":
+		gen = genPythonResource
+	default:
+		return "", errors.Errorf("generating code for the '%s' runtime is not supported", runtime)
+	}
+
+	var w bytes.Buffer
+	for i, state := range states {
+		if i > 0 {
+			w.WriteString("\n")
+		}
+		if err := gen(&w, state); err != nil {
+			return "", err
+		}
+	}
+	return w.String(), nil
+}
+
+// importTypePath returns the package, the module path, and the name of a resource type.  For example, the type
+// `aws:s3/bucket:Bucket` is in the package `aws` and the module `s3`, and is named `Bucket`.  Types in a package's
+// `index` module have an empty module path.
+func importTypePath(t tokens.Type) (string, []string, string) {
+	mod := string(t.Module().Name())
+	if i := strings.Index(mod, "/"); i != -1 {
+		mod = mod[:i]
+	}
+
+	var path []string
+	if mod != "" && mod != "index" {
+		path = strings.Split(mod, ".")
+	}
+	return string(t.Package()), path, string(t.Name())
+}
+
+// importIdentifier returns a variable name for a resource, in camelCase or, if snake is true, snake_case.
+func importIdentifier(name tokens.QName, snake bool) string {
+	words := strings.FieldsFunc(string(name), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+	if len(words) == 0 {
+		return "resource"
+	}
+
+	for i, word := range words {
+		switch {
+		case snake:
+			words[i] = toSnakeCase(word)
+		case i > 0:
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+
+	sep := ""
+	if snake {
+		sep = "_"
+	}
+	ident := strings.Join(words, sep)
+	if unicode.IsDigit(rune(ident[0])) {
+		ident = "_" + ident
+	}
+	return ident
+}
+
+// toSnakeCase converts a camelCase name to snake_case.
+func toSnakeCase(s string) string {
+	var b bytes.Buffer
+	for i, c := range s {
+		if unicode.IsUpper(c) {
+			if i > 0 {
+				b.WriteRune('_')
+			}
+			c = unicode.ToLower(c)
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// importProperties returns the keys of the properties that should be declared for an imported resource.  Internal
+// properties, whose names begin with `__`, are omitted, as are nulls.
+func importProperties(props resource.PropertyMap) []resource.PropertyKey {
+	var keys []resource.PropertyKey
+	for _, k := range props.StableKeys() {
+		if !strings.HasPrefix(string(k), "__") && !props[k].IsNull() {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// unsupportedImportValue returns the error reported for a property whose value can't be generated.
+func unsupportedImportValue(state *resource.State, k resource.PropertyKey, v resource.PropertyValue) error {
+	return errors.Errorf("cannot generate code for resource '%s': its property '%s' holds a value of type %s, "+
+		"which can't be written as source code", state.URN, k, v.TypeString())
+}
+
+func genTypeScriptResource(w *bytes.Buffer, state *resource.State) error {
+	pkg, path, name := importTypePath(state.Type)
+	class := strings.Join(append(append([]string{pkg}, path...), name), ".")
+
+	fmt.Fprintf(w, "const %s = new %s(%s, {\n", importIdentifier(state.URN.Name(), false), class,
+		strconv.Quote(string(state.URN.Name())))
+	for _, k := range importProperties(state.Inputs) {
+		fmt.Fprintf(w, "    %s: ", k)
+		if bad, ok := genTypeScriptValue(w, state.Inputs[k], "    "); !ok {
+			return unsupportedImportValue(state, k, bad)
+		}
+		w.WriteString(",\n")
+	}
+	w.WriteString("}, { protect: true });\n")
+	return nil
+}
+
+// genTypeScriptValue writes a value as a TypeScript expression.  If the value, or one of its elements, can't be
+// written, that value is returned along with false.
+func genTypeScriptValue(w *bytes.Buffer, v resource.PropertyValue, indent string) (resource.PropertyValue, bool) {
+	switch {
+	case v.IsNull():
+		w.WriteString("undefined")
+	case v.IsBool():
+		fmt.Fprintf(w, "%v", v.BoolValue())
+	case v.IsNumber():
+		w.WriteString(strconv.FormatFloat(v.NumberValue(), 'f', -1, 64))
+	case v.IsString():
+		w.WriteString(strconv.Quote(v.StringValue()))
+	case v.IsArray():
+		w.WriteString("[")
+		for i, elem := range v.ArrayValue() {
+			if i > 0 {
+				w.WriteString(", ")
+			}
+			if bad, ok := genTypeScriptValue(w, elem, indent); !ok {
+				return bad, false
+			}
+		}
+		w.WriteString("]")
+	case v.IsObject():
+		obj := v.ObjectValue()
+		w.WriteString("{\n")
+		for _, k := range importProperties(obj) {
+			fmt.Fprintf(w, "%s    %s: ", indent, strconv.Quote(string(k)))
+			if bad, ok := genTypeScriptValue(w, obj[k], indent+"    "); !ok {
+				return bad, false
+			}
+			w.WriteString(",\n")
+		}
+		fmt.Fprintf(w, "%s}", indent)
+	default:
+		// Assets, archives, and unknowns can't be recovered from a provider's read.
+		return v, false
+	}
+	return v, true
+}
+
+func genPythonResource(w *bytes.Buffer, state *resource.State) error {
+	pkg, path, name := importTypePath(state.Type)
+	class := strings.Join(append(append([]string{"pulumi_" + toSnakeCase(pkg)}, path...), name), ".")
+
+	fmt.Fprintf(w, "%s = %s(%s,\n", importIdentifier(state.URN.Name(), true), class,
+		strconv.Quote(string(state.URN.Name())))
+	for _, k := range importProperties(state.Inputs) {
+		fmt.Fprintf(w, "    %s=", toSnakeCase(string(k)))
+		if bad, ok := genPythonValue(w, state.Inputs[k], "    "); !ok {
+			return unsupportedImportValue(state, k, bad)
+		}
+		w.WriteString(",\n")
+	}
+	w.WriteString("    opts=pulumi.ResourceOptions(protect=True))\n")
+	return nil
+}
+
+// genPythonValue writes a value as a Python expression.  If the value, or one of its elements, can't be written, that
+// value is returned along with false.
+func genPythonValue(w *bytes.Buffer, v resource.PropertyValue, indent string) (resource.PropertyValue, bool) {
+	switch {
+	case v.IsNull():
+		w.WriteString("None")
+	case v.IsBool():
+		if v.BoolValue() {
+			w.WriteString("True")
+		} else {
+			w.WriteString("False")
+		}
+	case v.IsNumber():
+		w.WriteString(strconv.FormatFloat(v.NumberValue(), 'f', -1, 64))
+	case v.IsString():
+		w.WriteString(strconv.Quote(v.StringValue()))
+	case v.IsArray():
+		w.WriteString("[")
+		for i, elem := range v.ArrayValue() {
+			if i > 0 {
+				w.WriteString(", ")
+			}
+			if bad, ok := genPythonValue(w, elem, indent); !ok {
+				return bad, false
+			}
+		}
+		w.WriteString("]")
+	case v.IsObject():
+		obj := v.ObjectValue()
+		w.WriteString("{\n")
+		for _, k := range importProperties(obj) {
+			fmt.Fprintf(w, "%s    %s: ", indent, strconv.Quote(string(k)))
+			if bad, ok := genPythonValue(w, obj[k], indent+"    "); !ok {
+				return bad, false
+			}
+			w.WriteString(",\n")
+		}
+		fmt.Fprintf(w, "%s}", indent)
+	default:
+		// Assets, archives, and unknowns can't be recovered from a provider's read.
+		return v, false
+	}
+	return v, true
+}
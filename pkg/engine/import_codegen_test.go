@@ -0,0 +1,76 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/tokens"
+)
+
+func newImportedState(inputs resource.PropertyMap) *resource.State {
+	t := tokens.Type("aws:s3/bucket:Bucket")
+	urn := resource.NewURN("test", "test", "", t, "my-bucket")
+	return resource.NewState(t, urn, true, false, "my-bucket-1234", inputs, inputs, "", true, nil)
+}
+
+func TestGenerateImportCode(t *testing.T) {
+	state := newImportedState(resource.NewPropertyMapFromMap(map[string]interface{}{
+		"bucket": "my-bucket-1234",
+		"tags":   map[string]interface{}{"Name": "x"},
+		"__meta": "internal",
+	}))
+
+	code, err := GenerateImportCode("nodejs", []*resource.State{state})
+	assert.NoError(t, err)
+	assert.Equal(t, `const myBucket = new aws.s3.Bucket("my-bucket", {
+    bucket: "my-bucket-1234",
+    tags: {
+        "Name": "x",
+    },
+}, { protect: true });
+`, code)
+
+	code, err = GenerateImportCode("python", []*resource.State{state})
+	assert.NoError(t, err)
+	assert.Equal(t, `my_bucket = pulumi_aws.s3.Bucket("my-bucket",
+    bucket="my-bucket-1234",
+    tags={
+        "Name": "x",
+    },
+    opts=pulumi.ResourceOptions(protect=True))
+`, code)
+
+	_, err = GenerateImportCode("go", []*resource.State{state})
+	assert.Error(t, err)
+}
+
+func TestGenerateImportCodeUnsupportedValue(t *testing.T) {
+	asset, err := resource.NewTextAsset("hello")
+	assert.NoError(t, err)
+	state := newImportedState(resource.PropertyMap{
+		"bucket": resource.NewStringProperty("my-bucket-1234"),
+		"files":  resource.NewArrayProperty([]resource.PropertyValue{resource.NewAssetProperty(asset)}),
+	})
+
+	for _, runtime := range []string{"nodejs", "python"} {
+		_, err = GenerateImportCode(runtime, []*resource.State{state})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "its property 'files' holds a value of type asset")
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+)
+
+// GetResourcePropertiesPatch renders the change made by a step as a unified-diff-like textual patch.  Each property is
+// flattened into a single `path: value` line, so the output contains no ANSI colors or indentation and is stable from
+// run to run, making it suitable for storing as an artifact and comparing between runs.  For example:
+//
+//	--- urn:pulumi:dev::proj::aws:s3/bucket:Bucket::b (old)
+//	+++ urn:pulumi:dev::proj::aws:s3/bucket:Bucket::b (new)
+//	@@ update aws:s3/bucket:Bucket @@
+//	 acl: "private"
+//	-tags.env: "dev"
+//	+tags.env: "prod"
+//
+// All properties are included, not just those that changed, so that every patch is complete on its own.
+func GetResourcePropertiesPatch(step StepEventMetadata, planning bool, opts DiffOptions) string {
+	var olds, news resource.PropertyMap
+	if step.Old != nil {
+		olds = step.Old.Inputs
+	}
+	if step.New != nil {
+		news = step.New.Inputs
+		if len(step.New.Outputs) > 0 {
+			news = step.New.Outputs
+			if step.Old != nil {
+				olds = step.Old.Outputs
+			}
+		}
+	}
+
+	oldText := strings.Join(flattenPropertyMap(olds, nil, planning, opts), "")
+	newText := strings.Join(flattenPropertyMap(news, nil, planning, opts), "")
+
+	var b bytes.Buffer
+	writeString(&b, fmt.Sprintf("--- %s (old)\n", step.URN))
+	writeString(&b, fmt.Sprintf("+++ %s (new)\n", step.URN))
+	writeString(&b, fmt.Sprintf("@@ %s %s @@\n", step.Op, step.Type))
+
+	differ := diffmatchpatch.New()
+	differ.DiffTimeout = 0
+	hashed1, hashed2, lineArray := differ.DiffLinesToChars(oldText, newText)
+	diffs := differ.DiffCharsToLines(differ.DiffMain(hashed1, hashed2, false), lineArray)
+	for _, d := range diffs {
+		var prefix string
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			prefix = "-"
+		case diffmatchpatch.DiffInsert:
+			prefix = "+"
+		case diffmatchpatch.DiffEqual:
+			prefix = " "
+		}
+		for _, line := range strings.SplitAfter(d.Text, "\n") {
+			if line != "" {
+				writeString(&b, prefix+line)
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// flattenPropertyMap renders each leaf property beneath a map as a single newline-terminated `path: value` line.
+func flattenPropertyMap(
+	props resource.PropertyMap, path resource.PropertyPath, planning bool, opts DiffOptions) []string {
+
+	var lines []string
+	for _, k := range props.StableKeys() {
+		kpath := path.Key(k)
+		if show, kopts := opts.filterPath(kpath); show {
+			lines = append(lines, flattenPropertyValue(props[k], kpath, planning, kopts)...)
+		}
+	}
+	return lines
+}
+
+// flattenPropertyValue renders a property value as one or more newline-terminated `path: value` lines.
+func flattenPropertyValue(
+	v resource.PropertyValue, path resource.PropertyPath, planning bool, opts DiffOptions) []string {
+
+	switch {
+	case v.IsObject() && len(v.ObjectValue()) > 0:
+		return flattenPropertyMap(v.ObjectValue(), path, planning, opts)
+	case v.IsArray() && len(v.ArrayValue()) > 0:
+		var lines []string
+		for i, elem := range v.ArrayValue() {
+			ipath := path.Index(i)
+			if show, iopts := opts.filterPath(ipath); show {
+				lines = append(lines, flattenPropertyValue(elem, ipath, planning, iopts)...)
+			}
+		}
+		return lines
+	case v.IsArchive() && v.ArchiveValue().IsAssets():
+		// Assets archives are flattened into their members, much like objects.
+		assets, _ := v.ArchiveValue().GetAssets()
+		var names []string
+		for name := range assets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		lines := []string{fmt.Sprintf("%s: archive(assets:%s)\n", path, shortHash(v.ArchiveValue().Hash))}
+		for _, name := range names {
+			npath := path.Key(resource.PropertyKey(name))
+			if show, nopts := opts.filterPath(npath); show {
+				lines = append(lines, flattenPropertyValue(
+					assetOrArchiveToPropertyValue(assets[name]), npath, planning, nopts)...)
+			}
+		}
+		return lines
+	default:
+		return []string{fmt.Sprintf("%s: %s\n", path, flattenLeafValue(v, planning))}
+	}
+}
+
+// flattenLeafValue renders a property value that has no children as a single line of text.
+func flattenLeafValue(v resource.PropertyValue, planning bool) string {
+	switch {
+	case v.IsNull():
+		return "<null>"
+	case v.IsBool():
+		return fmt.Sprintf("%t", v.BoolValue())
+	case v.IsNumber():
+		return fmt.Sprintf("%v", v.NumberValue())
+	case v.IsString():
+		if !isMaskedSecret(v) {
+			return fmt.Sprintf("%q", v.StringValue())
+		}
+		return SecretSentinel
+	case v.IsComputed() || v.IsOutput():
+		if planning {
+			return v.TypeString()
+		}
+		return "undefined"
+	case v.IsObject():
+		return "{}"
+	case v.IsArray():
+		return "[]"
+	case v.IsAsset():
+		return strings.TrimSuffix(makeAssetHeader(v.AssetValue()), "\n")
+	case v.IsArchive():
+		a := v.ArchiveValue()
+		if path, has := a.GetPath(); has {
+			return fmt.Sprintf("archive(file:%s) { %s }", shortHash(a.Hash), path)
+		}
+		return fmt.Sprintf("archive(uri:%s) { %s }", shortHash(a.Hash), a.URI)
+	default:
+		return v.String()
+	}
+}
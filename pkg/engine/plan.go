@@ -16,6 +16,8 @@ package engine
 
 import (
 	"os"
+	"sync"
+	"time"
 
 	"github.com/opentracing/opentracing-go"
 
@@ -129,6 +131,11 @@ func plan(ctx *Context, info *planContext, opts planOptions, dryRun bool) (*plan
 		return nil, err
 	}
 
+	// If the last update was interrupted, reconcile any operations it left pending before planning this one.
+	if err = reconcilePendingOperations(plugctx, target.Snapshot, opts); err != nil {
+		return nil, err
+	}
+
 	// If there are any analyzers in the project file, add them.
 	var analyzers []tokens.QName
 	if as := projinfo.Proj.Analyzers; as != nil {
@@ -185,9 +192,31 @@ func (res *planResult) Chdir() (func(), error) {
 // failed, if the error is non-nil; and finally the state of the resource modified in the failing step.
 func (res *planResult) Walk(ctx *Context, events deploy.Events, preview bool) (deploy.PlanSummary,
 	deploy.Step, resource.Status, error) {
+	proj := res.Ctx.Update.GetProject()
+	providerParallelism := make(map[tokens.Package]int)
+	for pkg, n := range proj.ProviderParallelism {
+		providerParallelism[tokens.Package(pkg)] = n
+	}
+
+	// Waits to retry failed operations are abandoned once the update is canceled.
+	retry := res.Options.Retry
+	retry.Context = ctx.Cancel.CancelContext()
+
 	opts := deploy.Options{
 		Events:   events,
 		Parallel: res.Options.Parallel,
+		Targets:  res.Options.Targets,
+		Retry:    retry,
+
+		RefreshProperties: res.Options.RefreshProperties,
+
+		Transformations:     proj.Transformations,
+		BlueGreen:           proj.BlueGreen,
+		ProviderParallelism: providerParallelism,
+	}
+
+	if plan := res.Options.Plan; plan != nil {
+		plan.reset()
 	}
 
 	// Fetch a plan iterator and keep walking it until we are done.
@@ -223,8 +252,13 @@ func (res *planResult) Walk(ctx *Context, events deploy.Events, preview bool) (d
 				return
 			}
 
-			// Perform any per-step actions.
-			rst, err = iter.Apply(step, preview)
+			// Perform any per-step actions.  Once the program has finished, the remaining deletes are grouped into
+			// waves of independent resources, and if operations may run in parallel, each wave is applied at once.
+			steps := []deploy.Step{step}
+			if res.Options.Parallel > 1 {
+				steps = append(steps, iter.NextDeleteWave()...)
+			}
+			step, rst, err = applySteps(iter, steps, preview, res.Options.Parallel)
 
 			// If an error occurred, exit early.
 			if err != nil {
@@ -238,8 +272,12 @@ func (res *planResult) Walk(ctx *Context, events deploy.Events, preview bool) (d
 			}
 		}
 
-		// Finally, return a summary and the resulting plan information.
+		// Finally, return a summary and the resulting plan information.  If held to a saved plan, every step in it
+		// must have been performed.
 		rst, err = resource.StatusOK, nil
+		if plan := res.Options.Plan; plan != nil {
+			err = plan.checkExecuted()
+		}
 	}()
 
 	select {
@@ -251,6 +289,42 @@ func (res *planResult) Walk(ctx *Context, events deploy.Events, preview bool) (d
 	}
 }
 
+// applySteps applies the given steps, none of which may depend on another, with at most the given number running in
+// parallel.  It returns the step that failed, if any did, along with its status and error; otherwise, it returns the
+// last step.
+func applySteps(iter *deploy.PlanIterator, steps []deploy.Step, preview bool,
+	parallel int) (deploy.Step, resource.Status, error) {
+
+	if len(steps) == 1 {
+		rst, err := iter.Apply(steps[0], preview)
+		return steps[0], rst, err
+	}
+
+	statuses := make([]resource.Status, len(steps))
+	errs := make([]error, len(steps))
+	sem := make(chan bool, parallel)
+	var wg sync.WaitGroup
+	for i, step := range steps {
+		wg.Add(1)
+		sem <- true
+		go func(i int, step deploy.Step) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			statuses[i], errs[i] = iter.Apply(step, preview)
+		}(i, step)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return steps[i], statuses[i], err
+		}
+	}
+	return steps[len(steps)-1], resource.StatusOK, nil
+}
+
 func (res *planResult) Close() error {
 	return res.Plugctx.Close()
 }
@@ -274,15 +348,18 @@ func printPlan(ctx *Context, result *planResult, dryRun bool) (ResourceChanges,
 
 	// Emit an event with a summary of operation counts.
 	changes := ResourceChanges(actions.Ops)
-	result.Options.Events.previewSummaryEvent(changes)
+	result.Options.Events.previewSummaryEvent(changes, actions.Estimated)
 	return changes, nil
 }
 
 type planActions struct {
-	Refresh bool
-	Ops     map[deploy.StepOp]int
-	Opts    planOptions
-	Seen    map[resource.URN]deploy.Step
+	Refresh   bool
+	Ops       map[deploy.StepOp]int
+	Opts      planOptions
+	Seen      map[resource.URN]deploy.Step
+	Estimated time.Duration // the sum of the estimated durations of the previewed steps.
+
+	lock sync.Mutex // serializes the events of steps applied in parallel.
 }
 
 func newPlanActions(opts planOptions) *planActions {
@@ -294,13 +371,36 @@ func newPlanActions(opts planOptions) *planActions {
 }
 
 func (acts *planActions) OnResourceStepPre(step deploy.Step) (interface{}, error) {
+	acts.lock.Lock()
+	defer acts.lock.Unlock()
+
+	if plan := acts.Opts.Plan; plan != nil {
+		if err := plan.checkStep(step); err != nil {
+			return nil, err
+		}
+	}
+	if plan := acts.Opts.RecordPlan; plan != nil {
+		plan.recordStep(step)
+	}
+	if report := acts.Opts.DriftReport; report != nil {
+		report.recordStep(step, acts.Opts.Events)
+	}
+	if explanation := acts.Opts.Explain; explanation != nil {
+		explanation.recordStep(step, acts.Opts.Events)
+	}
+
 	acts.Seen[step.URN()] = step
-	acts.Opts.Events.resourcePreEvent(step, true /*planning*/, acts.Opts.Debug)
+	estimate := acts.Opts.Durations.Estimate(step.Type(), step.Op())
+	acts.Estimated += estimate
+	acts.Opts.Events.resourcePreEvent(step, true /*planning*/, acts.Opts.Debug, estimate)
 	return nil, nil
 }
 
 func (acts *planActions) OnResourceStepPost(ctx interface{},
 	step deploy.Step, status resource.Status, err error) error {
+	acts.lock.Lock()
+	defer acts.lock.Unlock()
+
 	assertSeen(acts.Seen, step)
 
 	if err != nil {
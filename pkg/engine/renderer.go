@@ -0,0 +1,119 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/tokens"
+)
+
+// RenderContext describes where and how a step or property is being rendered.
+type RenderContext struct {
+	Type     tokens.Type   // the type of the resource being rendered.
+	Op       deploy.StepOp // the operation being performed on the resource.
+	Indent   int           // the indentation level at which the step or property is being rendered.
+	Planning bool          // true if we are previewing rather than performing an update.
+	Summary  bool          // true if only changed properties should be rendered.
+	Debug    bool          // true if debugging output should be included.
+	Options  DiffOptions   // the options controlling how diffs are rendered.
+}
+
+// Renderer renders the details of resource steps for display.  Each method may decline to render anything by
+// returning false, in which case the default rendering is used; this lets a renderer customize just the parts of the
+// display it cares about.  All colorization should be done using the directives in the pkg/diag/colors package.
+type Renderer interface {
+	// RenderStep renders the property details of a step, which follow its summary.
+	RenderStep(step StepEventMetadata, ctx RenderContext) (string, bool)
+	// RenderPropertyDiff renders a change to the property at the given path.  The result is written directly after the
+	// property's title, and must end in a newline.
+	RenderPropertyDiff(path resource.PropertyPath,
+		old resource.PropertyValue, new resource.PropertyValue, ctx RenderContext) (string, bool)
+	// RenderAsset renders the asset at the given path.  The result is written directly after the property's title.
+	RenderAsset(path resource.PropertyPath, asset *resource.Asset, ctx RenderContext) (string, bool)
+}
+
+// BaseRenderer is a Renderer that declines to render anything, and so always falls back to the default rendering.
+// It may be embedded by renderers that only wish to override some of the Renderer methods.
+type BaseRenderer struct{}
+
+var _ Renderer = BaseRenderer{}
+
+// RenderStep always uses the default rendering.
+func (BaseRenderer) RenderStep(step StepEventMetadata, ctx RenderContext) (string, bool) {
+	return "", false
+}
+
+// RenderPropertyDiff always uses the default rendering.
+func (BaseRenderer) RenderPropertyDiff(path resource.PropertyPath,
+	old resource.PropertyValue, new resource.PropertyValue, ctx RenderContext) (string, bool) {
+	return "", false
+}
+
+// RenderAsset always uses the default rendering.
+func (BaseRenderer) RenderAsset(path resource.PropertyPath, asset *resource.Asset, ctx RenderContext) (string, bool) {
+	return "", false
+}
+
+var renderersLock sync.RWMutex
+var renderers = make(map[tokens.Type]Renderer)
+
+// RegisterRenderer registers a renderer to use when displaying resources of the given type, replacing any renderer
+// previously registered for it.  Passing a nil renderer restores the default rendering for the type.
+func RegisterRenderer(t tokens.Type, r Renderer) {
+	renderersLock.Lock()
+	defer renderersLock.Unlock()
+
+	if r == nil {
+		delete(renderers, t)
+	} else {
+		renderers[t] = r
+	}
+}
+
+// getRenderer returns the renderer registered for the given type, or nil if there isn't one.
+func getRenderer(t tokens.Type) Renderer {
+	renderersLock.RLock()
+	defer renderersLock.RUnlock()
+	return renderers[t]
+}
+
+// renderState records the renderer to use while rendering a step, and the context in which it is being rendered.
+type renderState struct {
+	renderer Renderer
+	ctx      RenderContext
+}
+
+// renderWith gives the renderer in the given options, if any, a chance to render something at the given indentation
+// level.  If it does, the result is written to the buffer and true is returned.
+func renderWith(b *bytes.Buffer, indent int, opts DiffOptions,
+	render func(r Renderer, ctx RenderContext) (string, bool)) bool {
+
+	if opts.render == nil {
+		return false
+	}
+	ctx := opts.render.ctx
+	ctx.Indent = indent
+	ctx.Options = opts
+
+	s, ok := render(opts.render.renderer, ctx)
+	if ok {
+		writeString(b, s)
+	}
+	return ok
+}
@@ -0,0 +1,140 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/resource/plugin"
+	"github.com/pulumi/pulumi/pkg/util/contract"
+	"github.com/pulumi/pulumi/pkg/util/logging"
+)
+
+// RepairAction is a way of resolving an operation that an interrupted update left pending.
+type RepairAction string
+
+const (
+	// RepairAdopt records the resource's live state, as read from its provider, in the snapshot.
+	RepairAdopt RepairAction = "adopt"
+	// RepairDelete removes the resource from the snapshot, because it no longer exists.
+	RepairDelete RepairAction = "delete"
+	// RepairClear forgets the pending operation, leaving the snapshot's resources as they are.
+	RepairClear RepairAction = "clear"
+)
+
+// PendingOperationStatus describes the actual state of a resource whose operation was left pending, as reported by
+// its provider, along with the ways in which the operation may be resolved.
+type PendingOperationStatus struct {
+	Operation deploy.Operation     // the pending operation.
+	State     *resource.State      // the resource's state in the snapshot, if it has one.
+	Known     bool                 // true if the resource's provider could be asked whether the resource exists.
+	Live      resource.PropertyMap // the resource's live state, or nil if it doesn't exist (or isn't Known).
+
+	Actions     []RepairAction // the actions that may be taken to resolve the operation.
+	Recommended RepairAction   // the action that best reconciles the snapshot with the resource's actual state.
+}
+
+// InspectPendingOperations asks the provider of each resource whose operation was left pending in a snapshot whether
+// the resource exists, and if so, what its state is.  A resource that was being created can only be looked up if its
+// ID was recorded; otherwise, whether it exists can't be known.
+func InspectPendingOperations(plugctx *plugin.Context, snap *deploy.Snapshot) ([]PendingOperationStatus, error) {
+	if snap == nil {
+		return nil, nil
+	}
+
+	var statuses []PendingOperationStatus
+	for _, op := range snap.PendingOperations {
+		urn := op.Resource.URN
+		logging.V(7).Infof("Inspecting pending %v operation on '%v'", op.Type, urn)
+
+		status := PendingOperationStatus{Operation: op}
+		if op.Type != deploy.OperationTypeCreating {
+			status.State = findPendingOperationState(snap, op)
+		}
+
+		res := status.State
+		if res == nil {
+			res = op.Resource
+		}
+		if res.Custom && res.ID != "" {
+			provider, err := plugctx.Host.Provider(res.Type.Package(), nil)
+			if err != nil {
+				return nil, errors.Wrapf(err, "fetching provider to inspect %s", urn)
+			}
+			live, err := provider.Read(urn, res.ID, res.Outputs)
+			if err != nil {
+				return nil, errors.Wrapf(err, "reading %s's state", urn)
+			}
+			status.Known, status.Live = true, live
+		}
+
+		// Work out what can be done about the operation.  A resource can only be adopted if it is known to exist, and
+		// only removed if it is in the snapshot and nothing else depends on it.
+		canDelete := status.State != nil && !hasDependents(snap, status.State)
+		switch {
+		case status.Known && status.Live != nil:
+			status.Actions = []RepairAction{RepairAdopt}
+			status.Recommended = RepairAdopt
+		case status.Known && canDelete:
+			status.Actions = []RepairAction{RepairDelete}
+			status.Recommended = RepairDelete
+		default:
+			status.Recommended = RepairClear
+		}
+		if canDelete && status.Recommended != RepairDelete {
+			status.Actions = append(status.Actions, RepairDelete)
+		}
+		status.Actions = append(status.Actions, RepairClear)
+
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// RepairPendingOperation resolves a pending operation in a snapshot using the given action, which must be one of the
+// actions that the operation's status allows.  The snapshot is modified in place.
+func RepairPendingOperation(snap *deploy.Snapshot, status PendingOperationStatus, action RepairAction) {
+	contract.Require(snap != nil, "snap")
+
+	switch action {
+	case RepairAdopt:
+		contract.Assert(status.Known && status.Live != nil)
+		if status.State != nil {
+			status.State.Outputs = status.Live
+		} else {
+			// A resource whose creation was interrupted isn't in the snapshot yet, so add it.
+			res := *status.Operation.Resource
+			res.Outputs = status.Live
+			snap.Resources = append(snap.Resources, &res)
+		}
+	case RepairDelete:
+		contract.Assert(status.State != nil)
+		removeState(snap, status.State)
+	case RepairClear:
+		// Nothing to do but forget the operation.
+	default:
+		contract.Failf("unrecognized repair action: %v", action)
+	}
+
+	operations := make([]deploy.Operation, 0, len(snap.PendingOperations))
+	for _, op := range snap.PendingOperations {
+		if op.Resource != status.Operation.Resource {
+			operations = append(operations, op)
+		}
+	}
+	snap.PendingOperations = operations
+}
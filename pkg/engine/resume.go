@@ -0,0 +1,124 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/diag"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/resource/plugin"
+	"github.com/pulumi/pulumi/pkg/util/logging"
+)
+
+// reconcilePendingOperations reconciles the operations that an interrupted update left pending in a snapshot with the
+// actual state of their resources, as reported by their providers:
+//
+//   - a resource that was being deleted is removed from the snapshot if it no longer exists;
+//   - a resource that was being updated has its outputs refreshed, or is removed if it no longer exists;
+//   - a resource that was being created can't be looked up, as its ID was never recorded, so a warning is issued.
+//
+// The snapshot is modified in place.  Unless the update is resuming, the pending operations are only reported.
+func reconcilePendingOperations(plugctx *plugin.Context, snap *deploy.Snapshot, opts planOptions) error {
+	if snap == nil || len(snap.PendingOperations) == 0 {
+		return nil
+	}
+	if !opts.Resume {
+		opts.Diag.Warningf(diag.GetPendingOperationsWarning(""), len(snap.PendingOperations))
+		return nil
+	}
+
+	for _, op := range snap.PendingOperations {
+		urn := op.Resource.URN
+		logging.V(7).Infof("Reconciling pending %v operation on '%v'", op.Type, urn)
+
+		if op.Type == deploy.OperationTypeCreating {
+			opts.Diag.Warningf(diag.GetPendingCreateWarning(urn), urn)
+			continue
+		}
+
+		// Find the state that the operation was working on in the snapshot.  If it's gone, there's nothing to do.
+		state := findPendingOperationState(snap, op)
+		if state == nil || !state.Custom {
+			continue
+		}
+
+		provider, err := plugctx.Host.Provider(state.Type.Package(), nil)
+		if err != nil {
+			return errors.Wrapf(err, "fetching provider to reconcile %s", urn)
+		}
+		live, err := provider.Read(urn, state.ID, state.Outputs)
+		if err != nil {
+			return errors.Wrapf(err, "reading %s's state", urn)
+		}
+
+		switch {
+		case live == nil && hasDependents(snap, state):
+			opts.Diag.Infof(diag.GetPendingOperationReconciledInfo(urn), urn,
+				"the resource no longer exists, but other resources depend on it, so it was left in place")
+		case live == nil:
+			removeState(snap, state)
+			opts.Diag.Infof(diag.GetPendingOperationReconciledInfo(urn), urn, "the resource no longer exists")
+		case op.Type == deploy.OperationTypeUpdating:
+			state.Outputs = live
+			opts.Diag.Infof(diag.GetPendingOperationReconciledInfo(urn), urn, "refreshed the resource's state")
+		default:
+			opts.Diag.Infof(diag.GetPendingOperationReconciledInfo(urn), urn, "the resource still exists")
+		}
+	}
+
+	snap.PendingOperations = nil
+	return nil
+}
+
+// findPendingOperationState returns the state in a snapshot that a pending update or delete was operating on, if any.
+func findPendingOperationState(snap *deploy.Snapshot, op deploy.Operation) *resource.State {
+	for _, res := range snap.Resources {
+		if res.URN == op.Resource.URN && res.ID == op.Resource.ID && res.Delete == op.Resource.Delete {
+			return res
+		}
+	}
+	return nil
+}
+
+// hasDependents returns true if any other resource in a snapshot depends on, or is a child of, the given state.
+func hasDependents(snap *deploy.Snapshot, state *resource.State) bool {
+	for _, res := range snap.Resources {
+		if res == state {
+			continue
+		}
+		if res.Parent == state.URN {
+			return true
+		}
+		for _, dep := range res.Dependencies {
+			if dep == state.URN {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// removeState removes a state from a snapshot's resources.
+func removeState(snap *deploy.Snapshot, state *resource.State) {
+	resources := make([]*resource.State, 0, len(snap.Resources))
+	for _, res := range snap.Resources {
+		if res != state {
+			resources = append(resources, res)
+		}
+	}
+	snap.Resources = resources
+}
@@ -0,0 +1,68 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"github.com/pulumi/pulumi/pkg/diag"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+)
+
+// rollback makes a best effort to undo the steps that a failed update applied, returning each resource that was
+// changed to the state recorded for it before the update began: created resources are deleted, and updated resources
+// are updated back to their previous inputs.  Each step that can't be undone is reported, as is a summary of the
+// rollback as a whole.  Rolling back stops at the first rollback step that fails.
+//
+// The step that failed the update is also given, if known.  If it left its resource in an unknown state, as when its
+// operation timed out and was canceled, it too is reported as a change that can't be undone.
+func rollback(ctx *Context, info *planContext, plan *deploy.Plan, applied []deploy.Step, failedStep deploy.Step,
+	failedStatus resource.Status, opts planOptions) {
+
+	steps, failed := deploy.NewRollbackSteps(plan, applied)
+	if failedStep != nil && failedStatus == resource.StatusUnknown {
+		failed = append(failed, failedStep)
+	}
+	for _, step := range failed {
+		opts.Diag.Warningf(diag.GetRollbackFailedWarning(step.URN()), step.Op(), step.URN())
+	}
+
+	// Rollback steps are, of course, not part of any saved plan that the update was held to.
+	opts.Plan = nil
+	opts.Rollback = false
+	actions := newUpdateActions(ctx, info.Update, opts)
+
+	var rolledBack int
+	for _, step := range steps {
+		if ctx.Cancel.CancelErr() != nil {
+			break
+		}
+
+		payload, err := actions.OnResourceStepPre(step)
+		if err == nil {
+			var status resource.Status
+			status, err = step.Apply(false)
+			if postErr := actions.OnResourceStepPost(payload, step, status, err); err == nil {
+				err = postErr
+			}
+		}
+		if err != nil {
+			opts.Diag.Errorf(diag.Message(step.URN(), err.Error()))
+			break
+		}
+		rolledBack++
+	}
+
+	opts.Diag.Infof(diag.GetRollbackSummaryInfo(""), rolledBack, len(steps)-rolledBack+len(failed))
+}
@@ -0,0 +1,133 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+)
+
+// numberUnit is the unit in which a numeric property is measured, as guessed from the property's name.
+type numberUnit int
+
+const (
+	unitNone         numberUnit = iota // a plain number, such as a count.
+	unitMilliseconds                   // a duration in milliseconds, e.g. `timeoutMs`.
+	unitSeconds                        // a duration in seconds, e.g. `timeout` or `ttl`.
+	unitMinutes                        // a duration in minutes, e.g. `retentionMinutes`.
+	unitBytes                          // a size in bytes, e.g. `maxSizeBytes`.
+	unitMegabytes                      // a size in megabytes, e.g. `memorySize`.
+	unitGigabytes                      // a size in gigabytes, e.g. `diskSizeGb`.
+)
+
+// guessNumberUnit guesses the unit of the numeric property at the given path from the name of the property.
+func guessNumberUnit(path resource.PropertyPath) numberUnit {
+	// Use the innermost name in the path, skipping over any array indices.
+	var name string
+	for i := len(path) - 1; i >= 0; i-- {
+		if _, err := strconv.Atoi(path[i]); err != nil {
+			name = path[i]
+			break
+		}
+	}
+	lower := strings.ToLower(name)
+
+	switch {
+	case strings.HasSuffix(name, "Ms") || strings.HasSuffix(lower, "millis") ||
+		strings.HasSuffix(lower, "milliseconds"):
+		return unitMilliseconds
+	case strings.HasSuffix(lower, "seconds") || strings.HasSuffix(lower, "timeout") ||
+		strings.HasSuffix(lower, "ttl") || strings.HasSuffix(lower, "interval"):
+		return unitSeconds
+	case strings.HasSuffix(lower, "minutes"):
+		return unitMinutes
+	case strings.HasSuffix(lower, "bytes"):
+		return unitBytes
+	case strings.HasSuffix(lower, "mb") || strings.HasSuffix(lower, "memorysize") || lower == "memory":
+		return unitMegabytes
+	case strings.HasSuffix(lower, "gb") || strings.HasSuffix(lower, "gigabytes"):
+		return unitGigabytes
+	default:
+		return unitNone
+	}
+}
+
+// format renders a number measured in this unit in a human-friendly form, or returns false if the number is better
+// left as-is.
+func (u numberUnit) format(v float64) (string, bool) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return "", false
+	}
+
+	switch u {
+	case unitMilliseconds:
+		return formatDuration(v * float64(time.Millisecond)), true
+	case unitSeconds:
+		return formatDuration(v * float64(time.Second)), true
+	case unitMinutes:
+		return formatDuration(v * float64(time.Minute)), true
+	case unitBytes:
+		return formatSize(v), true
+	case unitMegabytes:
+		return formatSize(v * (1 << 20)), true
+	case unitGigabytes:
+		return formatSize(v * (1 << 30)), true
+	default:
+		return "", false
+	}
+}
+
+// formatDuration renders a duration given in nanoseconds, e.g. "1h30m0s".
+func formatDuration(ns float64) string {
+	if math.Abs(ns) >= math.MaxInt64 {
+		return fmt.Sprintf("%gs", ns/float64(time.Second))
+	}
+	return time.Duration(ns).String()
+}
+
+// formatSize renders a size given in bytes using binary units, e.g. "1.5 GiB".
+func formatSize(bytes float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}
+	i := 0
+	for math.Abs(bytes) >= 1024 && i < len(units)-1 {
+		bytes /= 1024
+		i++
+	}
+	return strconv.FormatFloat(bytes, 'f', -1, 64) + " " + units[i]
+}
+
+// formatNumberChange renders an annotation describing the change from one number to another: the signed difference
+// between them and, if the property appears to be a duration or size, the two values in human-friendly units.  For
+// example, a change in `timeout` from 30 to 300 is annotated as "(+270, 30s => 5m0s)".
+func formatNumberChange(old float64, new float64, path resource.PropertyPath) string {
+	delta := new - old
+	parts := []string{strconv.FormatFloat(delta, 'f', -1, 64)}
+	if delta > 0 {
+		parts[0] = "+" + parts[0]
+	}
+
+	unit := guessNumberUnit(path)
+	if oldText, ok := unit.format(old); ok {
+		if newText, ok := unit.format(new); ok {
+			parts = append(parts, oldText+" => "+newText)
+		}
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
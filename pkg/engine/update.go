@@ -15,6 +15,7 @@
 package engine
 
 import (
+	"sync"
 	"time"
 
 	"github.com/pulumi/pulumi/pkg/diag"
@@ -33,18 +34,76 @@ type UpdateOptions struct {
 	// the degree of parallelism for resource operations (<=1 for serial).
 	Parallel int
 
+	// how provider operations that fail with transient errors are retried.
+	Retry deploy.RetryPolicy
+
 	// true if debugging output it enabled
 	Debug bool
+
+	// the number of unchanged lines to show around each change when diffing text; nil means DefaultDiffContextLines.
+	DiffContextLines *int
+
+	// an optional set of property path patterns (e.g. `password` or `**.secretKey`) whose values are treated as
+	// secrets, and are therefore masked in all diffs and events emitted by the engine.
+	SecretPatterns []string
+
+	// an optional set of resource URNs to which the update is restricted.  Resources that aren't targeted are left
+	// exactly as they are, except for those that must be deleted because they depend on a deleted target.
+	Targets []resource.URN
+
+	// an optional set of property paths (e.g. `tags` or `rules.0.cidr`) to which a refresh is restricted: only the
+	// values at these paths are updated from each resource's provider, and its other outputs are left as they were.
+	RefreshProperties []string
+
+	// an optional plan to record the steps proposed by a preview into.
+	RecordPlan *UpdatePlan
+
+	// an optional plan, saved by an earlier preview, to which the update is held: any step that the plan does not
+	// contain is refused.
+	Plan *UpdatePlan
+
+	// if non-nil, a preview detects drift rather than previewing the program: the live state of each resource is read
+	// from its provider and compared against the checkpoint, and any differences are recorded in this report.  The
+	// stack's state is never modified.
+	DriftReport *DriftReport
+
+	// if non-nil, a preview records the steps it plans into this explanation, so that the reasons for the operation
+	// planned for a single resource can be shown.
+	Explain *Explanation
+
+	// true if operations left pending by an interrupted update should be reconciled with the actual state of their
+	// resources before proceeding.
+	Resume bool
+
+	// true if, should any step of an update fail, the changes that the update has already made should be rolled back
+	// (as far as possible) to the resources' previous states.
+	Rollback bool
+
+	// an optional set of existing resources to import into the stack.  When set, the stack's program isn't run: an
+	// update simply adds these resources to the checkpoint.
+	Imports []ImportSpec
+
+	// true if a destroy should remove the protection from any protected resources it would delete, rather than
+	// refusing to run.
+	ForceUnprotect bool
+
+	// an optional record of how long past operations took, used to estimate the duration of each step.  The
+	// durations of the operations performed by an update are added to it.
+	Durations *OperationDurations
+
+	// an optional reader of the outputs of other stacks, with which the program may reference them.
+	StackOutputs deploy.StackOutputsReader
 }
 
 // ResourceChanges contains the aggregate resource changes by operation type.
 type ResourceChanges map[deploy.StepOp]int
 
-// HasChanges returns true if there are any non-same changes in the resulting summary.
+// HasChanges returns true if there are any non-same changes in the resulting summary.  Reads of external resources
+// change nothing, and so are not counted.
 func (changes ResourceChanges) HasChanges() bool {
 	var c int
 	for op, count := range changes {
-		if op != deploy.OpSame {
+		if op != deploy.OpSame && op != deploy.OpRead {
 			c += count
 		}
 	}
@@ -63,7 +122,31 @@ func Update(u UpdateInfo, ctx *Context, opts UpdateOptions, dryRun bool) (Resour
 	}
 	defer info.Close()
 
-	emitter := makeEventEmitter(ctx.Events, u)
+	emitter := makeEventEmitter(ctx.Events, u, opts)
+
+	// When detecting drift, refresh the stack's resources instead of running its program.  This is only ever a
+	// preview, so that the refreshed state isn't saved.
+	if opts.DriftReport != nil {
+		contract.Assertf(dryRun, "drift can only be detected during a preview")
+		return update(ctx, info, planOptions{
+			UpdateOptions: opts,
+			SkipOutputs:   true,
+			SourceFunc:    newRefreshSource,
+			Events:        emitter,
+			Diag:          newEventSink(emitter),
+		}, true)
+	}
+
+	// When importing, read the existing resources rather than running the stack's program.
+	if len(opts.Imports) > 0 {
+		return importResources(ctx, info, planOptions{
+			UpdateOptions: opts,
+			SourceFunc:    newImportSource,
+			Events:        emitter,
+			Diag:          newEventSink(emitter),
+		}, dryRun)
+	}
+
 	return update(ctx, info, planOptions{
 		UpdateOptions: opts,
 		SourceFunc:    newUpdateSource,
@@ -98,7 +181,7 @@ func newUpdateSource(
 		Pwd:     pwd,
 		Program: main,
 		Target:  target,
-	}, dryRun), nil
+	}, opts.StackOutputs, dryRun), nil
 }
 
 func update(ctx *Context, info *planContext, opts planOptions, dryRun bool) (ResourceChanges, error) {
@@ -131,7 +214,7 @@ func update(ctx *Context, info *planContext, opts planOptions, dryRun bool) (Res
 			// Walk the plan, reporting progress and executing the actual operations as we go.
 			start := time.Now()
 			actions := newUpdateActions(ctx, info.Update, opts)
-			summary, step, _, err := result.Walk(ctx, actions, false)
+			summary, step, status, err := result.Walk(ctx, actions, false)
 			if err != nil && summary == nil {
 				// Something went wrong, and no changes were made.
 				return resourceChanges, err
@@ -145,6 +228,11 @@ func update(ctx *Context, info *planContext, opts planOptions, dryRun bool) (Res
 				}
 
 				opts.Diag.Errorf(diag.Message(failedUrn, err.Error()))
+
+				// If asked to, undo the changes that were made before the failure.  A cancelled update is left as is.
+				if opts.Rollback && ctx.Cancel.CancelErr() == nil {
+					rollback(ctx, info, result.Plan, actions.Applied, step, status, opts)
+				}
 			}
 
 			// Print out the total number of steps performed (and their kinds), the duration, and any summary info.
@@ -176,9 +264,13 @@ type updateActions struct {
 	Steps        int
 	Ops          map[deploy.StepOp]int
 	Seen         map[resource.URN]deploy.Step
+	Applied      []deploy.Step
 	MaybeCorrupt bool
 	Update       UpdateInfo
 	Opts         planOptions
+	Started      map[deploy.Step]time.Time // the time at which each step in progress started.
+
+	lock sync.Mutex // serializes the events of steps applied in parallel.
 }
 
 func newUpdateActions(context *Context, u UpdateInfo, opts planOptions) *updateActions {
@@ -188,14 +280,27 @@ func newUpdateActions(context *Context, u UpdateInfo, opts planOptions) *updateA
 		Seen:    make(map[resource.URN]deploy.Step),
 		Update:  u,
 		Opts:    opts,
+		Started: make(map[deploy.Step]time.Time),
 	}
 }
 
 func (acts *updateActions) OnResourceStepPre(step deploy.Step) (interface{}, error) {
+	acts.lock.Lock()
+	defer acts.lock.Unlock()
+
+	// Refuse to perform any operation that wasn't planned.
+	if plan := acts.Opts.Plan; plan != nil {
+		if err := plan.checkStep(step); err != nil {
+			return nil, err
+		}
+	}
+
 	// Ensure we've marked this step as observed.
 	acts.Seen[step.URN()] = step
 
-	acts.Opts.Events.resourcePreEvent(step, false /*planning*/, acts.Opts.Debug)
+	acts.Started[step] = time.Now()
+	estimate := acts.Opts.Durations.Estimate(step.Type(), step.Op())
+	acts.Opts.Events.resourcePreEvent(step, false /*planning*/, acts.Opts.Debug, estimate)
 
 	// Inform the snapshot service that we are about to perform a step.
 	return acts.Context.SnapshotManager.BeginMutation(step)
@@ -204,6 +309,9 @@ func (acts *updateActions) OnResourceStepPre(step deploy.Step) (interface{}, err
 func (acts *updateActions) OnResourceStepPost(ctx interface{},
 	step deploy.Step, status resource.Status, err error) error {
 
+	acts.lock.Lock()
+	defer acts.lock.Unlock()
+
 	assertSeen(acts.Seen, step)
 
 	// If we've already been terminated, exit without writing the checkpoint. We explicitly want to leave the
@@ -212,7 +320,9 @@ func (acts *updateActions) OnResourceStepPost(ctx interface{},
 		return nil
 	}
 
-	// Report the result of the step.
+	// Report the result of the step, recording how long it took if it succeeded.
+	started := acts.Started[step]
+	delete(acts.Started, step)
 	stepop := step.Op()
 	if err != nil {
 		if status == resource.StatusUnknown {
@@ -228,6 +338,14 @@ func (acts *updateActions) OnResourceStepPost(ctx interface{},
 			acts.Steps++
 			acts.Ops[stepop]++
 		}
+		if step.Res().Custom {
+			acts.Opts.Durations.Record(step.Type(), stepop, time.Since(started))
+		}
+
+		// Remember the step, should it need to be rolled back.
+		if acts.Opts.Rollback {
+			acts.Applied = append(acts.Applied, step)
+		}
 
 		// Also show outputs here for custom resources, since there might be some from the initial registration. We do
 		// not show outputs for component resources at this point: any that exist must be from a previous execution of
@@ -0,0 +1,262 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/resource/plugin"
+	"github.com/pulumi/pulumi/pkg/resource/stack"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+	"github.com/pulumi/pulumi/pkg/util/contract"
+)
+
+// PlannedStep is a single operation proposed by a preview.
+type PlannedStep struct {
+	// the URN of the resource the step operates on.
+	URN resource.URN `json:"urn"`
+	// the kind of operation.
+	Op deploy.StepOp `json:"op"`
+	// the resource's new inputs, for steps that produce a new state.  Values that weren't known at the time of the
+	// preview are recorded as unknown, and match any value when the plan is applied.
+	Inputs map[string]interface{} `json:"inputs,omitempty"`
+	// the resource's inputs and outputs before the step, for steps that operate on an existing resource.  A plan is
+	// only valid for the state it was made against, so a step whose resource has since changed is refused.
+	OldInputs  map[string]interface{} `json:"oldInputs,omitempty"`
+	OldOutputs map[string]interface{} `json:"oldOutputs,omitempty"`
+
+	executed bool // true once an update has performed this step.
+}
+
+// UpdatePlan is the set of operations proposed by a preview, which may be saved and later applied by an update.  An
+// update that is given a plan refuses to perform any operation that the plan does not contain, so that what is
+// deployed is exactly what was reviewed.
+type UpdatePlan struct {
+	Steps []PlannedStep `json:"steps"`
+
+	lock sync.Mutex
+}
+
+// recordStep adds a step proposed by a preview to the plan.  Steps that leave their resource unchanged, including reads
+// of external resources, are not recorded, as they are always permitted.
+func (p *UpdatePlan) recordStep(step deploy.Step) {
+	if step.Op() == deploy.OpSame || step.Op() == deploy.OpRead {
+		return
+	}
+
+	planned := PlannedStep{URN: step.URN(), Op: step.Op()}
+	if new := step.New(); new != nil {
+		planned.Inputs = serializePlannedProperties(new.Inputs)
+	}
+	if old := step.Old(); old != nil {
+		planned.OldInputs = serializePlannedProperties(old.Inputs)
+		planned.OldOutputs = serializePlannedProperties(old.Outputs)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.Steps = append(p.Steps, planned)
+}
+
+// checkStep returns an error if the given step is not one of the operations in the plan, if the resource it operates
+// on has changed since the plan was made, or if it would give its resource inputs other than those that were planned.
+// Otherwise, the planned step is marked as executed.
+func (p *UpdatePlan) checkStep(step deploy.Step) error {
+	if step.Op() == deploy.OpSame || step.Op() == deploy.OpRead {
+		return nil
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var err error
+	for i := range p.Steps {
+		planned := &p.Steps[i]
+		if planned.URN != step.URN() || planned.Op != step.Op() || planned.executed {
+			continue
+		}
+
+		if old := step.Old(); old != nil {
+			diffs := append(diffPlannedProperties(planned.OldInputs, old.Inputs),
+				diffPlannedProperties(planned.OldOutputs, old.Outputs)...)
+			if len(diffs) != 0 {
+				err = errors.Errorf("refusing to %s resource '%s': its state has changed since the plan was made (%s)",
+					step.Op(), step.URN(), strings.Join(uniqueStrings(diffs), ", "))
+				continue
+			}
+		}
+		if new := step.New(); new != nil {
+			if diffs := diffPlannedProperties(planned.Inputs, new.Inputs); len(diffs) != 0 {
+				err = errors.Errorf("refusing to %s resource '%s': its inputs differ from the plan (%s)",
+					step.Op(), step.URN(), strings.Join(diffs, ", "))
+				continue
+			}
+		}
+
+		planned.executed = true
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+	return errors.Errorf("refusing to %s resource '%s': the operation is not part of the plan", step.Op(), step.URN())
+}
+
+// reset marks every step in the plan as not yet performed, ready for it to be applied.
+func (p *UpdatePlan) reset() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for i := range p.Steps {
+		p.Steps[i].executed = false
+	}
+}
+
+// checkExecuted returns an error if any step in the plan was not performed, so that an update that carries out only
+// part of a plan does not appear to have succeeded.
+func (p *UpdatePlan) checkExecuted() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var missing []string
+	for _, planned := range p.Steps {
+		if !planned.executed {
+			missing = append(missing, fmt.Sprintf("%s '%s'", planned.Op, planned.URN))
+		}
+	}
+	if len(missing) != 0 {
+		return errors.Errorf("the update did not perform %d %s of the plan: %s",
+			len(missing), cmdutil.Plural("step", len(missing)), strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// serializePlannedProperties serializes a resource's inputs for inclusion in a plan.  Unlike the usual serialization,
+// computed values are kept, as the unknown sentinel, so that they can be told apart from values that are absent.
+func serializePlannedProperties(props resource.PropertyMap) map[string]interface{} {
+	dst := make(map[string]interface{})
+	for _, k := range props.StableKeys() {
+		if v := serializePlannedValue(props[k]); v != nil {
+			dst[string(k)] = v
+		}
+	}
+	return normalizePlannedValue(dst).(map[string]interface{})
+}
+
+func serializePlannedValue(prop resource.PropertyValue) interface{} {
+	switch {
+	case prop.IsComputed() || prop.IsOutput():
+		return plugin.UnknownStringValue
+	case prop.IsArray():
+		arr := make([]interface{}, len(prop.ArrayValue()))
+		for i, elem := range prop.ArrayValue() {
+			arr[i] = serializePlannedValue(elem)
+		}
+		return arr
+	case prop.IsObject():
+		return serializePlannedProperties(prop.ObjectValue())
+	default:
+		return stack.SerializePropertyValue(prop)
+	}
+}
+
+// normalizePlannedValue round-trips a serialized value through JSON, so that values recorded during a preview compare
+// equal to those read back from a saved plan.
+func normalizePlannedValue(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	contract.AssertNoError(err)
+	var result interface{}
+	err = json.Unmarshal(b, &result)
+	contract.AssertNoError(err)
+	return result
+}
+
+// diffPlannedProperties returns the names of the properties whose values differ from those in the plan, in sorted
+// order.
+func diffPlannedProperties(planned map[string]interface{}, inputs resource.PropertyMap) []string {
+	actual := serializePlannedProperties(inputs)
+
+	var diffs []string
+	for k, v := range planned {
+		if !plannedValueMatches(v, actual[k]) {
+			diffs = append(diffs, k)
+		}
+	}
+	for k := range actual {
+		if _, has := planned[k]; !has {
+			diffs = append(diffs, k)
+		}
+	}
+	sort.Strings(diffs)
+	return diffs
+}
+
+// uniqueStrings returns the distinct strings in a list, in sorted order.
+func uniqueStrings(strs []string) []string {
+	sort.Strings(strs)
+	var result []string
+	for i, s := range strs {
+		if i == 0 || s != strs[i-1] {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// plannedValueMatches returns true if an actual value is the one that was planned.  Unknown planned values match
+// anything.
+func plannedValueMatches(planned, actual interface{}) bool {
+	if planned == plugin.UnknownStringValue {
+		return true
+	}
+
+	switch p := planned.(type) {
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok || len(a) != len(p) {
+			return false
+		}
+		for i := range p {
+			if !plannedValueMatches(p[i], a[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok || len(a) != len(p) {
+			return false
+		}
+		for k, v := range p {
+			av, has := a[k]
+			if !has || !plannedValueMatches(v, av) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(planned, actual)
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/resource/plugin"
+	"github.com/pulumi/pulumi/pkg/tokens"
+)
+
+// testStep is a step that does nothing, used to exercise the checks made against a plan.
+type testStep struct {
+	op  deploy.StepOp
+	urn resource.URN
+	old *resource.State
+	new *resource.State
+}
+
+func (s *testStep) Apply(preview bool) (resource.Status, error) { return resource.StatusOK, nil }
+func (s *testStep) Op() deploy.StepOp                           { return s.op }
+func (s *testStep) URN() resource.URN                           { return s.urn }
+func (s *testStep) Type() tokens.Type                           { return s.urn.Type() }
+func (s *testStep) Old() *resource.State                        { return s.old }
+func (s *testStep) New() *resource.State                        { return s.new }
+func (s *testStep) Res() *resource.State                        { return s.new }
+func (s *testStep) Logical() bool                               { return true }
+func (s *testStep) Plan() *deploy.Plan                          { return nil }
+
+func newTestStep(op deploy.StepOp, old, new resource.PropertyMap) deploy.Step {
+	urn := resource.URN("urn:pulumi:test::test::pkgA:m:typA::resA")
+	step := &testStep{op: op, urn: urn}
+	if old != nil {
+		step.old = resource.NewState(urn.Type(), urn, true, false, "id", old, old, "", false, nil)
+	}
+	if new != nil {
+		step.new = resource.NewState(urn.Type(), urn, true, false, "", new, nil, "", false, nil)
+	}
+	return step
+}
+
+func TestCheckStep(t *testing.T) {
+	old := resource.NewPropertyMapFromMap(map[string]interface{}{"a": "old"})
+	new := resource.NewPropertyMapFromMap(map[string]interface{}{"a": "new", "b": []interface{}{1, 2}})
+	unknown := resource.PropertyMap{
+		"a": resource.NewStringProperty("new"),
+		"b": resource.MakeComputed(resource.NewStringProperty("")),
+	}
+
+	tests := []struct {
+		name    string
+		planned deploy.Step
+		actual  deploy.Step
+		err     string
+	}{
+		{
+			name:    "same",
+			planned: newTestStep(deploy.OpUpdate, old, new),
+			actual:  newTestStep(deploy.OpSame, old, old),
+		},
+		{
+			name:    "matching update",
+			planned: newTestStep(deploy.OpUpdate, old, new),
+			actual:  newTestStep(deploy.OpUpdate, old, new),
+		},
+		{
+			name:    "unknown input",
+			planned: newTestStep(deploy.OpUpdate, old, unknown),
+			actual:  newTestStep(deploy.OpUpdate, old, new),
+		},
+		{
+			name:    "matching delete",
+			planned: newTestStep(deploy.OpDelete, old, nil),
+			actual:  newTestStep(deploy.OpDelete, old, nil),
+		},
+		{
+			name:    "unplanned operation",
+			planned: newTestStep(deploy.OpUpdate, old, new),
+			actual:  newTestStep(deploy.OpReplace, old, new),
+			err:     "the operation is not part of the plan",
+		},
+		{
+			name:    "different inputs",
+			planned: newTestStep(deploy.OpCreate, nil, old),
+			actual:  newTestStep(deploy.OpCreate, nil, new),
+			err:     "its inputs differ from the plan (a, b)",
+		},
+		{
+			name:    "changed state",
+			planned: newTestStep(deploy.OpUpdate, old, new),
+			actual:  newTestStep(deploy.OpUpdate, new, new),
+			err:     "its state has changed since the plan was made (a, b)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := &UpdatePlan{}
+			plan.recordStep(tt.planned)
+
+			err := plan.checkStep(tt.actual)
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.err)
+			}
+		})
+	}
+}
+
+func TestCheckExecuted(t *testing.T) {
+	old := resource.NewPropertyMapFromMap(map[string]interface{}{"a": "old"})
+	new := resource.NewPropertyMapFromMap(map[string]interface{}{"a": "new"})
+
+	plan := &UpdatePlan{}
+	plan.recordStep(newTestStep(deploy.OpUpdate, old, new))
+	plan.recordStep(newTestStep(deploy.OpDelete, old, nil))
+
+	assert.NoError(t, plan.checkStep(newTestStep(deploy.OpUpdate, old, new)))
+	err := plan.checkExecuted()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "did not perform 1 step of the plan: delete")
+
+	// A step may only be performed once.
+	assert.Error(t, plan.checkStep(newTestStep(deploy.OpUpdate, old, new)))
+
+	assert.NoError(t, plan.checkStep(newTestStep(deploy.OpDelete, old, nil)))
+	assert.NoError(t, plan.checkExecuted())
+
+	plan.reset()
+	assert.Error(t, plan.checkExecuted())
+}
+
+func TestPlannedValueMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		planned interface{}
+		actual  interface{}
+		matches bool
+	}{
+		{"equal strings", "a", "a", true},
+		{"different strings", "a", "b", false},
+		{"equal numbers", 1.0, 1.0, true},
+		{"different types", "1", 1.0, false},
+		{"unknown", plugin.UnknownStringValue, "anything", true},
+		{"unknown absent", plugin.UnknownStringValue, nil, true},
+		{"nil", nil, nil, true},
+		{"nil and value", nil, "a", false},
+		{"equal arrays", []interface{}{"a", 1.0}, []interface{}{"a", 1.0}, true},
+		{"longer array", []interface{}{"a"}, []interface{}{"a", "b"}, false},
+		{"different elements", []interface{}{"a", "b"}, []interface{}{"a", "c"}, false},
+		{"unknown element", []interface{}{"a", plugin.UnknownStringValue}, []interface{}{"a", "c"}, true},
+		{"array and object", []interface{}{}, map[string]interface{}{}, false},
+		{
+			"equal objects",
+			map[string]interface{}{"a": "b", "c": []interface{}{1.0}},
+			map[string]interface{}{"a": "b", "c": []interface{}{1.0}},
+			true,
+		},
+		{
+			"extra key",
+			map[string]interface{}{"a": "b"},
+			map[string]interface{}{"a": "b", "c": "d"},
+			false,
+		},
+		{
+			"missing key",
+			map[string]interface{}{"a": "b", "c": "d"},
+			map[string]interface{}{"a": "b", "e": "d"},
+			false,
+		},
+		{
+			"nested unknown",
+			map[string]interface{}{"a": map[string]interface{}{"b": plugin.UnknownStringValue}},
+			map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{"c"}}},
+			true,
+		},
+		{
+			"nested difference",
+			map[string]interface{}{"a": map[string]interface{}{"b": "c"}},
+			map[string]interface{}{"a": map[string]interface{}{"b": "d"}},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.matches, plannedValueMatches(tt.planned, tt.actual))
+		})
+	}
+}
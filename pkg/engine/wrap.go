@@ -0,0 +1,127 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+
+	"github.com/pulumi/pulumi/pkg/diag/colors"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/util/contract"
+)
+
+// minWrapWidth is the narrowest width we will wrap text to; anything narrower leaves too little room for the values.
+const minWrapWidth = 20
+
+// wrapText wraps each line of some colorized text so that no line is wider than the given width.  Wrapped lines are
+// continued on the next line, indented so that they line up with the start of the property's value.  A width below
+// minWrapWidth leaves the text as-is.
+func wrapText(text string, width int) string {
+	if width < minWrapWidth {
+		return text
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = wrapLine(line, width)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wrapLine wraps a single line of colorized text to the given width.  Widths are measured in terminal columns, so
+// that wide characters (such as CJK ideographs) count as the two columns that they occupy.
+func wrapLine(line string, width int) string {
+	textAndTags := colors.SplitIntoTextAndTags(line)
+
+	var visible string
+	for i := 0; i < len(textAndTags); i += 2 {
+		visible += textAndTags[i]
+	}
+	if runewidth.StringWidth(visible) <= width {
+		return line
+	}
+
+	// Continuation lines line up with the start of the value, just past the property's title, if there's room;
+	// otherwise, they are indented one level beyond the line's own indentation.
+	cont := runewidth.StringWidth(visible) - runewidth.StringWidth(strings.TrimLeft(visible, " +-~*"))
+	if title := strings.Index(visible, ": "); title >= 0 && runewidth.StringWidth(visible[:title])+2 < width/2 {
+		cont = runewidth.StringWidth(visible[:title]) + 2
+	} else if cont+4 < width/2 {
+		cont += 4
+	}
+	indent := strings.Repeat(" ", cont)
+
+	var b bytes.Buffer
+	var activeTag string
+	col := 0
+	for i, textOrTag := range textAndTags {
+		if i%2 == 1 {
+			// Remember the color in effect, so that it can be restored on continuation lines.
+			if textOrTag == colors.Reset {
+				activeTag = ""
+			} else {
+				activeTag = textOrTag
+			}
+			writeString(&b, textOrTag)
+			continue
+		}
+
+		for _, r := range textOrTag {
+			// Wrap before any character that would overflow the line, rather than splitting a wide character.
+			w := runewidth.RuneWidth(r)
+			if col+w > width {
+				if activeTag != "" {
+					writeString(&b, colors.Reset)
+				}
+				writeString(&b, "\n"+indent+activeTag)
+				col = cont
+			}
+			_, err := b.WriteRune(r)
+			contract.IgnoreError(err)
+			col += w
+		}
+	}
+	return b.String()
+}
+
+// shortenURN shortens a URN so that it fits within the given width, if possible.  The stack, project, and parent types
+// are elided first, since the resource's own type and name are usually enough to identify it.
+func shortenURN(urn resource.URN, width int) string {
+	s := string(urn)
+	if width <= 0 || runewidth.StringWidth(s) <= width ||
+		!strings.HasPrefix(s, resource.URNPrefix) || strings.Count(s, resource.URNNameDelimiter) < 3 {
+		return s
+	}
+
+	short := "urn:pulumi:..." + "::" + string(urn.Type()) + "::" + string(urn.Name())
+	if runewidth.StringWidth(short) <= width {
+		return short
+	}
+
+	// As a last resort, just keep as much of the end of the URN, which includes its name, as fits.
+	if width > 3 {
+		runes := []rune(s)
+		start, w := len(runes), 0
+		for start > 0 && w+runewidth.RuneWidth(runes[start-1]) <= width-3 {
+			start--
+			w += runewidth.RuneWidth(runes[start])
+		}
+		return "..." + string(runes[start:])
+	}
+	return s
+}
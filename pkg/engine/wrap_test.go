@@ -0,0 +1,67 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+)
+
+func TestWrapLine(t *testing.T) {
+	tests := []string{
+		"    name: " + strings.Repeat("x", 50),
+		"    name: " + strings.Repeat("é", 50),   // two bytes, but one column, each
+		"    name: " + strings.Repeat("日本語", 10), // three bytes, and two columns, each
+		"    name: " + strings.Repeat("a日", 15),  // wide characters that straddle the edge
+		"  + " + strings.Repeat("ü", 19) + "::x", // no title
+	}
+	for _, line := range tests {
+		wrapped := wrapLine(line, 20)
+		lines := strings.Split(wrapped, "\n")
+		assert.True(t, len(lines) > 1, "%q", line)
+		for _, l := range lines {
+			assert.True(t, runewidth.StringWidth(l) <= 20, "%q is wider than 20 columns", l)
+		}
+
+		// Nothing is lost; the continuation lines are just indented.
+		var joined string
+		for i, l := range lines {
+			if i > 0 {
+				l = strings.TrimLeft(l, " ")
+			}
+			joined += l
+		}
+		assert.Equal(t, line, joined)
+	}
+
+	// Lines that fit are left alone, even if they have more bytes than columns.
+	line := "    name: " + strings.Repeat("é", 10)
+	assert.Equal(t, line, wrapLine(line, 20))
+}
+
+func TestShortenURN(t *testing.T) {
+	urn := resource.URN("urn:pulumi:stack::project::aws:s3/bucket:Bucket::名前名前名前名前")
+	assert.Equal(t, string(urn), shortenURN(urn, 100))
+	assert.Equal(t, "urn:pulumi:...::aws:s3/bucket:Bucket::名前名前名前名前", shortenURN(urn, 60))
+
+	short := shortenURN(urn, 20)
+	assert.True(t, runewidth.StringWidth(short) <= 20, "%q is wider than 20 columns", short)
+	assert.Equal(t, "...:名前名前名前名前", short)
+}
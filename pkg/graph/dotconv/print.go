@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
 
 	"github.com/pulumi/pulumi/pkg/graph"
 	"github.com/pulumi/pulumi/pkg/util/contract"
@@ -99,8 +100,15 @@ func Print(g graph.Graph, w io.Writer) error {
 					return err
 				}
 
+				var attrs []string
 				if out.Color() != "" {
-					if _, err := b.WriteString(fmt.Sprintf(" [color=\"%s\"]", out.Color())); err != nil {
+					attrs = append(attrs, fmt.Sprintf("color=\"%s\"", out.Color()))
+				}
+				if out.Label() != "" {
+					attrs = append(attrs, fmt.Sprintf("label=%s", strconv.Quote(out.Label())))
+				}
+				if len(attrs) > 0 {
+					if _, err := b.WriteString(fmt.Sprintf(" [%s]", strings.Join(attrs, ", "))); err != nil {
 						return err
 					}
 				}
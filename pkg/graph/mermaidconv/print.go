@@ -0,0 +1,96 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mermaidconv converts a resource graph into its Mermaid flowchart equivalent.  Mermaid diagrams are rendered
+// by many Markdown viewers, which makes them handy for documentation.  Please see https://mermaidjs.github.io for a
+// description of the syntax.
+package mermaidconv
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pulumi/pulumi/pkg/graph"
+)
+
+// Print prints a resource graph.
+func Print(g graph.Graph, w io.Writer) error {
+	// As with dotconv, write errors are latched by the buffer, so we only check the result of flushing it at the end.
+	b := bufio.NewWriter(w)
+	_, _ = b.WriteString("graph LR\n")
+
+	queued := make(map[graph.Vertex]bool)
+	frontier := make([]graph.Vertex, 0, len(g.Roots()))
+	for _, root := range g.Roots() {
+		to := root.To()
+		queued[to] = true
+		frontier = append(frontier, to)
+	}
+
+	c := 0
+	ids := make(map[graph.Vertex]string)
+	getID := func(v graph.Vertex) string {
+		if id, has := ids[v]; has {
+			return id
+		}
+		id := "Resource" + strconv.Itoa(c)
+		c++
+		ids[v] = id
+		return id
+	}
+
+	// Mermaid styles links by their position in the diagram, so count them as they are emitted.
+	indent := "    "
+	links := 0
+	var styles []string
+	for len(frontier) > 0 {
+		v := frontier[0]
+		frontier = frontier[1:]
+
+		id := getID(v)
+		_, _ = b.WriteString(fmt.Sprintf("%s%s[\"%s\"]\n", indent, id, escape(v.Label())))
+
+		for _, out := range v.Outs() {
+			to := out.To()
+			arrow := "-->"
+			if label := out.Label(); label != "" {
+				arrow = fmt.Sprintf("-->|\"%s\"|", escape(label))
+			}
+			_, _ = b.WriteString(fmt.Sprintf("%s%s %s %s\n", indent, id, arrow, getID(to)))
+
+			if color := out.Color(); color != "" {
+				styles = append(styles, fmt.Sprintf("%slinkStyle %d stroke:%s\n", indent, links, color))
+			}
+			links++
+
+			if !queued[to] {
+				queued[to] = true
+				frontier = append(frontier, to)
+			}
+		}
+	}
+
+	for _, style := range styles {
+		_, _ = b.WriteString(style)
+	}
+	return b.Flush()
+}
+
+// escape replaces the characters that would end a quoted Mermaid label with their entity codes.
+func escape(label string) string {
+	return strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(label)
+}
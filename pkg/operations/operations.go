@@ -15,7 +15,12 @@
 package operations
 
 import (
+	"sync"
 	"time"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/config"
+	"github.com/pulumi/pulumi/pkg/tokens"
 )
 
 // LogEntry is a row in the logs for a running compute service
@@ -23,6 +28,7 @@ type LogEntry struct {
 	ID        string
 	Timestamp int64
 	Message   string
+	URN       resource.URN // the resource that wrote the entry, if known.
 }
 
 // ResourceFilter specifies a specific resource or subset of resources.  It can be provided in three formats:
@@ -53,3 +59,26 @@ type Provider interface {
 	GetLogs(query LogQuery) (*[]LogEntry, error)
 	// TODO[pulumi/pulumi#609] Add support for metrics
 }
+
+// ProviderFactory creates an operations provider for a resource, given the stack's configuration.  A factory may
+// return a nil provider for resources that it has nothing to say about.
+type ProviderFactory func(config map[config.Key]string, component *Resource) (Provider, error)
+
+var providerFactories = make(map[tokens.Package]ProviderFactory)
+var providerFactoriesLock sync.RWMutex
+
+// RegisterProvider registers the factory that creates operations providers for the resources of a package.  The
+// provider for a resource answers operational queries, like requests for its runtime logs, about the resource and all
+// of its children.  Registering a factory for a package replaces any registered before it.
+func RegisterProvider(pkg tokens.Package, factory ProviderFactory) {
+	providerFactoriesLock.Lock()
+	defer providerFactoriesLock.Unlock()
+	providerFactories[pkg] = factory
+}
+
+// getProviderFactory returns the factory registered for a package, or nil if there is none.
+func getProviderFactory(pkg tokens.Package) ProviderFactory {
+	providerFactoriesLock.RLock()
+	defer providerFactoriesLock.RUnlock()
+	return providerFactories[pkg]
+}
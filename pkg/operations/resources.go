@@ -157,6 +157,12 @@ func (ops *resourceOperations) GetLogs(query LogQuery) (*[]LogEntry, error) {
 				return logsResult, err
 			}
 			if logsResult != nil {
+				// Attribute any logs that the provider didn't attribute itself to this resource.
+				for i := range *logsResult {
+					if (*logsResult)[i].URN == "" {
+						(*logsResult)[i].URN = ops.resource.State.URN
+					}
+				}
 				return logsResult, nil
 			}
 		}
@@ -249,12 +255,14 @@ func (ops *resourceOperations) getOperationsProvider() (Provider, error) {
 	if ops.resource == nil || ops.resource.State == nil {
 		return nil, nil
 	}
-	switch ops.resource.State.Type.Package() {
-	case "cloud":
-		return CloudOperationsProvider(ops.config, ops.resource)
-	case "aws":
-		return AWSOperationsProvider(ops.config, ops.resource)
-	default:
+	factory := getProviderFactory(ops.resource.State.Type.Package())
+	if factory == nil {
 		return nil, nil
 	}
+	return factory(ops.config, ops.resource)
+}
+
+func init() {
+	RegisterProvider("cloud", CloudOperationsProvider)
+	RegisterProvider("aws", AWSOperationsProvider)
 }
@@ -23,7 +23,10 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/config"
 	"github.com/pulumi/pulumi/pkg/resource/stack"
+	"github.com/pulumi/pulumi/pkg/tokens"
 )
 
 func getPulumiResources(t *testing.T, path string) *Resource {
@@ -111,3 +114,43 @@ func TestCrawler(t *testing.T) {
 	assert.Equal(t, 1, len(function.State.Inputs))
 	assert.Equal(t, 3, len(function.Children))
 }
+
+type testLogsProvider struct {
+	component *Resource
+}
+
+func (p *testLogsProvider) GetLogs(query LogQuery) (*[]LogEntry, error) {
+	if p.component.State.Type != "test:index:Function" {
+		return nil, nil
+	}
+	name := string(p.component.State.URN.Name())
+	return &[]LogEntry{{ID: name, Timestamp: int64(len(name)), Message: "hello from " + name}}, nil
+}
+
+// TestLogsByURN ensures that the logs of each resource are gathered by the provider registered for its package, and
+// attributed to the resource.
+func TestLogsByURN(t *testing.T) {
+	RegisterProvider("test", func(config map[config.Key]string, component *Resource) (Provider, error) {
+		return &testLogsProvider{component: component}, nil
+	})
+
+	urn := func(typ, name string) resource.URN {
+		return resource.NewURN("stack", "proj", "", tokens.Type(typ), tokens.QName(name))
+	}
+	component := resource.NewState("test:index:Component", urn("test:index:Component", "c"), false, false, "",
+		resource.PropertyMap{}, nil, "", false, nil)
+	short := resource.NewState("test:index:Function", urn("test:index:Function", "f"), true, false, "f",
+		resource.PropertyMap{}, nil, component.URN, false, nil)
+	long := resource.NewState("test:index:Function", urn("test:index:Function", "func"), true, false, "func",
+		resource.PropertyMap{}, nil, "", false, nil)
+
+	tree := NewResourceTree([]*resource.State{component, short, long})
+	logs, err := tree.OperationsProvider(nil).GetLogs(LogQuery{})
+	assert.NoError(t, err)
+	if assert.NotNil(t, logs) && assert.Len(t, *logs, 2) {
+		assert.Equal(t, short.URN, (*logs)[0].URN)
+		assert.Equal(t, "hello from f", (*logs)[0].Message)
+		assert.Equal(t, long.URN, (*logs)[1].URN)
+		assert.Equal(t, "hello from func", (*logs)[1].Message)
+	}
+}
@@ -44,6 +44,37 @@ type Crypter interface {
 	Decrypter
 }
 
+// KeyedEncrypter is an Encrypter that may encrypt the values of different configuration keys with different keys.
+type KeyedEncrypter interface {
+	Encrypter
+	// EncryptValueForKey encrypts the value of the given configuration key.
+	EncryptValueForKey(key Key, plaintext string) (string, error)
+}
+
+// EncrypterForKey returns the encrypter for the values of a configuration key: one that encrypts them as the keyed
+// encrypter chooses to, if enc is a KeyedEncrypter, or else enc itself.
+func EncrypterForKey(enc Encrypter, key Key) Encrypter {
+	if keyed, ok := enc.(KeyedEncrypter); ok {
+		return keyEncrypter{enc: keyed, key: key}
+	}
+	return enc
+}
+
+type keyEncrypter struct {
+	enc KeyedEncrypter
+	key Key
+}
+
+func (e keyEncrypter) EncryptValue(plaintext string) (string, error) {
+	return e.enc.EncryptValueForKey(e.key, plaintext)
+}
+
+// Resolver fetches the plaintext of secrets held in external stores, given references to them.  A Decrypter that is
+// also a Resolver resolves the references among the values it decrypts.
+type Resolver interface {
+	ResolveReference(ref string) (string, error)
+}
+
 // A nopDecrypter simply returns the ciphertext as-is.
 type nopDecrypter struct{}
 
@@ -90,10 +121,14 @@ func NewSymmetricCrypter(key []byte) Crypter {
 
 // NewSymmetricCrypterFromPassphrase uses a passphrase and salt to generate a key, and then returns a crypter using it.
 func NewSymmetricCrypterFromPassphrase(phrase string, salt []byte) Crypter {
+	return NewSymmetricCrypter(DeriveSymmetricKey(phrase, salt))
+}
+
+// DeriveSymmetricKey generates the key for a symmetric crypter from a passphrase and salt.
+func DeriveSymmetricKey(phrase string, salt []byte) []byte {
 	// Generate a key using PBKDF2 to slow down attempts to crack it.  1,000,000 iterations was chosen because it
 	// took a little over a second on an i7-7700HQ Quad Core procesor
-	key := pbkdf2.Key([]byte(phrase), salt, 1000000, SymmetricCrypterKeyBytes, sha256.New)
-	return NewSymmetricCrypter(key)
+	return pbkdf2.Key([]byte(phrase), salt, 1000000, SymmetricCrypterKeyBytes, sha256.New)
 }
 
 // SymmetricCrypterKeyBytes is the required key size in bytes.
@@ -0,0 +1,316 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Path is a path to a value within a structured configuration value.  Each of its elements is either the name of a
+// property of an object, as a string, or the index of an element of an array, as an int.
+type Path []interface{}
+
+// ParsePath parses a path such as `endpoints[0].host`, whose elements are separated by dots, with array indices
+// given in brackets.  A property whose name holds dots or brackets may be given in brackets as a quoted string, as
+// in `tags["app.kubernetes.io/name"]`.
+func ParsePath(s string) (Path, error) {
+	var path Path
+	for i := 0; i < len(s); {
+		switch {
+		case s[i] == '.':
+			if i == 0 || i == len(s)-1 || s[i+1] == '.' || s[i+1] == '[' {
+				return nil, errors.Errorf("invalid path '%s': expected a property name after '.'", s)
+			}
+			i++
+		case s[i] == '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return nil, errors.Errorf("invalid path '%s': missing ']'", s)
+			}
+			elem := s[i+1 : i+end]
+			if strings.HasPrefix(elem, `"`) {
+				name, err := strconv.Unquote(elem)
+				if err != nil {
+					return nil, errors.Errorf("invalid path '%s': malformed property name %s", s, elem)
+				}
+				path = append(path, name)
+			} else {
+				index, err := strconv.Atoi(elem)
+				if err != nil || index < 0 {
+					return nil, errors.Errorf("invalid path '%s': '%s' is not an array index", s, elem)
+				}
+				path = append(path, index)
+			}
+			i += end + 1
+			if i < len(s) && s[i] != '.' && s[i] != '[' {
+				return nil, errors.Errorf("invalid path '%s': expected '.' or '[' after ']'", s)
+			}
+		default:
+			end := strings.IndexAny(s[i:], ".[")
+			if end < 0 {
+				end = len(s) - i
+			}
+			path = append(path, s[i:i+end])
+			i += end
+		}
+	}
+	if len(path) == 0 {
+		return nil, errors.New("invalid path: the path is empty")
+	}
+	return path, nil
+}
+
+func (p Path) String() string {
+	var b bytes.Buffer
+	for i, elem := range p {
+		switch elem := elem.(type) {
+		case int:
+			fmt.Fprintf(&b, "[%d]", elem)
+		case string:
+			if strings.ContainsAny(elem, ".[]\"") {
+				fmt.Fprintf(&b, "[%s]", strconv.Quote(elem))
+			} else {
+				if i > 0 {
+					b.WriteByte('.')
+				}
+				b.WriteString(elem)
+			}
+		}
+	}
+	return b.String()
+}
+
+// stored returns the path with its property names escaped, as they are in the objects of stored values.
+func (p Path) stored() Path {
+	result := make(Path, len(p))
+	for i, elem := range p {
+		if name, ok := elem.(string); ok {
+			elem = escapeKey(name)
+		}
+		result[i] = elem
+	}
+	return result
+}
+
+// GetPath returns the value at the given path within the value of a key, and whether there is one.  If the path is
+// empty, it returns the value of the key itself.
+func (m Map) GetPath(key Key, path Path) (Value, bool, error) {
+	v, has := m[key]
+	if !has || len(path) == 0 {
+		return v, has, nil
+	}
+	path = path.stored()
+
+	root, err := v.toObject()
+	if err != nil {
+		return Value{}, false, err
+	}
+	leaf, has := lookup(root, path)
+	if !has {
+		return Value{}, false, nil
+	}
+	return leafValue(leaf), true, nil
+}
+
+// SetPath sets the value at the given path within the value of a key, creating any objects and arrays along the way
+// that don't exist; an array may be added to by setting the element just after its last.  If the path is empty, it
+// sets the value of the key itself.
+//
+// A string set within an object is stored as a number or a boolean if it is the JSON text of one, so that programs
+// read it as such.
+func (m Map) SetPath(key Key, path Path, v Value) error {
+	if len(path) == 0 {
+		m[key] = v
+		return nil
+	}
+	path = path.stored()
+
+	var root interface{}
+	if existing, has := m[key]; has {
+		if !existing.object {
+			return errors.Errorf("'%s' is not an object or array", key)
+		}
+		var err error
+		if root, err = existing.toObject(); err != nil {
+			return err
+		}
+	}
+
+	leaf, err := v.toObject()
+	if err != nil {
+		return err
+	}
+	if s, ok := leaf.(string); ok {
+		var scalar interface{}
+		if json.Unmarshal([]byte(s), &scalar) == nil {
+			switch scalar.(type) {
+			case float64, bool:
+				leaf = scalar
+			}
+		}
+	}
+
+	root, err = setAt(root, path, leaf)
+	if err != nil {
+		return errors.Wrapf(err, "setting '%s'", key)
+	}
+	m[key] = newObjectValue(root)
+	return nil
+}
+
+// RemovePath removes the value at the given path within the value of a key, if there is one.  Removing an element of
+// an array shifts the elements after it down.  If the path is empty, it removes the key itself.
+func (m Map) RemovePath(key Key, path Path) error {
+	existing, has := m[key]
+	if !has || len(path) == 0 {
+		delete(m, key)
+		return nil
+	}
+	if !existing.object {
+		return errors.Errorf("'%s' is not an object or array", key)
+	}
+
+	root, err := existing.toObject()
+	if err != nil {
+		return err
+	}
+	stored := path.stored()
+	parentPath, last := path[:len(path)-1], stored[len(stored)-1]
+	storedParentPath := stored[:len(stored)-1]
+	parent, has := lookup(root, storedParentPath)
+	if !has {
+		return nil
+	}
+	switch last := last.(type) {
+	case string:
+		props, ok := parent.(map[string]interface{})
+		if !ok || isSecureLeaf(parent) {
+			return errors.Errorf("removing from '%s': '%s' is not an object", key, parentPath)
+		}
+		delete(props, last)
+	case int:
+		elems, ok := parent.([]interface{})
+		if !ok {
+			return errors.Errorf("removing from '%s': '%s' is not an array", key, parentPath)
+		}
+		if last >= len(elems) {
+			return nil
+		}
+		if root, err = setAt(root, storedParentPath, append(elems[:last], elems[last+1:]...)); err != nil {
+			return err
+		}
+	}
+	m[key] = newObjectValue(root)
+	return nil
+}
+
+// lookup returns the value at the given path within a value, and whether there is one.
+func lookup(v interface{}, path Path) (interface{}, bool) {
+	for _, elem := range path {
+		switch elem := elem.(type) {
+		case string:
+			props, ok := v.(map[string]interface{})
+			if !ok || isSecureLeaf(v) {
+				return nil, false
+			}
+			if v, ok = props[elem]; !ok {
+				return nil, false
+			}
+		case int:
+			elems, ok := v.([]interface{})
+			if !ok || elem >= len(elems) {
+				return nil, false
+			}
+			v = elems[elem]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// setAt returns a value with the value at the given path within it set, creating any objects and arrays along the
+// path that don't exist.
+func setAt(v interface{}, path Path, leaf interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return leaf, nil
+	}
+
+	switch elem := path[0].(type) {
+	case string:
+		props, ok := v.(map[string]interface{})
+		if v == nil {
+			props = make(map[string]interface{})
+		} else if !ok || isSecureLeaf(v) {
+			return nil, errors.Errorf("cannot set property '%s' of a value that is not an object", unescapeKey(elem))
+		}
+		child, err := setAt(props[elem], path[1:], leaf)
+		if err != nil {
+			return nil, err
+		}
+		props[elem] = child
+		return props, nil
+	case int:
+		elems, ok := v.([]interface{})
+		if v != nil && !ok {
+			return nil, errors.Errorf("cannot set element %d of a value that is not an array", elem)
+		}
+		if elem > len(elems) {
+			return nil, errors.Errorf("cannot set element %d of an array of length %d", elem, len(elems))
+		}
+		if elem == len(elems) {
+			elems = append(elems, nil)
+		}
+		child, err := setAt(elems[elem], path[1:], leaf)
+		if err != nil {
+			return nil, err
+		}
+		elems[elem] = child
+		return elems, nil
+	default:
+		return nil, errors.Errorf("invalid path element %v", elem)
+	}
+}
+
+// isSecureLeaf returns true if a value within an object is a secret.
+func isSecureLeaf(v interface{}) bool {
+	_, ok := secureLeaf(v)
+	return ok
+}
+
+// leafValue returns the configuration value of a value within an object.
+func leafValue(v interface{}) Value {
+	if ciphertext, ok := secureLeaf(v); ok {
+		return NewSecureValue(ciphertext)
+	}
+	switch v := v.(type) {
+	case string:
+		return NewValue(v)
+	case map[string]interface{}, []interface{}:
+		return newObjectValue(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return NewValue(fmt.Sprintf("%v", v))
+		}
+		return NewValue(string(b))
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePath(t *testing.T) {
+	path, err := ParsePath(`data.endpoints[0]["app.kubernetes.io/name"].host`)
+	assert.NoError(t, err)
+	assert.Equal(t, Path{"data", "endpoints", 0, "app.kubernetes.io/name", "host"}, path)
+	assert.Equal(t, `data.endpoints[0]["app.kubernetes.io/name"].host`, path.String())
+
+	for _, invalid := range []string{"", ".data", "data.", "data..host", "data[x]", "data[-1]", "data[0", "data[0]x"} {
+		_, err = ParsePath(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestMapPaths(t *testing.T) {
+	key := MustMakeKey("app", "data")
+	m := Map{}
+
+	// Setting a value within a key that doesn't exist creates the objects and arrays along the way.
+	assert.NoError(t, m.SetPath(key, Path{"endpoints", 0, "host"}, NewValue("example.com")))
+	assert.NoError(t, m.SetPath(key, Path{"endpoints", 0, "port"}, NewValue("8080")))
+	assert.NoError(t, m.SetPath(key, Path{"endpoints", 1, "host"}, NewValue("example.org")))
+	assert.NoError(t, m.SetPath(key, Path{"password"}, NewSecureValue("ciphertext")))
+	assert.Error(t, m.SetPath(key, Path{"endpoints", 3}, NewValue("too far")))
+	assert.Error(t, m.SetPath(key, Path{"endpoints", "host"}, NewValue("not an object")))
+
+	expected, err := NewObjectValue(`{"endpoints":[{"host":"example.com","port":8080},{"host":"example.org"}],` +
+		`"password":{"secure":"ciphertext"}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, m[key])
+
+	v, has, err := m.GetPath(key, Path{"endpoints", 0, "port"})
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.Equal(t, NewValue("8080"), v)
+	v, has, err = m.GetPath(key, Path{"password"})
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.Equal(t, NewSecureValue("ciphertext"), v)
+	_, has, err = m.GetPath(key, Path{"endpoints", 2})
+	assert.NoError(t, err)
+	assert.False(t, has)
+
+	// Removing an element of an array shifts those after it down.
+	assert.NoError(t, m.RemovePath(key, Path{"endpoints", 0}))
+	assert.NoError(t, m.RemovePath(key, Path{"password"}))
+	assert.NoError(t, m.RemovePath(key, Path{"missing", "property"}))
+	expected, err = NewObjectValue(`{"endpoints":[{"host":"example.org"}]}`)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, m[key])
+
+	// Values that aren't objects have nothing within them.
+	plain := MustMakeKey("app", "name")
+	m[plain] = NewValue("prod")
+	assert.Error(t, m.SetPath(plain, Path{"property"}, NewValue("value")))
+	assert.Error(t, m.RemovePath(plain, Path{"property"}))
+}
+
+func TestMapPathsReservedKeys(t *testing.T) {
+	key := MustMakeKey("app", "cookie")
+	m := Map{}
+
+	// Properties named like the objects that hold secrets and references are ordinary properties.
+	assert.NoError(t, m.SetPath(key, Path{"secure"}, NewValue("yes")))
+	assert.False(t, m[key].Secure())
+	v, err := m[key].Value(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"secure":"yes"}`, v)
+
+	newV, err := roundtripValueYAML(m[key])
+	assert.NoError(t, err)
+	assert.Equal(t, m[key], newV)
+	newV, err = roundtripValueJSON(m[key])
+	assert.NoError(t, err)
+	assert.Equal(t, m[key], newV)
+
+	assert.NoError(t, m.SetPath(key, Path{"options", "ref"}, NewValue("main")))
+	assert.NoError(t, m.SetPath(key, Path{"options", `\secure`}, NewValue("escaped")))
+	assert.NoError(t, m.SetPath(key, Path{"token"}, NewSecureValue("ciphertext")))
+	assert.True(t, m[key].Secure())
+	v, err = m[key].Value(NewBlindingDecrypter())
+	assert.NoError(t, err)
+	assert.Equal(t, `{"options":{"\\secure":"escaped","ref":"main"},"secure":"yes","token":"[secret]"}`, v)
+
+	leaf, has, err := m.GetPath(key, Path{"secure"})
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.Equal(t, NewValue("yes"), leaf)
+	leaf, has, err = m.GetPath(key, Path{"token"})
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.Equal(t, NewSecureValue("ciphertext"), leaf)
+
+	assert.NoError(t, m.RemovePath(key, Path{"secure"}))
+	_, has, err = m.GetPath(key, Path{"secure"})
+	assert.NoError(t, err)
+	assert.False(t, has)
+}
@@ -16,13 +16,24 @@ package config
 
 import (
 	"encoding/json"
-	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/util/contract"
 )
 
-// Value is a single config value.
+// Value is a single config value.  A value is either a string, which may be a secret, or an object or array, which
+// is held as JSON, and whose secrets are held within it as objects of the form `{"secure": <ciphertext>}`, or a
+// reference to a secret held in an external store, which is resolved only when the value is used.  So that none of
+// the objects in a value can be mistaken for a secret or a reference, the keys named `secure` or `ref` within it are
+// held escaped, as described by escapeKey.
 type Value struct {
 	value  string
-	secure bool
+	secure bool // true if the value is a secret or, if it's an object, holds any.
+	object bool // true if the value is an object or array.
+	ref    bool // true if the value is a reference to a secret in an external store.
 }
 
 func NewSecureValue(v string) Value {
@@ -33,30 +44,298 @@ func NewValue(v string) Value {
 	return Value{value: v, secure: false}
 }
 
+// NewReferenceValue returns a secret value whose plaintext is held in an external store, at the given reference, such
+// as `ssm:///prod/db/password`.  The value is never saved; it's fetched each time the value is used.
+func NewReferenceValue(ref string) Value {
+	return Value{value: ref, secure: true, ref: true}
+}
+
+// NewObjectValue returns a structured value, given as JSON, which holds an object or array.  The JSON is that of the
+// value as it is stored, as returned by MarshalJSON, with its secrets as ciphertext and its keys escaped.
+func NewObjectValue(v string) (Value, error) {
+	var obj interface{}
+	if err := json.Unmarshal([]byte(v), &obj); err != nil {
+		return Value{}, errors.Wrap(err, "malformed object value")
+	}
+	result := Value{}
+	err := result.setFrom(obj)
+	if err == nil && !result.object {
+		return Value{}, errors.New("an object value must hold an object or array")
+	}
+	return result, err
+}
+
+// newObjectValue returns the structured value with the given contents, as decoded from JSON.
+func newObjectValue(obj interface{}) Value {
+	b, err := json.Marshal(obj)
+	contract.AssertNoError(err)
+	return Value{value: string(b), secure: hasSecureLeaf(obj), object: true}
+}
+
 // Value fetches the value of this configuration entry, using decrypter to decrypt if necessary.  If the value
-// is a secret and decrypter is nil, or if decryption fails for any reason, a non-nil error is returned.
+// is a secret and decrypter is nil, or if decryption fails for any reason, a non-nil error is returned.  The value of
+// an object is its JSON, with any secrets within it decrypted.  The value of a reference is fetched if decrypter is
+// also a Resolver; otherwise, it's the reference itself.
 func (c Value) Value(decrypter Decrypter) (string, error) {
+	if !c.object {
+		if !c.secure {
+			return c.value, nil
+		}
+		if decrypter == nil {
+			return "", errors.New("non-nil decrypter required for secret")
+		}
+		if c.ref {
+			if resolver, ok := decrypter.(Resolver); ok {
+				return resolver.ResolveReference(c.value)
+			}
+			return c.value, nil
+		}
+		return decrypter.DecryptValue(c.value)
+	}
+
+	obj, err := c.toObject()
+	if err != nil {
+		return "", err
+	}
+	if c.secure {
+		if decrypter == nil {
+			return "", errors.New("non-nil decrypter required for secret")
+		}
+		obj, err = mapSecureLeaves(obj, func(ciphertext string) (interface{}, error) {
+			return decrypter.DecryptValue(ciphertext)
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	b, err := json.Marshal(unescapeKeys(obj))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (c Value) Secure() bool {
+	return c.secure
+}
+
+// Object returns true if the value is an object or array, rather than a string.
+func (c Value) Object() bool {
+	return c.object
+}
+
+// Reference returns the reference to the secret in an external store that holds the value, if it is held in one.
+func (c Value) Reference() (string, bool) {
+	return c.value, c.ref
+}
+
+// SecureValues returns the plaintext of each secret in the value: the value itself, if it's a secret, or those held
+// within it, if it's an object.
+func (c Value) SecureValues(decrypter Decrypter) ([]string, error) {
 	if !c.secure {
+		return nil, nil
+	}
+	if !c.object {
+		plaintext, err := c.Value(decrypter)
+		if err != nil {
+			return nil, err
+		}
+		return []string{plaintext}, nil
+	}
+
+	obj, err := c.toObject()
+	if err != nil {
+		return nil, err
+	}
+	var secrets []string
+	_, err = mapSecureLeaves(obj, func(ciphertext string) (interface{}, error) {
+		plaintext, err := decrypter.DecryptValue(ciphertext)
+		secrets = append(secrets, plaintext)
+		return plaintext, err
+	})
+	return secrets, err
+}
+
+// Copy returns a copy of the value whose secrets, if it has any, are decrypted with one crypter and encrypted again
+// with another.
+func (c Value) Copy(decrypter Decrypter, encrypter Encrypter) (Value, error) {
+	if !c.secure || c.ref {
+		return c, nil
+	}
+	reencrypt := func(ciphertext string) (string, error) {
+		plaintext, err := decrypter.DecryptValue(ciphertext)
+		if err != nil {
+			return "", err
+		}
+		return encrypter.EncryptValue(plaintext)
+	}
+	if !c.object {
+		ciphertext, err := reencrypt(c.value)
+		if err != nil {
+			return Value{}, err
+		}
+		return NewSecureValue(ciphertext), nil
+	}
+
+	obj, err := c.toObject()
+	if err != nil {
+		return Value{}, err
+	}
+	reencrypted, err := mapSecureLeaves(obj, func(ciphertext string) (interface{}, error) {
+		ciphertext, err := reencrypt(ciphertext)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"secure": ciphertext}, nil
+	})
+	if err != nil {
+		return Value{}, err
+	}
+	return newObjectValue(reencrypted), nil
+}
+
+// toObject returns the contents of a structured value, as decoded from JSON, or, for any other value, the object that
+// holds it as a leaf.
+func (c Value) toObject() (interface{}, error) {
+	if !c.object {
+		if c.ref {
+			return map[string]interface{}{"ref": c.value}, nil
+		}
+		if c.secure {
+			return map[string]interface{}{"secure": c.value}, nil
+		}
 		return c.value, nil
 	}
-	if decrypter == nil {
-		return "", errors.New("non-nil decrypter required for secret")
+
+	var obj interface{}
+	if err := json.Unmarshal([]byte(c.value), &obj); err != nil {
+		return nil, errors.Wrap(err, "malformed object value")
 	}
+	return obj, nil
+}
 
-	return decrypter.DecryptValue(c.value)
+// escapeKey escapes a key of an object within a value, as it is stored.  Keys named `secure` or `ref`, which would
+// make an object that holds nothing else look like a secret or a reference, are given a leading backslash; and so are
+// keys that already look escaped, so that escaping can be undone exactly.
+func escapeKey(k string) string {
+	if isReservedKey(k) {
+		return `\` + k
+	}
+	return k
 }
 
-func (c Value) Secure() bool {
-	return c.secure
+// unescapeKey undoes escapeKey.
+func unescapeKey(k string) string {
+	if isReservedKey(k) && strings.HasPrefix(k, `\`) {
+		return k[1:]
+	}
+	return k
+}
+
+// isReservedKey returns true if a key is `secure` or `ref`, preceded by any number of backslashes.
+func isReservedKey(k string) bool {
+	k = strings.TrimLeft(k, `\`)
+	return k == "secure" || k == "ref"
+}
+
+// unescapeKeys returns a copy of an object with all of the keys within it unescaped.  Its secrets must already have
+// been replaced by their plaintext.
+func unescapeKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			result[unescapeKey(k)] = unescapeKeys(e)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, e := range v {
+			result[i] = unescapeKeys(e)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// secureLeaf returns the ciphertext of a secret held within an object, if v is one.
+func secureLeaf(v interface{}) (string, bool) {
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return "", false
+	}
+	ciphertext, ok := m["secure"].(string)
+	return ciphertext, ok
+}
+
+// hasSecureLeaf returns true if an object holds any secrets.
+func hasSecureLeaf(v interface{}) bool {
+	if _, ok := secureLeaf(v); ok {
+		return true
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, e := range v {
+			if hasSecureLeaf(e) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, e := range v {
+			if hasSecureLeaf(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mapSecureLeaves returns a copy of an object with each secret within it replaced by the result of f.
+func mapSecureLeaves(v interface{}, f func(ciphertext string) (interface{}, error)) (interface{}, error) {
+	if ciphertext, ok := secureLeaf(v); ok {
+		return f(ciphertext)
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			mapped, err := mapSecureLeaves(e, f)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = mapped
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, e := range v {
+			mapped, err := mapSecureLeaves(e, f)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = mapped
+		}
+		return result, nil
+	default:
+		return v, nil
+	}
 }
 
 func (c Value) MarshalJSON() ([]byte, error) {
+	if c.object {
+		return []byte(c.value), nil
+	}
 	if !c.secure {
 		return json.Marshal(c.value)
 	}
 
 	m := make(map[string]string)
-	m["secure"] = c.value
+	if c.ref {
+		m["ref"] = c.value
+	} else {
+		m["secure"] = c.value
+	}
 
 	return json.Marshal(m)
 }
@@ -64,31 +343,40 @@ func (c Value) MarshalJSON() ([]byte, error) {
 func (c *Value) UnmarshalJSON(b []byte) error {
 	var m map[string]string
 	err := json.Unmarshal(b, &m)
-	if err == nil {
-		if len(m) != 1 {
-			return errors.New("malformed secure data")
+	if err == nil && len(m) == 1 {
+		if val, has := m["secure"]; has {
+			c.value = val
+			c.secure = true
+			c.object = false
+			return nil
 		}
-
-		val, has := m["secure"]
-		if !has {
-			return errors.New("malformed secure data")
+		if val, has := m["ref"]; has {
+			*c = NewReferenceValue(val)
+			return nil
 		}
-
-		c.value = val
-		c.secure = true
-		return nil
 	}
 
-	return json.Unmarshal(b, &c.value)
+	var obj interface{}
+	if err = json.Unmarshal(b, &obj); err != nil {
+		return err
+	}
+	return c.setFrom(obj)
 }
 
 func (c Value) MarshalYAML() (interface{}, error) {
+	if c.object {
+		return c.toObject()
+	}
 	if !c.secure {
 		return c.value, nil
 	}
 
 	m := make(map[string]string)
-	m["secure"] = c.value
+	if c.ref {
+		m["ref"] = c.value
+	} else {
+		m["secure"] = c.value
+	}
 
 	return m, nil
 }
@@ -96,21 +384,63 @@ func (c Value) MarshalYAML() (interface{}, error) {
 func (c *Value) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var m map[string]string
 	err := unmarshal(&m)
-	if err == nil {
-		if len(m) != 1 {
-			return errors.New("malformed secure data")
+	if err == nil && len(m) == 1 {
+		if val, has := m["secure"]; has {
+			c.value = val
+			c.secure = true
+			c.object = false
+			return nil
 		}
-
-		val, has := m["secure"]
-		if !has {
-			return errors.New("malformed secure data")
+		if val, has := m["ref"]; has {
+			*c = NewReferenceValue(val)
+			return nil
 		}
+	}
 
-		c.value = val
-		c.secure = true
+	// Scalars are read as strings, as they always have been, whatever their YAML type.
+	var s string
+	if err = unmarshal(&s); err == nil {
+		*c = NewValue(s)
 		return nil
 	}
 
-	c.secure = false
-	return unmarshal(&c.value)
+	var obj interface{}
+	if err = unmarshal(&obj); err != nil {
+		return err
+	}
+	return c.setFrom(normalizeYAML(obj))
+}
+
+// setFrom sets the value to one decoded from JSON or YAML, which is either a string or an object or array.
+func (c *Value) setFrom(obj interface{}) error {
+	switch obj := obj.(type) {
+	case string:
+		*c = NewValue(obj)
+	case map[string]interface{}, []interface{}:
+		*c = newObjectValue(obj)
+	default:
+		return errors.Errorf("unsupported configuration value %v; values must be strings, objects or arrays", obj)
+	}
+	return nil
+}
+
+// normalizeYAML converts the maps within a value decoded from YAML, whose keys may be of any type, to maps with string
+// keys, as would have been decoded from JSON.
+func normalizeYAML(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			result[fmt.Sprintf("%v", k)] = normalizeYAML(e)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, e := range v {
+			result[i] = normalizeYAML(e)
+		}
+		return result
+	default:
+		return v
+	}
 }
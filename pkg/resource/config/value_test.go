@@ -70,6 +70,90 @@ func TestMarshallSecureValueJSON(t *testing.T) {
 	assert.Equal(t, v, newV)
 }
 
+func TestMarshallObjectValueYAML(t *testing.T) {
+	v, err := NewObjectValue(`{"endpoints":[{"host":"example.com","port":8080}],"password":{"secure":"ciphertext"}}`)
+	assert.NoError(t, err)
+	assert.True(t, v.Object())
+	assert.True(t, v.Secure())
+
+	b, err := yaml.Marshal(v)
+	assert.NoError(t, err)
+	assert.Equal(t, "endpoints:\n- host: example.com\n  port: 8080\npassword:\n  secure: ciphertext\n", string(b))
+
+	newV, err := roundtripValueYAML(v)
+	assert.NoError(t, err)
+	assert.Equal(t, v, newV)
+}
+
+func TestMarshallObjectValueJSON(t *testing.T) {
+	v, err := NewObjectValue(`["a","b"]`)
+	assert.NoError(t, err)
+	assert.True(t, v.Object())
+	assert.False(t, v.Secure())
+
+	b, err := json.Marshal(v)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`["a","b"]`), b)
+
+	newV, err := roundtripValueJSON(v)
+	assert.NoError(t, err)
+	assert.Equal(t, v, newV)
+
+	_, err = NewObjectValue(`"a string"`)
+	assert.Error(t, err)
+}
+
+func TestObjectValueSecrets(t *testing.T) {
+	v, err := NewObjectValue(`{"user":"admin","password":{"secure":"ciphertext"}}`)
+	assert.NoError(t, err)
+
+	// The value of an object is its JSON, with its secrets decrypted.
+	plaintext, err := v.Value(NewBlindingDecrypter())
+	assert.NoError(t, err)
+	assert.Equal(t, `{"password":"[secret]","user":"admin"}`, plaintext)
+	secrets, err := v.SecureValues(NewBlindingDecrypter())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"[secret]"}, secrets)
+	_, err = v.Value(nil)
+	assert.Error(t, err)
+}
+
+// mapResolver is a decrypter that resolves references by looking them up in a map, for testing.
+type mapResolver map[string]string
+
+func (r mapResolver) DecryptValue(ciphertext string) (string, error) { return ciphertext, nil }
+
+func (r mapResolver) ResolveReference(ref string) (string, error) { return r[ref], nil }
+
+func TestReferenceValue(t *testing.T) {
+	v := NewReferenceValue("ssm:///prod/db/password")
+	assert.True(t, v.Secure())
+	ref, ok := v.Reference()
+	assert.True(t, ok)
+	assert.Equal(t, "ssm:///prod/db/password", ref)
+
+	b, err := yaml.Marshal(v)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("ref: ssm:///prod/db/password\n"), b)
+	newV, err := roundtripValueYAML(v)
+	assert.NoError(t, err)
+	assert.Equal(t, v, newV)
+	newV, err = roundtripValueJSON(v)
+	assert.NoError(t, err)
+	assert.Equal(t, v, newV)
+
+	// Only a resolver fetches the value; other decrypters see the reference, which is copied as it is.
+	plaintext, err := v.Value(mapResolver{"ssm:///prod/db/password": "hunter2"})
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", plaintext)
+	plaintext, err = v.Value(NewBlindingDecrypter())
+	assert.NoError(t, err)
+	assert.Equal(t, "ssm:///prod/db/password", plaintext)
+	copied, err := v.Copy(NewBlindingDecrypter(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, v, copied)
+}
+
 func roundtripValueYAML(v Value) (Value, error) {
 	return roundtripValue(v, yaml.Marshal, yaml.Unmarshal)
 }
@@ -0,0 +1,121 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/diag"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/util/logging"
+	"github.com/pulumi/pulumi/pkg/workspace"
+)
+
+const (
+	// defaultReadinessTimeout is how long a blue/green group's readiness check is retried if it sets no timeout.
+	defaultReadinessTimeout = 5 * time.Minute
+	// readinessCheckInterval is how long to wait between attempts of a readiness check.
+	readinessCheckInterval = 5 * time.Second
+)
+
+// blueGreenGroup returns the blue/green group of which a resource is a member, or nil if it belongs to none.  A
+// resource is a member of the first group all of whose tags it has, with the same values, in its `tags` input.
+func blueGreenGroup(goal *resource.Goal, groups []workspace.BlueGreenGroup) *workspace.BlueGreenGroup {
+	tags, has := goal.Properties[tagsKey]
+	if !has || !tags.IsObject() {
+		return nil
+	}
+
+	for i, group := range groups {
+		member := true
+		for k, v := range group.Tags {
+			tag, has := tags.ObjectValue()[resource.PropertyKey(k)]
+			if !has || !tag.IsString() || tag.StringValue() != v {
+				member = false
+				break
+			}
+		}
+		if member {
+			return &groups[i]
+		}
+	}
+	return nil
+}
+
+// awaitBlueGreenGroups runs between the two phases of each blue/green group that has replacements in this plan: once
+// its members' replacements have all been created, and before its old members are deleted.  The phases are described
+// for a preview; otherwise, the group's readiness check is retried until it passes, and if it never does, an error is
+// returned, so that none of the old resources are deleted.
+func (iter *PlanIterator) awaitBlueGreenGroups() error {
+	for _, group := range iter.opts.BlueGreen {
+		replaced := iter.blueGreenReplaces[group.Name]
+		if len(replaced) == 0 {
+			continue
+		}
+
+		check := group.ReadinessCheck
+		if check == "" {
+			check = "(none)"
+		}
+		iter.p.Diag().Infof(diag.GetBlueGreenPhasesInfo(""), group.Name, len(replaced), check, len(replaced))
+		if iter.p.preview || group.ReadinessCheck == "" {
+			continue
+		}
+
+		if err := runReadinessCheck(group); err != nil {
+			iter.p.Diag().Errorf(diag.GetBlueGreenNotReadyError(""), group.Name, err)
+			return errors.Errorf("blue/green group '%s' did not become ready; refusing to delete its old resources",
+				group.Name)
+		}
+	}
+	return nil
+}
+
+// runReadinessCheck runs a blue/green group's readiness check, retrying it until it succeeds or the group's readiness
+// timeout elapses.  It returns the error of the last attempt if the check never succeeds.
+func runReadinessCheck(group workspace.BlueGreenGroup) error {
+	timeout := defaultReadinessTimeout
+	if group.ReadinessTimeout != "" {
+		d, err := time.ParseDuration(group.ReadinessTimeout)
+		if err != nil {
+			return err
+		}
+		timeout = d
+	}
+
+	deadline := time.Now().Add(timeout)
+	for attempt := 1; ; attempt++ {
+		shell, flag := "sh", "-c"
+		if runtime.GOOS == "windows" {
+			shell, flag = "cmd", "/C"
+		}
+		out, err := exec.Command(shell, flag, group.ReadinessCheck).CombinedOutput() // nolint: gas
+		if err == nil {
+			logging.V(7).Infof("Blue/green group '%s' became ready after %d attempt(s)", group.Name, attempt)
+			return nil
+		}
+		logging.V(7).Infof("Blue/green group '%s' is not ready yet (attempt %d): %v\n%s",
+			group.Name, attempt, err, out)
+
+		if time.Now().Add(readinessCheckInterval).After(deadline) {
+			return errors.Wrapf(err, "readiness check failed %d time(s) over %v", attempt, timeout)
+		}
+		time.Sleep(readinessCheckInterval)
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/workspace"
+)
+
+func TestBlueGreenGroup(t *testing.T) {
+	t.Parallel()
+
+	groups := []workspace.BlueGreenGroup{
+		{Name: "web", Tags: map[string]string{"app": "web", "tier": "frontend"}},
+		{Name: "app", Tags: map[string]string{"app": "web"}},
+	}
+	goal := func(tags map[string]interface{}) *resource.Goal {
+		props := resource.PropertyMap{}
+		if tags != nil {
+			props["tags"] = resource.NewPropertyValue(tags)
+		}
+		return &resource.Goal{Properties: props}
+	}
+
+	assert.Nil(t, blueGreenGroup(goal(nil), groups))
+	assert.Nil(t, blueGreenGroup(goal(map[string]interface{}{"app": "api"}), groups))
+	assert.Equal(t, "app", blueGreenGroup(goal(map[string]interface{}{"app": "web"}), groups).Name)
+	assert.Equal(t, "web",
+		blueGreenGroup(goal(map[string]interface{}{"app": "web", "tier": "frontend", "env": "prod"}), groups).Name)
+}
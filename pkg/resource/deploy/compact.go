@@ -0,0 +1,139 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"github.com/pulumi/pulumi/pkg/resource"
+)
+
+// Compaction records what CompactSnapshot removed from a snapshot.
+type Compaction struct {
+	Pruned       []*resource.State               // the entries pending deletion that were removed.
+	Aliases      map[resource.URN][]resource.URN // the aliases removed from each resource.
+	Dependencies map[resource.URN][]resource.URN // the dependencies removed from each resource.
+}
+
+// IsEmpty returns true if the compaction removed nothing, leaving the snapshot as it was.
+func (c Compaction) IsEmpty() bool {
+	return len(c.Pruned) == 0 && len(c.Aliases) == 0 && len(c.Dependencies) == 0
+}
+
+// CompactSnapshot removes the entries of a snapshot that no update will ever need, which accumulate over the life of a
+// stack, and returns what it removed.  The snapshot's resources are otherwise left alone, in their order, so that if
+// there is nothing to remove, the snapshot is unchanged.  It removes:
+//
+//  1. Entries pending deletion that have nothing to delete: those that were never created, those of external
+//     resources, which the engine never deletes, and those with the same URN and ID as another entry, which would
+//     delete that entry's resource out from under it.  Entries that a pending operation refers to are kept.
+//  2. Aliases that can never be matched: a resource's own URN, repeats, and the URNs of other resources, which
+//     take precedence over aliases when resources are looked up.
+//  3. Dependencies on resources that are no longer in the snapshot, and repeated dependencies.
+func CompactSnapshot(snap *Snapshot) Compaction {
+	var result Compaction
+	if snap == nil {
+		return result
+	}
+
+	type identity struct {
+		urn resource.URN
+		id  resource.ID
+	}
+	live, pending := make(map[identity]bool), make(map[identity]bool)
+	liveURNs, parents := make(map[resource.URN]bool), make(map[resource.URN]bool)
+	for _, res := range snap.Resources {
+		if !res.Delete {
+			live[identity{res.URN, res.ID}] = true
+			liveURNs[res.URN] = true
+		}
+		parents[res.Parent] = true
+	}
+	for _, op := range snap.PendingOperations {
+		pending[identity{op.Resource.URN, op.Resource.ID}] = true
+	}
+
+	// Prune the entries pending deletion that have nothing to delete, unless one is the only entry that its children
+	// can refer to as their parent.
+	seen, seenURNs := make(map[identity]bool), make(map[resource.URN]bool)
+	var resources []*resource.State
+	for _, res := range snap.Resources {
+		key := identity{res.URN, res.ID}
+		if res.Delete && !pending[key] && (res.ID == "" || res.External || live[key] || seen[key]) &&
+			(!parents[res.URN] || liveURNs[res.URN] || seenURNs[res.URN]) {
+			result.Pruned = append(result.Pruned, res)
+			continue
+		}
+		if res.Delete {
+			seen[key] = true
+		}
+		seenURNs[res.URN] = true
+		resources = append(resources, res)
+	}
+	if len(result.Pruned) > 0 {
+		snap.Resources = resources
+	}
+
+	urns := make(map[resource.URN]bool)
+	for _, res := range snap.Resources {
+		urns[res.URN] = true
+	}
+
+	for _, res := range snap.Resources {
+		// Drop the aliases that can't be matched.
+		var aliases, staleAliases []resource.URN
+		for _, alias := range res.Aliases {
+			if alias == res.URN || liveURNs[alias] || containsURN(aliases, alias) {
+				staleAliases = append(staleAliases, alias)
+			} else {
+				aliases = append(aliases, alias)
+			}
+		}
+		if len(staleAliases) > 0 {
+			res.Aliases = aliases
+			if result.Aliases == nil {
+				result.Aliases = make(map[resource.URN][]resource.URN)
+			}
+			result.Aliases[res.URN] = append(result.Aliases[res.URN], staleAliases...)
+		}
+
+		// And the dependencies on resources that are gone.
+		var deps, staleDeps []resource.URN
+		for _, dep := range res.Dependencies {
+			if !urns[dep] || containsURN(deps, dep) {
+				staleDeps = append(staleDeps, dep)
+			} else {
+				deps = append(deps, dep)
+			}
+		}
+		if len(staleDeps) > 0 {
+			res.Dependencies = deps
+			if result.Dependencies == nil {
+				result.Dependencies = make(map[resource.URN][]resource.URN)
+			}
+			result.Dependencies[res.URN] = append(result.Dependencies[res.URN], staleDeps...)
+		}
+	}
+
+	return result
+}
+
+// containsURN returns true if a list of URNs contains the given URN.
+func containsURN(urns []resource.URN, urn resource.URN) bool {
+	for _, u := range urns {
+		if u == urn {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/tokens"
+)
+
+func TestCompactSnapshot(t *testing.T) {
+	t.Parallel()
+
+	newState := func(name string, id resource.ID, del bool, deps ...resource.URN) *resource.State {
+		urn := resource.NewURN("dev", "proj", "", "test:index:Resource", tokens.QName(name))
+		return resource.NewState("test:index:Resource", urn, true, del, id, resource.PropertyMap{}, nil, "", false,
+			deps)
+	}
+
+	a := newState("a", "a-1", false)
+	staleA := newState("a", "a-1", true)    // the same resource as a's entry.
+	replacedA := newState("a", "a-0", true) // an old copy of a, still to be deleted.
+	neverCreated := newState("b", "", true) // a replacement that failed before it was created.
+	external := newState("c", "c-1", true)  // an external resource, which is never deleted.
+	external.External = true
+	interrupted := newState("d", "", true) // the subject of an interrupted operation.
+	c := newState("c", "c-2", false, a.URN, a.URN, neverCreated.URN)
+	c.Aliases = []resource.URN{c.URN, a.URN, "urn:pulumi:old::proj::test:index:Resource::c",
+		"urn:pulumi:old::proj::test:index:Resource::c"}
+
+	snap := NewSnapshot(Manifest{}, []*resource.State{a, staleA, replacedA, neverCreated, external, interrupted, c})
+	snap.PendingOperations = []Operation{{Resource: interrupted, Type: OperationTypeDeleting}}
+
+	compaction := CompactSnapshot(snap)
+	assert.False(t, compaction.IsEmpty())
+	assert.Equal(t, []*resource.State{staleA, neverCreated, external}, compaction.Pruned)
+	assert.Equal(t, []*resource.State{a, replacedA, interrupted, c}, snap.Resources)
+	assert.Equal(t, []resource.URN{"urn:pulumi:old::proj::test:index:Resource::c"}, c.Aliases)
+	assert.Equal(t, []resource.URN{c.URN, a.URN, "urn:pulumi:old::proj::test:index:Resource::c"},
+		compaction.Aliases[c.URN])
+	assert.Equal(t, []resource.URN{a.URN}, c.Dependencies)
+	assert.Equal(t, []resource.URN{a.URN, neverCreated.URN}, compaction.Dependencies[c.URN])
+
+	// Compacting again changes nothing.
+	assert.True(t, CompactSnapshot(snap).IsEmpty())
+	assert.True(t, CompactSnapshot(nil).IsEmpty())
+}
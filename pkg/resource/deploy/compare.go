@@ -0,0 +1,78 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"github.com/pulumi/pulumi/pkg/resource"
+)
+
+// ResourceChange describes how a resource changed from one snapshot of a stack to another.
+type ResourceChange struct {
+	Op  StepOp          // how the resource changed: created, deleted, replaced, updated, or the same.
+	URN resource.URN    // the resource's URN.
+	Old *resource.State // the resource in the first snapshot, or nil if it was created.
+	New *resource.State // the resource in the second snapshot, or nil if it was deleted.
+}
+
+// CompareSnapshots returns how each resource in either of two snapshots of a stack, either of which may be nil,
+// changed from the first to the second.  A resource whose ID changed was replaced; one whose inputs or outputs changed
+// was updated.  Resources pending deletion are ignored.  The resources of the second snapshot come first, in its
+// order, followed by those that were deleted, in the order of the first.
+func CompareSnapshots(old *Snapshot, new *Snapshot) []ResourceChange {
+	olds, news := liveResources(old), liveResources(new)
+
+	var changes []ResourceChange
+	for _, n := range snapshotResources(new) {
+		if n.Delete {
+			continue
+		}
+		o, has := olds[n.URN]
+		switch {
+		case !has:
+			changes = append(changes, ResourceChange{Op: OpCreate, URN: n.URN, New: n})
+		case o.ID != n.ID:
+			changes = append(changes, ResourceChange{Op: OpReplace, URN: n.URN, Old: o, New: n})
+		case !o.Inputs.DeepEquals(n.Inputs) || !o.Outputs.DeepEquals(n.Outputs):
+			changes = append(changes, ResourceChange{Op: OpUpdate, URN: n.URN, Old: o, New: n})
+		default:
+			changes = append(changes, ResourceChange{Op: OpSame, URN: n.URN, Old: o, New: n})
+		}
+	}
+	for _, o := range snapshotResources(old) {
+		if _, has := news[o.URN]; !has && !o.Delete {
+			changes = append(changes, ResourceChange{Op: OpDelete, URN: o.URN, Old: o})
+		}
+	}
+	return changes
+}
+
+// liveResources returns the resources in a snapshot that are not pending deletion, indexed by URN.
+func liveResources(snap *Snapshot) map[resource.URN]*resource.State {
+	resources := make(map[resource.URN]*resource.State)
+	for _, res := range snapshotResources(snap) {
+		if !res.Delete {
+			resources[res.URN] = res
+		}
+	}
+	return resources
+}
+
+// snapshotResources returns the resources in a snapshot, which may be nil.
+func snapshotResources(snap *Snapshot) []*resource.State {
+	if snap == nil {
+		return nil
+	}
+	return snap.Resources
+}
@@ -0,0 +1,148 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/diag"
+	"github.com/pulumi/pulumi/pkg/resource"
+)
+
+// goalDependencies returns the resources on which a goal depends: its parent, if any, followed by its dependencies.
+func goalDependencies(goal *resource.Goal) []resource.URN {
+	if goal.Parent == "" {
+		return goal.Dependencies
+	}
+	return append([]resource.URN{goal.Parent}, goal.Dependencies...)
+}
+
+// findDependencyCycle returns a cycle of dependencies among the given goals that begins and ends with the resource
+// with the given URN, or nil if there is no such cycle.  Goals that have not been registered yet have no known
+// dependencies, so a cycle is only found once its last resource registers.
+func findDependencyCycle(urn resource.URN, goals map[resource.URN]*resource.Goal) []resource.URN {
+	visited := make(map[resource.URN]bool)
+	var visit func(cur resource.URN, path []resource.URN) []resource.URN
+	visit = func(cur resource.URN, path []resource.URN) []resource.URN {
+		goal, has := goals[cur]
+		if !has {
+			return nil
+		}
+		for _, dep := range goalDependencies(goal) {
+			if dep == urn {
+				return append(path, dep)
+			}
+			if !visited[dep] {
+				visited[dep] = true
+				if cycle := visit(dep, append(path, dep)); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		return nil
+	}
+	return visit(urn, []resource.URN{urn})
+}
+
+// edgeProperties returns the top-level properties of a goal whose values refer to the given dependency, by its URN or
+// ID.  Dependencies that no property refers to were most likely requested explicitly, using `dependsOn`.
+func edgeProperties(goal *resource.Goal, dep resource.URN, olds map[resource.URN]*resource.State) []string {
+	refs := []string{string(dep)}
+	if old, has := olds[dep]; has && old.ID != "" {
+		refs = append(refs, string(old.ID))
+	}
+
+	var props []string
+	for _, k := range goal.Properties.StableKeys() {
+		for _, ref := range refs {
+			if containsString(goal.Properties[k], ref) {
+				props = append(props, string(k))
+				break
+			}
+		}
+	}
+	return props
+}
+
+// containsString returns true if the given value is, or contains, the given string.
+func containsString(v resource.PropertyValue, s string) bool {
+	switch {
+	case v.IsString():
+		return v.StringValue() == s
+	case v.IsArray():
+		for _, elem := range v.ArrayValue() {
+			if containsString(elem, s) {
+				return true
+			}
+		}
+	case v.IsObject():
+		for _, elem := range v.ObjectValue() {
+			if containsString(elem, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// describeDependencyCycle renders each edge of a dependency cycle on its own line, noting the properties that create
+// it, and suggests an edge whose removal would break the cycle.
+func describeDependencyCycle(cycle []resource.URN, goals map[resource.URN]*resource.Goal,
+	olds map[resource.URN]*resource.State) (string, string) {
+
+	var lines []string
+	var suggestion string
+	for i := 0; i < len(cycle)-1; i++ {
+		from, to := cycle[i], cycle[i+1]
+		goal := goals[from]
+
+		var via string
+		switch props := edgeProperties(goal, to, olds); {
+		case goal.Parent == to:
+			via = "as its parent"
+		case len(props) > 0:
+			via = fmt.Sprintf("via properties [%s]", strings.Join(props, ", "))
+		default:
+			via = "via dependsOn"
+			if suggestion == "" {
+				suggestion = fmt.Sprintf("remove '%s' from the dependsOn of '%s'", to, from)
+			}
+		}
+		lines = append(lines, fmt.Sprintf("\t%s\n\t    depends on %s (%s)", from, to, via))
+	}
+	if suggestion == "" {
+		from, to := cycle[len(cycle)-2], cycle[len(cycle)-1]
+		suggestion = fmt.Sprintf("change '%s' so that it no longer depends on '%s'", from, to)
+	}
+	return strings.Join(lines, "\n"), suggestion
+}
+
+// checkDependencyCycles records the goal of the resource with the given URN, and returns an error if its
+// dependencies, or those of the resources it depends on, lead back to it.
+func (iter *PlanIterator) checkDependencyCycles(urn resource.URN, goal *resource.Goal) error {
+	iter.goals[urn] = goal
+
+	cycle := findDependencyCycle(urn, iter.goals)
+	if cycle == nil {
+		return nil
+	}
+
+	path, suggestion := describeDependencyCycle(cycle, iter.goals, iter.p.Olds())
+	iter.p.Diag().Errorf(diag.GetResourceDependencyCycleError(urn), urn, path, suggestion)
+	return errors.New("The program's resources contain a dependency cycle; refusing to proceed")
+}
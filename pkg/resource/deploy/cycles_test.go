@@ -0,0 +1,53 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+)
+
+func TestFindDependencyCycle(t *testing.T) {
+	t.Parallel()
+
+	a, b, c := resource.URN("urn:a"), resource.URN("urn:b"), resource.URN("urn:c")
+	goals := map[resource.URN]*resource.Goal{
+		a: {Dependencies: []resource.URN{b}},
+		b: {
+			Properties:   resource.PropertyMap{"source": resource.NewStringProperty(string(c))},
+			Dependencies: []resource.URN{c},
+		},
+	}
+
+	// No cycle exists until the last resource in it registers.
+	assert.Nil(t, findDependencyCycle(a, goals))
+
+	goals[c] = &resource.Goal{Parent: a}
+	cycle := findDependencyCycle(c, goals)
+	assert.Equal(t, []resource.URN{c, a, b, c}, cycle)
+
+	path, suggestion := describeDependencyCycle(cycle, goals, nil)
+	assert.True(t, strings.Contains(path, "depends on urn:a (as its parent)"))
+	assert.True(t, strings.Contains(path, "depends on urn:c (via properties [source])"))
+	assert.Equal(t, "remove 'urn:b' from the dependsOn of 'urn:a'", suggestion)
+
+	// A resource that depends on itself is a cycle of its own.
+	goals[a] = &resource.Goal{Dependencies: []resource.URN{a}}
+	assert.Equal(t, []resource.URN{a, a}, findDependencyCycle(a, goals))
+}
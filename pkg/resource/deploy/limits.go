@@ -0,0 +1,92 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"time"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/plugin"
+	"github.com/pulumi/pulumi/pkg/tokens"
+	"github.com/pulumi/pulumi/pkg/util/logging"
+)
+
+// newProviderLimits creates a semaphore for each provider package whose resource operations are limited to some
+// number of concurrent calls.  Packages without a positive limit are only limited by the plan's overall parallelism.
+func newProviderLimits(parallelism map[tokens.Package]int) map[tokens.Package]chan bool {
+	limits := make(map[tokens.Package]chan bool)
+	for pkg, n := range parallelism {
+		if n > 0 {
+			limits[pkg] = make(chan bool, n)
+		}
+	}
+	return limits
+}
+
+// limitingProvider is a provider whose Create, Read, Update, and Delete calls wait for a slot in a semaphore shared by
+// every use of the provider's package, so that no more of them are outstanding at once than the semaphore allows.
+type limitingProvider struct {
+	plugin.Provider
+
+	sem chan bool
+}
+
+// newLimitingProvider wraps a provider so that its resource operations acquire the given semaphore.  If there is no
+// semaphore, the provider is returned as-is.
+func newLimitingProvider(prov plugin.Provider, sem chan bool) plugin.Provider {
+	if sem == nil {
+		return prov
+	}
+	return &limitingProvider{Provider: prov, sem: sem}
+}
+
+// acquire waits for a slot in the provider's semaphore, and returns a function that releases it.
+func (p *limitingProvider) acquire(op string, urn resource.URN) func() {
+	select {
+	case p.sem <- true:
+	default:
+		logging.V(7).Infof("%s of %s is waiting for one of the %d slots of provider '%s'", op, urn, cap(p.sem), p.Pkg())
+		p.sem <- true
+	}
+	return func() { <-p.sem }
+}
+
+func (p *limitingProvider) Create(urn resource.URN, news resource.PropertyMap,
+	timeout time.Duration) (resource.ID, resource.PropertyMap, resource.Status, error) {
+
+	defer p.acquire("Create", urn)()
+	return p.Provider.Create(urn, news, timeout)
+}
+
+func (p *limitingProvider) Read(urn resource.URN, id resource.ID,
+	props resource.PropertyMap) (resource.PropertyMap, error) {
+
+	defer p.acquire("Read", urn)()
+	return p.Provider.Read(urn, id, props)
+}
+
+func (p *limitingProvider) Update(urn resource.URN, id resource.ID, olds resource.PropertyMap,
+	news resource.PropertyMap, timeout time.Duration) (resource.PropertyMap, resource.Status, error) {
+
+	defer p.acquire("Update", urn)()
+	return p.Provider.Update(urn, id, olds, news, timeout)
+}
+
+func (p *limitingProvider) Delete(urn resource.URN, id resource.ID, props resource.PropertyMap,
+	timeout time.Duration) (resource.Status, error) {
+
+	defer p.acquire("Delete", urn)()
+	return p.Provider.Delete(urn, id, props, timeout)
+}
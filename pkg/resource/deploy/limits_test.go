@@ -0,0 +1,72 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/tokens"
+)
+
+// TestProviderLimits ensures that no more of a provider's operations are outstanding at once than its limit allows,
+// even when every use of the provider's package is wrapped separately.
+func TestProviderLimits(t *testing.T) {
+	t.Parallel()
+
+	limits := newProviderLimits(map[tokens.Package]int{"saas": 2, "aws": 0})
+	assert.Nil(t, limits["aws"])
+	assert.Nil(t, limits["gcp"])
+
+	var lock sync.Mutex
+	outstanding, most := 0, 0
+	prov := &testProvider{
+		pkg: "saas",
+		delete: func(urn resource.URN, id resource.ID, props resource.PropertyMap,
+			timeout time.Duration) (resource.Status, error) {
+			lock.Lock()
+			outstanding++
+			if outstanding > most {
+				most = outstanding
+			}
+			lock.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			lock.Lock()
+			outstanding--
+			lock.Unlock()
+			return resource.StatusOK, nil
+		},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := newLimitingProvider(prov, limits["saas"]).Delete("urn", "id", nil, 0)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 2, most)
+	assert.Equal(t, prov, newLimitingProvider(prov, limits["aws"]))
+}
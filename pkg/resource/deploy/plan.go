@@ -34,10 +34,13 @@ type Plan struct {
 	target    *Target                          // the deployment target.
 	prev      *Snapshot                        // the old resource snapshot for comparison.
 	olds      map[resource.URN]*resource.State // a map of all old resources.
+	aliases   map[resource.URN]resource.URN    // the URNs of old resources, by the URNs they were previously known by.
 	source    Source                           // the source of new resources.
 	analyzers []tokens.QName                   // the analyzers to run during this plan's generation.
 	preview   bool                             // true if this plan is to be previewed rather than applied.
 	depGraph  *graph.DependencyGraph           // the dependency graph of the old snapshot
+	retry     RetryPolicy                      // how provider operations that fail transiently are retried.
+	limits    map[tokens.Package]chan bool     // semaphores limiting the concurrent operations of each provider.
 }
 
 // NewPlan creates a new deployment plan from a resource snapshot plus a package to evaluate.
@@ -59,6 +62,7 @@ func NewPlan(ctx *plugin.Context, target *Target, prev *Snapshot, source Source,
 	var depGraph *graph.DependencyGraph
 	// Produce a map of all old resources for fast resources.
 	olds := make(map[resource.URN]*resource.State)
+	aliases := make(map[resource.URN]resource.URN)
 	if prev != nil {
 		for _, oldres := range prev.Resources {
 			// Ignore resources that are pending deletion; these should not be recorded in the LUT.
@@ -69,6 +73,9 @@ func NewPlan(ctx *plugin.Context, target *Target, prev *Snapshot, source Source,
 			urn := oldres.URN
 			contract.Assert(olds[urn] == nil)
 			olds[urn] = oldres
+			for _, alias := range oldres.Aliases {
+				aliases[alias] = urn
+			}
 		}
 
 		depGraph = graph.NewDependencyGraph(prev.Resources)
@@ -79,6 +86,7 @@ func NewPlan(ctx *plugin.Context, target *Target, prev *Snapshot, source Source,
 		target:    target,
 		prev:      prev,
 		olds:      olds,
+		aliases:   aliases,
 		source:    source,
 		analyzers: analyzers,
 		preview:   preview,
@@ -99,5 +107,10 @@ func (p *Plan) IsRefresh() bool                        { return p.source.IsRefre
 func (p *Plan) Provider(pkg tokens.Package) (plugin.Provider, error) {
 	// TODO: ideally we would flow versions on specific requests along to the underlying host function.  Absent that,
 	//     we will just pass nil, which returns us the most recent version available to us.
-	return p.ctx.Host.Provider(pkg, nil)
+	prov, err := p.ctx.Host.Provider(pkg, nil)
+	if err != nil || prov == nil {
+		return prov, err
+	}
+	// Each attempt at an operation occupies one of its provider's slots, but waiting to retry it does not.
+	return newRetryingProvider(newLimitingProvider(prov, p.limits[pkg]), p.retry, p.Diag()), nil
 }
@@ -21,16 +21,29 @@ import (
 
 	"github.com/pulumi/pulumi/pkg/diag"
 	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/graph"
 	"github.com/pulumi/pulumi/pkg/resource/plugin"
 	"github.com/pulumi/pulumi/pkg/tokens"
 	"github.com/pulumi/pulumi/pkg/util/contract"
 	"github.com/pulumi/pulumi/pkg/util/logging"
+	"github.com/pulumi/pulumi/pkg/workspace"
 )
 
 // Options controls the planning and deployment process.
 type Options struct {
-	Events   Events // an optional events callback interface.
-	Parallel int    // the degree of parallelism for resource operations (<=1 for serial).
+	Events   Events         // an optional events callback interface.
+	Parallel int            // the degree of parallelism for resource operations (<=1 for serial).
+	Targets  []resource.URN // if non-empty, the only resources that may be created, updated, replaced, or deleted.
+	Retry    RetryPolicy    // how provider operations that fail with transient errors are retried.
+
+	// if non-empty, the only property paths of each resource's outputs that a refresh updates from its provider.
+	RefreshProperties []string
+
+	Transformations []workspace.Transformation // rewrites to apply to every matching resource the program registers.
+	BlueGreen       []workspace.BlueGreenGroup // groups of resources that are replaced in a create-then-swap fashion.
+
+	// the most resource operations that may be outstanding at once for each provider package, within Parallel.
+	ProviderParallelism map[tokens.Package]int
 }
 
 // Events is an interface that can be used to hook interesting engine/planning events.
@@ -42,25 +55,54 @@ type Events interface {
 
 // Start initializes and returns an iterator that can be used to step through a plan's individual steps.
 func (p *Plan) Start(opts Options) (*PlanIterator, error) {
+	// Provider operations performed by this plan's steps are retried according to its options.
+	p.retry = opts.Retry
+	p.limits = newProviderLimits(opts.ProviderParallelism)
+
+	// The plan's resources hold secrets wherever they're derived from the stack's secret configuration.
+	secrets, err := newSecretTracker(p.target, p.olds)
+	if err != nil {
+		return nil, err
+	}
+
 	// Ask the source for its iterator.
 	src, err := p.source.Iterate(opts)
 	if err != nil {
 		return nil, err
 	}
 
+	var targets, impliedTargets map[resource.URN]bool
+	if len(opts.Targets) > 0 {
+		targets = make(map[resource.URN]bool)
+		for _, urn := range opts.Targets {
+			targets[urn] = true
+		}
+		if p.source != NullSource && !p.IsRefresh() {
+			impliedTargets = p.impliedTargets(opts.Targets, targets)
+		}
+	}
+
 	// Create an iterator that can be used to perform the planning process.
 	return &PlanIterator{
-		p:           p,
-		opts:        opts,
-		src:         src,
-		urns:        make(map[resource.URN]bool),
-		creates:     make(map[resource.URN]bool),
-		updates:     make(map[resource.URN]bool),
-		replaces:    make(map[resource.URN]bool),
-		deletes:     make(map[resource.URN]bool),
-		sames:       make(map[resource.URN]bool),
-		pendingNews: make(map[resource.URN]Step),
-		dones:       make(map[*resource.State]bool),
+		p:              p,
+		opts:           opts,
+		src:            src,
+		targets:        targets,
+		impliedTargets: impliedTargets,
+		urns:           make(map[resource.URN]bool),
+		creates:        make(map[resource.URN]bool),
+		updates:        make(map[resource.URN]bool),
+		replaces:       make(map[resource.URN]bool),
+		deletes:        make(map[resource.URN]bool),
+		sames:          make(map[resource.URN]bool),
+		reads:          make(map[resource.URN]bool),
+		skippedCreates: make(map[resource.URN]bool),
+		pendingNews:    make(map[resource.URN]Step),
+		goals:          make(map[resource.URN]*resource.Goal),
+		dones:          make(map[*resource.State]bool),
+		secrets:        secrets,
+
+		blueGreenReplaces: make(map[string][]resource.URN),
 	}, nil
 }
 
@@ -81,17 +123,29 @@ type PlanIterator struct {
 	opts Options        // the options this iterator was created with.
 	src  SourceIterator // the iterator that fetches source resources.
 
-	urns     map[resource.URN]bool // URNs discovered.
-	creates  map[resource.URN]bool // URNs discovered to be created.
-	updates  map[resource.URN]bool // URNs discovered to be updated.
-	replaces map[resource.URN]bool // URNs discovered to be replaced.
-	deletes  map[resource.URN]bool // URNs discovered to be deleted.
-	sames    map[resource.URN]bool // URNs discovered to be the same.
+	targets        map[resource.URN]bool // the URNs targeted by this plan, or nil if all resources are targeted.
+	impliedTargets map[resource.URN]bool // the URNs that are targeted because targeted resources depend on them.
+
+	urns           map[resource.URN]bool // URNs discovered.
+	creates        map[resource.URN]bool // URNs discovered to be created.
+	updates        map[resource.URN]bool // URNs discovered to be updated.
+	replaces       map[resource.URN]bool // URNs discovered to be replaced.
+	deletes        map[resource.URN]bool // URNs discovered to be deleted.
+	sames          map[resource.URN]bool // URNs discovered to be the same.
+	reads          map[resource.URN]bool // URNs of external resources discovered to be read.
+	skippedCreates map[resource.URN]bool // URNs that would have been created, but were not targeted.
 
 	pendingNews map[resource.URN]Step // a map of logical steps currently active.
 
+	goals map[resource.URN]*resource.Goal // the goals registered so far, used to detect dependency cycles.
+
+	secrets *secretTracker // decides which properties of the resources registered hold secrets.
+
+	blueGreenReplaces map[string][]resource.URN // the resources replaced in each blue/green group, by group name.
+
 	stepqueue []Step                   // a queue of steps to drain.
-	delqueue  []Step                   // a queue of deletes left to perform.
+	delwaves  [][]Step                 // the waves of deletes left to perform, in order.
+	delwave   int                      // the number of waves of deletes started so far.
 	resources []*resource.State        // the resulting ordered resource states.
 	dones     map[*resource.State]bool // true for each old state we're done with.
 
@@ -132,6 +186,11 @@ func (iter *PlanIterator) Apply(step Step, preview bool) (resource.Status, error
 
 	// If there is no error, proceed to save the state; otherwise, go straight to the exit codepath.
 	if err == nil {
+		// Record which of the new state's properties hold secrets before it's saved or displayed.
+		if step.New() != nil {
+			iter.secrets.mark(step.Old(), step.New())
+		}
+
 		// If we have a state object, and this is a create or update, remember it, as we may need to update it later.
 		if step.Logical() && step.New() != nil {
 			if prior, has := iter.pendingNews[urn]; has {
@@ -200,6 +259,13 @@ outer:
 						iter.stepqueue = steps[1:]
 					}
 					return steps[0], nil
+				case ReadResourceEvent:
+					// If the intent is to read an external resource, read it, but never manage it.
+					step, steperr := iter.makeReadResourceStep(e)
+					if steperr != nil {
+						return nil, steperr
+					}
+					return step, nil
 				case RegisterResourceOutputsEvent:
 					// If the intent is to complete a prior resource registration, do so.  We do this by just
 					// processing the request from the existing state, and do not expose our callers to it.
@@ -215,7 +281,11 @@ outer:
 			// If all returns are nil, the source is done, note it, and don't go back for more.  Add any deletions to be
 			// performed, and then keep going 'round the next iteration of the loop so we can wrap up the planning.
 			iter.srcdone = true
-			iter.delqueue = iter.computeDeletes()
+			iter.checkTargets()
+			if err := iter.awaitBlueGreenGroups(); err != nil {
+				return nil, err
+			}
+			iter.delwaves = iter.computeDeleteWaves(iter.computeDeletes())
 		} else {
 			// The interpreter has finished, so we need to now drain any deletions that piled up.
 			if step := iter.nextDeleteStep(); step != nil {
@@ -275,8 +345,14 @@ func (iter *PlanIterator) diff(urn resource.URN, id resource.ID, oldInputs, oldO
 func (iter *PlanIterator) makeRegisterResourceSteps(e RegisterResourceEvent) ([]Step, error) {
 	var invalid bool // will be set to true if this object fails validation.
 
-	// Use the resource goal state name to produce a globally unique URN.
+	// Apply any transformations to the goal before planning, unless we're refreshing (in which case the goal is
+	// simply the resource's current state).
 	goal := e.Goal()
+	if !iter.p.IsRefresh() {
+		goal = transformGoal(goal, iter.opts.Transformations)
+	}
+
+	// Use the resource goal state name to produce a globally unique URN.
 	parentType := tokens.Type("")
 	if p := goal.Parent; p != "" && p.Type() != resource.RootStackType {
 		// Skip empty parents and don't use the root stack type; otherwise, use the full qualified type.
@@ -291,6 +367,11 @@ func (iter *PlanIterator) makeRegisterResourceSteps(e RegisterResourceEvent) ([]
 	}
 	iter.urns[urn] = true
 
+	// Make sure that this resource doesn't close a cycle of dependencies, which could never be satisfied.
+	if err := iter.checkDependencyCycles(urn, goal); err != nil {
+		return nil, err
+	}
+
 	// Check for an old resource so that we can figure out if this is a create, delete, etc., and/or to diff.
 	old, hasOld := iter.p.Olds()[urn]
 	var oldInputs resource.PropertyMap
@@ -298,11 +379,18 @@ func (iter *PlanIterator) makeRegisterResourceSteps(e RegisterResourceEvent) ([]
 	if hasOld {
 		oldInputs = old.Inputs
 		oldOutputs = old.Outputs
+	} else if renamed, has := iter.p.aliases[urn]; has {
+		// The program is registering a resource under a name that it no longer has, probably because the stack was
+		// renamed but the program wasn't changed to match; warn that this will replace it.
+		iter.p.Diag().Warningf(diag.GetAliasedResourceWarning(urn), urn, renamed)
 	}
 
 	// Produce a new state object that we'll build up as operations are performed.  Ultimately, this is what will
 	// get serialized into the checkpoint file.  Normally there are no outputs, unless this is a refresh.
 	props, inputs, outputs, new := iter.getResourcePropertyStates(urn, goal)
+	if hasOld {
+		new.Aliases = old.Aliases
+	}
 
 	// Fetch the provider for this resource type, assuming it isn't just a logical one.
 	var prov plugin.Provider
@@ -322,6 +410,23 @@ func (iter *PlanIterator) makeRegisterResourceSteps(e RegisterResourceEvent) ([]
 	// We may be re-creating this resource if it got deleted earlier in the execution of this plan.
 	recreating := iter.deletes[urn]
 
+	// If only some resources are targeted by this plan, leave any others exactly as they were.  A resource that was
+	// deleted earlier in this plan must be re-created, however, whether or not it is targeted.
+	if !recreating && !iter.isTargeted(urn) {
+		return iter.makeUntargetedSteps(e, old, new), nil
+	}
+	if err = iter.checkUntargetedDependencies(urn, goal); err != nil {
+		return nil, err
+	}
+
+	// If the program asked us to ignore changes to certain properties, carry their old values forward so that those
+	// properties neither trigger an update nor show up in the diff.
+	if hasOld && !recreating && !refresh && len(goal.IgnoreChanges) > 0 {
+		inputs = processIgnoreChanges(urn, oldInputs, inputs, goal.IgnoreChanges)
+		props = inputs
+		new.Inputs = inputs
+	}
+
 	// If this isn't a refresh, ensure the provider is okay with this resource and fetch the inputs to pass to
 	// subsequent methods.  If these are not inputs, we are just going to blindly store the outputs, so skip this.
 	if prov != nil && !refresh {
@@ -393,7 +498,7 @@ func (iter *PlanIterator) makeRegisterResourceSteps(e RegisterResourceEvent) ([]
 		delete(iter.deletes, urn)
 		iter.replaces[urn] = true
 		return []Step{
-			NewReplaceStep(iter.p, old, new, nil, false),
+			NewReplaceStep(iter.p, old, new, nil, nil, false),
 			NewCreateReplacementStep(iter.p, e, old, new, nil, false),
 		}, nil
 	}
@@ -408,6 +513,11 @@ func (iter *PlanIterator) makeRegisterResourceSteps(e RegisterResourceEvent) ([]
 	if hasOld {
 		contract.Assert(old != nil && old.Type == new.Type)
 
+		// Refreshing a resource never clears its taint; only replacing it does.
+		if refresh {
+			new.Taint = old.Taint
+		}
+
 		// Determine whether the change resulted in a diff.
 		diff, err := iter.diff(urn, old.ID, oldInputs, oldOutputs, inputs, outputs, props, prov, refresh,
 			allowUnknowns)
@@ -421,9 +531,31 @@ func (iter *PlanIterator) makeRegisterResourceSteps(e RegisterResourceEvent) ([]
 				"unrecognized diff state for %s: %d", urn, diff.Changes)
 		}
 
+		// If the program asked for the resource to be replaced whenever certain properties change, and any of them
+		// have, insist upon a replacement, whatever the provider said.
+		if diff.Changes == plugin.DiffSome && !refresh && len(goal.ReplaceOnChanges) > 0 {
+			diff.ReplaceKeys = processReplaceOnChanges(oldInputs, inputs, diff.ReplaceKeys, goal.ReplaceOnChanges)
+		}
+
+		// A tainted resource is replaced, whether or not anything about it has changed.
+		tainted := old.Taint != "" && !refresh
+		if tainted {
+			logging.V(7).Infof("Planner decided to replace tainted resource '%v' (%v)", urn, old.Taint)
+			diff.Changes = plugin.DiffSome
+		}
+
+		// A member of a blue/green group is never updated in place; if it changes, it is replaced.
+		var blueGreen *workspace.BlueGreenGroup
+		if !refresh {
+			blueGreen = blueGreenGroup(goal, iter.opts.BlueGreen)
+		}
+		if blueGreen != nil && diff.Changes == plugin.DiffSome && !diff.Replace() {
+			logging.V(7).Infof("Planner decided to replace '%v', a member of blue/green group '%v'", urn, blueGreen.Name)
+		}
+
 		// If there were changes, check for a replacement vs. an in-place update.
 		if diff.Changes == plugin.DiffSome {
-			if diff.Replace() {
+			if diff.Replace() || tainted || blueGreen != nil {
 				iter.replaces[urn] = true
 
 				// If we are going to perform a replacement, we need to recompute the default values.  The above logic
@@ -456,9 +588,16 @@ func (iter *PlanIterator) makeRegisterResourceSteps(e RegisterResourceEvent) ([]
 				//       until pulumi/pulumi#624 is resolved, we cannot safely perform this operation on resources
 				//       that have dependent resources (we try to delete the resource while they refer to it).
 				//
-				// The provider is responsible for requesting which of these two modes to use.
-
-				if diff.DeleteBeforeReplace {
+				// The provider is responsible for requesting which of these two modes to use, although the program
+				// may also insist upon DeleteBeforeCreate for any resource.
+
+				// The members of a blue/green group are replaced by first creating the new resource, unless the
+				// provider cannot do that: then the resource is deleted first, and leaves the group's replacement.
+				if blueGreen != nil && diff.DeleteBeforeReplace {
+					iter.p.Diag().Warningf(diag.GetBlueGreenDeleteBeforeReplaceWarning(urn), urn, blueGreen.Name)
+					blueGreen = nil
+				}
+				if (diff.DeleteBeforeReplace || goal.DeleteBeforeReplace) && blueGreen == nil {
 					logging.V(7).Infof("Planner decided to delete-before-replacement for resource '%v'", urn)
 					contract.Assert(iter.p.depGraph != nil)
 
@@ -492,14 +631,20 @@ func (iter *PlanIterator) makeRegisterResourceSteps(e RegisterResourceEvent) ([]
 
 					return append(steps,
 						NewDeleteReplacementStep(iter.p, old, false),
-						NewReplaceStep(iter.p, old, new, diff.ReplaceKeys, false),
+						NewReplaceStep(iter.p, old, new, diff.ReplaceKeys, diff.DetailedDiff, false),
 						NewCreateReplacementStep(iter.p, e, old, new, diff.ReplaceKeys, false),
 					), nil
 				}
 
+				// Note the replacements made in each blue/green group, whose old resources must not be deleted until
+				// the group is ready.
+				if blueGreen != nil {
+					iter.blueGreenReplaces[blueGreen.Name] = append(iter.blueGreenReplaces[blueGreen.Name], urn)
+				}
+
 				return []Step{
 					NewCreateReplacementStep(iter.p, e, old, new, diff.ReplaceKeys, true),
-					NewReplaceStep(iter.p, old, new, diff.ReplaceKeys, true),
+					NewReplaceStep(iter.p, old, new, diff.ReplaceKeys, diff.DetailedDiff, true),
 					// note that the delete step is generated "later" on, after all creates/updates finish.
 				}, nil
 			}
@@ -509,7 +654,7 @@ func (iter *PlanIterator) makeRegisterResourceSteps(e RegisterResourceEvent) ([]
 			if logging.V(7) {
 				logging.V(7).Infof("Planner decided to update '%v' (oldprops=%v inputs=%v", urn, oldInputs, new.Inputs)
 			}
-			return []Step{NewUpdateStep(iter.p, e, old, new, diff.StableKeys)}, nil
+			return []Step{NewUpdateStep(iter.p, e, old, new, diff.StableKeys, diff.DetailedDiff)}, nil
 		}
 
 		// No need to update anything, the properties didn't change.
@@ -528,6 +673,95 @@ func (iter *PlanIterator) makeRegisterResourceSteps(e RegisterResourceEvent) ([]
 	return []Step{NewCreateStep(iter.p, e, new)}, nil
 }
 
+// isTargeted returns true if the resource with the given URN may be changed by this plan.
+func (iter *PlanIterator) isTargeted(urn resource.URN) bool {
+	return iter.targets == nil || iter.targets[urn] || iter.impliedTargets[urn]
+}
+
+// impliedTargets returns the resources in the previous snapshot on which the given targets depend, directly or
+// indirectly, either as dependencies or as parents, and which aren't targets themselves.  These are targeted too, so
+// that a targeted resource is never changed without the resources that it depends on; each is reported, so that it's
+// clear from the preview why it is changing.  The root stack resource is left out, since everything descends from it.
+func (p *Plan) impliedTargets(urns []resource.URN, targets map[resource.URN]bool) map[resource.URN]bool {
+	implied := make(map[resource.URN]bool)
+	queue := append([]resource.URN(nil), urns...)
+	for len(queue) > 0 {
+		urn := queue[0]
+		queue = queue[1:]
+
+		old, has := p.olds[urn]
+		if !has {
+			continue
+		}
+		for _, dep := range append([]resource.URN{old.Parent}, old.Dependencies...) {
+			if dep == "" || targets[dep] || implied[dep] {
+				continue
+			}
+			if res, has := p.olds[dep]; !has || res.Type == resource.RootStackType {
+				continue
+			}
+			implied[dep] = true
+			p.Diag().Infof(diag.GetImpliedTargetInfo(dep), dep, urn)
+			queue = append(queue, dep)
+		}
+	}
+	return implied
+}
+
+// makeUntargetedSteps produces the step for a resource that isn't targeted by this plan.  If the resource already
+// exists, its old state is carried forward unchanged, regardless of what the program asked for; otherwise, its
+// creation is skipped, and it will not be recorded in the resulting snapshot.
+func (iter *PlanIterator) makeUntargetedSteps(e RegisterResourceEvent, old *resource.State,
+	new *resource.State) []Step {
+
+	if old == nil {
+		logging.V(7).Infof("Planner decided to skip creating untargeted resource '%v'", new.URN)
+		iter.skippedCreates[new.URN] = true
+		return []Step{NewSkippedCreateStep(iter.p, e, new)}
+	}
+
+	logging.V(7).Infof("Planner decided not to update untargeted resource '%v'", old.URN)
+	iter.sames[old.URN] = true
+	same := resource.NewState(old.Type, old.URN, old.Custom, false, "",
+		old.Inputs, nil, old.Parent, old.Protect, old.Dependencies)
+	same.CustomTimeouts = old.CustomTimeouts
+	same.Taint = old.Taint
+	same.Aliases = old.Aliases
+	return []Step{NewSameStep(iter.p, e, old, same)}
+}
+
+// checkUntargetedDependencies returns an error if the targeted resource with the given URN depends on a resource that
+// was not created because it isn't targeted.  Existing resources that targets depend on are targeted implicitly (see
+// impliedTargets), but a new one only comes to light once its creation has already been skipped, when the program
+// registers the targeted resource that depends on it; by then it is too late to create it.
+func (iter *PlanIterator) checkUntargetedDependencies(urn resource.URN, goal *resource.Goal) error {
+	deps := goal.Dependencies
+	if goal.Parent != "" {
+		deps = append([]resource.URN{goal.Parent}, deps...)
+	}
+
+	var failed bool
+	for _, dep := range deps {
+		if iter.skippedCreates[dep] {
+			iter.p.Diag().Errorf(diag.GetUntargetedDependencyError(urn), urn, dep)
+			failed = true
+		}
+	}
+	if failed {
+		return errors.New("One or more targeted resources depend on new resources that are not targeted")
+	}
+	return nil
+}
+
+// checkTargets warns about any targets that refer to neither an existing resource nor one registered by the program.
+func (iter *PlanIterator) checkTargets() {
+	for _, urn := range iter.opts.Targets {
+		if _, hasOld := iter.p.Olds()[urn]; !hasOld && !iter.urns[urn] {
+			iter.p.Diag().Warningf(diag.GetTargetNotFoundWarning(urn), urn)
+		}
+	}
+}
+
 // getResourcePropertyStates returns the properties, inputs, outputs, and new resource state, given a goal state.
 func (iter *PlanIterator) getResourcePropertyStates(urn resource.URN, goal *resource.Goal) (resource.PropertyMap,
 	resource.PropertyMap, resource.PropertyMap, *resource.State) {
@@ -546,9 +780,52 @@ func (iter *PlanIterator) getResourcePropertyStates(urn resource.URN, goal *reso
 		// In the case of non-refreshes, outputs remain empty (they will be computed), but inputs are present.
 		inputs = props
 	}
-	return props, inputs, outputs,
-		resource.NewState(goal.Type, urn, goal.Custom, false, "",
-			inputs, outputs, goal.Parent, goal.Protect, goal.Dependencies)
+	new := resource.NewState(goal.Type, urn, goal.Custom, false, "",
+		inputs, outputs, goal.Parent, goal.Protect, goal.Dependencies)
+	new.CustomTimeouts = goal.CustomTimeouts
+	new.PropertyDependencies = goal.PropertyDependencies
+	return props, inputs, outputs, new
+}
+
+// processIgnoreChanges resets each of the given property paths in news to its value in olds, returning the result.
+func processIgnoreChanges(urn resource.URN, olds resource.PropertyMap, news resource.PropertyMap,
+	ignoreChanges []string) resource.PropertyMap {
+	for _, ignore := range ignoreChanges {
+		reset, ok := resource.ParsePropertyPath(ignore).Reset(olds, news)
+		if !ok {
+			logging.V(7).Infof("Planner could not apply ignoreChanges path '%v' to '%v'", ignore, urn)
+			continue
+		}
+		news = reset
+	}
+	return news
+}
+
+// processReplaceOnChanges adds the top-level key of each of the given property paths whose value differs between olds
+// and news to the list of keys that cause the resource to be replaced, returning the result.
+func processReplaceOnChanges(olds resource.PropertyMap, news resource.PropertyMap, replaceKeys []resource.PropertyKey,
+	replaceOnChanges []string) []resource.PropertyKey {
+	for _, p := range replaceOnChanges {
+		path := resource.ParsePropertyPath(p)
+		if len(path) == 0 {
+			continue
+		}
+		old, hasOld := path.Get(olds)
+		new, hasNew := path.Get(news)
+		if hasOld == hasNew && (!hasOld || old.DeepEquals(new)) {
+			continue
+		}
+
+		key := resource.PropertyKey(path[0])
+		var has bool
+		for _, k := range replaceKeys {
+			has = has || k == key
+		}
+		if !has {
+			replaceKeys = append(replaceKeys, key)
+		}
+	}
+	return replaceKeys
 }
 
 // issueCheckErrors prints any check errors to the diagnostics sink.
@@ -570,6 +847,43 @@ func (iter *PlanIterator) issueCheckErrors(new *resource.State, urn resource.URN
 	return true
 }
 
+// makeReadResourceStep produces the step that reads an external resource.  The resource's state is recorded alongside
+// those of the resources this stack manages, but it is never created, updated, or deleted by this stack.
+func (iter *PlanIterator) makeReadResourceStep(e ReadResourceEvent) (Step, error) {
+	// External resources get URNs just as registered resources do, so that their dependents may refer to them.
+	parentType := tokens.Type("")
+	if p := e.Parent(); p != "" && p.Type() != resource.RootStackType {
+		parentType = p.QualifiedType()
+	}
+
+	urn := resource.NewURN(iter.p.Target().Name, iter.p.source.Project(), parentType, e.Type(), e.Name())
+	if iter.urns[urn] {
+		iter.p.Diag().Errorf(diag.GetDuplicateResourceURNError(urn), urn)
+		return nil, errors.Errorf("resource '%s' was registered or read more than once", urn)
+	}
+	iter.urns[urn] = true
+
+	// A resource that this stack manages must not be turned into an external one, or the engine would stop managing
+	// it without ever deleting it.
+	old, hasOld := iter.p.Olds()[urn]
+	if hasOld && !old.External {
+		return nil, errors.Errorf("resource '%s' is managed by this stack, and so cannot be read as an external "+
+			"resource; delete it from the stack first", urn)
+	}
+
+	new := resource.NewState(e.Type(), urn, true, false, e.ID(), e.Properties(), nil, e.Parent(), false,
+		e.Dependencies())
+	new.External = true
+	if hasOld {
+		// Until the resource has been read, its last known outputs are the best guess at its state.
+		new.Outputs = old.Outputs
+	}
+
+	logging.V(7).Infof("Planner decided to read external resource '%v' (id=%v)", urn, e.ID())
+	iter.reads[urn] = true
+	return NewReadStep(iter.p, e, old, new), nil
+}
+
 func (iter *PlanIterator) registerResourceOutputs(e RegisterResourceOutputsEvent) error {
 	// Look up the final state in the pending registration list.
 	urn := e.URN()
@@ -583,6 +897,7 @@ func (iter *PlanIterator) registerResourceOutputs(e RegisterResourceOutputsEvent
 	outs := e.Outputs()
 	logging.V(7).Infof("Registered resource outputs %s: old=#%d, new=#%d", urn, len(reg.New().Outputs), len(outs))
 	reg.New().Outputs = e.Outputs()
+	iter.secrets.markOutputs(reg.New())
 
 	// If there is an event subscription for finishing the resource, execute them.
 	if e := iter.opts.Events; e != nil {
@@ -604,15 +919,20 @@ func (iter *PlanIterator) computeDeletes() []Step {
 	// dependencies prior to their dependent nodes.
 	var dels []Step
 	if prev := iter.p.prev; prev != nil {
+		condemned := iter.computeCondemned(prev)
 		for i := len(prev.Resources) - 1; i >= 0; i-- {
 			// If this resource is explicitly marked for deletion or wasn't seen at all, delete it.
 			res := prev.Resources[i]
 			if res.Delete {
+				if !iter.isTargeted(res.URN) {
+					logging.V(7).Infof("Planner decided not to delete untargeted '%v' pending replacement", res.URN)
+					continue
+				}
 				logging.V(7).Infof("Planner decided to delete '%v' due to replacement", res.URN)
 				contract.Assert(!iter.deletes[res.URN])
 				iter.deletes[res.URN] = true
 				dels = append(dels, NewDeleteReplacementStep(iter.p, res, true))
-			} else if !iter.sames[res.URN] && !iter.updates[res.URN] && !iter.replaces[res.URN] && !iter.deletes[res.URN] {
+			} else if condemned[res.URN] {
 				logging.V(7).Infof("Planner decided to delete '%v'", res.URN)
 				iter.deletes[res.URN] = true
 				dels = append(dels, NewDeleteStep(iter.p, res))
@@ -622,16 +942,92 @@ func (iter *PlanIterator) computeDeletes() []Step {
 	return dels
 }
 
+// computeCondemned returns the URNs of the live resources in the previous snapshot that should be deleted because they
+// weren't seen.  If only some resources are targeted, a resource is only deleted if it is targeted, or if it depends on
+// another resource that is being deleted (and so cannot be left behind).
+func (iter *PlanIterator) computeCondemned(prev *Snapshot) map[resource.URN]bool {
+	condemned := make(map[resource.URN]bool)
+	for _, res := range prev.Resources {
+		urn := res.URN
+		if res.Delete || iter.sames[urn] || iter.updates[urn] || iter.replaces[urn] || iter.deletes[urn] ||
+			iter.reads[urn] {
+			continue
+		}
+
+		// Resources are stored in dependency order, so any dependencies have already been considered.
+		required := condemned[res.Parent]
+		for _, dep := range res.Dependencies {
+			required = required || condemned[dep]
+		}
+		// Resources that are only targeted because targets depend on them are updated, but never deleted.
+		if iter.targets == nil || iter.targets[urn] || required {
+			condemned[urn] = true
+		}
+	}
+	return condemned
+}
+
+// computeDeleteWaves groups the given deletes, which are in the order in which they may be performed serially, into
+// waves.  None of the resources deleted by a wave depend on one another, and so if resource operations may run in
+// parallel, each wave's deletes may be performed in parallel once the previous wave has finished.  Otherwise, all of
+// the deletes are performed in their original order, as a single wave.
+func (iter *PlanIterator) computeDeleteWaves(dels []Step) [][]Step {
+	if len(dels) == 0 {
+		return nil
+	}
+	if iter.opts.Parallel <= 1 {
+		return [][]Step{dels}
+	}
+
+	// Deletes are in the reverse of the snapshot's dependency order, whereas waves are computed in that order.
+	states := make([]*resource.State, len(dels))
+	steps := make(map[*resource.State]Step)
+	for i, del := range dels {
+		states[len(dels)-1-i] = del.Old()
+		steps[del.Old()] = del
+	}
+
+	var waves [][]Step
+	for _, wave := range graph.DeletionWaves(states) {
+		var wsteps []Step
+		for _, state := range wave {
+			wsteps = append(wsteps, steps[state])
+		}
+		waves = append(waves, wsteps)
+	}
+	return waves
+}
+
 // nextDeleteStep produces a new step that deletes a resource if necessary.
 func (iter *PlanIterator) nextDeleteStep() Step {
-	if len(iter.delqueue) > 0 {
-		del := iter.delqueue[0]
-		iter.delqueue = iter.delqueue[1:]
-		return del
+	for len(iter.delwaves) > 0 {
+		wave := iter.delwaves[0]
+		if len(wave) == 0 {
+			iter.delwaves = iter.delwaves[1:]
+			continue
+		}
+		iter.delwaves[0] = wave[1:]
+		return wave[0]
 	}
 	return nil
 }
 
+// NextDeleteWave returns, and removes from the plan, the rest of the wave to which the step most recently returned by
+// Next belongs, if it is a delete.  The deletes in a wave are independent of one another, and so may be applied in
+// parallel with that step.  Steps performed while the program is still running belong to no wave, and so for them, the
+// result is always empty.
+func (iter *PlanIterator) NextDeleteWave() []Step {
+	if !iter.srcdone || len(iter.delwaves) == 0 {
+		return nil
+	}
+	wave := iter.delwaves[0]
+	iter.delwaves = iter.delwaves[1:]
+
+	iter.delwave++
+	iter.p.Diag().Infof(diag.GetDeleteWaveInfo(""), iter.delwave, iter.delwave+len(iter.delwaves), len(wave)+1)
+	return wave
+}
+
 // Provider fetches the provider for a given resource type, possibly lazily allocating the plugins for it.  If a
 // provider could not be found, or an error occurred while creating it, a non-nil error is returned.
 func (iter *PlanIterator) Provider(t tokens.Type) (plugin.Provider, error) {
@@ -16,6 +16,7 @@ package deploy
 
 import (
 	"testing"
+	"time"
 
 	"github.com/blang/semver"
 	"github.com/pkg/errors"
@@ -206,20 +207,20 @@ func TestBasicCRUDPlan(t *testing.T) {
 	newResA := resource.NewGoal(typA, namA, true, resource.PropertyMap{
 		"af1": resource.NewStringProperty("a-value"),
 		"af2": resource.NewNumberProperty(42),
-	}, "", false, nil)
+	}, "", false, nil, nil, false, resource.CustomTimeouts{}, nil, nil)
 	newStateA := &testRegEvent{goal: newResA}
 	//     - B is updated:
 	newResB := resource.NewGoal(typB, namB, true, resource.PropertyMap{
 		"bf1": resource.NewStringProperty("b-value"),
 		// delete the bf2 field, and add bf3.
 		"bf3": resource.NewBoolProperty(true),
-	}, "", false, nil)
+	}, "", false, nil, nil, false, resource.CustomTimeouts{}, nil, nil)
 	newStateB := &testRegEvent{goal: newResB}
 	//     - C has no changes:
 	newResC := resource.NewGoal(typC, namC, true, resource.PropertyMap{
 		"cf1": resource.NewStringProperty("c-value"),
 		"cf2": resource.NewNumberProperty(83),
-	}, "", false, nil)
+	}, "", false, nil, nil, false, resource.CustomTimeouts{}, nil, nil)
 	newStateC := &testRegEvent{goal: newResC}
 	//     - No D; it is deleted.
 
@@ -333,6 +334,339 @@ func TestBasicCRUDPlan(t *testing.T) {
 	assert.True(t, iter.Deletes()[urnD])
 }
 
+// TestTargetedPlan creates a plan that is restricted to a subset of the resources it would otherwise change.
+func TestTargetedPlan(t *testing.T) {
+	t.Parallel()
+
+	pkg := tokens.Package("testtarget")
+	ctx, err := plugin.NewContext(cmdutil.Diag(), &testProviderHost{
+		provider: func(propkg tokens.Package, version *semver.Version) (plugin.Provider, error) {
+			return &testProvider{
+				check: func(urn resource.URN,
+					olds, news resource.PropertyMap) (resource.PropertyMap, []plugin.CheckFailure, error) {
+					return news, nil, nil // accept all changes.
+				},
+				diff: func(urn resource.URN, id resource.ID, olds resource.PropertyMap,
+					news resource.PropertyMap) (plugin.DiffResult, error) {
+					return plugin.DiffResult{}, nil // accept all changes.
+				},
+			}, nil
+		},
+	}, nil, nil, "", nil)
+	assert.Nil(t, err)
+
+	targ := &Target{Name: tokens.QName("target")}
+	mod := tokens.Module(pkg + ":index")
+	typ := tokens.Type(mod + ":R")
+	urnOf := func(name tokens.QName) resource.URN {
+		return resource.NewURN(targ.Name, pkg.Name(), "", typ, name)
+	}
+	props := func(v string) resource.PropertyMap {
+		return resource.PropertyMap{"f": resource.NewStringProperty(v)}
+	}
+
+	// B and C are changed by the program, and D and E (which depends on D) are removed from it.  A is new.
+	urnA, urnB, urnC, urnD, urnE := urnOf("a"), urnOf("b"), urnOf("c"), urnOf("d"), urnOf("e")
+	oldResB := resource.NewState(typ, urnB, true, false, "b-b-b", props("b"), nil, "", false, nil)
+	oldResC := resource.NewState(typ, urnC, true, false, "c-c-c", props("c"), nil, "", false, nil)
+	oldResD := resource.NewState(typ, urnD, true, false, "d-d-d", props("d"), nil, "", false, nil)
+	oldResE := resource.NewState(typ, urnE, true, false, "e-e-e", props("e"), nil, "", false,
+		[]resource.URN{urnD})
+	oldsnap := NewSnapshot(Manifest{}, []*resource.State{oldResB, oldResC, oldResD, oldResE})
+
+	source := NewFixedSource(pkg.Name(), []SourceEvent{
+		&testRegEvent{goal: resource.NewGoal(typ, "a", true, props("a"), "", false, nil, nil, false,
+			resource.CustomTimeouts{}, nil, nil)},
+		&testRegEvent{goal: resource.NewGoal(typ, "b", true, props("b2"), "", false, nil, nil, false,
+			resource.CustomTimeouts{}, nil, nil)},
+		&testRegEvent{goal: resource.NewGoal(typ, "c", true, props("c2"), "", false, nil, nil, false,
+			resource.CustomTimeouts{}, nil, nil)},
+	})
+
+	// Only B and D are targeted; E must be deleted too, since it depends on D.
+	plan := NewPlan(ctx, targ, oldsnap, source, nil, false)
+	iter, err := plan.Start(Options{Targets: []resource.URN{urnB, urnD}})
+	assert.Nil(t, err)
+
+	ops := make(map[resource.URN]StepOp)
+	for {
+		step, err := iter.Next()
+		assert.Nil(t, err)
+		if step == nil {
+			break
+		}
+		ops[step.URN()] = step.Op()
+
+		switch step.URN() {
+		case urnA:
+			same, ok := step.(*SameStep)
+			assert.True(t, ok)
+			assert.True(t, same.IsSkippedCreate())
+		case urnC:
+			// C's old state is carried forward, ignoring the program's changes.
+			assert.Equal(t, oldResC.Inputs, step.New().Inputs)
+		}
+
+		_, err = step.Apply(true)
+		assert.Nil(t, err)
+	}
+
+	assert.Equal(t, map[resource.URN]StepOp{
+		urnA: OpSame,
+		urnB: OpUpdate,
+		urnC: OpSame,
+		urnD: OpDelete,
+		urnE: OpDelete,
+	}, ops)
+}
+
+// TestTargetedPlanImpliedTargets ensures that the existing resources on which targeted resources depend, whether as
+// dependencies or as parents, are targeted too.
+func TestTargetedPlanImpliedTargets(t *testing.T) {
+	t.Parallel()
+
+	pkg := tokens.Package("testimplied")
+	ctx, err := plugin.NewContext(cmdutil.Diag(), &testProviderHost{
+		provider: func(propkg tokens.Package, version *semver.Version) (plugin.Provider, error) {
+			return &testProvider{
+				check: func(urn resource.URN,
+					olds, news resource.PropertyMap) (resource.PropertyMap, []plugin.CheckFailure, error) {
+					return news, nil, nil // accept all changes.
+				},
+				diff: func(urn resource.URN, id resource.ID, olds resource.PropertyMap,
+					news resource.PropertyMap) (plugin.DiffResult, error) {
+					return plugin.DiffResult{}, nil // accept all changes.
+				},
+			}, nil
+		},
+	}, nil, nil, "", nil)
+	assert.Nil(t, err)
+
+	targ := &Target{Name: tokens.QName("target")}
+	mod := tokens.Module(pkg + ":index")
+	typ := tokens.Type(mod + ":R")
+	urnOf := func(name tokens.QName) resource.URN {
+		return resource.NewURN(targ.Name, pkg.Name(), "", typ, name)
+	}
+	props := func(v string) resource.PropertyMap {
+		return resource.PropertyMap{"f": resource.NewStringProperty(v)}
+	}
+
+	// B depends on A, which depends on Z; D is a child of C; E is unrelated.  The program changes all of them.
+	urnZ, urnA, urnB, urnC, urnE := urnOf("z"), urnOf("a"), urnOf("b"), urnOf("c"), urnOf("e")
+	urnD := resource.NewURN(targ.Name, pkg.Name(), urnC.QualifiedType(), typ, "d")
+	oldResZ := resource.NewState(typ, urnZ, true, false, "z-z-z", props("z"), nil, "", false, nil)
+	oldResA := resource.NewState(typ, urnA, true, false, "a-a-a", props("a"), nil, "", false, []resource.URN{urnZ})
+	oldResB := resource.NewState(typ, urnB, true, false, "b-b-b", props("b"), nil, "", false, []resource.URN{urnA})
+	oldResC := resource.NewState(typ, urnC, true, false, "c-c-c", props("c"), nil, "", false, nil)
+	oldResD := resource.NewState(typ, urnD, true, false, "d-d-d", props("d"), nil, urnC, false, nil)
+	oldResE := resource.NewState(typ, urnE, true, false, "e-e-e", props("e"), nil, "", false, nil)
+	oldsnap := NewSnapshot(Manifest{}, []*resource.State{oldResZ, oldResA, oldResB, oldResC, oldResD, oldResE})
+
+	goal := func(name tokens.QName, v string, parent resource.URN, deps ...resource.URN) SourceEvent {
+		return &testRegEvent{goal: resource.NewGoal(typ, name, true, props(v), parent, false, deps, nil, false,
+			resource.CustomTimeouts{}, nil, nil)}
+	}
+	source := NewFixedSource(pkg.Name(), []SourceEvent{
+		goal("z", "z2", ""),
+		goal("a", "a2", "", urnZ),
+		goal("b", "b2", "", urnA),
+		goal("c", "c2", ""),
+		goal("d", "d2", urnC),
+		goal("e", "e2", ""),
+	})
+
+	// Only B and D are targeted, but they can't be updated without A and Z, and C, respectively.
+	plan := NewPlan(ctx, targ, oldsnap, source, nil, false)
+	iter, err := plan.Start(Options{Targets: []resource.URN{urnB, urnD}})
+	assert.Nil(t, err)
+
+	ops := make(map[resource.URN]StepOp)
+	for {
+		step, err := iter.Next()
+		assert.Nil(t, err)
+		if step == nil {
+			break
+		}
+		ops[step.URN()] = step.Op()
+
+		_, err = step.Apply(true)
+		assert.Nil(t, err)
+	}
+
+	assert.Equal(t, map[resource.URN]StepOp{
+		urnZ: OpUpdate,
+		urnA: OpUpdate,
+		urnB: OpUpdate,
+		urnC: OpUpdate,
+		urnD: OpUpdate,
+		urnE: OpSame,
+	}, ops)
+}
+
+// TestDeleteBeforeReplaceGoal ensures that a program can insist that a resource be deleted before it is replaced, even
+// though its provider doesn't require it, and that the resources depending on it are replaced along with it.
+func TestDeleteBeforeReplaceGoal(t *testing.T) {
+	t.Parallel()
+
+	pkg := tokens.Package("testdbr")
+	ctx, err := plugin.NewContext(cmdutil.Diag(), &testProviderHost{
+		provider: func(propkg tokens.Package, version *semver.Version) (plugin.Provider, error) {
+			return &testProvider{
+				check: func(urn resource.URN,
+					olds, news resource.PropertyMap) (resource.PropertyMap, []plugin.CheckFailure, error) {
+					return news, nil, nil // accept all changes.
+				},
+				diff: func(urn resource.URN, id resource.ID, olds resource.PropertyMap,
+					news resource.PropertyMap) (plugin.DiffResult, error) {
+					// every change requires replacement, but the provider doesn't ask for delete-before-replace.
+					return plugin.DiffResult{Changes: plugin.DiffSome, ReplaceKeys: []resource.PropertyKey{"f"}}, nil
+				},
+			}, nil
+		},
+	}, nil, nil, "", nil)
+	assert.Nil(t, err)
+
+	targ := &Target{Name: tokens.QName("target")}
+	mod := tokens.Module(pkg + ":index")
+	typ := tokens.Type(mod + ":R")
+	urnOf := func(name tokens.QName) resource.URN {
+		return resource.NewURN(targ.Name, pkg.Name(), "", typ, name)
+	}
+	props := func(v string) resource.PropertyMap {
+		return resource.PropertyMap{"f": resource.NewStringProperty(v)}
+	}
+
+	// A is changed by the program, which asks for it to be deleted before it is replaced; B depends on A.
+	urnA, urnB := urnOf("a"), urnOf("b")
+	oldResA := resource.NewState(typ, urnA, true, false, "a-a-a", props("a"), nil, "", false, nil)
+	oldResB := resource.NewState(typ, urnB, true, false, "b-b-b", props("b"), nil, "", false,
+		[]resource.URN{urnA})
+	oldsnap := NewSnapshot(Manifest{}, []*resource.State{oldResA, oldResB})
+
+	source := NewFixedSource(pkg.Name(), []SourceEvent{
+		&testRegEvent{goal: resource.NewGoal(typ, "a", true, props("a2"), "", false, nil, nil, true,
+			resource.CustomTimeouts{}, nil, nil)},
+		&testRegEvent{goal: resource.NewGoal(typ, "b", true, props("b"), "", false, []resource.URN{urnA}, nil,
+			false, resource.CustomTimeouts{}, nil, nil)},
+	})
+
+	plan := NewPlan(ctx, targ, oldsnap, source, nil, false)
+	iter, err := plan.Start(Options{})
+	assert.Nil(t, err)
+
+	type urnOp struct {
+		URN resource.URN
+		Op  StepOp
+	}
+	var ops []urnOp
+	for {
+		step, err := iter.Next()
+		assert.Nil(t, err)
+		if step == nil {
+			break
+		}
+		ops = append(ops, urnOp{step.URN(), step.Op()})
+		if replace, ok := step.(*ReplaceStep); ok {
+			assert.True(t, replace.DeleteBeforeReplace())
+		}
+
+		_, err = step.Apply(true)
+		assert.Nil(t, err)
+	}
+
+	assert.Equal(t, []urnOp{
+		{urnB, OpDeleteReplaced},
+		{urnA, OpDeleteReplaced},
+		{urnA, OpReplace},
+		{urnA, OpCreateReplacement},
+		{urnB, OpReplace},
+		{urnB, OpCreateReplacement},
+	}, ops)
+}
+
+// TestCustomTimeouts ensures that each resource operation is given the timeout that the program asked for, and that
+// delete timeouts are remembered for resources that the program no longer declares.
+func TestCustomTimeouts(t *testing.T) {
+	t.Parallel()
+
+	timeouts := make(map[resource.URN]time.Duration)
+	pkg := tokens.Package("testtimeouts")
+	ctx, err := plugin.NewContext(cmdutil.Diag(), &testProviderHost{
+		provider: func(propkg tokens.Package, version *semver.Version) (plugin.Provider, error) {
+			return &testProvider{
+				check: func(urn resource.URN,
+					olds, news resource.PropertyMap) (resource.PropertyMap, []plugin.CheckFailure, error) {
+					return news, nil, nil // accept all changes.
+				},
+				diff: func(urn resource.URN, id resource.ID, olds resource.PropertyMap,
+					news resource.PropertyMap) (plugin.DiffResult, error) {
+					return plugin.DiffResult{Changes: plugin.DiffSome}, nil
+				},
+				create: func(urn resource.URN, news resource.PropertyMap,
+					timeout time.Duration) (resource.ID, resource.PropertyMap, resource.Status, error) {
+					timeouts[urn] = timeout
+					return "created-id", resource.PropertyMap{}, resource.StatusOK, nil
+				},
+				update: func(urn resource.URN, id resource.ID, olds, news resource.PropertyMap,
+					timeout time.Duration) (resource.PropertyMap, resource.Status, error) {
+					timeouts[urn] = timeout
+					return resource.PropertyMap{}, resource.StatusOK, nil
+				},
+				delete: func(urn resource.URN, id resource.ID, props resource.PropertyMap,
+					timeout time.Duration) (resource.Status, error) {
+					timeouts[urn] = timeout
+					return resource.StatusOK, nil
+				},
+			}, nil
+		},
+	}, nil, nil, "", nil)
+	assert.Nil(t, err)
+
+	targ := &Target{Name: tokens.QName("target")}
+	mod := tokens.Module(pkg + ":index")
+	typ := tokens.Type(mod + ":R")
+	urnOf := func(name tokens.QName) resource.URN {
+		return resource.NewURN(targ.Name, pkg.Name(), "", typ, name)
+	}
+	props := func(v string) resource.PropertyMap {
+		return resource.PropertyMap{"f": resource.NewStringProperty(v)}
+	}
+
+	// A is new, B is changed, and C, which was given a delete timeout when it was created, is removed.
+	urnA, urnB, urnC := urnOf("a"), urnOf("b"), urnOf("c")
+	oldResB := resource.NewState(typ, urnB, true, false, "b-b-b", props("b"), nil, "", false, nil)
+	oldResC := resource.NewState(typ, urnC, true, false, "c-c-c", props("c"), nil, "", false, nil)
+	oldResC.CustomTimeouts = resource.CustomTimeouts{Delete: 3 * time.Minute}
+	oldsnap := NewSnapshot(Manifest{}, []*resource.State{oldResB, oldResC})
+
+	source := NewFixedSource(pkg.Name(), []SourceEvent{
+		&testRegEvent{goal: resource.NewGoal(typ, "a", true, props("a"), "", false, nil, nil, false,
+			resource.CustomTimeouts{Create: time.Minute}, nil, nil)},
+		&testRegEvent{goal: resource.NewGoal(typ, "b", true, props("b2"), "", false, nil, nil, false,
+			resource.CustomTimeouts{Update: 2 * time.Minute}, nil, nil)},
+	})
+
+	plan := NewPlan(ctx, targ, oldsnap, source, nil, false)
+	iter, err := plan.Start(Options{})
+	assert.Nil(t, err)
+	for {
+		step, err := iter.Next()
+		assert.Nil(t, err)
+		if step == nil {
+			break
+		}
+		_, err = step.Apply(false)
+		assert.Nil(t, err)
+	}
+
+	assert.Equal(t, map[resource.URN]time.Duration{
+		urnA: time.Minute,
+		urnB: 2 * time.Minute,
+		urnC: 3 * time.Minute,
+	}, timeouts)
+}
+
 type testRegEvent struct {
 	goal   *resource.Goal
 	result *RegisterResult
@@ -398,12 +732,13 @@ type testProvider struct {
 	config func(map[config.Key]string) error
 	check  func(resource.URN,
 		resource.PropertyMap, resource.PropertyMap) (resource.PropertyMap, []plugin.CheckFailure, error)
-	create func(resource.URN, resource.PropertyMap) (resource.ID, resource.PropertyMap, resource.Status, error)
+	create func(resource.URN, resource.PropertyMap,
+		time.Duration) (resource.ID, resource.PropertyMap, resource.Status, error)
 	diff   func(resource.URN, resource.ID, resource.PropertyMap, resource.PropertyMap) (plugin.DiffResult, error)
 	read   func(resource.URN, resource.ID, resource.PropertyMap) (resource.PropertyMap, error)
 	update func(resource.URN, resource.ID,
-		resource.PropertyMap, resource.PropertyMap) (resource.PropertyMap, resource.Status, error)
-	delete func(resource.URN, resource.ID, resource.PropertyMap) (resource.Status, error)
+		resource.PropertyMap, resource.PropertyMap, time.Duration) (resource.PropertyMap, resource.Status, error)
+	delete func(resource.URN, resource.ID, resource.PropertyMap, time.Duration) (resource.Status, error)
 	invoke func(tokens.ModuleMember, resource.PropertyMap) (resource.PropertyMap, []plugin.CheckFailure, error)
 }
 
@@ -420,9 +755,9 @@ func (prov *testProvider) Check(urn resource.URN,
 	olds, news resource.PropertyMap, _ bool) (resource.PropertyMap, []plugin.CheckFailure, error) {
 	return prov.check(urn, olds, news)
 }
-func (prov *testProvider) Create(urn resource.URN, props resource.PropertyMap) (resource.ID,
-	resource.PropertyMap, resource.Status, error) {
-	return prov.create(urn, props)
+func (prov *testProvider) Create(urn resource.URN, props resource.PropertyMap,
+	timeout time.Duration) (resource.ID, resource.PropertyMap, resource.Status, error) {
+	return prov.create(urn, props, timeout)
 }
 func (prov *testProvider) Read(urn resource.URN, id resource.ID,
 	props resource.PropertyMap) (resource.PropertyMap, error) {
@@ -432,13 +767,13 @@ func (prov *testProvider) Diff(urn resource.URN, id resource.ID,
 	olds resource.PropertyMap, news resource.PropertyMap, _ bool) (plugin.DiffResult, error) {
 	return prov.diff(urn, id, olds, news)
 }
-func (prov *testProvider) Update(urn resource.URN, id resource.ID,
-	olds resource.PropertyMap, news resource.PropertyMap) (resource.PropertyMap, resource.Status, error) {
-	return prov.update(urn, id, olds, news)
+func (prov *testProvider) Update(urn resource.URN, id resource.ID, olds resource.PropertyMap,
+	news resource.PropertyMap, timeout time.Duration) (resource.PropertyMap, resource.Status, error) {
+	return prov.update(urn, id, olds, news, timeout)
 }
 func (prov *testProvider) Delete(urn resource.URN,
-	id resource.ID, props resource.PropertyMap) (resource.Status, error) {
-	return prov.delete(urn, id, props)
+	id resource.ID, props resource.PropertyMap, timeout time.Duration) (resource.Status, error) {
+	return prov.delete(urn, id, props, timeout)
 }
 func (prov *testProvider) Invoke(tok tokens.ModuleMember,
 	args resource.PropertyMap) (resource.PropertyMap, []plugin.CheckFailure, error) {
@@ -449,3 +784,61 @@ func (prov *testProvider) GetPluginInfo() (workspace.PluginInfo, error) {
 		Name: "testProvider",
 	}, nil
 }
+
+// TestProcessReplaceOnChanges ensures that a change to any property that a program lists in replaceOnChanges causes a
+// replacement, keyed by the property's top-level key.
+func TestProcessReplaceOnChanges(t *testing.T) {
+	t.Parallel()
+
+	olds := resource.PropertyMap{
+		"image": resource.NewStringProperty("nginx:1.14"),
+		"spec": resource.NewObjectProperty(resource.PropertyMap{
+			"replicas": resource.NewNumberProperty(1),
+			"port":     resource.NewNumberProperty(80),
+		}),
+	}
+	news := resource.PropertyMap{
+		"image": resource.NewStringProperty("nginx:1.14"),
+		"spec": resource.NewObjectProperty(resource.PropertyMap{
+			"replicas": resource.NewNumberProperty(2),
+			"port":     resource.NewNumberProperty(80),
+		}),
+		"name": resource.NewStringProperty("web"),
+	}
+
+	assert.Nil(t, processReplaceOnChanges(olds, news, nil, []string{"image", "spec.port"}))
+	assert.Equal(t, []resource.PropertyKey{"spec"},
+		processReplaceOnChanges(olds, news, nil, []string{"image", "spec.replicas"}))
+	assert.Equal(t, []resource.PropertyKey{"spec", "name"},
+		processReplaceOnChanges(olds, news, []resource.PropertyKey{"spec"}, []string{"spec.replicas", "name"}))
+}
+
+// TestComputeDeleteWaves ensures that deletes are grouped into waves of independent resources only if operations may
+// run in parallel, and that each resource is deleted in a later wave than everything that depends on it.
+func TestComputeDeleteWaves(t *testing.T) {
+	t.Parallel()
+
+	newState := func(name string, deps ...resource.URN) *resource.State {
+		urn := resource.URN("urn:pulumi:test::test::test:resource:type::" + name)
+		return resource.NewState("test:resource:type", urn, false, false, "", resource.PropertyMap{}, nil, "", false, deps)
+	}
+	a := newState("a")
+	b := newState("b", a.URN)
+	c := newState("c", a.URN)
+	d := newState("d")
+
+	// Deletes are computed in the reverse of the snapshot's order.
+	var dels []Step
+	for _, res := range []*resource.State{d, c, b, a} {
+		dels = append(dels, NewDeleteStep(nil, res))
+	}
+
+	serial := &PlanIterator{opts: Options{}}
+	assert.Equal(t, [][]Step{dels}, serial.computeDeleteWaves(dels))
+
+	parallel := &PlanIterator{opts: Options{Parallel: 4}}
+	waves := parallel.computeDeleteWaves(dels)
+	assert.Len(t, waves, 2)
+	assert.Equal(t, []Step{dels[0], dels[1], dels[2]}, waves[0])
+	assert.Equal(t, []Step{dels[3]}, waves[1])
+}
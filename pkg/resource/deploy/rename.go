@@ -0,0 +1,93 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/tokens"
+)
+
+// RenameStack rewrites the URNs of a snapshot's resources, and the references between them, to name the given stack
+// and project, as they would if the resources had been created by a stack with those names; if newProject is empty,
+// each resource keeps its project.  The stack's root resource, whose name is made up of the project and stack names,
+// is renamed to match.  The URNs that resources had before are recorded as their aliases.
+func RenameStack(snap *Snapshot, newStack tokens.QName, newProject tokens.PackageName) error {
+	if snap == nil {
+		return nil
+	}
+
+	renames := make(map[resource.URN]resource.URN)
+	rename := func(urn resource.URN) resource.URN {
+		if urn == "" {
+			return urn
+		}
+		if renamed, has := renames[urn]; has {
+			return renamed
+		}
+
+		project := newProject
+		if project == "" {
+			project = urn.Project()
+		}
+		name := urn.Name()
+		if urn.Type() == resource.RootStackType && string(name) == string(urn.Project())+"-"+string(urn.Stack()) {
+			name = tokens.QName(string(project) + "-" + string(newStack))
+		}
+		renamed := resource.NewURN(newStack, project, "", urn.QualifiedType(), name)
+		renames[urn] = renamed
+		return renamed
+	}
+
+	seen := make(map[resource.URN]bool)
+	for _, res := range snap.Resources {
+		urn := rename(res.URN)
+		if seen[urn] && !res.Delete {
+			return errors.Errorf("renaming '%s' would give it the same URN as another resource, '%s'", res.URN, urn)
+		}
+		seen[urn] = seen[urn] || !res.Delete
+	}
+
+	renameState := func(res *resource.State) {
+		if urn := rename(res.URN); urn != res.URN {
+			res.Aliases = appendAlias(res.Aliases, res.URN)
+			res.URN = urn
+		}
+		res.Parent = rename(res.Parent)
+		deps := make([]resource.URN, len(res.Dependencies))
+		for i, dep := range res.Dependencies {
+			deps[i] = rename(dep)
+		}
+		res.Dependencies = deps
+	}
+	for _, res := range snap.Resources {
+		renameState(res)
+	}
+	for _, op := range snap.PendingOperations {
+		renameState(op.Resource)
+	}
+	return nil
+}
+
+// appendAlias adds a URN to a list of aliases, unless it is already in the list.
+func appendAlias(aliases []resource.URN, urn resource.URN) []resource.URN {
+	for _, alias := range aliases {
+		if alias == urn {
+			return aliases
+		}
+	}
+	return append(aliases, urn)
+}
@@ -0,0 +1,66 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+)
+
+func TestRenameStack(t *testing.T) {
+	t.Parallel()
+
+	rootURN := resource.NewURN("dev", "proj", "", resource.RootStackType, "proj-dev")
+	root := resource.NewState(resource.RootStackType, rootURN, false, false, "",
+		resource.PropertyMap{}, nil, "", false, nil)
+	vpcURN := resource.NewURN("dev", "proj", "", "aws:ec2/vpc:Vpc", "vpc")
+	vpc := resource.NewState("aws:ec2/vpc:Vpc", vpcURN, true, false, "vpc-123",
+		resource.PropertyMap{}, nil, rootURN, false, nil)
+	subnetURN := resource.NewURN("dev", "proj", "", "aws:ec2/subnet:Subnet", "subnet")
+	subnet := resource.NewState("aws:ec2/subnet:Subnet", subnetURN, true, false, "subnet-456",
+		resource.PropertyMap{}, nil, rootURN, false, []resource.URN{vpcURN})
+	pending := resource.NewState("aws:ec2/subnet:Subnet", subnetURN, true, false, "subnet-456",
+		resource.PropertyMap{}, nil, rootURN, false, []resource.URN{vpcURN})
+
+	snap := NewSnapshot(Manifest{}, []*resource.State{root, vpc, subnet})
+	snap.PendingOperations = []Operation{{Resource: pending, Type: OperationTypeUpdating}}
+
+	err := RenameStack(snap, "prod", "")
+	assert.NoError(t, err)
+
+	newRootURN := resource.NewURN("prod", "proj", "", resource.RootStackType, "proj-prod")
+	newVpcURN := resource.NewURN("prod", "proj", "", "aws:ec2/vpc:Vpc", "vpc")
+	newSubnetURN := resource.NewURN("prod", "proj", "", "aws:ec2/subnet:Subnet", "subnet")
+	assert.Equal(t, newRootURN, root.URN)
+	assert.Equal(t, []resource.URN{rootURN}, root.Aliases)
+	assert.Equal(t, newVpcURN, vpc.URN)
+	assert.Equal(t, newRootURN, vpc.Parent)
+	assert.Equal(t, []resource.URN{vpcURN}, vpc.Aliases)
+	assert.Equal(t, newSubnetURN, subnet.URN)
+	assert.Equal(t, []resource.URN{newVpcURN}, subnet.Dependencies)
+	assert.Equal(t, newSubnetURN, pending.URN)
+	assert.Equal(t, []resource.URN{newVpcURN}, pending.Dependencies)
+	assert.NoError(t, snap.VerifyIntegrity())
+
+	// Renaming the project too rewrites the project part of each URN, and records the URNs from before each rename.
+	err = RenameStack(snap, "prod", "other")
+	assert.NoError(t, err)
+	assert.Equal(t, resource.NewURN("prod", "other", "", "aws:ec2/vpc:Vpc", "vpc"), vpc.URN)
+	assert.Equal(t, resource.NewURN("prod", "other", "", resource.RootStackType, "other-prod"), root.URN)
+	assert.Equal(t, []resource.URN{vpcURN, newVpcURN}, vpc.Aliases)
+}
@@ -0,0 +1,145 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"context"
+	"time"
+
+	"github.com/pulumi/pulumi/pkg/diag"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/plugin"
+	"github.com/pulumi/pulumi/pkg/util/logging"
+)
+
+const (
+	// DefaultRetryInitialBackoff is the time to wait before the first retry of a failed operation, if none is given.
+	DefaultRetryInitialBackoff = time.Second
+	// DefaultRetryMaxBackoff is the longest time to wait between retries of a failed operation, if none is given.
+	DefaultRetryMaxBackoff = 30 * time.Second
+)
+
+// RetryPolicy controls how provider operations that fail with transient errors are retried.  After each failed
+// attempt, the time to wait before the next one doubles, up to the maximum backoff.
+type RetryPolicy struct {
+	MaxAttempts    int           // the maximum number of attempts at each operation (<=1 for no retries).
+	InitialBackoff time.Duration // the time to wait before the first retry (0 for the default).
+	MaxBackoff     time.Duration // the longest time to wait between retries (0 for the default).
+
+	// an optional context whose cancellation abandons any wait for a retry, returning the last attempt's error.
+	Context context.Context
+}
+
+// retryingProvider is a provider that retries Create, Read, Update, and Delete calls that fail with transient errors,
+// according to a retry policy.  Each retry is reported as a warning.
+type retryingProvider struct {
+	plugin.Provider
+
+	policy RetryPolicy
+	diag   diag.Sink
+}
+
+// newRetryingProvider wraps a provider so that its operations are retried according to the given policy.  If the
+// policy doesn't allow any retries, the provider is returned as-is.
+func newRetryingProvider(prov plugin.Provider, policy RetryPolicy, d diag.Sink) plugin.Provider {
+	if policy.MaxAttempts <= 1 {
+		return prov
+	}
+	if policy.InitialBackoff <= 0 {
+		policy.InitialBackoff = DefaultRetryInitialBackoff
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = DefaultRetryMaxBackoff
+	}
+	if policy.Context == nil {
+		policy.Context = context.Background()
+	}
+	return &retryingProvider{Provider: prov, policy: policy, diag: d}
+}
+
+// retry calls the given function until it succeeds, fails with an error that isn't transient, or has been attempted
+// as many times as the policy allows.  An attempt whose resource is left in an unknown state is never retried.
+func (p *retryingProvider) retry(op string, urn resource.URN, f func() (resource.Status, error)) (resource.Status,
+	error) {
+
+	backoff := p.policy.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		status, err := f()
+		if err == nil || status != resource.StatusOK || attempt >= p.policy.MaxAttempts ||
+			!plugin.IsTransientError(err) {
+			return status, err
+		}
+
+		logging.V(7).Infof("%s of %s failed on attempt %d; retrying in %v: %v", op, urn, attempt, backoff, err)
+		p.diag.Warningf(diag.GetOperationRetryWarning(urn), op, urn, attempt, p.policy.MaxAttempts, backoff, err)
+		select {
+		case <-p.policy.Context.Done():
+			logging.V(7).Infof("abandoning retries of %s of %s: %v", op, urn, p.policy.Context.Err())
+			return status, err
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > p.policy.MaxBackoff {
+			backoff = p.policy.MaxBackoff
+		}
+	}
+}
+
+func (p *retryingProvider) Create(urn resource.URN, news resource.PropertyMap,
+	timeout time.Duration) (resource.ID, resource.PropertyMap, resource.Status, error) {
+
+	var id resource.ID
+	var outs resource.PropertyMap
+	status, err := p.retry("Create", urn, func() (resource.Status, error) {
+		var status resource.Status
+		var err error
+		id, outs, status, err = p.Provider.Create(urn, news, timeout)
+		return status, err
+	})
+	return id, outs, status, err
+}
+
+func (p *retryingProvider) Read(urn resource.URN, id resource.ID,
+	props resource.PropertyMap) (resource.PropertyMap, error) {
+
+	var outs resource.PropertyMap
+	_, err := p.retry("Read", urn, func() (resource.Status, error) {
+		var err error
+		outs, err = p.Provider.Read(urn, id, props)
+		return resource.StatusOK, err
+	})
+	return outs, err
+}
+
+func (p *retryingProvider) Update(urn resource.URN, id resource.ID, olds resource.PropertyMap,
+	news resource.PropertyMap, timeout time.Duration) (resource.PropertyMap, resource.Status, error) {
+
+	var outs resource.PropertyMap
+	status, err := p.retry("Update", urn, func() (resource.Status, error) {
+		var status resource.Status
+		var err error
+		outs, status, err = p.Provider.Update(urn, id, olds, news, timeout)
+		return status, err
+	})
+	return outs, status, err
+}
+
+func (p *retryingProvider) Delete(urn resource.URN, id resource.ID, props resource.PropertyMap,
+	timeout time.Duration) (resource.Status, error) {
+
+	return p.retry("Delete", urn, func() (resource.Status, error) {
+		return p.Provider.Delete(urn, id, props, timeout)
+	})
+}
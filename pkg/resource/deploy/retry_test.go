@@ -0,0 +1,113 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/util/cmdutil"
+	"github.com/pulumi/pulumi/pkg/util/rpcutil/rpcerror"
+)
+
+// TestRetryTransientErrors ensures that operations failing with transient errors are retried until they succeed, and
+// that no more attempts are made than the policy allows.
+func TestRetryTransientErrors(t *testing.T) {
+	t.Parallel()
+
+	attempts := 0
+	prov := &testProvider{
+		delete: func(urn resource.URN, id resource.ID, props resource.PropertyMap,
+			timeout time.Duration) (resource.Status, error) {
+			attempts++
+			if attempts < 3 {
+				return resource.StatusOK, rpcerror.New(codes.Unavailable, "throttled")
+			}
+			return resource.StatusOK, nil
+		},
+	}
+
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+	_, err := newRetryingProvider(prov, policy, cmdutil.Diag()).Delete("urn", "id", nil, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, attempts)
+
+	// With one fewer attempt allowed, the last transient error is returned.
+	attempts = 0
+	policy.MaxAttempts = 2
+	_, err = newRetryingProvider(prov, policy, cmdutil.Diag()).Delete("urn", "id", nil, 0)
+	assert.NotNil(t, err)
+	assert.Equal(t, 2, attempts)
+}
+
+// TestNoRetryPermanentErrors ensures that errors that aren't transient, and failures that leave a resource in an
+// unknown state, are never retried.
+func TestNoRetryPermanentErrors(t *testing.T) {
+	t.Parallel()
+
+	attempts := 0
+	var status resource.Status
+	var failure error
+	prov := &testProvider{
+		delete: func(urn resource.URN, id resource.ID, props resource.PropertyMap,
+			timeout time.Duration) (resource.Status, error) {
+			attempts++
+			return status, failure
+		},
+	}
+	retrying := newRetryingProvider(prov, RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Millisecond},
+		cmdutil.Diag())
+
+	status, failure = resource.StatusOK, errors.New("bad request")
+	_, err := retrying.Delete("urn", "id", nil, 0)
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, attempts)
+
+	attempts = 0
+	status, failure = resource.StatusUnknown, rpcerror.New(codes.Unavailable, "connection reset")
+	_, err = retrying.Delete("urn", "id", nil, 0)
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+// TestRetryCanceled ensures that canceling the policy's context abandons the wait for the next retry.
+func TestRetryCanceled(t *testing.T) {
+	t.Parallel()
+
+	attempts := 0
+	prov := &testProvider{
+		delete: func(urn resource.URN, id resource.ID, props resource.PropertyMap,
+			timeout time.Duration) (resource.Status, error) {
+			attempts++
+			return resource.StatusOK, rpcerror.New(codes.Unavailable, "throttled")
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour, Context: ctx}
+
+	start := time.Now()
+	_, err := newRetryingProvider(prov, policy, cmdutil.Diag()).Delete("urn", "id", nil, 0)
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, attempts)
+	assert.True(t, time.Since(start) < time.Minute)
+}
@@ -0,0 +1,70 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"github.com/pulumi/pulumi/pkg/resource"
+)
+
+// rollbackEvent stands in for the registration that a rollback step would otherwise report its result to.  Rollback
+// steps run after the program has finished, so there is no one to tell.
+type rollbackEvent struct{}
+
+var _ RegisterResourceEvent = rollbackEvent{}
+
+func (rollbackEvent) event()                      {}
+func (rollbackEvent) Goal() *resource.Goal        { return nil }
+func (rollbackEvent) Done(result *RegisterResult) {}
+
+// NewRollbackSteps returns the steps that undo, as far as possible, the given steps that an update applied before
+// failing.  The steps are undone in the reverse of the order in which they were applied: each resource that was
+// created is deleted, and each resource that was updated is updated back to its previous inputs.  Deletes and
+// replacements can't be undone, nor can the creation of a protected resource, nor any other operation that isn't
+// known to be reversible; these steps are returned separately.
+func NewRollbackSteps(plan *Plan, applied []Step) ([]Step, []Step) {
+	var steps, failed []Step
+	for i := len(applied) - 1; i >= 0; i-- {
+		step := applied[i]
+		switch step.Op() {
+		case OpCreate:
+			if step.New().Protect {
+				failed = append(failed, step)
+				continue
+			}
+			steps = append(steps, NewDeleteStep(plan, step.New()))
+		case OpUpdate:
+			old, cur := step.Old(), step.New()
+			rolled := resource.NewState(cur.Type, cur.URN, cur.Custom, false, "",
+				old.Inputs, nil, cur.Parent, cur.Protect, cur.Dependencies)
+			rolled.CustomTimeouts = cur.CustomTimeouts
+			steps = append(steps, NewUpdateStep(plan, rollbackEvent{}, cur, rolled, nil, nil))
+		case OpSame, OpRead, OpReadDiscard:
+			// These steps change nothing outside of the checkpoint, so there is nothing to undo.
+		case OpReplace:
+			// A replacement is undone, or not, by the steps that create and delete its resources.
+		case OpCreateReplacement:
+			// The replaced resource may already be deleted, or be pending deletion, so deleting its replacement would
+			// leave nothing in its place.
+			failed = append(failed, step)
+		case OpDelete, OpDeleteReplaced:
+			// A deleted resource can't be brought back.
+			failed = append(failed, step)
+		default:
+			// Any other operation, such as an import, isn't known to be reversible.
+			failed = append(failed, step)
+		}
+	}
+	return steps, failed
+}
@@ -0,0 +1,60 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/tokens"
+)
+
+func newRollbackTestState(name string, id resource.ID) *resource.State {
+	t := tokens.Type("pkgA:m:typA")
+	urn := resource.NewURN("test", "test", "", t, tokens.QName(name))
+	return resource.NewState(t, urn, true, false, id, resource.PropertyMap{}, nil, "", false, nil)
+}
+
+// TestRollbackStepsIrreversible ensures that steps that can't be undone are reported as such, and that steps that
+// change nothing are ignored.
+func TestRollbackStepsIrreversible(t *testing.T) {
+	t.Parallel()
+
+	old := newRollbackTestState("a", "id")
+	new := newRollbackTestState("a", "")
+	external := newRollbackTestState("b", "id")
+	external.External = true
+	imported := newRollbackTestState("c", "id")
+
+	applied := []Step{
+		NewSameStep(nil, &testRegEvent{}, old, new),
+		NewCreateReplacementStep(nil, &testRegEvent{}, old, new, nil, true),
+		NewReplaceStep(nil, old, new, nil, nil, true),
+		NewDeleteStep(nil, external),
+		NewImportStep(nil, imported),
+		NewDeleteStep(nil, old),
+	}
+
+	steps, failed := NewRollbackSteps(nil, applied)
+	assert.Len(t, steps, 0)
+
+	var ops []StepOp
+	for _, step := range failed {
+		ops = append(ops, step.Op())
+	}
+	assert.Equal(t, []StepOp{OpDelete, OpImport, OpCreateReplacement}, ops)
+}
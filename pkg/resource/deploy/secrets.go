@@ -0,0 +1,200 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+)
+
+// secretTracker decides which properties of the resources registered by a plan hold secrets.  An input holds a secret
+// if it holds one of the stack's secret configuration values, or if it was computed from the outputs of a resource
+// that holds secrets; an output holds a secret if its input does, if it holds a secret configuration value, or if it
+// is unchanged since it last held one.  Secretness thus follows values through the dependency graph, from the
+// configuration to every output derived from it, even where a provider transforms a secret so that it's no longer
+// recognizable.
+//
+// The dependencies of each input are those that the program reported for it.  If the program reported none, as older
+// language SDKs don't, an input holds a secret only if it holds one of the secret values of its resource's
+// dependencies.  Values are only ever compared whole: a secret found within a longer string isn't recognized, since
+// short secrets would be found almost anywhere.
+type secretTracker struct {
+	lock     sync.Mutex
+	config   map[string]bool                  // the plaintexts of the stack's secret configuration values.
+	olds     map[resource.URN]*resource.State // the states of the stack's resources before the plan.
+	news     map[resource.URN]*resource.State // the states registered by the plan so far.
+	children map[resource.URN][]resource.URN  // the children of each resource registered by the plan so far.
+}
+
+func newSecretTracker(target *Target, olds map[resource.URN]*resource.State) (*secretTracker, error) {
+	plaintexts := make(map[string]bool)
+	if target.Decrypter != nil {
+		for k, v := range target.Config {
+			secrets, err := v.SecureValues(target.Decrypter)
+			if err != nil {
+				return nil, errors.Wrapf(err, "decrypting the value of '%s'", k)
+			}
+			for _, secret := range secrets {
+				plaintexts[secret] = true
+			}
+		}
+	}
+	return &secretTracker{
+		config:   plaintexts,
+		olds:     olds,
+		news:     make(map[resource.URN]*resource.State),
+		children: make(map[resource.URN][]resource.URN),
+	}, nil
+}
+
+// mark records which of a new state's properties hold secrets, given its old state, if it has one.
+func (t *secretTracker) mark(old *resource.State, new *resource.State) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if _, has := t.news[new.URN]; !has && new.Parent != "" {
+		t.children[new.Parent] = append(t.children[new.Parent], new.URN)
+	}
+	t.news[new.URN] = new
+	t.markLocked(old, new, new.Dependencies)
+}
+
+// markOutputs records which of a resource's properties hold secrets once the program has registered its outputs.  A
+// component's outputs are usually derived from its children's, so the children's secrets are considered along with
+// those of its dependencies.
+func (t *secretTracker) markOutputs(new *resource.State) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	related := append(append([]resource.URN(nil), new.Dependencies...), t.children[new.URN]...)
+	t.markLocked(t.olds[new.URN], new, related)
+}
+
+func (t *secretTracker) markLocked(old *resource.State, new *resource.State, related []resource.URN) {
+	// The secret values of the related resources, for the properties whose dependencies weren't reported.
+	relatedSecrets := make(map[string]bool)
+	for _, urn := range related {
+		for _, secret := range secretValues(t.state(urn)) {
+			relatedSecrets[secret] = true
+		}
+	}
+
+	isSecretInput := func(k resource.PropertyKey) bool {
+		if !new.Inputs.HasValue(k) {
+			return false
+		}
+		if holdsSecret(new.Inputs[k], t.config) {
+			return true
+		}
+		if new.PropertyDependencies == nil {
+			return holdsSecret(new.Inputs[k], relatedSecrets)
+		}
+		for _, dep := range new.PropertyDependencies[k] {
+			if res := t.state(dep); res != nil && len(res.SecretOutputs) > 0 {
+				return true
+			}
+		}
+		return false
+	}
+	isSecret := func(k resource.PropertyKey) bool {
+		if isSecretInput(k) || holdsSecret(new.Outputs[k], t.config) {
+			return true
+		}
+		// The outputs of a component, registered by the program, are matched against those of its children too.
+		if new.PropertyDependencies == nil || !new.Custom {
+			if holdsSecret(new.Outputs[k], relatedSecrets) {
+				return true
+			}
+		}
+		// An output that hasn't changed since it last held a secret still holds it.
+		return old != nil && hasSecretOutput(old, k) && new.Outputs.HasValue(k) &&
+			new.Outputs[k].DeepEquals(old.Outputs[k])
+	}
+
+	var keys []resource.PropertyKey
+	for _, k := range new.Inputs.Merge(new.Outputs).StableKeys() {
+		if isSecret(k) {
+			keys = append(keys, k)
+		}
+	}
+	new.SecretOutputs = keys
+}
+
+// state returns the latest state of a resource: the one registered by the plan, if it has been, or its old one.  The
+// caller must hold the tracker's lock.
+func (t *secretTracker) state(urn resource.URN) *resource.State {
+	if res, has := t.news[urn]; has {
+		return res
+	}
+	return t.olds[urn]
+}
+
+// hasSecretOutput returns true if the given property of a resource holds a secret.
+func hasSecretOutput(res *resource.State, k resource.PropertyKey) bool {
+	for _, secret := range res.SecretOutputs {
+		if secret == k {
+			return true
+		}
+	}
+	return false
+}
+
+// secretValues returns the plaintexts of the strings held by a resource's secret properties.
+func secretValues(res *resource.State) []string {
+	if res == nil {
+		return nil
+	}
+	var result []string
+	for _, k := range res.SecretOutputs {
+		result = appendStrings(result, res.Inputs[k])
+		result = appendStrings(result, res.Outputs[k])
+	}
+	return result
+}
+
+// appendStrings appends every non-empty string held by a property value, however deeply nested, to a list.
+func appendStrings(strs []string, v resource.PropertyValue) []string {
+	switch {
+	case v.IsString():
+		if s := v.StringValue(); s != "" {
+			strs = append(strs, s)
+		}
+	case v.IsArray():
+		for _, elem := range v.ArrayValue() {
+			strs = appendStrings(strs, elem)
+		}
+	case v.IsObject():
+		for _, elem := range v.ObjectValue() {
+			strs = appendStrings(strs, elem)
+		}
+	}
+	return strs
+}
+
+// holdsSecret returns true if any string held by a property value is one of the given secrets.
+func holdsSecret(v resource.PropertyValue, secrets map[string]bool) bool {
+	if len(secrets) == 0 {
+		return false
+	}
+	for _, s := range appendStrings(nil, v) {
+		if secrets[s] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/config"
+)
+
+func TestSecretTracker(t *testing.T) {
+	t.Parallel()
+
+	target := &Target{
+		Config: config.Map{
+			config.MustMakeKey("test", "password"): config.NewSecureValue("hunter2"),
+			config.MustMakeKey("test", "region"):   config.NewValue("us-west-2"),
+		},
+		Decrypter: config.NopDecrypter,
+	}
+	a, b, c, d := resource.URN("urn:a"), resource.URN("urn:b"), resource.URN("urn:c"), resource.URN("urn:d")
+	oldC := &resource.State{
+		URN:           c,
+		Outputs:       resource.PropertyMap{"hash": resource.NewStringProperty("5f4dcc3b")},
+		SecretOutputs: []resource.PropertyKey{"hash"},
+	}
+	tracker, err := newSecretTracker(target, map[resource.URN]*resource.State{c: oldC})
+	assert.NoError(t, err)
+
+	// A property that holds a secret configuration value is secret; one that holds a plain value isn't, even if the
+	// secret can be found within it.
+	resA := &resource.State{
+		URN: a,
+		Inputs: resource.PropertyMap{
+			"password": resource.NewStringProperty("hunter2"),
+			"region":   resource.NewStringProperty("us-west-2"),
+		},
+		Outputs: resource.PropertyMap{
+			"banner": resource.NewStringProperty("hunter2 was here"),
+		},
+	}
+	tracker.mark(nil, resA)
+	assert.Equal(t, []resource.PropertyKey{"password"}, resA.SecretOutputs)
+
+	// Secrets follow property dependencies: an input computed from a resource that holds secrets is secret, however
+	// it was computed, and so is the output it becomes.
+	resB := &resource.State{
+		URN:    b,
+		Parent: c,
+		Inputs: resource.PropertyMap{
+			"name": resource.NewStringProperty("app"),
+			"url":  resource.NewStringProperty("db://admin:hunter2@db/app"),
+		},
+		Outputs: resource.PropertyMap{
+			"name": resource.NewStringProperty("app"),
+			"url":  resource.NewStringProperty("db://admin:hunter2@db/app"),
+		},
+		Dependencies:         []resource.URN{a},
+		PropertyDependencies: map[resource.PropertyKey][]resource.URN{"url": {a}},
+	}
+	tracker.mark(nil, resB)
+	assert.Equal(t, []resource.PropertyKey{"url"}, resB.SecretOutputs)
+
+	// Without property dependencies, an input is secret if it holds one of its dependencies' secret values, whether
+	// or not the dependency has been registered yet.
+	resD := &resource.State{
+		URN: d,
+		Inputs: resource.PropertyMap{
+			"checksum": resource.NewStringProperty("5f4dcc3b"),
+			"label":    resource.NewStringProperty("build-5f4dcc3b"),
+		},
+		Dependencies: []resource.URN{a, c},
+	}
+	tracker.mark(nil, resD)
+	assert.Equal(t, []resource.PropertyKey{"checksum"}, resD.SecretOutputs)
+
+	// An output that's unchanged since it last held a secret still holds it, and a component's outputs are secret if
+	// they hold its children's secrets.
+	resC := &resource.State{URN: c, Outputs: oldC.Outputs.Copy()}
+	tracker.mark(oldC, resC)
+	assert.Equal(t, []resource.PropertyKey{"hash"}, resC.SecretOutputs)
+	resC.Outputs["url"] = resource.NewStringProperty("db://admin:hunter2@db/app")
+	tracker.markOutputs(resC)
+	assert.Equal(t, []resource.PropertyKey{"hash", "url"}, resC.SecretOutputs)
+
+	// An output that changes no longer holds the secret it held.
+	resC.Outputs["hash"] = resource.NewStringProperty("e10adc39")
+	tracker.markOutputs(resC)
+	assert.Equal(t, []resource.PropertyKey{"url"}, resC.SecretOutputs)
+}
@@ -29,8 +29,26 @@ import (
 // IDs, names, and properties; their dependencies; and more.  A snapshot is a diffable entity and can be used to create
 // or apply an infrastructure deployment plan in order to make reality match the snapshot state.
 type Snapshot struct {
-	Manifest  Manifest          // a deployment manifest of versions, checksums, and so on.
-	Resources []*resource.State // fetches all resources and their associated states.
+	Manifest          Manifest          // a deployment manifest of versions, checksums, and so on.
+	Resources         []*resource.State // fetches all resources and their associated states.
+	PendingOperations []Operation       // operations that were in progress when the snapshot was taken.
+}
+
+// OperationType is the kind of operation being performed on a resource.
+type OperationType string
+
+const (
+	OperationTypeCreating OperationType = "creating" // the resource is being created.
+	OperationTypeUpdating OperationType = "updating" // the resource is being updated.
+	OperationTypeDeleting OperationType = "deleting" // the resource is being deleted.
+)
+
+// Operation is an operation that was begun on a resource but had not finished when a snapshot was taken, because the
+// update performing it was interrupted.  Its resource is the state being created or, for updates and deletions, the
+// existing state being operated upon.
+type Operation struct {
+	Resource *resource.State
+	Type     OperationType
 }
 
 // Manifest captures versions for all binaries used to construct this snapshot.
@@ -77,3 +77,28 @@ type RegisterResourceOutputsEvent interface {
 	// Done indicates that we are done with this step.  It must be called to perform cleanup associated with the step.
 	Done()
 }
+
+// ReadResourceEvent is an event that asks the engine to read the state of an external resource: one that belongs to
+// something other than this stack, and so which the engine never creates, updates, or deletes.
+type ReadResourceEvent interface {
+	SourceEvent
+	// ID is the ID of the external resource to read.
+	ID() resource.ID
+	// Type is the type of the resource to read.
+	Type() tokens.Type
+	// Name is the name, for URN purposes, of the resource to read.
+	Name() tokens.QName
+	// Parent is an optional parent URN for the resource.
+	Parent() resource.URN
+	// Properties returns any additional state needed to identify the resource.
+	Properties() resource.PropertyMap
+	// Dependencies returns the URNs of the resources that the program observed this read depending upon.
+	Dependencies() []resource.URN
+	// Done indicates that we are done with this step.  It must be called to perform cleanup associated with the step.
+	Done(result *ReadResult)
+}
+
+// ReadResult is the state of an external resource after it has been read.
+type ReadResult struct {
+	State *resource.State // the resource state.
+}
@@ -16,6 +16,7 @@ package deploy
 
 import (
 	"fmt"
+	"time"
 
 	pbempty "github.com/golang/protobuf/ptypes/empty"
 	"github.com/pkg/errors"
@@ -45,19 +46,23 @@ type EvalRunInfo struct {
 
 // NewEvalSource returns a planning source that fetches resources by evaluating a package with a set of args and
 // a confgiuration map.  This evaluation is performed using the given plugin context and may optionally use the
-// given plugin host (or the default, if this is nil).  Note that closing the eval source also closes the host.
-func NewEvalSource(plugctx *plugin.Context, runinfo *EvalRunInfo, dryRun bool) Source {
+// given plugin host (or the default, if this is nil).  Note that closing the eval source also closes the host.  The
+// program may read the outputs of other stacks with the given reader, if it is non-nil.
+func NewEvalSource(plugctx *plugin.Context, runinfo *EvalRunInfo, stackOutputs StackOutputsReader,
+	dryRun bool) Source {
 	return &evalSource{
-		plugctx: plugctx,
-		runinfo: runinfo,
-		dryRun:  dryRun,
+		plugctx:      plugctx,
+		runinfo:      runinfo,
+		stackOutputs: stackOutputs,
+		dryRun:       dryRun,
 	}
 }
 
 type evalSource struct {
-	plugctx *plugin.Context // the plugin context.
-	runinfo *EvalRunInfo    // the directives to use when running the program.
-	dryRun  bool            // true if this is a dry-run operation only.
+	plugctx      *plugin.Context    // the plugin context.
+	runinfo      *EvalRunInfo       // the directives to use when running the program.
+	stackOutputs StackOutputsReader // the reader of the outputs of other stacks, if any.
+	dryRun       bool               // true if this is a dry-run operation only.
 }
 
 func (src *evalSource) Close() error {
@@ -82,18 +87,20 @@ func (src *evalSource) Iterate(opts Options) (SourceIterator, error) {
 	// First, fire up a resource monitor that will watch for and record resource creation.
 	regChan := make(chan *registerResourceEvent)
 	regOutChan := make(chan *registerResourceOutputsEvent)
-	mon, err := newResourceMonitor(src, regChan, regOutChan)
+	regReadChan := make(chan *readResourceEvent)
+	mon, err := newResourceMonitor(src, regChan, regOutChan, regReadChan)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to start resource monitor")
 	}
 
 	// Create a new iterator with appropriate channels, and gear up to go!
 	iter := &evalSourceIterator{
-		mon:        mon,
-		src:        src,
-		regChan:    regChan,
-		regOutChan: regOutChan,
-		finChan:    make(chan error),
+		mon:         mon,
+		src:         src,
+		regChan:     regChan,
+		regOutChan:  regOutChan,
+		regReadChan: regReadChan,
+		finChan:     make(chan error),
 	}
 
 	// Now invoke Run in a goroutine.  All subsequent resource creation events will come in over the gRPC channel,
@@ -105,12 +112,13 @@ func (src *evalSource) Iterate(opts Options) (SourceIterator, error) {
 }
 
 type evalSourceIterator struct {
-	mon        *resmon                            // the resource monitor, per iterator.
-	src        *evalSource                        // the owning eval source object.
-	regChan    chan *registerResourceEvent        // the channel that contains resource registrations.
-	regOutChan chan *registerResourceOutputsEvent // the channel that contains resource completions.
-	finChan    chan error                         // the channel that communicates completion.
-	done       bool                               // set to true when the evaluation is done.
+	mon         *resmon                            // the resource monitor, per iterator.
+	src         *evalSource                        // the owning eval source object.
+	regChan     chan *registerResourceEvent        // the channel that contains resource registrations.
+	regOutChan  chan *registerResourceOutputsEvent // the channel that contains resource completions.
+	regReadChan chan *readResourceEvent            // the channel that contains reads of external resources.
+	finChan     chan error                         // the channel that communicates completion.
+	done        bool                               // set to true when the evaluation is done.
 }
 
 func (iter *evalSourceIterator) Close() error {
@@ -137,6 +145,10 @@ func (iter *evalSourceIterator) Next() (SourceEvent, error) {
 		logging.V(5).Infof("EvalSourceIterator produced a completion: urn=%v,#outs=%v",
 			regOut.URN(), len(regOut.Outputs()))
 		return regOut, nil
+	case read := <-iter.regReadChan:
+		contract.Assert(read != nil)
+		logging.V(5).Infof("EvalSourceIterator produced a read: t=%v,name=%v,id=%v", read.Type(), read.Name(), read.ID())
+		return read, nil
 	case err := <-iter.finChan:
 		// If we are finished, we can safely exit.  The contract with the language provider is that this implies
 		// that the language runtime has exited and so calling Close on the plugin is fine.
@@ -199,23 +211,25 @@ func (iter *evalSourceIterator) forkRun(opts Options) {
 // resmon implements the pulumirpc.ResourceMonitor interface and acts as the gateway between a language runtime's
 // evaluation of a program and the internal resource planning and deployment logic.
 type resmon struct {
-	src        *evalSource                        // the evaluation source.
-	regChan    chan *registerResourceEvent        // the channel to send resource registrations to.
-	regOutChan chan *registerResourceOutputsEvent // the channel to send resource output registrations to.
-	addr       string                             // the address the host is listening on.
-	cancel     chan bool                          // a channel that can cancel the server.
-	done       chan error                         // a channel that resolves when the server completes.
+	src         *evalSource                        // the evaluation source.
+	regChan     chan *registerResourceEvent        // the channel to send resource registrations to.
+	regOutChan  chan *registerResourceOutputsEvent // the channel to send resource output registrations to.
+	regReadChan chan *readResourceEvent            // the channel to send reads of external resources to.
+	addr        string                             // the address the host is listening on.
+	cancel      chan bool                          // a channel that can cancel the server.
+	done        chan error                         // a channel that resolves when the server completes.
 }
 
 // newResourceMonitor creates a new resource monitor RPC server.
 func newResourceMonitor(src *evalSource, regChan chan *registerResourceEvent,
-	regOutChan chan *registerResourceOutputsEvent) (*resmon, error) {
+	regOutChan chan *registerResourceOutputsEvent, regReadChan chan *readResourceEvent) (*resmon, error) {
 	// New up an engine RPC server.
 	resmon := &resmon{
-		src:        src,
-		regChan:    regChan,
-		regOutChan: regOutChan,
-		cancel:     make(chan bool),
+		src:         src,
+		regChan:     regChan,
+		regOutChan:  regOutChan,
+		regReadChan: regReadChan,
+		cancel:      make(chan bool),
 	}
 
 	// Fire up a gRPC server and start listening for incomings.
@@ -248,27 +262,31 @@ func (rm *resmon) Cancel() error {
 
 // Invoke performs an invocation of a member located in a resource provider.
 func (rm *resmon) Invoke(ctx context.Context, req *pulumirpc.InvokeRequest) (*pulumirpc.InvokeResponse, error) {
-	// Fetch the token and load up the resource provider.
-	// TODO: we should be flowing version information about this request, but instead, we'll bind to the latest.
+	// Unpack all of the arguments and prepare to perform the invocation.
 	tok := tokens.ModuleMember(req.GetTok())
-	prov, err := rm.src.plugctx.Host.Provider(tok.Package(), nil)
-	if err != nil {
-		return nil, err
-	} else if prov == nil {
-		return nil, errors.Errorf("could not load resource provider for package '%v' from $PATH", tok.Package())
-	}
-
-	// Now unpack all of the arguments and prepare to perform the invocation.
 	label := fmt.Sprintf("ResourceMonitor.Invoke(%s)", tok)
 	args, err := plugin.UnmarshalProperties(
 		req.GetArgs(), plugin.MarshalOptions{Label: label, KeepUnknowns: true})
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to unmarshal %v args", tok)
 	}
-
-	// Do the invoke and then return the arguments.
 	logging.V(5).Infof("ResourceMonitor.Invoke received: tok=%v #args=%v", tok, len(args))
-	ret, failures, err := prov.Invoke(tok, args)
+
+	// Functions built into the engine are performed here; the rest by the provider of their package.
+	var ret resource.PropertyMap
+	var failures []plugin.CheckFailure
+	if tok == ReadStackOutputsToken {
+		ret, failures, err = readStackOutputs(rm.src.stackOutputs, args)
+	} else {
+		// TODO: we should be flowing version information about this request, but instead, we'll bind to the latest.
+		prov, provErr := rm.src.plugctx.Host.Provider(tok.Package(), nil)
+		if provErr != nil {
+			return nil, provErr
+		} else if prov == nil {
+			return nil, errors.Errorf("could not load resource provider for package '%v' from $PATH", tok.Package())
+		}
+		ret, failures, err = prov.Invoke(tok, args)
+	}
 	if err != nil {
 		return nil, errors.Wrapf(err, "invocation of %v returned an error", tok)
 	}
@@ -286,54 +304,69 @@ func (rm *resmon) Invoke(ctx context.Context, req *pulumirpc.InvokeRequest) (*pu
 	return &pulumirpc.InvokeResponse{Return: mret, Failures: chkfails}, nil
 }
 
-// ReadResource reads the current state associated with a resource from its provider plugin.
+// ReadResource reads the current state associated with an external resource from its provider plugin.  The engine
+// records the resource in the stack's state, so that its outputs are available to its dependents, but never creates,
+// updates, or deletes it.
 func (rm *resmon) ReadResource(ctx context.Context,
 	req *pulumirpc.ReadResourceRequest) (*pulumirpc.ReadResourceResponse, error) {
-	// Read the basic inputs necessary to identify the plugin.
+	// Read the basic inputs necessary to identify the resource.
 	t := tokens.Type(req.GetType())
 	name := tokens.QName(req.GetName())
 	parent := resource.URN(req.GetParent())
-	prov, err := rm.src.plugctx.Host.Provider(t.Package(), nil)
+	id := resource.ID(req.GetId())
+	if id == "" {
+		return nil, errors.Errorf("missing required ID for external resource %s", name)
+	}
+	label := fmt.Sprintf("ResourceMonitor.ReadResource(%s, %s, %s)", id, t, name)
+
+	// Unmarshal any additional state that came with the message.
+	props, err := plugin.UnmarshalProperties(
+		req.GetProperties(), plugin.MarshalOptions{Label: label, KeepUnknowns: true})
 	if err != nil {
-		return nil, err
-	} else if prov == nil {
-		return nil, errors.Errorf("could not load resource provider for package '%v' from $PATH", t.Package())
+		return nil, errors.Wrapf(err, "failed to unmarshal read properties for resource %s", id)
 	}
 
-	// Manufacture a URN that is based on the program evaluation context.
-	var pt tokens.Type
-	if parent != "" {
-		pt = parent.Type()
+	var dependencies []resource.URN
+	for _, dep := range req.GetDependencies() {
+		dependencies = append(dependencies, resource.URN(dep))
+	}
+	logging.V(5).Infof("ResourceMonitor.ReadResource received: %s #props=%d deps=%v", label, len(props), dependencies)
+
+	// Send the read to the engine, and block waiting for it to finish.
+	step := &readResourceEvent{
+		id:           id,
+		t:            t,
+		name:         name,
+		parent:       parent,
+		props:        props,
+		dependencies: dependencies,
+		done:         make(chan *ReadResult),
+	}
+	select {
+	case rm.regReadChan <- step:
+	case <-rm.cancel:
+		logging.V(5).Infof("ResourceMonitor.ReadResource operation canceled, name=%s", name)
+		return nil, rpcerror.New(codes.Unavailable, "resource monitor shut down while sending resource read")
 	}
-	urn := resource.NewURN(rm.src.Stack(), rm.src.Project(), pt, t, name)
 
-	// Now get the ID.  If it is an unknown value -- as might happen during planning when, for example, reading
-	// the output of another resource's output property -- then we can skip the RPC as it can't possibly do anything.
-	id := resource.ID(req.GetId())
-	label := fmt.Sprintf("ResourceMonitor.ReadResource(%s, %s, %s)", id, t, name)
-	resp := &pulumirpc.ReadResourceResponse{Urn: string(urn)}
+	var result *ReadResult
+	select {
+	case result = <-step.done:
+	case <-rm.cancel:
+		logging.V(5).Infof("ResourceMonitor.ReadResource operation canceled, name=%s", name)
+		return nil, rpcerror.New(codes.Unavailable, "resource monitor shut down while waiting on read's done channel")
+	}
 
+	// If the ID isn't known yet, as might happen during planning, the resource couldn't be read, so it has no state.
+	state := result.State
+	resp := &pulumirpc.ReadResourceResponse{Urn: string(state.URN)}
 	if id != plugin.UnknownStringValue {
-		// Unmarshal any additional state that came with the message.
-		props, err := plugin.UnmarshalProperties(
-			req.GetProperties(), plugin.MarshalOptions{Label: label, KeepUnknowns: true})
-		if err != nil {
-			return nil, errors.Wrapf(err, "failed to unmarshal read properties for resource %s", id)
-		}
-
-		// Now actually call the plugin to read the state and then return the results.
-		logging.V(5).Infof("ResourceMonitor.ReadResource received: %s #props=%d", label, len(props))
-		result, err := prov.Read(urn, id, props)
+		marshaled, err := plugin.MarshalProperties(state.Outputs, plugin.MarshalOptions{Label: label, KeepUnknowns: true})
 		if err != nil {
-			return nil, errors.Wrapf(err, "reading resource %s state", urn)
-		}
-		marshaled, err := plugin.MarshalProperties(result, plugin.MarshalOptions{Label: label, KeepUnknowns: true})
-		if err != nil {
-			return nil, errors.Wrapf(err, "failed to marshal %s return state", urn)
+			return nil, errors.Wrapf(err, "failed to marshal %s return state", state.URN)
 		}
 		resp.Properties = marshaled
 	}
-
 	return resp, nil
 }
 
@@ -348,12 +381,39 @@ func (rm *resmon) RegisterResource(ctx context.Context,
 	custom := req.GetCustom()
 	parent := resource.URN(req.GetParent())
 	protect := req.GetProtect()
+	ignoreChanges := req.GetIgnoreChanges()
+	deleteBeforeReplace := req.GetDeleteBeforeReplace()
+	replaceOnChanges := req.GetReplaceOnChanges()
+
+	var customTimeouts resource.CustomTimeouts
+	var err error
+	if customTimeouts.Create, err = parseCustomTimeout("create", req.GetCustomTimeoutCreate(), name); err != nil {
+		return nil, err
+	}
+	if customTimeouts.Update, err = parseCustomTimeout("update", req.GetCustomTimeoutUpdate(), name); err != nil {
+		return nil, err
+	}
+	if customTimeouts.Delete, err = parseCustomTimeout("delete", req.GetCustomTimeoutDelete(), name); err != nil {
+		return nil, err
+	}
 
 	dependencies := []resource.URN{}
 	for _, dependingURN := range req.GetDependencies() {
 		dependencies = append(dependencies, resource.URN(dependingURN))
 	}
 
+	var propertyDependencies map[resource.PropertyKey][]resource.URN
+	if pdeps := req.GetPropertyDependencies(); len(pdeps) > 0 {
+		propertyDependencies = make(map[resource.PropertyKey][]resource.URN)
+		for k, deps := range pdeps {
+			urns := []resource.URN{}
+			for _, urn := range deps.GetUrns() {
+				urns = append(urns, resource.URN(urn))
+			}
+			propertyDependencies[resource.PropertyKey(k)] = urns
+		}
+	}
+
 	props, err := plugin.UnmarshalProperties(
 		req.GetObject(), plugin.MarshalOptions{Label: label, KeepUnknowns: true, ComputeAssetHashes: true})
 	if err != nil {
@@ -361,12 +421,16 @@ func (rm *resmon) RegisterResource(ctx context.Context,
 	}
 
 	logging.V(5).Infof(
-		"ResourceMonitor.RegisterResource received: t=%v, name=%v, custom=%v, #props=%v, parent=%v, protect=%v, deps=%v",
-		t, name, custom, len(props), parent, protect, dependencies)
+		"ResourceMonitor.RegisterResource received: t=%v, name=%v, custom=%v, #props=%v, parent=%v, protect=%v, "+
+			"deps=%v, ignoreChanges=%v, deleteBeforeReplace=%v, customTimeouts=%v, replaceOnChanges=%v",
+		t, name, custom, len(props), parent, protect, dependencies, ignoreChanges, deleteBeforeReplace,
+		customTimeouts, replaceOnChanges)
 
 	// Send the goal state to the engine.
 	step := &registerResourceEvent{
-		goal: resource.NewGoal(t, name, custom, props, parent, protect, dependencies),
+		goal: resource.NewGoal(
+			t, name, custom, props, parent, protect, dependencies, ignoreChanges, deleteBeforeReplace,
+			customTimeouts, replaceOnChanges, propertyDependencies),
 		done: make(chan *RegisterResult),
 	}
 
@@ -412,6 +476,19 @@ func (rm *resmon) RegisterResource(ctx context.Context,
 	}, nil
 }
 
+// parseCustomTimeout parses the timeout a program gave for one of a resource's operations.  An empty timeout means
+// that there is no limit.
+func parseCustomTimeout(op string, timeout string, name tokens.QName) (time.Duration, error) {
+	if timeout == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(timeout)
+	if err != nil || d < 0 {
+		return 0, errors.Errorf("invalid %s timeout '%s' for resource %s", op, timeout, name)
+	}
+	return d, nil
+}
+
 // RegisterResourceOutputs records some new output properties for a resource that have arrived after its initial
 // provisioning.  These will make their way into the eventual checkpoint state file for that resource.
 func (rm *resmon) RegisterResourceOutputs(ctx context.Context,
@@ -497,3 +574,29 @@ func (g *registerResourceOutputsEvent) Done() {
 	// Communicate the resulting state back to the RPC thread, which is parked awaiting our reply.
 	g.done <- true
 }
+
+type readResourceEvent struct {
+	id           resource.ID          // the ID of the external resource to read.
+	t            tokens.Type          // the type of the resource.
+	name         tokens.QName         // the name of the resource, for URN purposes.
+	parent       resource.URN         // an optional parent URN for the resource.
+	props        resource.PropertyMap // any additional state needed to identify the resource.
+	dependencies []resource.URN       // the resources that the program observed this read depending upon.
+	done         chan *ReadResult     // the channel to communicate with after the resource has been read.
+}
+
+var _ ReadResourceEvent = (*readResourceEvent)(nil)
+
+func (g *readResourceEvent) event() {}
+
+func (g *readResourceEvent) ID() resource.ID                  { return g.id }
+func (g *readResourceEvent) Type() tokens.Type                { return g.t }
+func (g *readResourceEvent) Name() tokens.QName               { return g.name }
+func (g *readResourceEvent) Parent() resource.URN             { return g.parent }
+func (g *readResourceEvent) Properties() resource.PropertyMap { return g.props }
+func (g *readResourceEvent) Dependencies() []resource.URN     { return g.dependencies }
+
+func (g *readResourceEvent) Done(result *ReadResult) {
+	// Communicate the resulting state back to the RPC thread, which is parked awaiting our reply.
+	g.done <- result
+}
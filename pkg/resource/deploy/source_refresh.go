@@ -20,6 +20,7 @@ import (
 	"github.com/pulumi/pulumi/pkg/resource"
 	"github.com/pulumi/pulumi/pkg/resource/plugin"
 	"github.com/pulumi/pulumi/pkg/tokens"
+	"github.com/pulumi/pulumi/pkg/util/logging"
 	"github.com/pulumi/pulumi/pkg/workspace"
 )
 
@@ -52,18 +53,37 @@ func (src *refreshSource) Iterate(opts Options) (SourceIterator, error) {
 	if snap := src.target.Snapshot; snap != nil {
 		states = snap.Resources
 	}
+	var targets map[resource.URN]bool
+	if len(opts.Targets) > 0 {
+		targets = make(map[resource.URN]bool)
+		for _, urn := range opts.Targets {
+			targets[urn] = true
+		}
+	}
+	var properties []resource.PropertyPath
+	for _, p := range opts.RefreshProperties {
+		if path := resource.ParsePropertyPath(p); len(path) > 0 {
+			properties = append(properties, path)
+		}
+	}
 	return &refreshSourceIterator{
-		plugctx: src.plugctx,
-		states:  states,
-		current: -1,
+		plugctx:    src.plugctx,
+		retry:      opts.Retry,
+		states:     states,
+		targets:    targets,
+		properties: properties,
+		current:    -1,
 	}, nil
 }
 
 // refreshSourceIterator returns state from an existing snapshot, augmented by consulting the resource provider.
 type refreshSourceIterator struct {
-	plugctx *plugin.Context
-	states  []*resource.State
-	current int
+	plugctx    *plugin.Context
+	retry      RetryPolicy
+	states     []*resource.State
+	targets    map[resource.URN]bool   // if non-nil, the only resources to refresh.
+	properties []resource.PropertyPath // if non-empty, the only property paths to refresh.
+	current    int
 }
 
 func (iter *refreshSourceIterator) Close() error {
@@ -88,24 +108,50 @@ func (iter *refreshSourceIterator) Next() (SourceEvent, error) {
 
 // newRefreshGoal refreshes the state, if appropriate, and returns a new goal state.
 func (iter *refreshSourceIterator) newRefreshGoal(s *resource.State) (*resource.Goal, error) {
-	// If this is a custom resource, go ahead and load up its plugin, and ask it to refresh the state.
-	if s.Custom {
+	// If this is a custom resource, go ahead and load up its plugin, and ask it to refresh the state.  Resources that
+	// aren't targeted are left as they are, without consulting their providers, which the planner also expects.
+	if s.Custom && (iter.targets == nil || iter.targets[s.URN]) {
 		provider, err := iter.plugctx.Host.Provider(s.Type.Package(), nil)
 		if err != nil {
 			return nil, errors.Wrapf(err, "fetching provider to refresh %s", s.URN)
 		}
+		provider = newRetryingProvider(provider, iter.retry, iter.plugctx.Diag)
 		refreshed, err := provider.Read(s.URN, s.ID, s.Outputs)
 		if err != nil {
 			return nil, errors.Wrapf(err, "refreshing %s's state", s.URN)
 		} else if refreshed == nil {
 			return nil, nil // the resource was deleted.
 		}
+		if len(iter.properties) > 0 {
+			refreshed = refreshProperties(s.URN, s.Outputs, refreshed, iter.properties)
+		}
+		timeouts := s.CustomTimeouts
 		s = resource.NewState(
 			s.Type, s.URN, s.Custom, s.Delete, s.ID, s.Inputs, refreshed, s.Parent, s.Protect, s.Dependencies)
+		s.CustomTimeouts = timeouts
 	}
 
 	// Now just return the actual state as the goal state.
-	return resource.NewGoal(s.Type, s.URN.Name(), s.Custom, s.Outputs, s.Parent, s.Protect, s.Dependencies), nil
+	return resource.NewGoal(
+		s.Type, s.URN.Name(), s.Custom, s.Outputs, s.Parent, s.Protect, s.Dependencies, nil, false,
+		s.CustomTimeouts, nil, s.PropertyDependencies), nil
+}
+
+// refreshProperties returns a copy of the old outputs in which the value at each of the given paths has been replaced
+// with its refreshed value, so that only those values are refreshed.
+func refreshProperties(urn resource.URN, olds resource.PropertyMap, refreshed resource.PropertyMap,
+	paths []resource.PropertyPath) resource.PropertyMap {
+
+	result := olds
+	for _, path := range paths {
+		reset, ok := path.Reset(refreshed, result)
+		if !ok {
+			logging.V(7).Infof("Refresh could not refresh property '%v' of '%v'", path, urn)
+			continue
+		}
+		result = reset
+	}
+	return result
 }
 
 type refreshSourceEvent struct {
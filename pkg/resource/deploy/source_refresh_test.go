@@ -0,0 +1,50 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+)
+
+// TestRefreshProperties ensures that refreshing only some property paths leaves the other outputs as they were.
+func TestRefreshProperties(t *testing.T) {
+	urn := resource.URN("urn:pulumi:stack::proj::aws:ec2/instance:Instance::web")
+	olds := resource.NewPropertyMapFromMap(map[string]interface{}{
+		"instanceType": "t2.micro",
+		"tags":         map[string]interface{}{"env": "prod", "owner": "ops"},
+		"publicIp":     "10.0.0.1",
+	})
+	refreshed := resource.NewPropertyMapFromMap(map[string]interface{}{
+		"instanceType": "t2.large",
+		"tags":         map[string]interface{}{"env": "staging", "owner": "dev"},
+	})
+
+	result := refreshProperties(urn, olds, refreshed, []resource.PropertyPath{
+		resource.ParsePropertyPath("tags.env"),
+		resource.ParsePropertyPath("publicIp"),
+	})
+	assert.Equal(t, resource.NewPropertyMapFromMap(map[string]interface{}{
+		"instanceType": "t2.micro",
+		"tags":         map[string]interface{}{"env": "staging", "owner": "ops"},
+	}), result)
+
+	// The old outputs are left untouched.
+	assert.Equal(t, "prod", olds["tags"].ObjectValue()["env"].StringValue())
+	assert.Equal(t, "10.0.0.1", olds["publicIp"].StringValue())
+}
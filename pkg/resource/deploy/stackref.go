@@ -0,0 +1,65 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/plugin"
+	"github.com/pulumi/pulumi/pkg/tokens"
+)
+
+// ReadStackOutputsToken is the token of a function, built into the engine rather than offered by a provider, that a
+// program invokes to read the outputs of another stack.  Its arguments are the `name` of the stack and, optionally,
+// the URL of the `backend` that holds it, as given to `pulumi login`; without one, the stack is looked for in the
+// backend of the stack being updated.  It returns the stack's `outputs`.
+const ReadStackOutputsToken tokens.ModuleMember = "pulumi:pulumi:readStackOutputs"
+
+// StackOutputsReader reads the outputs of stacks that a program references, which may be kept in other backends than
+// the stack being updated.
+type StackOutputsReader interface {
+	// ReadStackOutputs returns the outputs of the named stack in the backend at the given URL, or in the backend of
+	// the stack being updated if the URL is empty.
+	ReadStackOutputs(backendURL string, name string) (resource.PropertyMap, error)
+}
+
+// readStackOutputs performs an invocation of ReadStackOutputsToken with the given reader.
+func readStackOutputs(reader StackOutputsReader,
+	args resource.PropertyMap) (resource.PropertyMap, []plugin.CheckFailure, error) {
+
+	name, backendURL := args["name"], args["backend"]
+	switch {
+	case name.IsComputed() || backendURL.IsComputed():
+		// The stack can't be known until its name is, so its outputs can't be either.
+		return resource.PropertyMap{"outputs": resource.MakeComputed(resource.NewStringProperty(""))}, nil, nil
+	case !name.IsString() || name.StringValue() == "":
+		return nil, []plugin.CheckFailure{{Property: "name", Reason: "the name of the stack must be a string"}}, nil
+	case !backendURL.IsNull() && !backendURL.IsString():
+		return nil, []plugin.CheckFailure{{Property: "backend", Reason: "the URL of the backend must be a string"}}, nil
+	case reader == nil:
+		return nil, nil, errors.New("the outputs of other stacks can't be read by this operation")
+	}
+
+	var url string
+	if backendURL.IsString() {
+		url = backendURL.StringValue()
+	}
+	outputs, err := reader.ReadStackOutputs(url, name.StringValue())
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "reading the outputs of stack '%s'", name.StringValue())
+	}
+	return resource.PropertyMap{"outputs": resource.NewObjectProperty(outputs)}, nil, nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+)
+
+type testStackOutputsReader map[string]resource.PropertyMap
+
+func (r testStackOutputsReader) ReadStackOutputs(backendURL string, name string) (resource.PropertyMap, error) {
+	outputs, has := r[backendURL+"#"+name]
+	if !has {
+		return nil, errors.Errorf("no stack '%s' in '%s'", name, backendURL)
+	}
+	return outputs, nil
+}
+
+// TestReadStackOutputs ensures that the outputs of referenced stacks are read from the backends that hold them.
+func TestReadStackOutputs(t *testing.T) {
+	reader := testStackOutputsReader{
+		"#network":                 {"vpcId": resource.NewStringProperty("vpc-1")},
+		"s3://state#network":       {"vpcId": resource.NewStringProperty("vpc-2")},
+		"https://api.pulumi.com#a": {},
+	}
+
+	ret, failures, err := readStackOutputs(reader, resource.PropertyMap{"name": resource.NewStringProperty("network")})
+	assert.NoError(t, err)
+	assert.Empty(t, failures)
+	assert.Equal(t, "vpc-1", ret["outputs"].ObjectValue()["vpcId"].StringValue())
+
+	ret, failures, err = readStackOutputs(reader, resource.PropertyMap{
+		"name":    resource.NewStringProperty("network"),
+		"backend": resource.NewStringProperty("s3://state"),
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, failures)
+	assert.Equal(t, "vpc-2", ret["outputs"].ObjectValue()["vpcId"].StringValue())
+
+	_, _, err = readStackOutputs(reader, resource.PropertyMap{"name": resource.NewStringProperty("missing")})
+	assert.Error(t, err)
+
+	_, failures, err = readStackOutputs(reader, resource.PropertyMap{"name": resource.NewNumberProperty(1)})
+	assert.NoError(t, err)
+	assert.Len(t, failures, 1)
+
+	// A stack whose name isn't known yet has outputs that aren't either.
+	ret, _, err = readStackOutputs(nil, resource.PropertyMap{
+		"name": resource.MakeComputed(resource.NewStringProperty("")),
+	})
+	assert.NoError(t, err)
+	assert.True(t, ret["outputs"].IsComputed())
+
+	_, _, err = readStackOutputs(nil, resource.PropertyMap{"name": resource.NewStringProperty("network")})
+	assert.Error(t, err)
+}
@@ -15,8 +15,11 @@
 package deploy
 
 import (
+	"strings"
+
 	"github.com/pkg/errors"
 
+	"github.com/pulumi/pulumi/pkg/diag"
 	"github.com/pulumi/pulumi/pkg/diag/colors"
 	"github.com/pulumi/pulumi/pkg/resource"
 	"github.com/pulumi/pulumi/pkg/resource/plugin"
@@ -40,10 +43,11 @@ type Step interface {
 
 // SameStep is a mutating step that does nothing.
 type SameStep struct {
-	plan *Plan                 // the current plan.
-	reg  RegisterResourceEvent // the registration intent to convey a URN back to.
-	old  *resource.State       // the state of the resource before this step.
-	new  *resource.State       // the state of the resource after this step.
+	plan          *Plan                 // the current plan.
+	reg           RegisterResourceEvent // the registration intent to convey a URN back to.
+	old           *resource.State       // the state of the resource before this step.
+	new           *resource.State       // the state of the resource after this step.
+	skippedCreate bool                  // true if this step stands in for a create that was skipped.
 }
 
 var _ Step = (*SameStep)(nil)
@@ -65,20 +69,42 @@ func NewSameStep(plan *Plan, reg RegisterResourceEvent, old *resource.State, new
 	}
 }
 
+// NewSkippedCreateStep returns a step that stands in for the creation of a resource that is not targeted by the
+// current plan.  The resource is not created, and so has no old state, and is not recorded in the resulting snapshot.
+func NewSkippedCreateStep(plan *Plan, reg RegisterResourceEvent, new *resource.State) Step {
+	contract.Assert(reg != nil)
+	contract.Assert(new != nil)
+	contract.Assert(new.URN != "")
+	contract.Assert(new.ID == "")
+	contract.Assert(!new.Delete)
+	return &SameStep{
+		plan:          plan,
+		reg:           reg,
+		new:           new,
+		skippedCreate: true,
+	}
+}
+
 func (s *SameStep) Op() StepOp           { return OpSame }
 func (s *SameStep) Plan() *Plan          { return s.plan }
-func (s *SameStep) Type() tokens.Type    { return s.old.Type }
-func (s *SameStep) URN() resource.URN    { return s.old.URN }
+func (s *SameStep) Type() tokens.Type    { return s.new.Type }
+func (s *SameStep) URN() resource.URN    { return s.new.URN }
 func (s *SameStep) Old() *resource.State { return s.old }
 func (s *SameStep) New() *resource.State { return s.new }
 func (s *SameStep) Res() *resource.State { return s.new }
 func (s *SameStep) Logical() bool        { return true }
 
+// IsSkippedCreate returns true if this step stands in for a create that was skipped because the resource is not
+// targeted by the current plan.
+func (s *SameStep) IsSkippedCreate() bool { return s.skippedCreate }
+
 func (s *SameStep) Apply(preview bool) (resource.Status, error) {
-	// Retain the URN, ID, and outputs:
-	s.new.URN = s.old.URN
-	s.new.ID = s.old.ID
-	s.new.Outputs = s.old.Outputs
+	// Retain the URN, ID, and outputs, if the resource exists:
+	if !s.skippedCreate {
+		s.new.URN = s.old.URN
+		s.new.ID = s.old.ID
+		s.new.Outputs = s.old.Outputs
+	}
 	s.reg.Done(&RegisterResult{State: s.new, Stable: true})
 	return resource.StatusOK, nil
 }
@@ -155,7 +181,7 @@ func (s *CreateStep) Apply(preview bool) (resource.Status, error) {
 			if err != nil {
 				return resource.StatusOK, err
 			}
-			id, outs, rst, err := prov.Create(s.URN(), s.new.Inputs)
+			id, outs, rst, err := prov.Create(s.URN(), s.new.Inputs, s.new.CustomTimeouts.Create)
 			if err != nil {
 				return rst, err
 			}
@@ -209,6 +235,9 @@ func NewDeleteReplacementStep(plan *Plan, old *resource.State, pendingDelete boo
 }
 
 func (s *DeleteStep) Op() StepOp {
+	if s.old.External {
+		return OpReadDiscard
+	}
 	if s.replacing {
 		return OpDeleteReplaced
 	}
@@ -229,14 +258,15 @@ func (s *DeleteStep) Apply(preview bool) (resource.Status, error) {
 			errors.Errorf("refusing to delete protected resource '%s'", s.old.URN)
 	}
 
-	if !preview {
+	// An external resource doesn't belong to this stack, so it is simply discarded from the stack's state.
+	if !preview && !s.old.External {
 		if s.old.Custom && !s.plan.IsRefresh() {
 			// Invoke the Delete RPC function for this provider:
 			prov, err := getProvider(s)
 			if err != nil {
 				return resource.StatusOK, err
 			}
-			if rst, err := prov.Delete(s.URN(), s.old.ID, s.old.All()); err != nil {
+			if rst, err := prov.Delete(s.URN(), s.old.ID, s.old.All(), s.old.CustomTimeouts.Delete); err != nil {
 				return rst, err
 			}
 		}
@@ -247,17 +277,18 @@ func (s *DeleteStep) Apply(preview bool) (resource.Status, error) {
 
 // UpdateStep is a mutating step that updates an existing resource's state.
 type UpdateStep struct {
-	plan    *Plan                  // the current plan.
-	reg     RegisterResourceEvent  // the registration intent to convey a URN back to.
-	old     *resource.State        // the state of the existing resource.
-	new     *resource.State        // the newly computed state of the resource after updating.
-	stables []resource.PropertyKey // an optional list of properties that won't change during this update.
+	plan    *Plan                          // the current plan.
+	reg     RegisterResourceEvent          // the registration intent to convey a URN back to.
+	old     *resource.State                // the state of the existing resource.
+	new     *resource.State                // the newly computed state of the resource after updating.
+	stables []resource.PropertyKey         // an optional list of properties that won't change during this update.
+	diffs   map[string]plugin.PropertyDiff // an optional, detailed diff supplied by the provider.
 }
 
 var _ Step = (*UpdateStep)(nil)
 
 func NewUpdateStep(plan *Plan, reg RegisterResourceEvent, old *resource.State,
-	new *resource.State, stables []resource.PropertyKey) Step {
+	new *resource.State, stables []resource.PropertyKey, diffs map[string]plugin.PropertyDiff) Step {
 	contract.Assert(old != nil)
 	contract.Assert(old.URN != "")
 	contract.Assert(old.ID != "" || !old.Custom)
@@ -273,6 +304,7 @@ func NewUpdateStep(plan *Plan, reg RegisterResourceEvent, old *resource.State,
 		old:     old,
 		new:     new,
 		stables: stables,
+		diffs:   diffs,
 	}
 }
 
@@ -285,6 +317,9 @@ func (s *UpdateStep) New() *resource.State { return s.new }
 func (s *UpdateStep) Res() *resource.State { return s.new }
 func (s *UpdateStep) Logical() bool        { return true }
 
+// DetailedDiff returns the provider's detailed diff of the update, if it supplied one.
+func (s *UpdateStep) DetailedDiff() map[string]plugin.PropertyDiff { return s.diffs }
+
 func (s *UpdateStep) Apply(preview bool) (resource.Status, error) {
 	// Always propagate the URN and ID, even in previews and refreshes.
 	s.new.URN = s.old.URN
@@ -299,7 +334,8 @@ func (s *UpdateStep) Apply(preview bool) (resource.Status, error) {
 			}
 
 			// Update to the combination of the old "all" state (including outputs), but overwritten with new inputs.
-			outs, rst, upderr := prov.Update(s.URN(), s.old.ID, s.old.All(), s.new.Inputs)
+			outs, rst, upderr := prov.Update(s.URN(), s.old.ID, s.old.All(), s.new.Inputs,
+				s.new.CustomTimeouts.Update)
 			if upderr != nil {
 				return rst, upderr
 			}
@@ -314,21 +350,156 @@ func (s *UpdateStep) Apply(preview bool) (resource.Status, error) {
 	return resource.StatusOK, nil
 }
 
+// ImportStep is a mutating step that brings an existing resource, created outside of Pulumi, under management by
+// reading its current state from its provider and adding it to the stack.
+type ImportStep struct {
+	plan *Plan           // the current plan.
+	new  *resource.State // the state of the resource to import, whose ID identifies the existing resource.
+}
+
+var _ Step = (*ImportStep)(nil)
+
+func NewImportStep(plan *Plan, new *resource.State) Step {
+	contract.Assert(new != nil)
+	contract.Assert(new.URN != "")
+	contract.Assert(new.ID != "")
+	contract.Assert(new.Custom)
+	contract.Assert(!new.Delete)
+	return &ImportStep{
+		plan: plan,
+		new:  new,
+	}
+}
+
+func (s *ImportStep) Op() StepOp           { return OpImport }
+func (s *ImportStep) Plan() *Plan          { return s.plan }
+func (s *ImportStep) Type() tokens.Type    { return s.new.Type }
+func (s *ImportStep) URN() resource.URN    { return s.new.URN }
+func (s *ImportStep) Old() *resource.State { return nil }
+func (s *ImportStep) New() *resource.State { return s.new }
+func (s *ImportStep) Res() *resource.State { return s.new }
+func (s *ImportStep) Logical() bool        { return true }
+
+func (s *ImportStep) Apply(preview bool) (resource.Status, error) {
+	// Reading the resource has no side effects, so it is read even during previews.
+	prov, err := getProvider(s)
+	if err != nil {
+		return resource.StatusOK, err
+	}
+	outs, err := prov.Read(s.URN(), s.new.ID, nil)
+	if err != nil {
+		return resource.StatusOK, err
+	} else if outs == nil {
+		return resource.StatusOK, errors.Errorf("resource '%s' does not exist", s.new.ID)
+	}
+
+	// The inputs that produced the resource aren't known, so they are derived from its current state by the provider,
+	// which discards any output-only properties.  The program that manages the resource from now on should declare it
+	// with these same inputs.
+	inputs, failures, err := prov.Check(s.URN(), nil, outs, false)
+	if err != nil {
+		return resource.StatusOK, err
+	} else if len(failures) != 0 {
+		var reasons []string
+		for _, failure := range failures {
+			reasons = append(reasons, failure.Reason)
+		}
+		return resource.StatusOK, errors.Errorf("could not determine the inputs of resource '%s': %s",
+			s.new.ID, strings.Join(reasons, "; "))
+	}
+
+	s.new.Inputs = inputs
+	s.new.Outputs = outs
+	return resource.StatusOK, nil
+}
+
+// ReadStep is a step that reads the current state of an external resource from its provider.  An external resource
+// belongs to something other than this stack, so although its state is recorded, to make it available to the resources
+// that depend upon it, the engine never creates, updates, or deletes it.  Any difference between its state as recorded
+// and as read is shown, but never acted upon.
+type ReadStep struct {
+	plan *Plan             // the current plan.
+	read ReadResourceEvent // the read intent to convey the resource's state back to.
+	old  *resource.State   // the state of the resource as last read, if any.
+	new  *resource.State   // the state of the resource to read, whose ID identifies it.
+}
+
+var _ Step = (*ReadStep)(nil)
+
+func NewReadStep(plan *Plan, read ReadResourceEvent, old *resource.State, new *resource.State) Step {
+	contract.Assert(new != nil)
+	contract.Assert(new.URN != "")
+	contract.Assert(new.ID != "")
+	contract.Assert(new.Custom)
+	contract.Assert(new.External)
+	contract.Assert(old == nil || old.External)
+	return &ReadStep{
+		plan: plan,
+		read: read,
+		old:  old,
+		new:  new,
+	}
+}
+
+func (s *ReadStep) Op() StepOp           { return OpRead }
+func (s *ReadStep) Plan() *Plan          { return s.plan }
+func (s *ReadStep) Type() tokens.Type    { return s.new.Type }
+func (s *ReadStep) URN() resource.URN    { return s.new.URN }
+func (s *ReadStep) Old() *resource.State { return s.old }
+func (s *ReadStep) New() *resource.State { return s.new }
+func (s *ReadStep) Res() *resource.State { return s.new }
+func (s *ReadStep) Logical() bool        { return true }
+
+func (s *ReadStep) Apply(preview bool) (resource.Status, error) {
+	// Reading the resource has no side effects, so it is read even during previews, unless its ID isn't known yet.
+	if s.new.ID != plugin.UnknownStringValue {
+		prov, err := getProvider(s)
+		if err != nil {
+			return resource.StatusOK, err
+		}
+		outs, err := prov.Read(s.URN(), s.new.ID, s.new.Inputs)
+		if err != nil {
+			return resource.StatusOK, err
+		} else if outs == nil {
+			return resource.StatusOK, errors.Errorf("external resource '%s' does not exist", s.new.ID)
+		}
+
+		// Report any drift since the resource was last read, but leave it alone, since it isn't this stack's.
+		if s.old != nil {
+			if diff := s.old.Outputs.Diff(outs); diff != nil {
+				var changed []string
+				for _, k := range diff.Keys() {
+					if diff.Changed(k) {
+						changed = append(changed, string(k))
+					}
+				}
+				s.plan.Diag().Warningf(diag.GetExternalResourceDriftWarning(s.URN()),
+					s.URN(), strings.Join(changed, ", "))
+			}
+		}
+		s.new.Outputs = outs
+	}
+
+	s.read.Done(&ReadResult{State: s.new})
+	return resource.StatusOK, nil
+}
+
 // ReplaceStep is a logical step indicating a resource will be replaced.  This is comprised of three physical steps:
 // a creation of the new resource, any number of intervening updates of dependents to the new resource, and then
 // a deletion of the now-replaced old resource.  This logical step is primarily here for tools and visualization.
 type ReplaceStep struct {
-	plan          *Plan                  // the current plan.
-	old           *resource.State        // the state of the existing resource.
-	new           *resource.State        // the new state snapshot.
-	keys          []resource.PropertyKey // the keys causing replacement.
-	pendingDelete bool                   // true if a pending deletion should happen.
+	plan          *Plan                          // the current plan.
+	old           *resource.State                // the state of the existing resource.
+	new           *resource.State                // the new state snapshot.
+	keys          []resource.PropertyKey         // the keys causing replacement.
+	pendingDelete bool                           // true if a pending deletion should happen.
+	diffs         map[string]plugin.PropertyDiff // an optional, detailed diff supplied by the provider.
 }
 
 var _ Step = (*ReplaceStep)(nil)
 
 func NewReplaceStep(plan *Plan, old *resource.State, new *resource.State,
-	keys []resource.PropertyKey, pendingDelete bool) Step {
+	keys []resource.PropertyKey, diffs map[string]plugin.PropertyDiff, pendingDelete bool) Step {
 	contract.Assert(old != nil)
 	contract.Assert(old.URN != "")
 	contract.Assert(old.ID != "" || !old.Custom)
@@ -343,6 +514,7 @@ func NewReplaceStep(plan *Plan, old *resource.State, new *resource.State,
 		new:           new,
 		keys:          keys,
 		pendingDelete: pendingDelete,
+		diffs:         diffs,
 	}
 }
 
@@ -356,6 +528,13 @@ func (s *ReplaceStep) Res() *resource.State         { return s.new }
 func (s *ReplaceStep) Keys() []resource.PropertyKey { return s.keys }
 func (s *ReplaceStep) Logical() bool                { return true }
 
+// DeleteBeforeReplace returns true if the old resource is deleted before its replacement is created, rather than
+// afterwards.
+func (s *ReplaceStep) DeleteBeforeReplace() bool { return !s.pendingDelete }
+
+// DetailedDiff returns the provider's detailed diff of the replacement, if it supplied one.
+func (s *ReplaceStep) DetailedDiff() map[string]plugin.PropertyDiff { return s.diffs }
+
 func (s *ReplaceStep) Apply(preview bool) (resource.Status, error) {
 	// If this is a pending delete, we should have marked the old resource for deletion in the CreateReplacement step.
 	contract.Assert(!s.pendingDelete || s.old.Delete)
@@ -373,6 +552,9 @@ const (
 	OpReplace           StepOp = "replace"            // replacing a resource with a new one.
 	OpCreateReplacement StepOp = "create-replacement" // creating a new resource for a replacement.
 	OpDeleteReplaced    StepOp = "delete-replaced"    // deleting an existing resource after replacement.
+	OpImport            StepOp = "import"             // importing an existing resource into the stack.
+	OpRead              StepOp = "read"               // reading the state of an external resource.
+	OpReadDiscard       StepOp = "discard"            // discarding an external resource that is no longer read.
 )
 
 // StepOps contains the full set of step operation types.
@@ -384,6 +566,9 @@ var StepOps = []StepOp{
 	OpReplace,
 	OpCreateReplacement,
 	OpDeleteReplaced,
+	OpImport,
+	OpRead,
+	OpReadDiscard,
 }
 
 // Color returns a suggested color for lines of this op type.
@@ -403,6 +588,10 @@ func (op StepOp) Color() string {
 		return colors.SpecCreateReplacement
 	case OpDeleteReplaced:
 		return colors.SpecDeleteReplaced
+	case OpImport:
+		return colors.SpecImport
+	case OpRead, OpReadDiscard:
+		return colors.SpecRead
 	default:
 		contract.Failf("Unrecognized resource step op: '%v'", op)
 		return ""
@@ -431,6 +620,12 @@ func (op StepOp) RawPrefix() string {
 		return "++"
 	case OpDeleteReplaced:
 		return "--"
+	case OpImport:
+		return "= "
+	case OpRead:
+		return "> "
+	case OpReadDiscard:
+		return "< "
 	default:
 		contract.Failf("Unrecognized resource step op: %v", op)
 		return ""
@@ -441,6 +636,12 @@ func (op StepOp) PastTense() string {
 	switch op {
 	case OpSame, OpCreate, OpDelete, OpReplace, OpCreateReplacement, OpDeleteReplaced, OpUpdate:
 		return string(op) + "d"
+	case OpImport:
+		return "imported"
+	case OpRead:
+		return "read"
+	case OpReadDiscard:
+		return "discarded"
 	default:
 		contract.Failf("Unexpected resource step op: %v", op)
 		return ""
@@ -0,0 +1,105 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/tokens"
+	"github.com/pulumi/pulumi/pkg/util/logging"
+	"github.com/pulumi/pulumi/pkg/workspace"
+)
+
+// tagsKey is the input property into which transformations merge tags.
+const tagsKey resource.PropertyKey = "tags"
+
+// transformGoal applies each of the given transformations that matches a resource's type to its goal, in order, and
+// returns the resulting goal.  The original goal is left untouched.
+func transformGoal(goal *resource.Goal, transforms []workspace.Transformation) *resource.Goal {
+	var result *resource.Goal
+	for i, t := range transforms {
+		if !transformationMatches(t, goal) {
+			continue
+		}
+		logging.V(7).Infof("Applying transformation #%d to resource %s of type %s", i, goal.Name, goal.Type)
+
+		if result == nil {
+			transformed := *goal
+			transformed.Properties = goal.Properties.Copy()
+			result = &transformed
+		}
+		for k, v := range t.Defaults {
+			if !result.Properties.HasValue(resource.PropertyKey(k)) {
+				result.Properties[resource.PropertyKey(k)] = resource.NewPropertyValue(v)
+			}
+		}
+		for k, v := range t.Set {
+			result.Properties[resource.PropertyKey(k)] = resource.NewPropertyValue(v)
+		}
+		if len(t.Tags) > 0 {
+			mergeTags(result, t.Tags)
+		}
+		if t.Protect != nil {
+			result.Protect = *t.Protect
+		}
+	}
+
+	if result == nil {
+		return goal
+	}
+	return result
+}
+
+// transformationMatches returns true if the given transformation applies to a resource.
+func transformationMatches(t workspace.Transformation, goal *resource.Goal) bool {
+	if len(t.Types) == 0 {
+		return goal.Custom
+	}
+	for _, pattern := range t.Types {
+		if typePatternMatches(pattern, goal.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// typePatternMatches returns true if a type token matches a pattern, in which `*` matches any sequence of characters.
+func typePatternMatches(pattern string, t tokens.Type) bool {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	re := regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+	return re.MatchString(string(t))
+}
+
+// mergeTags merges tags into a goal's tags input, replacing any existing tags with the same keys.  If the program's
+// tags aren't known yet, they're left alone, as there's no telling what they will be.
+func mergeTags(goal *resource.Goal, tags map[string]interface{}) {
+	merged := resource.PropertyMap{}
+	if existing, has := goal.Properties[tagsKey]; has && !existing.IsNull() {
+		if !existing.IsObject() {
+			logging.V(7).Infof("Not merging tags into resource %s, whose tags are not an object", goal.Name)
+			return
+		}
+		merged = existing.ObjectValue().Copy()
+	}
+	for k, v := range tags {
+		merged[resource.PropertyKey(k)] = resource.NewPropertyValue(v)
+	}
+	goal.Properties[tagsKey] = resource.NewObjectProperty(merged)
+}
@@ -0,0 +1,71 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/workspace"
+)
+
+func TestTransformGoal(t *testing.T) {
+	t.Parallel()
+
+	protect := true
+	transforms := []workspace.Transformation{
+		{
+			Types:    []string{"aws:s3/*"},
+			Set:      map[string]interface{}{"acl": "private"},
+			Defaults: map[string]interface{}{"versioning": true, "region": "us-west-2"},
+			Protect:  &protect,
+		},
+		{
+			Tags: map[string]interface{}{"team": "platform"},
+		},
+	}
+
+	goal := resource.NewGoal("aws:s3/bucket:Bucket", "b", true, resource.PropertyMap{
+		"acl":    resource.NewStringProperty("public-read"),
+		"region": resource.NewStringProperty("us-east-1"),
+		"tags": resource.NewObjectProperty(resource.PropertyMap{
+			"owner": resource.NewStringProperty("me"),
+			"team":  resource.NewStringProperty("app"),
+		}),
+	}, "", false, nil, nil, false, resource.CustomTimeouts{}, nil, nil)
+
+	result := transformGoal(goal, transforms)
+	assert.True(t, result.Protect)
+	assert.Equal(t, resource.PropertyMap{
+		"acl":        resource.NewStringProperty("private"),
+		"region":     resource.NewStringProperty("us-east-1"),
+		"versioning": resource.NewBoolProperty(true),
+		"tags": resource.NewObjectProperty(resource.PropertyMap{
+			"owner": resource.NewStringProperty("me"),
+			"team":  resource.NewStringProperty("platform"),
+		}),
+	}, result.Properties)
+
+	// The original goal is left untouched.
+	assert.False(t, goal.Protect)
+	assert.Equal(t, resource.NewStringProperty("public-read"), goal.Properties["acl"])
+
+	// Component resources only match transformations that name their types.
+	component := resource.NewGoal("my:index:Component", "c", false, resource.PropertyMap{},
+		"", false, nil, nil, false, resource.CustomTimeouts{}, nil, nil)
+	assert.Equal(t, component, transformGoal(component, transforms))
+}
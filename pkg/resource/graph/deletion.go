@@ -0,0 +1,60 @@
+// Copyright 2016-2018, Pulumi Corporation.  All rights reserved.
+
+package graph
+
+import (
+	"github.com/pulumi/pulumi/pkg/resource"
+)
+
+// DeletionWaves groups the given resources into the waves in which they must be deleted.  A resource can't be deleted
+// until every resource that depends on it, and each of its children, has been deleted; the resources within a single
+// wave don't depend on one another, and so may be deleted in parallel.  The resources should be in topological order
+// with respect to their dependencies, as they are in a snapshot.  Within a wave, resources are in the reverse of that
+// order, which is the order in which a serial destroy deletes them.
+func DeletionWaves(resources []*resource.State) [][]*resource.State {
+	// Walk the resources in reverse, so that each resource is visited only after everything that depends on it.  By
+	// then, its wave is known, and it pushes each of its dependencies and its parent into a later wave.
+	waves := make(map[resource.URN]int)
+	var result [][]*resource.State
+	for i := len(resources) - 1; i >= 0; i-- {
+		res := resources[i]
+		wave := waves[res.URN]
+		for len(result) <= wave {
+			result = append(result, nil)
+		}
+		result[wave] = append(result[wave], res)
+
+		for _, dep := range deletionPredecessors(res) {
+			if waves[dep] < wave+1 {
+				waves[dep] = wave + 1
+			}
+		}
+	}
+	return result
+}
+
+// BlockedByProtection returns the resources that can't be deleted because they are protected, or because a protected
+// resource depends on them or is one of their children.  The resources should be in topological order with respect to
+// their dependencies.
+func BlockedByProtection(resources []*resource.State) map[resource.URN]bool {
+	blocked := make(map[resource.URN]bool)
+	for i := len(resources) - 1; i >= 0; i-- {
+		res := resources[i]
+		if res.Protect || blocked[res.URN] {
+			blocked[res.URN] = true
+			for _, dep := range deletionPredecessors(res) {
+				blocked[dep] = true
+			}
+		}
+	}
+	return blocked
+}
+
+// deletionPredecessors returns the resources that can only be deleted after the given resource has been: each of its
+// dependencies, and its parent.
+func deletionPredecessors(res *resource.State) []resource.URN {
+	if res.Parent == "" {
+		return res.Dependencies
+	}
+	return append(append([]resource.URN{}, res.Dependencies...), res.Parent)
+}
@@ -0,0 +1,42 @@
+// Copyright 2016-2018, Pulumi Corporation.  All rights reserved.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeletionWaves(t *testing.T) {
+	a := NewResource("a")
+	b := NewResource("b", a.URN)
+	c := NewResource("c", a.URN)
+	d := NewResource("d", b.URN)
+	e := NewResource("e")
+	e.Parent = c.URN
+
+	assert.Equal(t, [][]*resource.State{
+		{e, d},
+		{c, b},
+		{a},
+	}, DeletionWaves([]*resource.State{a, b, c, d, e}))
+
+	assert.Nil(t, DeletionWaves(nil))
+}
+
+func TestBlockedByProtection(t *testing.T) {
+	a := NewResource("a")
+	b := NewResource("b", a.URN)
+	c := NewResource("c", a.URN)
+	d := NewResource("d", b.URN)
+	e := NewResource("e")
+	e.Parent = c.URN
+	b.Protect = true
+
+	assert.Equal(t, map[resource.URN]bool{
+		a.URN: true,
+		b.URN: true,
+	}, BlockedByProtection([]*resource.State{a, b, c, d, e}))
+}
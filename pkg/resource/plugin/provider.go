@@ -16,6 +16,7 @@ package plugin
 
 import (
 	"io"
+	"time"
 
 	"github.com/pulumi/pulumi/pkg/resource"
 	"github.com/pulumi/pulumi/pkg/resource/config"
@@ -47,17 +48,22 @@ type Provider interface {
 	// Diff checks what impacts a hypothetical update will have on the resource's properties.
 	Diff(urn resource.URN, id resource.ID, olds resource.PropertyMap, news resource.PropertyMap,
 		allowUnknowns bool) (DiffResult, error)
-	// Create allocates a new instance of the provided resource and returns its unique resource.ID.
-	Create(urn resource.URN, news resource.PropertyMap) (resource.ID, resource.PropertyMap, resource.Status, error)
+	// Create allocates a new instance of the provided resource and returns its unique resource.ID.  If timeout is
+	// non-zero, the operation is canceled, and fails, once it has taken that long.
+	Create(urn resource.URN, news resource.PropertyMap,
+		timeout time.Duration) (resource.ID, resource.PropertyMap, resource.Status, error)
 	// Read the current live state associated with a resource.  Enough state must be include in the inputs to uniquely
 	// identify the resource; this is typically just the resource ID, but may also include some properties.  If the
 	// resource is missing (for instance, because it has been deleted), the resulting property map will be nil.
 	Read(urn resource.URN, id resource.ID, props resource.PropertyMap) (resource.PropertyMap, error)
-	// Update updates an existing resource with new values.
-	Update(urn resource.URN, id resource.ID,
-		olds resource.PropertyMap, news resource.PropertyMap) (resource.PropertyMap, resource.Status, error)
-	// Delete tears down an existing resource.
-	Delete(urn resource.URN, id resource.ID, props resource.PropertyMap) (resource.Status, error)
+	// Update updates an existing resource with new values.  If timeout is non-zero, the operation is canceled, and
+	// fails, once it has taken that long.
+	Update(urn resource.URN, id resource.ID, olds resource.PropertyMap, news resource.PropertyMap,
+		timeout time.Duration) (resource.PropertyMap, resource.Status, error)
+	// Delete tears down an existing resource.  If timeout is non-zero, the operation is canceled, and fails, once it
+	// has taken that long.
+	Delete(urn resource.URN, id resource.ID, props resource.PropertyMap,
+		timeout time.Duration) (resource.Status, error)
 	// Invoke dynamically executes a built-in function in the provider.
 	Invoke(tok tokens.ModuleMember, args resource.PropertyMap) (resource.PropertyMap, []CheckFailure, error)
 	// GetPluginInfo returns this plugin's information.
@@ -88,9 +94,35 @@ type DiffResult struct {
 	ReplaceKeys         []resource.PropertyKey // an optional list of replacement keys.
 	StableKeys          []resource.PropertyKey // an optional list of property keys that are stable.
 	DeleteBeforeReplace bool                   // if true, this resource must be deleted before recreating it.
+	// an optional, detailed diff supplied by the provider, keyed by the path of each changed property.  If non-nil,
+	// it is preferred over a diff of the old and new properties, which may report changes that the provider ignores.
+	DetailedDiff map[string]PropertyDiff
 }
 
 // Replace returns true if this diff represents a replacement.
 func (r DiffResult) Replace() bool {
 	return len(r.ReplaceKeys) > 0
 }
+
+// DiffKind is the kind of change made to a single property, as reported by a provider's detailed diff.
+type DiffKind int
+
+const (
+	DiffAdd           DiffKind = 0 // the property was added.
+	DiffAddReplace    DiffKind = 1 // the property was added, and this change requires a replacement.
+	DiffDelete        DiffKind = 2 // the property was deleted.
+	DiffDeleteReplace DiffKind = 3 // the property was deleted, and this change requires a replacement.
+	DiffUpdate        DiffKind = 4 // the property's value was changed.
+	DiffUpdateReplace DiffKind = 5 // the property's value was changed, and this change requires a replacement.
+)
+
+// IsReplace returns true if this kind of change requires a replacement.
+func (k DiffKind) IsReplace() bool {
+	return k == DiffAddReplace || k == DiffDeleteReplace || k == DiffUpdateReplace
+}
+
+// PropertyDiff describes the change made to a single property, as reported by a provider's detailed diff.
+type PropertyDiff struct {
+	Kind      DiffKind // the kind of change.
+	InputDiff bool     // true if the change is between the old and new inputs, rather than the old state and new inputs.
+}
@@ -0,0 +1,39 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"google.golang.org/grpc/codes"
+
+	"github.com/pulumi/pulumi/pkg/util/rpcutil/rpcerror"
+)
+
+// IsTransientError returns true if an error returned by a provider is transient, meaning that the operation that
+// failed may succeed if it is tried again.  Providers classify their errors by their gRPC status codes: an operation
+// that fails with `Unavailable`, `ResourceExhausted` (e.g. because the cloud API is throttling requests), or `Aborted`
+// is known not to have taken effect, and may safely be retried.
+func IsTransientError(err error) bool {
+	rpcErr, ok := rpcerror.FromError(err)
+	if !ok {
+		return false
+	}
+
+	switch rpcErr.Code() {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted:
+		return true
+	default:
+		return false
+	}
+}
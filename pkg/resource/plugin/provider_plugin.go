@@ -15,8 +15,11 @@
 package plugin
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/blang/semver"
 	pbempty "github.com/golang/protobuf/ptypes/empty"
@@ -230,19 +233,59 @@ func (p *provider) Diff(urn resource.URN, id resource.ID,
 	}
 	changes := resp.GetChanges()
 	deleteBeforeReplace := resp.GetDeleteBeforeReplace()
-	logging.V(7).Infof("%s success: changes=%d #replaces=%d #stables=%d delbefrepl=%v",
-		label, changes, len(replaces), len(stables), deleteBeforeReplace)
+
+	// If the provider supplied a detailed diff, any property whose change it says requires a replacement causes one,
+	// whether or not the provider also listed the property amongst its replaces.
+	var detailedDiff map[string]PropertyDiff
+	if resp.GetHasDetailedDiff() {
+		detailed := resp.GetDetailedDiff()
+		var paths []string
+		for path := range detailed {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		detailedDiff = make(map[string]PropertyDiff)
+		for _, path := range paths {
+			diff := detailed[path]
+			kind := DiffKind(diff.GetKind())
+			detailedDiff[path] = PropertyDiff{Kind: kind, InputDiff: diff.GetInputDiff()}
+			if kind.IsReplace() {
+				replaces = appendReplaceKey(replaces, path)
+			}
+		}
+	}
+
+	logging.V(7).Infof("%s success: changes=%d #replaces=%d #stables=%d delbefrepl=%v #detailed=%d",
+		label, changes, len(replaces), len(stables), deleteBeforeReplace, len(detailedDiff))
 	return DiffResult{
 		Changes:             DiffChanges(changes),
 		ReplaceKeys:         replaces,
 		StableKeys:          stables,
 		DeleteBeforeReplace: deleteBeforeReplace,
+		DetailedDiff:        detailedDiff,
 	}, nil
 }
 
+// appendReplaceKey adds the top-level key of the given property path to a list of replacement keys, if it isn't already
+// present.
+func appendReplaceKey(replaces []resource.PropertyKey, path string) []resource.PropertyKey {
+	elems := resource.ParsePropertyPath(path)
+	if len(elems) == 0 {
+		return replaces
+	}
+	key := resource.PropertyKey(elems[0])
+	for _, k := range replaces {
+		if k == key {
+			return replaces
+		}
+	}
+	return append(replaces, key)
+}
+
 // Create allocates a new instance of the provided resource and assigns its unique resource.ID and outputs afterwards.
-func (p *provider) Create(urn resource.URN, props resource.PropertyMap) (resource.ID,
-	resource.PropertyMap, resource.Status, error) {
+func (p *provider) Create(urn resource.URN, props resource.PropertyMap,
+	timeout time.Duration) (resource.ID, resource.PropertyMap, resource.Status, error) {
 	contract.Assert(urn != "")
 	contract.Assert(props != nil)
 
@@ -260,12 +303,14 @@ func (p *provider) Create(urn resource.URN, props resource.PropertyMap) (resourc
 		return "", nil, resource.StatusOK, err
 	}
 
-	resp, err := client.Create(p.ctx.Request(), &pulumirpc.CreateRequest{
+	ctx, cancel := p.requestContext(timeout)
+	defer cancel()
+	resp, err := client.Create(ctx, &pulumirpc.CreateRequest{
 		Urn:        string(urn),
 		Properties: mprops,
 	})
 	if err != nil {
-		resourceStatus, rpcErr := resourceStateAndError(err)
+		resourceStatus, rpcErr := operationStateAndError(ctx, err, timeout)
 		logging.V(7).Infof("%s failed: err=%v", label, rpcErr)
 		return "", nil, resourceStatus, rpcErr
 	}
@@ -339,8 +384,8 @@ func (p *provider) Read(urn resource.URN, id resource.ID, props resource.Propert
 }
 
 // Update updates an existing resource with new values.
-func (p *provider) Update(urn resource.URN, id resource.ID,
-	olds resource.PropertyMap, news resource.PropertyMap) (resource.PropertyMap, resource.Status, error) {
+func (p *provider) Update(urn resource.URN, id resource.ID, olds resource.PropertyMap, news resource.PropertyMap,
+	timeout time.Duration) (resource.PropertyMap, resource.Status, error) {
 	contract.Assert(urn != "")
 	contract.Assert(id != "")
 	contract.Assert(news != nil)
@@ -365,14 +410,16 @@ func (p *provider) Update(urn resource.URN, id resource.ID,
 		return nil, resource.StatusOK, err
 	}
 
-	resp, err := client.Update(p.ctx.Request(), &pulumirpc.UpdateRequest{
+	ctx, cancel := p.requestContext(timeout)
+	defer cancel()
+	resp, err := client.Update(ctx, &pulumirpc.UpdateRequest{
 		Id:   string(id),
 		Urn:  string(urn),
 		Olds: molds,
 		News: mnews,
 	})
 	if err != nil {
-		resourceStatus, rpcErr := resourceStateAndError(err)
+		resourceStatus, rpcErr := operationStateAndError(ctx, err, timeout)
 		logging.V(7).Infof("%s failed: %v", label, rpcErr)
 		return nil, resourceStatus, rpcErr
 	}
@@ -388,7 +435,8 @@ func (p *provider) Update(urn resource.URN, id resource.ID,
 }
 
 // Delete tears down an existing resource.
-func (p *provider) Delete(urn resource.URN, id resource.ID, props resource.PropertyMap) (resource.Status, error) {
+func (p *provider) Delete(urn resource.URN, id resource.ID, props resource.PropertyMap,
+	timeout time.Duration) (resource.Status, error) {
 	contract.Assert(urn != "")
 	contract.Assert(id != "")
 
@@ -406,12 +454,14 @@ func (p *provider) Delete(urn resource.URN, id resource.ID, props resource.Prope
 		return resource.StatusOK, err
 	}
 
-	if _, err := client.Delete(p.ctx.Request(), &pulumirpc.DeleteRequest{
+	ctx, cancel := p.requestContext(timeout)
+	defer cancel()
+	if _, err := client.Delete(ctx, &pulumirpc.DeleteRequest{
 		Id:         string(id),
 		Urn:        string(urn),
 		Properties: mprops,
 	}); err != nil {
-		resourceStatus, rpcErr := resourceStateAndError(err)
+		resourceStatus, rpcErr := operationStateAndError(ctx, err, timeout)
 		logging.V(7).Infof("%s failed: %v", label, rpcErr)
 		return resourceStatus, rpcErr
 	}
@@ -525,6 +575,27 @@ func createConfigureError(rpcerr *rpcerror.Error) error {
 	return rpcerr
 }
 
+// requestContext returns the context for an RPC that performs a resource operation.  If timeout is non-zero, the
+// context is canceled once it elapses, which cancels the RPC.  The returned function must be called once the RPC is
+// done.
+func (p *provider) requestContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout == 0 {
+		return p.ctx.Request(), func() {}
+	}
+	return context.WithTimeout(p.ctx.Request(), timeout)
+}
+
+// operationStateAndError is like resourceStateAndError, but also reports an RPC that was canceled because its
+// operation timed out.  As there is no telling how far the provider got before the operation was abandoned, the
+// resource's state is unknown.
+func operationStateAndError(ctx context.Context, err error, timeout time.Duration) (resource.Status, error) {
+	if ctx.Err() == context.DeadlineExceeded {
+		logging.V(8).Infof("provider operation timed out after %v", timeout)
+		return resource.StatusUnknown, errors.Errorf("the operation timed out after %v", timeout)
+	}
+	return resourceStateAndError(err)
+}
+
 // resourceStateAndError interprets an error obtained from a gRPC endpoint.
 //
 // gRPC gives us a `status.Status` structure as an `error` whenever our
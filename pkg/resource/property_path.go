@@ -0,0 +1,213 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"path"
+	"strconv"
+	"strings"
+)
+
+// PropertyPath is the path to a nested property value, with one element per object key or array index.  For example,
+// the path to `metadata.annotations.foo` is ["metadata", "annotations", "foo"], and the path to the image of the first
+// element of a `containers` array is ["containers", "0", "image"].
+type PropertyPath []string
+
+// ParsePropertyPath parses a dotted path string (or pattern) into its elements.
+func ParsePropertyPath(s string) PropertyPath {
+	if s == "" {
+		return nil
+	}
+	return PropertyPath(strings.Split(s, "."))
+}
+
+// Key returns a new path that refers to the given object key beneath this path.
+func (p PropertyPath) Key(k PropertyKey) PropertyPath {
+	return p.append(string(k))
+}
+
+// Index returns a new path that refers to the given array index beneath this path.
+func (p PropertyPath) Index(i int) PropertyPath {
+	return p.append(strconv.Itoa(i))
+}
+
+func (p PropertyPath) append(elem string) PropertyPath {
+	// Always copy, so that sibling paths derived from the same parent never share a backing array.
+	result := make(PropertyPath, len(p), len(p)+1)
+	copy(result, p)
+	return append(result, elem)
+}
+
+// String returns the dotted form of this path.
+func (p PropertyPath) String() string {
+	return strings.Join(p, ".")
+}
+
+// Matches returns true if this path matches the given glob pattern.  Patterns are dotted like paths; each element is
+// matched using path.Match semantics (so `*` matches a single key or index), and the special element `**` matches any
+// number of elements, including none.
+func (p PropertyPath) Matches(pattern string) bool {
+	return matchPropertyPath(ParsePropertyPath(pattern), p)
+}
+
+// MayMatchDescendants returns true if this path, or any path beneath it, might match the given glob pattern.  This is
+// useful to decide whether it is worth recursing into an object or array while searching for matching properties.
+func (p PropertyPath) MayMatchDescendants(pattern string) bool {
+	pat := ParsePropertyPath(pattern)
+	elems := p
+	for len(elems) > 0 {
+		if len(pat) == 0 {
+			return false
+		}
+		if pat[0] == "**" {
+			return true
+		}
+		if ok, err := path.Match(pat[0], elems[0]); err != nil || !ok {
+			return false
+		}
+		pat, elems = pat[1:], elems[1:]
+	}
+	return true
+}
+
+// MatchesAny returns true if this path matches any of the given glob patterns.
+func (p PropertyPath) MatchesAny(patterns []string) bool {
+	for _, pattern := range patterns {
+		if p.Matches(pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPropertyPath(pat []string, elems []string) bool {
+	if len(pat) == 0 {
+		return len(elems) == 0
+	}
+	if pat[0] == "**" {
+		for i := 0; i <= len(elems); i++ {
+			if matchPropertyPath(pat[1:], elems[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(elems) == 0 {
+		return false
+	}
+	if ok, err := path.Match(pat[0], elems[0]); err != nil || !ok {
+		return false
+	}
+	return matchPropertyPath(pat[1:], elems[1:])
+}
+
+// Get returns the value found at this path within the given property map, if there is one.
+func (p PropertyPath) Get(props PropertyMap) (PropertyValue, bool) {
+	v := NewObjectProperty(props)
+	for _, elem := range p {
+		switch {
+		case v.IsObject():
+			child, has := v.ObjectValue()[PropertyKey(elem)]
+			if !has {
+				return PropertyValue{}, false
+			}
+			v = child
+		case v.IsArray():
+			arr := v.ArrayValue()
+			i, err := strconv.Atoi(elem)
+			if err != nil || i < 0 || i >= len(arr) {
+				return PropertyValue{}, false
+			}
+			v = arr[i]
+		default:
+			return PropertyValue{}, false
+		}
+	}
+	return v, true
+}
+
+// Reset returns a copy of the property map news in which the value at this path has been reset to the value found at
+// the same path in olds; if olds has no such value, the value is removed from news instead.  Any objects and arrays
+// along the path are copied, so neither map is modified.  If the path cannot be reset (for example, because its parent
+// doesn't exist in news), news is returned unchanged along with false.
+func (p PropertyPath) Reset(olds PropertyMap, news PropertyMap) (PropertyMap, bool) {
+	if len(p) == 0 {
+		return news, false
+	}
+
+	var replacement *PropertyValue
+	if old, has := p.Get(olds); has {
+		replacement = &old
+	}
+
+	result, ok := resetPropertyValue(NewObjectProperty(news), p, replacement)
+	if !ok {
+		return news, false
+	}
+	return result.ObjectValue(), true
+}
+
+// resetPropertyValue returns a copy of v in which the value at the given path elements has been replaced with the
+// given replacement, or removed if the replacement is nil.
+func resetPropertyValue(v PropertyValue, elems []string, replacement *PropertyValue) (PropertyValue, bool) {
+	switch {
+	case v.IsObject():
+		k := PropertyKey(elems[0])
+		obj := v.ObjectValue().Copy()
+		if len(elems) == 1 {
+			if replacement == nil {
+				delete(obj, k)
+			} else {
+				obj[k] = *replacement
+			}
+			return NewObjectProperty(obj), true
+		}
+
+		child, has := obj[k]
+		if !has {
+			return v, false
+		}
+		newChild, ok := resetPropertyValue(child, elems[1:], replacement)
+		if !ok {
+			return v, false
+		}
+		obj[k] = newChild
+		return NewObjectProperty(obj), true
+	case v.IsArray():
+		i, err := strconv.Atoi(elems[0])
+		if err != nil || i < 0 || i >= len(v.ArrayValue()) {
+			return v, false
+		}
+		arr := make([]PropertyValue, len(v.ArrayValue()))
+		copy(arr, v.ArrayValue())
+		if len(elems) == 1 {
+			// Array elements can't be removed without shifting their siblings, so only replacement is supported.
+			if replacement == nil {
+				return v, false
+			}
+			arr[i] = *replacement
+			return NewArrayProperty(arr), true
+		}
+
+		newElem, ok := resetPropertyValue(arr[i], elems[1:], replacement)
+		if !ok {
+			return v, false
+		}
+		arr[i] = newElem
+		return NewArrayProperty(arr), true
+	default:
+		return v, false
+	}
+}
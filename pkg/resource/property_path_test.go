@@ -0,0 +1,103 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPropertyPathMatches(t *testing.T) {
+	t.Parallel()
+
+	p := PropertyPath(nil).Key("metadata").Key("annotations").Key("foo")
+	assert.Equal(t, "metadata.annotations.foo", p.String())
+	assert.True(t, p.Matches("metadata.annotations.foo"))
+	assert.True(t, p.Matches("metadata.annotations.*"))
+	assert.True(t, p.Matches("metadata.**"))
+	assert.True(t, p.Matches("**.foo"))
+	assert.True(t, p.Matches("**"))
+	assert.False(t, p.Matches("metadata.*"))
+	assert.False(t, p.Matches("metadata.labels.*"))
+
+	arr := PropertyPath(nil).Key("containers").Index(0).Key("image")
+	assert.Equal(t, "containers.0.image", arr.String())
+	assert.True(t, arr.Matches("containers.*.image"))
+	assert.False(t, arr.Matches("containers.1.image"))
+	assert.True(t, arr.MatchesAny([]string{"tags.*", "containers.**"}))
+}
+
+func TestPropertyPathMayMatchDescendants(t *testing.T) {
+	t.Parallel()
+
+	tags := PropertyPath(nil).Key("tags")
+	assert.True(t, tags.MayMatchDescendants("tags.*"))
+	assert.True(t, tags.MayMatchDescendants("**.name"))
+	assert.False(t, tags.MayMatchDescendants("metadata.*"))
+
+	name := tags.Key("Name")
+	assert.True(t, name.MayMatchDescendants("tags.*"))
+	assert.False(t, name.MayMatchDescendants("tags"))
+}
+
+func TestPropertyPathKeyDoesNotAlias(t *testing.T) {
+	t.Parallel()
+
+	parent := make(PropertyPath, 0, 4).Key("a")
+	b := parent.Key("b")
+	c := parent.Key("c")
+	assert.Equal(t, "a.b", b.String())
+	assert.Equal(t, "a.c", c.String())
+}
+
+func TestPropertyPathReset(t *testing.T) {
+	t.Parallel()
+
+	olds := NewPropertyMapFromMap(map[string]interface{}{
+		"tags": map[string]interface{}{"owner": "alice", "env": "dev"},
+		"list": []interface{}{"a", "b"},
+	})
+	news := NewPropertyMapFromMap(map[string]interface{}{
+		"tags": map[string]interface{}{"owner": "bob", "env": "prod", "extra": "x"},
+		"list": []interface{}{"a", "c"},
+	})
+
+	// Resetting a nested key takes the old value, without modifying either input.
+	reset, ok := ParsePropertyPath("tags.owner").Reset(olds, news)
+	assert.True(t, ok)
+	assert.Equal(t, "alice", reset["tags"].ObjectValue()["owner"].StringValue())
+	assert.Equal(t, "prod", reset["tags"].ObjectValue()["env"].StringValue())
+	assert.Equal(t, "bob", news["tags"].ObjectValue()["owner"].StringValue())
+
+	// Resetting a key that didn't exist before removes it.
+	reset, ok = ParsePropertyPath("tags.extra").Reset(olds, news)
+	assert.True(t, ok)
+	_, has := reset["tags"].ObjectValue()["extra"]
+	assert.False(t, has)
+
+	// Array elements can be reset by index.
+	reset, ok = ParsePropertyPath("list.1").Reset(olds, news)
+	assert.True(t, ok)
+	assert.Equal(t, "b", reset["list"].ArrayValue()[1].StringValue())
+
+	// Paths whose parents don't exist are left alone.
+	_, ok = ParsePropertyPath("missing.key").Reset(olds, news)
+	assert.False(t, ok)
+
+	v, has := ParsePropertyPath("list.0").Get(news)
+	assert.True(t, has)
+	assert.Equal(t, "a", v.StringValue())
+}
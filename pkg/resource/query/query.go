@@ -0,0 +1,56 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package query evaluates JMESPath (http://jmespath.org) queries over property values.  The values are converted to
+// plain Go values, using their Mappable form, before being queried, and the results are converted back.
+package query
+
+import (
+	"github.com/jmespath/go-jmespath"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+)
+
+// Query is a parsed query expression.
+type Query struct {
+	expr string
+	jp   *jmespath.JMESPath
+}
+
+// Parse parses a query expression.
+func Parse(expr string) (*Query, error) {
+	jp, err := jmespath.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &Query{expr: expr, jp: jp}, nil
+}
+
+// String returns the text of the query expression.
+func (q *Query) String() string {
+	return q.expr
+}
+
+// Eval evaluates the query against the given value.
+func (q *Query) Eval(v resource.PropertyValue) (resource.PropertyValue, error) {
+	var data interface{}
+	if !v.IsNull() {
+		data = v.Mappable()
+	}
+	result, err := q.jp.Search(data)
+	if err != nil {
+		return resource.PropertyValue{}, err
+	}
+	return resource.NewPropertyValue(result), nil
+}
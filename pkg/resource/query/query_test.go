@@ -0,0 +1,133 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+)
+
+func testResources() resource.PropertyValue {
+	return resource.NewPropertyValue([]interface{}{
+		map[string]interface{}{
+			"type": "aws:ec2/instance:Instance",
+			"name": "web",
+			"outputs": map[string]interface{}{
+				"publicIp": "10.0.0.1",
+				"tags":     map[string]interface{}{"env": "prod", "team": "web"},
+				"ports":    []interface{}{80.0, 443.0},
+			},
+		},
+		map[string]interface{}{
+			"type": "aws:ec2/instance:Instance",
+			"name": "worker",
+			"outputs": map[string]interface{}{
+				"tags":  map[string]interface{}{"env": "dev"},
+				"ports": []interface{}{8080.0},
+			},
+		},
+		map[string]interface{}{
+			"type": "aws:s3/bucket:Bucket",
+			"name": "assets",
+			"outputs": map[string]interface{}{
+				"tags": map[string]interface{}{"env": "prod"},
+			},
+		},
+	})
+}
+
+func evalQuery(t *testing.T, expr string) interface{} {
+	q, err := Parse(expr)
+	if !assert.NoError(t, err, expr) {
+		return nil
+	}
+	v, err := q.Eval(testResources())
+	assert.NoError(t, err, expr)
+	return v.Mappable()
+}
+
+func TestQueryPaths(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "web", evalQuery(t, "[0].name"))
+	assert.Equal(t, "assets", evalQuery(t, "[-1].name"))
+	assert.Equal(t, nil, evalQuery(t, "[5].name"))
+	assert.Equal(t, "10.0.0.1", evalQuery(t, `[0].outputs."publicIp"`))
+	assert.Equal(t, []interface{}{"web", "worker", "assets"}, evalQuery(t, "[*].name"))
+	assert.Equal(t, []interface{}{"10.0.0.1"}, evalQuery(t, "[*].outputs.publicIp"))
+	assert.Equal(t, []interface{}{80.0, 443.0, 8080.0}, evalQuery(t, "[*].outputs.ports[]"))
+	assert.Equal(t, []interface{}{"prod", "web"}, evalQuery(t, "sort([0].outputs.tags.*)"))
+	assert.Equal(t, 3.0, evalQuery(t, "length(@)"))
+	assert.Equal(t, "web", evalQuery(t, "[*].name | [0]"))
+}
+
+func TestQueryFilters(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []interface{}{"web", "assets"}, evalQuery(t, "[?outputs.tags.env == 'prod'].name"))
+	assert.Equal(t, []interface{}{"worker"}, evalQuery(t, "[?outputs.tags.env != `\"prod\"`].name"))
+	assert.Equal(t, []interface{}{"web"},
+		evalQuery(t, "[?type == 'aws:ec2/instance:Instance' && outputs.publicIp].name"))
+	assert.Equal(t, []interface{}{"worker", "assets"}, evalQuery(t, "[?!(outputs.publicIp)].name"))
+	assert.Equal(t, []interface{}{"web"}, evalQuery(t, "[?length(outputs.ports || `[]`) > `1`].name"))
+	assert.Equal(t, []interface{}{"worker"}, evalQuery(t, "[?contains(outputs.ports || `[]`, `8080`)].name"))
+	assert.Equal(t, []interface{}{"web", "worker"}, evalQuery(t, "[?starts_with(type, 'aws:ec2/')].name"))
+}
+
+func TestQuerySelections(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "web", "env": "prod"},
+		map[string]interface{}{"name": "worker", "env": "dev"},
+		map[string]interface{}{"name": "assets", "env": "prod"},
+	}, evalQuery(t, "[*].{name: name, env: outputs.tags.env}"))
+	assert.Equal(t, []interface{}{"web", "prod"}, evalQuery(t, "[0].[name, outputs.tags.env]"))
+	assert.Equal(t, "env,team", evalQuery(t, "join(',', sort(keys([0].outputs.tags)))"))
+}
+
+func TestQueryErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, expr := range []string{
+		"",
+		"foo.",
+		"foo[",
+		"[?foo == ]",
+		"{foo}",
+		"'unterminated",
+		"foo bar",
+		"#",
+	} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+
+	// Functions are checked when the query is evaluated.
+	for _, expr := range []string{
+		"length(@)",
+		"length(@, @)",
+		"nosuchfunction(@)",
+	} {
+		q, err := Parse(expr)
+		if assert.NoError(t, err, expr) {
+			_, err = q.Eval(resource.NewBoolProperty(true))
+			assert.Error(t, err, expr)
+		}
+	}
+}
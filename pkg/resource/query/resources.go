@@ -0,0 +1,70 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"github.com/pulumi/pulumi/pkg/resource"
+)
+
+// ResourcesValue returns an array holding the value of each of the given resources, as returned by ResourceValue, so
+// that queries can be evaluated over a stack's resources.
+func ResourcesValue(resources []*resource.State) resource.PropertyValue {
+	values := make([]resource.PropertyValue, len(resources))
+	for i, res := range resources {
+		values[i] = ResourceValue(res)
+	}
+	return resource.NewArrayProperty(values)
+}
+
+// ResourceValue returns an object describing a resource's state, with the following properties:
+//
+//	urn, type, name, id, parent    strings; id and parent are null if the resource has none
+//	custom, protect, delete        booleans
+//	dependencies                   an array of the URNs of the resource's dependencies
+//	inputs, outputs                objects holding the resource's input and output properties
+func ResourceValue(res *resource.State) resource.PropertyValue {
+	optional := func(s string) resource.PropertyValue {
+		if s == "" {
+			return resource.NewNullProperty()
+		}
+		return resource.NewStringProperty(s)
+	}
+
+	dependencies := make([]resource.PropertyValue, len(res.Dependencies))
+	for i, dep := range res.Dependencies {
+		dependencies[i] = resource.NewStringProperty(string(dep))
+	}
+	inputs, outputs := res.Inputs, res.Outputs
+	if inputs == nil {
+		inputs = resource.PropertyMap{}
+	}
+	if outputs == nil {
+		outputs = resource.PropertyMap{}
+	}
+
+	return resource.NewObjectProperty(resource.PropertyMap{
+		"urn":          resource.NewStringProperty(string(res.URN)),
+		"type":         resource.NewStringProperty(string(res.Type)),
+		"name":         resource.NewStringProperty(string(res.URN.Name())),
+		"id":           optional(string(res.ID)),
+		"parent":       optional(string(res.Parent)),
+		"custom":       resource.NewBoolProperty(res.Custom),
+		"protect":      resource.NewBoolProperty(res.Protect),
+		"delete":       resource.NewBoolProperty(res.Delete),
+		"dependencies": resource.NewArrayProperty(dependencies),
+		"inputs":       resource.NewObjectProperty(inputs),
+		"outputs":      resource.NewObjectProperty(outputs),
+	})
+}
@@ -15,31 +15,61 @@
 package resource
 
 import (
+	"time"
+
 	"github.com/pulumi/pulumi/pkg/tokens"
 )
 
 // Goal is a desired state for a resource object.  Normally it represents a subset of the resource's state expressed by
 // a program, however if Output is true, it represents a more complete, post-deployment view of the state.
 type Goal struct {
-	Type         tokens.Type  // the type of resource.
-	Name         tokens.QName // the name for the resource's URN.
-	Custom       bool         // true if this resource is custom, managed by a plugin.
-	Properties   PropertyMap  // the resource's property state.
-	Parent       URN          // an optional parent URN for this resource.
-	Protect      bool         // true to protect this resource from deletion.
-	Dependencies []URN        // dependencies of this resource object.
+	Type                tokens.Type    // the type of resource.
+	Name                tokens.QName   // the name for the resource's URN.
+	Custom              bool           // true if this resource is custom, managed by a plugin.
+	Properties          PropertyMap    // the resource's property state.
+	Parent              URN            // an optional parent URN for this resource.
+	Protect             bool           // true to protect this resource from deletion.
+	Dependencies        []URN          // dependencies of this resource object.
+	IgnoreChanges       []string       // property paths whose changes should be ignored when diffing.
+	DeleteBeforeReplace bool           // true if this resource must be deleted before its replacement is created.
+	CustomTimeouts      CustomTimeouts // optional limits on how long the resource's operations may take.
+	ReplaceOnChanges    []string       // property paths whose changes should force the resource to be replaced.
+
+	// the resources whose outputs each property's value was computed from, if the program reported them.
+	PropertyDependencies map[PropertyKey][]URN
 }
 
 // NewGoal allocates a new resource goal state.
 func NewGoal(t tokens.Type, name tokens.QName, custom bool, props PropertyMap,
-	parent URN, protect bool, dependencies []URN) *Goal {
+	parent URN, protect bool, dependencies []URN, ignoreChanges []string, deleteBeforeReplace bool,
+	customTimeouts CustomTimeouts, replaceOnChanges []string, propertyDependencies map[PropertyKey][]URN) *Goal {
 	return &Goal{
-		Type:         t,
-		Name:         name,
-		Custom:       custom,
-		Properties:   props,
-		Parent:       parent,
-		Protect:      protect,
-		Dependencies: dependencies,
+		Type:                t,
+		Name:                name,
+		Custom:              custom,
+		Properties:          props,
+		Parent:              parent,
+		Protect:             protect,
+		Dependencies:        dependencies,
+		IgnoreChanges:       ignoreChanges,
+		DeleteBeforeReplace: deleteBeforeReplace,
+		CustomTimeouts:      customTimeouts,
+		ReplaceOnChanges:    replaceOnChanges,
+
+		PropertyDependencies: propertyDependencies,
 	}
 }
+
+// CustomTimeouts limits how long each of the operations on a resource may take before it is abandoned.  A zero
+// duration means that the operation may take as long as it needs.  An operation that times out is canceled, leaving its
+// resource in an unknown state, and fails the update, which stops there unless it is to be rolled back on failure.
+type CustomTimeouts struct {
+	Create time.Duration // the maximum time to wait for the resource to be created.
+	Update time.Duration // the maximum time to wait for the resource to be updated.
+	Delete time.Duration // the maximum time to wait for the resource to be deleted.
+}
+
+// IsZero returns true if none of the operations have a timeout.
+func (t CustomTimeouts) IsZero() bool {
+	return t.Create == 0 && t.Update == 0 && t.Delete == 0
+}
@@ -33,6 +33,16 @@ type State struct {
 	Parent       URN         // an optional parent URN that this resource belongs to.
 	Protect      bool        // true to "protect" this resource (protected resources cannot be deleted).
 	Dependencies []URN       // the resource's dependencies
+
+	CustomTimeouts CustomTimeouts // optional limits on how long the resource's operations may take.
+	Taint          string         // if non-empty, the reason this resource must be replaced by the next update.
+	External       bool           // true if this resource is read, but never created, updated, or deleted, by the engine.
+	Aliases        []URN          // the URNs by which this resource was previously known, such as before a rename.
+	SecretOutputs  []PropertyKey  // the properties whose values hold secrets, which are encrypted when persisted.
+
+	// the resources whose outputs each property's value was computed from, as reported by the program that registered
+	// the resource during the current plan.  These are not recorded in checkpoints.
+	PropertyDependencies map[PropertyKey][]URN
 }
 
 // NewState creates a new resource value from existing resource state information.
@@ -17,7 +17,10 @@
 package stack
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/json"
+	"fmt"
 
 	"github.com/blang/semver"
 	"github.com/pkg/errors"
@@ -32,10 +35,28 @@ import (
 )
 
 func UnmarshalVersionedCheckpointToLatestCheckpoint(bytes []byte) (*apitype.CheckpointV1, error) {
+	bytes, err := DecompressCheckpoint(bytes)
+	if err != nil {
+		return nil, err
+	}
+
 	var versionedCheckpoint apitype.VersionedCheckpoint
-	if err := json.Unmarshal(bytes, &versionedCheckpoint); err != nil {
+	if err = json.Unmarshal(bytes, &versionedCheckpoint); err != nil {
+		if syntaxErr, ok := err.(*json.SyntaxError); ok && syntaxErr.Offset >= int64(len(bytes)) {
+			return nil, errors.New("checkpoint is truncated")
+		}
 		return nil, err
 	}
+	if versionedCheckpoint.Checksum != "" {
+		var checksum string
+		if checksum, err = checkpointChecksum(versionedCheckpoint.Checkpoint); err != nil {
+			return nil, err
+		}
+		if checksum != versionedCheckpoint.Checksum {
+			return nil, errors.New("checkpoint checksum mismatch; it has been corrupted or modified since it was " +
+				"written (if it was edited deliberately, remove its `checksum` property)")
+		}
+	}
 
 	switch versionedCheckpoint.Version {
 	case 0:
@@ -66,7 +87,13 @@ func SerializeCheckpoint(stack tokens.QName, config config.Map, snap *deploy.Sna
 	if snap != nil {
 		latest = SerializeDeployment(snap)
 	}
+	return MakeCheckpoint(stack, config, latest)
+}
 
+// MakeCheckpoint wraps a serialized deployment in a versioned checkpoint, checksumming the checkpoint as it will be
+// written.  Any changes to the deployment, such as the encryption of its resources' secret outputs, must be made
+// first, or the checksum won't match.
+func MakeCheckpoint(stack tokens.QName, config config.Map, latest *apitype.Deployment) *apitype.VersionedCheckpoint {
 	b, err := json.Marshal(apitype.CheckpointV1{
 		Stack:  stack,
 		Config: config,
@@ -74,12 +101,26 @@ func SerializeCheckpoint(stack tokens.QName, config config.Map, snap *deploy.Sna
 	})
 	contract.AssertNoError(err)
 
+	checksum, err := checkpointChecksum(b)
+	contract.AssertNoError(err)
+
 	return &apitype.VersionedCheckpoint{
 		Version:    apitype.DeploymentSchemaVersionCurrent,
 		Checkpoint: json.RawMessage(b),
+		Checksum:   checksum,
 	}
 }
 
+// checkpointChecksum returns the checksum of a checkpoint's JSON.  The JSON is compacted first, so that the checksum
+// doesn't depend on how the checkpoint was indented when it was written.
+func checkpointChecksum(checkpoint json.RawMessage) (string, error) {
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, checkpoint); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(compacted.Bytes())), nil
+}
+
 // DeserializeCheckpoint takes a serialized deployment record and returns its associated snapshot.
 func DeserializeCheckpoint(chkpoint *apitype.CheckpointV1) (*deploy.Snapshot, error) {
 	contract.Require(chkpoint != nil, "chkpoint")
@@ -119,18 +160,37 @@ func DeserializeCheckpoint(chkpoint *apitype.CheckpointV1) (*deploy.Snapshot, er
 		}
 
 		snap = deploy.NewSnapshot(manifest, resources)
+
+		// Also recover any operations that an interrupted update left pending.
+		for _, op := range latest.PendingOperations {
+			desres, err := DeserializeResource(op.Resource)
+			if err != nil {
+				return nil, err
+			}
+			snap.PendingOperations = append(snap.PendingOperations, deploy.Operation{
+				Resource: desres,
+				Type:     deploy.OperationType(op.Type),
+			})
+		}
 	}
 
 	return snap, nil
 }
 
 // GetRootStackResource returns the root stack resource from a given snapshot, or nil if not found.  If the stack
-// exists, its output properties, if any, are also returned in the resulting map.
+// exists, its output properties, if any, are also returned in the resulting map, with any secrets replaced by
+// RedactedSecret.
 func GetRootStackResource(snap *deploy.Snapshot) (*resource.State, map[string]interface{}) {
 	if snap != nil {
 		for _, res := range snap.Resources {
 			if res.Type == resource.RootStackType {
-				return res, SerializeResource(res).Outputs
+				outputs := SerializeResource(res).Outputs
+				for _, k := range res.SecretOutputs {
+					if _, has := outputs[string(k)]; has {
+						outputs[string(k)] = RedactedSecret
+					}
+				}
+				return res, outputs
 			}
 		}
 	}
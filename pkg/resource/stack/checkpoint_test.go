@@ -15,10 +15,14 @@
 package stack
 
 import (
+	"encoding/json"
 	"io/ioutil"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/resource/config"
 )
 
 func TestLoadV0Checkpoint(t *testing.T) {
@@ -40,3 +44,88 @@ func TestLoadV1Checkpoint(t *testing.T) {
 	assert.NotNil(t, chk.Latest)
 	assert.Len(t, chk.Latest.Resources, 30)
 }
+
+func TestLoadCompressedCheckpoint(t *testing.T) {
+	bytes, err := ioutil.ReadFile("testdata/checkpoint-v1.json")
+	assert.NoError(t, err)
+
+	compressed, err := CompressCheckpoint(bytes)
+	assert.NoError(t, err)
+	assert.True(t, len(compressed) < len(bytes))
+
+	chk, err := UnmarshalVersionedCheckpointToLatestCheckpoint(compressed)
+	assert.NoError(t, err)
+	assert.NotNil(t, chk.Latest)
+	assert.Len(t, chk.Latest.Resources, 30)
+}
+
+func TestLoadNewerCheckpointFormat(t *testing.T) {
+	bytes, err := ioutil.ReadFile("testdata/checkpoint-v1.json")
+	assert.NoError(t, err)
+
+	compressed, err := CompressCheckpoint(bytes)
+	assert.NoError(t, err)
+	compressed[len(checkpointMagic)] = CheckpointFormatCurrent + 1
+
+	_, err = UnmarshalVersionedCheckpointToLatestCheckpoint(compressed)
+	assert.Error(t, err)
+}
+
+func TestLoadEncryptedCheckpoint(t *testing.T) {
+	bytes, err := ioutil.ReadFile("testdata/checkpoint-v1.json")
+	assert.NoError(t, err)
+
+	compressed, err := CompressCheckpoint(bytes)
+	assert.NoError(t, err)
+
+	key := make([]byte, config.SymmetricCrypterKeyBytes)
+	for _, data := range [][]byte{bytes, compressed} {
+		var encrypted, decrypted []byte
+		encrypted, err = EncryptCheckpoint(data, config.NewSymmetricCrypter(key))
+		assert.NoError(t, err)
+		assert.True(t, IsEncryptedCheckpoint(encrypted))
+		assert.False(t, strings.Contains(string(encrypted), "urn:pulumi"))
+
+		// An encrypted checkpoint can't be read until it is decrypted, and only with the right key.
+		_, err = UnmarshalVersionedCheckpointToLatestCheckpoint(encrypted)
+		assert.Equal(t, ErrCheckpointEncrypted, err)
+
+		wrongKey := make([]byte, config.SymmetricCrypterKeyBytes)
+		wrongKey[0] = 1
+		_, err = DecryptCheckpoint(encrypted, config.NewSymmetricCrypter(wrongKey))
+		assert.Error(t, err)
+
+		decrypted, err = DecryptCheckpoint(encrypted, config.NewSymmetricCrypter(key))
+		assert.NoError(t, err)
+		assert.Equal(t, IsCompressedCheckpoint(data), IsCompressedCheckpoint(decrypted))
+
+		chk, unmarshalErr := UnmarshalVersionedCheckpointToLatestCheckpoint(decrypted)
+		assert.NoError(t, unmarshalErr)
+		assert.NotNil(t, chk.Latest)
+		assert.Len(t, chk.Latest.Resources, 30)
+	}
+}
+
+func TestCheckpointChecksum(t *testing.T) {
+	bytes, err := json.MarshalIndent(SerializeCheckpoint("stack", nil, nil), "", "    ")
+	assert.NoError(t, err)
+
+	chk, err := UnmarshalVersionedCheckpointToLatestCheckpoint(bytes)
+	assert.NoError(t, err)
+	assert.Equal(t, "stack", string(chk.Stack))
+
+	tampered := strings.Replace(string(bytes), `"stack": "stack"`, `"stack": "other"`, 1)
+	assert.NotEqual(t, string(bytes), tampered)
+	_, err = UnmarshalVersionedCheckpointToLatestCheckpoint([]byte(tampered))
+	assert.Error(t, err)
+}
+
+func TestLoadTruncatedCheckpoint(t *testing.T) {
+	bytes, err := ioutil.ReadFile("testdata/checkpoint-v1.json")
+	assert.NoError(t, err)
+
+	_, err = UnmarshalVersionedCheckpointToLatestCheckpoint(bytes[:len(bytes)/2])
+	if assert.Error(t, err) {
+		assert.Equal(t, "checkpoint is truncated", err.Error())
+	}
+}
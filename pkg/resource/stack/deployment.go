@@ -18,10 +18,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/pulumi/pulumi/pkg/apitype"
 	"github.com/pulumi/pulumi/pkg/resource"
 	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/tokens"
 	"github.com/pulumi/pulumi/pkg/util/contract"
 )
 
@@ -51,12 +53,34 @@ func SerializeDeployment(snap *deploy.Snapshot) *apitype.Deployment {
 	contract.Require(snap != nil, "snap")
 
 	// Capture the version information into a manifest.
+	manifest := SerializeManifest(snap.Manifest)
+
+	// Serialize all vertices and only include a vertex section if non-empty.
+	var resources []apitype.Resource
+	for _, res := range snap.Resources {
+		resources = append(resources, SerializeResource(res))
+	}
+
+	var operations []apitype.Operation
+	for _, op := range snap.PendingOperations {
+		operations = append(operations, SerializeOperation(op))
+	}
+
+	return &apitype.Deployment{
+		Manifest:          manifest,
+		Resources:         resources,
+		PendingOperations: operations,
+	}
+}
+
+// SerializeManifest serializes a snapshot's manifest.
+func SerializeManifest(m deploy.Manifest) apitype.Manifest {
 	manifest := apitype.Manifest{
-		Time:    snap.Manifest.Time,
-		Magic:   snap.Manifest.Magic,
-		Version: snap.Manifest.Version,
+		Time:    m.Time,
+		Magic:   m.Magic,
+		Version: m.Version,
 	}
-	for _, plug := range snap.Manifest.Plugins {
+	for _, plug := range m.Plugins {
 		var version string
 		if plug.Version != nil {
 			version = plug.Version.String()
@@ -68,16 +92,14 @@ func SerializeDeployment(snap *deploy.Snapshot) *apitype.Deployment {
 			Version: version,
 		})
 	}
+	return manifest
+}
 
-	// Serialize all vertices and only include a vertex section if non-empty.
-	var resources []apitype.Resource
-	for _, res := range snap.Resources {
-		resources = append(resources, SerializeResource(res))
-	}
-
-	return &apitype.Deployment{
-		Manifest:  manifest,
-		Resources: resources,
+// SerializeOperation serializes an operation that is pending on a resource.
+func SerializeOperation(op deploy.Operation) apitype.Operation {
+	return apitype.Operation{
+		Resource: SerializeResource(op.Resource),
+		Type:     apitype.OperationType(op.Type),
 	}
 }
 
@@ -101,6 +123,57 @@ func DeserializeDeployment(deployment *apitype.UntypedDeployment) (*deploy.Snaps
 	return DeserializeCheckpoint(checkpoint)
 }
 
+// UnmarshalUntypedDeployment deserializes the deployment held by an untyped deployment, if its version is one that
+// this version of Pulumi supports.
+func UnmarshalUntypedDeployment(deployment *apitype.UntypedDeployment) (*apitype.Deployment, error) {
+	contract.Require(deployment != nil, "deployment")
+	switch {
+	case deployment.Version > apitype.DeploymentSchemaVersionCurrent:
+		return nil, ErrDeploymentSchemaVersionTooNew
+	case deployment.Version < DeploymentSchemaVersionOldestSupported:
+		return nil, ErrDeploymentSchemaVersionTooOld
+	}
+
+	var latest apitype.Deployment
+	if err := json.Unmarshal([]byte(deployment.Deployment), &latest); err != nil {
+		return nil, err
+	}
+	return &latest, nil
+}
+
+// MarshalUntypedDeployment serializes a deployment into an untyped deployment of the current version.
+func MarshalUntypedDeployment(deployment *apitype.Deployment) (*apitype.UntypedDeployment, error) {
+	contract.Require(deployment != nil, "deployment")
+
+	data, err := json.Marshal(deployment)
+	if err != nil {
+		return nil, err
+	}
+	return &apitype.UntypedDeployment{
+		Version:    apitype.DeploymentSchemaVersionCurrent,
+		Deployment: json.RawMessage(data),
+	}, nil
+}
+
+// RenameDeployment rewrites a deployment's resources as if they had been created by a stack with the given name, and
+// project if newProject is non-empty, as described by deploy.RenameStack.
+func RenameDeployment(deployment *apitype.UntypedDeployment, newStack tokens.QName,
+	newProject tokens.PackageName) (*apitype.UntypedDeployment, error) {
+
+	snap, err := DeserializeDeployment(deployment)
+	if err != nil {
+		return nil, err
+	}
+	if snap == nil {
+		return deployment, nil
+	}
+	if err = deploy.RenameStack(snap, newStack, newProject); err != nil {
+		return nil, err
+	}
+
+	return MarshalUntypedDeployment(SerializeDeployment(snap))
+}
+
 // SerializeResource turns a resource into a structure suitable for serialization.
 func SerializeResource(res *resource.State) apitype.Resource {
 	contract.Assert(res != nil)
@@ -116,17 +189,31 @@ func SerializeResource(res *resource.State) apitype.Resource {
 		outputs = SerializeProperties(outp)
 	}
 
+	var timeouts *apitype.CustomTimeoutsV1
+	if t := res.CustomTimeouts; !t.IsZero() {
+		timeouts = &apitype.CustomTimeoutsV1{
+			Create: t.Create.Seconds(),
+			Update: t.Update.Seconds(),
+			Delete: t.Delete.Seconds(),
+		}
+	}
+
 	return apitype.Resource{
-		URN:          res.URN,
-		Custom:       res.Custom,
-		Delete:       res.Delete,
-		ID:           res.ID,
-		Type:         res.Type,
-		Parent:       res.Parent,
-		Inputs:       inputs,
-		Outputs:      outputs,
-		Protect:      res.Protect,
-		Dependencies: res.Dependencies,
+		URN:            res.URN,
+		Custom:         res.Custom,
+		Delete:         res.Delete,
+		ID:             res.ID,
+		Type:           res.Type,
+		Parent:         res.Parent,
+		Inputs:         inputs,
+		Outputs:        outputs,
+		Protect:        res.Protect,
+		Dependencies:   res.Dependencies,
+		CustomTimeouts: timeouts,
+		Taint:          res.Taint,
+		External:       res.External,
+		Aliases:        res.Aliases,
+		SecretOutputs:  res.SecretOutputs,
 	}
 }
 
@@ -198,8 +285,25 @@ func DeserializeResource(res apitype.Resource) (*resource.State, error) {
 		inputs = defaults.Merge(inputs)
 	}
 
-	return resource.NewState(
-		res.Type, res.URN, res.Custom, res.Delete, res.ID, inputs, outputs, res.Parent, res.Protect, res.Dependencies), nil
+	state := resource.NewState(
+		res.Type, res.URN, res.Custom, res.Delete, res.ID, inputs, outputs, res.Parent, res.Protect, res.Dependencies)
+	if t := res.CustomTimeouts; t != nil {
+		state.CustomTimeouts = resource.CustomTimeouts{
+			Create: secondsToDuration(t.Create),
+			Update: secondsToDuration(t.Update),
+			Delete: secondsToDuration(t.Delete),
+		}
+	}
+	state.Taint = res.Taint
+	state.External = res.External
+	state.Aliases = res.Aliases
+	state.SecretOutputs = res.SecretOutputs
+	return state, nil
+}
+
+// secondsToDuration converts a number of seconds, as recorded in a checkpoint, to a duration.
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
 }
 
 // DeserializeProperties deserializes an entire map of deploy properties into a resource property map.
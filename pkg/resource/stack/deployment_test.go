@@ -165,3 +165,20 @@ func TestLoadTooOldDeployment(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, ErrDeploymentSchemaVersionTooOld, err)
 }
+
+func TestUntypedDeploymentRoundTrip(t *testing.T) {
+	a := newVerifyResource("a", "")
+	deployment := newVerifyDeployment(a)
+
+	untyped, err := MarshalUntypedDeployment(deployment)
+	assert.NoError(t, err)
+	assert.Equal(t, apitype.DeploymentSchemaVersionCurrent, untyped.Version)
+
+	typed, err := UnmarshalUntypedDeployment(untyped)
+	assert.NoError(t, err)
+	assert.Equal(t, deployment.Resources[0].URN, typed.Resources[0].URN)
+
+	untyped.Version = apitype.DeploymentSchemaVersionCurrent + 1
+	_, err = UnmarshalUntypedDeployment(untyped)
+	assert.Equal(t, ErrDeploymentSchemaVersionTooNew, err)
+}
@@ -0,0 +1,220 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/tokens"
+	"github.com/pulumi/pulumi/pkg/util/contract"
+)
+
+// DeploymentFilter selects some of the resources of a deployment.  A resource is selected if its type is one of
+// Types and its URN matches one of URNs, where an empty list selects every resource.
+type DeploymentFilter struct {
+	// Types are the types of the resources to select.
+	Types []tokens.Type
+	// URNs are patterns of the URNs of the resources to select, in which `*` matches any run of characters and `?`
+	// matches any one character.
+	URNs []string
+}
+
+// IsEmpty returns true if the filter selects every resource.
+func (f DeploymentFilter) IsEmpty() bool {
+	return len(f.Types) == 0 && len(f.URNs) == 0
+}
+
+// Matches returns true if the filter selects the given resource.
+func (f DeploymentFilter) Matches(res apitype.Resource) bool {
+	if len(f.Types) > 0 {
+		var matched bool
+		for _, t := range f.Types {
+			matched = matched || res.Type == t
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(f.URNs) > 0 {
+		var matched bool
+		for _, pattern := range f.URNs {
+			matched = matched || matchGlob(pattern, string(res.URN))
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// matchGlob returns true if a string matches a pattern in which `*` matches any run of characters, including none,
+// and `?` matches any one character.  Unlike path.Match, `*` also matches `/`, which types and URNs contain.
+func matchGlob(pattern, s string) bool {
+	p, str := []rune(pattern), []rune(s)
+
+	// When a `*` has been seen, star is its position in the pattern and mark the position in the string that it
+	// matches up to so far.  On a mismatch, the `*` is made to match one more character and matching resumes after it.
+	pi, si, star, mark := 0, 0, -1, 0
+	for si < len(str) {
+		switch {
+		case pi < len(p) && p[pi] == '*':
+			star, mark = pi, si
+			pi++
+		case pi < len(p) && (p[pi] == '?' || p[pi] == str[si]):
+			pi++
+			si++
+		case star >= 0:
+			mark++
+			pi, si = star+1, mark
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
+
+// FilterDeployment returns a copy of a deployment holding only the resources that the filter selects, along with
+// the operations pending on them.  The resources may still refer to resources that weren't selected; FixupDeployment
+// removes those references.
+func FilterDeployment(deployment *apitype.Deployment, filter DeploymentFilter) *apitype.Deployment {
+	contract.Require(deployment != nil, "deployment")
+
+	filtered := &apitype.Deployment{Manifest: deployment.Manifest}
+	for _, res := range deployment.Resources {
+		if filter.Matches(res) {
+			filtered.Resources = append(filtered.Resources, res)
+		}
+	}
+	for _, op := range deployment.PendingOperations {
+		if filter.Matches(op.Resource) {
+			filtered.PendingOperations = append(filtered.PendingOperations, op)
+		}
+	}
+	return filtered
+}
+
+// FixupDeployment removes the references that the resources of a deployment, and those of its pending operations,
+// make to resources that aren't in it, as when only some of a stack's resources were exported: a missing parent is
+// cleared, leaving the resource without one, and a missing dependency is dropped.  It returns a description of each
+// reference that it removed.
+func FixupDeployment(deployment *apitype.Deployment) []IntegrityProblem {
+	contract.Require(deployment != nil, "deployment")
+
+	present := make(map[resource.URN]bool)
+	for _, res := range deployment.Resources {
+		present[res.URN] = true
+	}
+	for _, op := range deployment.PendingOperations {
+		present[op.Resource.URN] = true
+	}
+
+	var fixes []IntegrityProblem
+	fixup := func(res *apitype.Resource) {
+		if res.Parent != "" && !present[res.Parent] {
+			fixes = append(fixes, IntegrityProblem{URN: res.URN,
+				Message: fmt.Sprintf("removed missing parent '%s'", res.Parent)})
+			res.Parent = ""
+		}
+
+		var deps []resource.URN
+		for _, dep := range res.Dependencies {
+			if present[dep] {
+				deps = append(deps, dep)
+			} else {
+				fixes = append(fixes, IntegrityProblem{URN: res.URN,
+					Message: fmt.Sprintf("removed missing dependency '%s'", dep)})
+			}
+		}
+		if len(deps) != len(res.Dependencies) {
+			res.Dependencies = deps
+		}
+	}
+	for i := range deployment.Resources {
+		fixup(&deployment.Resources[i])
+	}
+	for i := range deployment.PendingOperations {
+		fixup(&deployment.PendingOperations[i].Resource)
+	}
+	return fixes
+}
+
+// RedactedSecret is what RedactSecrets replaces secrets with.
+const RedactedSecret = "[secret]"
+
+// RedactSecrets replaces each occurrence of the given secrets, such as the values of a stack's secret configuration,
+// in the properties of a deployment's resources with RedactedSecret.  The deployment is changed in place.  It returns
+// the number of property values that held secrets.
+func RedactSecrets(deployment *apitype.Deployment, secrets []string) int {
+	contract.Require(deployment != nil, "deployment")
+
+	// Redact the longest secrets first, so that a secret that contains another is redacted whole.
+	var sorted []string
+	for _, secret := range secrets {
+		if secret != "" {
+			sorted = append(sorted, secret)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	var redacted int
+	var redact func(v interface{}) interface{}
+	redact = func(v interface{}) interface{} {
+		switch v := v.(type) {
+		case string:
+			s := v
+			for _, secret := range sorted {
+				s = strings.Replace(s, secret, RedactedSecret, -1)
+			}
+			if s != v {
+				redacted++
+			}
+			return s
+		case []interface{}:
+			for i, e := range v {
+				v[i] = redact(e)
+			}
+			return v
+		case map[string]interface{}:
+			for k, e := range v {
+				v[k] = redact(e)
+			}
+			return v
+		default:
+			return v
+		}
+	}
+	redactResource := func(res *apitype.Resource) {
+		for _, props := range []map[string]interface{}{res.Inputs, res.Defaults, res.Outputs} {
+			redact(props)
+		}
+	}
+	for i := range deployment.Resources {
+		redactResource(&deployment.Resources[i])
+	}
+	for i := range deployment.PendingOperations {
+		redactResource(&deployment.PendingOperations[i].Resource)
+	}
+	return redacted
+}
@@ -0,0 +1,104 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/tokens"
+)
+
+func TestMatchGlob(t *testing.T) {
+	urn := "urn:pulumi:stack::proj::aws:s3/bucket:Bucket::logs"
+	assert.True(t, matchGlob(urn, urn))
+	assert.True(t, matchGlob("*", urn))
+	assert.True(t, matchGlob("*::aws:s3/*::*", urn))
+	assert.True(t, matchGlob("*::log?", urn))
+	assert.True(t, matchGlob("urn:*:stack::*", urn))
+	assert.False(t, matchGlob("*::log", urn))
+	assert.False(t, matchGlob("*::aws:ec2/*", urn))
+	assert.False(t, matchGlob("", urn))
+	assert.True(t, matchGlob("", ""))
+	assert.True(t, matchGlob("**", ""))
+}
+
+func TestFilterDeployment(t *testing.T) {
+	a := newVerifyResource("a", "")
+	b := newVerifyResource("b", a.URN, a.URN)
+	c := newVerifyResource("c", "", b.URN)
+	c.Type = "test:index:Other"
+	c.URN = resource.NewURN("stack", "proj", "", c.Type, "c")
+	deployment := newVerifyDeployment(a, b, c)
+	deployment.PendingOperations = []apitype.Operation{{Resource: b, Type: apitype.OperationTypeUpdating}}
+
+	// Without any filters, every resource is selected.
+	filtered := FilterDeployment(deployment, DeploymentFilter{})
+	assert.Len(t, filtered.Resources, 3)
+	assert.Len(t, filtered.PendingOperations, 1)
+
+	filtered = FilterDeployment(deployment, DeploymentFilter{Types: []tokens.Type{"test:index:Res"}})
+	assert.Equal(t, []apitype.Resource{a, b}, filtered.Resources)
+
+	filtered = FilterDeployment(deployment, DeploymentFilter{
+		Types: []tokens.Type{"test:index:Res", "test:index:Other"},
+		URNs:  []string{"*::b", "*::c"},
+	})
+	assert.Equal(t, []apitype.Resource{b, c}, filtered.Resources)
+	assert.Len(t, filtered.PendingOperations, 1)
+
+	// b's parent and dependency, a, wasn't selected, so fixing up the deployment removes them from b and from the
+	// pending operation on it.
+	fixes := FixupDeployment(filtered)
+	assert.Len(t, fixes, 4)
+	assert.Equal(t, resource.URN(""), filtered.Resources[0].Parent)
+	assert.Len(t, filtered.Resources[0].Dependencies, 0)
+	assert.Equal(t, []resource.URN{b.URN}, filtered.Resources[1].Dependencies)
+	assert.Equal(t, resource.URN(""), filtered.PendingOperations[0].Resource.Parent)
+	assert.Len(t, VerifyDeployment(filtered), 0)
+
+	// The original deployment is left as it was.
+	assert.Equal(t, a.URN, deployment.Resources[1].Parent)
+	assert.Len(t, FixupDeployment(deployment), 0)
+}
+
+func TestRedactSecrets(t *testing.T) {
+	a := newVerifyResource("a", "")
+	a.Inputs = map[string]interface{}{
+		"password": "hunter2",
+		"connection": map[string]interface{}{
+			"url":   "postgres://admin:hunter2@db",
+			"hosts": []interface{}{"db", "hunter2hunter2"},
+		},
+		"port": float64(5432),
+	}
+	a.Outputs = map[string]interface{}{"token": "s3cr3t-and-more"}
+	deployment := newVerifyDeployment(a)
+
+	assert.Equal(t, 0, RedactSecrets(deployment, nil))
+	assert.Equal(t, 4, RedactSecrets(deployment, []string{"hunter2", "", "s3cr3t", "s3cr3t-and-more"}))
+	assert.Equal(t, map[string]interface{}{
+		"password": RedactedSecret,
+		"connection": map[string]interface{}{
+			"url":   "postgres://admin:" + RedactedSecret + "@db",
+			"hosts": []interface{}{"db", RedactedSecret + RedactedSecret},
+		},
+		"port": float64(5432),
+	}, deployment.Resources[0].Inputs)
+	assert.Equal(t, map[string]interface{}{"token": RedactedSecret}, deployment.Resources[0].Outputs)
+}
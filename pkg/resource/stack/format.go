@@ -0,0 +1,252 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/resource/config"
+	"github.com/pulumi/pulumi/pkg/util/contract"
+)
+
+const (
+	// CheckpointFormatV1 is the original format of checkpoint files: the JSON of an apitype.VersionedCheckpoint.
+	CheckpointFormatV1 = 1
+	// CheckpointFormatV2 prefixes the checkpoint with a header that records the format version and the compression
+	// of what follows.
+	CheckpointFormatV2 = 2
+	// CheckpointFormatV3 adds a byte to the header that records the encryption of what follows.
+	CheckpointFormatV3 = 3
+	// CheckpointFormatCurrent is the newest checkpoint file format that this version of Pulumi can read.  Files in a
+	// newer format are rejected.
+	CheckpointFormatCurrent = CheckpointFormatV3
+)
+
+// CheckpointCompression is how the checkpoint following a format v2 header is compressed.
+type CheckpointCompression byte
+
+const (
+	// CheckpointCompressionNone means that the checkpoint isn't compressed.
+	CheckpointCompressionNone CheckpointCompression = 0
+	// CheckpointCompressionGzip means that the checkpoint is compressed with gzip.
+	CheckpointCompressionGzip CheckpointCompression = 1
+)
+
+// CheckpointEncryption is how the checkpoint following a format v3 header is encrypted.
+type CheckpointEncryption byte
+
+const (
+	// CheckpointEncryptionNone means that the checkpoint isn't encrypted.
+	CheckpointEncryptionNone CheckpointEncryption = 0
+	// CheckpointEncryptionSecretsProvider means that the checkpoint is encrypted by the secrets provider of its stack,
+	// as a single secret value.
+	CheckpointEncryptionSecretsProvider CheckpointEncryption = 1
+)
+
+// ErrCheckpointEncrypted is returned when reading a checkpoint that must first be decrypted with DecryptCheckpoint.
+var ErrCheckpointEncrypted = errors.New(
+	"checkpoint is encrypted; it can only be read with its stack's secrets provider")
+
+// checkpointMagic begins every checkpoint file in format v2 or later.  Its first byte can't begin a JSON document,
+// which is how files in format v1 are told apart.  The magic is followed by a byte each for the format version and
+// the compression, then, from format v3, by a byte for the encryption, and then by the checkpoint.  An encrypted
+// checkpoint is compressed before it is encrypted.
+var checkpointMagic = []byte("\x89PLMCKPT")
+
+// checkpointHeader is the header of a checkpoint file in format v2 or later.
+type checkpointHeader struct {
+	format      byte
+	compression CheckpointCompression
+	encryption  CheckpointEncryption
+}
+
+// parseCheckpointHeader returns the header of a checkpoint file in format v2 or later and the checkpoint following
+// it.
+func parseCheckpointHeader(data []byte) (checkpointHeader, []byte, error) {
+	contract.Require(bytes.HasPrefix(data, checkpointMagic), "data")
+
+	n := len(checkpointMagic)
+	if len(data) < n+2 {
+		return checkpointHeader{}, nil, errors.New("checkpoint file header is truncated")
+	}
+	header := checkpointHeader{format: data[n], compression: CheckpointCompression(data[n+1])}
+	if header.format > CheckpointFormatCurrent {
+		return checkpointHeader{}, nil, errors.Errorf("checkpoint file format %d is newer than the newest this "+
+			"version of Pulumi supports, %d; please upgrade the Pulumi CLI", header.format, CheckpointFormatCurrent)
+	}
+	n += 2
+
+	if header.format >= CheckpointFormatV3 {
+		if len(data) < n+1 {
+			return checkpointHeader{}, nil, errors.New("checkpoint file header is truncated")
+		}
+		header.encryption = CheckpointEncryption(data[n])
+		n++
+	}
+	return header, data[n:], nil
+}
+
+// gzipMagic begins every gzip stream.  A format v1 file that was compressed by hand begins with it.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// CompressCheckpoint wraps a marshaled checkpoint in a format v2 header and compresses it with gzip.
+func CompressCheckpoint(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write(checkpointMagic)
+	buf.WriteByte(CheckpointFormatV2)
+	buf.WriteByte(byte(CheckpointCompressionGzip))
+
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// IsCompressedCheckpoint returns true if the contents of a checkpoint file are compressed.
+func IsCompressedCheckpoint(data []byte) bool {
+	if bytes.HasPrefix(data, gzipMagic) {
+		return true
+	}
+	if !bytes.HasPrefix(data, checkpointMagic) {
+		return false
+	}
+	header, _, err := parseCheckpointHeader(data)
+	return err == nil && header.compression != CheckpointCompressionNone
+}
+
+// IsEncryptedCheckpoint returns true if the contents of a checkpoint file are encrypted.
+func IsEncryptedCheckpoint(data []byte) bool {
+	if !bytes.HasPrefix(data, checkpointMagic) {
+		return false
+	}
+	header, _, err := parseCheckpointHeader(data)
+	return err == nil && header.encryption != CheckpointEncryptionNone
+}
+
+// EncryptCheckpoint encrypts the contents of a checkpoint file of any format, compressed or not, with the given
+// encrypter, and wraps the result in a format v3 header.
+func EncryptCheckpoint(data []byte, enc config.Encrypter) ([]byte, error) {
+	compression, body := CheckpointCompressionNone, data
+	if bytes.HasPrefix(data, gzipMagic) {
+		compression = CheckpointCompressionGzip
+	} else if bytes.HasPrefix(data, checkpointMagic) {
+		header, rest, err := parseCheckpointHeader(data)
+		if err != nil {
+			return nil, err
+		}
+		if header.encryption != CheckpointEncryptionNone {
+			return nil, errors.New("checkpoint is already encrypted")
+		}
+		compression, body = header.compression, rest
+	}
+
+	ciphertext, err := enc.EncryptValue(string(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "encrypting checkpoint")
+	}
+
+	var buf bytes.Buffer
+	buf.Write(checkpointMagic)
+	buf.WriteByte(CheckpointFormatV3)
+	buf.WriteByte(byte(compression))
+	buf.WriteByte(byte(CheckpointEncryptionSecretsProvider))
+	buf.WriteString(ciphertext)
+	return buf.Bytes(), nil
+}
+
+// DecryptCheckpoint returns the contents of a checkpoint file with any encryption removed, using the given decrypter.
+// What it returns may still be compressed.  Files that aren't encrypted are returned as they are.
+func DecryptCheckpoint(data []byte, dec config.Decrypter) ([]byte, error) {
+	if !bytes.HasPrefix(data, checkpointMagic) {
+		return data, nil
+	}
+	header, body, err := parseCheckpointHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch header.encryption {
+	case CheckpointEncryptionNone:
+		return data, nil
+	case CheckpointEncryptionSecretsProvider:
+		plaintext, decryptErr := dec.DecryptValue(string(body))
+		if decryptErr != nil {
+			return nil, errors.Wrap(decryptErr, "decrypting checkpoint")
+		}
+
+		var buf bytes.Buffer
+		buf.Write(checkpointMagic)
+		buf.WriteByte(CheckpointFormatV2)
+		buf.WriteByte(byte(header.compression))
+		buf.WriteString(plaintext)
+		return buf.Bytes(), nil
+	default:
+		return nil, errors.Errorf("unsupported checkpoint encryption %d", header.encryption)
+	}
+}
+
+// DecompressCheckpoint returns the marshaled checkpoint in the contents of a checkpoint file of any format.  Files in
+// format v1 are returned as they are, unless they are compressed with gzip.
+func DecompressCheckpoint(data []byte) ([]byte, error) {
+	if bytes.HasPrefix(data, gzipMagic) {
+		return gunzip(data)
+	}
+	if !bytes.HasPrefix(data, checkpointMagic) {
+		return data, nil
+	}
+
+	header, body, err := parseCheckpointHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if header.encryption != CheckpointEncryptionNone {
+		return nil, ErrCheckpointEncrypted
+	}
+
+	switch header.compression {
+	case CheckpointCompressionNone:
+		return body, nil
+	case CheckpointCompressionGzip:
+		return gunzip(body)
+	default:
+		return nil, errors.Errorf("unsupported checkpoint compression %d", header.compression)
+	}
+}
+
+// gunzip decompresses data compressed with gzip.
+func gunzip(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "decompressing checkpoint")
+	}
+	defer contract.IgnoreClose(r)
+
+	result, err := ioutil.ReadAll(r)
+	if err == io.ErrUnexpectedEOF {
+		return nil, errors.New("checkpoint is truncated")
+	} else if err != nil {
+		return nil, errors.Wrap(err, "decompressing checkpoint")
+	}
+	return result, nil
+}
@@ -0,0 +1,191 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/config"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/util/contract"
+)
+
+const (
+	// SecretSig is the signature of a serialized property value that holds the ciphertext of a secret, in place of its
+	// value.
+	SecretSig = "1b47061264138c4ac30d75fd1eb44270"
+	// secretCiphertextKey is the key of the ciphertext in a serialized secret.
+	secretCiphertextKey = "ciphertext"
+)
+
+// EncryptSecretOutputs encrypts the inputs and outputs of the secret properties of serialized resources, replacing each
+// value with its ciphertext.  Values that are already encrypted are left as they are.  The encrypter is only fetched
+// if there are any secrets, so that stacks without them never need one.
+func EncryptSecretOutputs(resources []*apitype.Resource, getEncrypter func() (config.Encrypter, error)) error {
+	var enc config.Encrypter
+	for _, res := range resources {
+		if len(res.SecretOutputs) == 0 {
+			continue
+		}
+		if enc == nil {
+			var err error
+			if enc, err = getEncrypter(); err != nil {
+				return err
+			}
+		}
+		if err := encryptResourceSecretOutputs(res, enc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeploymentResources returns every resource in a deployment, including those with pending operations, so that they
+// can be changed in place.
+func DeploymentResources(deployment *apitype.Deployment) []*apitype.Resource {
+	contract.Require(deployment != nil, "deployment")
+
+	resources := make([]*apitype.Resource, 0, len(deployment.Resources)+len(deployment.PendingOperations))
+	for i := range deployment.Resources {
+		resources = append(resources, &deployment.Resources[i])
+	}
+	for i := range deployment.PendingOperations {
+		resources = append(resources, &deployment.PendingOperations[i].Resource)
+	}
+	return resources
+}
+
+func encryptResourceSecretOutputs(res *apitype.Resource, enc config.Encrypter) error {
+	for _, k := range res.SecretOutputs {
+		for _, props := range []map[string]interface{}{res.Inputs, res.Outputs} {
+			v, has := props[string(k)]
+			if !has || isEncryptedSecret(v) {
+				continue
+			}
+			plaintext, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			ciphertext, err := enc.EncryptValue(string(plaintext))
+			if err != nil {
+				return errors.Wrapf(err, "encrypting the secret '%s' of %s", k, res.URN)
+			}
+			props[string(k)] = encryptedSecret(ciphertext)
+		}
+	}
+	return nil
+}
+
+// ReencryptSecretOutputs decrypts the secret properties of serialized resources with one crypter and encrypts them
+// again with another, returning the number of values re-encrypted.
+func ReencryptSecretOutputs(resources []*apitype.Resource, dec config.Decrypter, enc config.Encrypter) (int, error) {
+	reencrypted := 0
+	for _, res := range resources {
+		for _, k := range res.SecretOutputs {
+			for _, props := range []map[string]interface{}{res.Inputs, res.Outputs} {
+				v, has := props[string(k)]
+				if !has || !isEncryptedSecret(v) {
+					continue
+				}
+				ciphertext, ok := v.(map[string]interface{})[secretCiphertextKey].(string)
+				if !ok {
+					return 0, errors.Errorf("the secret '%s' of %s has no ciphertext", k, res.URN)
+				}
+				plaintext, err := dec.DecryptValue(ciphertext)
+				if err != nil {
+					return 0, errors.Wrapf(err, "decrypting the secret '%s' of %s", k, res.URN)
+				}
+				if ciphertext, err = enc.EncryptValue(plaintext); err != nil {
+					return 0, errors.Wrapf(err, "encrypting the secret '%s' of %s", k, res.URN)
+				}
+				props[string(k)] = encryptedSecret(ciphertext)
+				reencrypted++
+			}
+		}
+	}
+	return reencrypted, nil
+}
+
+// DecryptSecretOutputs decrypts the secret properties of a snapshot's resources, which are deserialized still
+// encrypted, in place.  The decrypter is only fetched if there are any.
+func DecryptSecretOutputs(snap *deploy.Snapshot, getDecrypter func() (config.Decrypter, error)) error {
+	if snap == nil {
+		return nil
+	}
+
+	resources := append([]*resource.State(nil), snap.Resources...)
+	for _, op := range snap.PendingOperations {
+		resources = append(resources, op.Resource)
+	}
+
+	var dec config.Decrypter
+	for _, res := range resources {
+		for _, k := range res.SecretOutputs {
+			for _, props := range []resource.PropertyMap{res.Inputs, res.Outputs} {
+				v, has := props[k]
+				if !has || !v.IsObject() || !resource.HasSig(v.ObjectValue(), SecretSig) {
+					continue
+				}
+				if dec == nil {
+					var err error
+					if dec, err = getDecrypter(); err != nil {
+						return err
+					}
+				}
+				plain, err := decryptSecret(v.ObjectValue(), dec)
+				if err != nil {
+					return errors.Wrapf(err, "decrypting the secret '%s' of %s", k, res.URN)
+				}
+				props[k] = plain
+			}
+		}
+	}
+	return nil
+}
+
+// decryptSecret decrypts a secret deserialized as an object that holds its ciphertext.
+func decryptSecret(obj resource.PropertyMap, dec config.Decrypter) (resource.PropertyValue, error) {
+	ciphertext, has := obj[secretCiphertextKey]
+	if !has || !ciphertext.IsString() {
+		return resource.PropertyValue{}, errors.New("the secret has no ciphertext")
+	}
+	plaintext, err := dec.DecryptValue(ciphertext.StringValue())
+	if err != nil {
+		return resource.PropertyValue{}, err
+	}
+	var v interface{}
+	if err = json.Unmarshal([]byte(plaintext), &v); err != nil {
+		return resource.PropertyValue{}, errors.Wrap(err, "the secret is not a serialized property value")
+	}
+	return DeserializePropertyValue(v)
+}
+
+// encryptedSecret returns the serialized form of a secret, given its ciphertext.
+func encryptedSecret(ciphertext string) map[string]interface{} {
+	return map[string]interface{}{
+		string(resource.SigKey): SecretSig,
+		secretCiphertextKey:     ciphertext,
+	}
+}
+
+// isEncryptedSecret returns true if a serialized property value holds the ciphertext of a secret.
+func isEncryptedSecret(v interface{}) bool {
+	obj, ok := v.(map[string]interface{})
+	return ok && obj[string(resource.SigKey)] == SecretSig
+}
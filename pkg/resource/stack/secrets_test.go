@@ -0,0 +1,88 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/config"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+)
+
+// TestSecretOutputsRoundTrip encrypts the secret outputs of a snapshot as it's serialized, and decrypts them again.
+func TestSecretOutputsRoundTrip(t *testing.T) {
+	res := &resource.State{
+		Type: "test:index:Database",
+		URN:  "urn:pulumi:test::test::test:index:Database::db",
+		Inputs: resource.NewPropertyMapFromMap(map[string]interface{}{
+			"name":     "app",
+			"password": "hunter2",
+		}),
+		Outputs: resource.NewPropertyMapFromMap(map[string]interface{}{
+			"name":     "app",
+			"password": "hunter2",
+			"endpoint": map[string]interface{}{"url": "db://admin:hunter2@db", "port": float64(5432)},
+		}),
+		SecretOutputs: []resource.PropertyKey{"endpoint", "password"},
+	}
+	deployment := SerializeDeployment(deploy.NewSnapshot(deploy.Manifest{}, []*resource.State{res}))
+	resources := DeploymentResources(deployment)
+	assert.Len(t, resources, 1)
+
+	// Stacks without secrets never need an encrypter.
+	noSecrets := *resources[0]
+	noSecrets.SecretOutputs = nil
+	err := EncryptSecretOutputs([]*apitype.Resource{&noSecrets}, func() (config.Encrypter, error) {
+		assert.Fail(t, "the encrypter should not be fetched")
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	crypter := config.NewSymmetricCrypter(make([]byte, config.SymmetricCrypterKeyBytes))
+	getEncrypter := func() (config.Encrypter, error) { return crypter, nil }
+	assert.NoError(t, EncryptSecretOutputs(resources, getEncrypter))
+	encrypted := resources[0]
+	assert.Equal(t, "app", encrypted.Outputs["name"])
+	assert.True(t, isEncryptedSecret(encrypted.Inputs["password"]))
+	assert.True(t, isEncryptedSecret(encrypted.Outputs["password"]))
+	assert.True(t, isEncryptedSecret(encrypted.Outputs["endpoint"]))
+
+	// Encrypting again leaves the secrets as they are.
+	ciphertext := encrypted.Outputs["password"].(map[string]interface{})[secretCiphertextKey].(string)
+	assert.False(t, strings.Contains(ciphertext, "hunter2"))
+	assert.NoError(t, EncryptSecretOutputs(resources, getEncrypter))
+	assert.Equal(t, ciphertext, encrypted.Outputs["password"].(map[string]interface{})[secretCiphertextKey])
+
+	// Secrets can be re-encrypted with another crypter.
+	other := config.NewSymmetricCrypter([]byte(strings.Repeat("k", config.SymmetricCrypterKeyBytes)))
+	reencrypted, err := ReencryptSecretOutputs(resources, crypter, other)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, reencrypted)
+
+	// Secrets are deserialized still encrypted, and then decrypted in place.
+	untyped, err := MarshalUntypedDeployment(deployment)
+	assert.NoError(t, err)
+	snap, err := DeserializeDeployment(untyped)
+	assert.NoError(t, err)
+	assert.True(t, resource.HasSig(snap.Resources[0].Outputs["password"].ObjectValue(), SecretSig))
+	assert.NoError(t, DecryptSecretOutputs(snap, func() (config.Decrypter, error) { return other, nil }))
+	assert.Equal(t, res.Inputs, snap.Resources[0].Inputs)
+	assert.Equal(t, res.Outputs, snap.Resources[0].Outputs)
+}
@@ -0,0 +1,122 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"fmt"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/util/contract"
+)
+
+// IntegrityProblem is a problem found in a deployment by VerifyDeployment.
+type IntegrityProblem struct {
+	// URN is the resource with the problem, if the problem is with a resource.
+	URN resource.URN `json:"urn,omitempty"`
+	// Message describes the problem.
+	Message string `json:"message"`
+}
+
+func (p IntegrityProblem) String() string {
+	if p.URN == "" {
+		return p.Message
+	}
+	return fmt.Sprintf("%s: %s", p.URN, p.Message)
+}
+
+// VerifyUntypedDeployment is VerifyDeployment for a deployment that hasn't been deserialized.  It returns the
+// deserialized deployment along with its problems, or an error if the deployment can't be read at all.
+func VerifyUntypedDeployment(deployment *apitype.UntypedDeployment) (*apitype.Deployment, []IntegrityProblem, error) {
+	latest, err := UnmarshalUntypedDeployment(deployment)
+	if err != nil {
+		return nil, nil, err
+	}
+	return latest, VerifyDeployment(latest), nil
+}
+
+// VerifyDeployment checks a deployment for every problem that would keep it from being used: the problems that
+// deploy.Snapshot's VerifyIntegrity finds, as well as malformed resources.  Unlike VerifyIntegrity, which stops at the
+// first problem, it reports all of them, along with the resource that each one is with.
+func VerifyDeployment(deployment *apitype.Deployment) []IntegrityProblem {
+	contract.Require(deployment != nil, "deployment")
+
+	var problems []IntegrityProblem
+	report := func(urn resource.URN, format string, args ...interface{}) {
+		problems = append(problems, IntegrityProblem{URN: urn, Message: fmt.Sprintf(format, args...)})
+	}
+
+	manifest := deploy.Manifest{Version: deployment.Manifest.Version}
+	if deployment.Manifest.Magic != manifest.NewMagic() {
+		report("", "magic cookie mismatch; possible tampering/corruption detected")
+	}
+
+	// Resources must come after their parents and dependencies, so index every resource's position to tell a missing
+	// resource apart from one that comes too late.
+	positions := make(map[resource.URN]int)
+	for i, res := range deployment.Resources {
+		if _, has := positions[res.URN]; !has {
+			positions[res.URN] = i
+		}
+	}
+	refersTo := func(i int, what string, res apitype.Resource, other resource.URN) {
+		switch pos, has := positions[other]; {
+		case !has:
+			report(res.URN, "%s %s is missing", what, other)
+		case pos >= i:
+			report(res.URN, "%s %s comes after it", what, other)
+		}
+	}
+
+	live := make(map[resource.URN]bool)
+	for i, res := range deployment.Resources {
+		if !res.URN.IsValid() {
+			report(res.URN, "malformed URN")
+		} else if res.Type != res.URN.Type() {
+			report(res.URN, "type %s doesn't match the URN's type %s", res.Type, res.URN.Type())
+		}
+		if res.Custom && res.ID == "" {
+			report(res.URN, "custom resource has no ID")
+		}
+
+		if res.Parent != "" {
+			refersTo(i, "parent", res, res.Parent)
+		}
+		for _, dep := range res.Dependencies {
+			refersTo(i, "dependency", res, dep)
+		}
+
+		// The only time we should have duplicate URNs is when all but one of them are marked for deletion.
+		if !res.Delete {
+			if live[res.URN] {
+				report(res.URN, "duplicate resource (not marked for deletion)")
+			}
+			live[res.URN] = true
+		}
+
+		if _, err := DeserializeResource(res); err != nil {
+			report(res.URN, "malformed state: %v", err)
+		}
+	}
+
+	for _, op := range deployment.PendingOperations {
+		if !op.Resource.URN.IsValid() {
+			report(op.Resource.URN, "pending %s operation has a malformed URN", op.Type)
+		}
+	}
+
+	return problems
+}
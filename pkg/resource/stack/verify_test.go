@@ -0,0 +1,76 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/apitype"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/tokens"
+)
+
+func newVerifyResource(name string, parent resource.URN, deps ...resource.URN) apitype.Resource {
+	return apitype.Resource{
+		URN:          resource.NewURN("stack", "proj", "", "test:index:Res", tokens.QName(name)),
+		Type:         "test:index:Res",
+		Parent:       parent,
+		Dependencies: deps,
+	}
+}
+
+func newVerifyDeployment(resources ...apitype.Resource) *apitype.Deployment {
+	manifest := deploy.Manifest{Version: "1.0.0"}
+	return &apitype.Deployment{
+		Manifest:  apitype.Manifest{Version: manifest.Version, Magic: manifest.NewMagic()},
+		Resources: resources,
+	}
+}
+
+func TestVerifyIntactDeployment(t *testing.T) {
+	a := newVerifyResource("a", "")
+	b := newVerifyResource("b", a.URN, a.URN)
+	assert.Len(t, VerifyDeployment(newVerifyDeployment(a, b)), 0)
+}
+
+func TestVerifyBrokenDeployment(t *testing.T) {
+	a := newVerifyResource("a", "")
+	b := newVerifyResource("b", "", a.URN)
+	c := newVerifyResource("c", a.URN)
+	missing := newVerifyResource("missing", "")
+	d := newVerifyResource("d", missing.URN)
+	malformed := apitype.Resource{URN: "not-a-urn", Type: "test:index:Res"}
+
+	// b depends on a, which comes after it, and a is there twice.
+	problems := VerifyDeployment(newVerifyDeployment(b, a, c, d, a, malformed))
+	assert.Equal(t, []IntegrityProblem{
+		{URN: b.URN, Message: "dependency " + string(a.URN) + " comes after it"},
+		{URN: d.URN, Message: "parent " + string(missing.URN) + " is missing"},
+		{URN: a.URN, Message: "duplicate resource (not marked for deletion)"},
+		{URN: "not-a-urn", Message: "malformed URN"},
+	}, problems)
+}
+
+func TestVerifyTamperedManifest(t *testing.T) {
+	deployment := newVerifyDeployment()
+	deployment.Manifest.Magic = "tampered"
+	problems := VerifyDeployment(deployment)
+	if assert.Len(t, problems, 1) {
+		assert.Equal(t, resource.URN(""), problems[0].URN)
+	}
+}
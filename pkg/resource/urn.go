@@ -68,6 +68,15 @@ func NewURN(stack tokens.QName, proj tokens.PackageName, parentType, baseType to
 	)
 }
 
+// IsValid returns true if the URN is well-formed: it has the standard prefix and all of its elements.
+func (urn URN) IsValid() bool {
+	s := string(urn)
+	if !strings.HasPrefix(s, URNPrefix) {
+		return false
+	}
+	return len(strings.SplitN(s[len(URNPrefix):], URNNameDelimiter, 4)) == 4
+}
+
 // URNName returns the URN name part of a URN (i.e., strips off the prefix).
 func (urn URN) URNName() string {
 	s := string(urn)
@@ -0,0 +1,115 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/pkg/errors"
+)
+
+// AWSKMSScheme is the scheme of the URLs of keys in AWS KMS.
+const AWSKMSScheme = "awskms"
+
+// awsKMSKeyManager is a key manager that wraps data keys with a key in AWS KMS.
+//
+// KMS records the key, and the version of its key material, with which each data key was wrapped, so data keys go on
+// being unwrapped after a key is rotated, whether automatically or by hand.  A data key is wrapped with the key's
+// current material only when a stack is given a new data key, as by `pulumi stack change-secrets-provider`.
+type awsKMSKeyManager struct {
+	url   string
+	keyID string
+	svc   *kms.KMS
+}
+
+// openAWSKMS returns the key manager for a URL of the form `awskms://<key>`, where the key is a key ID, a key ARN, an
+// alias name such as `alias/pulumi`, or an alias ARN.  The URL's query may give the key's `region`, the `profile` of
+// the credentials with which to use it, and an `endpoint` to use instead of AWS's.  Otherwise, the region and
+// credentials are found as they are by the AWS CLI.
+func openAWSKMS(keyURL string) (KeyManager, error) {
+	// Key ARNs aren't valid URL hosts, so the key is taken from the URL by hand, rather than by parsing it.
+	rest := strings.TrimPrefix(keyURL, AWSKMSScheme+"://")
+	var rawQuery string
+	if i := strings.Index(rest, "?"); i >= 0 {
+		rest, rawQuery = rest[:i], rest[i+1:]
+	}
+	keyID := strings.Trim(rest, "/")
+	if keyID == "" {
+		return nil, errors.Errorf("%s does not name a key; expected %s://<key>", keyURL, AWSKMSScheme)
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", keyURL)
+	}
+
+	sess, err := newAWSSession(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &awsKMSKeyManager{url: keyURL, keyID: keyID, svc: kms.New(sess)}, nil
+}
+
+// newAWSSession returns a session for the `region`, credentials `profile`, and `endpoint` given by the query of a URL,
+// which are otherwise found as they are by the AWS CLI.
+func newAWSSession(query url.Values) (*session.Session, error) {
+	config := aws.NewConfig()
+	if region := query.Get("region"); region != "" {
+		config = config.WithRegion(region)
+	}
+	if endpoint := query.Get("endpoint"); endpoint != "" {
+		config = config.WithEndpoint(endpoint)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *config,
+		Profile:           query.Get("profile"),
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "creating AWS session")
+	}
+	return sess, nil
+}
+
+func (m *awsKMSKeyManager) URL() string {
+	return m.url
+}
+
+func (m *awsKMSKeyManager) WrapKey(plaintext []byte) ([]byte, error) {
+	out, err := m.svc.Encrypt(&kms.EncryptInput{
+		KeyId:     aws.String(m.keyID),
+		Plaintext: plaintext,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.CiphertextBlob, nil
+}
+
+func (m *awsKMSKeyManager) UnwrapKey(ciphertext []byte) ([]byte, error) {
+	// The ciphertext names the key that encrypted it, so the key needn't be given; it may even be another key than
+	// this one, if the stack's data key was wrapped before the URL was changed to that of an alias of a new key.
+	out, err := m.svc.Decrypt(&kms.DecryptInput{
+		CiphertextBlob: ciphertext,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Plaintext, nil
+}
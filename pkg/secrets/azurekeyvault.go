@@ -0,0 +1,284 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/util/contract"
+)
+
+// AzureKeyVaultScheme is the scheme of the URLs of keys in Azure Key Vault.
+const AzureKeyVaultScheme = "azurekeyvault"
+
+const (
+	// azureTenantIDEnvVar, azureClientIDEnvVar and azureClientSecretEnvVar are the environment variables holding the
+	// tenant, client ID and secret of a service principal with which to use Key Vault.  If the secret is unset, the
+	// client ID, if any, selects the user-assigned managed identity to use.
+	azureTenantIDEnvVar     = "AZURE_TENANT_ID"
+	azureClientIDEnvVar     = "AZURE_CLIENT_ID"
+	azureClientSecretEnvVar = "AZURE_CLIENT_SECRET"
+	// azureKeyVaultAPIVersion is the version of the Key Vault REST API that is used.
+	azureKeyVaultAPIVersion = "7.0"
+	// azureKeyVaultResource is the resource for which access tokens are requested.
+	azureKeyVaultResource = "https://vault.azure.net"
+	// azureIdentityEndpoint is where the Azure Instance Metadata Service issues tokens for managed identities.
+	azureIdentityEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+	// azureLoginEndpoint is where service principals obtain tokens.
+	azureLoginEndpoint = "https://login.microsoftonline.com"
+)
+
+// azureKeyVaultKeyManager is a key manager that wraps data keys with an RSA key in Azure Key Vault, using its REST API.
+//
+// Each data key is wrapped with the key's current version, unless the URL names a version, and the version is
+// recorded along with the wrapped key, so that data keys go on being unwrapped after the key is rotated, for as long
+// as the versions that wrapped them are enabled.
+type azureKeyVaultKeyManager struct {
+	url       string
+	endpoint  string // the URL of the vault.
+	key       string // the key's name.
+	version   string // the key's version, if a particular one is to be used.
+	algorithm string // the algorithm with which keys are wrapped.
+	creds     *azureCredentials
+}
+
+// azureWrappedKey is a data key wrapped by a key in Key Vault, as it is saved.
+type azureWrappedKey struct {
+	KeyID string `json:"kid"`   // the ID of the version of the key that wrapped the data key.
+	Value string `json:"value"` // the wrapped data key, base64url encoded.
+}
+
+// openAzureKeyVault returns the key manager for a URL of the form
+// `azurekeyvault://<vault>.vault.azure.net/keys/<key>[/<version>]`.  The URL's query may give the `algorithm` with
+// which to wrap keys, RSA-OAEP-256 by default, and the `clientId` of the user-assigned managed identity to use.
+//
+// Key Vault is used as a service principal if AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET are set, or
+// else with the managed identity of the machine, selected by AZURE_CLIENT_ID if the machine has several.
+func openAzureKeyVault(keyURL string) (KeyManager, error) {
+	u, err := url.Parse(keyURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", keyURL)
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if u.Host == "" || len(parts) < 2 || len(parts) > 3 || parts[0] != "keys" || parts[1] == "" {
+		return nil, errors.Errorf("%s does not name a key; expected %s://<vault>.vault.azure.net/keys/<key>",
+			keyURL, AzureKeyVaultScheme)
+	}
+	var version string
+	if len(parts) == 3 {
+		version = parts[2]
+	}
+
+	query := u.Query()
+	algorithm := query.Get("algorithm")
+	if algorithm == "" {
+		algorithm = "RSA-OAEP-256"
+	}
+	clientID := query.Get("clientId")
+	if clientID == "" {
+		clientID = os.Getenv(azureClientIDEnvVar)
+	}
+
+	return &azureKeyVaultKeyManager{
+		url:       keyURL,
+		endpoint:  "https://" + strings.ToLower(u.Host),
+		key:       parts[1],
+		version:   version,
+		algorithm: algorithm,
+		creds:     findAzureCredentials(clientID),
+	}, nil
+}
+
+func (m *azureKeyVaultKeyManager) URL() string {
+	return m.url
+}
+
+func (m *azureKeyVaultKeyManager) WrapKey(plaintext []byte) ([]byte, error) {
+	keyURL := m.endpoint + "/keys/" + url.PathEscape(m.key)
+	if m.version != "" {
+		keyURL += "/" + url.PathEscape(m.version)
+	}
+
+	var wrapped azureWrappedKey
+	if err := m.call(keyURL+"/wrapkey", base64.RawURLEncoding.EncodeToString(plaintext), &wrapped); err != nil {
+		return nil, err
+	}
+	return json.Marshal(wrapped)
+}
+
+func (m *azureKeyVaultKeyManager) UnwrapKey(ciphertext []byte) ([]byte, error) {
+	var wrapped azureWrappedKey
+	if err := json.Unmarshal(ciphertext, &wrapped); err != nil {
+		return nil, errors.Wrap(err, "malformed wrapped key")
+	}
+	// The key ID names the version of the key that wrapped the data key, which is the one that must unwrap it, in the
+	// vault in which it was wrapped.
+	if !strings.HasPrefix(strings.ToLower(wrapped.KeyID), m.endpoint+"/keys/") {
+		return nil, errors.Errorf("the data key was wrapped by %s, which is not in the vault %s", wrapped.KeyID,
+			m.endpoint)
+	}
+
+	var unwrapped azureWrappedKey
+	if err := m.call(wrapped.KeyID+"/unwrapkey", wrapped.Value, &unwrapped); err != nil {
+		return nil, err
+	}
+	return base64.RawURLEncoding.DecodeString(unwrapped.Value)
+}
+
+// call performs an operation on a key, wrapkey or unwrapkey, with the given base64url-encoded value.
+func (m *azureKeyVaultKeyManager) call(operationURL string, value string, response *azureWrappedKey) error {
+	body, err := json.Marshal(map[string]string{"alg": m.algorithm, "value": value})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", operationURL+"?api-version="+azureKeyVaultAPIVersion, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	token, err := m.creds.accessToken()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer contract.IgnoreClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Error.Message != "" {
+			return errors.Errorf("%s: %s", resp.Status, apiErr.Error.Message)
+		}
+		return errors.New(resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(response)
+}
+
+// azureCredentials issues the access tokens with which Key Vault is called, caching each until shortly before it
+// expires.
+type azureCredentials struct {
+	source  string                        // where the tokens come from, for error messages.
+	request func() (*http.Request, error) // makes a request for a new token.
+
+	lock    sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// findAzureCredentials returns the credentials of the service principal given by the environment, if there is one, or
+// else those of the machine's managed identity, which is selected by clientID if it's given.
+func findAzureCredentials(clientID string) *azureCredentials {
+	tenant, secret := os.Getenv(azureTenantIDEnvVar), os.Getenv(azureClientSecretEnvVar)
+	if tenant != "" && secret != "" {
+		endpoint := azureLoginEndpoint + "/" + url.PathEscape(tenant) + "/oauth2/v2.0/token"
+		return &azureCredentials{
+			source: "service principal " + clientID,
+			request: func() (*http.Request, error) {
+				form := url.Values{
+					"grant_type":    {"client_credentials"},
+					"client_id":     {clientID},
+					"client_secret": {secret},
+					"scope":         {azureKeyVaultResource + "/.default"},
+				}
+				req, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+				if err != nil {
+					return nil, err
+				}
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return req, nil
+			},
+		}
+	}
+
+	return &azureCredentials{
+		source: "the managed identity",
+		request: func() (*http.Request, error) {
+			query := url.Values{"api-version": {"2018-02-01"}, "resource": {azureKeyVaultResource}}
+			if clientID != "" {
+				query.Set("client_id", clientID)
+			}
+			req, err := http.NewRequest("GET", azureIdentityEndpoint+"?"+query.Encode(), nil)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Metadata", "true")
+			return req, nil
+		},
+	}
+}
+
+// accessToken returns an access token, requesting a new one if there is none or it's about to expire.
+func (c *azureCredentials) accessToken() (string, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.token != "" && time.Now().Add(time.Minute).Before(c.expires) {
+		return c.token, nil
+	}
+
+	req, err := c.request()
+	if err != nil {
+		return "", err
+	}
+	// The metadata service is only reachable on Azure; elsewhere, give up on it quickly.
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "requesting an Azure access token for %s (set %s, %s and %s to use a service "+
+			"principal)", c.source, azureTenantIDEnvVar, azureClientIDEnvVar, azureClientSecretEnvVar)
+	}
+	defer contract.IgnoreClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("requesting an Azure access token for %s: %s", c.source, resp.Status)
+	}
+
+	// The lifetime of the token is a number of seconds, which the metadata service gives as a string.
+	var token struct {
+		AccessToken string      `json:"access_token"`
+		ExpiresIn   interface{} `json:"expires_in"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", errors.Wrap(err, "reading Azure access token")
+	}
+	var lifetime float64
+	switch expiresIn := token.ExpiresIn.(type) {
+	case float64:
+		lifetime = expiresIn
+	case string:
+		if lifetime, err = strconv.ParseFloat(expiresIn, 64); err != nil {
+			return "", errors.Wrap(err, "reading Azure access token expiry")
+		}
+	}
+	c.token, c.expires = token.AccessToken, time.Now().Add(time.Duration(lifetime)*time.Second)
+	return c.token, nil
+}
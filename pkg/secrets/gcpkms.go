@@ -0,0 +1,379 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"bytes"
+	"crypto"
+	cryptorand "crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/util/contract"
+)
+
+// GCPKMSScheme is the scheme of the URLs of keys in Google Cloud KMS.
+const GCPKMSScheme = "gcpkms"
+
+const (
+	// gcpAccessTokenEnvVar is the environment variable holding an OAuth access token with which to call Cloud KMS, as
+	// printed by `gcloud auth print-access-token`.  It takes precedence over any other credentials.
+	gcpAccessTokenEnvVar = "GOOGLE_OAUTH_ACCESS_TOKEN"
+	// gcpCredentialsEnvVar is the environment variable holding the path of a file of application default credentials.
+	gcpCredentialsEnvVar = "GOOGLE_APPLICATION_CREDENTIALS"
+	// gcpKMSEndpoint is the endpoint of the Cloud KMS REST API.
+	gcpKMSEndpoint = "https://cloudkms.googleapis.com"
+	// gcpTokenEndpoint is where user credentials are exchanged for access tokens.
+	gcpTokenEndpoint = "https://oauth2.googleapis.com/token"
+	// gcpMetadataTokenEndpoint is where the metadata server issues access tokens for the service account of the
+	// instance, or, with GKE Workload Identity, of the pod's Kubernetes service account.
+	gcpMetadataTokenEndpoint = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+	// gcpKMSScope is the OAuth scope requested for access tokens of service account keys.
+	gcpKMSScope = "https://www.googleapis.com/auth/cloudkms"
+)
+
+// gcpKeyNameRegexp matches the resource names of keys in Cloud KMS.
+var gcpKeyNameRegexp = regexp.MustCompile(`^projects/[^/]+/locations/[^/]+/keyRings/[^/]+/cryptoKeys/[^/]+$`)
+
+// gcpKMSKeyManager is a key manager that wraps data keys with a key in Google Cloud KMS, using its REST API.
+//
+// Cloud KMS encrypts with the key's primary version, and records the version in the ciphertext, so data keys go on
+// being unwrapped after the key is rotated, for as long as the versions that wrapped them are enabled.
+type gcpKMSKeyManager struct {
+	url      string
+	name     string // the key's resource name.
+	endpoint string // the endpoint of the Cloud KMS API.
+	creds    *gcpCredentials
+}
+
+// openGCPKMS returns the key manager for a URL of the form
+// `gcpkms://projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key>`, naming a key and the key ring
+// that holds it.  The URL's query may give an `endpoint` to use instead of Google's.
+//
+// Cloud KMS is called with the first credentials found of: an access token in GOOGLE_OAUTH_ACCESS_TOKEN; the
+// application default credentials in the file named by GOOGLE_APPLICATION_CREDENTIALS, or else in the file written
+// by `gcloud auth application-default login`, which may hold a user's credentials or a service account key; and
+// finally the service account of the machine, as given by its metadata server, which on GKE with Workload Identity is
+// the service account bound to the pod's Kubernetes service account.
+func openGCPKMS(keyURL string) (KeyManager, error) {
+	rest := strings.TrimPrefix(keyURL, GCPKMSScheme+"://")
+	var rawQuery string
+	if i := strings.Index(rest, "?"); i >= 0 {
+		rest, rawQuery = rest[:i], rest[i+1:]
+	}
+	name := strings.Trim(rest, "/")
+	if !gcpKeyNameRegexp.MatchString(name) {
+		return nil, errors.Errorf("%s does not name a key; expected "+
+			"%s://projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key>", keyURL, GCPKMSScheme)
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", keyURL)
+	}
+	endpoint := gcpKMSEndpoint
+	if e := query.Get("endpoint"); e != "" {
+		endpoint = strings.TrimSuffix(e, "/")
+	}
+
+	creds, err := findGCPCredentials()
+	if err != nil {
+		return nil, err
+	}
+	return &gcpKMSKeyManager{url: keyURL, name: name, endpoint: endpoint, creds: creds}, nil
+}
+
+func (m *gcpKMSKeyManager) URL() string {
+	return m.url
+}
+
+func (m *gcpKMSKeyManager) WrapKey(plaintext []byte) ([]byte, error) {
+	var resp struct {
+		Ciphertext []byte `json:"ciphertext"`
+	}
+	if err := m.call("encrypt", map[string][]byte{"plaintext": plaintext}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Ciphertext, nil
+}
+
+func (m *gcpKMSKeyManager) UnwrapKey(ciphertext []byte) ([]byte, error) {
+	var resp struct {
+		Plaintext []byte `json:"plaintext"`
+	}
+	if err := m.call("decrypt", map[string][]byte{"ciphertext": ciphertext}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Plaintext, nil
+}
+
+// call calls a method of the key, such as encrypt or decrypt, and decodes its response.  Byte slices are encoded in
+// base64 by encoding/json, as the API expects.
+func (m *gcpKMSKeyManager) call(method string, request interface{}, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", m.endpoint+"/v1/"+m.name+":"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	token, err := m.creds.accessToken()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer contract.IgnoreClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Error.Message != "" {
+			return errors.Errorf("%s: %s", resp.Status, apiErr.Error.Message)
+		}
+		return errors.New(resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(response)
+}
+
+// gcpCredentials issues the access tokens with which Google Cloud APIs are called, caching each until shortly before
+// it expires.
+type gcpCredentials struct {
+	source  string                        // where the tokens come from, for error messages.
+	request func() (*http.Request, error) // makes a request for a new token, or is nil if the token never changes.
+
+	lock    sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// findGCPCredentials returns the first credentials found, as described by openGCPKMS.
+func findGCPCredentials() (*gcpCredentials, error) {
+	if token := os.Getenv(gcpAccessTokenEnvVar); token != "" {
+		return &gcpCredentials{source: gcpAccessTokenEnvVar, token: token}, nil
+	}
+
+	file := os.Getenv(gcpCredentialsEnvVar)
+	if file == "" {
+		if wellKnown := gcpWellKnownCredentialsFile(); wellKnown != "" {
+			if _, err := os.Stat(wellKnown); err == nil {
+				file = wellKnown
+			}
+		}
+	}
+	if file != "" {
+		return gcpFileCredentials(file)
+	}
+
+	return &gcpCredentials{
+		source: "the metadata server",
+		request: func() (*http.Request, error) {
+			req, err := http.NewRequest("GET", gcpMetadataTokenEndpoint, nil)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Metadata-Flavor", "Google")
+			return req, nil
+		},
+	}, nil
+}
+
+// gcpWellKnownCredentialsFile returns the path of the file to which `gcloud auth application-default login` writes
+// credentials.
+func gcpWellKnownCredentialsFile() string {
+	if appData := os.Getenv("APPDATA"); appData != "" {
+		return filepath.Join(appData, "gcloud", "application_default_credentials.json")
+	}
+	u, err := user.Current()
+	if err != nil || u == nil {
+		return ""
+	}
+	return filepath.Join(u.HomeDir, ".config", "gcloud", "application_default_credentials.json")
+}
+
+// gcpFileCredentials returns the credentials in a file of application default credentials: either a user's, as
+// written by gcloud, or a service account key.
+func gcpFileCredentials(file string) (*gcpCredentials, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading Google Cloud credentials")
+	}
+	var key struct {
+		Type         string `json:"type"`
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+		RefreshToken string `json:"refresh_token"`
+		ClientEmail  string `json:"client_email"`
+		PrivateKey   string `json:"private_key"`
+		TokenURI     string `json:"token_uri"`
+	}
+	if err = json.Unmarshal(data, &key); err != nil {
+		return nil, errors.Wrapf(err, "reading Google Cloud credentials from %s", file)
+	}
+
+	switch key.Type {
+	case "authorized_user":
+		return &gcpCredentials{
+			source: file,
+			request: func() (*http.Request, error) {
+				return gcpTokenRequest(gcpTokenEndpoint, url.Values{
+					"grant_type":    {"refresh_token"},
+					"client_id":     {key.ClientID},
+					"client_secret": {key.ClientSecret},
+					"refresh_token": {key.RefreshToken},
+				})
+			},
+		}, nil
+	case "service_account":
+		privateKey, err := parseRSAPrivateKey(key.PrivateKey)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading the service account key in %s", file)
+		}
+		tokenURI := key.TokenURI
+		if tokenURI == "" {
+			tokenURI = gcpTokenEndpoint
+		}
+		return &gcpCredentials{
+			source: file,
+			request: func() (*http.Request, error) {
+				assertion, err := signJWT(privateKey, map[string]interface{}{
+					"iss":   key.ClientEmail,
+					"scope": gcpKMSScope,
+					"aud":   tokenURI,
+					"iat":   time.Now().Unix(),
+					"exp":   time.Now().Add(time.Hour).Unix(),
+				})
+				if err != nil {
+					return nil, err
+				}
+				return gcpTokenRequest(tokenURI, url.Values{
+					"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+					"assertion":  {assertion},
+				})
+			},
+		}, nil
+	default:
+		return nil, errors.Errorf("the Google Cloud credentials in %s are of an unsupported type, '%s'; expected "+
+			"authorized_user or service_account", file, key.Type)
+	}
+}
+
+// gcpTokenRequest makes a request for an access token from an OAuth token endpoint.
+func gcpTokenRequest(endpoint string, form url.Values) (*http.Request, error) {
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
+
+// accessToken returns an access token, requesting a new one if there is none or it's about to expire.
+func (c *gcpCredentials) accessToken() (string, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.request == nil || (c.token != "" && time.Now().Add(time.Minute).Before(c.expires)) {
+		return c.token, nil
+	}
+
+	req, err := c.request()
+	if err != nil {
+		return "", err
+	}
+	// The metadata server is only reachable on Google Cloud; elsewhere, give up on it quickly.
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "requesting a Google Cloud access token from %s (set %s or %s to use other "+
+			"credentials)", c.source, gcpCredentialsEnvVar, gcpAccessTokenEnvVar)
+	}
+	defer contract.IgnoreClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("requesting a Google Cloud access token from %s: %s", c.source, resp.Status)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", errors.Wrap(err, "reading Google Cloud access token")
+	}
+	c.token, c.expires = token.AccessToken, time.Now().Add(time.Duration(token.ExpiresIn)*time.Second)
+	return c.token, nil
+}
+
+// parseRSAPrivateKey parses a PEM-encoded RSA private key, in PKCS #8 or PKCS #1 form.
+func parseRSAPrivateKey(data string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("the private key is not PEM-encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("the private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// signJWT returns a JSON Web Token of the given claims, signed with an RSA key using RS256.
+func signJWT(key *rsa.PrivateKey, claims map[string]interface{}) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(cryptorand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,189 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/resource/config"
+)
+
+const (
+	// SSMScheme is the scheme of references to parameters in AWS Systems Manager Parameter Store.
+	SSMScheme = "ssm"
+	// SecretsManagerScheme is the scheme of references to secrets in AWS Secrets Manager.
+	SecretsManagerScheme = "secretsmanager"
+)
+
+// reference is a reference to a secret in an external store, as given in a stack's configuration.
+type reference struct {
+	name  string     // the name of the secret in its store.
+	field string     // the property of the secret, which holds a JSON object, to use; if empty, all of it is used.
+	query url.Values // the options with which to fetch the secret.
+}
+
+// secretStores maps the scheme of the references to each store to the function that fetches a secret from it.
+var secretStores = map[string]func(ref reference) (string, error){
+	SSMScheme:            fetchSSMParameter,
+	SecretsManagerScheme: fetchSecretsManagerSecret,
+}
+
+// ValidateReference checks that a reference to a secret in an external store is well formed, and names a store that
+// can be read, without fetching the secret.
+func ValidateReference(ref string) error {
+	_, _, err := parseReference(ref)
+	return err
+}
+
+// parseReference parses a reference of the form `<scheme>://<name>[?<query>][#<field>]`, returning the function that
+// fetches the secret it refers to.
+func parseReference(ref string) (func(reference) (string, error), reference, error) {
+	fetch, ok := secretStores[keyURLScheme(ref)]
+	if !ok {
+		return nil, reference{}, errors.Errorf("%s is not a reference to a secret; expected %s://<parameter> or "+
+			"%s://<secret>", ref, SSMScheme, SecretsManagerScheme)
+	}
+
+	// Parameter names start with slashes and secret IDs may be ARNs, neither of which are valid URL hosts, so the
+	// reference is taken apart by hand, rather than by parsing it.
+	rest := ref[len(keyURLScheme(ref))+len("://"):]
+	var result reference
+	if i := strings.Index(rest, "#"); i >= 0 {
+		rest, result.field = rest[:i], rest[i+1:]
+	}
+	var rawQuery string
+	if i := strings.Index(rest, "?"); i >= 0 {
+		rest, rawQuery = rest[:i], rest[i+1:]
+	}
+	if rest == "" {
+		return nil, reference{}, errors.Errorf("%s does not name a secret", ref)
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, reference{}, errors.Wrapf(err, "parsing %s", ref)
+	}
+	result.name, result.query = rest, query
+	return fetch, result, nil
+}
+
+// NewReferenceResolver returns a decrypter that decrypts secrets with the given decrypter, and that resolves references
+// to secrets in external stores by fetching them.  Each secret is fetched only once.
+func NewReferenceResolver(decrypter config.Decrypter) config.Decrypter {
+	return &referenceResolver{Decrypter: decrypter, values: make(map[string]string)}
+}
+
+type referenceResolver struct {
+	config.Decrypter
+
+	lock   sync.Mutex
+	values map[string]string // the secrets fetched so far, by reference.
+}
+
+func (r *referenceResolver) ResolveReference(ref string) (string, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if value, has := r.values[ref]; has {
+		return value, nil
+	}
+
+	fetch, parsed, err := parseReference(ref)
+	if err != nil {
+		return "", err
+	}
+	value, err := fetch(parsed)
+	if err != nil {
+		return "", errors.Wrapf(err, "fetching %s", ref)
+	}
+	if parsed.field != "" {
+		if value, err = jsonField(value, parsed.field); err != nil {
+			return "", errors.Wrapf(err, "reading %s", ref)
+		}
+	}
+	r.values[ref] = value
+	return value, nil
+}
+
+// jsonField returns a property of a secret that holds a JSON object.  A property that isn't a string is returned as
+// JSON.
+func jsonField(secret string, field string) (string, error) {
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &obj); err != nil {
+		return "", errors.New("the secret is not a JSON object")
+	}
+	v, has := obj[field]
+	if !has {
+		return "", errors.Errorf("the secret has no property '%s'", field)
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// fetchSSMParameter fetches a parameter, decrypting it if it's a SecureString, given a reference of the form
+// `ssm://<name>`, such as `ssm:///prod/db/password`.  The query may give the `region`, `profile` and `endpoint` with
+// which to fetch it.
+func fetchSSMParameter(ref reference) (string, error) {
+	sess, err := newAWSSession(ref.query)
+	if err != nil {
+		return "", err
+	}
+	out, err := ssm.New(sess).GetParameter(&ssm.GetParameterInput{
+		Name:           aws.String(ref.name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(out.Parameter.Value), nil
+}
+
+// fetchSecretsManagerSecret fetches a secret given a reference of the form `secretsmanager://<secret>`, where the
+// secret is given by its name or ARN.  The query may give the `version` or `stage` of the secret to fetch, along with
+// the `region`, `profile` and `endpoint` with which to fetch it.
+func fetchSecretsManagerSecret(ref reference) (string, error) {
+	sess, err := newAWSSession(ref.query)
+	if err != nil {
+		return "", err
+	}
+	input := &secretsmanager.GetSecretValueInput{SecretId: aws.String(ref.name)}
+	if version := ref.query.Get("version"); version != "" {
+		input.VersionId = aws.String(version)
+	}
+	if stage := ref.query.Get("stage"); stage != "" {
+		input.VersionStage = aws.String(stage)
+	}
+	out, err := secretsmanager.New(sess).GetSecretValue(input)
+	if err != nil {
+		return "", err
+	}
+	if out.SecretString == nil {
+		return string(out.SecretBinary), nil
+	}
+	return *out.SecretString, nil
+}
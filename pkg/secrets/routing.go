@@ -0,0 +1,117 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/resource/config"
+)
+
+// Route is a crypter that encrypts some of a stack's secrets, rather than the stack's own crypter: the values of the
+// configuration keys that it's given and, if it's asked to, the stack's state.
+type Route struct {
+	Name    string         // the name of the route, which is recorded in the ciphertext of each secret it encrypts.
+	Crypter config.Crypter // the crypter that encrypts the secrets.
+	Config  []string       // the configuration keys whose values it encrypts; a trailing `*` matches any suffix.
+	State   bool           // true if it encrypts the stack's state, and so the outputs of its resources.
+}
+
+// MatchesConfigKey returns true if the route encrypts the value of the given configuration key.
+func (r Route) MatchesConfigKey(key config.Key) bool {
+	for _, pattern := range r.Config {
+		if strings.HasSuffix(pattern, "*") {
+			if strings.HasPrefix(key.String(), strings.TrimSuffix(pattern, "*")) {
+				return true
+			}
+		} else if pattern == key.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// routedPrefix starts the ciphertext of each secret encrypted by a route, which is followed by the route's name and a
+// colon.  It is neither a base64 character nor the start of a version tag, so it never starts other ciphertext.
+const routedPrefix = "@"
+
+// NewRoutingCrypter returns a crypter that encrypts each secret with the first of the given routes that is for it, or
+// else with the primary crypter.  Values encrypted without a configuration key, such as the stack's state, are for the
+// first route that encrypts the state.  Each secret is decrypted with the crypter that encrypted it, which is recorded
+// in its ciphertext, so that a secret moved from one route to another can still be read.
+func NewRoutingCrypter(primary config.Crypter, routes []Route) config.Crypter {
+	return &routingCrypter{primary: primary, routes: routes}
+}
+
+type routingCrypter struct {
+	primary config.Crypter
+	routes  []Route
+}
+
+// PrimaryCrypter returns the crypter with which a routing crypter encrypts the secrets that none of its routes are for,
+// or, given any other crypter, the crypter itself.
+func PrimaryCrypter(crypter config.Crypter) config.Crypter {
+	if routing, ok := crypter.(*routingCrypter); ok {
+		return routing.primary
+	}
+	return crypter
+}
+
+func (c *routingCrypter) EncryptValue(plaintext string) (string, error) {
+	for _, route := range c.routes {
+		if route.State {
+			return encryptRouted(route, plaintext)
+		}
+	}
+	return c.primary.EncryptValue(plaintext)
+}
+
+func (c *routingCrypter) EncryptValueForKey(key config.Key, plaintext string) (string, error) {
+	for _, route := range c.routes {
+		if route.MatchesConfigKey(key) {
+			return encryptRouted(route, plaintext)
+		}
+	}
+	return c.primary.EncryptValue(plaintext)
+}
+
+func (c *routingCrypter) DecryptValue(ciphertext string) (string, error) {
+	if !strings.HasPrefix(ciphertext, routedPrefix) {
+		return c.primary.DecryptValue(ciphertext)
+	}
+
+	colon := strings.Index(ciphertext, ":")
+	if colon < 0 {
+		return "", errors.New("bad value")
+	}
+	name := ciphertext[len(routedPrefix):colon]
+	for _, route := range c.routes {
+		if route.Name == name {
+			return route.Crypter.DecryptValue(ciphertext[colon+1:])
+		}
+	}
+	return "", errors.Errorf("the value was encrypted with the secrets key '%s', which the stack doesn't have", name)
+}
+
+// encryptRouted encrypts a secret with a route, recording the route's name in the ciphertext.
+func encryptRouted(route Route, plaintext string) (string, error) {
+	ciphertext, err := route.Crypter.EncryptValue(plaintext)
+	if err != nil {
+		return "", errors.Wrapf(err, "encrypting with the secrets key '%s'", route.Name)
+	}
+	return routedPrefix + route.Name + ":" + ciphertext, nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package secrets encrypts the secrets of stacks with keys kept by key management services.  Each stack has a data
+// key of its own, with which its secrets are encrypted; the data key is stored alongside the stack's settings,
+// encrypted, or "wrapped", with a key that never leaves the key management service.  Reading the stack's secrets
+// takes permission to use that key, rather than a passphrase.
+//
+// The package also resolves references to secrets held in external stores, such as AWS Secrets Manager, which stacks
+// may use as configuration values in place of secrets of their own.
+package secrets
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/base64"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/resource/config"
+)
+
+// KeyManager wraps and unwraps the data keys of stacks with a key that it keeps.
+type KeyManager interface {
+	// URL returns the URL of the key with which the key manager wraps data keys, as given to OpenKeyManager.
+	URL() string
+	// WrapKey encrypts a data key.
+	WrapKey(plaintext []byte) ([]byte, error)
+	// UnwrapKey decrypts a data key that was encrypted by WrapKey, even if the key manager's key has since been
+	// rotated.
+	UnwrapKey(ciphertext []byte) ([]byte, error)
+}
+
+// keyManagers maps the scheme of the URLs of each kind of key to the function that opens its key manager.
+var keyManagers = map[string]func(keyURL string) (KeyManager, error){
+	AWSKMSScheme:        openAWSKMS,
+	AzureKeyVaultScheme: openAzureKeyVault,
+	GCPKMSScheme:        openGCPKMS,
+}
+
+// IsKeyManagerURL returns true if a secrets provider is the URL of a key in a key management service, rather than the
+// name of another kind of provider.
+func IsKeyManagerURL(provider string) bool {
+	_, ok := keyManagers[keyURLScheme(provider)]
+	return ok
+}
+
+// OpenKeyManager returns the key manager for a key with the given URL.  Keys in AWS KMS have URLs of the form
+// `awskms://<key>`, keys in Azure Key Vault have URLs of the form `azurekeyvault://<vault>.vault.azure.net/keys/<key>`,
+// and keys in Google Cloud KMS have URLs of the form
+// `gcpkms://projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key>`.
+func OpenKeyManager(keyURL string) (KeyManager, error) {
+	open, ok := keyManagers[keyURLScheme(keyURL)]
+	if !ok {
+		return nil, errors.Errorf("%s is not the URL of a key; expected %s://<key>, %s://<key> or %s://<key>", keyURL,
+			AWSKMSScheme, AzureKeyVaultScheme, GCPKMSScheme)
+	}
+	return open(keyURL)
+}
+
+// keyURLScheme returns the scheme of a key's URL, or "" if it has none.
+func keyURLScheme(keyURL string) string {
+	if i := strings.Index(keyURL, "://"); i > 0 {
+		return keyURL[:i]
+	}
+	return ""
+}
+
+// NewCrypter creates a new data key, and returns a crypter that encrypts with it, along with the data key wrapped by
+// the given key manager, which is to be saved so that the crypter can be restored by CrypterFromKey.
+func NewCrypter(km KeyManager) (config.Crypter, string, error) {
+	key := make([]byte, config.SymmetricCrypterKeyBytes)
+	if _, err := cryptorand.Read(key); err != nil {
+		return nil, "", errors.Wrap(err, "generating a data key")
+	}
+	wrapped, err := km.WrapKey(key)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "encrypting a data key with %s", km.URL())
+	}
+	return config.NewSymmetricCrypter(key), base64.StdEncoding.EncodeToString(wrapped), nil
+}
+
+// CrypterFromKey returns the crypter that encrypts with a data key that was wrapped by the given key manager, as
+// returned by NewCrypter.
+func CrypterFromKey(km KeyManager, wrappedKey string) (config.Crypter, error) {
+	wrapped, err := base64.StdEncoding.DecodeString(wrappedKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed data key")
+	}
+	key, err := km.UnwrapKey(wrapped)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decrypting the data key with %s", km.URL())
+	}
+	if len(key) != config.SymmetricCrypterKeyBytes {
+		return nil, errors.Errorf("the data key decrypted with %s is %d bytes long, rather than %d", km.URL(), len(key),
+			config.SymmetricCrypterKeyBytes)
+	}
+	return config.NewSymmetricCrypter(key), nil
+}
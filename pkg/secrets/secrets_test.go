@@ -0,0 +1,276 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/resource/config"
+	"github.com/pulumi/pulumi/pkg/util/contract"
+)
+
+// xorKeyManager is a key manager that "wraps" keys by XORing them with a byte, for testing.
+type xorKeyManager byte
+
+func (m xorKeyManager) URL() string { return "xor://" }
+
+func (m xorKeyManager) WrapKey(plaintext []byte) ([]byte, error) {
+	result := make([]byte, len(plaintext))
+	for i, b := range plaintext {
+		result[i] = b ^ byte(m)
+	}
+	return result, nil
+}
+
+func (m xorKeyManager) UnwrapKey(ciphertext []byte) ([]byte, error) {
+	return m.WrapKey(ciphertext)
+}
+
+func TestCrypterFromKey(t *testing.T) {
+	km := xorKeyManager(0x5a)
+	crypter, key, err := NewCrypter(km)
+	assert.NoError(t, err)
+	ciphertext, err := crypter.EncryptValue("hunter2")
+	assert.NoError(t, err)
+
+	// The crypter restored from the wrapped key decrypts what the first encrypted.
+	restored, err := CrypterFromKey(km, key)
+	assert.NoError(t, err)
+	plaintext, err := restored.DecryptValue(ciphertext)
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", plaintext)
+
+	// But one restored with another key manager doesn't.
+	other, err := CrypterFromKey(xorKeyManager(0x33), key)
+	assert.NoError(t, err)
+	_, err = other.DecryptValue(ciphertext)
+	assert.Error(t, err)
+
+	_, err = CrypterFromKey(km, "not base64!")
+	assert.Error(t, err)
+}
+
+func TestOpenAWSKMS(t *testing.T) {
+	assert.True(t, IsKeyManagerURL("awskms://alias/pulumi"))
+	assert.False(t, IsKeyManagerURL("passphrase"))
+
+	km, err := OpenKeyManager("awskms://alias/pulumi?region=us-west-2")
+	assert.NoError(t, err)
+	assert.Equal(t, "alias/pulumi", km.(*awsKMSKeyManager).keyID)
+	assert.Equal(t, "us-west-2", *km.(*awsKMSKeyManager).svc.Config.Region)
+
+	km, err = OpenKeyManager("awskms:///arn:aws:kms:us-east-1:123456789012:key/1234abcd?region=us-east-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "arn:aws:kms:us-east-1:123456789012:key/1234abcd", km.(*awsKMSKeyManager).keyID)
+
+	_, err = OpenKeyManager("awskms://")
+	assert.Error(t, err)
+	_, err = OpenKeyManager("vault://key")
+	assert.Error(t, err)
+}
+
+func TestGCPKMS(t *testing.T) {
+	const name = "projects/p/locations/global/keyRings/r/cryptoKeys/k"
+
+	// A fake Cloud KMS that "encrypts" by reversing the bytes given to it.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req map[string][]byte
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		reverse := func(b []byte) []byte {
+			result := make([]byte, len(b))
+			for i := range b {
+				result[i] = b[len(b)-1-i]
+			}
+			return result
+		}
+		switch r.URL.Path {
+		case "/v1/" + name + ":encrypt":
+			contract.IgnoreError(json.NewEncoder(w).Encode(map[string][]byte{"ciphertext": reverse(req["plaintext"])}))
+		case "/v1/" + name + ":decrypt":
+			contract.IgnoreError(json.NewEncoder(w).Encode(map[string][]byte{"plaintext": reverse(req["ciphertext"])}))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	old := os.Getenv(gcpAccessTokenEnvVar)
+	defer func() { contract.IgnoreError(os.Setenv(gcpAccessTokenEnvVar, old)) }()
+	assert.NoError(t, os.Setenv(gcpAccessTokenEnvVar, "token"))
+
+	km, err := OpenKeyManager("gcpkms://" + name + "?endpoint=" + server.URL)
+	assert.NoError(t, err)
+	crypter, key, err := NewCrypter(km)
+	assert.NoError(t, err)
+	restored, err := CrypterFromKey(km, key)
+	assert.NoError(t, err)
+	ciphertext, err := crypter.EncryptValue("hunter2")
+	assert.NoError(t, err)
+	plaintext, err := restored.DecryptValue(ciphertext)
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", plaintext)
+
+	_, err = OpenKeyManager("gcpkms://projects/p/locations/global/keyRings/r")
+	assert.Error(t, err)
+}
+
+func TestAzureKeyVault(t *testing.T) {
+	// A fake Key Vault that "wraps" by reversing the bytes given to it, and whose key's current version is changed by
+	// rotating it.
+	version := "v1"
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req azureWrappedKey
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		value, err := base64.RawURLEncoding.DecodeString(req.Value)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		reversed := make([]byte, len(value))
+		for i := range value {
+			reversed[i] = value[len(value)-1-i]
+		}
+		resp := azureWrappedKey{Value: base64.RawURLEncoding.EncodeToString(reversed)}
+		switch r.URL.Path {
+		case "/keys/k/wrapkey":
+			resp.KeyID = server.URL + "/keys/k/" + version
+		case "/keys/k/v1/unwrapkey", "/keys/k/v2/unwrapkey":
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		contract.IgnoreError(json.NewEncoder(w).Encode(resp))
+	}))
+	defer server.Close()
+
+	km, err := OpenKeyManager("azurekeyvault://Vault.vault.azure.net/keys/k")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://vault.vault.azure.net", km.(*azureKeyVaultKeyManager).endpoint)
+	km.(*azureKeyVaultKeyManager).endpoint = server.URL
+	km.(*azureKeyVaultKeyManager).creds = &azureCredentials{token: "token", expires: time.Now().Add(time.Hour)}
+
+	crypter, key, err := NewCrypter(km)
+	assert.NoError(t, err)
+	ciphertext, err := crypter.EncryptValue("hunter2")
+	assert.NoError(t, err)
+
+	// After the key is rotated, the data key is still unwrapped, with the version that wrapped it.
+	version = "v2"
+	restored, err := CrypterFromKey(km, key)
+	assert.NoError(t, err)
+	plaintext, err := restored.DecryptValue(ciphertext)
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", plaintext)
+
+	_, err = OpenKeyManager("azurekeyvault://vault.vault.azure.net/secrets/k")
+	assert.Error(t, err)
+}
+
+func TestReferenceResolver(t *testing.T) {
+	// A fake store whose secrets are JSON objects, which counts how many times each is fetched.
+	fetched := make(map[string]int)
+	secretStores["test"] = func(ref reference) (string, error) {
+		fetched[ref.name]++
+		return `{"user":"admin","password":"hunter2","port":5432}`, nil
+	}
+	defer delete(secretStores, "test")
+
+	resolver := NewReferenceResolver(config.NopDecrypter).(config.Resolver)
+	for i := 0; i < 2; i++ {
+		password, err := resolver.ResolveReference("test://prod/db?region=us-west-2#password")
+		assert.NoError(t, err)
+		assert.Equal(t, "hunter2", password)
+	}
+	assert.Equal(t, 1, fetched["prod/db"])
+
+	port, err := resolver.ResolveReference("test://prod/db#port")
+	assert.NoError(t, err)
+	assert.Equal(t, "5432", port)
+	_, err = resolver.ResolveReference("test://prod/db#host")
+	assert.Error(t, err)
+
+	assert.NoError(t, ValidateReference("ssm:///prod/db/password"))
+	assert.NoError(t, ValidateReference("secretsmanager://arn:aws:secretsmanager:us-west-2:123456789012:secret:db"))
+	assert.Error(t, ValidateReference("ssm://"))
+	assert.Error(t, ValidateReference("vault://secret/db"))
+}
+
+func TestRoutingCrypter(t *testing.T) {
+	newCrypter := func(b byte) config.Crypter {
+		crypter, _, err := NewCrypter(xorKeyManager(b))
+		assert.NoError(t, err)
+		return crypter
+	}
+	primary, team, security := newCrypter(0x11), newCrypter(0x22), newCrypter(0x33)
+	crypter := NewRoutingCrypter(primary, []Route{
+		{Name: "security", Crypter: security, Config: []string{"app:rootPassword", "aws:*"}, State: true},
+		{Name: "team", Crypter: team, Config: []string{"app:*"}},
+	})
+
+	// Each key's value is encrypted with the first route for it, and decrypted with whichever route encrypted it.
+	ciphertext, err := config.EncrypterForKey(crypter, config.MustMakeKey("app", "rootPassword")).EncryptValue("hunter2")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(ciphertext, "@security:"))
+	_, err = security.DecryptValue(strings.TrimPrefix(ciphertext, "@security:"))
+	assert.NoError(t, err)
+	plaintext, err := crypter.DecryptValue(ciphertext)
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", plaintext)
+
+	ciphertext, err = config.EncrypterForKey(crypter, config.MustMakeKey("app", "apiToken")).EncryptValue("hunter2")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(ciphertext, "@team:"))
+
+	// Keys without a route, and the values of encrypters that don't route, are encrypted with the primary crypter.
+	ciphertext, err = config.EncrypterForKey(crypter, config.MustMakeKey("gcp", "credentials")).EncryptValue("hunter2")
+	assert.NoError(t, err)
+	plaintext, err = primary.DecryptValue(ciphertext)
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", plaintext)
+	assert.Equal(t, primary, config.EncrypterForKey(primary, config.MustMakeKey("app", "apiToken")))
+
+	// The state is encrypted with the route for it.
+	ciphertext, err = crypter.EncryptValue("state")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(ciphertext, "@security:"))
+
+	// A stack that no longer has a route can't read what it encrypted.
+	_, err = NewRoutingCrypter(primary, nil).DecryptValue(ciphertext)
+	assert.Error(t, err)
+}
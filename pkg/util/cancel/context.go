@@ -71,6 +71,12 @@ func (c *Context) CancelErr() error {
 	return c.cancel.Err()
 }
 
+// CancelContext returns a context that is done once this context is canceled or terminated, for use with APIs that
+// accept a context.Context.
+func (c *Context) CancelContext() context.Context {
+	return c.cancel
+}
+
 // Terminated returns a channel that will be closed when the context is terminated.
 func (c *Context) Terminated() <-chan struct{} {
 	return c.terminate.Done()
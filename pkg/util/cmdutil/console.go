@@ -72,3 +72,14 @@ func RemoveTralingNewline(s string) string {
 
 	return s
 }
+
+// Plural returns the plural form of the noun s if the count c is not one.
+func Plural(s string, c int) string {
+	if c == 1 {
+		return s
+	}
+	if n := len(s); n > 1 && s[n-1] == 'y' && !strings.ContainsRune("aeiou", rune(s[n-2])) {
+		return s[:n-1] + "ies"
+	}
+	return s + "s"
+}
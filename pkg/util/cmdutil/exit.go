@@ -90,10 +90,24 @@ func runPostCommandHooks(c *cobra.Command, args []string) error {
 func RunFunc(run func(cmd *cobra.Command, args []string) error) func(*cobra.Command, []string) {
 	return func(cmd *cobra.Command, args []string) {
 		if err := run(cmd, args); err != nil {
+			// A command may ask to exit with a particular code, in which case it may not have an error to report.
+			code := -1
+			if exitErr, ok := err.(*ExitCodeError); ok {
+				code = exitErr.Code
+				err = exitErr.Err
+			}
+
 			// Sadly, the fact that we hard-exit below means that it's up to us to replicate the Cobra post-run
 			// behavior here.
 			if postRunErr := runPostCommandHooks(cmd, args); postRunErr != nil {
-				err = multierror.Append(err, postRunErr)
+				if err == nil {
+					err = postRunErr
+				} else {
+					err = multierror.Append(err, postRunErr)
+				}
+			}
+			if err == nil {
+				os.Exit(code)
 			}
 
 			// If there is a stack trace, and logging is enabled, append it.  Otherwise, debug logging it.
@@ -105,11 +119,32 @@ func RunFunc(run func(cmd *cobra.Command, args []string) error) func(*cobra.Comm
 				logging.V(3).Infof(DetailedError(err))
 			}
 
-			ExitError(msg)
+			exitErrorCode(code, msg)
 		}
 	}
 }
 
+// Exit codes that commands document for use by scripts.
+const (
+	ExitSuccess        = 0 // the command succeeded.
+	ExitFailure        = 1 // the command failed.
+	ExitChangesPresent = 2 // the command succeeded, and found changes to be made (e.g. `preview --detailed-exit-code`).
+)
+
+// ExitCodeError is returned from a command's run func to make it exit with a particular exit code.  If Err is nil,
+// the command exits without reporting an error.
+type ExitCodeError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitCodeError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit code %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
 // Exit exits with a given error.
 func Exit(err error) {
 	ExitError(errorMessage(err))
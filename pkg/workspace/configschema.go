@@ -0,0 +1,222 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/resource/config"
+)
+
+// ConfigType is the type of the value of a configuration key declared in a project's config schema.
+type ConfigType string
+
+const (
+	ConfigTypeString  ConfigType = "string"
+	ConfigTypeInteger ConfigType = "integer"
+	ConfigTypeNumber  ConfigType = "number"
+	ConfigTypeBoolean ConfigType = "boolean"
+	ConfigTypeObject  ConfigType = "object"
+	ConfigTypeArray   ConfigType = "array"
+)
+
+// isScalar returns true if values of the type are given as strings, rather than as objects or arrays.
+func (t ConfigType) isScalar() bool {
+	return t != ConfigTypeObject && t != ConfigTypeArray
+}
+
+// ConfigSchemaEntry declares a configuration key that a project's program reads, so that the CLI can check its value
+// when it's set and before each update, rather than the program failing on it partway through.
+// nolint: lll
+type ConfigSchemaEntry struct {
+	Type        ConfigType `json:"type,omitempty" yaml:"type,omitempty"`               // the type of the value; if empty, any value is allowed.
+	Description string     `json:"description,omitempty" yaml:"description,omitempty"` // what the value is for, shown in errors.
+	Required    bool       `json:"required,omitempty" yaml:"required,omitempty"`       // true if every stack must set the key.
+	Secret      bool       `json:"secret,omitempty" yaml:"secret,omitempty"`           // true if the value must be encrypted.
+	Allowed     []string   `json:"allowed,omitempty" yaml:"allowed,omitempty"`         // the values allowed, if only some are.
+}
+
+// validateConfigSchema checks that the config schema's keys and entries are well formed.
+func (proj *Project) validateConfigSchema() error {
+	for k, entry := range proj.ConfigSchema {
+		if _, err := proj.configKey(k); err != nil {
+			return errors.Wrapf(err, "configSchema entry '%s'", k)
+		}
+		switch entry.Type {
+		case "", ConfigTypeString, ConfigTypeInteger, ConfigTypeNumber, ConfigTypeBoolean, ConfigTypeObject,
+			ConfigTypeArray:
+		default:
+			return errors.Errorf("configSchema entry '%s' has an unknown type '%s'; expected string, integer, "+
+				"number, boolean, object or array", k, entry.Type)
+		}
+		if len(entry.Allowed) == 0 {
+			continue
+		}
+		if !entry.Type.isScalar() {
+			return errors.Errorf("configSchema entry '%s' may not restrict the values of an %s", k, entry.Type)
+		}
+		for _, allowed := range entry.Allowed {
+			if !hasConfigType(allowed, false, entry.Type) {
+				return errors.Errorf("configSchema entry '%s' allows '%s', which is not a %s", k, allowed, entry.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// configSchemaEntry returns the entry of the project's config schema for a key, if it has one.
+func (proj *Project) configSchemaEntry(key config.Key) (ConfigSchemaEntry, bool) {
+	for k, entry := range proj.ConfigSchema {
+		if ck, err := proj.configKey(k); err == nil && ck == key {
+			return entry, true
+		}
+	}
+	return ConfigSchemaEntry{}, false
+}
+
+// ValidateConfigValue checks the value of a key against the project's config schema, if it declares the key.  The type
+// of a secret is only checked if decrypter is non-nil.
+func (proj *Project) ValidateConfigValue(key config.Key, v config.Value, decrypter config.Decrypter) error {
+	entry, has := proj.configSchemaEntry(key)
+	if !has {
+		return nil
+	}
+
+	if entry.Secret && !v.Secure() {
+		return errors.Errorf("%s must be a secret; set it with `pulumi config set --secret %s <value>`",
+			describeConfigKey(key, entry), key)
+	}
+	if v.Secure() && decrypter == nil {
+		return nil
+	}
+	// A reference to a secret in an external store is only checked once it's resolved, as it is during updates.
+	if _, ref := v.Reference(); ref {
+		if _, ok := decrypter.(config.Resolver); !ok {
+			return nil
+		}
+	}
+	value, err := v.Value(decrypter)
+	if err != nil {
+		return errors.Wrapf(err, "decrypting %s", key)
+	}
+
+	if entry.Type != "" && !hasConfigType(value, v.Object(), entry.Type) {
+		shown := value
+		if v.Secure() {
+			shown = "[secret]"
+		}
+		return errors.Errorf("%s must be %s %s; got '%s'", describeConfigKey(key, entry), article(entry.Type),
+			entry.Type, shown)
+	}
+	if len(entry.Allowed) > 0 && !v.Object() {
+		for _, allowed := range entry.Allowed {
+			if value == allowed {
+				return nil
+			}
+		}
+		return errors.Errorf("%s must be one of %s", describeConfigKey(key, entry), strings.Join(entry.Allowed, ", "))
+	}
+	return nil
+}
+
+// ValidateConfig checks a stack's configuration against the project's config schema: every required key must be set,
+// and every key that the schema declares must have a value it allows.  All of the problems found are reported
+// together.  The types of secrets are only checked if decrypter is non-nil.
+func (proj *Project) ValidateConfig(cfg config.Map, decrypter config.Decrypter) error {
+	if len(proj.ConfigSchema) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(proj.ConfigSchema))
+	for k := range proj.ConfigSchema {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var result error
+	for _, k := range names {
+		entry := proj.ConfigSchema[k]
+		key, err := proj.configKey(k)
+		if err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "configSchema entry '%s'", k))
+			continue
+		}
+
+		v, has := cfg[key]
+		if !has {
+			if entry.Required {
+				secret := ""
+				if entry.Secret {
+					secret = "--secret "
+				}
+				result = multierror.Append(result, errors.Errorf(
+					"%s is required; set it with `pulumi config set %s%s <value>`",
+					describeConfigKey(key, entry), secret, key))
+			}
+			continue
+		}
+		if err = proj.ValidateConfigValue(key, v, decrypter); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+	if result != nil {
+		return errors.Wrap(result, "the stack's configuration does not match the project's configSchema")
+	}
+	return nil
+}
+
+// hasConfigType returns true if a value, given as a string or, for an object or array, as JSON, has the given type.
+func hasConfigType(value string, object bool, t ConfigType) bool {
+	switch t {
+	case ConfigTypeString:
+		return !object
+	case ConfigTypeInteger:
+		_, err := strconv.ParseInt(value, 10, 64)
+		return !object && err == nil
+	case ConfigTypeNumber:
+		_, err := strconv.ParseFloat(value, 64)
+		return !object && err == nil
+	case ConfigTypeBoolean:
+		return !object && (value == "true" || value == "false")
+	case ConfigTypeObject:
+		return object && strings.HasPrefix(value, "{")
+	case ConfigTypeArray:
+		return object && strings.HasPrefix(value, "[")
+	default:
+		return true
+	}
+}
+
+// describeConfigKey returns the name of a key for errors, along with its description, if it has one.
+func describeConfigKey(key config.Key, entry ConfigSchemaEntry) string {
+	if entry.Description == "" {
+		return fmt.Sprintf("'%s'", key)
+	}
+	return fmt.Sprintf("'%s' (%s)", key, entry.Description)
+}
+
+// article returns the indefinite article for a type's name.
+func article(t ConfigType) string {
+	if t == ConfigTypeInteger || t == ConfigTypeObject || t == ConfigTypeArray {
+		return "an"
+	}
+	return "a"
+}
@@ -0,0 +1,73 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/resource/config"
+)
+
+func TestValidateConfig(t *testing.T) {
+	proj := &Project{Name: "app", Runtime: "nodejs", ConfigSchema: map[string]ConfigSchemaEntry{
+		"size":       {Type: ConfigTypeString, Allowed: []string{"small", "large"}},
+		"replicas":   {Type: ConfigTypeInteger, Required: true},
+		"token":      {Required: true, Secret: true, Description: "the API token"},
+		"tags":       {Type: ConfigTypeObject},
+		"aws:region": {Required: true},
+	}}
+	assert.NoError(t, proj.Validate())
+
+	size, replicas, token := config.MustMakeKey("app", "size"), config.MustMakeKey("app", "replicas"),
+		config.MustMakeKey("app", "token")
+	tags, region := config.MustMakeKey("app", "tags"), config.MustMakeKey("aws", "region")
+	tagsValue, err := config.NewObjectValue(`{"team":"infra"}`)
+	assert.NoError(t, err)
+
+	cfg := config.Map{
+		size:     config.NewValue("small"),
+		replicas: config.NewValue("3"),
+		token:    config.NewSecureValue("ciphertext"),
+		tags:     tagsValue,
+		region:   config.NewValue("us-west-2"),
+	}
+	assert.NoError(t, proj.ValidateConfig(cfg, nil))
+
+	// Keys the schema doesn't declare may have any value.
+	assert.NoError(t, proj.ValidateConfigValue(config.MustMakeKey("app", "other"), tagsValue, nil))
+
+	assert.Error(t, proj.ValidateConfigValue(size, config.NewValue("medium"), nil))
+	assert.Error(t, proj.ValidateConfigValue(replicas, config.NewValue("three"), nil))
+	assert.Error(t, proj.ValidateConfigValue(token, config.NewValue("plaintext"), nil))
+	assert.Error(t, proj.ValidateConfigValue(tags, config.NewValue("infra"), nil))
+
+	// Every problem is reported at once.
+	delete(cfg, token)
+	delete(cfg, region)
+	cfg[replicas] = config.NewValue("three")
+	err = proj.ValidateConfig(cfg, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "'app:token' (the API token) is required")
+	assert.Contains(t, err.Error(), "pulumi config set --secret app:token")
+	assert.Contains(t, err.Error(), "'aws:region' is required")
+	assert.Contains(t, err.Error(), "'app:replicas' must be an integer")
+
+	proj.ConfigSchema["count"] = ConfigSchemaEntry{Type: "int"}
+	assert.Error(t, proj.Validate())
+	proj.ConfigSchema["count"] = ConfigSchemaEntry{Type: ConfigTypeInteger, Allowed: []string{"1", "two"}}
+	assert.Error(t, proj.Validate())
+}
@@ -52,6 +52,12 @@ func DeleteAccessToken(key string) error {
 	if creds.AccessTokens != nil {
 		delete(creds.AccessTokens, key)
 	}
+	if creds.ReadOnly != nil {
+		delete(creds.ReadOnly, key)
+	}
+	if creds.Mirrors != nil {
+		delete(creds.Mirrors, key)
+	}
 	if creds.Current == key {
 		creds.Current = ""
 	}
@@ -74,16 +80,89 @@ func StoreAccessToken(key string, token string, current bool) error {
 	return StoreCredentials(creds)
 }
 
+// IsReadOnlyAccess returns true if the backend under the given key was logged into in read-only mode.
+func IsReadOnlyAccess(key string) (bool, error) {
+	creds, err := GetStoredCredentials()
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	return creds.ReadOnly[key], nil
+}
+
+// StoreReadOnlyAccess records whether the backend under the given key was logged into in read-only mode.
+func StoreReadOnlyAccess(key string, readOnly bool) error {
+	creds, err := GetStoredCredentials()
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if readOnly {
+		if creds.ReadOnly == nil {
+			creds.ReadOnly = make(map[string]bool)
+		}
+		creds.ReadOnly[key] = true
+	} else {
+		delete(creds.ReadOnly, key)
+	}
+	return StoreCredentials(creds)
+}
+
+// BackendMirrors are the backends to which the state of the stacks in another backend is mirrored.
+type BackendMirrors struct {
+	URLs    []string `json:"urls"`              // the URLs of the mirrors.
+	Failure string   `json:"failure,omitempty"` // what to do when a mirror can't be written: warn or fail.
+}
+
+// GetBackendMirrors returns the mirrors of the backend under the given key, or nil if it has none.
+func GetBackendMirrors(key string) (*BackendMirrors, error) {
+	creds, err := GetStoredCredentials()
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if mirrors, ok := creds.Mirrors[key]; ok {
+		return &mirrors, nil
+	}
+	return nil, nil
+}
+
+// StoreBackendMirrors records the mirrors of the backend under the given key, replacing any it had.  If mirrors is
+// nil or has no URLs, the backend has none.
+func StoreBackendMirrors(key string, mirrors *BackendMirrors) error {
+	creds, err := GetStoredCredentials()
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if mirrors != nil && len(mirrors.URLs) > 0 {
+		if creds.Mirrors == nil {
+			creds.Mirrors = make(map[string]BackendMirrors)
+		}
+		creds.Mirrors[key] = *mirrors
+	} else {
+		delete(creds.Mirrors, key)
+	}
+	return StoreCredentials(creds)
+}
+
 // Credentials hold the information necessary for authenticating Pulumi Cloud API requests.  It contains
 // a map from the cloud API URL to the associated access token.
 type Credentials struct {
-	Current      string            `json:"current,omitempty"`      // the currently selected key.
-	AccessTokens map[string]string `json:"accessTokens,omitempty"` // a map of arbitrary key strings to tokens.
+	Current      string                    `json:"current,omitempty"`      // the currently selected key.
+	AccessTokens map[string]string         `json:"accessTokens,omitempty"` // a map of arbitrary key strings to tokens.
+	ReadOnly     map[string]bool           `json:"readOnly,omitempty"`     // the keys logged into in read-only mode.
+	Mirrors      map[string]BackendMirrors `json:"mirrors,omitempty"`      // the mirrors of the backends under keys.
 }
 
 // getCredsFilePath returns the path to the Pulumi credentials file on disk, regardless of
 // whether it exists or not.
 func getCredsFilePath() (string, error) {
+	pulumiFolder, err := getCredsFolder()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(pulumiFolder, "credentials.json"), nil
+}
+
+// getCredsFolder returns the path to the folder in which credentials are stored, creating it if it doesn't exist.
+func getCredsFolder() (string, error) {
 	user, err := user.Current()
 	if user == nil || err != nil {
 		return "", errors.Wrapf(err, "getting creds file path: failed to get current user")
@@ -99,8 +178,7 @@ func getCredsFilePath() (string, error) {
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to create '%s'", pulumiFolder)
 	}
-
-	return filepath.Join(pulumiFolder, "credentials.json"), nil
+	return pulumiFolder, nil
 }
 
 // GetCurrentCloudURL returns the URL of the cloud we are currently connected to. This may be empty if we
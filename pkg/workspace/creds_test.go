@@ -0,0 +1,56 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadOnlyAccess(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pulumi-creds")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	old := os.Getenv(PulumiCredentialsPathEnvVar)
+	defer os.Setenv(PulumiCredentialsPathEnvVar, old)
+	assert.NoError(t, os.Setenv(PulumiCredentialsPathEnvVar, dir))
+
+	const key = "s3://bucket/prefix"
+	assert.NoError(t, StoreAccessToken(key, "", true))
+	readOnly, err := IsReadOnlyAccess(key)
+	assert.NoError(t, err)
+	assert.False(t, readOnly)
+
+	assert.NoError(t, StoreReadOnlyAccess(key, true))
+	readOnly, err = IsReadOnlyAccess(key)
+	assert.NoError(t, err)
+	assert.True(t, readOnly)
+
+	// Logging in again without read-only mode leaves it.
+	assert.NoError(t, StoreReadOnlyAccess(key, false))
+	readOnly, err = IsReadOnlyAccess(key)
+	assert.NoError(t, err)
+	assert.False(t, readOnly)
+
+	// Logging out forgets it.
+	assert.NoError(t, StoreReadOnlyAccess(key, true))
+	assert.NoError(t, DeleteAccessToken(key))
+	readOnly, err = IsReadOnlyAccess(key)
+	assert.NoError(t, err)
+	assert.False(t, readOnly)
+}
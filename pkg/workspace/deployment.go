@@ -0,0 +1,210 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/resource/config"
+	"github.com/pulumi/pulumi/pkg/util/contract"
+)
+
+// DeploymentFile is the name of the file that, by default, describes a multi-stack deployment.
+const DeploymentFile = "Pulumi.deployment.yaml"
+
+// Deployment describes a set of stacks, possibly belonging to different projects, that are deployed together.  A
+// stack may depend on others, in which case it is updated after them and destroyed before them; a stack's
+// configuration may also be taken from the outputs of the stacks it depends on.
+type Deployment struct {
+	Stacks map[string]*DeploymentStack `json:"stacks" yaml:"stacks"` // the stacks to deploy, keyed by name.
+}
+
+// DeploymentStack is a single stack in a deployment.
+// nolint: lll
+type DeploymentStack struct {
+	Project   string            `json:"project" yaml:"project"`                         // the directory holding the stack's Pulumi.yaml, relative to the deployment file.
+	Stack     string            `json:"stack,omitempty" yaml:"stack,omitempty"`         // the name of the stack; by default, the name of its entry.
+	DependsOn []string          `json:"dependsOn,omitempty" yaml:"dependsOn,omitempty"` // the names of the entries for the stacks that this stack depends on.
+	Config    map[string]string `json:"config,omitempty" yaml:"config,omitempty"`       // configuration to set from other stacks' outputs, each written `<entry>.<output>`.
+}
+
+// StackName returns the name of the stack, which is the name of its entry unless it is given explicitly.
+func (ds *DeploymentStack) StackName(entry string) string {
+	if ds.Stack != "" {
+		return ds.Stack
+	}
+	return entry
+}
+
+// OutputReference splits a configuration value of the form `<entry>.<output>` into the name of the entry whose stack
+// has the output and the name of the output.
+func OutputReference(ref string) (string, string, error) {
+	dot := strings.Index(ref, ".")
+	if dot <= 0 || dot == len(ref)-1 {
+		return "", "", errors.Errorf("'%s' does not name a stack output; expected '<stack>.<output>'", ref)
+	}
+	return ref[:dot], ref[dot+1:], nil
+}
+
+// Dependencies returns the names of the entries that the given entry depends on, both explicitly and by using their
+// outputs as its configuration, in sorted order.
+func (d *Deployment) Dependencies(entry string) []string {
+	ds := d.Stacks[entry]
+	if ds == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var deps []string
+	add := func(dep string) {
+		if !seen[dep] {
+			seen[dep] = true
+			deps = append(deps, dep)
+		}
+	}
+	for _, dep := range ds.DependsOn {
+		add(dep)
+	}
+	for _, ref := range ds.Config {
+		if dep, _, err := OutputReference(ref); err == nil {
+			add(dep)
+		}
+	}
+	sort.Strings(deps)
+	return deps
+}
+
+// Validate checks that a deployment is well formed: that each of its stacks names a project, that each dependency and
+// output reference names another entry, and that there are no cycles between the stacks.
+func (d *Deployment) Validate() error {
+	if len(d.Stacks) == 0 {
+		return errors.New("deployment is missing a 'stacks' attribute")
+	}
+	for _, entry := range d.entries() {
+		ds := d.Stacks[entry]
+		if ds == nil || ds.Project == "" {
+			return errors.Errorf("stack '%s' is missing a 'project' attribute", entry)
+		}
+		for key, ref := range ds.Config {
+			if _, err := config.ParseKey(key); err != nil {
+				return errors.Wrapf(err, "stack '%s' has an invalid configuration key", entry)
+			}
+			if _, _, err := OutputReference(ref); err != nil {
+				return errors.Wrapf(err, "stack '%s' has invalid configuration for '%s'", entry, key)
+			}
+		}
+		for _, dep := range d.Dependencies(entry) {
+			if dep == entry {
+				return errors.Errorf("stack '%s' depends on itself", entry)
+			}
+			if _, has := d.Stacks[dep]; !has {
+				return errors.Errorf("stack '%s' depends on '%s', which is not in the deployment", entry, dep)
+			}
+		}
+	}
+	_, err := d.TopologicalOrder()
+	return err
+}
+
+// TopologicalOrder returns the names of the deployment's entries ordered so that each comes after all of those it
+// depends on.  Entries that don't depend on one another are ordered by name.  An error is returned if the stacks'
+// dependencies form a cycle.
+func (d *Deployment) TopologicalOrder() ([]string, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	var order []string
+	var visit func(entry string, path []string) error
+	visit = func(entry string, path []string) error {
+		switch state[entry] {
+		case visiting:
+			return errors.Errorf("stacks have a dependency cycle: %s", strings.Join(append(path, entry), " -> "))
+		case visited:
+			return nil
+		}
+		state[entry] = visiting
+		for _, dep := range d.Dependencies(entry) {
+			if _, has := d.Stacks[dep]; !has {
+				continue
+			}
+			if err := visit(dep, append(path, entry)); err != nil {
+				return err
+			}
+		}
+		state[entry] = visited
+		order = append(order, entry)
+		return nil
+	}
+	for _, entry := range d.entries() {
+		if err := visit(entry, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// entries returns the names of the deployment's entries in sorted order.
+func (d *Deployment) entries() []string {
+	entries := make([]string, 0, len(d.Stacks))
+	for entry := range d.Stacks {
+		entries = append(entries, entry)
+	}
+	sort.Strings(entries)
+	return entries
+}
+
+// ProjectPath returns the path of the directory holding the project of the given entry's stack.  A relative path is
+// taken to be relative to the directory of the deployment file at path.
+func (d *Deployment) ProjectPath(path, entry string) string {
+	contract.Require(d.Stacks[entry] != nil, "entry")
+	dir := d.Stacks[entry].Project
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(path), dir)
+	}
+	return dir
+}
+
+// LoadDeployment reads a deployment definition from a file.
+func LoadDeployment(path string) (*Deployment, error) {
+	contract.Require(path != "", "path")
+
+	m, err := marshallerForPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var d Deployment
+	if err = m.Unmarshal(b, &d); err != nil {
+		return nil, err
+	}
+	if err = d.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeploymentOrder(t *testing.T) {
+	d := &Deployment{Stacks: map[string]*DeploymentStack{
+		"app": {
+			Project: "app",
+			Config:  map[string]string{"app:vpcId": "network.vpcId", "app:dbHost": "database.host"},
+		},
+		"database": {Project: "database", DependsOn: []string{"network"}},
+		"network":  {Project: "network"},
+		"dns":      {Project: "dns"},
+	}}
+	assert.NoError(t, d.Validate())
+	assert.Equal(t, []string{"database", "network"}, d.Dependencies("app"))
+
+	order, err := d.TopologicalOrder()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"network", "database", "app", "dns"}, order)
+}
+
+func TestDeploymentValidate(t *testing.T) {
+	assert.Error(t, (&Deployment{}).Validate())
+	assert.Error(t, (&Deployment{Stacks: map[string]*DeploymentStack{"a": {}}}).Validate())
+	assert.Error(t, (&Deployment{Stacks: map[string]*DeploymentStack{
+		"a": {Project: "a", DependsOn: []string{"missing"}},
+	}}).Validate())
+	assert.Error(t, (&Deployment{Stacks: map[string]*DeploymentStack{
+		"a": {Project: "a", Config: map[string]string{"a:key": "noOutput"}},
+	}}).Validate())
+	assert.Error(t, (&Deployment{Stacks: map[string]*DeploymentStack{
+		"a": {Project: "a", DependsOn: []string{"b"}},
+		"b": {Project: "b", Config: map[string]string{"b:key": "a.out"}},
+	}}).Validate())
+}
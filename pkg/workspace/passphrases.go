@@ -0,0 +1,279 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/util/contract"
+	"github.com/pulumi/pulumi/pkg/util/logging"
+)
+
+// PassphraseCacheTimeoutEnvVar is the environment variable that sets how long the keys unlocked by passphrases are
+// cached, as a duration such as `15m`.  Unless it's set, they aren't cached at all.
+const PassphraseCacheTimeoutEnvVar = "PULUMI_PASSPHRASE_CACHE_TIMEOUT"
+
+// ErrNoPassphraseAgent is returned when a key is to be cached, but no passphrase agent is running to hold it.
+var ErrNoPassphraseAgent = errors.New("no passphrase agent is running")
+
+// GetPassphraseCacheTimeout returns how long the keys unlocked by passphrases are cached, or zero if they aren't.
+func GetPassphraseCacheTimeout() (time.Duration, error) {
+	v := os.Getenv(PassphraseCacheTimeoutEnvVar)
+	if v == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid %s", PassphraseCacheTimeoutEnvVar)
+	}
+	if timeout < 0 {
+		return 0, errors.Errorf("invalid %s: it must not be negative", PassphraseCacheTimeoutEnvVar)
+	}
+	return timeout, nil
+}
+
+// The keys unlocked by passphrases are cached by a passphrase agent: a process that holds them in memory, and that
+// hands them out over a socket that only the user may connect to.  They're never written to disk.  The agent exits
+// once the last of its keys expires, or when it's told to forget them.
+
+// passphraseAgentRequest is a request made of the passphrase agent.
+type passphraseAgentRequest struct {
+	Op      string        `json:"op"`                // the operation: "get", "put", "forget", or "ping".
+	ID      string        `json:"id,omitempty"`      // the ID of the key to get or put.
+	Key     []byte        `json:"key,omitempty"`     // the key to put.
+	Timeout time.Duration `json:"timeout,omitempty"` // how long to keep the key that's put.
+}
+
+// passphraseAgentResponse is the passphrase agent's response to a request.
+type passphraseAgentResponse struct {
+	Key []byte `json:"key,omitempty"` // the key that was asked for, if the agent has it.
+}
+
+// GetCachedPassphraseKey returns the key cached under the given ID, or nil if there is none or it has expired.
+func GetCachedPassphraseKey(id string) ([]byte, error) {
+	resp, err := callPassphraseAgent(passphraseAgentRequest{Op: "get", ID: id})
+	if err == ErrNoPassphraseAgent {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return resp.Key, nil
+}
+
+// StoreCachedPassphraseKey caches a key unlocked by a passphrase under the given ID, until the timeout passes.  If no
+// passphrase agent is running, ErrNoPassphraseAgent is returned, and the caller may start one with
+// `pulumi passphrase-agent` and try again.
+func StoreCachedPassphraseKey(id string, key []byte, timeout time.Duration) error {
+	_, err := callPassphraseAgent(passphraseAgentRequest{Op: "put", ID: id, Key: key, Timeout: timeout})
+	return err
+}
+
+// ForgetCachedPassphraseKeys forgets every key unlocked by a passphrase, so that each passphrase must be entered again
+// the next time it's needed.
+func ForgetCachedPassphraseKeys() error {
+	_, err := callPassphraseAgent(passphraseAgentRequest{Op: "forget"})
+	if err == ErrNoPassphraseAgent {
+		return nil
+	}
+	return err
+}
+
+// ServePassphraseAgent runs a passphrase agent until the last of its keys expires, or until it's told to forget them.
+func ServePassphraseAgent() error {
+	sock, err := getPassphraseAgentSocketPath()
+	if err != nil {
+		return err
+	}
+
+	// A socket left behind by an agent that has exited is removed; one that's still answered belongs to another agent.
+	if _, err = callPassphraseAgent(passphraseAgentRequest{Op: "ping"}); err == nil {
+		return errors.New("a passphrase agent is already running")
+	}
+	if err = os.Remove(sock); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		return errors.Wrap(err, "listening for passphrase agent requests")
+	}
+	defer contract.IgnoreClose(l)
+	if err = os.Chmod(sock, 0600); err != nil {
+		return err
+	}
+
+	agent := newPassphraseAgent(l)
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if agent.done() {
+				return nil
+			}
+			return errors.Wrap(err, "accepting a passphrase agent request")
+		}
+		go agent.serve(conn)
+	}
+}
+
+// getPassphraseAgentSocketPath returns the path to the passphrase agent's socket, which is kept with the credentials.
+func getPassphraseAgentSocketPath() (string, error) {
+	pulumiFolder, err := getCredsFolder()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(pulumiFolder, "passphrase-agent.sock"), nil
+}
+
+// callPassphraseAgent makes a request of the passphrase agent, returning ErrNoPassphraseAgent if none is running.
+func callPassphraseAgent(req passphraseAgentRequest) (passphraseAgentResponse, error) {
+	sock, err := getPassphraseAgentSocketPath()
+	if err != nil {
+		return passphraseAgentResponse{}, err
+	}
+	conn, err := net.DialTimeout("unix", sock, time.Second)
+	if err != nil {
+		logging.V(7).Infof("could not connect to the passphrase agent: %v", err)
+		return passphraseAgentResponse{}, ErrNoPassphraseAgent
+	}
+	defer contract.IgnoreClose(conn)
+	if err = conn.SetDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		return passphraseAgentResponse{}, err
+	}
+
+	if err = json.NewEncoder(conn).Encode(req); err != nil {
+		return passphraseAgentResponse{}, errors.Wrap(err, "sending a request to the passphrase agent")
+	}
+	var resp passphraseAgentResponse
+	if err = json.NewDecoder(conn).Decode(&resp); err != nil {
+		return passphraseAgentResponse{}, errors.Wrap(err, "reading the passphrase agent's response")
+	}
+	return resp, nil
+}
+
+// cachedPassphraseKey is a key unlocked by a passphrase, and the time until which it may be used without the
+// passphrase being entered again.
+type cachedPassphraseKey struct {
+	key     []byte
+	expires time.Time
+}
+
+// passphraseAgent holds the keys unlocked by passphrases, by the IDs that their users give them.
+type passphraseAgent struct {
+	lock       sync.Mutex
+	l          net.Listener
+	keys       map[string]cachedPassphraseKey
+	lastExpiry time.Time   // the time at which the last key expires.
+	timer      *time.Timer // fires at the last expiry.
+	stopped    bool        // true once the agent has stopped listening.
+}
+
+func newPassphraseAgent(l net.Listener) *passphraseAgent {
+	a := &passphraseAgent{l: l, keys: make(map[string]cachedPassphraseKey)}
+
+	// An agent that's given no keys at all doesn't linger.
+	a.lastExpiry = time.Now().Add(time.Minute)
+	a.timer = time.AfterFunc(time.Minute, a.expire)
+	return a
+}
+
+// serve answers a single request.
+func (a *passphraseAgent) serve(conn net.Conn) {
+	defer contract.IgnoreClose(conn)
+	if err := conn.SetDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		return
+	}
+
+	var req passphraseAgentRequest
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		return
+	}
+	var resp passphraseAgentResponse
+	switch req.Op {
+	case "get":
+		resp.Key = a.get(req.ID)
+	case "put":
+		a.put(req.ID, req.Key, req.Timeout)
+	case "forget":
+		a.forget()
+	}
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		logging.V(7).Infof("could not answer a passphrase agent request: %v", err)
+	}
+}
+
+func (a *passphraseAgent) get(id string) []byte {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	cached, has := a.keys[id]
+	if !has || !time.Now().Before(cached.expires) {
+		return nil
+	}
+	return cached.key
+}
+
+func (a *passphraseAgent) put(id string, key []byte, timeout time.Duration) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if timeout <= 0 || a.stopped {
+		return
+	}
+	expires := time.Now().Add(timeout)
+	a.keys[id] = cachedPassphraseKey{key: key, expires: expires}
+	if expires.After(a.lastExpiry) {
+		a.lastExpiry = expires
+		a.timer.Reset(time.Until(expires))
+	}
+}
+
+// forget forgets every key and stops the agent.
+func (a *passphraseAgent) forget() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.stopLocked()
+}
+
+// expire stops the agent once the last of its keys has expired.
+func (a *passphraseAgent) expire() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if !time.Now().Before(a.lastExpiry) {
+		a.stopLocked()
+	}
+}
+
+func (a *passphraseAgent) stopLocked() {
+	a.keys = make(map[string]cachedPassphraseKey)
+	a.timer.Stop()
+	if !a.stopped {
+		a.stopped = true
+		contract.IgnoreClose(a.l)
+	}
+}
+
+// done returns true if the agent has been stopped.
+func (a *passphraseAgent) done() bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.stopped
+}
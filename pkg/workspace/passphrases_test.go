@@ -0,0 +1,99 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPassphraseCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pulumi-creds")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	old := os.Getenv(PulumiCredentialsPathEnvVar)
+	defer os.Setenv(PulumiCredentialsPathEnvVar, old)
+	assert.NoError(t, os.Setenv(PulumiCredentialsPathEnvVar, dir))
+
+	// Without an agent, nothing is cached.
+	key, err := GetCachedPassphraseKey("dev")
+	assert.NoError(t, err)
+	assert.Nil(t, key)
+	assert.Equal(t, ErrNoPassphraseAgent, StoreCachedPassphraseKey("dev", []byte("0123456789"), time.Hour))
+	assert.NoError(t, ForgetCachedPassphraseKeys())
+
+	served := make(chan error)
+	go func() { served <- ServePassphraseAgent() }()
+	for i := 0; i < 100; i++ {
+		if _, err = callPassphraseAgent(passphraseAgentRequest{Op: "ping"}); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.NoError(t, err)
+
+	assert.NoError(t, StoreCachedPassphraseKey("dev", []byte("0123456789"), time.Hour))
+	key, err = GetCachedPassphraseKey("dev")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("0123456789"), key)
+
+	// Keys are forgotten once their timeouts pass.
+	assert.NoError(t, StoreCachedPassphraseKey("prod", []byte("9876543210"), time.Nanosecond))
+	time.Sleep(time.Millisecond)
+	key, err = GetCachedPassphraseKey("prod")
+	assert.NoError(t, err)
+	assert.Nil(t, key)
+
+	// Nothing but the socket is written to disk.
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	if assert.Len(t, files, 1) {
+		assert.Equal(t, "passphrase-agent.sock", files[0].Name())
+	}
+
+	// Forgetting the keys forgets them all, and stops the agent.
+	assert.NoError(t, ForgetCachedPassphraseKeys())
+	assert.NoError(t, <-served)
+	key, err = GetCachedPassphraseKey("dev")
+	assert.NoError(t, err)
+	assert.Nil(t, key)
+}
+
+func TestPassphraseCacheTimeout(t *testing.T) {
+	old := os.Getenv(PassphraseCacheTimeoutEnvVar)
+	defer os.Setenv(PassphraseCacheTimeoutEnvVar, old)
+
+	// Keys aren't cached unless a timeout is set.
+	assert.NoError(t, os.Setenv(PassphraseCacheTimeoutEnvVar, ""))
+	timeout, err := GetPassphraseCacheTimeout()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), timeout)
+
+	assert.NoError(t, os.Setenv(PassphraseCacheTimeoutEnvVar, "1h"))
+	timeout, err = GetPassphraseCacheTimeout()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour, timeout)
+
+	assert.NoError(t, os.Setenv(PassphraseCacheTimeoutEnvVar, "-1m"))
+	_, err = GetPassphraseCacheTimeout()
+	assert.Error(t, err)
+	assert.NoError(t, os.Setenv(PassphraseCacheTimeoutEnvVar, "forever"))
+	_, err = GetPassphraseCacheTimeout()
+	assert.Error(t, err)
+}
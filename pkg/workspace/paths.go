@@ -23,6 +23,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/pulumi/pulumi/pkg/encoding"
+	"github.com/pulumi/pulumi/pkg/resource/config"
 	"github.com/pulumi/pulumi/pkg/tokens"
 	"github.com/pulumi/pulumi/pkg/util/fsutil"
 )
@@ -31,10 +32,14 @@ const (
 	BackupDir      = "backups"    // the name of the folder where backup stack information is stored.
 	BookkeepingDir = ".pulumi"    // the name of our bookeeping folder, we store state here (like .git for git).
 	ConfigDir      = "config"     // the name of the folder that holds local configuration information.
+	DurationsDir   = "durations"  // the name of the directory that holds the operation durations of cloud stacks.
 	GitDir         = ".git"       // the name of the folder git uses to store information.
 	HistoryDir     = "history"    // the name of the directory that holds historical information for projects.
+	JournalDir     = "journal"    // the name of the directory that holds the changes not yet saved to checkpoints.
+	LockDir        = "locks"      // the name of the directory that holds the locks of stacks being updated.
 	PluginDir      = "plugins"    // the name of the directory containing plugins.
 	StackDir       = "stacks"     // the name of the directory that holds stack information for projects.
+	TagDir         = "tags"       // the name of the directory that holds the tags of stacks.
 	TemplateDir    = "templates"  // the name of the directory containing templates.
 	WorkspaceDir   = "workspaces" // the name of the directory that holds workspace information for projects.
 
@@ -67,8 +72,13 @@ func DetectProjectStackPath(stackName tokens.QName) (string, error) {
 		return "", err
 	}
 
+	return ProjectStackPath(proj, projPath, stackName), nil
+}
+
+// ProjectStackPath returns the name of the file that holds the stack specific settings of the project at projPath.
+func ProjectStackPath(proj *Project, projPath string, stackName tokens.QName) string {
 	return filepath.Join(filepath.Dir(projPath), proj.Config, fmt.Sprintf("%s.%s%s", ProjectFile, qnameFileName(stackName),
-		filepath.Ext(projPath))), nil
+		filepath.Ext(projPath)))
 }
 
 // DetectProjectPathFrom locates the closest project from the given path, searching "upwards" in the directory
@@ -94,6 +104,23 @@ func DetectProjectStack(stackName tokens.QName) (*ProjectStack, error) {
 	return LoadProjectStack(path)
 }
 
+// DetectStackConfig returns the configuration of a stack of the closest project, which inherits the project's
+// `stackConfig`, along with where each of its values is set.  Unlike DetectProjectStack, whose configuration holds
+// only the values set for the stack itself, this is the configuration with which the stack is deployed.
+func DetectStackConfig(stackName tokens.QName) (config.Map, map[config.Key]ConfigSource, error) {
+	proj, projPath, err := DetectProjectAndPath()
+	if err != nil {
+		return nil, nil, err
+	}
+	ps, err := LoadProjectStack(ProjectStackPath(proj, projPath, stackName))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg, sources := proj.MergeStackConfig(ps)
+	return cfg, sources, nil
+}
+
 // DetectProjectAndPath loads the closest package from the current working directory, or an error if not found.  It
 // also returns the path where the package was found.
 func DetectProjectAndPath() (*Project, string, error) {
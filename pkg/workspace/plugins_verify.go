@@ -0,0 +1,170 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/pulumi/pulumi/pkg/util/contract"
+)
+
+// PluginVerificationFile is the name of the file, in a plugin's directory, that records how the plugin's tarball was
+// verified when it was installed.
+const PluginVerificationFile = ".pulumi-verification.json"
+
+// PluginVerification records how a plugin's tarball was verified when it was installed.
+type PluginVerification struct {
+	Source            string    `json:"source"`            // where the tarball came from.
+	SHA256            string    `json:"sha256"`            // the SHA256 checksum of the tarball, in hex.
+	ChecksumVerified  bool      `json:"checksumVerified"`  // true if the checksum matched a published one.
+	SignatureVerified bool      `json:"signatureVerified"` // true if the checksum's signature was verified.
+	Time              time.Time `json:"time"`              // when the plugin was installed.
+}
+
+// ParsePluginChecksum parses a published SHA256 checksum, which is either the hex checksum on its own or a line in
+// the format written by `sha256sum`, the checksum followed by the name of the file.
+func ParsePluginChecksum(text string) (string, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return "", errors.New("checksum is empty")
+	}
+	checksum := strings.ToLower(fields[0])
+	if b, err := hex.DecodeString(checksum); err != nil || len(b) != sha256.Size {
+		return "", errors.Errorf("'%s' is not a SHA256 checksum", fields[0])
+	}
+	return checksum, nil
+}
+
+// ParsePluginPublicKey parses a base64-encoded ed25519 public key used to verify plugins' signatures.
+func ParsePluginPublicKey(s string) (ed25519.PublicKey, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding public key")
+	}
+	if len(b) != ed25519.PublicKeySize {
+		return nil, errors.Errorf("public key is %d bytes long; expected %d", len(b), ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(b), nil
+}
+
+// VerifyPluginTarball reads a plugin's tarball, and checks it against the expected SHA256 checksum, if checksum isn't
+// empty, and checks that signature, a base64-encoded ed25519 signature of the tarball's SHA256 digest, was made with
+// the given key, if key isn't nil.  The tarball is spooled to a temporary file, so that nothing is extracted before it
+// has been verified; the returned reader reads the verified tarball and removes the temporary file when closed.
+func VerifyPluginTarball(tarball io.ReadCloser, checksum string, signature []byte,
+	key ed25519.PublicKey) (io.ReadCloser, PluginVerification, error) {
+
+	defer contract.IgnoreClose(tarball)
+
+	temp, err := ioutil.TempFile("", "pulumi-plugin-")
+	if err != nil {
+		return nil, PluginVerification{}, err
+	}
+	result := &tempFileReadCloser{File: temp}
+	fail := func(err error) (io.ReadCloser, PluginVerification, error) {
+		contract.IgnoreClose(result)
+		return nil, PluginVerification{}, err
+	}
+
+	hash := sha256.New()
+	if _, err = io.Copy(io.MultiWriter(temp, hash), tarball); err != nil {
+		return fail(errors.Wrap(err, "reading plugin tarball"))
+	}
+	digest := hash.Sum(nil)
+	v := PluginVerification{SHA256: hex.EncodeToString(digest), Time: time.Now()}
+
+	if checksum != "" {
+		if !strings.EqualFold(checksum, v.SHA256) {
+			return fail(errors.Errorf("plugin checksum mismatch: expected %s, got %s", checksum, v.SHA256))
+		}
+		v.ChecksumVerified = true
+	}
+
+	if key != nil {
+		if len(signature) == 0 {
+			return fail(errors.New("plugin is not signed"))
+		}
+		sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+		if err != nil {
+			return fail(errors.Wrap(err, "decoding plugin signature"))
+		}
+		if !ed25519.Verify(key, digest, sig) {
+			return fail(errors.New("plugin signature is not valid for the given public key"))
+		}
+		v.SignatureVerified = true
+	}
+
+	if _, err = temp.Seek(0, io.SeekStart); err != nil {
+		return fail(err)
+	}
+	return result, v, nil
+}
+
+// SaveVerification records how the plugin's tarball was verified in the plugin's directory.
+func (info PluginInfo) SaveVerification(v PluginVerification) error {
+	dir, err := info.DirPath()
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, PluginVerificationFile), b, 0600)
+}
+
+// LoadVerification returns how the plugin's tarball was verified when it was installed, or nil if that wasn't
+// recorded, as is the case for plugins installed by older versions of the CLI.
+func (info PluginInfo) LoadVerification() (*PluginVerification, error) {
+	dir, err := info.DirPath()
+	if err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, PluginVerificationFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var v PluginVerification
+	if err = json.Unmarshal(b, &v); err != nil {
+		return nil, errors.Wrapf(err, "reading %s", PluginVerificationFile)
+	}
+	return &v, nil
+}
+
+// tempFileReadCloser is a temporary file that is removed when it is closed.
+type tempFileReadCloser struct {
+	*os.File
+}
+
+func (f *tempFileReadCloser) Close() error {
+	err := f.File.Close()
+	contract.IgnoreError(os.Remove(f.File.Name()))
+	return err
+}
@@ -0,0 +1,91 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestParsePluginChecksum(t *testing.T) {
+	sum := sha256.Sum256([]byte("plugin"))
+	checksum := hex.EncodeToString(sum[:])
+
+	c, err := ParsePluginChecksum(checksum + "\n")
+	assert.NoError(t, err)
+	assert.Equal(t, checksum, c)
+
+	c, err = ParsePluginChecksum(checksum + "  pulumi-resource-aws-v0.14.0-linux-amd64.tar.gz\n")
+	assert.NoError(t, err)
+	assert.Equal(t, checksum, c)
+
+	_, err = ParsePluginChecksum("")
+	assert.Error(t, err)
+	_, err = ParsePluginChecksum("abc123")
+	assert.Error(t, err)
+}
+
+func TestVerifyPluginTarball(t *testing.T) {
+	contents := []byte("not really a tarball")
+	sum := sha256.Sum256(contents)
+	checksum := hex.EncodeToString(sum[:])
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+	signature := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, sum[:])))
+
+	verify := func(contents []byte, checksum string, signature []byte, key ed25519.PublicKey) (PluginVerification,
+		error) {
+		r, v, err := VerifyPluginTarball(ioutil.NopCloser(bytes.NewReader(contents)), checksum, signature, key)
+		if err != nil {
+			return v, err
+		}
+		defer func() { assert.NoError(t, r.Close()) }()
+		b, err := ioutil.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Equal(t, contents, b)
+		return v, nil
+	}
+
+	v, err := verify(contents, checksum, signature, pub)
+	assert.NoError(t, err)
+	assert.Equal(t, checksum, v.SHA256)
+	assert.True(t, v.ChecksumVerified)
+	assert.True(t, v.SignatureVerified)
+
+	v, err = verify(contents, "", nil, nil)
+	assert.NoError(t, err)
+	assert.False(t, v.ChecksumVerified)
+	assert.False(t, v.SignatureVerified)
+
+	// A tampered tarball fails both its checksum and its signature.
+	tampered := append([]byte{}, contents...)
+	tampered[0] = 'N'
+	_, err = verify(tampered, checksum, nil, nil)
+	assert.Error(t, err)
+	_, err = verify(tampered, "", signature, pub)
+	assert.Error(t, err)
+
+	// A key requires a signature.
+	_, err = verify(contents, checksum, nil, pub)
+	assert.Error(t, err)
+}
@@ -18,6 +18,8 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/pulumi/pulumi/pkg/resource/config"
 	"github.com/pulumi/pulumi/pkg/util/contract"
@@ -55,6 +57,77 @@ type Project struct {
 	NoDefaultIgnores *bool  `json:"nodefaultignores,omitempty" yaml:"nodefaultignores,omitempty"` // true if we should only respect .pulumiignore when archiving
 
 	Config string `json:"config,omitempty" yaml:"config,omitempty"` // where to store Pulumi.<stack-name>.yaml files, this is combined with the folder Pulumi.yaml is in.
+
+	StackConfig config.Map `json:"stackConfig,omitempty" yaml:"stackConfig,omitempty"` // configuration inherited by every stack, unless a stack sets the same key itself.
+
+	ConfigEnv []ConfigEnvVar `json:"configEnv,omitempty" yaml:"configEnv,omitempty"` // configuration keys whose values are read from environment variables, when they are set.
+
+	ConfigSchema map[string]ConfigSchemaEntry `json:"configSchema,omitempty" yaml:"configSchema,omitempty"` // the configuration keys the program reads, and the values they may have.
+
+	Transformations []Transformation `json:"transformations,omitempty" yaml:"transformations,omitempty"` // rewrites applied by the engine to every matching resource.
+
+	BlueGreen []BlueGreenGroup `json:"blueGreen,omitempty" yaml:"blueGreen,omitempty"` // groups of resources replaced in a create-then-swap fashion.
+
+	ProviderParallelism map[string]int `json:"providerParallelism,omitempty" yaml:"providerParallelism,omitempty"` // the most resource operations each provider package (e.g. `aws`) may have outstanding at once.
+}
+
+// Transformation rewrites the desired state of each resource whose type matches one of its patterns, after the program
+// registers the resource but before the engine plans its deployment.  This lets defaults be enforced for a project no
+// matter what language its program is written in.
+//
+// A transformation can't override the provider that manages a resource: every resource is managed by the provider for
+// its type's package, whose settings come from the stack's configuration, so that is where they should be enforced.
+// nolint: lll
+type Transformation struct {
+	Types    []string               `json:"types,omitempty" yaml:"types,omitempty"`       // type patterns, such as `aws:s3/*`, to match; if empty, all custom resources match.
+	Set      map[string]interface{} `json:"set,omitempty" yaml:"set,omitempty"`           // inputs to set, overriding any values given by the program.
+	Defaults map[string]interface{} `json:"defaults,omitempty" yaml:"defaults,omitempty"` // inputs to set only if the program doesn't set them.
+	Tags     map[string]interface{} `json:"tags,omitempty" yaml:"tags,omitempty"`         // tags to merge into the resource's `tags` input.
+	Protect  *bool                  `json:"protect,omitempty" yaml:"protect,omitempty"`   // if set, whether the resource is protected from deletion.
+}
+
+// ConfigEnvVar maps a configuration key to an environment variable, whose value, when it's set, is used for the key in
+// every update, instead of the one in the stack's settings.  This keeps values such as the short-lived credentials
+// of CI jobs out of the settings files.
+// nolint: lll
+type ConfigEnvVar struct {
+	Key    string `json:"key" yaml:"key"`                           // the configuration key, such as `aws:region`; keys without a namespace are the project's.
+	Env    string `json:"env" yaml:"env"`                           // the name of the environment variable.
+	Secret bool   `json:"secret,omitempty" yaml:"secret,omitempty"` // true if the value is a secret, to be encrypted and hidden from output.
+}
+
+// ConfigKey returns the configuration key whose value is read from the variable, given the project it belongs to.
+func (v ConfigEnvVar) ConfigKey(proj *Project) (config.Key, error) {
+	return proj.configKey(v.Key)
+}
+
+// configKey parses a configuration key given in Pulumi.yaml, where keys without a namespace are the project's.
+func (proj *Project) configKey(s string) (config.Key, error) {
+	if !strings.Contains(s, ":") {
+		return config.MustMakeKey(string(proj.Name), s), nil
+	}
+	return config.ParseKey(s)
+}
+
+// ParseConfigEnvVar parses a mapping of a configuration key to an environment variable given as `<key>=<variable>`.
+func ParseConfigEnvVar(s string, secret bool) (ConfigEnvVar, error) {
+	eq := strings.Index(s, "=")
+	if eq <= 0 || eq == len(s)-1 {
+		return ConfigEnvVar{}, errors.Errorf("'%s' must be of the form <key>=<variable>", s)
+	}
+	return ConfigEnvVar{Key: s[:eq], Env: s[eq+1:], Secret: secret}, nil
+}
+
+// BlueGreenGroup is a set of resources, identified by their tags, that are changed in a blue/green fashion: rather
+// than being updated in place, a member that changes is always replaced, by first creating the new resource.  Only once
+// every replacement has been created and the group's readiness check passes are the old resources deleted.  A member
+// whose provider requires it to be deleted before it is replaced is the exception: it is deleted first, with a warning.
+// nolint: lll
+type BlueGreenGroup struct {
+	Name             string            `json:"name" yaml:"name"`                                             // the group's name, shown in previews.
+	Tags             map[string]string `json:"tags" yaml:"tags"`                                             // the tags that a resource must have, in its `tags` input, to be a member.
+	ReadinessCheck   string            `json:"readinessCheck,omitempty" yaml:"readinessCheck,omitempty"`     // a shell command that exits successfully once the new resources are ready.
+	ReadinessTimeout string            `json:"readinessTimeout,omitempty" yaml:"readinessTimeout,omitempty"` // how long to keep retrying the readiness check (e.g. `10m`); five minutes by default.
 }
 
 func (proj *Project) Validate() error {
@@ -64,6 +137,40 @@ func (proj *Project) Validate() error {
 	if proj.Runtime == "" {
 		return errors.New("project is missing a 'runtime' attribute")
 	}
+	for key, v := range proj.StackConfig {
+		if v.Secure() {
+			return errors.Errorf("stackConfig value '%s' is a secret; secrets may only be set for a single stack", key)
+		}
+	}
+	for _, v := range proj.ConfigEnv {
+		if v.Key == "" || v.Env == "" {
+			return errors.New("configEnv entries must have both a 'key' and an 'env' attribute")
+		}
+		if _, err := v.ConfigKey(proj); err != nil {
+			return errors.Wrapf(err, "configEnv entry for '%s'", v.Env)
+		}
+	}
+	if err := proj.validateConfigSchema(); err != nil {
+		return err
+	}
+	for pkg, n := range proj.ProviderParallelism {
+		if n <= 0 {
+			return errors.Errorf("providerParallelism for '%s' must be positive; got %d", pkg, n)
+		}
+	}
+	for _, group := range proj.BlueGreen {
+		if group.Name == "" {
+			return errors.New("blue/green group is missing a 'name' attribute")
+		}
+		if len(group.Tags) == 0 {
+			return errors.Errorf("blue/green group '%s' is missing a 'tags' attribute", group.Name)
+		}
+		if group.ReadinessTimeout != "" {
+			if _, err := time.ParseDuration(group.ReadinessTimeout); err != nil {
+				return errors.Errorf("blue/green group '%s' has an invalid readinessTimeout: %v", group.Name, err)
+			}
+		}
+	}
 	return nil
 }
 
@@ -75,6 +182,37 @@ func (proj *Project) UseDefaultIgnores() bool {
 	return !(*proj.NoDefaultIgnores)
 }
 
+// ConfigSource is where a value in a stack's configuration is set.
+type ConfigSource int
+
+const (
+	// ConfigSourceStack is the stack's own settings file, Pulumi.<stack-name>.yaml.
+	ConfigSourceStack ConfigSource = iota
+	// ConfigSourceProject is the project's `stackConfig`, in Pulumi.yaml.
+	ConfigSourceProject
+)
+
+func (s ConfigSource) String() string {
+	if s == ConfigSourceProject {
+		return "project"
+	}
+	return "stack"
+}
+
+// MergeStackConfig returns the configuration of a stack with the given settings: the project's `stackConfig`, with any
+// keys that the stack sets itself overridden.  It also returns where each value is set.
+func (proj *Project) MergeStackConfig(ps *ProjectStack) (config.Map, map[config.Key]ConfigSource) {
+	cfg := make(config.Map)
+	sources := make(map[config.Key]ConfigSource)
+	for key, v := range proj.StackConfig {
+		cfg[key], sources[key] = v, ConfigSourceProject
+	}
+	for key, v := range ps.Config {
+		cfg[key], sources[key] = v, ConfigSourceStack
+	}
+	return cfg, sources
+}
+
 // Save writes a project definition to a file.
 func (proj *Project) Save(path string) error {
 	contract.Require(path != "", "path")
@@ -97,8 +235,21 @@ func (proj *Project) Save(path string) error {
 // ProjectStack holds stack specific information about a project.
 // nolint: lll
 type ProjectStack struct {
-	EncryptionSalt string     `json:"encryptionsalt,omitempty" yaml:"encryptionsalt,omitempty"` // base64 encoded encryption salt.
-	Config         config.Map `json:"config,omitempty" yaml:"config,omitempty"`                 // optional config.
+	SecretsProvider string                 `json:"secretsprovider,omitempty" yaml:"secretsprovider,omitempty"` // the URL of the key that wraps EncryptedKey, if any.
+	EncryptedKey    string                 `json:"encryptedkey,omitempty" yaml:"encryptedkey,omitempty"`       // base64 encoded data key, wrapped by SecretsProvider.
+	EncryptionSalt  string                 `json:"encryptionsalt,omitempty" yaml:"encryptionsalt,omitempty"`   // base64 encoded encryption salt.
+	Config          config.Map             `json:"config,omitempty" yaml:"config,omitempty"`                   // optional config.
+	SecretsKeys     map[string]*SecretsKey `json:"secretskeys,omitempty" yaml:"secretskeys,omitempty"`         // further keys that encrypt particular secrets instead, by name.
+}
+
+// SecretsKey is a key in a key management service that encrypts some of a stack's secrets, instead of the key given by
+// the stack's secrets provider, so that reading them takes permission to use another key.
+// nolint: lll
+type SecretsKey struct {
+	Provider     string   `json:"provider" yaml:"provider"`                             // the URL of the key that wraps EncryptedKey.
+	EncryptedKey string   `json:"encryptedkey,omitempty" yaml:"encryptedkey,omitempty"` // base64 encoded data key, wrapped by Provider.
+	Config       []string `json:"config,omitempty" yaml:"config,omitempty"`             // the configuration keys whose values it encrypts; a trailing `*` matches any suffix.
+	State        bool     `json:"state,omitempty" yaml:"state,omitempty"`               // true if it encrypts the stack's state, when the state is encrypted.
 }
 
 // Save writes a project definition to a file.
@@ -0,0 +1,85 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/resource/config"
+)
+
+func TestMergeStackConfig(t *testing.T) {
+	region := config.MustMakeKey("aws", "region")
+	size := config.MustMakeKey("app", "size")
+	name := config.MustMakeKey("app", "name")
+
+	proj := &Project{Name: "app", Runtime: "nodejs", StackConfig: config.Map{
+		region: config.NewValue("us-west-2"),
+		size:   config.NewValue("small"),
+	}}
+	assert.NoError(t, proj.Validate())
+
+	ps := &ProjectStack{Config: config.Map{
+		size: config.NewValue("large"),
+		name: config.NewValue("prod"),
+	}}
+	cfg, sources := proj.MergeStackConfig(ps)
+	assert.Equal(t, config.Map{
+		region: config.NewValue("us-west-2"),
+		size:   config.NewValue("large"),
+		name:   config.NewValue("prod"),
+	}, cfg)
+	assert.Equal(t, map[config.Key]ConfigSource{
+		region: ConfigSourceProject,
+		size:   ConfigSourceStack,
+		name:   ConfigSourceStack,
+	}, sources)
+
+	// The stack's own settings are left as they are.
+	assert.Len(t, ps.Config, 2)
+
+	// Secrets are encrypted with a key of each stack's own, so the project can't set any.
+	proj.StackConfig[name] = config.NewSecureValue("ciphertext")
+	assert.Error(t, proj.Validate())
+}
+
+func TestConfigEnvVar(t *testing.T) {
+	v, err := ParseConfigEnvVar("aws:accessKey=AWS_ACCESS_KEY_ID", true)
+	assert.NoError(t, err)
+	assert.Equal(t, ConfigEnvVar{Key: "aws:accessKey", Env: "AWS_ACCESS_KEY_ID", Secret: true}, v)
+
+	for _, s := range []string{"aws:accessKey", "=AWS_ACCESS_KEY_ID", "aws:accessKey="} {
+		_, err = ParseConfigEnvVar(s, false)
+		assert.Error(t, err, s)
+	}
+
+	// Keys without a namespace belong to the project.
+	proj := &Project{Name: "app", Runtime: "nodejs", ConfigEnv: []ConfigEnvVar{
+		{Key: "token", Env: "APP_TOKEN"},
+		{Key: "aws:region", Env: "AWS_REGION"},
+	}}
+	assert.NoError(t, proj.Validate())
+	key, err := proj.ConfigEnv[0].ConfigKey(proj)
+	assert.NoError(t, err)
+	assert.Equal(t, config.MustMakeKey("app", "token"), key)
+	key, err = proj.ConfigEnv[1].ConfigKey(proj)
+	assert.NoError(t, err)
+	assert.Equal(t, config.MustMakeKey("aws", "region"), key)
+
+	proj.ConfigEnv = append(proj.ConfigEnv, ConfigEnvVar{Key: "token"})
+	assert.Error(t, proj.Validate())
+}
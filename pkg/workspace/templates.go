@@ -17,12 +17,14 @@ package workspace
 import (
 	"archive/tar"
 	"compress/gzip"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"os/user"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
 
@@ -50,8 +52,66 @@ type Template struct {
 	Description string `json:"description" yaml:"description"`
 	// Optional bool which determines whether dependencies should be installed after project creation.
 	InstallDependencies bool `json:"installdependencies" yaml:"installdependencies"`
-	// Optional default config values.
-	Config map[config.Key]string `json:"config" yaml:"config"`
+	// Optional config values to prompt for, and their defaults.
+	Config map[config.Key]TemplateConfigValue `json:"config" yaml:"config"`
+	// Optional shell commands to run in the new project's directory once it has been created.
+	PostScaffold []string `json:"postScaffold,omitempty" yaml:"postScaffold,omitempty"`
+
+	// The directory holding the template's files.
+	Dir string `json:"-" yaml:"-"`
+}
+
+// TemplateConfigValue describes a config value that `pulumi new` prompts for.  In a manifest, a value may be given as
+// just its default, or as an object with any of these properties.
+type TemplateConfigValue struct {
+	// Optional description of the value, shown when prompting for it.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Optional default value.
+	Default string `json:"default,omitempty" yaml:"default,omitempty"`
+	// Optional bool which determines whether the value is encrypted.
+	Secret bool `json:"secret,omitempty" yaml:"secret,omitempty"`
+	// Optional bool which determines whether the value must be non-empty.
+	Required bool `json:"required,omitempty" yaml:"required,omitempty"`
+	// Optional regular expression that the whole value must match.
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+}
+
+// templateConfigValue is TemplateConfigValue without its custom unmarshalers, to which it unmarshals objects.
+type templateConfigValue TemplateConfigValue
+
+func (v *TemplateConfigValue) UnmarshalJSON(b []byte) error {
+	var def string
+	if err := json.Unmarshal(b, &def); err == nil {
+		*v = TemplateConfigValue{Default: def}
+		return nil
+	}
+	return json.Unmarshal(b, (*templateConfigValue)(v))
+}
+
+func (v *TemplateConfigValue) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var def string
+	if err := unmarshal(&def); err == nil {
+		*v = TemplateConfigValue{Default: def}
+		return nil
+	}
+	return unmarshal((*templateConfigValue)(v))
+}
+
+// Validate returns an error if the given value isn't valid for this config value.
+func (v TemplateConfigValue) Validate(value string) error {
+	if v.Required && value == "" {
+		return errors.New("a value is required")
+	}
+	if v.Pattern != "" && value != "" {
+		re, err := regexp.Compile("^(?:" + v.Pattern + ")$")
+		if err != nil {
+			return errors.Wrapf(err, "the template's pattern '%s' is invalid", v.Pattern)
+		}
+		if !re.MatchString(value) {
+			return errors.Errorf("the value must match the pattern '%s'", v.Pattern)
+		}
+	}
+	return nil
 }
 
 // LoadLocalTemplate returns a local template.
@@ -61,12 +121,23 @@ func LoadLocalTemplate(name string) (Template, error) {
 		return Template{}, err
 	}
 
+	template, err := LoadTemplate(templateDir)
+	if err != nil {
+		return Template{}, err
+	}
+
+	template.Name = name
+	return template, nil
+}
+
+// LoadTemplate returns the template whose files are in the given directory.  It is named after the directory.
+func LoadTemplate(templateDir string) (Template, error) {
 	info, err := os.Stat(templateDir)
 	if err != nil {
 		return Template{}, err
 	}
 	if !info.IsDir() {
-		return Template{}, errors.Errorf("template '%s' in %s is not a directory", name, templateDir)
+		return Template{}, errors.Errorf("template in %s is not a directory", templateDir)
 	}
 
 	// Read the description from the manifest (if it exists).
@@ -75,7 +146,8 @@ func LoadLocalTemplate(name string) (Template, error) {
 		return Template{}, err
 	}
 
-	template.Name = name
+	template.Name = filepath.Base(templateDir)
+	template.Dir = templateDir
 	return template, nil
 }
 
@@ -145,9 +217,8 @@ func InstallTemplate(name string, tarball io.ReadCloser) error {
 // CopyTemplateFilesDryRun does a dry run of copying a template to a destination directory,
 // to ensure it won't overwrite any files.
 func (template Template) CopyTemplateFilesDryRun(destDir string) error {
-	var err error
-	var sourceDir string
-	if sourceDir, err = GetTemplateDir(template.Name); err != nil {
+	sourceDir, err := template.sourceDir()
+	if err != nil {
 		return err
 	}
 
@@ -172,7 +243,7 @@ func (template Template) CopyTemplateFilesDryRun(destDir string) error {
 func (template Template) CopyTemplateFiles(
 	destDir string, force bool, projectName string, projectDescription string) error {
 
-	sourceDir, err := GetTemplateDir(template.Name)
+	sourceDir, err := template.sourceDir()
 	if err != nil {
 		return err
 	}
@@ -208,6 +279,15 @@ func (template Template) CopyTemplateFiles(
 	})
 }
 
+// sourceDir returns the directory holding the template's files, which, unless the template was loaded from elsewhere,
+// is in the local template cache.
+func (template Template) sourceDir() (string, error) {
+	if template.Dir != "" {
+		return template.Dir, nil
+	}
+	return GetTemplateDir(template.Name)
+}
+
 // GetTemplateDir returns the directory in which templates on the current machine are stored.
 func GetTemplateDir(name string) (string, error) {
 	u, err := user.Current()
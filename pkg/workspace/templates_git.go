@@ -0,0 +1,152 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/util/contract"
+	"github.com/pulumi/pulumi/pkg/util/logging"
+)
+
+// IsTemplateURL returns true if the given template name is instead the URL of a git repository holding a template.
+func IsTemplateURL(s string) bool {
+	for _, prefix := range []string{"https://", "http://", "ssh://", "git://", "file://", "git@"} {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTemplateURL splits a template URL of the form `<repository>[//<subdirectory>][#<ref>]` into the URL of the git
+// repository, the subdirectory of the repository holding the template (which is empty if the template is at the root),
+// and the branch, tag, or commit to use (which is empty for the repository's default branch).
+func ParseTemplateURL(s string) (string, string, string, error) {
+	repo, ref := s, ""
+	if hash := strings.LastIndex(repo, "#"); hash >= 0 {
+		repo, ref = repo[:hash], repo[hash+1:]
+		if ref == "" {
+			return "", "", "", errors.Errorf("template URL '%s' has an empty ref", s)
+		}
+		// A ref that begins with a dash would be taken by git as an option.
+		if strings.HasPrefix(ref, "-") {
+			return "", "", "", errors.Errorf("template URL '%s' has an invalid ref '%s'", s, ref)
+		}
+	}
+
+	// Skip past the scheme's `//`, if there is one, to find the one that introduces the subdirectory.
+	start := 0
+	if scheme := strings.Index(repo, "://"); scheme >= 0 {
+		start = scheme + len("://")
+	}
+	subdir := ""
+	if sep := strings.Index(repo[start:], "//"); sep >= 0 {
+		repo, subdir = repo[:start+sep], strings.Trim(repo[start+sep+2:], "/")
+		if subdir == "" {
+			return "", "", "", errors.Errorf("template URL '%s' has an empty subdirectory", s)
+		}
+	}
+	if repo == "" {
+		return "", "", "", errors.Errorf("template URL '%s' has no repository", s)
+	}
+
+	return repo, subdir, ref, nil
+}
+
+// RetrieveGitTemplate clones the git repository named by a template URL, as described by ParseTemplateURL, into a new
+// temporary directory and loads the template from it.  It returns the template and the temporary directory, which the
+// caller should remove once it is done with the template.
+func RetrieveGitTemplate(templateURL string) (Template, string, error) {
+	repo, subdir, ref, err := ParseTemplateURL(templateURL)
+	if err != nil {
+		return Template{}, "", err
+	}
+
+	tempDir, err := ioutil.TempDir("", "pulumi-template-")
+	if err != nil {
+		return Template{}, "", err
+	}
+	cloneDir := filepath.Join(tempDir, "repo")
+
+	// Make a shallow clone when the default branch is wanted.  A ref may name a commit, which can't be cloned
+	// directly, so in that case clone the whole repository, resolve the ref to a commit, and check that out.  The
+	// ref is only ever passed to git as part of a revision expression, so that it can't be mistaken for an option.
+	if ref == "" {
+		_, err = runGit("", "clone", "--depth", "1", "--", repo, cloneDir)
+	} else if _, err = runGit("", "clone", "--", repo, cloneDir); err == nil {
+		var commit string
+		if commit, err = runGit(cloneDir, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err == nil {
+			_, err = runGit(cloneDir, "checkout", "--quiet", commit)
+		} else {
+			err = errors.Errorf("'%s' is not a branch, tag, or commit of the repository", ref)
+		}
+	}
+	if err != nil {
+		contract.IgnoreError(os.RemoveAll(tempDir))
+		return Template{}, "", errors.Wrapf(err, "retrieving template from %s", templateURL)
+	}
+
+	templateDir := filepath.Join(cloneDir, filepath.FromSlash(subdir))
+	if templateDir != cloneDir && !strings.HasPrefix(templateDir, cloneDir+string(filepath.Separator)) {
+		contract.IgnoreError(os.RemoveAll(tempDir))
+		return Template{}, "", errors.Errorf("template subdirectory '%s' is outside the repository", subdir)
+	}
+
+	// Don't copy the repository's metadata into new projects.
+	if err = os.RemoveAll(filepath.Join(cloneDir, ".git")); err != nil {
+		contract.IgnoreError(os.RemoveAll(tempDir))
+		return Template{}, "", err
+	}
+
+	template, err := LoadTemplate(templateDir)
+	if err != nil {
+		contract.IgnoreError(os.RemoveAll(tempDir))
+		return Template{}, "", errors.Wrapf(err, "loading template from %s", templateURL)
+	}
+	if subdir == "" {
+		template.Name = strings.TrimSuffix(filepath.Base(repo), ".git")
+	}
+	return template, tempDir, nil
+}
+
+// runGit runs git with the given arguments in the given directory, returning what it writes to stdout, or an error
+// that includes git's output if it fails.
+func runGit(dir string, args ...string) (string, error) {
+	gitBin, err := exec.LookPath("git")
+	if err != nil {
+		return "", errors.Wrap(err, "git is required to use templates from git repositories")
+	}
+
+	logging.V(7).Infof("running git %s", strings.Join(args, " "))
+	// nolint: gas
+	cmd := exec.Command(gitBin, args...)
+	cmd.Dir = dir
+	var stdout, output bytes.Buffer
+	cmd.Stdout = io.MultiWriter(&stdout, &output)
+	cmd.Stderr = &output
+	if err = cmd.Run(); err != nil {
+		return "", errors.Wrapf(err, "'git %s' failed: %s", args[0], strings.TrimSpace(output.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -18,6 +18,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+
+	"github.com/pulumi/pulumi/pkg/resource/config"
 )
 
 func TestGetValidDefaultProjectName(t *testing.T) {
@@ -76,3 +79,62 @@ func getValidProjectNamePrefixes() []string {
 	results = append(results, ".")
 	return results
 }
+
+func TestParseTemplateURL(t *testing.T) {
+	cases := []struct {
+		url, repo, subdir, ref string
+	}{
+		{"https://github.com/acme/templates.git", "https://github.com/acme/templates.git", "", ""},
+		{"https://github.com/acme/templates.git//aws/typescript", "https://github.com/acme/templates.git",
+			"aws/typescript", ""},
+		{"https://github.com/acme/templates.git//aws#v1.2.0", "https://github.com/acme/templates.git", "aws", "v1.2.0"},
+		{"git@github.com:acme/templates.git#main", "git@github.com:acme/templates.git", "", "main"},
+		{"git@github.com:acme/templates.git//gcp/", "git@github.com:acme/templates.git", "gcp", ""},
+	}
+	for _, c := range cases {
+		assert.True(t, IsTemplateURL(c.url), c.url)
+		repo, subdir, ref, err := ParseTemplateURL(c.url)
+		assert.NoError(t, err, c.url)
+		assert.Equal(t, c.repo, repo, c.url)
+		assert.Equal(t, c.subdir, subdir, c.url)
+		assert.Equal(t, c.ref, ref, c.url)
+	}
+
+	assert.False(t, IsTemplateURL("typescript"))
+	for _, url := range []string{
+		"https://github.com/acme/templates.git#",
+		"https://github.com/acme/templates.git//",
+		"https://github.com/acme/templates.git#--upload-pack=touch",
+	} {
+		_, _, _, err := ParseTemplateURL(url)
+		assert.Error(t, err, url)
+	}
+}
+
+func TestTemplateConfigValue(t *testing.T) {
+	var template Template
+	assert.NoError(t, yaml.Unmarshal([]byte(
+		"config:\n"+
+			"  aws:region: us-west-2\n"+
+			"  proj:instanceCount:\n"+
+			"    description: The number of instances\n"+
+			"    default: \"2\"\n"+
+			"    pattern: \"[0-9]+\"\n"+
+			"  proj:dbPassword:\n"+
+			"    secret: true\n"+
+			"    required: true\n"), &template))
+
+	region := template.Config[config.MustMakeKey("aws", "region")]
+	assert.Equal(t, TemplateConfigValue{Default: "us-west-2"}, region)
+	assert.NoError(t, region.Validate(""))
+
+	count := template.Config[config.MustMakeKey("proj", "instanceCount")]
+	assert.Equal(t, "The number of instances", count.Description)
+	assert.NoError(t, count.Validate("12"))
+	assert.Error(t, count.Validate("twelve"))
+
+	password := template.Config[config.MustMakeKey("proj", "dbPassword")]
+	assert.True(t, password.Secret)
+	assert.Error(t, password.Validate(""))
+	assert.NoError(t, password.Validate("hunter2"))
+}
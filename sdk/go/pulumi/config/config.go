@@ -81,6 +81,12 @@ func (c *Config) GetInt64(key string) int64 {
 	return GetInt64(c.ctx, c.fullKey(key))
 }
 
+// GetObject loads an optional object or array configuration value by its key into output, which is left as it is if
+// the value doesn't exist.
+func (c *Config) GetObject(key string, output interface{}) error {
+	return GetObject(c.ctx, c.fullKey(key), output)
+}
+
 // GetUint loads an optional uint configuration value by its key, or returns 0 if it doesn't exist.
 func (c *Config) GetUint(key string) uint {
 	return GetUint(c.ctx, c.fullKey(key))
@@ -151,6 +157,11 @@ func (c *Config) RequireInt64(key string) int64 {
 	return RequireInt64(c.ctx, c.fullKey(key))
 }
 
+// RequireObject loads an object or array configuration value by its key into output, or panics if it doesn't exist.
+func (c *Config) RequireObject(key string, output interface{}) {
+	RequireObject(c.ctx, c.fullKey(key), output)
+}
+
 // RequireUint loads a uint configuration value by its key, or panics if it doesn't exist.
 func (c *Config) RequireUint(key string) uint {
 	return RequireUint(c.ctx, c.fullKey(key))
@@ -221,6 +232,12 @@ func (c *Config) TryInt64(key string) (int64, error) {
 	return TryInt64(c.ctx, c.fullKey(key))
 }
 
+// TryObject loads an object or array configuration value by its key into output, or returns an error if it doesn't
+// exist.
+func (c *Config) TryObject(key string, output interface{}) error {
+	return TryObject(c.ctx, c.fullKey(key), output)
+}
+
 // TryUint loads an optional uint configuration value by its key, or returns an error if it doesn't exist.
 func (c *Config) TryUint(key string) (uint, error) {
 	return TryUint(c.ctx, c.fullKey(key))
@@ -31,6 +31,7 @@ func TestConfig(t *testing.T) {
 			"testpkg:bbb":    "true",
 			"testpkg:intint": "42",
 			"testpkg:fpfpfp": "99.963",
+			"testpkg:obj":    `{"hosts":["a","b"],"port":80}`,
 		},
 	})
 	assert.Nil(t, err)
@@ -76,4 +77,16 @@ func TestConfig(t *testing.T) {
 	assert.Equal(t, 99.963, k4)
 	_, err = cfg.Try("missing")
 	assert.NotNil(t, err)
+
+	// Test objects, which are decoded from JSON.
+	var obj struct {
+		Hosts []string `json:"hosts"`
+		Port  int      `json:"port"`
+	}
+	assert.Nil(t, cfg.GetObject("obj", &obj))
+	assert.Equal(t, []string{"a", "b"}, obj.Hosts)
+	assert.Equal(t, 80, obj.Port)
+	assert.Nil(t, cfg.GetObject("missing", &obj))
+	assert.NotNil(t, cfg.TryObject("missing", &obj))
+	assert.NotNil(t, cfg.TryObject("sss", &obj))
 }
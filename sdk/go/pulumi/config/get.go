@@ -15,6 +15,9 @@
 package config
 
 import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
 	"github.com/spf13/cast"
 
 	"github.com/pulumi/pulumi/sdk/go/pulumi"
@@ -90,6 +93,15 @@ func GetInt64(ctx *pulumi.Context, key string) int64 {
 	return 0
 }
 
+// GetObject loads an optional configuration value by its key, which is an object or array, into output, as by
+// json.Unmarshal.  If the value doesn't exist, output is left as it is.
+func GetObject(ctx *pulumi.Context, key string, output interface{}) error {
+	if v, ok := ctx.GetConfig(key); ok {
+		return unmarshalObject(key, v, output)
+	}
+	return nil
+}
+
 // GetUint loads an optional configuration value by its key, as a uint, or returns 0 if it doesn't exist.
 func GetUint(ctx *pulumi.Context, key string) uint {
 	if v, ok := ctx.GetConfig(key); ok {
@@ -129,3 +141,11 @@ func GetUint64(ctx *pulumi.Context, key string) uint64 {
 	}
 	return 0
 }
+
+// unmarshalObject decodes the JSON of a configuration value that is an object or array.
+func unmarshalObject(key string, v string, output interface{}) error {
+	if err := json.Unmarshal([]byte(v), output); err != nil {
+		return errors.Wrapf(err, "configuration variable '%s' is not a valid object", key)
+	}
+	return nil
+}
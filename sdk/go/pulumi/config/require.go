@@ -78,6 +78,15 @@ func RequireInt64(ctx *pulumi.Context, key string) int64 {
 	return cast.ToInt64(v)
 }
 
+// RequireObject loads a configuration value by its key, which is an object or array, into output, as by
+// json.Unmarshal, or panics if it doesn't exist or isn't valid.
+func RequireObject(ctx *pulumi.Context, key string, output interface{}) {
+	v := Require(ctx, key)
+	if err := unmarshalObject(key, v, output); err != nil {
+		contract.Failf("%v", err)
+	}
+}
+
 // RequireUint loads an optional configuration value by its key, as a uint, or panics if it doesn't exist.
 func RequireUint(ctx *pulumi.Context, key string) uint {
 	v := Require(ctx, key)
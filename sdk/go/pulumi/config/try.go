@@ -103,6 +103,16 @@ func TryInt64(ctx *pulumi.Context, key string) (int64, error) {
 	return cast.ToInt64(v), nil
 }
 
+// TryObject loads a configuration value by its key, which is an object or array, into output, as by json.Unmarshal,
+// or returns an error if it doesn't exist or isn't valid.
+func TryObject(ctx *pulumi.Context, key string, output interface{}) error {
+	v, err := Try(ctx, key)
+	if err != nil {
+		return err
+	}
+	return unmarshalObject(key, v, output)
+}
+
 // TryUint loads an optional configuration value by its key, as a uint, or returns an error if it doesn't exist.
 func TryUint(ctx *pulumi.Context, key string) (uint, error) {
 	v, err := Try(ctx, key)
@@ -150,8 +150,8 @@ export class Config {
     }
 
     /**
-     * requireObject loads a configuration value, as a number, by its given key.  If it doesn't exist, or the
-     * configuration value is not a legal number, an error is thrown.
+     * requireObject loads a configuration value, as an object, by its given key.  If it doesn't exist, or the
+     * configuration value is not legal JSON, an error is thrown.
      *
      * @param key The key to lookup.
      */
@@ -20,6 +20,7 @@ export * from "./config";
 export * from "./errors";
 export * from "./metadata";
 export * from "./resource";
+export * from "./stackReference";
 
 // Export submodules individually.
 import * as asset from "./asset";
@@ -111,6 +111,47 @@ export interface ResourceOptions {
      * When set to true, protect ensures this resource cannot be deleted.
      */
     protect?: boolean;
+    /**
+     * Ignore changes to any of the specified properties.  Each entry is a property path, such as "tags" or
+     * "tags.owner", whose value will be left as-is when computing whether the resource needs to be updated.
+     */
+    ignoreChanges?: string[];
+    /**
+     * When set to true, this resource is deleted before its replacement is created whenever it must be replaced,
+     * rather than afterwards.  Any resources that depend on it are replaced along with it.
+     */
+    deleteBeforeReplace?: boolean;
+    /**
+     * An optional limit on how long each of this resource's create, update, and delete operations may take.  An
+     * operation that takes longer is abandoned, and fails the deployment, whose earlier changes are rolled back if the
+     * update was run with `--rollback-on-failure`.
+     */
+    customTimeouts?: CustomTimeouts;
+    /**
+     * Replace this resource, rather than updating it in place, whenever any of the specified properties change.  Each
+     * entry is a property path, such as "image" or "spec.template"; this applies even when the resource's provider
+     * could have updated the property in place.
+     */
+    replaceOnChanges?: string[];
+}
+
+/**
+ * CustomTimeouts limits how long each operation on a resource may take.  Each limit is a duration such as "30s",
+ * "5m", or "1h30m"; an operation without a limit may take as long as it needs.
+ */
+export interface CustomTimeouts {
+    /**
+     * The maximum time to wait for the resource to be created.
+     */
+    create?: string;
+    /**
+     * The maximum time to wait for the resource to be updated.
+     */
+    update?: string;
+    /**
+     * The maximum time to wait for the resource to be deleted.
+     */
+    delete?: string;
 }
 
 /**
@@ -44,11 +44,14 @@ interface ResourceResolverOperation {
     serializedProps: Record<string, any>;
     // A set of dependency URNs that this resource is dependent upon (both implicitly and explicitly).
     dependencies: Set<URN>;
+    // The URNs of the resources that each property's value was computed from, by property key.
+    propertyDependencies: Map<string, Set<URN>>;
 }
 
 /**
- * Reads an existing custom resource's state from the resource monitor.  Note that resources read in this way
- * will not be part of the resulting stack's state, as they are presumed to belong to another.
+ * Reads an existing custom resource's state from the resource monitor.  Resources read in this way are recorded in the
+ * resulting stack's state as external resources: the engine reads them during each deployment, but as they are presumed
+ * to belong to another, it never creates, updates, or deletes them.
  */
 export function readResource(res: Resource, t: string, name: string, props: Inputs, opts: ResourceOptions): void {
     const id: Input<ID> | undefined = opts.id;
@@ -73,6 +76,7 @@ export function readResource(res: Resource, t: string, name: string, props: Inpu
         req.setId(resolvedID);
         req.setParent(resop.parentURN);
         req.setProperties(gstruct.Struct.fromJavaScript(resop.serializedProps));
+        req.setDependenciesList(Array.from(resop.dependencies));
 
         // Now run the operation, serializing the invocation if necessary.
         const opLabel = `monitor.readResource(${label})`;
@@ -121,6 +125,21 @@ export function registerResource(res: Resource, t: string, name: string, custom:
         req.setObject(gstruct.Struct.fromJavaScript(resop.serializedProps));
         req.setProtect(opts.protect);
         req.setDependenciesList(Array.from(resop.dependencies));
+        req.setIgnorechangesList(opts.ignoreChanges || []);
+        req.setDeletebeforereplace(opts.deleteBeforeReplace || false);
+        if (opts.customTimeouts) {
+            req.setCustomtimeoutcreate(opts.customTimeouts.create || "");
+            req.setCustomtimeoutupdate(opts.customTimeouts.update || "");
+            req.setCustomtimeoutdelete(opts.customTimeouts.delete || "");
+        }
+        req.setReplaceonchangesList(opts.replaceOnChanges || []);
+
+        const propertyDependencies = req.getPropertydependenciesMap();
+        for (const [key, urns] of resop.propertyDependencies) {
+            const deps = new resproto.RegisterResourceRequest.PropertyDependencies();
+            deps.setUrnsList(Array.from(urns));
+            propertyDependencies.set(key, deps);
+        }
 
         // Now run the operation, serializing the invocation if necessary.
         const opLabel = `monitor.registerResource(${label})`;
@@ -208,7 +227,9 @@ async function prepareResource(label: string, res: Resource, custom: boolean,
     // Serialize out all our props to their final values.  In doing so, we'll also collect all
     // the Resources pointed to by any Dependency objects we encounter, adding them to 'propertyDependencies'.
     const implicitDependencies: Resource[] = [];
-    const serializedProps = await serializeResourceProperties(label, props, implicitDependencies);
+    const propertyToDependencies: Record<string, Resource[]> = {};
+    const serializedProps = await serializeResourceProperties(
+        label, props, implicitDependencies, propertyToDependencies);
 
     let parentURN: URN | undefined;
     if (opts.parent) {
@@ -220,6 +241,15 @@ async function prepareResource(label: string, res: Resource, custom: boolean,
         dependencies.add(await implicitDep.urn.promise());
     }
 
+    const propertyDependencies = new Map<string, Set<URN>>();
+    for (const key of Object.keys(propertyToDependencies)) {
+        const urns = new Set<URN>();
+        for (const dep of propertyToDependencies[key]) {
+            urns.add(await dep.urn.promise());
+        }
+        propertyDependencies.set(key, urns);
+    }
+
     return {
         resolveURN: resolveURN!,
         resolveID: resolveID,
@@ -227,6 +257,7 @@ async function prepareResource(label: string, res: Resource, custom: boolean,
         serializedProps: serializedProps,
         parentURN: parentURN,
         dependencies: dependencies,
+        propertyDependencies: propertyDependencies,
     };
 }
 
@@ -72,18 +72,25 @@ export function transferProperties(onto: Resource, label: string, props: Inputs)
 /**
  * serializeFilteredProperties walks the props object passed in, awaiting all interior promises for propertoes with
  * keys that match the provided filter, creating a reasonable POJO object that can be remoted over to
- * registerResource.
+ * registerResource.  If propertyDependencies is supplied, the Resources that each property depends on are recorded in
+ * it, by property key.
  */
 async function serializeFilteredProperties(
         label: string, props: Inputs, acceptKey: (k: string) => boolean,
-        dependentResources: Resource[] = []): Promise<Record<string, any>> {
+        dependentResources: Resource[] = [],
+        propertyDependencies?: Record<string, Resource[]>): Promise<Record<string, any>> {
     const result: Record<string, any> = {};
     for (const k of Object.keys(props)) {
         if (acceptKey(k)) {
             // We treat properties with undefined values as if they do not exist.
-            const v = await serializeProperty(`${label}.${k}`, props[k], dependentResources);
+            const propertyDeps: Resource[] = [];
+            const v = await serializeProperty(`${label}.${k}`, props[k], propertyDeps);
+            dependentResources.push(...propertyDeps);
             if (v !== undefined) {
                 result[k] = v;
+                if (propertyDependencies) {
+                    propertyDependencies[k] = propertyDeps;
+                }
             }
         }
     }
@@ -96,8 +103,10 @@ async function serializeFilteredProperties(
  * and `urn`, creating a reasonable POJO object that can be remoted over to registerResource.
  */
 export async function serializeResourceProperties(
-        label: string, props: Inputs, dependentResources: Resource[] = []): Promise<Record<string, any>> {
-    return serializeFilteredProperties(label, props, key => key !== "id" && key !== "urn", dependentResources);
+        label: string, props: Inputs, dependentResources: Resource[] = [],
+        propertyDependencies?: Record<string, Resource[]>): Promise<Record<string, any>> {
+    return serializeFilteredProperties(
+        label, props, key => key !== "id" && key !== "urn", dependentResources, propertyDependencies);
 }
 
 /**
@@ -0,0 +1,59 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import { all, Input, Output, output } from "./resource";
+import * as runtime from "./runtime";
+
+/**
+ * StackReferenceArgs are the arguments used to construct a StackReference.
+ */
+export interface StackReferenceArgs {
+    /**
+     * The URL of the backend that holds the stack, as given to `pulumi login`, such as `s3://bucket/prefix` or
+     * `https://api.pulumi.com`.  Defaults to the backend of the stack being updated.  The backend is opened with the
+     * credentials that `pulumi login` stored for it.
+     */
+    readonly backend?: Input<string>;
+}
+
+/**
+ * StackReference refers to another stack, whose outputs the program may read.  The stack may be kept in another
+ * backend than the stack being updated.
+ */
+export class StackReference {
+    /**
+     * The name of the referenced stack.
+     */
+    public readonly name: Output<string>;
+
+    /**
+     * The outputs of the referenced stack.
+     */
+    public readonly outputs: Output<{[name: string]: any}>;
+
+    constructor(name: Input<string>, args?: StackReferenceArgs) {
+        this.name = output(name);
+        this.outputs = all([name, args && args.backend]).apply(async ([n, backend]) => {
+            const result = await runtime.invoke("pulumi:pulumi:readStackOutputs", { name: n, backend: backend });
+            return result.outputs || {};
+        });
+    }
+
+    /**
+     * getOutput fetches the value of the named output of the referenced stack, which is undefined if it has none.
+     */
+    public getOutput(name: Input<string>): Output<any> {
+        return all([this.outputs, name]).apply(([outputs, n]) => outputs[n]);
+    }
+}
@@ -44,6 +44,39 @@ func (x DiffResponse_DiffChanges) String() string {
 }
 func (DiffResponse_DiffChanges) EnumDescriptor() ([]byte, []int) { return fileDescriptor5, []int{8, 0} }
 
+type PropertyDiff_Kind int32
+
+const (
+	PropertyDiff_ADD            PropertyDiff_Kind = 0
+	PropertyDiff_ADD_REPLACE    PropertyDiff_Kind = 1
+	PropertyDiff_DELETE         PropertyDiff_Kind = 2
+	PropertyDiff_DELETE_REPLACE PropertyDiff_Kind = 3
+	PropertyDiff_UPDATE         PropertyDiff_Kind = 4
+	PropertyDiff_UPDATE_REPLACE PropertyDiff_Kind = 5
+)
+
+var PropertyDiff_Kind_name = map[int32]string{
+	0: "ADD",
+	1: "ADD_REPLACE",
+	2: "DELETE",
+	3: "DELETE_REPLACE",
+	4: "UPDATE",
+	5: "UPDATE_REPLACE",
+}
+var PropertyDiff_Kind_value = map[string]int32{
+	"ADD":            0,
+	"ADD_REPLACE":    1,
+	"DELETE":         2,
+	"DELETE_REPLACE": 3,
+	"UPDATE":         4,
+	"UPDATE_REPLACE": 5,
+}
+
+func (x PropertyDiff_Kind) String() string {
+	return proto.EnumName(PropertyDiff_Kind_name, int32(x))
+}
+func (PropertyDiff_Kind) EnumDescriptor() ([]byte, []int) { return fileDescriptor5, []int{9, 0} }
+
 type ConfigureRequest struct {
 	Variables map[string]string `protobuf:"bytes,1,rep,name=variables" json:"variables,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 }
@@ -276,6 +309,8 @@ type DiffResponse struct {
 	Stables             []string                 `protobuf:"bytes,2,rep,name=stables" json:"stables,omitempty"`
 	DeleteBeforeReplace bool                     `protobuf:"varint,3,opt,name=deleteBeforeReplace" json:"deleteBeforeReplace,omitempty"`
 	Changes             DiffResponse_DiffChanges `protobuf:"varint,4,opt,name=changes,enum=pulumirpc.DiffResponse_DiffChanges" json:"changes,omitempty"`
+	DetailedDiff        map[string]*PropertyDiff `protobuf:"bytes,5,rep,name=detailedDiff" json:"detailedDiff,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	HasDetailedDiff     bool                     `protobuf:"varint,6,opt,name=hasDetailedDiff" json:"hasDetailedDiff,omitempty"`
 }
 
 func (m *DiffResponse) Reset()                    { *m = DiffResponse{} }
@@ -311,6 +346,45 @@ func (m *DiffResponse) GetChanges() DiffResponse_DiffChanges {
 	return DiffResponse_DIFF_UNKNOWN
 }
 
+func (m *DiffResponse) GetDetailedDiff() map[string]*PropertyDiff {
+	if m != nil {
+		return m.DetailedDiff
+	}
+	return nil
+}
+
+func (m *DiffResponse) GetHasDetailedDiff() bool {
+	if m != nil {
+		return m.HasDetailedDiff
+	}
+	return false
+}
+
+// PropertyDiff describes the change to a single property reported by a provider's detailed diff.
+type PropertyDiff struct {
+	Kind      PropertyDiff_Kind `protobuf:"varint,1,opt,name=kind,enum=pulumirpc.PropertyDiff_Kind" json:"kind,omitempty"`
+	InputDiff bool              `protobuf:"varint,2,opt,name=inputDiff" json:"inputDiff,omitempty"`
+}
+
+func (m *PropertyDiff) Reset()                    { *m = PropertyDiff{} }
+func (m *PropertyDiff) String() string            { return proto.CompactTextString(m) }
+func (*PropertyDiff) ProtoMessage()               {}
+func (*PropertyDiff) Descriptor() ([]byte, []int) { return fileDescriptor5, []int{9} }
+
+func (m *PropertyDiff) GetKind() PropertyDiff_Kind {
+	if m != nil {
+		return m.Kind
+	}
+	return PropertyDiff_ADD
+}
+
+func (m *PropertyDiff) GetInputDiff() bool {
+	if m != nil {
+		return m.InputDiff
+	}
+	return false
+}
+
 type CreateRequest struct {
 	Urn        string                   `protobuf:"bytes,1,opt,name=urn" json:"urn,omitempty"`
 	Properties *google_protobuf1.Struct `protobuf:"bytes,2,opt,name=properties" json:"properties,omitempty"`
@@ -319,7 +393,7 @@ type CreateRequest struct {
 func (m *CreateRequest) Reset()                    { *m = CreateRequest{} }
 func (m *CreateRequest) String() string            { return proto.CompactTextString(m) }
 func (*CreateRequest) ProtoMessage()               {}
-func (*CreateRequest) Descriptor() ([]byte, []int) { return fileDescriptor5, []int{9} }
+func (*CreateRequest) Descriptor() ([]byte, []int) { return fileDescriptor5, []int{10} }
 
 func (m *CreateRequest) GetUrn() string {
 	if m != nil {
@@ -343,7 +417,7 @@ type CreateResponse struct {
 func (m *CreateResponse) Reset()                    { *m = CreateResponse{} }
 func (m *CreateResponse) String() string            { return proto.CompactTextString(m) }
 func (*CreateResponse) ProtoMessage()               {}
-func (*CreateResponse) Descriptor() ([]byte, []int) { return fileDescriptor5, []int{10} }
+func (*CreateResponse) Descriptor() ([]byte, []int) { return fileDescriptor5, []int{11} }
 
 func (m *CreateResponse) GetId() string {
 	if m != nil {
@@ -368,7 +442,7 @@ type ReadRequest struct {
 func (m *ReadRequest) Reset()                    { *m = ReadRequest{} }
 func (m *ReadRequest) String() string            { return proto.CompactTextString(m) }
 func (*ReadRequest) ProtoMessage()               {}
-func (*ReadRequest) Descriptor() ([]byte, []int) { return fileDescriptor5, []int{11} }
+func (*ReadRequest) Descriptor() ([]byte, []int) { return fileDescriptor5, []int{12} }
 
 func (m *ReadRequest) GetId() string {
 	if m != nil {
@@ -399,7 +473,7 @@ type ReadResponse struct {
 func (m *ReadResponse) Reset()                    { *m = ReadResponse{} }
 func (m *ReadResponse) String() string            { return proto.CompactTextString(m) }
 func (*ReadResponse) ProtoMessage()               {}
-func (*ReadResponse) Descriptor() ([]byte, []int) { return fileDescriptor5, []int{12} }
+func (*ReadResponse) Descriptor() ([]byte, []int) { return fileDescriptor5, []int{13} }
 
 func (m *ReadResponse) GetId() string {
 	if m != nil {
@@ -425,7 +499,7 @@ type UpdateRequest struct {
 func (m *UpdateRequest) Reset()                    { *m = UpdateRequest{} }
 func (m *UpdateRequest) String() string            { return proto.CompactTextString(m) }
 func (*UpdateRequest) ProtoMessage()               {}
-func (*UpdateRequest) Descriptor() ([]byte, []int) { return fileDescriptor5, []int{13} }
+func (*UpdateRequest) Descriptor() ([]byte, []int) { return fileDescriptor5, []int{14} }
 
 func (m *UpdateRequest) GetId() string {
 	if m != nil {
@@ -462,7 +536,7 @@ type UpdateResponse struct {
 func (m *UpdateResponse) Reset()                    { *m = UpdateResponse{} }
 func (m *UpdateResponse) String() string            { return proto.CompactTextString(m) }
 func (*UpdateResponse) ProtoMessage()               {}
-func (*UpdateResponse) Descriptor() ([]byte, []int) { return fileDescriptor5, []int{14} }
+func (*UpdateResponse) Descriptor() ([]byte, []int) { return fileDescriptor5, []int{15} }
 
 func (m *UpdateResponse) GetProperties() *google_protobuf1.Struct {
 	if m != nil {
@@ -480,7 +554,7 @@ type DeleteRequest struct {
 func (m *DeleteRequest) Reset()                    { *m = DeleteRequest{} }
 func (m *DeleteRequest) String() string            { return proto.CompactTextString(m) }
 func (*DeleteRequest) ProtoMessage()               {}
-func (*DeleteRequest) Descriptor() ([]byte, []int) { return fileDescriptor5, []int{15} }
+func (*DeleteRequest) Descriptor() ([]byte, []int) { return fileDescriptor5, []int{16} }
 
 func (m *DeleteRequest) GetId() string {
 	if m != nil {
@@ -514,6 +588,7 @@ func init() {
 	proto.RegisterType((*CheckFailure)(nil), "pulumirpc.CheckFailure")
 	proto.RegisterType((*DiffRequest)(nil), "pulumirpc.DiffRequest")
 	proto.RegisterType((*DiffResponse)(nil), "pulumirpc.DiffResponse")
+	proto.RegisterType((*PropertyDiff)(nil), "pulumirpc.PropertyDiff")
 	proto.RegisterType((*CreateRequest)(nil), "pulumirpc.CreateRequest")
 	proto.RegisterType((*CreateResponse)(nil), "pulumirpc.CreateResponse")
 	proto.RegisterType((*ReadRequest)(nil), "pulumirpc.ReadRequest")
@@ -522,6 +597,7 @@ func init() {
 	proto.RegisterType((*UpdateResponse)(nil), "pulumirpc.UpdateResponse")
 	proto.RegisterType((*DeleteRequest)(nil), "pulumirpc.DeleteRequest")
 	proto.RegisterEnum("pulumirpc.DiffResponse_DiffChanges", DiffResponse_DiffChanges_name, DiffResponse_DiffChanges_value)
+	proto.RegisterEnum("pulumirpc.PropertyDiff_Kind", PropertyDiff_Kind_name, PropertyDiff_Kind_value)
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -893,58 +969,68 @@ var _ResourceProvider_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("provider.proto", fileDescriptor5) }
 
 var fileDescriptor5 = []byte{
-	// 840 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xc4, 0x56, 0xdd, 0x6e, 0xc3, 0x34,
-	0x14, 0x6e, 0xda, 0xae, 0x6b, 0x4f, 0x7f, 0x14, 0x19, 0xd8, 0xba, 0x8c, 0x8b, 0x29, 0xdc, 0x4c,
-	0x20, 0xa5, 0xa8, 0xbb, 0xe0, 0x47, 0x9b, 0x40, 0xdd, 0x3a, 0xa8, 0xa6, 0x75, 0x23, 0xd3, 0x98,
-	0xe0, 0x06, 0x65, 0x8d, 0xdb, 0x85, 0xa6, 0x71, 0x70, 0x9c, 0xa2, 0xf2, 0x06, 0x88, 0x37, 0xe0,
-	0x31, 0x78, 0x0f, 0xde, 0x86, 0x07, 0x40, 0xb1, 0x9d, 0x34, 0x6e, 0xb7, 0xb5, 0x4c, 0x20, 0xee,
-	0x72, 0x7c, 0xbe, 0x73, 0xbe, 0xf3, 0x6b, 0x07, 0x5a, 0x21, 0x25, 0x73, 0xcf, 0xc5, 0xd4, 0x0a,
-	0x29, 0x61, 0x04, 0xd5, 0xc2, 0xd8, 0x8f, 0x67, 0x1e, 0x0d, 0x47, 0x46, 0x23, 0xf4, 0xe3, 0x89,
-	0x17, 0x08, 0x85, 0x71, 0x38, 0x21, 0x64, 0xe2, 0xe3, 0x0e, 0x97, 0x1e, 0xe3, 0x71, 0x07, 0xcf,
-	0x42, 0xb6, 0x90, 0xca, 0xf7, 0x57, 0x95, 0x11, 0xa3, 0xf1, 0x88, 0x09, 0xad, 0xf9, 0xbb, 0x06,
-	0xfa, 0x39, 0x09, 0xc6, 0xde, 0x24, 0xa6, 0xd8, 0xc6, 0x3f, 0xc5, 0x38, 0x62, 0xe8, 0x6b, 0xa8,
-	0xcd, 0x1d, 0xea, 0x39, 0x8f, 0x3e, 0x8e, 0xda, 0xda, 0x51, 0xe9, 0xb8, 0xde, 0xfd, 0xd0, 0xca,
-	0xc8, 0xad, 0x55, 0xbc, 0xf5, 0x6d, 0x0a, 0xee, 0x07, 0x8c, 0x2e, 0xec, 0xa5, 0xb1, 0x71, 0x0a,
-	0x2d, 0x55, 0x89, 0x74, 0x28, 0x4d, 0xf1, 0xa2, 0xad, 0x1d, 0x69, 0xc7, 0x35, 0x3b, 0xf9, 0x44,
-	0xef, 0xc2, 0xce, 0xdc, 0xf1, 0x63, 0xdc, 0x2e, 0xf2, 0x33, 0x21, 0x7c, 0x5e, 0xfc, 0x54, 0x33,
-	0xff, 0xd0, 0xe0, 0x20, 0x23, 0xeb, 0x53, 0x4a, 0xe8, 0xb5, 0x17, 0x45, 0x5e, 0x30, 0xb9, 0xc2,
-	0x8b, 0x08, 0x7d, 0x03, 0xf5, 0xd9, 0x52, 0x94, 0x71, 0x76, 0x9e, 0x8b, 0x73, 0xd5, 0xd4, 0x5a,
-	0x7e, 0xdb, 0x79, 0x1f, 0x46, 0x0f, 0x60, 0xa9, 0x42, 0x08, 0xca, 0x81, 0x33, 0xc3, 0x32, 0x56,
-	0xfe, 0x8d, 0x8e, 0xa0, 0xee, 0xe2, 0x68, 0x44, 0xbd, 0x90, 0x79, 0x24, 0x90, 0x21, 0xe7, 0x8f,
-	0xcc, 0x21, 0x34, 0x07, 0xc1, 0x9c, 0x4c, 0xb3, 0x6a, 0xea, 0x50, 0x62, 0x64, 0x9a, 0x66, 0xcc,
-	0xc8, 0x14, 0x7d, 0x04, 0x65, 0x87, 0x4e, 0x22, 0x6e, 0x5d, 0xef, 0xee, 0x5b, 0xa2, 0x43, 0x56,
-	0xda, 0x21, 0xeb, 0x8e, 0x77, 0xc8, 0xe6, 0x20, 0x73, 0x0e, 0xad, 0xd4, 0x5f, 0x14, 0x92, 0x20,
-	0xc2, 0xa8, 0x03, 0x15, 0x8a, 0x59, 0x4c, 0x03, 0xee, 0xf3, 0x15, 0x07, 0x12, 0x86, 0x4e, 0xa0,
-	0x3a, 0x76, 0x3c, 0x3f, 0xa6, 0x38, 0xe1, 0x2c, 0x71, 0x93, 0x5c, 0x99, 0x9e, 0xf0, 0x68, 0x7a,
-	0x29, 0xf4, 0x76, 0x06, 0x34, 0x7f, 0x81, 0x06, 0xd7, 0xe4, 0xd2, 0x48, 0x29, 0x6b, 0x76, 0xf2,
-	0x99, 0xa4, 0x41, 0x7c, 0x77, 0x73, 0x1a, 0x09, 0x28, 0x01, 0x07, 0xf8, 0xe7, 0xa8, 0x5d, 0xda,
-	0x00, 0x4e, 0x40, 0x66, 0x0c, 0x4d, 0xc9, 0xbd, 0x4c, 0xd9, 0x0b, 0xc2, 0x98, 0x45, 0x1b, 0x53,
-	0x16, 0xb0, 0xb7, 0xa5, 0xdc, 0x93, 0x29, 0x4b, 0x0d, 0x32, 0xa0, 0x1a, 0x52, 0x12, 0x62, 0xca,
-	0xd2, 0x81, 0xcd, 0x64, 0xb4, 0x97, 0x34, 0xc1, 0x89, 0xb2, 0x19, 0x90, 0x92, 0xf9, 0xab, 0x06,
-	0xf5, 0x0b, 0x6f, 0x3c, 0x4e, 0xcb, 0xd6, 0x82, 0xa2, 0xe7, 0x4a, 0xeb, 0xa2, 0xe7, 0xa6, 0x65,
-	0x2c, 0xae, 0x97, 0xb1, 0xf4, 0x4f, 0xca, 0x58, 0xde, 0xa6, 0x8c, 0x7f, 0x69, 0xd0, 0x10, 0xb1,
-	0xc8, 0x32, 0x1a, 0x50, 0xa5, 0x38, 0xf4, 0x9d, 0x91, 0xdc, 0xeb, 0x9a, 0x9d, 0xc9, 0xa8, 0x0d,
-	0xbb, 0x11, 0x13, 0x2b, 0x5f, 0xe4, 0xaa, 0x54, 0x44, 0x1f, 0xc3, 0x3b, 0x2e, 0xf6, 0x31, 0xc3,
-	0x3d, 0x3c, 0x26, 0xc9, 0xd6, 0x73, 0x0b, 0x1e, 0x6f, 0xd5, 0x7e, 0x4e, 0x85, 0xce, 0x60, 0x77,
-	0xf4, 0xe4, 0x04, 0x13, 0x2c, 0x02, 0x6d, 0x75, 0x3f, 0xc8, 0x15, 0x3f, 0x1f, 0x11, 0x17, 0xce,
-	0x05, 0xd4, 0x4e, 0x6d, 0xcc, 0x33, 0x51, 0x42, 0x79, 0x8e, 0x74, 0x68, 0x5c, 0x0c, 0x2e, 0x2f,
-	0x7f, 0xb8, 0x1f, 0x5e, 0x0d, 0x6f, 0x1e, 0x86, 0x7a, 0x01, 0x35, 0xa1, 0xc6, 0x4f, 0x86, 0x37,
-	0xc3, 0xbe, 0xae, 0x65, 0xe2, 0xdd, 0xcd, 0x75, 0x5f, 0x2f, 0x9a, 0xdf, 0x43, 0xf3, 0x9c, 0x62,
-	0x87, 0xe1, 0x97, 0x47, 0xf7, 0x13, 0x00, 0xd9, 0x49, 0x0f, 0x6f, 0x1c, 0xe0, 0x1c, 0xd4, 0xfc,
-	0x0e, 0x5a, 0xa9, 0x6f, 0x59, 0xd3, 0xd5, 0x06, 0xbf, 0xd9, 0xf5, 0x13, 0xd4, 0x6d, 0xec, 0xb8,
-	0xdb, 0x0f, 0x8e, 0xca, 0x54, 0xda, 0x9e, 0xe9, 0x01, 0x1a, 0x82, 0xe9, 0xdf, 0x4e, 0xe1, 0x37,
-	0x0d, 0x9a, 0xf7, 0xa1, 0x9b, 0x2b, 0xfd, 0xff, 0x39, 0xfe, 0x03, 0x68, 0xa5, 0xc1, 0xc8, 0x44,
-	0xd5, 0xc4, 0xb4, 0xed, 0x13, 0xfb, 0x11, 0x9a, 0x17, 0x7c, 0xce, 0xff, 0xfb, 0xee, 0x74, 0xff,
-	0x2c, 0x83, 0x6e, 0xe3, 0x88, 0xc4, 0x74, 0x84, 0x6f, 0xe5, 0x1f, 0x00, 0xea, 0x41, 0x2d, 0x7b,
-	0xce, 0xd0, 0xe1, 0x2b, 0x8f, 0xb1, 0xb1, 0xb7, 0xc6, 0xd1, 0x4f, 0xfe, 0x06, 0xcc, 0x02, 0xfa,
-	0x02, 0x2a, 0xe2, 0x25, 0x41, 0xed, 0x9c, 0x03, 0xe5, 0xb1, 0x32, 0x0e, 0x9e, 0xd1, 0x88, 0xe2,
-	0x99, 0x05, 0x74, 0x0a, 0x3b, 0xfc, 0x7e, 0x44, 0x6b, 0x77, 0x69, 0x6a, 0xde, 0x5e, 0x57, 0x64,
-	0xd6, 0x9f, 0x41, 0x39, 0xd9, 0x6a, 0xb4, 0xb7, 0x76, 0x17, 0x08, 0xdb, 0xfd, 0x17, 0xee, 0x08,
-	0x11, 0xb9, 0xd8, 0x3a, 0x25, 0x72, 0x65, 0xc9, 0x95, 0xc8, 0xd5, 0x15, 0x15, 0xdc, 0xc9, 0xc4,
-	0x2b, 0xdc, 0xb9, 0x65, 0x53, 0xb8, 0xf3, 0xab, 0x21, 0xb8, 0xc5, 0x14, 0x29, 0xdc, 0xca, 0x94,
-	0x2b, 0xdc, 0xea, 0xc8, 0xf1, 0xaa, 0x55, 0xc4, 0xec, 0x28, 0x0e, 0x94, 0x71, 0x7a, 0xa5, 0x69,
-	0x5f, 0x42, 0xf3, 0x2b, 0xcc, 0x6e, 0xf9, 0xef, 0xde, 0x20, 0x18, 0x13, 0xf4, 0x02, 0xd4, 0x78,
-	0x2f, 0xe7, 0x7c, 0x09, 0x37, 0x0b, 0x8f, 0x15, 0x0e, 0x3c, 0xf9, 0x3b, 0x00, 0x00, 0xff, 0xff,
-	0x4a, 0x1b, 0x84, 0x5d, 0x4f, 0x0a, 0x00, 0x00,
+	// 994 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x56, 0xdd, 0x72, 0xdb, 0x44,
+	0x14, 0xb6, 0x2c, 0xc7, 0x89, 0x8f, 0x6d, 0x55, 0x2c, 0x90, 0x28, 0x6a, 0x2e, 0x32, 0xe2, 0x26,
+	0xc0, 0xa0, 0x74, 0xdc, 0x0b, 0x7e, 0xa6, 0x1d, 0x48, 0x22, 0x05, 0x32, 0x69, 0x1c, 0xa3, 0x36,
+	0x14, 0xb8, 0x29, 0x8a, 0xb5, 0x76, 0x16, 0x2b, 0x92, 0x58, 0x49, 0x66, 0xc2, 0x1b, 0x30, 0xbc,
+	0x01, 0x8f, 0xc1, 0x13, 0x70, 0xc7, 0x15, 0xef, 0xc4, 0x68, 0x57, 0x92, 0x57, 0xfe, 0x49, 0x42,
+	0x07, 0xa6, 0x77, 0x3a, 0xfb, 0x7d, 0xe7, 0x77, 0xcf, 0x39, 0x5a, 0x50, 0x22, 0x1a, 0x4e, 0x89,
+	0x87, 0xa9, 0x19, 0xd1, 0x30, 0x09, 0x51, 0x2b, 0x4a, 0xfd, 0xf4, 0x9a, 0xd0, 0x68, 0xa8, 0x77,
+	0x22, 0x3f, 0x1d, 0x93, 0x80, 0x03, 0xfa, 0xc3, 0x71, 0x18, 0x8e, 0x7d, 0xbc, 0xcf, 0xa4, 0xcb,
+	0x74, 0xb4, 0x8f, 0xaf, 0xa3, 0xe4, 0x26, 0x07, 0x77, 0xe6, 0xc1, 0x38, 0xa1, 0xe9, 0x30, 0xe1,
+	0xa8, 0xf1, 0xbb, 0x04, 0xea, 0x51, 0x18, 0x8c, 0xc8, 0x38, 0xa5, 0xd8, 0xc1, 0x3f, 0xa5, 0x38,
+	0x4e, 0xd0, 0x57, 0xd0, 0x9a, 0xba, 0x94, 0xb8, 0x97, 0x3e, 0x8e, 0x35, 0x69, 0x57, 0xde, 0x6b,
+	0xf7, 0x3e, 0x30, 0x4b, 0xe7, 0xe6, 0x3c, 0xdf, 0xfc, 0xa6, 0x20, 0xdb, 0x41, 0x42, 0x6f, 0x9c,
+	0x99, 0xb2, 0xfe, 0x04, 0x94, 0x2a, 0x88, 0x54, 0x90, 0x27, 0xf8, 0x46, 0x93, 0x76, 0xa5, 0xbd,
+	0x96, 0x93, 0x7d, 0xa2, 0x77, 0x60, 0x6d, 0xea, 0xfa, 0x29, 0xd6, 0xea, 0xec, 0x8c, 0x0b, 0x9f,
+	0xd5, 0x3f, 0x91, 0x8c, 0x3f, 0x24, 0xd8, 0x2e, 0x9d, 0xd9, 0x94, 0x86, 0xf4, 0x8c, 0xc4, 0x31,
+	0x09, 0xc6, 0xa7, 0xf8, 0x26, 0x46, 0x5f, 0x43, 0xfb, 0x7a, 0x26, 0xe6, 0x71, 0xee, 0x2f, 0x8b,
+	0x73, 0x5e, 0xd5, 0x9c, 0x7d, 0x3b, 0xa2, 0x0d, 0xfd, 0x10, 0x60, 0x06, 0x21, 0x04, 0x8d, 0xc0,
+	0xbd, 0xc6, 0x79, 0xac, 0xec, 0x1b, 0xed, 0x42, 0xdb, 0xc3, 0xf1, 0x90, 0x92, 0x28, 0x21, 0x61,
+	0x90, 0x87, 0x2c, 0x1e, 0x19, 0x7d, 0xe8, 0x9e, 0x04, 0xd3, 0x70, 0x52, 0x56, 0x53, 0x05, 0x39,
+	0x09, 0x27, 0x45, 0xc6, 0x49, 0x38, 0x41, 0x1f, 0x42, 0xc3, 0xa5, 0xe3, 0x98, 0x69, 0xb7, 0x7b,
+	0x5b, 0x26, 0xbf, 0x21, 0xb3, 0xb8, 0x21, 0xf3, 0x39, 0xbb, 0x21, 0x87, 0x91, 0x8c, 0x29, 0x28,
+	0x85, 0xbd, 0x38, 0x0a, 0x83, 0x18, 0xa3, 0x7d, 0x68, 0x52, 0x9c, 0xa4, 0x34, 0xd0, 0xa4, 0xdb,
+	0x0d, 0xe4, 0x34, 0xf4, 0x18, 0x36, 0x46, 0x2e, 0xf1, 0x53, 0x8a, 0x33, 0x9f, 0x32, 0x53, 0x11,
+	0xca, 0x74, 0x85, 0x87, 0x93, 0x63, 0x8e, 0x3b, 0x25, 0xd1, 0xf8, 0x05, 0x3a, 0x0c, 0x11, 0xd2,
+	0x28, 0x5c, 0xb6, 0x9c, 0xec, 0x33, 0x4b, 0x23, 0xf4, 0xbd, 0xbb, 0xd3, 0xc8, 0x48, 0x19, 0x39,
+	0xc0, 0x3f, 0xc7, 0x9a, 0x7c, 0x07, 0x39, 0x23, 0x19, 0x29, 0x74, 0x73, 0xdf, 0xb3, 0x94, 0x49,
+	0x10, 0xa5, 0x49, 0x7c, 0x67, 0xca, 0x9c, 0xf6, 0x7a, 0x29, 0x1f, 0x42, 0x47, 0x44, 0x90, 0x0e,
+	0x1b, 0x11, 0x0d, 0x23, 0x4c, 0x93, 0xa2, 0x61, 0x4b, 0x19, 0x6d, 0x66, 0x97, 0xe0, 0xc6, 0x65,
+	0x0f, 0xe4, 0x92, 0xf1, 0xab, 0x04, 0x6d, 0x8b, 0x8c, 0x46, 0x45, 0xd9, 0x14, 0xa8, 0x13, 0x2f,
+	0xd7, 0xae, 0x13, 0xaf, 0x28, 0x63, 0x7d, 0xb1, 0x8c, 0xf2, 0xbf, 0x29, 0x63, 0xe3, 0x3e, 0x65,
+	0xfc, 0x4b, 0x86, 0x0e, 0x8f, 0x25, 0x2f, 0xa3, 0x0e, 0x1b, 0x14, 0x47, 0xbe, 0x3b, 0xcc, 0xe7,
+	0xba, 0xe5, 0x94, 0x32, 0xd2, 0x60, 0x3d, 0x4e, 0xf8, 0xc8, 0xd7, 0x19, 0x54, 0x88, 0xe8, 0x11,
+	0xbc, 0xed, 0x61, 0x1f, 0x27, 0xf8, 0x10, 0x8f, 0xc2, 0x6c, 0xea, 0x99, 0x06, 0x8b, 0x77, 0xc3,
+	0x59, 0x06, 0xa1, 0xa7, 0xb0, 0x3e, 0xbc, 0x72, 0x83, 0x31, 0xe6, 0x81, 0x2a, 0xbd, 0xf7, 0x84,
+	0xe2, 0x8b, 0x11, 0x31, 0xe1, 0x88, 0x53, 0x9d, 0x42, 0x07, 0x9d, 0x41, 0xc7, 0xc3, 0x89, 0x4b,
+	0x7c, 0xec, 0x65, 0xb8, 0xb6, 0xc6, 0x2e, 0xf0, 0xfd, 0x95, 0x36, 0x04, 0x2e, 0xdf, 0x40, 0x15,
+	0x75, 0xb4, 0x07, 0x0f, 0xae, 0xdc, 0x58, 0x64, 0x69, 0x4d, 0x16, 0xfb, 0xfc, 0xb1, 0xfe, 0x2d,
+	0xbc, 0xb5, 0x60, 0x6c, 0xc9, 0xc6, 0xfa, 0x48, 0xdc, 0x58, 0xd5, 0xce, 0x1a, 0xe4, 0xfd, 0xc1,
+	0x02, 0x14, 0x56, 0xd9, 0x53, 0x68, 0x0b, 0xa9, 0x22, 0x15, 0x3a, 0xd6, 0xc9, 0xf1, 0xf1, 0xab,
+	0x8b, 0xfe, 0x69, 0xff, 0xfc, 0x65, 0x5f, 0xad, 0xa1, 0x2e, 0xb4, 0xd8, 0x49, 0xff, 0xbc, 0x6f,
+	0xab, 0x52, 0x29, 0x3e, 0x3f, 0x3f, 0xb3, 0xd5, 0xba, 0xf1, 0xa7, 0x04, 0x1d, 0xd1, 0x34, 0x7a,
+	0x04, 0x8d, 0x09, 0x09, 0x78, 0x63, 0x29, 0xbd, 0x9d, 0x15, 0x11, 0x98, 0xa7, 0x24, 0xf0, 0x1c,
+	0xc6, 0x44, 0x3b, 0xd0, 0x62, 0xb3, 0xc1, 0xf2, 0xaf, 0xb3, 0xfc, 0x67, 0x07, 0xc6, 0x0f, 0xd0,
+	0xc8, 0xb8, 0x68, 0x1d, 0xe4, 0x03, 0xcb, 0x52, 0x6b, 0xe8, 0x01, 0xb4, 0x0f, 0x2c, 0xeb, 0x95,
+	0x63, 0x0f, 0x9e, 0x1d, 0x1c, 0x65, 0x11, 0x01, 0x34, 0x2d, 0xfb, 0x99, 0xfd, 0xc2, 0x56, 0xeb,
+	0x08, 0x81, 0xc2, 0xbf, 0x4b, 0x5c, 0xce, 0xf0, 0x8b, 0x81, 0x75, 0xf0, 0xc2, 0x56, 0x1b, 0x19,
+	0xce, 0xbf, 0x4b, 0x7c, 0xcd, 0xf8, 0x1e, 0xba, 0x47, 0x14, 0xbb, 0x09, 0x5e, 0xbd, 0x50, 0x3e,
+	0x06, 0xc8, 0xe7, 0x8b, 0xe0, 0x3b, 0xd7, 0x8a, 0x40, 0x35, 0xbe, 0x03, 0xa5, 0xb0, 0x9d, 0x77,
+	0xfa, 0xfc, 0xd8, 0xbd, 0xb6, 0xe9, 0x2b, 0x68, 0x3b, 0xd8, 0xf5, 0xee, 0x3f, 0xce, 0x55, 0x4f,
+	0xf2, 0xfd, 0x3d, 0xbd, 0x84, 0x0e, 0xf7, 0xf4, 0x5f, 0xa7, 0xf0, 0x9b, 0x04, 0xdd, 0x8b, 0xc8,
+	0x13, 0x4a, 0xff, 0x26, 0x97, 0xd2, 0x09, 0x28, 0x45, 0x30, 0x79, 0xa2, 0xd5, 0xc4, 0xa4, 0xfb,
+	0x27, 0xf6, 0x23, 0x74, 0x2d, 0xb6, 0x7d, 0xfe, 0xff, 0xdb, 0xe9, 0xfd, 0xdd, 0x00, 0xd5, 0xc1,
+	0x71, 0x98, 0xd2, 0x21, 0x1e, 0xe4, 0xef, 0x32, 0x74, 0x08, 0xad, 0xf2, 0x91, 0x81, 0x1e, 0xde,
+	0xf2, 0x44, 0xd2, 0x37, 0x17, 0x7c, 0xd8, 0xd9, 0x1b, 0xcd, 0xa8, 0xa1, 0xcf, 0xa1, 0xc9, 0xff,
+	0xef, 0x48, 0x13, 0x0c, 0x54, 0x9e, 0x10, 0xfa, 0xf6, 0x12, 0x84, 0x17, 0xcf, 0xa8, 0xa1, 0x27,
+	0xb0, 0xc6, 0xfe, 0x5a, 0x68, 0xe1, 0x0f, 0x57, 0xa8, 0x6b, 0x8b, 0x40, 0xa9, 0xfd, 0x29, 0x34,
+	0xd8, 0x42, 0xd9, 0x5c, 0xd8, 0xae, 0x5c, 0x77, 0x6b, 0xc5, 0xd6, 0xe5, 0x91, 0xf3, 0xa9, 0xab,
+	0x44, 0x5e, 0x19, 0x72, 0x7d, 0x7b, 0x09, 0x22, 0xfa, 0xce, 0x3a, 0xbe, 0xe2, 0x5b, 0x18, 0x36,
+	0x7d, 0x6b, 0xe1, 0x5c, 0xf4, 0xcd, 0xbb, 0xa8, 0xe2, 0xbb, 0xd2, 0xe5, 0xfa, 0xf6, 0x12, 0x44,
+	0xa8, 0x5a, 0x93, 0xf7, 0x4e, 0xc5, 0x40, 0xa5, 0x9d, 0x6e, 0xb9, 0xb4, 0x2f, 0xa0, 0xfb, 0x25,
+	0x4e, 0x06, 0xec, 0x11, 0x7e, 0x12, 0x8c, 0x42, 0xb4, 0x82, 0xaa, 0xbf, 0x2b, 0x6e, 0xe6, 0x92,
+	0x6e, 0xd4, 0x2e, 0x9b, 0x8c, 0xf8, 0xf8, 0x9f, 0x01, 0x00, 0x30, 0xec, 0xb0, 0xa0, 0xe5, 0x0b,
+	0x00, 0x00,
 }
@@ -22,11 +22,12 @@ var _ = math.Inf
 
 // ReadResourceRequest contains enough information to uniquely qualify and read a resource's state.
 type ReadResourceRequest struct {
-	Id         string                   `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
-	Type       string                   `protobuf:"bytes,2,opt,name=type" json:"type,omitempty"`
-	Name       string                   `protobuf:"bytes,3,opt,name=name" json:"name,omitempty"`
-	Parent     string                   `protobuf:"bytes,4,opt,name=parent" json:"parent,omitempty"`
-	Properties *google_protobuf1.Struct `protobuf:"bytes,5,opt,name=properties" json:"properties,omitempty"`
+	Id           string                   `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	Type         string                   `protobuf:"bytes,2,opt,name=type" json:"type,omitempty"`
+	Name         string                   `protobuf:"bytes,3,opt,name=name" json:"name,omitempty"`
+	Parent       string                   `protobuf:"bytes,4,opt,name=parent" json:"parent,omitempty"`
+	Properties   *google_protobuf1.Struct `protobuf:"bytes,5,opt,name=properties" json:"properties,omitempty"`
+	Dependencies []string                 `protobuf:"bytes,6,rep,name=dependencies" json:"dependencies,omitempty"`
 }
 
 func (m *ReadResourceRequest) Reset()                    { *m = ReadResourceRequest{} }
@@ -69,6 +70,13 @@ func (m *ReadResourceRequest) GetProperties() *google_protobuf1.Struct {
 	return nil
 }
 
+func (m *ReadResourceRequest) GetDependencies() []string {
+	if m != nil {
+		return m.Dependencies
+	}
+	return nil
+}
+
 // ReadResourceResponse contains the result of reading a resource's state.
 type ReadResourceResponse struct {
 	Urn        string                   `protobuf:"bytes,1,opt,name=urn" json:"urn,omitempty"`
@@ -96,13 +104,20 @@ func (m *ReadResourceResponse) GetProperties() *google_protobuf1.Struct {
 
 // RegisterResourceRequest contains information about a resource object that was newly allocated.
 type RegisterResourceRequest struct {
-	Type         string                   `protobuf:"bytes,1,opt,name=type" json:"type,omitempty"`
-	Name         string                   `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
-	Parent       string                   `protobuf:"bytes,3,opt,name=parent" json:"parent,omitempty"`
-	Custom       bool                     `protobuf:"varint,4,opt,name=custom" json:"custom,omitempty"`
-	Object       *google_protobuf1.Struct `protobuf:"bytes,5,opt,name=object" json:"object,omitempty"`
-	Protect      bool                     `protobuf:"varint,6,opt,name=protect" json:"protect,omitempty"`
-	Dependencies []string                 `protobuf:"bytes,7,rep,name=dependencies" json:"dependencies,omitempty"`
+	Type                 string                                                   `protobuf:"bytes,1,opt,name=type" json:"type,omitempty"`
+	Name                 string                                                   `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+	Parent               string                                                   `protobuf:"bytes,3,opt,name=parent" json:"parent,omitempty"`
+	Custom               bool                                                     `protobuf:"varint,4,opt,name=custom" json:"custom,omitempty"`
+	Object               *google_protobuf1.Struct                                 `protobuf:"bytes,5,opt,name=object" json:"object,omitempty"`
+	Protect              bool                                                     `protobuf:"varint,6,opt,name=protect" json:"protect,omitempty"`
+	Dependencies         []string                                                 `protobuf:"bytes,7,rep,name=dependencies" json:"dependencies,omitempty"`
+	IgnoreChanges        []string                                                 `protobuf:"bytes,8,rep,name=ignoreChanges" json:"ignoreChanges,omitempty"`
+	DeleteBeforeReplace  bool                                                     `protobuf:"varint,9,opt,name=deleteBeforeReplace" json:"deleteBeforeReplace,omitempty"`
+	CustomTimeoutCreate  string                                                   `protobuf:"bytes,10,opt,name=customTimeoutCreate" json:"customTimeoutCreate,omitempty"`
+	CustomTimeoutUpdate  string                                                   `protobuf:"bytes,11,opt,name=customTimeoutUpdate" json:"customTimeoutUpdate,omitempty"`
+	CustomTimeoutDelete  string                                                   `protobuf:"bytes,12,opt,name=customTimeoutDelete" json:"customTimeoutDelete,omitempty"`
+	ReplaceOnChanges     []string                                                 `protobuf:"bytes,13,rep,name=replaceOnChanges" json:"replaceOnChanges,omitempty"`
+	PropertyDependencies map[string]*RegisterResourceRequest_PropertyDependencies `protobuf:"bytes,14,rep,name=propertyDependencies" json:"propertyDependencies,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 }
 
 func (m *RegisterResourceRequest) Reset()                    { *m = RegisterResourceRequest{} }
@@ -159,6 +174,78 @@ func (m *RegisterResourceRequest) GetDependencies() []string {
 	return nil
 }
 
+func (m *RegisterResourceRequest) GetIgnoreChanges() []string {
+	if m != nil {
+		return m.IgnoreChanges
+	}
+	return nil
+}
+
+func (m *RegisterResourceRequest) GetDeleteBeforeReplace() bool {
+	if m != nil {
+		return m.DeleteBeforeReplace
+	}
+	return false
+}
+
+func (m *RegisterResourceRequest) GetCustomTimeoutCreate() string {
+	if m != nil {
+		return m.CustomTimeoutCreate
+	}
+	return ""
+}
+
+func (m *RegisterResourceRequest) GetCustomTimeoutUpdate() string {
+	if m != nil {
+		return m.CustomTimeoutUpdate
+	}
+	return ""
+}
+
+func (m *RegisterResourceRequest) GetCustomTimeoutDelete() string {
+	if m != nil {
+		return m.CustomTimeoutDelete
+	}
+	return ""
+}
+
+func (m *RegisterResourceRequest) GetReplaceOnChanges() []string {
+	if m != nil {
+		return m.ReplaceOnChanges
+	}
+	return nil
+}
+
+func (m *RegisterResourceRequest) GetPropertyDependencies() map[string]*RegisterResourceRequest_PropertyDependencies {
+	if m != nil {
+		return m.PropertyDependencies
+	}
+	return nil
+}
+
+// PropertyDependencies lists the resources whose outputs a single property's value was computed from.
+type RegisterResourceRequest_PropertyDependencies struct {
+	Urns []string `protobuf:"bytes,1,rep,name=urns" json:"urns,omitempty"`
+}
+
+func (m *RegisterResourceRequest_PropertyDependencies) Reset() {
+	*m = RegisterResourceRequest_PropertyDependencies{}
+}
+func (m *RegisterResourceRequest_PropertyDependencies) String() string {
+	return proto.CompactTextString(m)
+}
+func (*RegisterResourceRequest_PropertyDependencies) ProtoMessage() {}
+func (*RegisterResourceRequest_PropertyDependencies) Descriptor() ([]byte, []int) {
+	return fileDescriptor6, []int{2, 1}
+}
+
+func (m *RegisterResourceRequest_PropertyDependencies) GetUrns() []string {
+	if m != nil {
+		return m.Urns
+	}
+	return nil
+}
+
 // RegisterResourceResponse is returned by the engine after a resource has finished being initialized.  It includes the
 // auto-assigned URN, the provider-assigned ID, and any other properties initialized by the engine.
 type RegisterResourceResponse struct {
@@ -238,6 +325,7 @@ func init() {
 	proto.RegisterType((*ReadResourceRequest)(nil), "pulumirpc.ReadResourceRequest")
 	proto.RegisterType((*ReadResourceResponse)(nil), "pulumirpc.ReadResourceResponse")
 	proto.RegisterType((*RegisterResourceRequest)(nil), "pulumirpc.RegisterResourceRequest")
+	proto.RegisterType((*RegisterResourceRequest_PropertyDependencies)(nil), "pulumirpc.RegisterResourceRequest.PropertyDependencies")
 	proto.RegisterType((*RegisterResourceResponse)(nil), "pulumirpc.RegisterResourceResponse")
 	proto.RegisterType((*RegisterResourceOutputsRequest)(nil), "pulumirpc.RegisterResourceOutputsRequest")
 }
@@ -416,35 +504,47 @@ var _ResourceMonitor_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("resource.proto", fileDescriptor6) }
 
 var fileDescriptor6 = []byte{
-	// 472 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x8c, 0x54, 0x3b, 0x8f, 0xd3, 0x40,
-	0x10, 0x3e, 0xdb, 0x87, 0x43, 0x86, 0x53, 0x38, 0x0d, 0x28, 0x67, 0x0c, 0x3a, 0x22, 0xd3, 0x84,
-	0xc6, 0x11, 0x47, 0x41, 0x49, 0x45, 0x41, 0x81, 0x10, 0xa6, 0x06, 0xc9, 0xb1, 0x87, 0xc8, 0x90,
-	0x78, 0x97, 0x7d, 0x9c, 0x74, 0x7f, 0x06, 0xfe, 0x1a, 0x05, 0x3f, 0x04, 0xad, 0xd7, 0x1b, 0xe2,
-	0xc7, 0x3d, 0xba, 0x79, 0x79, 0xe6, 0xfb, 0xbe, 0x99, 0x35, 0xcc, 0x04, 0x49, 0xa6, 0x45, 0x41,
-	0x29, 0x17, 0x4c, 0x31, 0x9c, 0x72, 0xbd, 0xd5, 0xbb, 0x4a, 0xf0, 0x22, 0x7e, 0xba, 0x61, 0x6c,
-	0xb3, 0xa5, 0x55, 0x93, 0x58, 0xeb, 0x6f, 0x2b, 0xda, 0x71, 0x75, 0x65, 0xeb, 0xe2, 0x67, 0xfd,
-	0xa4, 0x54, 0x42, 0x17, 0xaa, 0xcd, 0xce, 0xb8, 0x60, 0x97, 0x55, 0x49, 0xc2, 0xfa, 0xc9, 0x2f,
-	0x0f, 0x1e, 0x65, 0x94, 0x97, 0x59, 0x3b, 0x2c, 0xa3, 0x9f, 0x9a, 0xa4, 0xc2, 0x19, 0xf8, 0x55,
-	0x19, 0x79, 0x0b, 0x6f, 0x39, 0xcd, 0xfc, 0xaa, 0x44, 0x84, 0x63, 0x75, 0xc5, 0x29, 0xf2, 0x9b,
-	0x48, 0x63, 0x9b, 0x58, 0x9d, 0xef, 0x28, 0x0a, 0x6c, 0xcc, 0xd8, 0x38, 0x87, 0x90, 0xe7, 0x82,
-	0x6a, 0x15, 0x1d, 0x37, 0xd1, 0xd6, 0xc3, 0x37, 0x00, 0x5c, 0x30, 0x4e, 0x42, 0x55, 0x24, 0xa3,
-	0x7b, 0x0b, 0x6f, 0xf9, 0xe0, 0xe2, 0x2c, 0xb5, 0x50, 0x53, 0x07, 0x35, 0xfd, 0xdc, 0x40, 0xcd,
-	0x0e, 0x4a, 0x93, 0x1c, 0x1e, 0x77, 0xf1, 0x49, 0xce, 0x6a, 0x49, 0x78, 0x0a, 0x81, 0x16, 0x75,
-	0x8b, 0xd0, 0x98, 0xbd, 0x11, 0xfe, 0xdd, 0x47, 0xfc, 0xf1, 0xe0, 0x2c, 0xa3, 0x4d, 0x25, 0x15,
-	0x89, 0xbe, 0x0e, 0x8e, 0xb7, 0x37, 0xc2, 0xdb, 0x1f, 0xe5, 0x1d, 0x74, 0x78, 0xcf, 0x21, 0x2c,
-	0xb4, 0x54, 0x6c, 0xd7, 0xe8, 0x71, 0x3f, 0x6b, 0x3d, 0x5c, 0x41, 0xc8, 0xd6, 0xdf, 0xa9, 0x50,
-	0xb7, 0x69, 0xd1, 0x96, 0x61, 0x04, 0x13, 0x93, 0x32, 0x5f, 0x84, 0x4d, 0x27, 0xe7, 0x62, 0x02,
-	0x27, 0x25, 0x71, 0xaa, 0x4b, 0xaa, 0x0b, 0xc3, 0x7c, 0xb2, 0x08, 0x96, 0xd3, 0xac, 0x13, 0x4b,
-	0x7e, 0x7b, 0x10, 0x0d, 0x29, 0x5e, 0x2b, 0xa5, 0xdd, 0xbe, 0xbf, 0xdf, 0xfe, 0x7f, 0xb4, 0xc1,
-	0xdd, 0xd0, 0xce, 0x21, 0x94, 0x2a, 0x5f, 0x6f, 0xc9, 0xd1, 0xb6, 0x9e, 0x61, 0x61, 0x2d, 0x73,
-	0x03, 0x06, 0xa6, 0x73, 0x13, 0x82, 0xf3, 0x3e, 0xc0, 0x8f, 0x5a, 0x71, 0xad, 0xa4, 0x5b, 0xc5,
-	0x10, 0xe6, 0x2b, 0x98, 0x30, 0x5b, 0x73, 0xdb, 0xba, 0x5d, 0xdd, 0xc5, 0x5f, 0x1f, 0x1e, 0xba,
-	0xfe, 0x1f, 0x58, 0x5d, 0x29, 0x26, 0xf0, 0x2d, 0x84, 0xef, 0xeb, 0x4b, 0xf6, 0x83, 0x30, 0x4a,
-	0xf7, 0x8f, 0x2c, 0xb5, 0xa1, 0x76, 0x78, 0xfc, 0x64, 0x24, 0x63, 0xe5, 0x4b, 0x8e, 0xf0, 0x13,
-	0x9c, 0x1c, 0xde, 0x28, 0x9e, 0x1f, 0x14, 0x8f, 0x3c, 0xae, 0xf8, 0xf9, 0xb5, 0xf9, 0x7d, 0xcb,
-	0x2f, 0x70, 0xda, 0x97, 0x03, 0x93, 0xce, 0x67, 0xa3, 0xf7, 0x1a, 0xbf, 0xb8, 0xb1, 0x66, 0xdf,
-	0xfe, 0xeb, 0xf0, 0xe2, 0x5b, 0xb5, 0xf1, 0xe5, 0x0d, 0x1d, 0xba, 0x1b, 0x89, 0xe7, 0x03, 0xb9,
-	0xdf, 0x99, 0x1f, 0x51, 0x72, 0xb4, 0x0e, 0x9b, 0xc8, 0xeb, 0x7f, 0x01, 0x00, 0x00, 0xff, 0xff,
-	0x20, 0x92, 0x11, 0xa7, 0xc5, 0x04, 0x00, 0x00,
+	// 662 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x55, 0xcd, 0x6e, 0xd3, 0x40,
+	0x10, 0xae, 0x9d, 0xd6, 0x6d, 0xa6, 0x6d, 0xa8, 0xb6, 0x55, 0xea, 0x1a, 0x54, 0x22, 0xc3, 0x21,
+	0xf4, 0x90, 0x42, 0x39, 0x14, 0x21, 0x24, 0x24, 0xda, 0x1e, 0x38, 0x54, 0x05, 0x03, 0x47, 0x90,
+	0x1c, 0x7b, 0x1a, 0x4c, 0x93, 0xdd, 0x65, 0xbd, 0x5b, 0x29, 0x37, 0xde, 0x83, 0x03, 0xef, 0xc2,
+	0xb3, 0xf0, 0x20, 0x68, 0x77, 0xed, 0x90, 0xc4, 0x4e, 0x5b, 0x71, 0xdb, 0xf9, 0xf9, 0x66, 0xbf,
+	0xfd, 0x3c, 0x33, 0x86, 0x96, 0xc0, 0x9c, 0x29, 0x91, 0x60, 0x8f, 0x0b, 0x26, 0x19, 0x69, 0x72,
+	0x35, 0x54, 0xa3, 0x4c, 0xf0, 0x24, 0xb8, 0x3f, 0x60, 0x6c, 0x30, 0xc4, 0x43, 0x13, 0xe8, 0xab,
+	0xcb, 0x43, 0x1c, 0x71, 0x39, 0xb6, 0x79, 0xc1, 0x83, 0xf9, 0x60, 0x2e, 0x85, 0x4a, 0x64, 0x11,
+	0x6d, 0x71, 0xc1, 0xae, 0xb3, 0x14, 0x85, 0xb5, 0xc3, 0xdf, 0x0e, 0x6c, 0x47, 0x18, 0xa7, 0x51,
+	0x71, 0x59, 0x84, 0xdf, 0x15, 0xe6, 0x92, 0xb4, 0xc0, 0xcd, 0x52, 0xdf, 0xe9, 0x38, 0xdd, 0x66,
+	0xe4, 0x66, 0x29, 0x21, 0xb0, 0x2c, 0xc7, 0x1c, 0x7d, 0xd7, 0x78, 0xcc, 0x59, 0xfb, 0x68, 0x3c,
+	0x42, 0xbf, 0x61, 0x7d, 0xfa, 0x4c, 0xda, 0xe0, 0xf1, 0x58, 0x20, 0x95, 0xfe, 0xb2, 0xf1, 0x16,
+	0x16, 0x39, 0x06, 0xe0, 0x82, 0x71, 0x14, 0x32, 0xc3, 0xdc, 0x5f, 0xe9, 0x38, 0xdd, 0xf5, 0xa3,
+	0xdd, 0x9e, 0xa5, 0xda, 0x2b, 0xa9, 0xf6, 0x3e, 0x18, 0xaa, 0xd1, 0x54, 0x2a, 0x09, 0x61, 0x23,
+	0x45, 0x8e, 0x34, 0x45, 0x9a, 0x68, 0xa8, 0xd7, 0x69, 0x74, 0x9b, 0xd1, 0x8c, 0x2f, 0x8c, 0x61,
+	0x67, 0xf6, 0x0d, 0x39, 0x67, 0x34, 0x47, 0xb2, 0x05, 0x0d, 0x25, 0x68, 0xf1, 0x0a, 0x7d, 0x9c,
+	0xa3, 0xe1, 0xde, 0x99, 0x46, 0xf8, 0xd3, 0x83, 0xdd, 0x08, 0x07, 0x59, 0x2e, 0x51, 0xcc, 0x6b,
+	0x55, 0x6a, 0xe3, 0xd4, 0x68, 0xe3, 0xd6, 0x6a, 0xd3, 0x98, 0xd1, 0xa6, 0x0d, 0x5e, 0xa2, 0x72,
+	0xc9, 0x46, 0x46, 0xb3, 0xb5, 0xa8, 0xb0, 0xc8, 0x21, 0x78, 0xac, 0xff, 0x0d, 0x13, 0x79, 0x9b,
+	0x5e, 0x45, 0x1a, 0xf1, 0x61, 0x55, 0x87, 0x34, 0xc2, 0x33, 0x95, 0x4a, 0xb3, 0xa2, 0xe2, 0x6a,
+	0x55, 0x45, 0xf2, 0x18, 0x36, 0xb3, 0x01, 0x65, 0x02, 0x4f, 0xbe, 0xc6, 0x74, 0x80, 0xb9, 0xbf,
+	0x66, 0x92, 0x66, 0x9d, 0xe4, 0x29, 0x6c, 0xa7, 0x38, 0x44, 0x89, 0x6f, 0xf0, 0x92, 0x09, 0x8c,
+	0x90, 0x0f, 0xe3, 0x04, 0xfd, 0xa6, 0xb9, 0xaf, 0x2e, 0xa4, 0x11, 0xf6, 0x41, 0x1f, 0xb3, 0x11,
+	0x32, 0x25, 0x4f, 0x04, 0xc6, 0x12, 0x7d, 0x30, 0x1a, 0xd4, 0x85, 0x2a, 0x88, 0x4f, 0x3c, 0xd5,
+	0x88, 0xf5, 0x1a, 0x84, 0x0d, 0x55, 0x10, 0xa7, 0x86, 0x87, 0xbf, 0x51, 0x83, 0xb0, 0x21, 0x72,
+	0x00, 0x5b, 0xc2, 0x12, 0xbc, 0xa0, 0xe5, 0x83, 0x37, 0xcd, 0x83, 0x2b, 0x7e, 0xc2, 0x61, 0xa7,
+	0x68, 0x85, 0xf1, 0xe9, 0xb4, 0x8a, 0xad, 0x4e, 0xa3, 0xbb, 0x7e, 0xf4, 0xaa, 0x37, 0x99, 0xcc,
+	0xde, 0x82, 0x16, 0xe9, 0xbd, 0xab, 0x81, 0x9f, 0x51, 0x29, 0xc6, 0x51, 0x6d, 0xe5, 0xe0, 0x87,
+	0x03, 0x7b, 0x0b, 0x31, 0xba, 0xaf, 0xaf, 0x70, 0x5c, 0xf6, 0xf5, 0x15, 0x8e, 0xc9, 0x39, 0xac,
+	0x5c, 0xc7, 0x43, 0x85, 0x45, 0x4b, 0x1f, 0xff, 0x27, 0xa5, 0xc8, 0x56, 0x79, 0xe9, 0xbe, 0x70,
+	0x82, 0x03, 0xd8, 0xa9, 0x4b, 0xd1, 0x9d, 0xad, 0x04, 0xcd, 0x7d, 0xc7, 0x88, 0x65, 0xce, 0xe1,
+	0x2f, 0x07, 0xfc, 0xea, 0x3d, 0x0b, 0xa7, 0xd0, 0x2e, 0x17, 0x77, 0xb2, 0x5c, 0xfe, 0x35, 0x7a,
+	0xe3, 0x6e, 0x8d, 0xde, 0x06, 0x2f, 0x97, 0x71, 0x7f, 0x88, 0xe5, 0xc4, 0x58, 0x4b, 0x0f, 0x80,
+	0x3d, 0xe9, 0x15, 0xa3, 0xe9, 0x95, 0x66, 0x88, 0xb0, 0x3f, 0x4f, 0xf0, 0x42, 0x49, 0xae, 0x64,
+	0x5e, 0x4e, 0x71, 0x95, 0xe6, 0x33, 0x58, 0x65, 0x36, 0xe7, 0xb6, 0x4d, 0x51, 0xe6, 0x1d, 0xfd,
+	0x71, 0xe1, 0x5e, 0x59, 0xff, 0x9c, 0xd1, 0x4c, 0x32, 0x41, 0x5e, 0x83, 0xf7, 0x96, 0x5e, 0xb3,
+	0x2b, 0x24, 0xfe, 0xd4, 0x67, 0xb1, 0xae, 0xe2, 0xf2, 0x60, 0xaf, 0x26, 0x62, 0xe5, 0x0b, 0x97,
+	0xc8, 0x7b, 0xd8, 0x98, 0x5e, 0x6f, 0x64, 0x7f, 0xe6, 0xeb, 0x56, 0x76, 0x77, 0xf0, 0x70, 0x61,
+	0x7c, 0x52, 0xf2, 0x33, 0x6c, 0xcd, 0xcb, 0x41, 0xc2, 0xdb, 0x9b, 0x26, 0x78, 0x74, 0x63, 0xce,
+	0xa4, 0xfc, 0x17, 0xd8, 0x5d, 0xa0, 0x36, 0x79, 0x72, 0x43, 0x85, 0xd9, 0x2f, 0x12, 0xb4, 0x2b,
+	0x72, 0x9f, 0xe9, 0xff, 0x5c, 0xb8, 0xd4, 0xf7, 0x8c, 0xe7, 0xf9, 0xdf, 0x01, 0x00, 0xe0, 0x00,
+	0x24, 0x6a, 0x24, 0x07, 0x00, 0x00,
 }
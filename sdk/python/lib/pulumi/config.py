@@ -16,6 +16,8 @@
 The config module contains all configuration management functionality.
 """
 
+import json
+
 import errors
 from runtime.config import get_config
 
@@ -82,6 +84,19 @@ class Config(object):
         except:
             raise ConfigTypeError(self.full_key(key), v, 'float')
 
+    def get_object(self, key):
+        """
+        Returns an optional configuration value, as an object (a dict or list), by its key, or None if it doesn't
+        exist.  If the configuration value isn't legal JSON, this function will throw an error.
+        """
+        v = self.get(key)
+        if v is None:
+            return None
+        try:
+            return json.loads(v)
+        except:
+            raise ConfigTypeError(self.full_key(key), v, 'JSON object')
+
     def require(self, key):
         """
         Returns a configuration value by its given key.  If it doesn't exist, an error is thrown.
@@ -121,6 +136,16 @@ class Config(object):
             raise ConfigMissingError(self.full_key(key))
         return v
 
+    def require_object(self, key):
+        """
+        Returns a configuration value, as an object (a dict or list), by its given key.  If it doesn't exist, or the
+        configuration value is not legal JSON, an error is thrown.
+        """
+        v = self.get_object(key)
+        if v is None:
+            raise ConfigMissingError(self.full_key(key))
+        return v
+
     def full_key(self, key):
         """
         Turns a simple configuration key into a fully resolved one, by prepending the bag's name.